@@ -4,26 +4,70 @@ import (
 	"api/internal/httputils"
 	"api/internal/middleware"
 	"api/internal/profile"
+	"bufio"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/fasthttp/router"
 	"github.com/go-playground/validator/v10"
+	"github.com/skip2/go-qrcode"
 	"github.com/valyala/fasthttp"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func ProfileRoutes(routes *router.Router, handler ProfileHandler, middleware middleware.Middleware) *router.Router {
+func ProfileRoutes(routes *router.Router, handler ProfileHandler, middleware, signature middleware.Middleware) *router.Router {
 	group := routes.Group("/profile/v1")
 
-	group.Handle(http.MethodPost, "/webhook", middleware.WrapHandler(handler.Webhook))
+	group.Handle(http.MethodPost, "/webhook", middleware.WrapHandler(signature.WrapHandler(handler.Webhook)))
 	group.Handle(http.MethodPost, "/user", middleware.WrapHandler(handler.CreateUser))
 	group.Handle(http.MethodPost, "/account", middleware.WrapHandler(handler.CreateAccount))
 	group.Handle(http.MethodGet, "/users", middleware.WrapHandler(handler.ListUsers))
 	group.Handle(http.MethodGet, "/account", middleware.WrapHandler(handler.FindAccount))
 	group.Handle(http.MethodPost, "/pix", middleware.WrapHandler(handler.SendPix))
-	group.Handle(http.MethodPost, "/pixWebhook", middleware.WrapHandler(handler.PixWebhook))
+	group.Handle(http.MethodPost, "/pix/{transactionId}/refund", middleware.WrapHandler(handler.RefundPix))
+	group.Handle(http.MethodGet, "/pix/scheduled", middleware.WrapHandler(handler.ListScheduledPix))
+	group.Handle(http.MethodGet, "/accounts/{id}/transactions", middleware.WrapHandler(handler.ListAccountTransactions))
+	group.Handle(http.MethodGet, "/accounts/{id}/statement", middleware.WrapHandler(handler.ExportStatement))
+	group.Handle(http.MethodPost, "/pix/qrcode", middleware.WrapHandler(handler.CreateQRCode))
+	group.Handle(http.MethodPost, "/pix/qrcode/parse", middleware.WrapHandler(handler.ParseQRCode))
+	group.Handle(http.MethodGet, "/pix/qrcode/png", middleware.WrapHandler(handler.QRCodePNG))
+	group.Handle(http.MethodDelete, "/pix/scheduled/{scheduleId}", middleware.WrapHandler(handler.CancelScheduledPix))
+	group.Handle(http.MethodPost, "/cob", middleware.WrapHandler(handler.CreateCharge))
+	group.Handle(http.MethodPut, "/cob/{txid}", middleware.WrapHandler(handler.CreateCharge))
+	group.Handle(http.MethodGet, "/cob/{txid}", middleware.WrapHandler(handler.FindCharge))
+	group.Handle(http.MethodPatch, "/cob/{txid}", middleware.WrapHandler(handler.CancelCharge))
+	group.Handle(http.MethodGet, "/cob", middleware.WrapHandler(handler.ListCharges))
+	group.Handle(http.MethodPut, "/cobv/{txid}", middleware.WrapHandler(handler.CreateDueCharge))
+	group.Handle(http.MethodGet, "/cobv/{txid}", middleware.WrapHandler(handler.FindCharge))
+	group.Handle(http.MethodPatch, "/cobv/{txid}", middleware.WrapHandler(handler.CancelCharge))
+	group.Handle(http.MethodGet, "/cobv", middleware.WrapHandler(handler.ListDueCharges))
+	group.Handle(http.MethodPost, "/pixWebhook", middleware.WrapHandler(signature.WrapHandler(handler.PixWebhook)))
 	group.Handle(http.MethodPost, "/key", middleware.WrapHandler(handler.CreateKey))
+	group.Handle(http.MethodGet, "/key/{key}/lookup", middleware.WrapHandler(handler.LookupKey))
+	group.Handle(http.MethodGet, "/support/lookup-limits", middleware.WrapHandler(handler.LookupLimits))
+	group.Handle(http.MethodDelete, "/support/lookup-limits", middleware.WrapHandler(handler.UnlockLookups))
+	group.Handle(http.MethodGet, "/support/reviews", middleware.WrapHandler(handler.ListReviews))
+	group.Handle(http.MethodPost, "/support/reviews/{reviewId}/approve", middleware.WrapHandler(handler.ApproveReview))
+	group.Handle(http.MethodPost, "/support/reviews/{reviewId}/reject", middleware.WrapHandler(handler.RejectReview))
+	group.Handle(http.MethodGet, "/support/infractions", middleware.WrapHandler(handler.ListInfractionReports))
+	group.Handle(http.MethodPost, "/support/infractions/{infractionId}/resolve", middleware.WrapHandler(handler.ResolveInfraction))
+	group.Handle(http.MethodPost, "/accounts/{id}/infractions", middleware.WrapHandler(handler.ReportInfraction))
+	group.Handle(http.MethodGet, "/accounts/{id}/infractions", middleware.WrapHandler(handler.ListInfractionReports))
+	group.Handle(http.MethodGet, "/accounts/{id}/limits", middleware.WrapHandler(handler.ListPixLimits))
+	group.Handle(http.MethodPut, "/accounts/{id}/limits/{type}", middleware.WrapHandler(handler.RequestPixLimit))
+	group.Handle(http.MethodPost, "/accounts/{id}/webhooks", middleware.WrapHandler(handler.CreateWebhookSubscription))
+	group.Handle(http.MethodGet, "/accounts/{id}/webhooks", middleware.WrapHandler(handler.ListWebhookSubscriptions))
+	group.Handle(http.MethodPatch, "/accounts/{id}/webhooks/{webhookId}", middleware.WrapHandler(handler.SetWebhookSubscriptionEnabled))
+	group.Handle(http.MethodDelete, "/accounts/{id}/webhooks/{webhookId}", middleware.WrapHandler(handler.DeleteWebhookSubscription))
+	group.Handle(http.MethodPost, "/accounts/{id}/webhooks/{webhookId}/secret", middleware.WrapHandler(handler.RotateWebhookSecret))
+	group.Handle(http.MethodPost, "/accounts/{id}/webhooks/{webhookId}/ping", middleware.WrapHandler(handler.PingWebhookSubscription))
 
 	return routes
 }
@@ -35,8 +79,39 @@ type ProfileHandler interface {
 	ListUsers(ctx *fasthttp.RequestCtx)
 	FindAccount(ctx *fasthttp.RequestCtx)
 	SendPix(ctx *fasthttp.RequestCtx)
+	RefundPix(ctx *fasthttp.RequestCtx)
+	ListScheduledPix(ctx *fasthttp.RequestCtx)
+	ListAccountTransactions(ctx *fasthttp.RequestCtx)
+	ExportStatement(ctx *fasthttp.RequestCtx)
+	CancelScheduledPix(ctx *fasthttp.RequestCtx)
+	CreateQRCode(ctx *fasthttp.RequestCtx)
+	ParseQRCode(ctx *fasthttp.RequestCtx)
+	QRCodePNG(ctx *fasthttp.RequestCtx)
+	CreateCharge(ctx *fasthttp.RequestCtx)
+	FindCharge(ctx *fasthttp.RequestCtx)
+	ListCharges(ctx *fasthttp.RequestCtx)
+	CreateDueCharge(ctx *fasthttp.RequestCtx)
+	ListDueCharges(ctx *fasthttp.RequestCtx)
+	CancelCharge(ctx *fasthttp.RequestCtx)
 	PixWebhook(ctx *fasthttp.RequestCtx)
 	CreateKey(ctx *fasthttp.RequestCtx)
+	LookupKey(ctx *fasthttp.RequestCtx)
+	LookupLimits(ctx *fasthttp.RequestCtx)
+	UnlockLookups(ctx *fasthttp.RequestCtx)
+	ListReviews(ctx *fasthttp.RequestCtx)
+	ApproveReview(ctx *fasthttp.RequestCtx)
+	RejectReview(ctx *fasthttp.RequestCtx)
+	ReportInfraction(ctx *fasthttp.RequestCtx)
+	ListInfractionReports(ctx *fasthttp.RequestCtx)
+	ResolveInfraction(ctx *fasthttp.RequestCtx)
+	ListPixLimits(ctx *fasthttp.RequestCtx)
+	RequestPixLimit(ctx *fasthttp.RequestCtx)
+	CreateWebhookSubscription(ctx *fasthttp.RequestCtx)
+	ListWebhookSubscriptions(ctx *fasthttp.RequestCtx)
+	SetWebhookSubscriptionEnabled(ctx *fasthttp.RequestCtx)
+	DeleteWebhookSubscription(ctx *fasthttp.RequestCtx)
+	RotateWebhookSecret(ctx *fasthttp.RequestCtx)
+	PingWebhookSubscription(ctx *fasthttp.RequestCtx)
 }
 
 type profileHandler struct {
@@ -174,12 +249,462 @@ func (r *profileHandler) SendPix(ctx *fasthttp.RequestCtx) {
 		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
 		return
 	}
-	err := r.backend.SendPix(ctx, body)
+	body.IdempotencyKey = string(ctx.Request.Header.Peek("Idempotency-Key"))
+
+	sent, err := r.backend.SendPix(ctx, body)
 	if err != nil {
 		httputils.BackendErrorFactory(&ctx.Response, err)
 		return
 	}
-	httputils.JSON(&ctx.Response, &httputils.Response{Status: http.StatusOK, Msg: "success"}, http.StatusOK)
+	// a pix parked by the risk analysis is only sent once an analyst approves it
+	if sent.Status == "IN_REVIEW" {
+		httputils.JSON(&ctx.Response, sent, http.StatusAccepted)
+		return
+	}
+	httputils.JSON(&ctx.Response, sent, http.StatusOK)
+}
+
+func (r *profileHandler) RefundPix(ctx *fasthttp.RequestCtx) {
+	var body profile.PixRefund
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	body.TransactionId, _ = ctx.UserValue("transactionId").(string)
+
+	if body.Amount < 0 {
+		httputils.JSONError(&ctx.Response, errors.New("amount cant be negative"), http.StatusBadRequest)
+		return
+	}
+
+	refund, err := r.backend.RefundPix(ctx, body)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, refund, http.StatusOK)
+}
+
+func (r *profileHandler) ListScheduledPix(ctx *fasthttp.RequestCtx) {
+	accountId, err := ctx.QueryArgs().GetUint("account_id")
+	if err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	list, err := r.backend.ListScheduledPix(ctx, int64(accountId))
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, list, http.StatusOK)
+}
+
+// ListAccountTransactions pages through the pix history of the account. The
+// filters are direction (SENT or RECEIVED), status, from and to (RFC 3339),
+// limit and the cursor returned by the previous page.
+func (r *profileHandler) ListAccountTransactions(ctx *fasthttp.RequestCtx) {
+	accountId, err := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+	if err != nil || accountId <= 0 {
+		httputils.JSONError(&ctx.Response, errors.New("account id must be a positive number"), http.StatusBadRequest)
+		return
+	}
+
+	args := ctx.QueryArgs()
+	filter := profile.HistoryFilter{
+		Direction: strings.ToUpper(string(args.Peek("direction"))),
+		Status:    strings.ToUpper(string(args.Peek("status"))),
+		Cursor:    string(args.Peek("cursor")),
+	}
+	if args.Has("limit") {
+		if filter.Limit, err = args.GetUint("limit"); err != nil {
+			httputils.JSONError(&ctx.Response, errors.New("limit must be a number"), http.StatusBadRequest)
+			return
+		}
+	}
+	for name, date := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if !args.Has(name) {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, string(args.Peek(name)))
+		if err != nil {
+			httputils.JSONError(&ctx.Response, errors.New(name+" must be a RFC 3339 date"), http.StatusBadRequest)
+			return
+		}
+		*date = &parsed
+	}
+
+	history, err := r.backend.ListAccountTransactions(ctx, accountId, filter)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, history, http.StatusOK)
+}
+
+// ExportStatement streams the statement of the account for the days from and
+// to (YYYY-MM-DD, both inclusive) as csv, ofx or pdf.
+func (r *profileHandler) ExportStatement(ctx *fasthttp.RequestCtx) {
+	accountId, err := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+	if err != nil || accountId <= 0 {
+		httputils.JSONError(&ctx.Response, errors.New("account id must be a positive number"), http.StatusBadRequest)
+		return
+	}
+
+	args := ctx.QueryArgs()
+	req := profile.StatementRequest{Format: strings.ToUpper(string(args.Peek("format")))}
+	if req.Format == "" {
+		req.Format = "CSV"
+	}
+	format, ok := profile.StatementFormats[req.Format]
+	if !ok {
+		httputils.JSONError(&ctx.Response, errors.New("format must be csv, ofx or pdf"), http.StatusBadRequest)
+		return
+	}
+	if req.From, err = time.Parse(time.DateOnly, string(args.Peek("from"))); err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("from must be a YYYY-MM-DD date"), http.StatusBadRequest)
+		return
+	}
+	if req.To, err = time.Parse(time.DateOnly, string(args.Peek("to"))); err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("to must be a YYYY-MM-DD date"), http.StatusBadRequest)
+		return
+	}
+
+	stream, err := r.backend.ExportStatement(ctx, accountId, req)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.%s", accountId, req.From.Format(time.DateOnly), req.To.Format(time.DateOnly), format.Extension)
+	ctx.Response.Header.SetContentType(format.ContentType)
+	ctx.Response.Header.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Response.SetStatusCode(http.StatusOK)
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		for {
+			data, err := stream.Next()
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("failed to stream statement of account %d: %v", accountId, err)
+				}
+				return
+			}
+			if _, err = w.Write(data); err != nil {
+				return
+			}
+			if err = w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func (r *profileHandler) CancelScheduledPix(ctx *fasthttp.RequestCtx) {
+	accountId, err := ctx.QueryArgs().GetUint("account_id")
+	if err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	var scheduled profile.ScheduledPix
+	scheduled.Id, _ = ctx.UserValue("scheduleId").(string)
+	scheduled.AccountId = int64(accountId)
+
+	canceled, err := r.backend.CancelScheduledPix(ctx, scheduled)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, canceled, http.StatusOK)
+}
+
+func (r *profileHandler) CreateQRCode(ctx *fasthttp.RequestCtx) {
+	var body profile.QRCode
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	if body.Amount < 0 {
+		httputils.JSONError(&ctx.Response, errors.New("amount cant be negative"), http.StatusBadRequest)
+		return
+	}
+
+	code, err := r.backend.CreateQRCode(ctx, body)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, code, http.StatusOK)
+}
+
+func (r *profileHandler) ParseQRCode(ctx *fasthttp.RequestCtx) {
+	var body profile.QRCode
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	code, err := r.backend.ParseQRCode(ctx, body.Payload)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, code, http.StatusOK)
+}
+
+// QRCodePNG renders a "copia e cola" payload as a QR image. The payload is
+// parsed first, so only valid codes are drawn.
+func (r *profileHandler) QRCodePNG(ctx *fasthttp.RequestCtx) {
+	payload := string(ctx.QueryArgs().Peek("payload"))
+	if payload == "" {
+		httputils.JSONError(&ctx.Response, errors.New("payload cant be empty"), http.StatusBadRequest)
+		return
+	}
+
+	size := ctx.QueryArgs().GetUintOrZero("size")
+	if size == 0 {
+		size = 256
+	}
+	if size < 64 || size > 1024 {
+		httputils.JSONError(&ctx.Response, errors.New("size must be between 64 and 1024"), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := r.backend.ParseQRCode(ctx, payload); err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+
+	png, err := qrcode.Encode(payload, qrcode.Medium, size)
+	if err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusInternalServerError)
+		return
+	}
+	ctx.Response.Header.SetContentType("image/png")
+	ctx.Response.SetStatusCode(http.StatusOK)
+	ctx.Response.SetBody(png)
+}
+
+// chargeRemovedByReceiver is the only status a charge can be patched to.
+const chargeRemovedByReceiver = "REMOVIDA_PELO_USUARIO_RECEBEDOR"
+
+// CreateCharge serves both POST /cob, where the txid is generated, and
+// PUT /cob/{txid}, where the caller chooses it.
+func (r *profileHandler) CreateCharge(ctx *fasthttp.RequestCtx) {
+	r.createCharge(ctx, false)
+}
+
+// CreateDueCharge serves PUT /cobv/{txid}, a charge with a due date.
+func (r *profileHandler) CreateDueCharge(ctx *fasthttp.RequestCtx) {
+	r.createCharge(ctx, true)
+}
+
+func (r *profileHandler) createCharge(ctx *fasthttp.RequestCtx, due bool) {
+	accountId, err := ctx.QueryArgs().GetUint("account_id")
+	if err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	var body profile.Charge
+	if err = json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	body.AccountId = int64(accountId)
+	body.TxID, _ = ctx.UserValue("txid").(string)
+
+	if err = validateCharge(&body, due); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := r.backend.CreateCharge(ctx, body)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, created, http.StatusCreated)
+}
+
+func validateCharge(charge *profile.Charge, due bool) error {
+	if charge.Key == "" {
+		return errors.New("chave cant be empty")
+	}
+	if amount, err := strconv.ParseFloat(charge.Value.Original, 64); err != nil || amount <= 0 {
+		return errors.New("valor.original must be a positive amount")
+	}
+
+	value := charge.Value
+	if !due {
+		if charge.Calendar.DueDate != "" || value.Fine != nil || value.Interest != nil || value.Rebate != nil || value.Discount != nil {
+			return errors.New("charges with due date, fine, interest, rebate or discount go to /cobv")
+		}
+		return nil
+	}
+
+	if charge.Calendar.DueDate == "" {
+		return errors.New("calendario.dataDeVencimento cant be empty")
+	}
+	for _, modality := range []*profile.ChargeModality{value.Fine, value.Interest, value.Rebate} {
+		if modality != nil && !validModality(modality.Modality, modality.Value) {
+			return errors.New("modalidade must be 1 or 2 and valorPerc a non negative amount")
+		}
+	}
+	if value.Discount != nil {
+		for _, date := range value.Discount.FixedDates {
+			if date.Date == "" || !validModality(value.Discount.Modality, date.Value) {
+				return errors.New("desconto needs modalidade 1 or 2 and dates with a non negative valorPerc")
+			}
+		}
+	}
+	return nil
+}
+
+func validModality(modality int, value string) bool {
+	amount, err := strconv.ParseFloat(value, 64)
+	return (modality == profile.ModalityFixed || modality == profile.ModalityPercent) && err == nil && amount >= 0
+}
+
+func (r *profileHandler) LookupKey(ctx *fasthttp.RequestCtx) {
+	accountId, err := ctx.QueryArgs().GetUint("account_id")
+	if err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+	key, _ := ctx.UserValue("key").(string)
+
+	found, err := r.backend.LookupKey(ctx, key, int64(accountId))
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, found, http.StatusOK)
+}
+
+// LookupLimits and UnlockLookups let support staff see and lift the key lookup
+// limits of a user, an account or both.
+func (r *profileHandler) LookupLimits(ctx *fasthttp.RequestCtx) {
+	userId, accountId, err := lookupLimitsArgs(ctx)
+	if err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	limits, err := r.backend.LookupLimits(ctx, userId, accountId)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, limits, http.StatusOK)
+}
+
+func (r *profileHandler) UnlockLookups(ctx *fasthttp.RequestCtx) {
+	userId, accountId, err := lookupLimitsArgs(ctx)
+	if err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	if err = r.backend.UnlockLookups(ctx, userId, accountId); err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	ctx.Response.SetStatusCode(http.StatusNoContent)
+}
+
+func lookupLimitsArgs(ctx *fasthttp.RequestCtx) (string, int64, error) {
+	userId := string(ctx.QueryArgs().Peek("user_id"))
+	accountId := 0
+	if ctx.QueryArgs().Has("account_id") {
+		var err error
+		if accountId, err = ctx.QueryArgs().GetUint("account_id"); err != nil {
+			return "", 0, errors.New("account_id must be a number")
+		}
+	}
+	if userId == "" && accountId == 0 {
+		return "", 0, errors.New("user_id or account_id is required")
+	}
+	return userId, int64(accountId), nil
+}
+
+func (r *profileHandler) FindCharge(ctx *fasthttp.RequestCtx) {
+	accountId, err := ctx.QueryArgs().GetUint("account_id")
+	if err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+	txId, _ := ctx.UserValue("txid").(string)
+
+	charge, err := r.backend.FindCharge(ctx, txId, int64(accountId))
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, charge, http.StatusOK)
+}
+
+func (r *profileHandler) ListCharges(ctx *fasthttp.RequestCtx) {
+	r.listCharges(ctx, false)
+}
+
+func (r *profileHandler) ListDueCharges(ctx *fasthttp.RequestCtx) {
+	r.listCharges(ctx, true)
+}
+
+// listCharges answers /cob with the immediate charges of the account and
+// /cobv with the ones with a due date.
+func (r *profileHandler) listCharges(ctx *fasthttp.RequestCtx, due bool) {
+	accountId, err := ctx.QueryArgs().GetUint("account_id")
+	if err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	list, err := r.backend.ListCharges(ctx, int64(accountId))
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+
+	charges := make([]*profile.Charge, 0, len(list))
+	for _, charge := range list {
+		if (charge.Calendar.DueDate != "") == due {
+			charges = append(charges, charge)
+		}
+	}
+	httputils.JSON(&ctx.Response, map[string][]*profile.Charge{"cobs": charges}, http.StatusOK)
+}
+
+// CancelCharge removes an active charge. As in the BACEN API, it is a PATCH
+// setting the status to REMOVIDA_PELO_USUARIO_RECEBEDOR.
+func (r *profileHandler) CancelCharge(ctx *fasthttp.RequestCtx) {
+	accountId, err := ctx.QueryArgs().GetUint("account_id")
+	if err != nil {
+		httputils.JSONError(&ctx.Response, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err = json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	if body.Status != chargeRemovedByReceiver {
+		httputils.JSONError(&ctx.Response, errors.New("status can only be changed to "+chargeRemovedByReceiver), http.StatusBadRequest)
+		return
+	}
+	txId, _ := ctx.UserValue("txid").(string)
+
+	canceled, err := r.backend.CancelCharge(ctx, txId, int64(accountId))
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, canceled, http.StatusOK)
 }
 
 func (r *profileHandler) FindAccount(ctx *fasthttp.RequestCtx) {
@@ -240,3 +765,343 @@ func NewProfileHandler(backend profile.Backend) ProfileHandler {
 		backend: backend,
 	}
 }
+
+func (r *profileHandler) CreateWebhookSubscription(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	var body profile.WebhookSubscription
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	body.AccountId = accountId
+
+	if err := validateWebhookSubscription(&body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := r.backend.CreateWebhookSubscription(ctx, body)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, created, http.StatusCreated)
+}
+
+func validateWebhookSubscription(subscription *profile.WebhookSubscription) error {
+	target, err := url.Parse(subscription.Url)
+	if err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+		return errors.New("url must be an http or https address")
+	}
+	if len(subscription.Events) == 0 {
+		return errors.New("events cant be empty")
+	}
+	for _, event := range subscription.Events {
+		if !profile.WebhookEvents[event] {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
+
+func (r *profileHandler) ListWebhookSubscriptions(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := r.backend.ListWebhookSubscriptions(ctx, accountId)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, subscriptions, http.StatusOK)
+}
+
+// SetWebhookSubscriptionEnabled takes {"enabled": true|false}.
+func (r *profileHandler) SetWebhookSubscriptionEnabled(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	if body.Enabled == nil {
+		httputils.JSONError(&ctx.Response, errors.New("enabled is required"), http.StatusBadRequest)
+		return
+	}
+
+	webhookId, _ := ctx.UserValue("webhookId").(string)
+	updated, err := r.backend.SetWebhookSubscriptionEnabled(ctx, webhookId, accountId, *body.Enabled)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, updated, http.StatusOK)
+}
+
+func (r *profileHandler) DeleteWebhookSubscription(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	webhookId, _ := ctx.UserValue("webhookId").(string)
+	if err := r.backend.DeleteWebhookSubscription(ctx, webhookId, accountId); err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, &httputils.Response{Status: http.StatusOK, Msg: "success"}, http.StatusOK)
+}
+
+// RotateWebhookSecret returns the new secret. The old one keeps signing
+// deliveries, next to the new one, until previous_secret_expires_at.
+func (r *profileHandler) RotateWebhookSecret(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	webhookId, _ := ctx.UserValue("webhookId").(string)
+	rotated, err := r.backend.RotateWebhookSecret(ctx, webhookId, accountId)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, rotated, http.StatusOK)
+}
+
+// PingWebhookSubscription sends a signed ping event and returns how the
+// endpoint answered.
+func (r *profileHandler) PingWebhookSubscription(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	webhookId, _ := ctx.UserValue("webhookId").(string)
+	delivery, err := r.backend.PingWebhookSubscription(ctx, webhookId, accountId)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, delivery, http.StatusOK)
+}
+
+// pathAccountId reads the {id} of the route, answering 400 when it is not a
+// positive number.
+func pathAccountId(ctx *fasthttp.RequestCtx) (int64, bool) {
+	accountId, err := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+	if err != nil || accountId <= 0 {
+		httputils.JSONError(&ctx.Response, errors.New("account id must be a positive number"), http.StatusBadRequest)
+		return 0, false
+	}
+	return accountId, true
+}
+
+func (r *profileHandler) ListPixLimits(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	limits, err := r.backend.ListPixLimits(ctx, accountId)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, limits, http.StatusOK)
+}
+
+// RequestPixLimit takes {"amount": 1000.00} for the limit in the path. A
+// lower limit is in force at once, a higher one only after the cooling-off
+// period, so the response may still show the current amount.
+func (r *profileHandler) RequestPixLimit(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Amount float64 `json:"amount"`
+	}
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	if body.Amount <= 0 {
+		httputils.JSONError(&ctx.Response, errors.New("amount must be positive"), http.StatusBadRequest)
+		return
+	}
+
+	limitType := strings.ToUpper(ctx.UserValue("type").(string))
+	limit, err := r.backend.RequestPixLimit(ctx, accountId, limitType, body.Amount)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, limit, http.StatusOK)
+}
+
+// ListReviews lists the pix the risk analysis parked, oldest first. status
+// defaults to PENDING.
+func (r *profileHandler) ListReviews(ctx *fasthttp.RequestCtx) {
+	limit := 0
+	if ctx.QueryArgs().Has("limit") {
+		var err error
+		if limit, err = ctx.QueryArgs().GetUint("limit"); err != nil {
+			httputils.JSONError(&ctx.Response, errors.New("limit must be a number"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	reviews, err := r.backend.ListReviews(ctx, strings.ToUpper(string(ctx.QueryArgs().Peek("status"))), limit)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, reviews, http.StatusOK)
+}
+
+type reviewBody struct {
+	Analyst string `json:"analyst"`
+	Note    string `json:"note"`
+}
+
+func readReviewBody(ctx *fasthttp.RequestCtx) (*reviewBody, error) {
+	var body reviewBody
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		return nil, err
+	}
+	if body.Analyst == "" {
+		return nil, errors.New("analyst is required")
+	}
+	return &body, nil
+}
+
+// ApproveReview sends the parked pix and returns it.
+func (r *profileHandler) ApproveReview(ctx *fasthttp.RequestCtx) {
+	body, err := readReviewBody(ctx)
+	if err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	sent, err := r.backend.ApproveReview(ctx, ctx.UserValue("reviewId").(string), body.Analyst, body.Note)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, sent, http.StatusOK)
+}
+
+func (r *profileHandler) RejectReview(ctx *fasthttp.RequestCtx) {
+	body, err := readReviewBody(ctx)
+	if err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+
+	rejected, err := r.backend.RejectReview(ctx, ctx.UserValue("reviewId").(string), body.Analyst, body.Note)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, rejected, http.StatusOK)
+}
+
+type infractionBody struct {
+	TransactionId string `json:"transaction_id"`
+	Reason        string `json:"reason"`
+}
+
+// ReportInfraction opens a MED report for a pix the account sent and blocks
+// its amount in the receiver account.
+func (r *profileHandler) ReportInfraction(ctx *fasthttp.RequestCtx) {
+	accountId, ok := pathAccountId(ctx)
+	if !ok {
+		return
+	}
+
+	var body infractionBody
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	if body.TransactionId == "" {
+		httputils.JSONError(&ctx.Response, errors.New("transaction_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	report, err := r.backend.ReportInfraction(ctx, accountId, body.TransactionId, body.Reason)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, report, http.StatusCreated)
+}
+
+// ListInfractionReports lists the reports of the account in the path, or every
+// report under /support, oldest first and filtered by the status query.
+func (r *profileHandler) ListInfractionReports(ctx *fasthttp.RequestCtx) {
+	var accountId int64
+	if ctx.UserValue("id") != nil {
+		var ok bool
+		if accountId, ok = pathAccountId(ctx); !ok {
+			return
+		}
+	}
+
+	limit := 0
+	if ctx.QueryArgs().Has("limit") {
+		var err error
+		if limit, err = ctx.QueryArgs().GetUint("limit"); err != nil {
+			httputils.JSONError(&ctx.Response, errors.New("limit must be a number"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	reports, err := r.backend.ListInfractionReports(ctx, accountId, strings.ToUpper(string(ctx.QueryArgs().Peek("status"))), limit)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, reports, http.StatusOK)
+}
+
+type resolutionBody struct {
+	Analyst string `json:"analyst"`
+	Accept  bool   `json:"accept"`
+	Note    string `json:"note"`
+}
+
+// ResolveInfraction accepts a report, refunding the blocked amount to the
+// reporter, or rejects it, releasing the block.
+func (r *profileHandler) ResolveInfraction(ctx *fasthttp.RequestCtx) {
+	var body resolutionBody
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		httputils.JSONError(&ctx.Response, err, http.StatusBadRequest)
+		return
+	}
+	if body.Analyst == "" {
+		httputils.JSONError(&ctx.Response, errors.New("analyst is required"), http.StatusBadRequest)
+		return
+	}
+
+	report, err := r.backend.ResolveInfraction(ctx, ctx.UserValue("infractionId").(string), body.Analyst, body.Accept, body.Note)
+	if err != nil {
+		httputils.BackendErrorFactory(&ctx.Response, err)
+		return
+	}
+	httputils.JSON(&ctx.Response, report, http.StatusOK)
+}
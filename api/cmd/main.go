@@ -29,16 +29,24 @@ func main() {
 	account := proto.NewAccountServiceClient(client)
 	keys := proto.NewKeysServiceClient(client)
 	pix := proto.NewPixTransactionServiceClient(client)
+	ledger := proto.NewLedgerServiceClient(client)
+	webhooks := proto.NewWebhookServiceClient(client)
+	risk := proto.NewRiskServiceClient(client)
+	med := proto.NewMedServiceClient(client)
 
-	profileBackend := profile.NewBackend(user, account, keys, pix)
+	profileBackend := profile.NewBackend(user, account, keys, pix, ledger, webhooks, risk, med)
 
 	profileHandler := handlers.NewProfileHandler(profileBackend)
 
 	routes := router.New()
 
 	logger := middleware.NewLogger(true)
+	if cfg.Webhook.Secret == "" {
+		log.Print("WEBHOOK_SECRET is not set, every webhook will be refused")
+	}
+	signature := middleware.NewSignature(cfg.Webhook.Secret, cfg.Webhook.Tolerance)
 
-	routes = handlers.ProfileRoutes(routes, profileHandler, logger)
+	routes = handlers.ProfileRoutes(routes, profileHandler, logger, signature)
 
 	log.Printf("Serve is running on port: %s\n", cfg.Api.Port)
 	if err = fasthttp.ListenAndServe(fmt.Sprintf(":%s", cfg.Api.Port), routes.Handler); err != nil {
@@ -1,16 +1,27 @@
 package config
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 type Config struct {
 	Api     ApiConfig
 	Profile ProfileConfig
+	Webhook WebhookConfig
 }
 
 type ApiConfig struct {
 	Port string
 }
 
+// WebhookConfig holds the secret the transaction service signs webhooks
+// with, and how far their timestamp may be from now.
+type WebhookConfig struct {
+	Secret    string
+	Tolerance time.Duration
+}
+
 type ProfileConfig struct {
 	Host string
 	Port string
@@ -25,6 +36,10 @@ func Load() *Config {
 		Api: ApiConfig{
 			Port: Getenv("API_PORT", "9060"),
 		},
+		Webhook: WebhookConfig{
+			Secret:    Getenv("WEBHOOK_SECRET", ""),
+			Tolerance: GetDuration("WEBHOOK_TOLERANCE", 5*time.Minute),
+		},
 	}
 }
 
@@ -34,3 +49,10 @@ func Getenv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
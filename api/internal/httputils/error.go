@@ -1,7 +1,9 @@
 package httputils
 
 import (
+	"errors"
 	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc/status"
 	"net/http"
 )
 
@@ -19,26 +21,39 @@ func (e *backendError) Error() string {
 }
 
 var (
-	ErrMessageNotFound    = backendError{msg: "not found"}
-	ErrMessageInternal    = backendError{msg: "internal server error"}
-	ErrMessageUnavailable = backendError{msg: "service unavailable"}
-	ErrMessageTimeout     = backendError{msg: "gateway timeout"}
+	ErrMessageNotFound      = backendError{msg: "not found"}
+	ErrMessageInternal      = backendError{msg: "internal server error"}
+	ErrMessageUnavailable   = backendError{msg: "service unavailable"}
+	ErrMessageTimeout       = backendError{msg: "gateway timeout"}
+	ErrMessageBadRequest    = backendError{msg: "bad request"}
+	ErrMessageConflict      = backendError{msg: "conflict"}
+	ErrMessageUnprocessable = backendError{msg: "unprocessable entity"}
+	ErrMessageForbidden     = backendError{msg: "forbidden"}
+	ErrMessageTooMany       = backendError{msg: "too many requests"}
 )
 
+var mapHttpStatus = map[*backendError]int{
+	&ErrMessageNotFound:      http.StatusNotFound,
+	&ErrMessageInternal:      http.StatusInternalServerError,
+	&ErrMessageUnavailable:   http.StatusServiceUnavailable,
+	&ErrMessageTimeout:       http.StatusGatewayTimeout,
+	&ErrMessageBadRequest:    http.StatusBadRequest,
+	&ErrMessageConflict:      http.StatusConflict,
+	&ErrMessageUnprocessable: http.StatusUnprocessableEntity,
+	&ErrMessageForbidden:     http.StatusForbidden,
+	&ErrMessageTooMany:       http.StatusTooManyRequests,
+}
+
 func BackendErrorFactory(ctx *fasthttp.Response, err error) {
 	if IsBackendError(err) {
-		switch err {
-		case &ErrMessageUnavailable:
-			JSONError(ctx, err, http.StatusServiceUnavailable)
-			return
-		case &ErrMessageNotFound:
-			JSONError(ctx, err, http.StatusNotFound)
+		if code, ok := mapHttpStatus[err.(*backendError)]; ok {
+			JSONError(ctx, err, code)
 			return
-		case &ErrMessageInternal:
-			JSONError(ctx, err, http.StatusInternalServerError)
-			return
-		case &ErrMessageTimeout:
-			JSONError(ctx, err, http.StatusGatewayTimeout)
+		}
+	}
+	if st, ok := status.FromError(err); ok {
+		if backendErr, found := mapGrpcError[st.Code()]; found {
+			JSONError(ctx, errors.New(st.Message()), mapHttpStatus[backendErr])
 			return
 		}
 	}
@@ -0,0 +1,66 @@
+package httputils
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"testing"
+)
+
+func TestBackendErrorFactory(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "backend error",
+			err:      &ErrMessageNotFound,
+			wantCode: http.StatusNotFound,
+			wantBody: `{"status":404,"msg":"not found"}`,
+		},
+		{
+			name:     "grpc conflict keeps the message",
+			err:      status.Error(codes.Aborted, "request in progress"),
+			wantCode: http.StatusConflict,
+			wantBody: `{"status":409,"msg":"request in progress"}`,
+		},
+		{
+			name:     "grpc failed precondition",
+			err:      status.Error(codes.FailedPrecondition, "key mismatch"),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `{"status":422,"msg":"key mismatch"}`,
+		},
+		{
+			name:     "grpc permission denied",
+			err:      status.Error(codes.PermissionDenied, "only the receiver can refund the transaction"),
+			wantCode: http.StatusForbidden,
+			wantBody: `{"status":403,"msg":"only the receiver can refund the transaction"}`,
+		},
+		{
+			name:     "grpc resource exhausted",
+			err:      status.Error(codes.ResourceExhausted, "too many key lookups, try again later"),
+			wantCode: http.StatusTooManyRequests,
+			wantBody: `{"status":429,"msg":"too many key lookups, try again later"}`,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("boom"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"status":500,"msg":"boom"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var response fasthttp.Response
+			BackendErrorFactory(&response, tc.err)
+			assert.Equal(t, tc.wantCode, response.StatusCode())
+			assert.Equal(t, tc.wantBody, string(response.Body()))
+		})
+	}
+}
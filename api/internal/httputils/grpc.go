@@ -6,10 +6,16 @@ import (
 )
 
 var mapGrpcError = map[codes.Code]*backendError{
-	codes.NotFound:         &ErrMessageNotFound,
-	codes.Internal:         &ErrMessageInternal,
-	codes.Unavailable:      &ErrMessageUnavailable,
-	codes.DeadlineExceeded: &ErrMessageTimeout,
+	codes.NotFound:           &ErrMessageNotFound,
+	codes.Internal:           &ErrMessageInternal,
+	codes.Unavailable:        &ErrMessageUnavailable,
+	codes.DeadlineExceeded:   &ErrMessageTimeout,
+	codes.InvalidArgument:    &ErrMessageBadRequest,
+	codes.AlreadyExists:      &ErrMessageConflict,
+	codes.Aborted:            &ErrMessageConflict,
+	codes.FailedPrecondition: &ErrMessageUnprocessable,
+	codes.PermissionDenied:   &ErrMessageForbidden,
+	codes.ResourceExhausted:  &ErrMessageTooMany,
 }
 
 func CodeToError(code codes.Code) error {
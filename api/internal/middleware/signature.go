@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"api/internal/httputils"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerTimestamp = "X-Webhook-Timestamp"
+	headerSignature = "X-Webhook-Signature"
+)
+
+var (
+	errInvalidSignature = errors.New("invalid webhook signature")
+	errMissingSecret    = errors.New("webhook secret is not configured")
+)
+
+// signature refuses webhooks that were not signed with secret, or that were
+// signed more than tolerance away from now, so captured requests can not be
+// replayed later.
+type signature struct {
+	secret    string
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func (s *signature) WrapHandler(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if s.secret == "" {
+		// webhooks move balances, without a secret none is taken
+		return func(ctx *fasthttp.RequestCtx) {
+			httputils.JSONError(&ctx.Response, errMissingSecret, http.StatusServiceUnavailable)
+		}
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if !s.valid(ctx) {
+			httputils.JSONError(&ctx.Response, errInvalidSignature, http.StatusUnauthorized)
+			return
+		}
+		next(ctx)
+	}
+}
+
+func (s *signature) valid(ctx *fasthttp.RequestCtx) bool {
+	timestamp := string(ctx.Request.Header.Peek(headerTimestamp))
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := s.now().Sub(time.Unix(seconds, 0))
+	if age > s.tolerance || age < -s.tolerance {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(ctx.Request.Body())
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), ctx.Request.Header.Peek(headerSignature))
+}
+
+// NewSignature checks the signature of incoming webhooks. With an empty secret
+// every webhook is refused.
+func NewSignature(secret string, tolerance time.Duration) Middleware {
+	return &signature{
+		secret:    secret,
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+}
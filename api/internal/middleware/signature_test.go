@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignature(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	body := `{"transaction_id":"pix-1"}`
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	cases := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		wantCode  int
+	}{
+		{name: "valid", secret: "secret", timestamp: fresh, signature: sign("secret", fresh, body), wantCode: http.StatusOK},
+		{name: "signed with another secret", secret: "secret", timestamp: fresh, signature: sign("other", fresh, body), wantCode: http.StatusUnauthorized},
+		{name: "replayed", secret: "secret", timestamp: stale, signature: sign("secret", stale, body), wantCode: http.StatusUnauthorized},
+		{name: "missing", secret: "secret", wantCode: http.StatusUnauthorized},
+		{name: "refused without a secret", timestamp: fresh, signature: sign("", fresh, body), wantCode: http.StatusServiceUnavailable},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := NewSignature(c.secret, 5*time.Minute).(*signature)
+			s.now = func() time.Time { return now }
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetBodyString(body)
+			ctx.Request.Header.Set(headerTimestamp, c.timestamp)
+			ctx.Request.Header.Set(headerSignature, c.signature)
+
+			s.WrapHandler(func(ctx *fasthttp.RequestCtx) {
+				ctx.SetStatusCode(http.StatusOK)
+			})(ctx)
+			assert.Equal(t, c.wantCode, ctx.Response.StatusCode())
+		})
+	}
+}
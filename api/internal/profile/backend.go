@@ -3,6 +3,8 @@ package profile
 import (
 	proto "api/proto/v1"
 	"context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strconv"
 )
 
 type Backend interface {
@@ -13,15 +15,71 @@ type Backend interface {
 	FindAccount(ctx context.Context, userId string) error
 	PixWebhook(ctx context.Context, webhook Webhook) error
 	CreateAccount(ctx context.Context, userId string, account Account) (int64, error)
-	SendPix(ctx context.Context, pix PixTransaction) error
+	SendPix(ctx context.Context, pix PixTransaction) (*PixTransaction, error)
+	RefundPix(ctx context.Context, refund PixRefund) (*PixTransaction, error)
+	ListScheduledPix(ctx context.Context, accountId int64) ([]*PixTransaction, error)
+	ListAccountTransactions(ctx context.Context, accountId int64, filter HistoryFilter) (*TransactionHistory, error)
+	ExportStatement(ctx context.Context, accountId int64, req StatementRequest) (StatementStream, error)
+	CancelScheduledPix(ctx context.Context, scheduled ScheduledPix) (*PixTransaction, error)
+	CreateQRCode(ctx context.Context, code QRCode) (*QRCode, error)
+	ParseQRCode(ctx context.Context, payload string) (*QRCode, error)
+	CreateCharge(ctx context.Context, charge Charge) (*Charge, error)
+	FindCharge(ctx context.Context, txId string, accountId int64) (*Charge, error)
+	ListCharges(ctx context.Context, accountId int64) ([]*Charge, error)
+	CancelCharge(ctx context.Context, txId string, accountId int64) (*Charge, error)
 	CreateKey(ctx context.Context, key Key) error
+	LookupKey(ctx context.Context, key string, accountId int64) (*KeyLookup, error)
+	LookupLimits(ctx context.Context, userId string, accountId int64) ([]*LookupLimit, error)
+	UnlockLookups(ctx context.Context, userId string, accountId int64) error
+	CreateWebhookSubscription(ctx context.Context, subscription WebhookSubscription) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, accountId int64) ([]*WebhookSubscription, error)
+	SetWebhookSubscriptionEnabled(ctx context.Context, id string, accountId int64, enabled bool) (*WebhookSubscription, error)
+	RotateWebhookSecret(ctx context.Context, id string, accountId int64) (*WebhookSubscription, error)
+	PingWebhookSubscription(ctx context.Context, id string, accountId int64) (*WebhookDelivery, error)
+	DeleteWebhookSubscription(ctx context.Context, id string, accountId int64) error
+	ListPixLimits(ctx context.Context, accountId int64) ([]*PixLimit, error)
+	RequestPixLimit(ctx context.Context, accountId int64, limitType string, amount float64) (*PixLimit, error)
+	ListReviews(ctx context.Context, status string, limit int) ([]*RiskAssessment, error)
+	ApproveReview(ctx context.Context, id, analyst, note string) (*PixTransaction, error)
+	RejectReview(ctx context.Context, id, analyst, note string) (*RiskAssessment, error)
+	ReportInfraction(ctx context.Context, accountId int64, transactionId, reason string) (*InfractionReport, error)
+	ListInfractionReports(ctx context.Context, accountId int64, status string, limit int) ([]*InfractionReport, error)
+	ResolveInfraction(ctx context.Context, id, analyst string, accept bool, note string) (*InfractionReport, error)
+}
+
+// StatementStream is a statement being exported. Next returns its chunks in
+// order, and io.EOF after the last one.
+type StatementStream interface {
+	Next() ([]byte, error)
 }
 
 type grpc struct {
-	user    proto.UserServiceClient
-	account proto.AccountServiceClient
-	keys    proto.KeysServiceClient
-	pix     proto.PixTransactionServiceClient
+	user     proto.UserServiceClient
+	account  proto.AccountServiceClient
+	keys     proto.KeysServiceClient
+	pix      proto.PixTransactionServiceClient
+	ledger   proto.LedgerServiceClient
+	webhooks proto.WebhookServiceClient
+	risk     proto.RiskServiceClient
+	med      proto.MedServiceClient
+}
+
+type statementStream struct {
+	stream proto.LedgerService_ExportStatementClient
+	first  []byte
+}
+
+func (s *statementStream) Next() ([]byte, error) {
+	if s.first != nil {
+		first := s.first
+		s.first = nil
+		return first, nil
+	}
+	chunk, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return chunk.Data, nil
 }
 
 func (g *grpc) Webhook(ctx context.Context, webhook Webhook) error {
@@ -31,8 +89,11 @@ func (g *grpc) Webhook(ctx context.Context, webhook Webhook) error {
 		Receiver: &proto.WebhookAccount{
 			Name:   webhook.Receiver.Name,
 			Agency: webhook.Receiver.Agency, Bank: webhook.Receiver.Bank},
-		Amount: webhook.Amount.InexactFloat64(),
-		Status: proto.Status(proto.Status_value[string(webhook.Status)]),
+		Amount:                webhook.Amount.InexactFloat64(),
+		Status:                proto.Status(proto.Status_value[string(webhook.Status)]),
+		TransactionId:         webhook.TransactionId,
+		OriginalTransactionId: webhook.OriginalTransactionId,
+		ReceiverKey:           webhook.ReceiverKey,
 	})
 	if err != nil {
 		return err
@@ -98,8 +159,11 @@ func (g *grpc) PixWebhook(ctx context.Context, webhook Webhook) error {
 			Agency: webhook.Sender.Agency, Bank: webhook.Sender.Bank},
 		Receiver: &proto.WebhookAccount{
 			Agency: webhook.Receiver.Agency, Bank: webhook.Receiver.Bank},
-		Amount: webhook.Amount.InexactFloat64(),
-		Status: proto.Status(proto.Status_value[string(webhook.Status)]),
+		Amount:                webhook.Amount.InexactFloat64(),
+		Status:                proto.Status(proto.Status_value[string(webhook.Status)]),
+		TransactionId:         webhook.TransactionId,
+		OriginalTransactionId: webhook.OriginalTransactionId,
+		ReceiverKey:           webhook.ReceiverKey,
 	})
 	if err != nil {
 		return err
@@ -107,16 +171,319 @@ func (g *grpc) PixWebhook(ctx context.Context, webhook Webhook) error {
 	return nil
 }
 
-func (g *grpc) SendPix(ctx context.Context, pix PixTransaction) error {
-	_, err := g.pix.SendPix(ctx, &proto.PixTransaction{
-		ReceiverKey: pix.ReceiverKey,
-		Amount:      pix.Amount,
-		AccountId:   pix.AccountId,
+func (g *grpc) SendPix(ctx context.Context, pix PixTransaction) (*PixTransaction, error) {
+	request := &proto.PixTransaction{
+		ReceiverKey:    pix.ReceiverKey,
+		Amount:         pix.Amount,
+		AccountId:      pix.AccountId,
+		IdempotencyKey: pix.IdempotencyKey,
+		CopyPaste:      pix.CopyPaste,
+		Txid:           pix.TxID,
+		LookupToken:    pix.LookupToken,
+	}
+	if pix.ExecuteAt != nil {
+		request.ExecuteAt = timestamppb.New(*pix.ExecuteAt)
+	}
+
+	response, err := g.pix.SendPix(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return protoToPixTransaction(response), nil
+}
+
+func (g *grpc) ListScheduledPix(ctx context.Context, accountId int64) ([]*PixTransaction, error) {
+	response, err := g.pix.ListScheduledPix(ctx, &proto.AccountRequest{AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*PixTransaction, len(response.Transactions))
+	for i := range response.Transactions {
+		list[i] = protoToPixTransaction(response.Transactions[i])
+	}
+	return list, nil
+}
+
+func (g *grpc) ListAccountTransactions(ctx context.Context, accountId int64, filter HistoryFilter) (*TransactionHistory, error) {
+	req := &proto.TransactionHistoryRequest{
+		AccountId: accountId,
+		Direction: filter.Direction,
+		Status:    filter.Status,
+		Limit:     int32(filter.Limit),
+		Cursor:    filter.Cursor,
+	}
+	if filter.From != nil {
+		req.From = timestamppb.New(*filter.From)
+	}
+	if filter.To != nil {
+		req.To = timestamppb.New(*filter.To)
+	}
+
+	response, err := g.pix.ListAccountTransactions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &TransactionHistory{
+		Transactions: make([]*PixTransaction, len(response.Transactions)),
+		NextCursor:   response.NextCursor,
+	}
+	for i := range response.Transactions {
+		history.Transactions[i] = protoToPixTransaction(response.Transactions[i])
+	}
+	return history, nil
+}
+
+func (g *grpc) CancelScheduledPix(ctx context.Context, scheduled ScheduledPix) (*PixTransaction, error) {
+	response, err := g.pix.CancelScheduledPix(ctx, &proto.ScheduledPixRequest{
+		Id:        scheduled.Id,
+		AccountId: scheduled.AccountId,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return protoToPixTransaction(response), nil
+}
+
+func (g *grpc) CreateQRCode(ctx context.Context, code QRCode) (*QRCode, error) {
+	response, err := g.pix.CreateQRCode(ctx, &proto.QRCode{
+		Key:          code.Key,
+		Amount:       code.Amount,
+		Txid:         code.TxID,
+		MerchantName: code.MerchantName,
+		MerchantCity: code.MerchantCity,
+		Description:  code.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return protoToQRCode(response), nil
+}
+
+func (g *grpc) ParseQRCode(ctx context.Context, payload string) (*QRCode, error) {
+	response, err := g.pix.ParseQRCode(ctx, &proto.QRCode{Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	return protoToQRCode(response), nil
+}
+
+func (g *grpc) CreateCharge(ctx context.Context, charge Charge) (*Charge, error) {
+	amount, err := strconv.ParseFloat(charge.Value.Original, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &proto.PixCharge{
+		Txid:         charge.TxID,
+		AccountId:    charge.AccountId,
+		Key:          charge.Key,
+		Amount:       amount,
+		Expiration:   charge.Calendar.Expiration,
+		PayerRequest: charge.PayerRequest,
+	}
+	if charge.Debtor != nil {
+		request.PayerCpf = charge.Debtor.Cpf
+		request.PayerName = charge.Debtor.Name
+	}
+	request.DueDate = charge.Calendar.DueDate
+	request.ValidAfterDue = charge.Calendar.ValidAfterDue
+	if request.Fine, err = modalityToProto(charge.Value.Fine); err != nil {
+		return nil, err
+	}
+	if request.Interest, err = modalityToProto(charge.Value.Interest); err != nil {
+		return nil, err
+	}
+	if request.Rebate, err = modalityToProto(charge.Value.Rebate); err != nil {
+		return nil, err
+	}
+	if discount := charge.Value.Discount; discount != nil {
+		for _, date := range discount.FixedDates {
+			value, err := strconv.ParseFloat(date.Value, 64)
+			if err != nil {
+				return nil, err
+			}
+			request.Discounts = append(request.Discounts, &proto.PixChargeDiscount{
+				Until:    date.Date,
+				Modality: modalityNames[discount.Modality],
+				Value:    value,
+			})
+		}
+	}
+
+	response, err := g.pix.CreateCharge(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return protoToCharge(response), nil
+}
+
+func (g *grpc) FindCharge(ctx context.Context, txId string, accountId int64) (*Charge, error) {
+	response, err := g.pix.FindCharge(ctx, &proto.PixChargeRequest{Txid: txId, AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+	return protoToCharge(response), nil
+}
+
+func (g *grpc) ListCharges(ctx context.Context, accountId int64) ([]*Charge, error) {
+	response, err := g.pix.ListCharges(ctx, &proto.AccountRequest{AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Charge, len(response.Charges))
+	for i := range response.Charges {
+		list[i] = protoToCharge(response.Charges[i])
+	}
+	return list, nil
+}
+
+func (g *grpc) CancelCharge(ctx context.Context, txId string, accountId int64) (*Charge, error) {
+	response, err := g.pix.CancelCharge(ctx, &proto.PixChargeRequest{Txid: txId, AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+	return protoToCharge(response), nil
+}
+
+var modalityNames = map[int]string{
+	ModalityFixed:   "FIXED",
+	ModalityPercent: "PERCENT",
+}
+
+func modalityToProto(modality *ChargeModality) (*proto.PixChargeAdjustment, error) {
+	if modality == nil {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(modality.Value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.PixChargeAdjustment{Modality: modalityNames[modality.Modality], Value: value}, nil
+}
+
+func modalityNumber(name string) int {
+	for number, modality := range modalityNames {
+		if modality == name {
+			return number
+		}
+	}
+	return 0
+}
+
+func protoToModality(adjustment *proto.PixChargeAdjustment) *ChargeModality {
+	if adjustment == nil {
+		return nil
+	}
+	return &ChargeModality{
+		Modality: modalityNumber(adjustment.Modality),
+		Value:    strconv.FormatFloat(adjustment.Value, 'f', 2, 64),
+	}
+}
+
+func protoToCharge(response *proto.PixCharge) *Charge {
+	createdAt := response.GetCreatedAt().AsTime()
+	value := strconv.FormatFloat(response.Amount, 'f', 2, 64)
+
+	charge := &Charge{
+		AccountId: response.AccountId,
+		Calendar: ChargeCalendar{
+			CreatedAt:     &createdAt,
+			Expiration:    response.Expiration,
+			DueDate:       response.DueDate,
+			ValidAfterDue: response.ValidAfterDue,
+		},
+		TxID:     response.Txid,
+		Revision: response.Revision,
+		Status:   response.Status,
+		Value: ChargeValue{
+			Original: value,
+			Final:    strconv.FormatFloat(response.AmountDue, 'f', 2, 64),
+			Fine:     protoToModality(response.Fine),
+			Interest: protoToModality(response.Interest),
+			Rebate:   protoToModality(response.Rebate),
+		},
+		Key:          response.Key,
+		PayerRequest: response.PayerRequest,
+	}
+	if len(response.Discounts) > 0 {
+		charge.Value.Discount = &ChargeDiscount{Modality: modalityNumber(response.Discounts[0].Modality)}
+		for _, discount := range response.Discounts {
+			charge.Value.Discount.FixedDates = append(charge.Value.Discount.FixedDates, ChargeDiscountDate{
+				Date:  discount.Until,
+				Value: strconv.FormatFloat(discount.Value, 'f', 2, 64),
+			})
+		}
+	}
+	if response.PayerCpf != "" || response.PayerName != "" {
+		charge.Debtor = &ChargeDebtor{Cpf: response.PayerCpf, Name: response.PayerName}
+	}
+	if response.TransactionId != "" {
+		charge.Pix = []ChargePix{{
+			EndToEndId: response.TransactionId,
+			Value:      charge.Value.Final,
+			PaidAt:     response.GetPaidAt().AsTime(),
+		}}
+	}
+	return charge
+}
+
+func protoToQRCode(response *proto.QRCode) *QRCode {
+	return &QRCode{
+		Key:          response.Key,
+		Amount:       response.Amount,
+		TxID:         response.Txid,
+		MerchantName: response.MerchantName,
+		MerchantCity: response.MerchantCity,
+		Description:  response.Description,
+		Payload:      response.Payload,
+	}
+}
+
+func protoToPixTransaction(response *proto.PixTransaction) *PixTransaction {
+	pix := &PixTransaction{
+		Id:             response.Id,
+		AccountId:      response.AccountId,
+		ReceiverKey:    response.ReceiverKey,
+		Amount:         response.Amount,
+		Status:         response.Status,
+		IdempotencyKey: response.IdempotencyKey,
+		TxID:           response.Txid,
+
+		OriginalTransactionId: response.OriginalTransactionId,
+		Type:                  response.Type,
+		ReceiverAccount:       response.ReceiverAccount,
+		RefundedAmount:        response.RefundedAmount,
+	}
+	if response.ExecuteAt != nil {
+		executeAt := response.ExecuteAt.AsTime()
+		pix.ExecuteAt = &executeAt
+	}
+	if response.Hour != nil {
+		createdAt := response.Hour.AsTime()
+		pix.CreatedAt = &createdAt
+	}
+	return pix
+}
+
+func (g *grpc) RefundPix(ctx context.Context, refund PixRefund) (*PixTransaction, error) {
+	response, err := g.pix.RefundPix(ctx, &proto.PixRefund{
+		TransactionId: refund.TransactionId,
+		AccountId:     refund.AccountId,
+		Amount:        refund.Amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PixTransaction{
+		Id:                    response.Id,
+		AccountId:             response.AccountId,
+		Amount:                response.Amount,
+		Status:                response.Status,
+		OriginalTransactionId: response.OriginalTransactionId,
+	}, nil
 }
 
 func (g *grpc) CreateKey(ctx context.Context, key Key) error {
@@ -131,11 +498,312 @@ func (g *grpc) CreateKey(ctx context.Context, key Key) error {
 	return nil
 }
 
-func NewBackend(user proto.UserServiceClient, account proto.AccountServiceClient, keys proto.KeysServiceClient, pix proto.PixTransactionServiceClient) Backend {
+func (g *grpc) LookupKey(ctx context.Context, key string, accountId int64) (*KeyLookup, error) {
+	response, err := g.keys.LookupKey(ctx, &proto.LookupKeyRequest{Key: key, AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+	return &KeyLookup{
+		Key:       response.Key,
+		Type:      Type(response.Type.String()),
+		Name:      response.Name,
+		MaskedCpf: response.MaskedCpf,
+		Bank:      response.Bank,
+		Agency:    response.Agency,
+		Token:     response.Token,
+		ExpiresAt: response.GetExpiresAt().AsTime(),
+	}, nil
+}
+
+func (g *grpc) LookupLimits(ctx context.Context, userId string, accountId int64) ([]*LookupLimit, error) {
+	response, err := g.keys.LookupLimits(ctx, &proto.LookupLimitsRequest{UserId: userId, AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make([]*LookupLimit, len(response.Limits))
+	for i, limit := range response.Limits {
+		limits[i] = &LookupLimit{
+			Subject: limit.Subject,
+			Tokens:  limit.Tokens,
+			Misses:  int(limit.Misses),
+			Locked:  limit.Locked,
+		}
+		if limit.LockedUntil != nil {
+			lockedUntil := limit.LockedUntil.AsTime()
+			limits[i].LockedUntil = &lockedUntil
+		}
+	}
+	return limits, nil
+}
+
+func (g *grpc) UnlockLookups(ctx context.Context, userId string, accountId int64) error {
+	_, err := g.keys.UnlockLookups(ctx, &proto.LookupLimitsRequest{UserId: userId, AccountId: accountId})
+	return err
+}
+
+// ExportStatement waits for the first chunk, so an invalid request fails here
+// instead of halfway through the response.
+func (g *grpc) ExportStatement(ctx context.Context, accountId int64, req StatementRequest) (StatementStream, error) {
+	stream, err := g.ledger.ExportStatement(ctx, &proto.StatementRequest{
+		AccountId: accountId,
+		From:      timestamppb.New(req.From),
+		To:        timestamppb.New(req.To.AddDate(0, 0, 1)),
+		Format:    req.Format,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return &statementStream{stream: stream, first: first.Data}, nil
+}
+
+func (g *grpc) CreateWebhookSubscription(ctx context.Context, subscription WebhookSubscription) (*WebhookSubscription, error) {
+	response, err := g.webhooks.CreateWebhookSubscription(ctx, &proto.WebhookSubscription{
+		AccountId: subscription.AccountId,
+		Url:       subscription.Url,
+		Events:    subscription.Events,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return protoToWebhookSubscription(response), nil
+}
+
+func (g *grpc) ListWebhookSubscriptions(ctx context.Context, accountId int64) ([]*WebhookSubscription, error) {
+	response, err := g.webhooks.ListWebhookSubscriptions(ctx, &proto.AccountRequest{AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*WebhookSubscription, len(response.Subscriptions))
+	for i := range response.Subscriptions {
+		list[i] = protoToWebhookSubscription(response.Subscriptions[i])
+	}
+	return list, nil
+}
+
+func (g *grpc) SetWebhookSubscriptionEnabled(ctx context.Context, id string, accountId int64, enabled bool) (*WebhookSubscription, error) {
+	response, err := g.webhooks.SetWebhookSubscriptionEnabled(ctx, &proto.WebhookSubscriptionRequest{Id: id, AccountId: accountId, Enabled: enabled})
+	if err != nil {
+		return nil, err
+	}
+	return protoToWebhookSubscription(response), nil
+}
+
+func (g *grpc) RotateWebhookSecret(ctx context.Context, id string, accountId int64) (*WebhookSubscription, error) {
+	response, err := g.webhooks.RotateWebhookSecret(ctx, &proto.WebhookSubscriptionRequest{Id: id, AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+	return protoToWebhookSubscription(response), nil
+}
+
+func (g *grpc) PingWebhookSubscription(ctx context.Context, id string, accountId int64) (*WebhookDelivery, error) {
+	response, err := g.webhooks.PingWebhookSubscription(ctx, &proto.WebhookSubscriptionRequest{Id: id, AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make([]WebhookAttempt, len(response.Attempts))
+	for i, attempt := range response.Attempts {
+		attempts[i] = WebhookAttempt{
+			Number:     attempt.Number,
+			StatusCode: attempt.StatusCode,
+			LatencyMs:  attempt.LatencyMs,
+			Response:   attempt.Response,
+			Error:      attempt.Error,
+			At:         attempt.At.AsTime(),
+		}
+	}
+	return &WebhookDelivery{
+		Id:             response.Id,
+		SubscriptionId: response.SubscriptionId,
+		Event:          response.Event,
+		Status:         response.Status,
+		Attempts:       attempts,
+		CreatedAt:      response.CreatedAt.AsTime(),
+	}, nil
+}
+
+func (g *grpc) DeleteWebhookSubscription(ctx context.Context, id string, accountId int64) error {
+	_, err := g.webhooks.DeleteWebhookSubscription(ctx, &proto.WebhookSubscriptionRequest{Id: id, AccountId: accountId})
+	return err
+}
+
+func protoToWebhookSubscription(subscription *proto.WebhookSubscription) *WebhookSubscription {
+	value := &WebhookSubscription{
+		Id:        subscription.Id,
+		AccountId: subscription.AccountId,
+		Url:       subscription.Url,
+		Events:    subscription.Events,
+		Enabled:   subscription.Enabled,
+		Secret:    subscription.Secret,
+		CreatedAt: subscription.CreatedAt.AsTime(),
+		UpdatedAt: subscription.UpdatedAt.AsTime(),
+	}
+	if subscription.PreviousSecretExpiresAt != nil {
+		expiresAt := subscription.PreviousSecretExpiresAt.AsTime()
+		value.PreviousSecretExpiresAt = &expiresAt
+	}
+	return value
+}
+
+func NewBackend(user proto.UserServiceClient, account proto.AccountServiceClient, keys proto.KeysServiceClient, pix proto.PixTransactionServiceClient, ledger proto.LedgerServiceClient, webhooks proto.WebhookServiceClient, risk proto.RiskServiceClient, med proto.MedServiceClient) Backend {
 	return &grpc{
-		user:    user,
-		account: account,
-		keys:    keys,
-		pix:     pix,
+		user:     user,
+		account:  account,
+		keys:     keys,
+		pix:      pix,
+		ledger:   ledger,
+		webhooks: webhooks,
+		risk:     risk,
+		med:      med,
+	}
+}
+
+func (g *grpc) ListPixLimits(ctx context.Context, accountId int64) ([]*PixLimit, error) {
+	response, err := g.pix.ListPixLimits(ctx, &proto.AccountRequest{AccountId: accountId})
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make([]*PixLimit, len(response.Limits))
+	for i := range response.Limits {
+		limits[i] = protoToPixLimit(response.Limits[i])
+	}
+	return limits, nil
+}
+
+func (g *grpc) RequestPixLimit(ctx context.Context, accountId int64, limitType string, amount float64) (*PixLimit, error) {
+	response, err := g.pix.RequestPixLimit(ctx, &proto.PixLimitRequest{AccountId: accountId, Type: limitType, Amount: amount})
+	if err != nil {
+		return nil, err
+	}
+	return protoToPixLimit(response), nil
+}
+
+func protoToPixLimit(limit *proto.PixLimit) *PixLimit {
+	pixLimit := &PixLimit{
+		Type:            limit.Type,
+		Amount:          limit.Amount,
+		RequestedAmount: limit.RequestedAmount,
+		Maximum:         limit.Maximum,
+	}
+	if limit.EffectiveAt != nil {
+		effectiveAt := limit.EffectiveAt.AsTime()
+		pixLimit.EffectiveAt = &effectiveAt
+	}
+	return pixLimit
+}
+
+func (g *grpc) ListReviews(ctx context.Context, status string, limit int) ([]*RiskAssessment, error) {
+	response, err := g.risk.ListReviews(ctx, &proto.ListReviewsRequest{Status: status, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+
+	assessments := make([]*RiskAssessment, len(response.Assessments))
+	for i := range response.Assessments {
+		assessments[i] = protoToRiskAssessment(response.Assessments[i])
+	}
+	return assessments, nil
+}
+
+func (g *grpc) ApproveReview(ctx context.Context, id, analyst, note string) (*PixTransaction, error) {
+	response, err := g.risk.ApproveReview(ctx, &proto.ReviewRequest{Id: id, Analyst: analyst, Note: note})
+	if err != nil {
+		return nil, err
+	}
+	return protoToPixTransaction(response), nil
+}
+
+func (g *grpc) RejectReview(ctx context.Context, id, analyst, note string) (*RiskAssessment, error) {
+	response, err := g.risk.RejectReview(ctx, &proto.ReviewRequest{Id: id, Analyst: analyst, Note: note})
+	if err != nil {
+		return nil, err
+	}
+	return protoToRiskAssessment(response), nil
+}
+
+func protoToRiskAssessment(assessment *proto.RiskAssessment) *RiskAssessment {
+	riskAssessment := &RiskAssessment{
+		Id:            assessment.Id,
+		TransactionId: assessment.TransactionId,
+		AccountId:     assessment.AccountId,
+		Receiver:      assessment.Receiver,
+		Amount:        assessment.Amount,
+		Score:         assessment.Score,
+		Decision:      assessment.Decision,
+		Reasons:       assessment.Reasons,
+		ReviewStatus:  assessment.ReviewStatus,
+		ReviewedBy:    assessment.ReviewedBy,
+		ReviewNote:    assessment.ReviewNote,
+		CreatedAt:     assessment.CreatedAt.AsTime(),
+	}
+	if assessment.ReviewedAt != nil {
+		reviewedAt := assessment.ReviewedAt.AsTime()
+		riskAssessment.ReviewedAt = &reviewedAt
+	}
+	return riskAssessment
+}
+
+func (g *grpc) ReportInfraction(ctx context.Context, accountId int64, transactionId, reason string) (*InfractionReport, error) {
+	response, err := g.med.ReportInfraction(ctx, &proto.InfractionReportRequest{AccountId: accountId, TransactionId: transactionId, Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	return protoToInfractionReport(response), nil
+}
+
+func (g *grpc) ListInfractionReports(ctx context.Context, accountId int64, status string, limit int) ([]*InfractionReport, error) {
+	response, err := g.med.ListInfractionReports(ctx, &proto.ListInfractionReportsRequest{AccountId: accountId, Status: status, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*InfractionReport, len(response.Reports))
+	for i := range response.Reports {
+		reports[i] = protoToInfractionReport(response.Reports[i])
+	}
+	return reports, nil
+}
+
+func (g *grpc) ResolveInfraction(ctx context.Context, id, analyst string, accept bool, note string) (*InfractionReport, error) {
+	response, err := g.med.ResolveInfraction(ctx, &proto.InfractionResolution{Id: id, Analyst: analyst, Accept: accept, Note: note})
+	if err != nil {
+		return nil, err
+	}
+	return protoToInfractionReport(response), nil
+}
+
+func protoToInfractionReport(report *proto.InfractionReport) *InfractionReport {
+	infractionReport := &InfractionReport{
+		Id:                  report.Id,
+		TransactionId:       report.TransactionId,
+		ReporterAccountId:   report.ReporterAccountId,
+		ReceiverAccountId:   report.ReceiverAccountId,
+		Amount:              report.Amount,
+		BlockedAmount:       report.BlockedAmount,
+		Reason:              report.Reason,
+		Status:              report.Status,
+		Analyst:             report.Analyst,
+		ResolutionNote:      report.ResolutionNote,
+		RefundTransactionId: report.RefundTransactionId,
+		AnalysisDueAt:       report.AnalysisDueAt.AsTime(),
+		CreatedAt:           report.CreatedAt.AsTime(),
+	}
+	if report.RefundDueAt != nil {
+		refundDueAt := report.RefundDueAt.AsTime()
+		infractionReport.RefundDueAt = &refundDueAt
+	}
+	if report.ResolvedAt != nil {
+		resolvedAt := report.ResolvedAt.AsTime()
+		infractionReport.ResolvedAt = &resolvedAt
 	}
+	return infractionReport
 }
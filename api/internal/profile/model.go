@@ -1,6 +1,9 @@
 package profile
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
 
 type WebhookStatus string
 
@@ -10,16 +13,161 @@ const (
 )
 
 type Webhook struct {
-	Sender   Account         `json:"sender"`
-	Receiver Account         `json:"receiver"`
-	Amount   decimal.Decimal `json:"amount"`
-	Status   WebhookStatus   `json:"status"`
+	TransactionId         string          `json:"transaction_id"`
+	OriginalTransactionId string          `json:"original_transaction_id,omitempty"`
+	Sender                Account         `json:"sender"`
+	Receiver              Account         `json:"receiver"`
+	Amount                decimal.Decimal `json:"amount"`
+	Status                WebhookStatus   `json:"status"`
+	ReceiverKey           string          `json:"receiver_key,omitempty"`
 }
 
 type PixTransaction struct {
-	AccountId   int64   `json:"account_id"`
-	ReceiverKey string  `json:"receiver_key"`
-	Amount      float64 `json:"amount"`
+	Id                    string     `json:"id,omitempty"`
+	AccountId             int64      `json:"account_id"`
+	ReceiverKey           string     `json:"receiver_key"`
+	Amount                float64    `json:"amount"`
+	Status                string     `json:"status,omitempty"`
+	IdempotencyKey        string     `json:"idempotency_key,omitempty"`
+	OriginalTransactionId string     `json:"original_transaction_id,omitempty"`
+	ExecuteAt             *time.Time `json:"execute_at,omitempty"`
+	CopyPaste             string     `json:"copy_paste,omitempty"`
+	TxID                  string     `json:"txid,omitempty"`
+	LookupToken           string     `json:"lookup_token,omitempty"`
+	Type                  string     `json:"type,omitempty"`
+	ReceiverAccount       int64      `json:"receiver_account,omitempty"`
+	RefundedAmount        float64    `json:"refunded_amount,omitempty"`
+	CreatedAt             *time.Time `json:"created_at,omitempty"`
+}
+
+// HistoryFilter narrows the transactions of an account. Direction is SENT,
+// RECEIVED or empty for both, and Cursor is the next_cursor of the previous
+// page.
+type HistoryFilter struct {
+	Direction string
+	Status    string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Cursor    string
+}
+
+// StatementRequest exports the statement of the days From to To, both
+// inclusive, in one of StatementFormats.
+type StatementRequest struct {
+	From   time.Time
+	To     time.Time
+	Format string
+}
+
+type StatementFormat struct {
+	ContentType string
+	Extension   string
+}
+
+var StatementFormats = map[string]StatementFormat{
+	"CSV": {ContentType: "text/csv; charset=utf-8", Extension: "csv"},
+	"OFX": {ContentType: "application/x-ofx", Extension: "ofx"},
+	"PDF": {ContentType: "application/pdf", Extension: "pdf"},
+}
+
+type TransactionHistory struct {
+	Transactions []*PixTransaction `json:"transactions"`
+	NextCursor   string            `json:"next_cursor,omitempty"`
+}
+
+// QRCode is a BR Code for a pix key. Payload is the "copia e cola" text.
+type QRCode struct {
+	Key          string  `json:"key"`
+	Amount       float64 `json:"amount,omitempty"`
+	TxID         string  `json:"txid,omitempty"`
+	MerchantName string  `json:"merchant_name"`
+	MerchantCity string  `json:"merchant_city"`
+	Description  string  `json:"description,omitempty"`
+	Payload      string  `json:"payload"`
+}
+
+// Charge is an immediate charge (cob), with the json of the BACEN Pix API.
+// The charge belongs to AccountId, which is taken from the query string.
+type Charge struct {
+	AccountId    int64          `json:"-"`
+	Calendar     ChargeCalendar `json:"calendario"`
+	TxID         string         `json:"txid,omitempty"`
+	Revision     int32          `json:"revisao"`
+	Status       string         `json:"status,omitempty"`
+	Debtor       *ChargeDebtor  `json:"devedor,omitempty"`
+	Value        ChargeValue    `json:"valor"`
+	Key          string         `json:"chave"`
+	PayerRequest string         `json:"solicitacaoPagador,omitempty"`
+	Pix          []ChargePix    `json:"pix,omitempty"`
+}
+
+// ChargeCalendar has Expiration for an immediate charge (cob), and DueDate
+// with ValidAfterDue days for a due date charge (cobv).
+type ChargeCalendar struct {
+	CreatedAt     *time.Time `json:"criacao,omitempty"`
+	Expiration    int64      `json:"expiracao,omitempty"`
+	DueDate       string     `json:"dataDeVencimento,omitempty"`
+	ValidAfterDue int32      `json:"validadeAposVencimento,omitempty"`
+}
+
+type ChargeDebtor struct {
+	Cpf  string `json:"cpf"`
+	Name string `json:"nome"`
+}
+
+// ChargeValue keeps amounts as decimal strings, as in "37.00". Final is the
+// amount due if the charge is paid today.
+type ChargeValue struct {
+	Original string          `json:"original"`
+	Final    string          `json:"final,omitempty"`
+	Fine     *ChargeModality `json:"multa,omitempty"`
+	Interest *ChargeModality `json:"juros,omitempty"`
+	Rebate   *ChargeModality `json:"abatimento,omitempty"`
+	Discount *ChargeDiscount `json:"desconto,omitempty"`
+}
+
+// Charge modalities as numbered by the BACEN Pix API.
+const (
+	ModalityFixed   = 1
+	ModalityPercent = 2
+)
+
+// ChargeModality is a fine, a daily interest or a rebate: a fixed value or a
+// percentage of the original value.
+type ChargeModality struct {
+	Modality int    `json:"modalidade"`
+	Value    string `json:"valorPerc"`
+}
+
+// ChargeDiscount is taken when paying until one of its dates.
+type ChargeDiscount struct {
+	Modality   int                  `json:"modalidade"`
+	FixedDates []ChargeDiscountDate `json:"descontoDataFixa"`
+}
+
+type ChargeDiscountDate struct {
+	Date  string `json:"data"`
+	Value string `json:"valorPerc"`
+}
+
+// ChargePix is the pix that paid the charge.
+type ChargePix struct {
+	EndToEndId string    `json:"endToEndId"`
+	Value      string    `json:"valor"`
+	PaidAt     time.Time `json:"horario"`
+}
+
+// ScheduledPix identifies a scheduled pix of an account.
+type ScheduledPix struct {
+	Id        string
+	AccountId int64
+}
+
+type PixRefund struct {
+	TransactionId string  `json:"-"`
+	AccountId     int64   `json:"account_id"`
+	Amount        float64 `json:"amount"`
 }
 
 type Account struct {
@@ -59,3 +207,115 @@ type Key struct {
 	Name      string `json:"name"`
 	Type      Type   `json:"type"`
 }
+
+// KeyLookup is the receiver of a key as shown to the payer. Sending the pix
+// with Token makes it fail if the key changed account meanwhile.
+type KeyLookup struct {
+	Key       string    `json:"key"`
+	Type      Type      `json:"type"`
+	Name      string    `json:"name"`
+	MaskedCpf string    `json:"masked_cpf"`
+	Bank      string    `json:"bank"`
+	Agency    string    `json:"agency"`
+	Token     string    `json:"lookup_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LookupLimit is how close a user or an account is to the key lookup limits,
+// for support staff. Tokens goes negative after lookups of missing keys.
+type LookupLimit struct {
+	Subject     string     `json:"subject"`
+	Tokens      float64    `json:"tokens"`
+	Misses      int        `json:"misses"`
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+// PixLimit is one of the TRANSACTION, DAILY, MONTHLY and NIGHTLY limits of an
+// account. An increase is only in force from effective_at.
+type PixLimit struct {
+	Type            string     `json:"type"`
+	Amount          float64    `json:"amount"`
+	RequestedAmount float64    `json:"requested_amount,omitempty"`
+	EffectiveAt     *time.Time `json:"effective_at,omitempty"`
+	Maximum         float64    `json:"maximum"`
+}
+
+// RiskAssessment is the decision the risk analysis took for a pix. Pix sent
+// to REVIEW wait for an analyst, review_status follows them.
+type RiskAssessment struct {
+	Id            string     `json:"id"`
+	TransactionId string     `json:"transaction_id"`
+	AccountId     int64      `json:"account_id"`
+	Receiver      string     `json:"receiver"`
+	Amount        float64    `json:"amount"`
+	Score         int32      `json:"score"`
+	Decision      string     `json:"decision"`
+	Reasons       []string   `json:"reasons"`
+	ReviewStatus  string     `json:"review_status,omitempty"`
+	ReviewedBy    string     `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote    string     `json:"review_note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// InfractionReport is a MED report of a fraudulent pix. BlockedAmount is what
+// could be blocked in the receiver account when it was opened.
+type InfractionReport struct {
+	Id                  string     `json:"id"`
+	TransactionId       string     `json:"transaction_id"`
+	ReporterAccountId   int64      `json:"reporter_account_id"`
+	ReceiverAccountId   int64      `json:"receiver_account_id"`
+	Amount              float64    `json:"amount"`
+	BlockedAmount       float64    `json:"blocked_amount"`
+	Reason              string     `json:"reason,omitempty"`
+	Status              string     `json:"status"`
+	Analyst             string     `json:"analyst,omitempty"`
+	ResolutionNote      string     `json:"resolution_note,omitempty"`
+	RefundTransactionId string     `json:"refund_transaction_id,omitempty"`
+	AnalysisDueAt       time.Time  `json:"analysis_due_at"`
+	RefundDueAt         *time.Time `json:"refund_due_at,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// WebhookEvents are the events a webhook subscription can listen to.
+var WebhookEvents = map[string]bool{
+	"pix.received":   true,
+	"pix.sent":       true,
+	"pix.failed":     true,
+	"refund.created": true,
+	"charge.paid":    true,
+}
+
+// WebhookSubscription sends the events of an account to Url. Secret is only
+// returned when the subscription is created and when its secret is rotated.
+type WebhookSubscription struct {
+	Id                      string     `json:"id"`
+	AccountId               int64      `json:"account_id"`
+	Url                     string     `json:"url"`
+	Events                  []string   `json:"events"`
+	Enabled                 bool       `json:"enabled"`
+	Secret                  string     `json:"secret,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+}
+
+type WebhookAttempt struct {
+	Number     int32     `json:"number"`
+	StatusCode int32     `json:"status_code,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+type WebhookDelivery struct {
+	Id             string           `json:"id"`
+	SubscriptionId string           `json:"subscription_id"`
+	Event          string           `json:"event"`
+	Status         string           `json:"status"`
+	Attempts       []WebhookAttempt `json:"attempts"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"log"
@@ -11,13 +12,27 @@ import (
 	"profile/internal/account"
 	"profile/internal/cfg"
 	"profile/internal/event"
+	"profile/internal/idempotency"
 	"profile/internal/key"
+	"profile/internal/keysync"
+	"profile/internal/ledger"
+	"profile/internal/limit"
+	"profile/internal/lookup"
+	"profile/internal/med"
+	"profile/internal/outbox"
+	"profile/internal/ratelimit"
+	"profile/internal/risk"
+	"profile/internal/statement"
 	"profile/internal/transaction"
 	"profile/internal/user"
+	"profile/internal/utils"
+	"profile/internal/webhook"
 	"profile/platform/kafka"
+	"profile/platform/redis"
 	"profile/platform/sqlserver"
 	proto "profile/proto/profile/v1"
 	transpb "profile/proto/transactions/v1"
+	"time"
 )
 
 func main() {
@@ -52,32 +67,116 @@ func main() {
 		log.Fatalf("Failed to connect to database %v", err)
 	}
 
+	redisClient := redis.StartRedis(config)
+
 	//kafka
 	kafkaConn := kafka.NewClient(config).Connect()
 
-	events := event.NewEvent(kafkaConn, "transaction_events_topic",
+	events := event.NewEvent(kafkaConn, transaction.PixEventsTopic,
+		event.WithAttempts(4), event.WithBroker("localhost:9092"))
+	keyEvents := event.NewEvent(kafkaConn, key.EventsTopic,
+		event.WithAttempts(4), event.WithBroker("localhost:9092"))
+	medEvents := event.NewEvent(kafkaConn, med.EventsTopic,
+		event.WithAttempts(4), event.WithBroker("localhost:9092"))
+	claimEvents := event.NewEvent(kafkaConn, key.ClaimsTopic,
 		event.WithAttempts(4), event.WithBroker("localhost:9092"))
 
 	transClient := transpb.NewKeysServiceClient(client)
+	transactionClient := transpb.NewTransactionServiceClient(client)
+	scheduleClient := transpb.NewScheduleServiceClient(client)
+	brcodeClient := transpb.NewBRCodeServiceClient(client)
+	chargeClient := transpb.NewChargeServiceClient(client)
+	webhookClient := transpb.NewWebhookServiceClient(client)
 
 	// repositories
 	userRepository := user.NewRepository(db, config)
 	keyRepository := key.NewRepository(db, config)
 	accountRepository := account.NewRepository(db, config)
+	ledgerRepository := ledger.NewRepository(db, config)
+	statementRepository := statement.NewRepository(db, config)
+	limitRepository := limit.NewRepository(db, config)
+	riskRepository := risk.NewRepository(db, config)
+	medRepository := med.NewRepository(db, config)
+	idempotencyRepository := idempotency.NewRepository(redisClient)
+	lookupRepository := lookup.NewRepository(redisClient, lookup.WithTTL(config.LookupConfig.TTL))
+	outboxRepository := outbox.NewRepository(db, config)
+	lookupLimiter := ratelimit.NewLimiter(redisClient,
+		ratelimit.WithBucket(config.LookupConfig.Capacity, config.LookupConfig.RefillPerMin),
+		ratelimit.WithPenalty(config.LookupConfig.MissPenalty, config.LookupConfig.MaxMisses, config.LookupConfig.MissWindow, config.LookupConfig.Lockout))
+	locker := redis.NewLocker(redisClient)
+
+	limitLocation, err := time.LoadLocation(config.LimitConfig.Location)
+	if err != nil {
+		log.Fatalf("Failed to load pix limit location %s: %v", config.LimitConfig.Location, err)
+	}
+
+	riskRules, err := risk.LoadRuleSet(config.RiskConfig.RulesPath)
+	if err != nil {
+		log.Fatalf("Failed to load risk rules %v", err)
+	}
 
 	// services
-	transactionService := transaction.NewService(config, events, accountRepository, transClient, userRepository)
+	riskService := risk.NewService(riskRepository, riskRules)
+	limitService := limit.NewService(limitRepository,
+		limit.WithDefaults(map[limit.Type]decimal.Decimal{
+			limit.TypeTransaction: decimal.NewFromFloat(config.LimitConfig.Transaction),
+			limit.TypeDaily:       decimal.NewFromFloat(config.LimitConfig.Daily),
+			limit.TypeMonthly:     decimal.NewFromFloat(config.LimitConfig.Monthly),
+			limit.TypeNightly:     decimal.NewFromFloat(config.LimitConfig.Nightly),
+		}),
+		limit.WithMaximums(map[limit.Type]decimal.Decimal{
+			limit.TypeTransaction: decimal.NewFromFloat(config.LimitConfig.MaxTransaction),
+			limit.TypeDaily:       decimal.NewFromFloat(config.LimitConfig.MaxDaily),
+			limit.TypeMonthly:     decimal.NewFromFloat(config.LimitConfig.MaxMonthly),
+			limit.TypeNightly:     decimal.NewFromFloat(config.LimitConfig.MaxNightly),
+		}),
+		limit.WithCoolingOff(config.LimitConfig.CoolingOff),
+		limit.WithNightWindow(config.LimitConfig.NightStart, config.LimitConfig.NightEnd, limitLocation))
+	ledgerService := ledger.NewService(ledgerRepository, ledger.WithHoldTimeout(config.LedgerConfig.HoldTimeout))
+	transactionService := transaction.NewService(config, accountRepository, transClient, transactionClient, scheduleClient, brcodeClient, chargeClient, userRepository, ledgerService, idempotencyRepository, lookupRepository, lookupLimiter, locker, limitService, riskService)
 	userService := user.NewService(userRepository)
+	statementService := statement.NewService(statementRepository, accountRepository)
+	webhookService := webhook.NewService(accountRepository, webhookClient)
 	keyService := key.NewService(keyRepository, transactionService, userRepository, accountRepository)
 	accountService := account.NewService(accountRepository)
+	medService := med.NewService(medRepository, ledgerService, transactionClient,
+		med.WithDeadlines(config.MedConfig.ReportWindow, config.MedConfig.AnalysisWindow, config.MedConfig.RefundWindow),
+		med.WithRetryBackoff(config.MedConfig.RetryBackoff))
+
+	outboxRelay := outbox.NewRelay(outboxRepository,
+		outbox.WithPublisher(transaction.PixEventsTopic, events),
+		outbox.WithPublisher(key.EventsTopic, keyEvents),
+		outbox.WithPublisher(med.EventsTopic, medEvents),
+		outbox.WithLocker(locker),
+		outbox.WithBatchSize(config.OutboxConfig.BatchSize),
+		outbox.WithMaxAttempts(config.OutboxConfig.MaxAttempts))
+
+	keyReconciler := keysync.NewReconciler(keyRepository, accountRepository, userRepository, outboxRepository, transClient,
+		keysync.WithBatchSize(config.KeySyncConfig.BatchSize),
+		keysync.WithRepair(config.KeySyncConfig.Repair))
+
+	// workers
+	go utils.Every(context.Background(), config.LedgerConfig.HoldSweepInterval, "hold_sweeper", ledgerService.ReleaseExpiredHolds)
+	go utils.Every(context.Background(), config.OutboxConfig.RelayInterval, "outbox_relay", outboxRelay.Run)
+	go utils.Every(context.Background(), config.KeySyncConfig.Interval, "key_reconciler", keyReconciler.Run)
+	go utils.Every(context.Background(), config.MedConfig.RetryInterval, "med_refunds", medService.RetryRefunds)
+	go func() {
+		if err := claimEvents.Consume(context.Background(), keyService.HandleClaim); err != nil {
+			log.Printf("key claims consumer stopped: %v", err)
+		}
+	}()
 
 	//server
-	profileServer := NewProfileService(userService, accountService, keyService, transactionService)
+	profileServer := NewProfileService(userService, accountService, keyService, transactionService, ledgerService, statementService, webhookService, limitService, riskService, medService)
 	server := grpc.NewServer()
 	proto.RegisterUserServiceServer(server, profileServer)
 	proto.RegisterAccountServiceServer(server, profileServer)
 	proto.RegisterKeysServiceServer(server, profileServer)
 	proto.RegisterPixTransactionServiceServer(server, profileServer)
+	proto.RegisterLedgerServiceServer(server, profileServer)
+	proto.RegisterWebhookServiceServer(server, profileServer)
+	proto.RegisterRiskServiceServer(server, profileServer)
+	proto.RegisterMedServiceServer(server, profileServer)
 
 	log.Printf("Serve is running  on port: %v", "9080")
 	if err := server.Serve(list); err != nil {
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 	"profile/internal/account"
+	"profile/internal/errutils"
 	"profile/internal/key"
+	"profile/internal/ledger"
+	"profile/internal/limit"
+	"profile/internal/med"
+	"profile/internal/risk"
+	"profile/internal/statement"
 	"profile/internal/transaction"
 	"profile/internal/user"
 	"profile/internal/utils"
+	"profile/internal/webhook"
 	"profile/proto/profile/v1"
 	"strconv"
 )
@@ -20,10 +29,20 @@ type ProfileServer struct {
 	account            account.Service
 	keys               key.Service
 	transactionService transaction.Service
+	ledger             ledger.Service
+	statements         statement.Service
+	webhooks           webhook.Service
+	limits             limit.Service
+	risk               risk.Service
+	med                med.Service
 	profile.UnimplementedUserServiceServer
 	profile.UnimplementedAccountServiceServer
 	profile.UnimplementedKeysServiceServer
 	profile.UnimplementedPixTransactionServiceServer
+	profile.UnimplementedLedgerServiceServer
+	profile.UnimplementedWebhookServiceServer
+	profile.UnimplementedRiskServiceServer
+	profile.UnimplementedMedServiceServer
 }
 
 type EmailAlreadyExist struct {
@@ -50,21 +69,44 @@ func (e *PhoneAlreadyExist) Error() string {
 	return e.Message
 }
 
-func (p ProfileServer) SendPix(ctx context.Context, pixEvent *profile.PixTransaction) (*empty.Empty, error) {
-	err := p.transactionService.SendPix(ctx, transaction.ProtoToPix(pixEvent))
+func (p ProfileServer) SendPix(ctx context.Context, pixEvent *profile.PixTransaction) (*profile.PixTransaction, error) {
+	sent, err := p.transactionService.SendPix(ctx, transaction.ProtoToPix(pixEvent))
 	if err != nil {
+		// a scheduled pix is validated by the transaction service
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
 		switch err {
+		case errutils.ErrIdempotencyKeyMismatch:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errutils.ErrIdempotencyKeyInProgress, errutils.ErrLockLost:
+			return nil, status.Error(codes.Aborted, err.Error())
+		case errutils.ErrAmountMismatch, errutils.ErrInvalidAmount, errutils.ErrLookupMismatch:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errutils.ErrLookupNotFound:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errutils.ErrPixDenied:
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errutils.ErrTransactionLimitExceeded, errutils.ErrDailyLimitExceeded,
+			errutils.ErrMonthlyLimitExceeded, errutils.ErrNightlyLimitExceeded:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
 	}
-	return &empty.Empty{}, nil
+	return transaction.ToProto(sent), nil
 }
 
 func (p ProfileServer) PixWebhook(ctx context.Context, webhook *profile.Webhook) (*empty.Empty, error) {
 	err := p.transactionService.PixWebhook(ctx, transaction.ProtoToWebhook(webhook))
 	if err != nil {
 		switch err {
+		case errutils.ErrInsufficientBalance, errutils.ErrReceiverAccountBlocked,
+			errutils.ErrTransactionLimitExceeded, errutils.ErrDailyLimitExceeded,
+			errutils.ErrMonthlyLimitExceeded, errutils.ErrNightlyLimitExceeded, errutils.ErrPixInReview:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errutils.ErrPixDenied:
+			return nil, status.Error(codes.PermissionDenied, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -72,6 +114,144 @@ func (p ProfileServer) PixWebhook(ctx context.Context, webhook *profile.Webhook)
 	return &empty.Empty{}, nil
 }
 
+func (p ProfileServer) RefundPix(ctx context.Context, refund *profile.PixRefund) (*profile.PixTransaction, error) {
+	refunded, err := p.transactionService.RefundPix(ctx, transaction.ProtoToRefund(refund))
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		switch err {
+		case errutils.ErrReceiverAccountBlocked:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return transaction.ToProto(refunded), nil
+}
+
+func (p ProfileServer) ListScheduledPix(ctx context.Context, req *profile.AccountRequest) (*profile.ListPixTransactions, error) {
+	scheduled, err := p.transactionService.ListScheduledPix(ctx, req.GetAccountId())
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.ToProtoList(scheduled), nil
+}
+
+func (p ProfileServer) ListAccountTransactions(ctx context.Context, req *profile.TransactionHistoryRequest) (*profile.TransactionHistory, error) {
+	history, err := p.transactionService.ListAccountTransactions(ctx, transaction.ProtoToHistoryRequest(req))
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.HistoryToProto(history), nil
+}
+
+func (p ProfileServer) CancelScheduledPix(ctx context.Context, req *profile.ScheduledPixRequest) (*profile.PixTransaction, error) {
+	canceled, err := p.transactionService.CancelScheduledPix(ctx, req.GetId(), req.GetAccountId())
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.ToProto(canceled), nil
+}
+
+func (p ProfileServer) CreateQRCode(ctx context.Context, req *profile.QRCode) (*profile.QRCode, error) {
+	code, err := p.transactionService.CreateQRCode(ctx, transaction.ProtoToQRCode(req))
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.QRCodeToProto(code), nil
+}
+
+func (p ProfileServer) ParseQRCode(ctx context.Context, req *profile.QRCode) (*profile.QRCode, error) {
+	code, err := p.transactionService.ParseQRCode(ctx, req.GetPayload())
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.QRCodeToProto(code), nil
+}
+
+func (p ProfileServer) CreateCharge(ctx context.Context, req *profile.PixCharge) (*profile.PixCharge, error) {
+	created, err := p.transactionService.CreateCharge(ctx, transaction.ProtoToCharge(req))
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.ChargeToProto(created), nil
+}
+
+func (p ProfileServer) FindCharge(ctx context.Context, req *profile.PixChargeRequest) (*profile.PixCharge, error) {
+	found, err := p.transactionService.FindCharge(ctx, req.GetTxid(), req.GetAccountId())
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.ChargeToProto(found), nil
+}
+
+func (p ProfileServer) ListCharges(ctx context.Context, req *profile.AccountRequest) (*profile.ListPixCharges, error) {
+	charges, err := p.transactionService.ListCharges(ctx, req.GetAccountId())
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.ChargesToProto(charges), nil
+}
+
+func (p ProfileServer) CancelCharge(ctx context.Context, req *profile.PixChargeRequest) (*profile.PixCharge, error) {
+	canceled, err := p.transactionService.CancelCharge(ctx, req.GetTxid(), req.GetAccountId())
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.ChargeToProto(canceled), nil
+}
+
+func (p ProfileServer) ListPixLimits(ctx context.Context, req *profile.AccountRequest) (*profile.PixLimits, error) {
+	limits, err := p.limits.List(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return limit.ToProtoList(limits), nil
+}
+
+func (p ProfileServer) RequestPixLimit(ctx context.Context, req *profile.PixLimitRequest) (*profile.PixLimit, error) {
+	requested, err := p.limits.Request(ctx, limit.ProtoToRequest(req))
+	if err != nil {
+		switch err {
+		case errutils.ErrInvalidLimit, errutils.ErrLimitAboveMaximum:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errutils.ErrLimitConflict:
+			return nil, status.Error(codes.Aborted, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return limit.ToProto(requested), nil
+}
+
 func (p ProfileServer) CreateAccount(ctx context.Context, ac *profile.Account) (*profile.AccountResponse, error) {
 	if ac.UserId == "" {
 		return nil, errors.New("user_id is required")
@@ -256,7 +436,15 @@ func (p ProfileServer) DeleteUser(ctx context.Context, request *profile.UserRequ
 func (p ProfileServer) CreateKey(ctx context.Context, req *profile.Key) (*profile.KeyResponse, error) {
 	created, err := p.keys.CreateKey(key.ProtoToKey(req))
 	if err != nil {
+		// the transaction service validates the key as well
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
 		switch err {
+		case errutils.ErrInvalidKey, errutils.ErrKeyNotOwned:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errutils.ErrKeyLimitReached:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -275,6 +463,14 @@ func (p ProfileServer) UpdateKey(ctx context.Context, req *profile.Key) (*empty.
 	_, err := p.keys.UpdateKey(toUpdate)
 	if err != nil {
 		switch err {
+		case errutils.ErrInvalidKey, errutils.ErrKeyNotOwned:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errutils.ErrKeyNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errutils.ErrKeyAlreadyExists:
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case errutils.ErrKeyConflict:
+			return nil, status.Error(codes.Aborted, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -302,6 +498,10 @@ func (p ProfileServer) DeleteKey(ctx context.Context, req *profile.KeyRequest) (
 	err := p.keys.DeleteKey(key.ProtoToKeyRequest(req))
 	if err != nil {
 		switch err {
+		case errutils.ErrKeyNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errutils.ErrKeyConflict:
+			return nil, status.Error(codes.Aborted, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -309,11 +509,301 @@ func (p ProfileServer) DeleteKey(ctx context.Context, req *profile.KeyRequest) (
 	return &empty.Empty{}, nil
 }
 
-func NewProfileService(userService user.Service, accountService account.Service, keyService key.Service, transactionService transaction.Service) *ProfileServer {
+func (p ProfileServer) LookupKey(ctx context.Context, req *profile.LookupKeyRequest) (*profile.KeyLookup, error) {
+	found, err := p.transactionService.LookupKey(ctx, req.Key, req.AccountId)
+	if err != nil {
+		switch err {
+		case errutils.ErrKeyNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errutils.ErrLookupRateLimited:
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		case errutils.ErrLookupLocked:
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return transaction.LookupToProto(found), nil
+}
+
+func (p ProfileServer) LookupLimits(ctx context.Context, req *profile.LookupLimitsRequest) (*profile.ListLookupLimits, error) {
+	if req.UserId == "" && req.AccountId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id or account_id is required")
+	}
+	limits, err := p.transactionService.LookupLimits(ctx, req.UserId, req.AccountId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return transaction.LookupLimitsToProto(limits), nil
+}
+
+func (p ProfileServer) UnlockLookups(ctx context.Context, req *profile.LookupLimitsRequest) (*empty.Empty, error) {
+	if req.UserId == "" && req.AccountId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id or account_id is required")
+	}
+	if err := p.transactionService.UnlockLookups(ctx, req.UserId, req.AccountId); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &empty.Empty{}, nil
+}
+
+func (p ProfileServer) Adjust(ctx context.Context, req *profile.LedgerAdjustment) (*profile.LedgerEntry, error) {
+	if req.AccountId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "account_id is required")
+	}
+	entry, err := p.ledger.Adjust(ctx, ledger.ProtoToAdjustment(req))
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+	return ledger.ToProto(entry), nil
+}
+
+func (p ProfileServer) Reverse(ctx context.Context, req *profile.LedgerReversal) (*profile.LedgerEntry, error) {
+	entry, err := p.ledger.Reverse(ctx, req.TransactionId, req.Description)
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+	return ledger.ToProto(entry), nil
+}
+
+func (p ProfileServer) ListEntries(ctx context.Context, req *profile.AccountRequest) (*profile.ListLedgerEntries, error) {
+	if req.AccountId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "account_id is required")
+	}
+	entries, err := p.ledger.ListEntries(ctx, req.AccountId)
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+
+	found := make([]*profile.LedgerEntry, len(entries))
+	for i := range entries {
+		found[i] = ledger.ToProto(entries[i])
+	}
+	return &profile.ListLedgerEntries{Entries: found}, nil
+}
+
+func (p ProfileServer) Reconcile(ctx context.Context, req *profile.AccountRequest) (*profile.Reconciliation, error) {
+	if req.AccountId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "account_id is required")
+	}
+	reconciliation, err := p.ledger.Reconcile(ctx, req.AccountId)
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+	return ledger.ToProtoReconciliation(reconciliation), nil
+}
+
+// statementChunkSize is how much of a statement is buffered before it is sent
+// as one message of the stream.
+const statementChunkSize = 32 * 1024
+
+type statementStream struct {
+	stream profile.LedgerService_ExportStatementServer
+}
+
+func (s statementStream) Write(data []byte) (int, error) {
+	if err := s.stream.Send(&profile.StatementChunk{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (p ProfileServer) ExportStatement(req *profile.StatementRequest, stream profile.LedgerService_ExportStatementServer) error {
+	request, err := statement.ProtoToRequest(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	w := bufio.NewWriterSize(statementStream{stream: stream}, statementChunkSize)
+	if _, err = p.statements.Export(stream.Context(), request, w); err != nil {
+		switch {
+		case errors.Is(err, errutils.ErrInvalidStatementPeriod):
+			return status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return status.Error(codes.NotFound, "account not found")
+		default:
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+func ledgerError(err error) error {
+	switch err {
+	case errutils.ErrInvalidAmount, errutils.ErrUnbalancedEntry, errutils.ErrTransactionIDRequired:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errutils.ErrEntryNotFound, errutils.ErrInactiveAccount:
+		return status.Error(codes.NotFound, err.Error())
+	case errutils.ErrEntryAlreadyPosted:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errutils.ErrInsufficientBalance:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (p ProfileServer) CreateWebhookSubscription(ctx context.Context, req *profile.WebhookSubscription) (*profile.WebhookSubscription, error) {
+	subscription, err := p.webhooks.Subscribe(ctx, webhook.ProtoToSubscription(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.ToProto(subscription), nil
+}
+
+func (p ProfileServer) ListWebhookSubscriptions(ctx context.Context, req *profile.AccountRequest) (*profile.WebhookSubscriptions, error) {
+	subscriptions, err := p.webhooks.List(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.ListToProto(subscriptions), nil
+}
+
+func (p ProfileServer) SetWebhookSubscriptionEnabled(ctx context.Context, req *profile.WebhookSubscriptionRequest) (*profile.WebhookSubscription, error) {
+	subscription, err := p.webhooks.SetEnabled(ctx, webhook.ProtoToRequest(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.ToProto(subscription), nil
+}
+
+func (p ProfileServer) RotateWebhookSecret(ctx context.Context, req *profile.WebhookSubscriptionRequest) (*profile.WebhookSubscription, error) {
+	subscription, err := p.webhooks.RotateSecret(ctx, webhook.ProtoToRequest(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.ToProto(subscription), nil
+}
+
+func (p ProfileServer) PingWebhookSubscription(ctx context.Context, req *profile.WebhookSubscriptionRequest) (*profile.WebhookDelivery, error) {
+	delivery, err := p.webhooks.Ping(ctx, webhook.ProtoToRequest(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.DeliveryToProto(delivery), nil
+}
+
+func (p ProfileServer) DeleteWebhookSubscription(ctx context.Context, req *profile.WebhookSubscriptionRequest) (*empty.Empty, error) {
+	if err := p.webhooks.Unsubscribe(ctx, webhook.ProtoToRequest(req)); err != nil {
+		return nil, webhookError(err)
+	}
+	return &empty.Empty{}, nil
+}
+
+// webhookError keeps the status of the transaction service, which validates
+// and owns the subscriptions.
+func webhookError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch err {
+	case errutils.ErrInactiveAccount:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (p ProfileServer) ListReviews(ctx context.Context, req *profile.ListReviewsRequest) (*profile.RiskAssessments, error) {
+	assessments, err := p.risk.ListReviews(ctx, risk.ReviewStatus(req.GetStatus()), int(req.GetLimit()))
+	if err != nil {
+		return nil, riskError(err)
+	}
+	return risk.ToProtoList(assessments), nil
+}
+
+func (p ProfileServer) ApproveReview(ctx context.Context, req *profile.ReviewRequest) (*profile.PixTransaction, error) {
+	sent, err := p.transactionService.ApproveReview(ctx, risk.ProtoToReviewRequest(req))
+	if err != nil {
+		return nil, riskError(err)
+	}
+	return transaction.ToProto(sent), nil
+}
+
+func (p ProfileServer) RejectReview(ctx context.Context, req *profile.ReviewRequest) (*profile.RiskAssessment, error) {
+	rejected, err := p.risk.Reject(ctx, risk.ProtoToReviewRequest(req))
+	if err != nil {
+		return nil, riskError(err)
+	}
+	return risk.ToProto(rejected), nil
+}
+
+// riskError also maps the errors of the approved pix, which is sent as the
+// review is approved.
+func riskError(err error) error {
+	switch err {
+	case errutils.ErrAnalystRequired:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errutils.ErrAssessmentNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errutils.ErrReviewResolved, errutils.ErrInsufficientBalance, errutils.ErrReceiverAccountBlocked,
+		errutils.ErrTransactionLimitExceeded, errutils.ErrDailyLimitExceeded,
+		errutils.ErrMonthlyLimitExceeded, errutils.ErrNightlyLimitExceeded:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (p ProfileServer) ReportInfraction(ctx context.Context, req *profile.InfractionReportRequest) (*profile.InfractionReport, error) {
+	report, err := p.med.Report(ctx, med.ProtoToReportRequest(req))
+	if err != nil {
+		return nil, medError(err)
+	}
+	return med.ToProto(report), nil
+}
+
+func (p ProfileServer) ListInfractionReports(ctx context.Context, req *profile.ListInfractionReportsRequest) (*profile.InfractionReports, error) {
+	reports, err := p.med.List(ctx, med.ProtoToListRequest(req))
+	if err != nil {
+		return nil, medError(err)
+	}
+	return med.ToProtoList(reports), nil
+}
+
+func (p ProfileServer) ResolveInfraction(ctx context.Context, req *profile.InfractionResolution) (*profile.InfractionReport, error) {
+	report, err := p.med.Resolve(ctx, med.ProtoToResolution(req))
+	if err != nil {
+		return nil, medError(err)
+	}
+	return med.ToProto(report), nil
+}
+
+func medError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch err {
+	case errutils.ErrTransactionIDRequired, errutils.ErrAnalystRequired:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errutils.ErrReportNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errutils.ErrNotTransactionSender:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errutils.ErrReportAlreadyOpen:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errutils.ErrReportResolved, errutils.ErrReportDeadlinePassed, errutils.ErrTransactionNotReportable,
+		errutils.ErrInactiveAccount:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func NewProfileService(userService user.Service, accountService account.Service, keyService key.Service, transactionService transaction.Service, ledgerService ledger.Service, statementService statement.Service, webhookService webhook.Service, limitService limit.Service, riskService risk.Service, medService med.Service) *ProfileServer {
 	return &ProfileServer{
 		user:               userService,
 		account:            accountService,
 		keys:               keyService,
 		transactionService: transactionService,
+		ledger:             ledgerService,
+		statements:         statementService,
+		webhooks:           webhookService,
+		limits:             limitService,
+		risk:               riskService,
+		med:                medService,
 	}
 }
@@ -9,15 +9,21 @@ import (
 )
 
 type Account struct {
-	Id        int64           `gorm:"primaryKey;type:varchar(36);column:id"`
-	UserID    string          `gorm:"foreignKey;type:varchar(36);column:user_id"`
-	Balance   decimal.Decimal `gorm:"type:decimal(10,2);column:balance"`
-	Agency    string          `gorm:"type:varchar(100);column:agency"`
-	Bank      string          `gorm:"type:varchar(100);column:bank"`
-	CreatedAt time.Time       `gorm:"type:datetime;column:created_at"`
-	UpdatedAt time.Time       `gorm:"type:datetime;column:updated_at"`
-	DeletedAt gorm.DeletedAt  `gorm:"index;type:datetime;column:deleted_at"`
-	BlockedAt *time.Time      `gorm:"type:datetime;column:blocked_at"`
+	Id         int64           `gorm:"primaryKey;type:varchar(36);column:id"`
+	UserID     string          `gorm:"foreignKey;type:varchar(36);column:user_id"`
+	Balance    decimal.Decimal `gorm:"type:decimal(10,2);column:balance"`
+	HeldAmount decimal.Decimal `gorm:"type:decimal(10,2);column:held_amount"`
+	Agency     string          `gorm:"type:varchar(100);column:agency"`
+	Bank       string          `gorm:"type:varchar(100);column:bank"`
+	CreatedAt  time.Time       `gorm:"type:datetime;column:created_at"`
+	UpdatedAt  time.Time       `gorm:"type:datetime;column:updated_at"`
+	DeletedAt  gorm.DeletedAt  `gorm:"index;type:datetime;column:deleted_at"`
+	BlockedAt  *time.Time      `gorm:"type:datetime;column:blocked_at"`
+}
+
+// Available is the ledger balance minus what is reserved by pending pix holds.
+func (a *Account) Available() decimal.Decimal {
+	return a.Balance.Sub(a.HeldAmount)
 }
 
 type AccountRequest struct {
@@ -47,11 +53,13 @@ func ProtoToAccount(account *proto.Account) *Account {
 func ToProto(account *Account) *proto.AccountResponse {
 	balance, _ := account.Balance.Float64()
 	return &proto.AccountResponse{
-		Id:      account.Id,
-		UserId:  account.UserID,
-		Balance: balance,
-		Agency:  account.Agency,
-		Bank:    account.Bank,
+		Id:               account.Id,
+		UserId:           account.UserID,
+		Balance:          balance,
+		Agency:           account.Agency,
+		Bank:             account.Bank,
+		HeldAmount:       account.HeldAmount.InexactFloat64(),
+		AvailableBalance: account.Available().InexactFloat64(),
 	}
 }
 
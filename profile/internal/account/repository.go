@@ -3,8 +3,11 @@ package account
 import (
 	"context"
 	"database/sql"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"profile/internal/cfg"
+	"profile/internal/ledger"
 	"sync"
 	"time"
 )
@@ -14,10 +17,10 @@ type Repository interface {
 	FindAccountById(id int64) (*Account, error)
 	UpdateAccount(account *Account) (*Account, error)
 	ListAccount(accountIDs []int64) ([]*Account, error)
+	ListAccountIDs(ctx context.Context, after int64, limit int) ([]int64, error)
 	DeleteAccount(id int64) error
 	FindByKey(key string) (*Account, error)
 	IsAccountActive(ctx context.Context, id int64) (bool, error)
-	UpdateAccounts(accounts []*Account) error
 }
 
 type repository struct {
@@ -30,13 +33,28 @@ var (
 	idMutex   sync.Mutex
 )
 
+// CreateAccount opens the account with a zero balance and posts the initial
+// balance as a journal adjustment, so the ledger accounts for every cent.
 func (r repository) CreateAccount(account *Account) (*Account, error) {
 	account.CreatedAt = time.Now()
 	account.UpdatedAt = time.Now()
-	err := r.db.Create(&account).Error
+	opening := account.Balance
+	account.Balance = decimal.Zero
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&account).Error; err != nil {
+			return err
+		}
+		if opening.IsZero() {
+			return nil
+		}
+		entry := ledger.NewTransfer(uuid.New().String(), ledger.TypeAdjustment, ledger.SystemAccountID, account.Id, opening, "opening balance")
+		return ledger.NewRepository(tx, r.cfg).Post(context.Background(), entry)
+	})
 	if err != nil {
 		return nil, err
 	}
+	account.Balance = opening
 	return account, nil
 }
 
@@ -51,28 +69,13 @@ func (r repository) FindAccountById(id int64) (*Account, error) {
 
 func (r repository) UpdateAccount(account *Account) (*Account, error) {
 	account.UpdatedAt = time.Now()
-	result := r.db.Save(&account)
+	result := r.db.Omit("balance", "held_amount").Save(&account)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return account, nil
 }
 
-func (r repository) UpdateAccounts(accounts []*Account) error {
-	tx := r.db.Begin()
-	now := time.Now()
-	for _, account := range accounts {
-		account.UpdatedAt = now
-		result := tx.Save(&account)
-		if result.Error != nil {
-			tx.Rollback()
-			return result.Error
-		}
-	}
-	tx.Commit()
-	return nil
-}
-
 func (r repository) ListAccount(ids []int64) ([]*Account, error) {
 	var listAccount []*Account
 	if err := r.db.Where("id IN (?)", ids).Find(&listAccount).Error; err != nil {
@@ -81,6 +84,20 @@ func (r repository) ListAccount(ids []int64) ([]*Account, error) {
 	return listAccount, nil
 }
 
+// ListAccountIDs pages through the account ids in order, starting after after.
+func (r repository) ListAccountIDs(ctx context.Context, after int64, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&Account{}).
+		Where("id > ?", after).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (r repository) DeleteAccount(id int64) error {
 	return r.db.Delete(&Account{}, "id = ?", id).Error
 }
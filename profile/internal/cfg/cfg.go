@@ -2,7 +2,9 @@ package cfg
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type SqlServerConfig struct {
@@ -27,12 +29,88 @@ type WebhookConfig struct {
 	Url string
 }
 
+type LedgerConfig struct {
+	HoldTimeout       time.Duration
+	HoldSweepInterval time.Duration
+}
+
+type OutboxConfig struct {
+	RelayInterval time.Duration
+	BatchSize     int
+	MaxAttempts   int
+}
+
+// KeySyncConfig drives the job that compares the keys with the copy in the
+// transaction service. Drift is only logged unless Repair is set.
+type KeySyncConfig struct {
+	Interval  time.Duration
+	BatchSize int
+	Repair    bool
+}
+
+// LookupConfig sets how long a key lookup token can be used to send a pix,
+// and how many lookups a user and an account can make. A lookup of a key that
+// does not exist costs MissPenalty tokens, and MaxMisses of them within
+// MissWindow lock the user or account out of lookups for Lockout.
+type LookupConfig struct {
+	TTL          time.Duration
+	Capacity     int
+	RefillPerMin int
+	MissPenalty  int
+	MaxMisses    int
+	MissWindow   time.Duration
+	Lockout      time.Duration
+}
+
+// LimitConfig holds the pix limits of accounts that never changed them and
+// the most a user can raise each of them to. Increases take effect after
+// CoolingOff, and the nightly limit applies from NightStart to NightEnd,
+// hours in Location.
+type LimitConfig struct {
+	Transaction    float64
+	Daily          float64
+	Monthly        float64
+	Nightly        float64
+	MaxTransaction float64
+	MaxDaily       float64
+	MaxMonthly     float64
+	MaxNightly     float64
+	CoolingOff     time.Duration
+	NightStart     int
+	NightEnd       int
+	Location       string
+}
+
+// RiskConfig points to the JSON rule set of the risk analysis, the rule set
+// shipped with the service is used when RulesPath is empty.
+type RiskConfig struct {
+	RulesPath string
+}
+
+// MedConfig holds the deadlines of infraction reports: how old a pix can be
+// reported, how long analysts have to resolve a report and how long an
+// accepted one has to be refunded, retried every RetryBackoff.
+type MedConfig struct {
+	ReportWindow   time.Duration
+	AnalysisWindow time.Duration
+	RefundWindow   time.Duration
+	RetryInterval  time.Duration
+	RetryBackoff   time.Duration
+}
+
 // Config is the struct that holds all the configuration for the application
 type Config struct {
 	SqlServerConfig SqlServerConfig
 	KafkaConfig     KafkaConfig
 	RedisConfig     RedisConfig
 	WebhookConfig   WebhookConfig
+	LedgerConfig    LedgerConfig
+	OutboxConfig    OutboxConfig
+	KeySyncConfig   KeySyncConfig
+	LookupConfig    LookupConfig
+	LimitConfig     LimitConfig
+	RiskConfig      RiskConfig
+	MedConfig       MedConfig
 }
 
 func Load() (*Config, error) {
@@ -56,6 +134,53 @@ func Load() (*Config, error) {
 		WebhookConfig{
 			Url: Getenv("WEBHOOK_URL", "http://localhost:9060/profile/v1/webhook"),
 		},
+		LedgerConfig{
+			HoldTimeout:       GetDuration("HOLD_TIMEOUT", 30*time.Minute),
+			HoldSweepInterval: GetDuration("HOLD_SWEEP_INTERVAL", time.Minute),
+		},
+		OutboxConfig{
+			RelayInterval: GetDuration("OUTBOX_RELAY_INTERVAL", time.Second),
+			BatchSize:     GetInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts:   GetInt("OUTBOX_MAX_ATTEMPTS", 10),
+		},
+		KeySyncConfig{
+			Interval:  GetDuration("KEY_SYNC_INTERVAL", 10*time.Minute),
+			BatchSize: GetInt("KEY_SYNC_BATCH_SIZE", 100),
+			Repair:    GetBool("KEY_SYNC_REPAIR", false),
+		},
+		LookupConfig{
+			TTL:          GetDuration("LOOKUP_TTL", 5*time.Minute),
+			Capacity:     GetInt("LOOKUP_LIMIT_CAPACITY", 20),
+			RefillPerMin: GetInt("LOOKUP_LIMIT_REFILL_PER_MIN", 10),
+			MissPenalty:  GetInt("LOOKUP_LIMIT_MISS_PENALTY", 5),
+			MaxMisses:    GetInt("LOOKUP_LIMIT_MAX_MISSES", 10),
+			MissWindow:   GetDuration("LOOKUP_LIMIT_MISS_WINDOW", time.Hour),
+			Lockout:      GetDuration("LOOKUP_LIMIT_LOCKOUT", 24*time.Hour),
+		},
+		LimitConfig{
+			Transaction:    GetFloat("PIX_LIMIT_TRANSACTION", 5000),
+			Daily:          GetFloat("PIX_LIMIT_DAILY", 10000),
+			Monthly:        GetFloat("PIX_LIMIT_MONTHLY", 100000),
+			Nightly:        GetFloat("PIX_LIMIT_NIGHTLY", 1000),
+			MaxTransaction: GetFloat("PIX_LIMIT_MAX_TRANSACTION", 50000),
+			MaxDaily:       GetFloat("PIX_LIMIT_MAX_DAILY", 100000),
+			MaxMonthly:     GetFloat("PIX_LIMIT_MAX_MONTHLY", 1000000),
+			MaxNightly:     GetFloat("PIX_LIMIT_MAX_NIGHTLY", 10000),
+			CoolingOff:     GetDuration("PIX_LIMIT_COOLING_OFF", 24*time.Hour),
+			NightStart:     GetInt("PIX_LIMIT_NIGHT_START", 20),
+			NightEnd:       GetInt("PIX_LIMIT_NIGHT_END", 6),
+			Location:       Getenv("PIX_LIMIT_LOCATION", "America/Sao_Paulo"),
+		},
+		RiskConfig{
+			RulesPath: Getenv("RISK_RULES_PATH", ""),
+		},
+		MedConfig{
+			ReportWindow:   GetDuration("MED_REPORT_WINDOW", 80*24*time.Hour),
+			AnalysisWindow: GetDuration("MED_ANALYSIS_WINDOW", 7*24*time.Hour),
+			RefundWindow:   GetDuration("MED_REFUND_WINDOW", 96*time.Hour),
+			RetryInterval:  GetDuration("MED_RETRY_INTERVAL", time.Minute),
+			RetryBackoff:   GetDuration("MED_RETRY_BACKOFF", 10*time.Minute),
+		},
 	}, nil
 }
 
@@ -65,3 +190,31 @@ func Getenv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func GetInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func GetFloat(key string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func GetBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
@@ -3,12 +3,52 @@ package errutils
 import "errors"
 
 var (
-	ErrInsufficientBalance    = errors.New("insufficient balance")
-	ErrKeyNotFound            = errors.New("key not found in the database")
-	ErrInvalidKey             = errors.New("invalid key")
-	ErrInactiveAccount        = errors.New("inactive account")
-	ErrReceiverAccountBlocked = errors.New("account blocked")
-	EmailAlreadyExists        = errors.New("email already exists")
-	CpfAlreadyExists          = errors.New("cpf already exists")
-	PhoneAlreadyExists        = errors.New("phone already exists")
+	ErrInsufficientBalance      = errors.New("insufficient balance")
+	ErrKeyNotFound              = errors.New("key not found in the database")
+	ErrInvalidKey               = errors.New("invalid key")
+	ErrInactiveAccount          = errors.New("inactive account")
+	ErrReceiverAccountBlocked   = errors.New("account blocked")
+	EmailAlreadyExists          = errors.New("email already exists")
+	CpfAlreadyExists            = errors.New("cpf already exists")
+	PhoneAlreadyExists          = errors.New("phone already exists")
+	ErrInvalidAmount            = errors.New("invalid amount")
+	ErrTransactionIDRequired    = errors.New("transaction_id is required")
+	ErrUnbalancedEntry          = errors.New("journal entry debits and credits do not match")
+	ErrEntryAlreadyPosted       = errors.New("journal entry already posted")
+	ErrEntryNotFound            = errors.New("journal entry not found")
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still in progress")
+	ErrUnknownTopic             = errors.New("no publisher registered for topic")
+	ErrAmountMismatch           = errors.New("amount does not match the qr code amount")
+	ErrKeyNotOwned              = errors.New("cpf key must be the account holder cpf")
+	ErrKeyLimitReached          = errors.New("account reached the maximum number of keys")
+	ErrKeyAlreadyExists         = errors.New("key already exists")
+	ErrKeyConflict              = errors.New("key was changed concurrently")
+	ErrLookupNotFound           = errors.New("lookup token not found or expired")
+	ErrLookupMismatch           = errors.New("lookup token does not match the pix")
+	ErrLookupRateLimited        = errors.New("too many key lookups, try again later")
+	ErrLookupLocked             = errors.New("key lookups are locked for this user or account")
+	ErrInvalidStatementFormat   = errors.New("statement format must be CSV, OFX or PDF")
+	ErrInvalidStatementPeriod   = errors.New("statement period must end after it starts and span at most a year")
+	ErrInvalidLimit             = errors.New("limit must have a known type and a positive amount")
+	ErrLimitAboveMaximum        = errors.New("limit is above the maximum allowed")
+	ErrLimitConflict            = errors.New("limit was changed concurrently")
+	ErrTransactionLimitExceeded = errors.New("amount is above the per transaction limit")
+	ErrDailyLimitExceeded       = errors.New("amount is above what is left of the daily limit")
+	ErrMonthlyLimitExceeded     = errors.New("amount is above what is left of the monthly limit")
+	ErrNightlyLimitExceeded     = errors.New("amount is above what is left of the nighttime limit")
+	ErrInvalidRuleSet           = errors.New("invalid risk rule set")
+	ErrPixDenied                = errors.New("pix denied by the risk analysis")
+	ErrPixInReview              = errors.New("pix is waiting for a risk review")
+	ErrAssessmentNotFound       = errors.New("risk assessment not found")
+	ErrReviewResolved           = errors.New("review is not pending")
+	ErrAnalystRequired          = errors.New("analyst is required")
+	ErrHoldNotFound             = errors.New("active hold not found")
+	ErrReportNotFound           = errors.New("infraction report not found")
+	ErrReportAlreadyOpen        = errors.New("transaction already has an infraction report")
+	ErrReportResolved           = errors.New("infraction report is not open")
+	ErrReportDeadlinePassed     = errors.New("transaction is past the infraction report deadline")
+	ErrNotTransactionSender     = errors.New("only the sender can report the transaction")
+	ErrTransactionNotReportable = errors.New("only completed pix to an account of this bank can be reported")
+	ErrLockLost                 = errors.New("account lock expired before the write")
 )
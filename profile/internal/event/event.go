@@ -2,13 +2,59 @@ package event
 
 import (
 	"context"
+	"errors"
 	kafkago "github.com/segmentio/kafka-go"
 	"log"
 	"profile/platform/kafka"
+	"strconv"
+	"time"
 )
 
+const (
+	// retryDelay is how long Consume waits before handling a failed message
+	// again. It doubles on every attempt up to maxRetryDelay.
+	retryDelay    = time.Second
+	maxRetryDelay = 30 * time.Second
+
+	HeaderOriginalTopic  = "x-original-topic"
+	HeaderOriginalOffset = "x-original-offset"
+	HeaderError          = "x-error"
+	HeaderAttempts       = "x-attempts"
+	HeaderFailedAt       = "x-failed-at"
+)
+
+// Client publishes to and consumes one topic. Messages with the same key go to
+// the same partition, so consumers read them in the order they were published.
 type Client interface {
-	Publish(ctx context.Context, payload []byte) error
+	Publish(ctx context.Context, key, payload []byte) error
+	Consume(ctx context.Context, handler Handler) error
+}
+
+// Handler handles one message. A failed message is handled again up to the
+// handle attempts of the client, so handlers must be idempotent. After that,
+// or right away when the error is Permanent, it goes to the dead-letter topic.
+type Handler func(ctx context.Context, payload []byte) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks a handler error that handling the message again cannot fix.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
 }
 
 type Options func(*event)
@@ -19,6 +65,14 @@ func WithAttempts(attempts int) Options {
 	}
 }
 
+// WithHandleAttempts sets how many times Consume handles a failing message
+// before moving it to the dead-letter topic.
+func WithHandleAttempts(attempts int) Options {
+	return func(e *event) {
+		e.handleAttempts = attempts
+	}
+}
+
 func WithBroker(broker string) Options {
 	return func(e *event) {
 		e.brokers = append(e.brokers, broker)
@@ -26,37 +80,149 @@ func WithBroker(broker string) Options {
 }
 
 type event struct {
-	topic       string
-	maxAttempts int
-	kafka       kafka.Client
-	brokers     []string
+	topic          string
+	maxAttempts    int
+	handleAttempts int
+	retryDelay     time.Duration
+	kafka          kafka.Client
+	brokers        []string
+	write          func(ctx context.Context, topic string, msg kafkago.Message) error
+}
+
+func (e *event) Publish(ctx context.Context, key, payload []byte) error {
+	return e.writeMessage(ctx, e.topic, kafkago.Message{Key: key, Value: payload})
 }
 
-func (e *event) Publish(ctx context.Context, payload []byte) error {
+func (e *event) writeMessage(ctx context.Context, topic string, msg kafkago.Message) error {
 	w := &kafkago.Writer{
 		Addr:                   kafkago.TCP(e.brokers...),
-		Topic:                  e.topic,
+		Topic:                  topic,
+		Balancer:               &kafkago.Hash{},
 		MaxAttempts:            e.maxAttempts,
 		Transport:              kafkago.DefaultTransport,
 		AllowAutoTopicCreation: true,
 	}
 
-	err := w.WriteMessages(ctx, kafkago.Message{Value: payload})
-	if err != nil {
-		log.Print("failed to write messages:", err)
+	err := w.WriteMessages(ctx, msg)
+	if closeErr := w.Close(); closeErr != nil {
+		log.Print("failed to close writer:", closeErr)
 	}
+	return err
+}
+
+// Consume hands the messages of the topic to handler, in order, until ctx is
+// done. The offset is only committed once the message was handled or moved to
+// the dead-letter topic.
+func (e *event) Consume(ctx context.Context, handler Handler) error {
+	r := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers: e.brokers,
+		Topic:   e.topic,
+		GroupID: e.topic + "_profile",
+	})
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.Print("failed to close reader:", err)
+		}
+	}()
+
+	for {
+		msg, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("failed to fetch message from [%s]: %v", e.topic, err)
+			continue
+		}
+
+		if err = e.process(ctx, msg, handler); err != nil {
+			return err
+		}
+
+		if err = r.CommitMessages(ctx, msg); err != nil {
+			log.Printf("failed to commit message from [%s] at offset %d: %v", e.topic, msg.Offset, err)
+		}
+	}
+}
+
+// process handles msg with backoff and moves it to the dead-letter topic when
+// every attempt failed. Moving it is retried until it works, so it only
+// returns an error when ctx is done first and the message must not be
+// committed.
+func (e *event) process(ctx context.Context, msg kafkago.Message, handler Handler) error {
+	attempts, err := 0, error(nil)
+	for {
+		attempts++
+		if err = handler(ctx, msg.Value); err == nil {
+			return nil
+		}
+		log.Printf("failed to handle message from [%s] at offset %d: %v", e.topic, msg.Offset, err)
+		if attempts >= e.handleAttempts || isPermanent(err) {
+			break
+		}
+		if waitErr := sleep(ctx, e.backoff(attempts)); waitErr != nil {
+			return waitErr
+		}
+	}
+
+	deadLetter := kafkago.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: append(msg.Headers,
+			kafkago.Header{Key: HeaderOriginalTopic, Value: []byte(e.topic)},
+			kafkago.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+			kafkago.Header{Key: HeaderError, Value: []byte(err.Error())},
+			kafkago.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+			kafkago.Header{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+		),
+	}
+	for attempt := 1; ; attempt++ {
+		writeErr := e.write(ctx, e.deadLetterTopic(), deadLetter)
+		if writeErr == nil {
+			log.Printf("moved message from [%s] at offset %d to [%s]", e.topic, msg.Offset, e.deadLetterTopic())
+			return nil
+		}
+		log.Printf("failed to move message from [%s] at offset %d to [%s]: %v", e.topic, msg.Offset, e.deadLetterTopic(), writeErr)
+		if waitErr := sleep(ctx, e.backoff(attempt)); waitErr != nil {
+			return waitErr
+		}
+	}
+}
+
+func (e *event) deadLetterTopic() string {
+	return e.topic + ".dlt"
+}
+
+func (e *event) backoff(attempt int) time.Duration {
+	backoff := e.retryDelay
+	for i := 1; i < attempt && backoff < maxRetryDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return backoff
+}
 
-	if err = w.Close(); err != nil {
-		log.Print("failed to close writer:", err)
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	return nil
 }
 
 func NewEvent(client kafka.Client, topic string, opts ...Options) Client {
 	e := &event{
-		kafka: client,
-		topic: topic,
+		kafka:          client,
+		topic:          topic,
+		handleAttempts: 5,
+		retryDelay:     retryDelay,
 	}
+	e.write = e.writeMessage
 	for _, f := range opts {
 		f(e)
 	}
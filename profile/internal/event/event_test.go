@@ -2,10 +2,15 @@ package event
 
 import (
 	"context"
+	"errors"
+	kafkago "github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"profile/platform/kafka"
+	"strconv"
 	"testing"
+	"time"
 )
 
 type mockKafkaClient struct {
@@ -27,11 +32,11 @@ func TestEventPublish(t *testing.T) {
 		name         string
 		client       Client
 		mockFunc     func(client *mockKafkaClient)
-		expectedErr  error
+		wantErr      bool
 		expectedLogs []string
 	}{
 		{
-			name: "successful publish",
+			name: "failed because broker is unreachable",
 			client: &event{
 				topic:       topic,
 				maxAttempts: 3,
@@ -41,7 +46,7 @@ func TestEventPublish(t *testing.T) {
 			mockFunc: func(client *mockKafkaClient) {
 				client.On("Publish", ctx, payload).Return(nil)
 			},
-			expectedErr:  nil,
+			wantErr:      true,
 			expectedLogs: nil,
 		},
 	}
@@ -51,10 +56,114 @@ func TestEventPublish(t *testing.T) {
 			mockClient := c.client.(*event).kafka.(*mockKafkaClient)
 			c.mockFunc(mockClient)
 
-			err := c.client.Publish(ctx, payload)
+			err := c.client.Publish(ctx, []byte("1"), payload)
 
-			assert.Equal(t, c.expectedErr, err)
+			assert.Equal(t, c.wantErr, err != nil)
 
 		})
 	}
 }
+
+type written struct {
+	topic string
+	msg   kafkago.Message
+}
+
+func newTestEvent(writes *[]written, writeErr error) *event {
+	return &event{
+		topic:          "key-claims",
+		handleAttempts: 3,
+		retryDelay:     time.Millisecond,
+		write: func(ctx context.Context, topic string, msg kafkago.Message) error {
+			*writes = append(*writes, written{topic: topic, msg: msg})
+			return writeErr
+		},
+	}
+}
+
+func TestProcess(t *testing.T) {
+	cases := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantTopic string
+	}{
+		{
+			name:      "handled on first attempt",
+			wantCalls: 1,
+		},
+		{
+			name:      "handled after retry",
+			failures:  2,
+			wantCalls: 3,
+		},
+		{
+			name:      "failure on every attempt goes to dead-letter topic",
+			failures:  5,
+			wantCalls: 3,
+			wantTopic: "key-claims.dlt",
+		},
+		{
+			name:      "permanent failure skips retries",
+			failures:  5,
+			err:       Permanent(errors.New("MOCK-ERROR")),
+			wantCalls: 1,
+			wantTopic: "key-claims.dlt",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var writes []written
+			e := newTestEvent(&writes, nil)
+
+			calls := 0
+			handler := func(ctx context.Context, payload []byte) error {
+				calls++
+				if calls <= c.failures {
+					if c.err != nil {
+						return c.err
+					}
+					return errors.New("MOCK-ERROR")
+				}
+				return nil
+			}
+			err := e.process(context.Background(), kafkago.Message{Offset: 7, Value: []byte("payload")}, handler)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.wantCalls, calls)
+			if c.wantTopic == "" {
+				assert.Empty(t, writes)
+				return
+			}
+			require.Len(t, writes, 1)
+			assert.Equal(t, c.wantTopic, writes[0].topic)
+			assert.Equal(t, []byte("payload"), writes[0].msg.Value)
+			headers := make(map[string]string)
+			for _, h := range writes[0].msg.Headers {
+				headers[h.Key] = string(h.Value)
+			}
+			assert.Equal(t, "key-claims", headers[HeaderOriginalTopic])
+			assert.Equal(t, "7", headers[HeaderOriginalOffset])
+			assert.Equal(t, "MOCK-ERROR", headers[HeaderError])
+			assert.Equal(t, strconv.Itoa(c.wantCalls), headers[HeaderAttempts])
+		})
+	}
+}
+
+func TestProcessDeadLetterFailureIsNotCommitted(t *testing.T) {
+	var writes []written
+	e := newTestEvent(&writes, errors.New("MOCK-WRITE-ERROR"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	handler := func(ctx context.Context, payload []byte) error {
+		return Permanent(errors.New("MOCK-ERROR"))
+	}
+	err := e.process(ctx, kafkago.Message{Value: []byte("payload")}, handler)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotEmpty(t, writes)
+}
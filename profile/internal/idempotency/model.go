@@ -0,0 +1,28 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+type Status string
+
+const (
+	StatusInProgress Status = "IN_PROGRESS"
+	StatusCompleted  Status = "COMPLETED"
+)
+
+type Record struct {
+	Fingerprint string          `json:"fingerprint"`
+	Status      Status          `json:"status"`
+	Response    json.RawMessage `json:"response,omitempty"`
+}
+
+// Fingerprint identifies a request payload, so a replayed key can be told
+// apart from the same key reused for a different request.
+func Fingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type Repository interface {
+	Reserve(ctx context.Context, key string, fingerprint string) (*Record, bool, error)
+	Complete(ctx context.Context, key string, record *Record) error
+	Release(ctx context.Context, key string) error
+}
+
+type Options func(*repository)
+
+func WithTTL(ttl time.Duration) Options {
+	return func(r *repository) {
+		r.ttl = ttl
+	}
+}
+
+func WithPrefix(prefix string) Options {
+	return func(r *repository) {
+		r.prefix = prefix
+	}
+}
+
+type repository struct {
+	client *redis.Client
+	ttl    time.Duration
+	prefix string
+}
+
+// Reserve claims the key for a new request. When the key is already taken it
+// returns the stored record and false, leaving the caller to decide between
+// replaying the original result and rejecting the request.
+func (r *repository) Reserve(ctx context.Context, key string, fingerprint string) (*Record, bool, error) {
+	record := &Record{Fingerprint: fingerprint, Status: StatusInProgress}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+
+	reserved, err := r.client.SetNX(ctx, r.prefix+key, payload, r.ttl).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if reserved {
+		return record, true, nil
+	}
+
+	stored, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			// expired between SETNX and GET, try to claim it again
+			return r.Reserve(ctx, key, fingerprint)
+		}
+		return nil, false, err
+	}
+
+	var existing Record
+	if err = json.Unmarshal(stored, &existing); err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (r *repository) Complete(ctx context.Context, key string, record *Record) error {
+	record.Status = StatusCompleted
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, r.prefix+key, payload, r.ttl).Err()
+}
+
+func (r *repository) Release(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key).Err()
+}
+
+func NewRepository(client *redis.Client, opts ...Options) Repository {
+	r := &repository{
+		client: client,
+		ttl:    24 * time.Hour,
+		prefix: "idempotency:",
+	}
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
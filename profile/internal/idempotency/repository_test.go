@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestRepository(t *testing.T) (Repository, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	return NewRepository(client, WithTTL(time.Minute)), server
+}
+
+func TestReserve(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name        string
+		mockFunc    func(repo Repository)
+		fingerprint string
+		want        *Record
+		reserved    bool
+	}{
+		{
+			name:        "new key is reserved",
+			mockFunc:    func(repo Repository) {},
+			fingerprint: "a",
+			want:        &Record{Fingerprint: "a", Status: StatusInProgress},
+			reserved:    true,
+		},
+		{
+			name: "key in progress is returned",
+			mockFunc: func(repo Repository) {
+				_, _, _ = repo.Reserve(ctx, "key", "a")
+			},
+			fingerprint: "a",
+			want:        &Record{Fingerprint: "a", Status: StatusInProgress},
+			reserved:    false,
+		},
+		{
+			name: "completed key replays the response",
+			mockFunc: func(repo Repository) {
+				_, _, _ = repo.Reserve(ctx, "key", "a")
+				_ = repo.Complete(ctx, "key", &Record{Fingerprint: "a", Response: json.RawMessage(`{"id":"1"}`)})
+			},
+			fingerprint: "b",
+			want:        &Record{Fingerprint: "a", Status: StatusCompleted, Response: json.RawMessage(`{"id":"1"}`)},
+			reserved:    false,
+		},
+		{
+			name: "released key can be reserved again",
+			mockFunc: func(repo Repository) {
+				_, _, _ = repo.Reserve(ctx, "key", "a")
+				_ = repo.Release(ctx, "key")
+			},
+			fingerprint: "b",
+			want:        &Record{Fingerprint: "b", Status: StatusInProgress},
+			reserved:    true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo, _ := newTestRepository(t)
+			c.mockFunc(repo)
+			got, reserved, err := repo.Reserve(ctx, "key", c.fingerprint)
+			require.NoError(t, err)
+			assert.Equal(t, c.reserved, reserved)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestReserveExpires(t *testing.T) {
+	ctx := context.Background()
+	repo, server := newTestRepository(t)
+
+	_, reserved, err := repo.Reserve(ctx, "key", "a")
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	server.FastForward(2 * time.Minute)
+
+	_, reserved, err = repo.Reserve(ctx, "key", "b")
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
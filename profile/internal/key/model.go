@@ -1,7 +1,10 @@
 package key
 
 import (
+	"encoding/json"
 	"gorm.io/gorm"
+	"profile/internal/account"
+	"profile/internal/outbox"
 	proto "profile/proto/profile/v1"
 	"time"
 )
@@ -15,16 +18,76 @@ const (
 	Random Type = "random"
 )
 
+// EventsTopic carries the key changes to the transaction service, which keeps
+// a copy of every key.
+const EventsTopic = "key_events_topic"
+
+// ClaimsTopic carries the keys a claim moved in the transaction service to
+// another account, as OpClaim events.
+const ClaimsTopic = "key_claims_topic"
+
+type Op string
+
+const (
+	OpUpsert Op = "UPSERT"
+	OpDelete Op = "DELETE"
+	OpClaim  Op = "CLAIM"
+)
+
+// Key is the source of truth for the copy in the transaction service. Version
+// goes up on every change and is sent with its event, so the copy can drop
+// events older than what it holds.
 type Key struct {
 	Id        string         `gorm:"primaryKey;type:varchar(36);column:id"`
 	AccountID int64          `gorm:"foreignKey;type:varchar(36);column:account_id"`
 	Name      string         `gorm:"type:varchar(200);column:name"`
 	Type      Type           `gorm:"type:varchar(100);column:type"`
+	Version   int64          `gorm:"type:bigint;column:version"`
 	CreatedAt time.Time      `gorm:"type:datetime;column:created_at"`
 	UpdatedAt time.Time      `gorm:"type:datetime;column:updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index;type:datetime;column:deleted_at"`
 }
 
+type Event struct {
+	ID      string `json:"id"`
+	Op      Op     `json:"op"`
+	Account struct {
+		Name   int64  `json:"name"`
+		Cpf    string `json:"cpf"`
+		Agency string `json:"agency"`
+		Bank   string `json:"bank"`
+	} `json:"account"`
+	Name       string    `json:"name"`
+	Type       Type      `json:"type"`
+	Version    int64     `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// NewMessage builds the outbox message of a change to key at its current
+// Version. The account and cpf are only read for OpUpsert.
+func NewMessage(op Op, key *Key, accountModel *account.Account, cpf string) (*outbox.Message, error) {
+	keyEvent := Event{
+		ID:         key.Id,
+		Op:         op,
+		Version:    key.Version,
+		OccurredAt: time.Now().UTC(),
+	}
+	keyEvent.Account.Name = key.AccountID
+	if op == OpUpsert {
+		keyEvent.Name = key.Name
+		keyEvent.Type = key.Type
+		keyEvent.Account.Cpf = cpf
+		keyEvent.Account.Agency = accountModel.Agency
+		keyEvent.Account.Bank = accountModel.Bank
+	}
+
+	payload, err := json.Marshal(keyEvent)
+	if err != nil {
+		return nil, err
+	}
+	return outbox.NewMessage(key.AccountID, EventsTopic, payload), nil
+}
+
 type KeyRequest struct {
 	keyID string
 }
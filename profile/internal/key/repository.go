@@ -2,17 +2,26 @@ package key
 
 import (
 	"context"
+	"errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"profile/internal/cfg"
+	"profile/internal/errutils"
+	"profile/internal/outbox"
 	"time"
 )
 
 type Repository interface {
 	CreateKey(key *Key) (*Key, error)
-	UpdateKey(key *Key) (*Key, error)
+	UpdateKey(key *Key, events ...*outbox.Message) (*Key, error)
+	MoveKey(key *Key, previous int64, events ...*outbox.Message) error
 	ListKey(ids []string) ([]*Key, error)
-	DeleteKey(id string) error
+	DeleteKey(key *Key, events ...*outbox.Message) error
+	CountKeys(accountID int64) (int64, error)
+	FindKeyByID(id string) (*Key, error)
+	ExistKey(name string, exceptID string) (bool, error)
+	ListAccountKeys(ctx context.Context, accountID int64) ([]*Key, error)
+	ListKeysWithDeleted(ctx context.Context, ids []string) ([]*Key, error)
 
 	FindKey(ctx context.Context, key string, accountId string) (*Key, error)
 }
@@ -22,25 +31,90 @@ type repository struct {
 	cfg *cfg.Config
 }
 
+// CreateKey saves the key under a lock of its account, so the cap of keys and
+// the single cpf key per account hold against concurrent creates.
 func (r repository) CreateKey(key *Key) (*Key, error) {
 	key.CreatedAt = time.Now()
 	key.UpdatedAt = time.Now()
-	key.Id = uuid.New().String()
-	err := r.db.Create(&key).Error
+	if key.Id == "" {
+		key.Id = uuid.New().String()
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockAccount(tx, key.AccountID); err != nil {
+			return err
+		}
+		if err := checkLimit(tx, key); err != nil {
+			return err
+		}
+		if err := checkType(tx, key); err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
 	if err != nil {
 		return nil, err
 	}
 	return key, nil
 }
 
-func (r repository) UpdateKey(key *Key) (*Key, error) {
-	result := r.db.Save(&key)
-	if result.Error != nil {
-		return nil, result.Error
+// UpdateKey writes the key at key.Version with its events, as long as the row
+// is still at the version before it. The type rules of CreateKey are checked
+// under the same lock of the account.
+func (r repository) UpdateKey(key *Key, events ...*outbox.Message) (*Key, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockAccount(tx, key.AccountID); err != nil {
+			return err
+		}
+		if err := checkType(tx, key); err != nil {
+			return err
+		}
+		return r.writeVersion(tx, key, map[string]interface{}{
+			"name":       key.Name,
+			"type":       key.Type,
+			"version":    key.Version,
+			"updated_at": key.UpdatedAt,
+		}, events)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return key, nil
 }
 
+// MoveKey writes the key, deleted or not, to key.AccountID with its events, as
+// long as the row is still at the previous version. The account it moves to
+// is held to the cap and type rules of CreateKey.
+func (r repository) MoveKey(key *Key, previous int64, events ...*outbox.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockAccount(tx, key.AccountID); err != nil {
+			return err
+		}
+		if err := checkLimit(tx, key); err != nil {
+			return err
+		}
+		if err := checkType(tx, key); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Model(&Key{}).
+			Where("id = ? AND version = ?", key.Id, previous).
+			Updates(map[string]interface{}{
+				"account_id": key.AccountID,
+				"name":       key.Name,
+				"type":       key.Type,
+				"version":    key.Version,
+				"updated_at": key.UpdatedAt,
+				"deleted_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errutils.ErrKeyConflict
+		}
+		return outbox.NewRepository(tx, r.cfg).Enqueue(context.Background(), events...)
+	})
+}
+
 func (r repository) ListKey(ids []string) ([]*Key, error) {
 	var listKey []*Key
 	if err := r.db.Where("id IN (?)", ids).Find(&listKey).Error; err != nil {
@@ -49,8 +123,110 @@ func (r repository) ListKey(ids []string) ([]*Key, error) {
 	return listKey, nil
 }
 
-func (r repository) DeleteKey(id string) error {
-	return r.db.Delete(&Key{}, "id = ?", id).Error
+// DeleteKey soft deletes the key at key.Version with its events, as long as
+// the row is still at the version before it.
+func (r repository) DeleteKey(key *Key, events ...*outbox.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return r.writeVersion(tx, key, map[string]interface{}{
+			"version":    key.Version,
+			"updated_at": key.UpdatedAt,
+			"deleted_at": key.UpdatedAt,
+		}, events)
+	})
+}
+
+func (r repository) writeVersion(tx *gorm.DB, key *Key, values map[string]interface{}, events []*outbox.Message) error {
+	result := tx.Model(&Key{}).
+		Where("id = ? AND version = ?", key.Id, key.Version-1).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errutils.ErrKeyConflict
+	}
+	return outbox.NewRepository(tx, r.cfg).Enqueue(context.Background(), events...)
+}
+
+// lockAccount takes the row lock of the account until tx ends, which orders the
+// key changes of one account.
+func lockAccount(tx *gorm.DB, accountID int64) error {
+	return tx.Table("accounts").Where("id = ?", accountID).
+		Update("updated_at", gorm.Expr("updated_at")).Error
+}
+
+// checkLimit refuses the key when the account of key already has the maximum
+// number of other keys.
+func checkLimit(tx *gorm.DB, key *Key) error {
+	var count int64
+	err := tx.Model(&Key{}).
+		Where("account_id = ? AND id <> ?", key.AccountID, key.Id).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count >= MaxKeysPerAccount {
+		return errutils.ErrKeyLimitReached
+	}
+	return nil
+}
+
+// checkType refuses a second cpf key in the account of key.
+func checkType(tx *gorm.DB, key *Key) error {
+	if key.Type != Cpf {
+		return nil
+	}
+	var count int64
+	err := tx.Model(&Key{}).
+		Where("account_id = ? AND type = ? AND id <> ?", key.AccountID, Cpf, key.Id).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errutils.ErrKeyAlreadyExists
+	}
+	return nil
+}
+
+func (r repository) FindKeyByID(id string) (*Key, error) {
+	var key Key
+	if err := r.db.Where("id = ?", id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errutils.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r repository) ExistKey(name string, exceptID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&Key{}).Where("name = ? AND id <> ?", name, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// ListAccountKeys returns the keys of the account, deleted ones included.
+func (r repository) ListAccountKeys(ctx context.Context, accountID int64) ([]*Key, error) {
+	var keys []*Key
+	if err := r.db.WithContext(ctx).Unscoped().Where("account_id = ?", accountID).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r repository) ListKeysWithDeleted(ctx context.Context, ids []string) ([]*Key, error) {
+	var keys []*Key
+	if err := r.db.WithContext(ctx).Unscoped().Where("id IN (?)", ids).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r repository) CountKeys(accountID int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&Key{}).Where("account_id = ?", accountID).Count(&count).Error
+	return count, err
 }
 
 func (r repository) FindKey(ctx context.Context, key string, accountId string) (*Key, error) {
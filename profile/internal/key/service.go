@@ -2,10 +2,17 @@ package key
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
 	"profile/internal/account"
+	"profile/internal/errutils"
+	events "profile/internal/event"
 	"profile/internal/transaction"
 	"profile/internal/user"
+	"time"
 )
 
 type Service interface {
@@ -14,6 +21,7 @@ type Service interface {
 	ListKey(req *ListKeyRequest) ([]*Key, error)
 	DeleteKey(id *KeyRequest) error
 	FindKey(ctx context.Context, key string, accountId string) (*Key, error)
+	HandleClaim(ctx context.Context, payload []byte) error
 }
 
 type service struct {
@@ -35,27 +43,99 @@ func (s service) CreateKey(key *Key) (*Key, error) {
 		return nil, err
 	}
 
-	err = s.transaction.CreateKey(context.Background(), &transaction.Key{
+	if err = Normalize(key, userModel.Cpf); err != nil {
+		return nil, err
+	}
+
+	// refused early to spare the transaction service, CreateKey checks the cap
+	// again under the lock of the account
+	count, err := s.repo.CountKeys(key.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxKeysPerAccount {
+		return nil, errutils.ErrKeyLimitReached
+	}
+
+	// the key is created in the transaction service first, which checks it is
+	// unique and generates random keys, with the id and version of this row
+	key.Id = uuid.New().String()
+	key.Version = 1
+	created, err := s.transaction.CreateKey(context.Background(), &transaction.Key{
+		Id:      key.Id,
 		Account: accountModel.Id,
 		Bank:    accountModel.Bank,
 		Agency:  accountModel.Agency,
 		Cpf:     userModel.Cpf,
 		Name:    key.Name,
 		Type:    transaction.Type(key.Type),
+		Version: key.Version,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return s.repo.CreateKey(key)
+	key.Name = created.Name
+	saved, err := s.repo.CreateKey(key)
+	if err != nil {
+		// left behind, the copy is removed by the key reconciliation
+		if deleteErr := s.transaction.DeleteKey(context.Background(), key.Id); deleteErr != nil {
+			log.Printf("failed to remove key %s from the transaction service: %v", key.Id, deleteErr)
+		}
+		return nil, err
+	}
+	return saved, nil
 }
 
+// UpdateKey changes the value or type of a key and publishes the change to the
+// transaction service with the next version of the key.
 func (s service) UpdateKey(key *Key) (*Key, error) {
 	if key.Id == "" {
 		return nil, errors.New("id is required")
 	}
-	//key.UpdatedAt = time.Now()
-	return s.repo.UpdateKey(key)
+
+	current, err := s.repo.FindKeyByID(key.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	accountModel, err := s.accountRepo.FindAccountById(current.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	userModel, err := s.userRepo.FindUserById(accountModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = Normalize(key, userModel.Cpf); err != nil {
+		return nil, err
+	}
+	if key.Type == Random {
+		key.Name = current.Name
+		if current.Type != Random {
+			key.Name = uuid.New().String()
+		}
+	}
+
+	exists, err := s.repo.ExistKey(key.Name, key.Id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errutils.ErrKeyAlreadyExists
+	}
+
+	current.Name = key.Name
+	current.Type = key.Type
+	current.Version++
+	current.UpdatedAt = time.Now()
+	message, err := NewMessage(OpUpsert, current, accountModel, userModel.Cpf)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateKey(current, message)
 }
 
 func (s service) ListKey(req *ListKeyRequest) ([]*Key, error) {
@@ -68,7 +148,19 @@ func (s service) DeleteKey(request *KeyRequest) error {
 	if request.keyID == "" {
 		return errors.New("account_id is required")
 	}
-	return s.repo.DeleteKey(request.keyID)
+
+	current, err := s.repo.FindKeyByID(request.keyID)
+	if err != nil {
+		return err
+	}
+
+	current.Version++
+	current.UpdatedAt = time.Now()
+	message, err := NewMessage(OpDelete, current, nil, "")
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteKey(current, message)
 }
 
 func (s *service) FindKey(ctx context.Context, key string, accountId string) (*Key, error) {
@@ -79,6 +171,69 @@ func (s *service) FindKey(ctx context.Context, key string, accountId string) (*K
 	return keys, nil
 }
 
+// HandleClaim moves a key a claim took in the transaction service to the
+// claimer account. The move goes back to the transaction service as an upsert
+// at a version after the claim, so the copy ends up with the claimer data.
+func (s service) HandleClaim(ctx context.Context, payload []byte) error {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil || event.Op != OpClaim {
+		log.Printf("skipping key claim event %s: malformed or not a claim", payload)
+		return nil
+	}
+
+	found, err := s.repo.ListKeysWithDeleted(ctx, []string{event.ID})
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		log.Printf("skipping claim of unknown key %s", event.ID)
+		return nil
+	}
+	current := found[0]
+	if current.AccountID == event.Account.Name && current.Version >= event.Version {
+		return nil
+	}
+
+	accountModel, err := s.accountRepo.FindAccountById(event.Account.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("skipping claim of key %s by unknown account %d", event.ID, event.Account.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	userModel, err := s.userRepo.FindUserById(accountModel.UserID)
+	if err != nil {
+		return err
+	}
+	// random keys are never claimed and a cpf key only by its own cpf
+	if event.Type == Random || (event.Type == Cpf && event.Name != userModel.Cpf) {
+		log.Printf("skipping claim of %s key %s by account %d", event.Type, event.ID, event.Account.Name)
+		return nil
+	}
+
+	previous := current.Version
+	current.AccountID = event.Account.Name
+	current.Name = event.Name
+	current.Type = event.Type
+	if event.Version > current.Version {
+		current.Version = event.Version
+	}
+	current.Version++
+	current.UpdatedAt = time.Now()
+	current.DeletedAt = gorm.DeletedAt{}
+	message, err := NewMessage(OpUpsert, current, accountModel, userModel.Cpf)
+	if err != nil {
+		return err
+	}
+	err = s.repo.MoveKey(current, previous, message)
+	if errors.Is(err, errutils.ErrKeyLimitReached) || errors.Is(err, errutils.ErrKeyAlreadyExists) {
+		// the claimer account has to make room for the key first
+		return events.Permanent(err)
+	}
+	return err
+}
+
 func NewService(repo Repository, transaction transaction.Service, userRepo user.Repository, accountRepo account.Repository) Service {
 	return &service{
 		repo:        repo,
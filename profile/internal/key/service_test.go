@@ -2,9 +2,16 @@ package key
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"profile/internal/account"
+	"profile/internal/errutils"
+	events "profile/internal/event"
+	"profile/internal/outbox"
+	"profile/internal/transaction"
+	"profile/internal/user"
 	"testing"
 )
 
@@ -14,12 +21,12 @@ type mockRepoKey struct {
 }
 
 func (m *mockRepoKey) CreateKey(key *Key) (*Key, error) {
-	args := m.Called(key)
+	args := m.Called(key.AccountID, key.Name, key.Type)
 	return args.Get(0).(*Key), args.Error(1)
 }
 
-func (m *mockRepoKey) UpdateKey(key *Key) (*Key, error) {
-	args := m.Called(key)
+func (m *mockRepoKey) UpdateKey(key *Key, events ...*outbox.Message) (*Key, error) {
+	args := m.Called(key.Id, key.Name, key.Type, key.Version)
 	return args.Get(0).(*Key), args.Error(1)
 }
 
@@ -28,8 +35,33 @@ func (m *mockRepoKey) ListKey(ids []string) ([]*Key, error) {
 	return args.Get(0).([]*Key), args.Error(1)
 }
 
-func (m *mockRepoKey) DeleteKey(id string) error {
+func (m *mockRepoKey) DeleteKey(key *Key, events ...*outbox.Message) error {
+	args := m.Called(key.Id, key.Version)
+	return args.Error(0)
+}
+
+func (m *mockRepoKey) CountKeys(accountID int64) (int64, error) {
+	args := m.Called(accountID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepoKey) FindKeyByID(id string) (*Key, error) {
 	args := m.Called(id)
+	return args.Get(0).(*Key), args.Error(1)
+}
+
+func (m *mockRepoKey) ExistKey(name string, exceptID string) (bool, error) {
+	args := m.Called(name, exceptID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepoKey) ListKeysWithDeleted(ctx context.Context, ids []string) ([]*Key, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]*Key), args.Error(1)
+}
+
+func (m *mockRepoKey) MoveKey(key *Key, previous int64, events ...*outbox.Message) error {
+	args := m.Called(key.Id, key.AccountID, key.Version, previous)
 	return args.Error(0)
 }
 
@@ -38,70 +70,131 @@ func (m *mockRepoKey) FindKey(ctx context.Context, key string, accountId string)
 	return args.Get(0).(*Key), args.Error(1)
 }
 
+type mockTransactionService struct {
+	transaction.Service
+	mock.Mock
+}
+
+func (m *mockTransactionService) CreateKey(ctx context.Context, req *transaction.Key) (*transaction.Key, error) {
+	args := m.Called(req.Name, req.Type)
+	return args.Get(0).(*transaction.Key), args.Error(1)
+}
+
+func (m *mockTransactionService) DeleteKey(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type mockAccountRepo struct {
+	account.Repository
+	mock.Mock
+}
+
+func (m *mockAccountRepo) FindAccountById(id int64) (*account.Account, error) {
+	args := m.Called(id)
+	return args.Get(0).(*account.Account), args.Error(1)
+}
+
+type mockUserRepo struct {
+	user.Repository
+	mock.Mock
+}
+
+func (m *mockUserRepo) FindUserById(id string) (*user.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+const holderCpf = "52998224725"
+
+func newOwnerMocks() (*mockAccountRepo, *mockUserRepo) {
+	accountRepo := new(mockAccountRepo)
+	accountRepo.On("FindAccountById", int64(1)).Return(&account.Account{Id: 1, UserID: "user-1"}, nil)
+	userRepo := new(mockUserRepo)
+	userRepo.On("FindUserById", "user-1").Return(&user.User{Id: "user-1", Cpf: holderCpf}, nil)
+	return accountRepo, userRepo
+}
+
 func TestCreateKey(t *testing.T) {
 	cases := []struct {
 		name     string
 		req      *Key
-		mockFunc func(repo *mockRepoKey)
+		mockFunc func(repo *mockRepoKey, transactionService *mockTransactionService)
 		want     *Key
 		err      error
 	}{
 		{
 			name: "success",
 			req: &Key{
-				AccountID: "1",
-				Name:      "test_key",
+				AccountID: 1,
+				Name:      "529.982.247-25",
 				Type:      Cpf,
 			},
-			mockFunc: func(repo *mockRepoKey) {
-				repo.On("CreateKey", &Key{
-					AccountID: "1",
-					Name:      "test_key",
-					Type:      Cpf,
-				}).
+			mockFunc: func(repo *mockRepoKey, transactionService *mockTransactionService) {
+				repo.On("CountKeys", int64(1)).Return(int64(0), nil)
+				transactionService.On("CreateKey", holderCpf, transaction.Type(Cpf)).
+					Return(&transaction.Key{Name: holderCpf, Type: transaction.Type(Cpf)}, nil)
+				repo.On("CreateKey", int64(1), holderCpf, Cpf).
 					Return(&Key{
 						Id:        "1",
-						AccountID: "1",
-						Name:      "test_key",
+						AccountID: 1,
+						Name:      holderCpf,
 						Type:      Cpf,
 					}, nil)
 			},
 			want: &Key{
 				Id:        "1",
-				AccountID: "1",
-				Name:      "test_key",
+				AccountID: 1,
+				Name:      holderCpf,
 				Type:      Cpf,
 			},
 			err: nil,
 		},
+		{
+			name: "failed because the account reached the maximum number of keys",
+			req: &Key{
+				AccountID: 1,
+				Name:      "fulano@pix.com",
+				Type:      Email,
+			},
+			mockFunc: func(repo *mockRepoKey, transactionService *mockTransactionService) {
+				repo.On("CountKeys", int64(1)).Return(int64(MaxKeysPerAccount), nil)
+			},
+			want: nil,
+			err:  errutils.ErrKeyLimitReached,
+		},
 		{
 			name: "failed because error in create key",
 			req: &Key{
-				AccountID: "1",
-				Name:      "test_key",
-				Type:      Cpf,
+				AccountID: 1,
+				Name:      "fulano@pix.com",
+				Type:      Email,
 			},
-			mockFunc: func(repo *mockRepoKey) {
-				repo.On("CreateKey", &Key{
-					AccountID: "1",
-					Name:      "test_key",
-					Type:      Cpf,
-				}).
-					Return((*Key)(nil), errors.New("MOCK-ERROR"))
+			mockFunc: func(repo *mockRepoKey, transactionService *mockTransactionService) {
+				repo.On("CountKeys", int64(1)).Return(int64(0), nil)
+				transactionService.On("CreateKey", "fulano@pix.com", transaction.Type(Email)).
+					Return(&transaction.Key{Name: "fulano@pix.com", Type: transaction.Type(Email)}, nil)
+				repo.On("CreateKey", int64(1), "fulano@pix.com", Email).
+					Return((*Key)(nil), errutils.ErrKeyLimitReached)
+				transactionService.On("DeleteKey", mock.Anything).Return(nil)
 			},
 			want: nil,
-			err:  errors.New("MOCK-ERROR"),
+			err:  errutils.ErrKeyLimitReached,
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			repo := new(mockRepoKey)
-			c.mockFunc(repo)
-			s := NewService(repo)
+			transactionService := new(mockTransactionService)
+			accountRepo, userRepo := newOwnerMocks()
+			c.mockFunc(repo, transactionService)
+			s := NewService(repo, transactionService, userRepo, accountRepo)
 			got, err := s.CreateKey(c.req)
-			assert.Equal(t, err, c.err)
-			assert.Equal(t, got, c.want)
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.want, got)
+			repo.AssertExpectations(t)
+			transactionService.AssertExpectations(t)
 		})
 	}
 }
@@ -117,48 +210,75 @@ func TestUpdateKey(t *testing.T) {
 		{
 			name: "success",
 			req: &Key{
-				Id:        "1",
-				AccountID: "1",
-				Name:      "test_key",
-				Type:      Cpf,
+				Id:   "1",
+				Name: "529.982.247-25",
+				Type: Cpf,
 			},
 			mockFunc: func(repo *mockRepoKey) {
-				repo.On("UpdateKey", &Key{
-					Id:        "1",
-					AccountID: "1",
-					Name:      "test_key",
-					Type:      Cpf,
-				}).
+				repo.On("FindKeyByID", "1").
+					Return(&Key{Id: "1", AccountID: 1, Name: "fulano@pix.com", Type: Email, Version: 1}, nil)
+				repo.On("ExistKey", holderCpf, "1").Return(false, nil)
+				repo.On("UpdateKey", "1", holderCpf, Cpf, int64(2)).
 					Return(&Key{
 						Id:        "1",
-						AccountID: "1",
-						Name:      "test_key",
+						AccountID: 1,
+						Name:      holderCpf,
 						Type:      Cpf,
+						Version:   2,
 					}, nil)
 			},
 			want: &Key{
 				Id:        "1",
-				AccountID: "1",
-				Name:      "test_key",
+				AccountID: 1,
+				Name:      holderCpf,
 				Type:      Cpf,
+				Version:   2,
 			},
 			err: nil,
 		},
+		{
+			name: "failed because the cpf is not the account holder",
+			req: &Key{
+				Id:   "1",
+				Name: "12345678909",
+				Type: Cpf,
+			},
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("FindKeyByID", "1").
+					Return(&Key{Id: "1", AccountID: 1, Name: "fulano@pix.com", Type: Email, Version: 1}, nil)
+			},
+			want: nil,
+			err:  errutils.ErrKeyNotOwned,
+		},
+		{
+			name: "failed because the account already has a cpf key",
+			req: &Key{
+				Id:   "1",
+				Name: holderCpf,
+				Type: Cpf,
+			},
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("FindKeyByID", "1").
+					Return(&Key{Id: "1", AccountID: 1, Name: "fulano@pix.com", Type: Email, Version: 1}, nil)
+				repo.On("ExistKey", holderCpf, "1").Return(false, nil)
+				repo.On("UpdateKey", "1", holderCpf, Cpf, int64(2)).
+					Return((*Key)(nil), errutils.ErrKeyAlreadyExists)
+			},
+			want: nil,
+			err:  errutils.ErrKeyAlreadyExists,
+		},
 		{
 			name: "failed because error in update key",
 			req: &Key{
-				Id:        "1",
-				AccountID: "1",
-				Name:      "test_key",
-				Type:      Cpf,
+				Id:   "1",
+				Name: "fulano@pix.com",
+				Type: Email,
 			},
 			mockFunc: func(repo *mockRepoKey) {
-				repo.On("UpdateKey", &Key{
-					Id:        "1",
-					AccountID: "1",
-					Name:      "test_key",
-					Type:      Cpf,
-				}).
+				repo.On("FindKeyByID", "1").
+					Return(&Key{Id: "1", AccountID: 1, Name: "+5511987654321", Type: Phone, Version: 1}, nil)
+				repo.On("ExistKey", "fulano@pix.com", "1").Return(false, nil)
+				repo.On("UpdateKey", "1", "fulano@pix.com", Email, int64(2)).
 					Return((*Key)(nil), errors.New("MOCK-ERROR"))
 			},
 			want: nil,
@@ -169,11 +289,13 @@ func TestUpdateKey(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			repo := new(mockRepoKey)
+			accountRepo, userRepo := newOwnerMocks()
 			c.mockFunc(repo)
-			s := NewService(repo)
+			s := NewService(repo, new(mockTransactionService), userRepo, accountRepo)
 			got, err := s.UpdateKey(c.req)
-			assert.Equal(t, err, c.err)
-			assert.Equal(t, got, c.want)
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.want, got)
+			repo.AssertExpectations(t)
 		})
 	}
 }
@@ -196,13 +318,13 @@ func TestListKey(t *testing.T) {
 					Return([]*Key{
 						{
 							Id:        "1",
-							AccountID: "1",
+							AccountID: 1,
 							Name:      "key_1",
 							Type:      Cpf,
 						},
 						{
 							Id:        "2",
-							AccountID: "1",
+							AccountID: 1,
 							Name:      "key_2",
 							Type:      Phone,
 						},
@@ -211,13 +333,13 @@ func TestListKey(t *testing.T) {
 			want: []*Key{
 				{
 					Id:        "1",
-					AccountID: "1",
+					AccountID: 1,
 					Name:      "key_1",
 					Type:      Cpf,
 				},
 				{
 					Id:        "2",
-					AccountID: "1",
+					AccountID: 1,
 					Name:      "key_2",
 					Type:      Phone,
 				},
@@ -242,7 +364,7 @@ func TestListKey(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			repo := new(mockRepoKey)
 			c.mockFunc(repo)
-			s := NewService(repo)
+			s := NewService(repo, nil, nil, nil)
 			got, err := s.ListKey(c.req)
 			assert.Equal(t, err, c.err)
 			assert.Equal(t, got, c.want)
@@ -263,7 +385,8 @@ func TestDeleteKey(t *testing.T) {
 				keyID: "1",
 			},
 			mockFunc: func(repo *mockRepoKey) {
-				repo.On("DeleteKey", "1").
+				repo.On("FindKeyByID", "1").Return(&Key{Id: "1", AccountID: 1, Version: 1}, nil)
+				repo.On("DeleteKey", "1", int64(2)).
 					Return(nil)
 			},
 			err: nil,
@@ -274,7 +397,8 @@ func TestDeleteKey(t *testing.T) {
 				keyID: "2",
 			},
 			mockFunc: func(repo *mockRepoKey) {
-				repo.On("DeleteKey", "2").
+				repo.On("FindKeyByID", "2").Return(&Key{Id: "2", AccountID: 1, Version: 1}, nil)
+				repo.On("DeleteKey", "2", int64(2)).
 					Return(errors.New("MOCK-ERROR"))
 			},
 			err: errors.New("MOCK-ERROR"),
@@ -285,7 +409,7 @@ func TestDeleteKey(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			repo := new(mockRepoKey)
 			c.mockFunc(repo)
-			s := NewService(repo)
+			s := NewService(repo, nil, nil, nil)
 			err := s.DeleteKey(c.req)
 			assert.Equal(t, err, c.err)
 		})
@@ -307,11 +431,11 @@ func TestFindKey(t *testing.T) {
 			accountId: "1",
 			mockFunc: func(repo *mockRepoKey) {
 				repo.On("FindKey", context.Background(), "some_key", "1").
-					Return(&Key{Id: "1", AccountID: "1", Name: "some_key", Type: Cpf}, nil)
+					Return(&Key{Id: "1", AccountID: 1, Name: "some_key", Type: Cpf}, nil)
 			},
 			want: &Key{
 				Id:        "1",
-				AccountID: "1",
+				AccountID: 1,
 				Name:      "some_key",
 				Type:      Cpf,
 			},
@@ -334,10 +458,96 @@ func TestFindKey(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			repo := new(mockRepoKey)
 			c.mockFunc(repo)
-			s := NewService(repo)
+			s := NewService(repo, nil, nil, nil)
 			got, err := s.FindKey(context.Background(), c.key, c.accountId)
 			assert.Equal(t, err, c.err)
 			assert.Equal(t, got, c.want)
 		})
 	}
 }
+
+func TestHandleClaim(t *testing.T) {
+	claimedAs := func(name string, keyType Type, version int64) []byte {
+		event := Event{ID: "1", Op: OpClaim, Name: name, Type: keyType, Version: version}
+		event.Account.Name = 1
+		payload, _ := json.Marshal(event)
+		return payload
+	}
+	claimed := func(version int64) []byte {
+		return claimedAs("fulano@pix.com", Email, version)
+	}
+
+	cases := []struct {
+		name     string
+		payload  []byte
+		mockFunc func(repo *mockRepoKey)
+		err      error
+	}{
+		{
+			name:    "key moves to the claimer after the version of the claim",
+			payload: claimed(4),
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("ListKeysWithDeleted", []string{"1"}).
+					Return([]*Key{{Id: "1", AccountID: 2, Name: "fulano@pix.com", Type: Email, Version: 3}}, nil)
+				repo.On("MoveKey", "1", int64(1), int64(5), int64(3)).Return(nil)
+			},
+		},
+		{
+			name:    "key changed by the donor after the claim still moves",
+			payload: claimed(4),
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("ListKeysWithDeleted", []string{"1"}).
+					Return([]*Key{{Id: "1", AccountID: 2, Name: "outro@pix.com", Type: Email, Version: 6}}, nil)
+				repo.On("MoveKey", "1", int64(1), int64(7), int64(6)).Return(nil)
+			},
+		},
+		{
+			name:    "claim already moved is skipped",
+			payload: claimed(4),
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("ListKeysWithDeleted", []string{"1"}).
+					Return([]*Key{{Id: "1", AccountID: 1, Name: "fulano@pix.com", Type: Email, Version: 5}}, nil)
+			},
+		},
+		{
+			name:    "cpf key of another cpf is skipped",
+			payload: claimedAs("11144477735", Cpf, 4),
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("ListKeysWithDeleted", []string{"1"}).
+					Return([]*Key{{Id: "1", AccountID: 2, Name: "11144477735", Type: Cpf, Version: 3}}, nil)
+			},
+		},
+		{
+			name:    "failed because the claimer reached the maximum number of keys",
+			payload: claimed(4),
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("ListKeysWithDeleted", []string{"1"}).
+					Return([]*Key{{Id: "1", AccountID: 2, Name: "fulano@pix.com", Type: Email, Version: 3}}, nil)
+				repo.On("MoveKey", "1", int64(1), int64(5), int64(3)).Return(errutils.ErrKeyLimitReached)
+			},
+			err: events.Permanent(errutils.ErrKeyLimitReached),
+		},
+		{
+			name:    "failed because the key changed concurrently",
+			payload: claimed(4),
+			mockFunc: func(repo *mockRepoKey) {
+				repo.On("ListKeysWithDeleted", []string{"1"}).
+					Return([]*Key{{Id: "1", AccountID: 2, Name: "fulano@pix.com", Type: Email, Version: 3}}, nil)
+				repo.On("MoveKey", "1", int64(1), int64(5), int64(3)).Return(errutils.ErrKeyConflict)
+			},
+			err: errutils.ErrKeyConflict,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepoKey)
+			accountRepo, userRepo := newOwnerMocks()
+			c.mockFunc(repo)
+			s := NewService(repo, new(mockTransactionService), userRepo, accountRepo)
+			err := s.HandleClaim(context.Background(), c.payload)
+			assert.Equal(t, c.err, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package key
+
+import (
+	"net/mail"
+	"profile/internal/errutils"
+	"regexp"
+	"strings"
+)
+
+// MaxKeysPerAccount is the regulatory cap of keys for an individual account.
+const MaxKeysPerAccount = 5
+
+// maxEmailLen is the longest email the DICT accepts as a key.
+const maxEmailLen = 77
+
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// Normalize checks key.Name against key.Type and rewrites it in the form it is
+// stored with. A cpf key must be cpf, the cpf of the account holder. Random
+// keys are generated by the transaction service, so their name is dropped.
+func Normalize(key *Key, cpf string) error {
+	switch key.Type {
+	case Cpf:
+		name := strings.NewReplacer(".", "", "-", "").Replace(key.Name)
+		if !validCpf(name) {
+			return errutils.ErrInvalidKey
+		}
+		if name != cpf {
+			return errutils.ErrKeyNotOwned
+		}
+		key.Name = name
+	case Phone:
+		if !phonePattern.MatchString(key.Name) {
+			return errutils.ErrInvalidKey
+		}
+	case Email:
+		email := strings.ToLower(strings.TrimSpace(key.Name))
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Name != "" || address.Address != email || len(email) > maxEmailLen {
+			return errutils.ErrInvalidKey
+		}
+		key.Name = email
+	case Random:
+		key.Name = ""
+	default:
+		return errutils.ErrInvalidKey
+	}
+	return nil
+}
+
+func validCpf(cpf string) bool {
+	if len(cpf) != 11 || strings.Count(cpf, cpf[:1]) == 11 {
+		return false
+	}
+	for _, c := range cpf {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return cpfDigit(cpf[:9]) == cpf[9] && cpfDigit(cpf[:10]) == cpf[10]
+}
+
+// cpfDigit is the check digit that follows digits.
+func cpfDigit(digits string) byte {
+	sum := 0
+	for i := range digits {
+		sum += int(digits[i]-'0') * (len(digits) + 1 - i)
+	}
+	digit := 11 - sum%11
+	if digit > 9 {
+		digit = 0
+	}
+	return byte('0' + digit)
+}
@@ -0,0 +1,93 @@
+package key
+
+import (
+	"github.com/stretchr/testify/assert"
+	"profile/internal/errutils"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name string
+		key  *Key
+		cpf  string
+		want string
+		err  error
+	}{
+		{
+			name: "cpf of the account holder",
+			key:  &Key{Type: Cpf, Name: "529.982.247-25"},
+			cpf:  "52998224725",
+			want: "52998224725",
+		},
+		{
+			name: "failed because the cpf check digits are wrong",
+			key:  &Key{Type: Cpf, Name: "52998224726"},
+			cpf:  "52998224726",
+			err:  errutils.ErrInvalidKey,
+		},
+		{
+			name: "failed because all cpf digits are the same",
+			key:  &Key{Type: Cpf, Name: "11111111111"},
+			cpf:  "11111111111",
+			err:  errutils.ErrInvalidKey,
+		},
+		{
+			name: "failed because the cpf has letters",
+			key:  &Key{Type: Cpf, Name: "5299822472a"},
+			cpf:  "5299822472a",
+			err:  errutils.ErrInvalidKey,
+		},
+		{
+			name: "failed because the cpf is not the account holder",
+			key:  &Key{Type: Cpf, Name: "52998224725"},
+			cpf:  "12345678909",
+			err:  errutils.ErrKeyNotOwned,
+		},
+		{
+			name: "e.164 phone",
+			key:  &Key{Type: Phone, Name: "+5511987654321"},
+			want: "+5511987654321",
+		},
+		{
+			name: "failed because the phone has no country code",
+			key:  &Key{Type: Phone, Name: "11987654321"},
+			err:  errutils.ErrInvalidKey,
+		},
+		{
+			name: "email is lowercased",
+			key:  &Key{Type: Email, Name: " Fulano.Tal@Pix.com "},
+			want: "fulano.tal@pix.com",
+		},
+		{
+			name: "failed because the email has a display name",
+			key:  &Key{Type: Email, Name: "Fulano <fulano@pix.com>"},
+			err:  errutils.ErrInvalidKey,
+		},
+		{
+			name: "failed because the email has no domain",
+			key:  &Key{Type: Email, Name: "fulano"},
+			err:  errutils.ErrInvalidKey,
+		},
+		{
+			name: "random key name is dropped",
+			key:  &Key{Type: Random, Name: "0b8e5f0e-4d5c-4b7a-9f3e-2a1c6d7e8f90"},
+			want: "",
+		},
+		{
+			name: "failed because the type is unknown",
+			key:  &Key{Type: "nickname", Name: "fulano"},
+			err:  errutils.ErrInvalidKey,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Normalize(tc.key, tc.cpf)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, tc.want, tc.key.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package keysync
+
+import (
+	"context"
+	"log"
+	"profile/internal/account"
+	"profile/internal/key"
+	"profile/internal/outbox"
+	"profile/internal/user"
+	transpb "profile/proto/transactions/v1"
+)
+
+type Kind string
+
+const (
+	// KindMissing is a key the transaction service does not have.
+	KindMissing Kind = "MISSING"
+	// KindStale is a copy at an older version than the key.
+	KindStale Kind = "STALE"
+	// KindDeleted is a deleted key still in the transaction service.
+	KindDeleted Kind = "DELETED"
+	// KindOrphan is a key only the transaction service has.
+	KindOrphan Kind = "ORPHAN"
+	// KindMismatch is a copy on another account, as when a claim moved the key
+	// and the move is still on its way from key.ClaimsTopic, or a copy that
+	// differs at the same or a later version. It is only reported, the copy
+	// drops the events of the account a claim took the key from.
+	KindMismatch Kind = "MISMATCH"
+)
+
+type Drift struct {
+	KeyID  string
+	Kind   Kind
+	Local  *key.Key
+	Remote *transpb.KeyResponse
+}
+
+// Diff compares the keys with their copies by id. Deleted keys without a copy
+// are in sync.
+func Diff(local []*key.Key, remote []*transpb.KeyResponse) []Drift {
+	remoteByID := make(map[string]*transpb.KeyResponse, len(remote))
+	for _, copied := range remote {
+		remoteByID[copied.Id] = copied
+	}
+	localByID := make(map[string]*key.Key, len(local))
+
+	var drifts []Drift
+	for _, owned := range local {
+		localByID[owned.Id] = owned
+		copied := remoteByID[owned.Id]
+		switch {
+		case copied != nil && copied.GetAccount().GetName() != owned.AccountID:
+			drifts = append(drifts, Drift{KeyID: owned.Id, Kind: KindMismatch, Local: owned, Remote: copied})
+		case owned.DeletedAt.Valid:
+			if copied != nil {
+				drifts = append(drifts, Drift{KeyID: owned.Id, Kind: KindDeleted, Local: owned, Remote: copied})
+			}
+		case copied == nil:
+			drifts = append(drifts, Drift{KeyID: owned.Id, Kind: KindMissing, Local: owned})
+		case !same(owned, copied) && copied.Version < owned.Version:
+			drifts = append(drifts, Drift{KeyID: owned.Id, Kind: KindStale, Local: owned, Remote: copied})
+		case !same(owned, copied):
+			drifts = append(drifts, Drift{KeyID: owned.Id, Kind: KindMismatch, Local: owned, Remote: copied})
+		}
+	}
+
+	for _, copied := range remote {
+		if localByID[copied.Id] == nil {
+			drifts = append(drifts, Drift{KeyID: copied.Id, Kind: KindOrphan, Remote: copied})
+		}
+	}
+	return drifts
+}
+
+func same(owned *key.Key, copied *transpb.KeyResponse) bool {
+	return owned.Name == copied.Name &&
+		string(owned.Type) == copied.Type.String() &&
+		owned.AccountID == copied.GetAccount().GetName()
+}
+
+type Reconciler interface {
+	Run(ctx context.Context) error
+}
+
+type Options func(*reconciler)
+
+func WithBatchSize(size int) Options {
+	return func(r *reconciler) {
+		r.batchSize = size
+	}
+}
+
+// WithRepair publishes the events that bring the transaction service back in
+// line with the keys, instead of only logging the drift.
+func WithRepair(repair bool) Options {
+	return func(r *reconciler) {
+		r.repair = repair
+	}
+}
+
+type reconciler struct {
+	keys      key.Repository
+	accounts  account.Repository
+	users     user.Repository
+	outbox    outbox.Repository
+	backend   transpb.KeysServiceClient
+	batchSize int
+	repair    bool
+	after     int64
+}
+
+// Run compares the keys of the next batch of accounts and starts over from the
+// first account after the last one. A change still waiting in the outbox shows
+// up as drift, repairing it publishes the same version again, which the
+// transaction service drops.
+func (r *reconciler) Run(ctx context.Context) error {
+	accountIDs, err := r.accounts.ListAccountIDs(ctx, r.after, r.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, accountID := range accountIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err = r.reconcile(ctx, accountID); err != nil {
+			log.Printf("failed to reconcile the keys of account %d: %v", accountID, err)
+		}
+	}
+
+	r.after = 0
+	if len(accountIDs) == r.batchSize {
+		r.after = accountIDs[len(accountIDs)-1]
+	}
+	return nil
+}
+
+func (r *reconciler) reconcile(ctx context.Context, accountID int64) error {
+	local, err := r.keys.ListAccountKeys(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	listed, err := r.backend.ListAccountKeys(ctx, &transpb.AccountKeysRequest{AccountId: accountID})
+	if err != nil {
+		return err
+	}
+	remote := listed.GetKeys()
+
+	// a key on another account on one side is looked up by id on the other, so
+	// it is not taken for missing or orphan
+	if ids := missingIDs(local, remote); len(ids) > 0 {
+		moved, err := r.backend.ListKey(ctx, &transpb.ListKeyRequest{KeyId: ids})
+		if err != nil {
+			return err
+		}
+		remote = append(remote, moved.GetKeys()...)
+	}
+	if ids := orphanIDs(local, remote); len(ids) > 0 {
+		moved, err := r.keys.ListKeysWithDeleted(ctx, ids)
+		if err != nil {
+			return err
+		}
+		local = append(local, moved...)
+	}
+
+	var messages []*outbox.Message
+	for _, drift := range Diff(local, remote) {
+		log.Printf("key %s of account %d is out of sync: %s", drift.KeyID, accountID, drift.Kind)
+		if !r.repair || drift.Kind == KindMismatch {
+			continue
+		}
+
+		message, err := r.repairMessage(drift)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, message)
+	}
+	return r.outbox.Enqueue(ctx, messages...)
+}
+
+func (r *reconciler) repairMessage(drift Drift) (*outbox.Message, error) {
+	switch drift.Kind {
+	case KindDeleted:
+		return key.NewMessage(key.OpDelete, drift.Local, nil, "")
+	case KindOrphan:
+		return key.NewMessage(key.OpDelete, &key.Key{
+			Id:        drift.Remote.Id,
+			AccountID: drift.Remote.GetAccount().GetName(),
+			Version:   drift.Remote.Version + 1,
+		}, nil, "")
+	}
+
+	accountModel, err := r.accounts.FindAccountById(drift.Local.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	userModel, err := r.users.FindUserById(accountModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return key.NewMessage(key.OpUpsert, drift.Local, accountModel, userModel.Cpf)
+}
+
+// missingIDs are the live keys without a copy.
+func missingIDs(local []*key.Key, remote []*transpb.KeyResponse) []string {
+	copied := make(map[string]bool, len(remote))
+	for _, k := range remote {
+		copied[k.Id] = true
+	}
+	var ids []string
+	for _, k := range local {
+		if !k.DeletedAt.Valid && !copied[k.Id] {
+			ids = append(ids, k.Id)
+		}
+	}
+	return ids
+}
+
+// orphanIDs are the copies without a key.
+func orphanIDs(local []*key.Key, remote []*transpb.KeyResponse) []string {
+	owned := make(map[string]bool, len(local))
+	for _, k := range local {
+		owned[k.Id] = true
+	}
+	var ids []string
+	for _, k := range remote {
+		if !owned[k.Id] {
+			ids = append(ids, k.Id)
+		}
+	}
+	return ids
+}
+
+func NewReconciler(keys key.Repository, accounts account.Repository, users user.Repository, outboxRepo outbox.Repository, backend transpb.KeysServiceClient, opts ...Options) Reconciler {
+	r := &reconciler{
+		keys:      keys,
+		accounts:  accounts,
+		users:     users,
+		outbox:    outboxRepo,
+		backend:   backend,
+		batchSize: 100,
+	}
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
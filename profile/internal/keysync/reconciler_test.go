@@ -0,0 +1,258 @@
+package keysync
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+	"profile/internal/account"
+	"profile/internal/key"
+	"profile/internal/outbox"
+	"profile/internal/user"
+	transpb "profile/proto/transactions/v1"
+	"testing"
+	"time"
+)
+
+type mockKeysRepo struct {
+	key.Repository
+	mock.Mock
+}
+
+func (m *mockKeysRepo) ListAccountKeys(ctx context.Context, accountID int64) ([]*key.Key, error) {
+	args := m.Called(accountID)
+	return args.Get(0).([]*key.Key), args.Error(1)
+}
+
+func (m *mockKeysRepo) ListKeysWithDeleted(ctx context.Context, ids []string) ([]*key.Key, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]*key.Key), args.Error(1)
+}
+
+type mockAccountRepo struct {
+	account.Repository
+	mock.Mock
+}
+
+func (m *mockAccountRepo) ListAccountIDs(ctx context.Context, after int64, limit int) ([]int64, error) {
+	args := m.Called(after, limit)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *mockAccountRepo) FindAccountById(id int64) (*account.Account, error) {
+	args := m.Called(id)
+	return args.Get(0).(*account.Account), args.Error(1)
+}
+
+type mockUserRepo struct {
+	user.Repository
+	mock.Mock
+}
+
+func (m *mockUserRepo) FindUserById(id string) (*user.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+type mockOutboxRepo struct {
+	outbox.Repository
+	mock.Mock
+	events []key.Event
+}
+
+func (m *mockOutboxRepo) Enqueue(ctx context.Context, messages ...*outbox.Message) error {
+	for _, message := range messages {
+		var keyEvent key.Event
+		_ = json.Unmarshal([]byte(message.Payload), &keyEvent)
+		m.events = append(m.events, keyEvent)
+	}
+	return nil
+}
+
+type mockKeysClient struct {
+	transpb.KeysServiceClient
+	mock.Mock
+}
+
+func (m *mockKeysClient) ListAccountKeys(ctx context.Context, in *transpb.AccountKeysRequest, opts ...grpc.CallOption) (*transpb.ListKeys, error) {
+	args := m.Called(in.AccountId)
+	return &transpb.ListKeys{Keys: args.Get(0).([]*transpb.KeyResponse)}, args.Error(1)
+}
+
+func (m *mockKeysClient) ListKey(ctx context.Context, in *transpb.ListKeyRequest, opts ...grpc.CallOption) (*transpb.ListKeys, error) {
+	args := m.Called(in.KeyId)
+	return &transpb.ListKeys{Keys: args.Get(0).([]*transpb.KeyResponse)}, args.Error(1)
+}
+
+func localKey(id string, accountID int64, name string, version int64) *key.Key {
+	return &key.Key{Id: id, AccountID: accountID, Name: name, Type: key.Email, Version: version}
+}
+
+func remoteKey(id string, accountID int64, name string, version int64) *transpb.KeyResponse {
+	return &transpb.KeyResponse{
+		Id:      id,
+		Account: &transpb.Account{Name: accountID},
+		Name:    name,
+		Type:    transpb.Type_email,
+		Version: version,
+	}
+}
+
+func deleted(k *key.Key) *key.Key {
+	k.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return k
+}
+
+func upsertEvent(id, name string, version int64) key.Event {
+	keyEvent := key.Event{ID: id, Op: key.OpUpsert, Name: name, Type: key.Email, Version: version}
+	keyEvent.Account.Name = 1
+	keyEvent.Account.Cpf = "52998224725"
+	keyEvent.Account.Agency = "0001"
+	keyEvent.Account.Bank = "260"
+	return keyEvent
+}
+
+func deleteEvent(id string, version int64) key.Event {
+	keyEvent := key.Event{ID: id, Op: key.OpDelete, Version: version}
+	keyEvent.Account.Name = 1
+	return keyEvent
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name   string
+		local  []*key.Key
+		remote []*transpb.KeyResponse
+		want   []Kind
+	}{
+		{
+			name:   "in sync",
+			local:  []*key.Key{localKey("1", 1, "a@pix.com", 2), deleted(localKey("2", 1, "b@pix.com", 3))},
+			remote: []*transpb.KeyResponse{remoteKey("1", 1, "a@pix.com", 2)},
+		},
+		{
+			name:  "missing copy",
+			local: []*key.Key{localKey("1", 1, "a@pix.com", 1)},
+			want:  []Kind{KindMissing},
+		},
+		{
+			name:   "copy at an older version",
+			local:  []*key.Key{localKey("1", 1, "new@pix.com", 2)},
+			remote: []*transpb.KeyResponse{remoteKey("1", 1, "a@pix.com", 1)},
+			want:   []Kind{KindStale},
+		},
+		{
+			name:   "copy moved by a claim",
+			local:  []*key.Key{localKey("1", 1, "a@pix.com", 1)},
+			remote: []*transpb.KeyResponse{remoteKey("1", 2, "a@pix.com", 1)},
+			want:   []Kind{KindMismatch},
+		},
+		{
+			name:   "copy moved by a claim before the key changed",
+			local:  []*key.Key{localKey("1", 1, "new@pix.com", 3)},
+			remote: []*transpb.KeyResponse{remoteKey("1", 2, "a@pix.com", 2)},
+			want:   []Kind{KindMismatch},
+		},
+		{
+			name:   "copy moved by a claim before the key was deleted",
+			local:  []*key.Key{deleted(localKey("1", 1, "a@pix.com", 3))},
+			remote: []*transpb.KeyResponse{remoteKey("1", 2, "a@pix.com", 2)},
+			want:   []Kind{KindMismatch},
+		},
+		{
+			name:   "deleted key still copied",
+			local:  []*key.Key{deleted(localKey("1", 1, "a@pix.com", 2))},
+			remote: []*transpb.KeyResponse{remoteKey("1", 1, "a@pix.com", 1)},
+			want:   []Kind{KindDeleted},
+		},
+		{
+			name:   "copy without a key",
+			remote: []*transpb.KeyResponse{remoteKey("1", 1, "a@pix.com", 1)},
+			want:   []Kind{KindOrphan},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []Kind
+			for _, drift := range Diff(tc.local, tc.remote) {
+				got = append(got, drift.Kind)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		name     string
+		repair   bool
+		mockFunc func(keys *mockKeysRepo, accounts *mockAccountRepo, users *mockUserRepo, backend *mockKeysClient)
+		want     []key.Event
+	}{
+		{
+			name:   "repairs the drift",
+			repair: true,
+			mockFunc: func(keys *mockKeysRepo, accounts *mockAccountRepo, users *mockUserRepo, backend *mockKeysClient) {
+				keys.On("ListAccountKeys", int64(1)).Return([]*key.Key{
+					localKey("missing", 1, "a@pix.com", 1),
+					deleted(localKey("deleted", 1, "b@pix.com", 3)),
+				}, nil)
+				backend.On("ListAccountKeys", int64(1)).Return([]*transpb.KeyResponse{
+					remoteKey("deleted", 1, "b@pix.com", 2),
+					remoteKey("orphan", 1, "c@pix.com", 1),
+				}, nil)
+				backend.On("ListKey", []string{"missing"}).Return([]*transpb.KeyResponse{}, nil)
+				keys.On("ListKeysWithDeleted", []string{"orphan"}).Return([]*key.Key{}, nil)
+				accounts.On("FindAccountById", int64(1)).Return(&account.Account{Id: 1, UserID: "u1", Agency: "0001", Bank: "260"}, nil)
+				users.On("FindUserById", "u1").Return(&user.User{Cpf: "52998224725"}, nil)
+			},
+			want: []key.Event{
+				upsertEvent("missing", "a@pix.com", 1),
+				deleteEvent("deleted", 3),
+				deleteEvent("orphan", 2),
+			},
+		},
+		{
+			name: "only reports without repair",
+			mockFunc: func(keys *mockKeysRepo, accounts *mockAccountRepo, users *mockUserRepo, backend *mockKeysClient) {
+				keys.On("ListAccountKeys", int64(1)).Return([]*key.Key{localKey("missing", 1, "a@pix.com", 1)}, nil)
+				backend.On("ListAccountKeys", int64(1)).Return([]*transpb.KeyResponse{}, nil)
+				backend.On("ListKey", []string{"missing"}).Return([]*transpb.KeyResponse{}, nil)
+			},
+		},
+		{
+			name:   "key moved by a claim is not repaired",
+			repair: true,
+			mockFunc: func(keys *mockKeysRepo, accounts *mockAccountRepo, users *mockUserRepo, backend *mockKeysClient) {
+				keys.On("ListAccountKeys", int64(1)).Return([]*key.Key{localKey("claimed", 1, "a@pix.com", 1)}, nil)
+				backend.On("ListAccountKeys", int64(1)).Return([]*transpb.KeyResponse{}, nil)
+				backend.On("ListKey", []string{"claimed"}).Return([]*transpb.KeyResponse{remoteKey("claimed", 2, "a@pix.com", 1)}, nil)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, accounts, users := new(mockKeysRepo), new(mockAccountRepo), new(mockUserRepo)
+			outboxRepo, backend := new(mockOutboxRepo), new(mockKeysClient)
+			accounts.On("ListAccountIDs", int64(0), 10).Return([]int64{1}, nil)
+			tc.mockFunc(keys, accounts, users, backend)
+
+			r := NewReconciler(keys, accounts, users, outboxRepo, backend, WithBatchSize(10), WithRepair(tc.repair))
+			err := r.Run(context.Background())
+			assert.NoError(t, err)
+
+			for i := range outboxRepo.events {
+				outboxRepo.events[i].OccurredAt = time.Time{}
+			}
+			assert.Equal(t, tc.want, outboxRepo.events)
+			keys.AssertExpectations(t)
+			accounts.AssertExpectations(t)
+			users.AssertExpectations(t)
+			backend.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package ledger
+
+import (
+	"github.com/shopspring/decimal"
+	"profile/internal/errutils"
+	"profile/internal/limit"
+	"profile/internal/outbox"
+	proto "profile/proto/profile/v1"
+	"time"
+)
+
+type Direction string
+
+const (
+	Debit  Direction = "DEBIT"
+	Credit Direction = "CREDIT"
+)
+
+type EntryType string
+
+const (
+	TypePix        EntryType = "PIX"
+	TypeReversal   EntryType = "REVERSAL"
+	TypeAdjustment EntryType = "ADJUSTMENT"
+	TypeRefund     EntryType = "REFUND"
+)
+
+// SystemAccountID is the bank's own clearing account, the counterpart of
+// every adjustment. It has no row in accounts.
+const SystemAccountID int64 = 0
+
+type JournalEntry struct {
+	Id            string         `gorm:"primaryKey;type:varchar(36);column:id"`
+	TransactionID string         `gorm:"type:varchar(36);column:transaction_id"`
+	Type          EntryType      `gorm:"type:varchar(20);column:type"`
+	Description   string         `gorm:"type:varchar(255);column:description"`
+	CreatedAt     time.Time      `gorm:"type:datetime;column:created_at"`
+	Lines         []*JournalLine `gorm:"foreignKey:EntryID"`
+}
+
+type JournalLine struct {
+	Id        string          `gorm:"primaryKey;type:varchar(36);column:id"`
+	EntryID   string          `gorm:"type:varchar(36);column:entry_id"`
+	AccountID int64           `gorm:"type:int;column:account_id"`
+	Direction Direction       `gorm:"type:varchar(6);column:direction"`
+	Amount    decimal.Decimal `gorm:"type:decimal(10,2);column:amount"`
+	CreatedAt time.Time       `gorm:"type:datetime;column:created_at"`
+}
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "ACTIVE"
+	HoldCaptured HoldStatus = "CAPTURED"
+	HoldReleased HoldStatus = "RELEASED"
+)
+
+// HoldKind tells the holds of pix sent by the account apart from the blocks
+// of infraction reports, which are not spent by the account and do not count
+// for its limits.
+type HoldKind string
+
+const (
+	HoldPix   HoldKind = "PIX"
+	HoldBlock HoldKind = "BLOCK"
+)
+
+// Hold reserves part of an account balance for a pix that was published but
+// not settled yet. It is captured by the journal entry of the same
+// transaction, or released when the pix fails or the hold expires. Events are
+// written to the outbox together with the hold, and the hold is only created
+// if it fits in the account limits of Policy, when set.
+type Hold struct {
+	Id            string            `gorm:"primaryKey;type:varchar(36);column:id"`
+	AccountID     int64             `gorm:"type:int;column:account_id"`
+	TransactionID string            `gorm:"type:varchar(36);column:transaction_id"`
+	Amount        decimal.Decimal   `gorm:"type:decimal(10,2);column:amount"`
+	Status        HoldStatus        `gorm:"type:varchar(20);column:status"`
+	Kind          HoldKind          `gorm:"type:varchar(20);column:kind"`
+	ExpiresAt     time.Time         `gorm:"type:datetime;column:expires_at"`
+	CreatedAt     time.Time         `gorm:"type:datetime;column:created_at"`
+	UpdatedAt     time.Time         `gorm:"type:datetime;column:updated_at"`
+	Events        []*outbox.Message `gorm:"-"`
+	Policy        *limit.Policy     `gorm:"-"`
+}
+
+// Fences are the fencing tokens of the account locks a caller holds, by
+// account id.
+type Fences map[int64]int64
+
+type Adjustment struct {
+	AccountID   int64
+	Amount      decimal.Decimal
+	Description string
+}
+
+type Reconciliation struct {
+	AccountID      int64
+	AccountBalance decimal.Decimal
+	LedgerBalance  decimal.Decimal
+	Drift          decimal.Decimal
+}
+
+// Delta is the effect of the line on the account balance: customer accounts
+// are liabilities of the bank, so credits increase them and debits decrease them.
+func (l *JournalLine) Delta() decimal.Decimal {
+	if l.Direction == Debit {
+		return l.Amount.Neg()
+	}
+	return l.Amount
+}
+
+func (e *JournalEntry) Validate() error {
+	if e.TransactionID == "" {
+		return errutils.ErrTransactionIDRequired
+	}
+	if len(e.Lines) < 2 {
+		return errutils.ErrUnbalancedEntry
+	}
+
+	debits, credits := decimal.Zero, decimal.Zero
+	for _, line := range e.Lines {
+		if !line.Amount.IsPositive() {
+			return errutils.ErrInvalidAmount
+		}
+		switch line.Direction {
+		case Debit:
+			debits = debits.Add(line.Amount)
+		case Credit:
+			credits = credits.Add(line.Amount)
+		default:
+			return errutils.ErrUnbalancedEntry
+		}
+	}
+	if !debits.Equal(credits) {
+		return errutils.ErrUnbalancedEntry
+	}
+	return nil
+}
+
+// NewTransfer moves amount from one account to another under a single entry.
+func NewTransfer(transactionID string, entryType EntryType, from, to int64, amount decimal.Decimal, description string) *JournalEntry {
+	return &JournalEntry{
+		TransactionID: transactionID,
+		Type:          entryType,
+		Description:   description,
+		Lines: []*JournalLine{
+			{AccountID: from, Direction: Debit, Amount: amount},
+			{AccountID: to, Direction: Credit, Amount: amount},
+		},
+	}
+}
+
+// Reversal mirrors every line of the entry, undoing its effect on balances.
+func (e *JournalEntry) Reversal(description string) *JournalEntry {
+	lines := make([]*JournalLine, len(e.Lines))
+	for i, line := range e.Lines {
+		direction := Debit
+		if line.Direction == Debit {
+			direction = Credit
+		}
+		lines[i] = &JournalLine{AccountID: line.AccountID, Direction: direction, Amount: line.Amount}
+	}
+	return &JournalEntry{
+		TransactionID: e.TransactionID,
+		Type:          TypeReversal,
+		Description:   description,
+		Lines:         lines,
+	}
+}
+
+func ProtoToAdjustment(req *proto.LedgerAdjustment) *Adjustment {
+	return &Adjustment{
+		AccountID:   req.AccountId,
+		Amount:      decimal.NewFromFloat(req.Amount).Round(2),
+		Description: req.Description,
+	}
+}
+
+func ToProto(entry *JournalEntry) *proto.LedgerEntry {
+	lines := make([]*proto.LedgerLine, len(entry.Lines))
+	for i, line := range entry.Lines {
+		lines[i] = &proto.LedgerLine{
+			AccountId: line.AccountID,
+			Direction: string(line.Direction),
+			Amount:    line.Amount.InexactFloat64(),
+		}
+	}
+	return &proto.LedgerEntry{
+		Id:            entry.Id,
+		TransactionId: entry.TransactionID,
+		Type:          string(entry.Type),
+		Description:   entry.Description,
+		Lines:         lines,
+	}
+}
+
+func ToProtoReconciliation(reconciliation *Reconciliation) *proto.Reconciliation {
+	return &proto.Reconciliation{
+		AccountId:      reconciliation.AccountID,
+		AccountBalance: reconciliation.AccountBalance.InexactFloat64(),
+		LedgerBalance:  reconciliation.LedgerBalance.InexactFloat64(),
+		Drift:          reconciliation.Drift.InexactFloat64(),
+	}
+}
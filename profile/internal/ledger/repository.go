@@ -0,0 +1,407 @@
+package ledger
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"profile/internal/cfg"
+	"profile/internal/errutils"
+	"profile/internal/limit"
+	"profile/internal/outbox"
+	"time"
+)
+
+type Repository interface {
+	Post(ctx context.Context, entry *JournalEntry) error
+	FindEntry(ctx context.Context, transactionID string, entryType EntryType) (*JournalEntry, error)
+	ListEntries(ctx context.Context, accountID int64) ([]*JournalEntry, error)
+	Balance(ctx context.Context, accountID int64) (decimal.Decimal, error)
+	AccountBalance(ctx context.Context, accountID int64) (decimal.Decimal, error)
+	CreateHold(ctx context.Context, hold *Hold) error
+	CreateBlock(ctx context.Context, hold *Hold) error
+	MoveHold(ctx context.Context, from, to string) error
+	ReleaseHold(ctx context.Context, transactionID string) error
+	ListExpiredHolds(ctx context.Context, now time.Time) ([]*Hold, error)
+	Fenced(fences Fences) Repository
+}
+
+type repository struct {
+	db     *gorm.DB
+	cfg    *cfg.Config
+	fences Fences
+}
+
+// Post writes the entry and applies its lines to accounts.balance in a single
+// database transaction, so the balance never moves without a journal record.
+// An active hold for the same transaction is captured in that transaction too.
+func (r repository) Post(ctx context.Context, entry *JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&JournalEntry{}).
+			Where("transaction_id = ? AND type = ?", entry.TransactionID, entry.Type).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errutils.ErrEntryAlreadyPosted
+		}
+
+		now := time.Now()
+		entry.Id = uuid.New().String()
+		entry.CreatedAt = now
+		for _, line := range entry.Lines {
+			line.Id = uuid.New().String()
+			line.EntryID = entry.Id
+			line.CreatedAt = now
+		}
+
+		if err = tx.Create(entry).Error; err != nil {
+			return err
+		}
+
+		if err = settleHold(tx, r.fences, entry.TransactionID, HoldCaptured, now); err != nil {
+			return err
+		}
+
+		for _, line := range entry.Lines {
+			if line.AccountID == SystemAccountID {
+				continue
+			}
+			if err = fence(tx, r.fences, line.AccountID); err != nil {
+				return err
+			}
+			delta := line.Delta()
+			query := tx.Table("accounts").Where("id = ?", line.AccountID)
+			if delta.IsNegative() {
+				query = query.Where("balance - held_amount + ? >= 0", delta)
+			}
+			result := query.Updates(map[string]interface{}{
+				"balance":    gorm.Expr("balance + ?", delta),
+				"updated_at": now,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errutils.ErrInsufficientBalance
+			}
+		}
+		return nil
+	})
+}
+
+func (r repository) FindEntry(ctx context.Context, transactionID string, entryType EntryType) (*JournalEntry, error) {
+	var entry JournalEntry
+	result := r.db.WithContext(ctx).Preload("Lines").
+		Where("transaction_id = ? AND type = ?", transactionID, entryType).
+		First(&entry)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errutils.ErrEntryNotFound
+		}
+		return nil, result.Error
+	}
+	return &entry, nil
+}
+
+func (r repository) ListEntries(ctx context.Context, accountID int64) ([]*JournalEntry, error) {
+	var entries []*JournalEntry
+	err := r.db.WithContext(ctx).Preload("Lines").
+		Where("id IN (?)", r.db.Model(&JournalLine{}).Select("entry_id").Where("account_id = ?", accountID)).
+		Order("created_at").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r repository) Balance(ctx context.Context, accountID int64) (decimal.Decimal, error) {
+	var balance decimal.NullDecimal
+	err := r.db.WithContext(ctx).Model(&JournalLine{}).
+		Select("SUM(CASE WHEN direction = ? THEN amount ELSE -amount END)", Credit).
+		Where("account_id = ?", accountID).
+		Scan(&balance).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return balance.Decimal, nil
+}
+
+func (r repository) AccountBalance(ctx context.Context, accountID int64) (decimal.Decimal, error) {
+	var balance decimal.NullDecimal
+	result := r.db.WithContext(ctx).Table("accounts").Select("balance").Where("id = ?", accountID).Scan(&balance)
+	if result.Error != nil {
+		return decimal.Zero, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return decimal.Zero, errutils.ErrInactiveAccount
+	}
+	return balance.Decimal, nil
+}
+
+// CreateHold reserves the amount only if it fits in the available balance,
+// so concurrent holds can never add up to more than the account holds. The
+// hold events go to the outbox in the same transaction.
+//
+// The limits are checked after the account row is updated: its lock queues
+// concurrent holds of the same account, so each one counts the holds the
+// others committed and together they can never go over an aggregate limit.
+func (r repository) CreateHold(ctx context.Context, hold *Hold) error {
+	if !hold.Amount.IsPositive() {
+		return errutils.ErrInvalidAmount
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// a transaction is held once: while its hold is active that hold is
+		// returned, and a released one makes room for the new hold
+		var existing Hold
+		result := tx.Where("transaction_id = ?", hold.TransactionID).Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			switch existing.Status {
+			case HoldActive:
+				*hold = existing
+				return nil
+			case HoldCaptured:
+				return errutils.ErrEntryAlreadyPosted
+			}
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := fence(tx, r.fences, hold.AccountID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result = tx.Table("accounts").
+			Where("id = ? AND balance - held_amount >= ?", hold.AccountID, hold.Amount).
+			Updates(map[string]interface{}{
+				"held_amount": gorm.Expr("held_amount + ?", hold.Amount),
+				"updated_at":  now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errutils.ErrInsufficientBalance
+		}
+
+		if hold.Policy != nil {
+			if err := checkLimits(tx, hold, now); err != nil {
+				return err
+			}
+		}
+
+		hold.Id = uuid.New().String()
+		hold.Status = HoldActive
+		hold.Kind = HoldPix
+		hold.CreatedAt = now
+		hold.UpdatedAt = now
+		if err := tx.Create(hold).Error; err != nil {
+			return err
+		}
+		return outbox.NewRepository(tx, r.cfg).Enqueue(ctx, hold.Events...)
+	})
+}
+
+// CreateBlock reserves as much of the amount as the account has available,
+// possibly nothing, and sets the hold amount to what was reserved. The account
+// row is locked first so the available balance cannot move while it is read.
+func (r repository) CreateBlock(ctx context.Context, hold *Hold) error {
+	if !hold.Amount.IsPositive() {
+		return errutils.ErrInvalidAmount
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Table("accounts").Where("id = ?", hold.AccountID).Update("updated_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errutils.ErrInactiveAccount
+		}
+
+		var available decimal.NullDecimal
+		err := tx.Table("accounts").Select("balance - held_amount").Where("id = ?", hold.AccountID).Scan(&available).Error
+		if err != nil {
+			return err
+		}
+		if available.Decimal.LessThan(hold.Amount) {
+			hold.Amount = decimal.Max(available.Decimal, decimal.Zero)
+		}
+
+		if hold.Amount.IsPositive() {
+			err = tx.Table("accounts").
+				Where("id = ?", hold.AccountID).
+				Update("held_amount", gorm.Expr("held_amount + ?", hold.Amount)).Error
+			if err != nil {
+				return err
+			}
+
+			hold.Id = uuid.New().String()
+			hold.Status = HoldActive
+			hold.Kind = HoldBlock
+			hold.CreatedAt = now
+			hold.UpdatedAt = now
+			if err = tx.Create(hold).Error; err != nil {
+				return err
+			}
+		}
+		return outbox.NewRepository(tx, r.cfg).Enqueue(ctx, hold.Events...)
+	})
+}
+
+// MoveHold hands an active hold over to another transaction, so posting that
+// transaction captures it.
+func (r repository) MoveHold(ctx context.Context, from, to string) error {
+	result := r.db.WithContext(ctx).Model(&Hold{}).
+		Where("transaction_id = ? AND status = ?", from, HoldActive).
+		Updates(map[string]interface{}{"transaction_id": to, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errutils.ErrHoldNotFound
+	}
+	return nil
+}
+
+func (r repository) ReleaseHold(ctx context.Context, transactionID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return settleHold(tx, r.fences, transactionID, HoldReleased, time.Now())
+	})
+}
+
+// Fenced returns a repository whose account writes are checked against the
+// fencing tokens of the locks the caller holds.
+func (r repository) Fenced(fences Fences) Repository {
+	return &repository{
+		db:     r.db,
+		cfg:    r.cfg,
+		fences: fences,
+	}
+}
+
+func (r repository) ListExpiredHolds(ctx context.Context, now time.Time) ([]*Hold, error) {
+	var holds []*Hold
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", HoldActive, now).
+		Find(&holds).Error
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// checkLimits counts what the account sent in the windows of the policy: the
+// active holds and the captured ones, released holds never left the account.
+// Blocks of infraction reports are left out, the account did not send them.
+func checkLimits(tx *gorm.DB, hold *Hold, now time.Time) error {
+	windows := hold.Policy.Windows(now)
+	day, month := windows.Day.In(now.Location()), windows.Month.In(now.Location())
+
+	// outside the night window nothing counts for the nightly limit
+	since, night := month, now.Add(time.Second)
+	if windows.Night != nil {
+		night = windows.Night.In(now.Location())
+		if night.Before(since) {
+			since = night
+		}
+	}
+
+	var usage struct {
+		Daily   decimal.NullDecimal
+		Monthly decimal.NullDecimal
+		Nightly decimal.NullDecimal
+	}
+	err := tx.Model(&Hold{}).
+		Select("SUM(CASE WHEN created_at >= ? THEN amount ELSE 0 END) AS daily, "+
+			"SUM(CASE WHEN created_at >= ? THEN amount ELSE 0 END) AS monthly, "+
+			"SUM(CASE WHEN created_at >= ? THEN amount ELSE 0 END) AS nightly", day, month, night).
+		Where("account_id = ? AND kind = ? AND status IN ? AND created_at >= ?", hold.AccountID, HoldPix, []HoldStatus{HoldActive, HoldCaptured}, since).
+		Scan(&usage).Error
+	if err != nil {
+		return err
+	}
+
+	return hold.Policy.Check(hold.Amount, limit.Usage{
+		Daily:   usage.Daily.Decimal,
+		Monthly: usage.Monthly.Decimal,
+		Nightly: usage.Nightly.Decimal,
+	}, windows.Night != nil)
+}
+
+// fence stores the token of the account lock the write runs under. A greater
+// stored token means a later holder already wrote the account, so this lock
+// expired while the write was on its way and the write is refused. The update
+// keeps the account row locked until the write commits. Accounts without a
+// token in fences are written unchecked.
+func fence(tx *gorm.DB, fences Fences, accountID int64) error {
+	token, ok := fences[accountID]
+	if !ok {
+		return nil
+	}
+
+	result := tx.Table("accounts").
+		Where("id = ? AND fence <= ?", accountID, token).
+		Update("fence", token)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errutils.ErrLockLost
+	}
+	return nil
+}
+
+// settleHold moves an active hold to its final status and gives the amount
+// back to the available balance. Missing or already settled holds are ignored.
+func settleHold(tx *gorm.DB, fences Fences, transactionID string, status HoldStatus, now time.Time) error {
+	var hold Hold
+	result := tx.Where("transaction_id = ? AND status = ?", transactionID, HoldActive).Limit(1).Find(&hold)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	result = tx.Model(&Hold{}).
+		Where("id = ? AND status = ?", hold.Id, HoldActive).
+		Updates(map[string]interface{}{"status": status, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := fence(tx, fences, hold.AccountID); err != nil {
+		return err
+	}
+	return tx.Table("accounts").
+		Where("id = ?", hold.AccountID).
+		Updates(map[string]interface{}{
+			"held_amount": gorm.Expr("held_amount - ?", hold.Amount),
+			"updated_at":  now,
+		}).Error
+}
+
+func NewRepository(db *gorm.DB, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
@@ -0,0 +1,219 @@
+package ledger
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"log"
+	"profile/internal/errutils"
+	"profile/internal/limit"
+	"profile/internal/outbox"
+	"time"
+)
+
+type Service interface {
+	PostPix(ctx context.Context, transactionID string, sender, receiver int64, amount decimal.Decimal) (*JournalEntry, error)
+	PostRefund(ctx context.Context, transactionID, originalTransactionID string, sender, receiver int64, amount decimal.Decimal) (*JournalEntry, error)
+	Reverse(ctx context.Context, transactionID string, description string) (*JournalEntry, error)
+	Adjust(ctx context.Context, req *Adjustment) (*JournalEntry, error)
+	FindEntry(ctx context.Context, transactionID string, entryType EntryType) (*JournalEntry, error)
+	ListEntries(ctx context.Context, accountID int64) ([]*JournalEntry, error)
+	Reconcile(ctx context.Context, accountID int64) (*Reconciliation, error)
+	Hold(ctx context.Context, accountID int64, transactionID string, amount decimal.Decimal, policy *limit.Policy, events ...*outbox.Message) (*Hold, error)
+	Block(ctx context.Context, accountID int64, transactionID string, amount decimal.Decimal, expiresAt time.Time, events ...*outbox.Message) (*Hold, error)
+	MoveHold(ctx context.Context, from, to string) error
+	Release(ctx context.Context, transactionID string) error
+	ReleaseExpiredHolds(ctx context.Context) error
+	// Fenced returns a service whose writes fail with ErrLockLost on accounts
+	// whose lock was taken over after the tokens in fences were handed out.
+	Fenced(fences Fences) Service
+}
+
+type Options func(*service)
+
+func WithHoldTimeout(timeout time.Duration) Options {
+	return func(s *service) {
+		s.holdTimeout = timeout
+	}
+}
+
+type service struct {
+	repo        Repository
+	holdTimeout time.Duration
+}
+
+func (s *service) PostPix(ctx context.Context, transactionID string, sender, receiver int64, amount decimal.Decimal) (*JournalEntry, error) {
+	entry := NewTransfer(transactionID, TypePix, sender, receiver, amount, "pix")
+	if err := s.repo.Post(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// PostRefund moves a refund back from the receiver of the original pix. The
+// original entry must exist, so a refund can never create money.
+func (s *service) PostRefund(ctx context.Context, transactionID, originalTransactionID string, sender, receiver int64, amount decimal.Decimal) (*JournalEntry, error) {
+	if _, err := s.repo.FindEntry(ctx, originalTransactionID, TypePix); err != nil {
+		return nil, err
+	}
+
+	entry := NewTransfer(transactionID, TypeRefund, sender, receiver, amount, "pix refund of "+originalTransactionID)
+	if err := s.repo.Post(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *service) Reverse(ctx context.Context, transactionID string, description string) (*JournalEntry, error) {
+	if transactionID == "" {
+		return nil, errutils.ErrTransactionIDRequired
+	}
+
+	original, err := s.repo.FindEntry(ctx, transactionID, TypePix)
+	if err != nil {
+		return nil, err
+	}
+
+	if description == "" {
+		description = "pix reversal"
+	}
+	entry := original.Reversal(description)
+	if err = s.repo.Post(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *service) Adjust(ctx context.Context, req *Adjustment) (*JournalEntry, error) {
+	if req.AccountID == SystemAccountID {
+		return nil, errutils.ErrInactiveAccount
+	}
+	if req.Amount.IsZero() {
+		return nil, errutils.ErrInvalidAmount
+	}
+
+	transactionID := uuid.New().String()
+	var entry *JournalEntry
+	if req.Amount.IsPositive() {
+		entry = NewTransfer(transactionID, TypeAdjustment, SystemAccountID, req.AccountID, req.Amount, req.Description)
+	} else {
+		entry = NewTransfer(transactionID, TypeAdjustment, req.AccountID, SystemAccountID, req.Amount.Neg(), req.Description)
+	}
+
+	if err := s.repo.Post(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *service) FindEntry(ctx context.Context, transactionID string, entryType EntryType) (*JournalEntry, error) {
+	return s.repo.FindEntry(ctx, transactionID, entryType)
+}
+
+func (s *service) ListEntries(ctx context.Context, accountID int64) ([]*JournalEntry, error) {
+	return s.repo.ListEntries(ctx, accountID)
+}
+
+func (s *service) Reconcile(ctx context.Context, accountID int64) (*Reconciliation, error) {
+	accountBalance, err := s.repo.AccountBalance(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerBalance, err := s.repo.Balance(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reconciliation{
+		AccountID:      accountID,
+		AccountBalance: accountBalance,
+		LedgerBalance:  ledgerBalance,
+		Drift:          accountBalance.Sub(ledgerBalance),
+	}, nil
+}
+
+func (s *service) Hold(ctx context.Context, accountID int64, transactionID string, amount decimal.Decimal, policy *limit.Policy, events ...*outbox.Message) (*Hold, error) {
+	if transactionID == "" {
+		return nil, errutils.ErrTransactionIDRequired
+	}
+
+	hold := &Hold{
+		AccountID:     accountID,
+		TransactionID: transactionID,
+		Amount:        amount,
+		ExpiresAt:     time.Now().Add(s.holdTimeout),
+		Events:        events,
+		Policy:        policy,
+	}
+	if err := s.repo.CreateHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Block holds up to amount of the account until expiresAt. Unlike Hold it
+// takes whatever is available, the returned hold has the amount it got.
+func (s *service) Block(ctx context.Context, accountID int64, transactionID string, amount decimal.Decimal, expiresAt time.Time, events ...*outbox.Message) (*Hold, error) {
+	if transactionID == "" {
+		return nil, errutils.ErrTransactionIDRequired
+	}
+
+	hold := &Hold{
+		AccountID:     accountID,
+		TransactionID: transactionID,
+		Amount:        amount,
+		ExpiresAt:     expiresAt,
+		Events:        events,
+	}
+	if err := s.repo.CreateBlock(ctx, hold); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (s *service) MoveHold(ctx context.Context, from, to string) error {
+	if from == "" || to == "" {
+		return errutils.ErrTransactionIDRequired
+	}
+	return s.repo.MoveHold(ctx, from, to)
+}
+
+func (s *service) Release(ctx context.Context, transactionID string) error {
+	if transactionID == "" {
+		return errutils.ErrTransactionIDRequired
+	}
+	return s.repo.ReleaseHold(ctx, transactionID)
+}
+
+func (s *service) ReleaseExpiredHolds(ctx context.Context) error {
+	holds, err := s.repo.ListExpiredHolds(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, hold := range holds {
+		if err = s.repo.ReleaseHold(ctx, hold.TransactionID); err != nil {
+			log.Printf("failed to release expired hold %s: %v", hold.Id, err)
+		}
+	}
+	return nil
+}
+
+func (s *service) Fenced(fences Fences) Service {
+	return &service{
+		repo:        s.repo.Fenced(fences),
+		holdTimeout: s.holdTimeout,
+	}
+}
+
+func NewService(repo Repository, opts ...Options) Service {
+	s := &service{
+		repo:        repo,
+		holdTimeout: 30 * time.Minute,
+	}
+	for _, f := range opts {
+		f(s)
+	}
+	return s
+}
@@ -0,0 +1,369 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"profile/internal/errutils"
+	"testing"
+	"time"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepo) Post(ctx context.Context, entry *JournalEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *mockRepo) FindEntry(ctx context.Context, transactionID string, entryType EntryType) (*JournalEntry, error) {
+	args := m.Called(transactionID, entryType)
+	return args.Get(0).(*JournalEntry), args.Error(1)
+}
+
+func (m *mockRepo) Balance(ctx context.Context, accountID int64) (decimal.Decimal, error) {
+	args := m.Called(accountID)
+	return args.Get(0).(decimal.Decimal), args.Error(1)
+}
+
+func (m *mockRepo) AccountBalance(ctx context.Context, accountID int64) (decimal.Decimal, error) {
+	args := m.Called(accountID)
+	return args.Get(0).(decimal.Decimal), args.Error(1)
+}
+
+func (m *mockRepo) CreateHold(ctx context.Context, hold *Hold) error {
+	args := m.Called(hold)
+	return args.Error(0)
+}
+
+func (m *mockRepo) ReleaseHold(ctx context.Context, transactionID string) error {
+	args := m.Called(transactionID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) ListExpiredHolds(ctx context.Context, now time.Time) ([]*Hold, error) {
+	args := m.Called()
+	return args.Get(0).([]*Hold), args.Error(1)
+}
+
+func (m *mockRepo) Fenced(fences Fences) Repository {
+	args := m.Called(fences)
+	return args.Get(0).(Repository)
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		entry *JournalEntry
+		err   error
+	}{
+		{
+			name:  "balanced transfer",
+			entry: NewTransfer("tx-1", TypePix, 1, 2, decimal.NewFromInt(10), "pix"),
+			err:   nil,
+		},
+		{
+			name: "unbalanced entry",
+			entry: &JournalEntry{
+				TransactionID: "tx-1",
+				Type:          TypePix,
+				Lines: []*JournalLine{
+					{AccountID: 1, Direction: Debit, Amount: decimal.NewFromInt(10)},
+					{AccountID: 2, Direction: Credit, Amount: decimal.NewFromInt(9)},
+				},
+			},
+			err: errutils.ErrUnbalancedEntry,
+		},
+		{
+			name:  "single line",
+			entry: &JournalEntry{TransactionID: "tx-1", Lines: []*JournalLine{{AccountID: 1, Direction: Debit, Amount: decimal.NewFromInt(10)}}},
+			err:   errutils.ErrUnbalancedEntry,
+		},
+		{
+			name:  "non positive amount",
+			entry: NewTransfer("tx-1", TypePix, 1, 2, decimal.Zero, "pix"),
+			err:   errutils.ErrInvalidAmount,
+		},
+		{
+			name:  "missing transaction id",
+			entry: NewTransfer("", TypePix, 1, 2, decimal.NewFromInt(10), "pix"),
+			err:   errutils.ErrTransactionIDRequired,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.err, c.entry.Validate())
+		})
+	}
+}
+
+func TestReverse(t *testing.T) {
+	cases := []struct {
+		name     string
+		mockFunc func(repo *mockRepo)
+		want     []*JournalLine
+		err      error
+	}{
+		{
+			name: "success",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindEntry", "tx-1", TypePix).
+					Return(NewTransfer("tx-1", TypePix, 1, 2, decimal.NewFromInt(10), "pix"), nil)
+				repo.On("Post", mock.Anything).Return(nil)
+			},
+			want: []*JournalLine{
+				{AccountID: 1, Direction: Credit, Amount: decimal.NewFromInt(10)},
+				{AccountID: 2, Direction: Debit, Amount: decimal.NewFromInt(10)},
+			},
+			err: nil,
+		},
+		{
+			name: "failed because original entry not found",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindEntry", "tx-1", TypePix).
+					Return((*JournalEntry)(nil), errutils.ErrEntryNotFound)
+			},
+			want: nil,
+			err:  errutils.ErrEntryNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			c.mockFunc(repo)
+			s := NewService(repo)
+			got, err := s.Reverse(context.Background(), "tx-1", "")
+			assert.Equal(t, c.err, err)
+			if c.want != nil {
+				assert.Equal(t, TypeReversal, got.Type)
+				assert.Equal(t, c.want, got.Lines)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      *Adjustment
+		mockFunc func(repo *mockRepo)
+		want     []*JournalLine
+		err      error
+	}{
+		{
+			name: "credit adjustment",
+			req:  &Adjustment{AccountID: 1, Amount: decimal.NewFromInt(5), Description: "bonus"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("Post", mock.Anything).Return(nil)
+			},
+			want: []*JournalLine{
+				{AccountID: SystemAccountID, Direction: Debit, Amount: decimal.NewFromInt(5)},
+				{AccountID: 1, Direction: Credit, Amount: decimal.NewFromInt(5)},
+			},
+		},
+		{
+			name: "debit adjustment",
+			req:  &Adjustment{AccountID: 1, Amount: decimal.NewFromInt(-5), Description: "fee"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("Post", mock.Anything).Return(nil)
+			},
+			want: []*JournalLine{
+				{AccountID: 1, Direction: Debit, Amount: decimal.NewFromInt(5)},
+				{AccountID: SystemAccountID, Direction: Credit, Amount: decimal.NewFromInt(5)},
+			},
+		},
+		{
+			name:     "failed because amount is zero",
+			req:      &Adjustment{AccountID: 1, Amount: decimal.Zero},
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrInvalidAmount,
+		},
+		{
+			name: "failed because error in post",
+			req:  &Adjustment{AccountID: 1, Amount: decimal.NewFromInt(-5)},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("Post", mock.Anything).Return(errutils.ErrInsufficientBalance)
+			},
+			err: errutils.ErrInsufficientBalance,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			c.mockFunc(repo)
+			s := NewService(repo)
+			got, err := s.Adjust(context.Background(), c.req)
+			assert.Equal(t, c.err, err)
+			if c.want != nil {
+				assert.Equal(t, TypeAdjustment, got.Type)
+				assert.Equal(t, c.want, got.Lines)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	cases := []struct {
+		name     string
+		mockFunc func(repo *mockRepo)
+		want     *Reconciliation
+		err      error
+	}{
+		{
+			name: "drift between account and journal",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("AccountBalance", int64(1)).Return(decimal.NewFromInt(100), nil)
+				repo.On("Balance", int64(1)).Return(decimal.NewFromInt(90), nil)
+			},
+			want: &Reconciliation{
+				AccountID:      1,
+				AccountBalance: decimal.NewFromInt(100),
+				LedgerBalance:  decimal.NewFromInt(90),
+				Drift:          decimal.NewFromInt(10),
+			},
+		},
+		{
+			name: "failed because error in balance",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("AccountBalance", int64(1)).Return(decimal.NewFromInt(100), nil)
+				repo.On("Balance", int64(1)).Return(decimal.Zero, errors.New("MOCK-ERROR"))
+			},
+			want: nil,
+			err:  errors.New("MOCK-ERROR"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			c.mockFunc(repo)
+			s := NewService(repo)
+			got, err := s.Reconcile(context.Background(), 1)
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestHold(t *testing.T) {
+	cases := []struct {
+		name          string
+		transactionID string
+		mockFunc      func(repo *mockRepo)
+		err           error
+	}{
+		{
+			name:          "success",
+			transactionID: "tx-1",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("CreateHold", mock.MatchedBy(func(hold *Hold) bool {
+					return hold.AccountID == 1 && hold.TransactionID == "tx-1" &&
+						hold.Amount.Equal(decimal.NewFromInt(10)) && hold.ExpiresAt.After(time.Now())
+				})).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name:          "failed because available balance is not enough",
+			transactionID: "tx-1",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("CreateHold", mock.Anything).Return(errutils.ErrInsufficientBalance)
+			},
+			err: errutils.ErrInsufficientBalance,
+		},
+		{
+			name:          "failed because transaction id is missing",
+			transactionID: "",
+			mockFunc:      func(repo *mockRepo) {},
+			err:           errutils.ErrTransactionIDRequired,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			c.mockFunc(repo)
+			s := NewService(repo, WithHoldTimeout(time.Minute))
+			_, err := s.Hold(context.Background(), 1, c.transactionID, decimal.NewFromInt(10), nil)
+			assert.Equal(t, c.err, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFenced(t *testing.T) {
+	repo, fenced := new(mockRepo), new(mockRepo)
+	repo.On("Fenced", Fences{1: 4}).Return(fenced)
+	fenced.On("ReleaseHold", "tx-1").Return(errutils.ErrLockLost)
+
+	s := NewService(repo, WithHoldTimeout(time.Minute))
+	err := s.Fenced(Fences{1: 4}).Release(context.Background(), "tx-1")
+	assert.Equal(t, errutils.ErrLockLost, err)
+	repo.AssertExpectations(t)
+	fenced.AssertExpectations(t)
+}
+
+func TestReleaseExpiredHolds(t *testing.T) {
+	repo := new(mockRepo)
+	repo.On("ListExpiredHolds").Return([]*Hold{
+		{Id: "hold-1", TransactionID: "tx-1"},
+		{Id: "hold-2", TransactionID: "tx-2"},
+	}, nil)
+	repo.On("ReleaseHold", "tx-1").Return(errors.New("MOCK-ERROR"))
+	repo.On("ReleaseHold", "tx-2").Return(nil)
+
+	s := NewService(repo)
+	err := s.ReleaseExpiredHolds(context.Background())
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestPostRefund(t *testing.T) {
+	cases := []struct {
+		name     string
+		mockFunc func(repo *mockRepo)
+		err      error
+	}{
+		{
+			name: "success",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindEntry", "pix-1", TypePix).
+					Return(NewTransfer("pix-1", TypePix, 1, 2, decimal.NewFromInt(10), "pix"), nil)
+				repo.On("Post", mock.MatchedBy(func(entry *JournalEntry) bool {
+					return entry.TransactionID == "refund-1" && entry.Type == TypeRefund &&
+						entry.Lines[0].AccountID == 2 && entry.Lines[1].AccountID == 1
+				})).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name: "failed because original pix was never posted",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindEntry", "pix-1", TypePix).
+					Return((*JournalEntry)(nil), errutils.ErrEntryNotFound)
+			},
+			err: errutils.ErrEntryNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			c.mockFunc(repo)
+			s := NewService(repo)
+			_, err := s.PostRefund(context.Background(), "refund-1", "pix-1", 2, 1, decimal.NewFromInt(4))
+			assert.Equal(t, c.err, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
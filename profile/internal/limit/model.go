@@ -0,0 +1,157 @@
+package limit
+
+import (
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"profile/internal/errutils"
+	proto "profile/proto/profile/v1"
+	"time"
+)
+
+type Type string
+
+const (
+	TypeTransaction Type = "TRANSACTION"
+	TypeDaily       Type = "DAILY"
+	TypeMonthly     Type = "MONTHLY"
+	TypeNightly     Type = "NIGHTLY"
+)
+
+var Types = []Type{TypeTransaction, TypeDaily, TypeMonthly, TypeNightly}
+
+func (t Type) Valid() bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Limit is the pix limit of one type for an account. Decreases take effect at
+// once, an increase asked by the user waits in RequestedAmount until
+// EffectiveAt, the end of the cooling-off period.
+type Limit struct {
+	AccountID       int64               `gorm:"primaryKey;type:int;column:account_id"`
+	Type            Type                `gorm:"primaryKey;type:varchar(20);column:type"`
+	Amount          decimal.Decimal     `gorm:"type:decimal(10,2);column:amount"`
+	RequestedAmount decimal.NullDecimal `gorm:"type:decimal(10,2);column:requested_amount"`
+	EffectiveAt     *time.Time          `gorm:"type:datetime;column:effective_at"`
+	CreatedAt       time.Time           `gorm:"type:datetime;column:created_at"`
+	UpdatedAt       time.Time           `gorm:"type:datetime;column:updated_at"`
+	Maximum         decimal.Decimal     `gorm:"-"`
+}
+
+func (Limit) TableName() string {
+	return "pix_limits"
+}
+
+// Settle applies a requested increase whose cooling-off period is over.
+func (l *Limit) Settle(now time.Time) {
+	if l.RequestedAmount.Valid && l.EffectiveAt != nil && !now.Before(*l.EffectiveAt) {
+		l.Amount = l.RequestedAmount.Decimal
+		l.RequestedAmount = decimal.NullDecimal{}
+		l.EffectiveAt = nil
+	}
+}
+
+type Request struct {
+	AccountID int64
+	Type      Type
+	Amount    decimal.Decimal
+}
+
+// Policy is the set of limits in force for an account. The nightly limit
+// applies from NightStart to NightEnd, hours in Location, and days and months
+// start at midnight in Location too.
+type Policy struct {
+	Transaction decimal.Decimal
+	Daily       decimal.Decimal
+	Monthly     decimal.Decimal
+	Nightly     decimal.Decimal
+	NightStart  int
+	NightEnd    int
+	Location    *time.Location
+}
+
+// Windows are the starts of the periods the aggregate limits are counted in.
+// Night is nil outside the nightly window.
+type Windows struct {
+	Day   time.Time
+	Month time.Time
+	Night *time.Time
+}
+
+// Usage is what the account already sent in each window.
+type Usage struct {
+	Daily   decimal.Decimal
+	Monthly decimal.Decimal
+	Nightly decimal.Decimal
+}
+
+func (p *Policy) Windows(now time.Time) Windows {
+	local := now.In(p.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, p.Location)
+	windows := Windows{
+		Day:   day,
+		Month: time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, p.Location),
+	}
+
+	switch hour := local.Hour(); {
+	case hour >= p.NightStart:
+		night := day.Add(time.Duration(p.NightStart) * time.Hour)
+		windows.Night = &night
+	case hour < p.NightEnd:
+		night := day.AddDate(0, 0, -1).Add(time.Duration(p.NightStart) * time.Hour)
+		windows.Night = &night
+	}
+	return windows
+}
+
+// Check tells whether amount can be sent on top of usage. night is whether
+// the pix is sent inside the nightly window.
+func (p *Policy) Check(amount decimal.Decimal, usage Usage, night bool) error {
+	switch {
+	case amount.GreaterThan(p.Transaction):
+		return errutils.ErrTransactionLimitExceeded
+	case night && usage.Nightly.Add(amount).GreaterThan(p.Nightly):
+		return errutils.ErrNightlyLimitExceeded
+	case usage.Daily.Add(amount).GreaterThan(p.Daily):
+		return errutils.ErrDailyLimitExceeded
+	case usage.Monthly.Add(amount).GreaterThan(p.Monthly):
+		return errutils.ErrMonthlyLimitExceeded
+	}
+	return nil
+}
+
+func ProtoToRequest(req *proto.PixLimitRequest) *Request {
+	return &Request{
+		AccountID: req.AccountId,
+		Type:      Type(req.Type),
+		Amount:    decimal.NewFromFloat(req.Amount).Round(2),
+	}
+}
+
+func ToProto(limit *Limit) *proto.PixLimit {
+	pb := &proto.PixLimit{
+		AccountId: limit.AccountID,
+		Type:      string(limit.Type),
+		Amount:    limit.Amount.InexactFloat64(),
+		Maximum:   limit.Maximum.InexactFloat64(),
+	}
+	if limit.RequestedAmount.Valid {
+		pb.RequestedAmount = limit.RequestedAmount.Decimal.InexactFloat64()
+	}
+	if limit.EffectiveAt != nil {
+		pb.EffectiveAt = timestamppb.New(*limit.EffectiveAt)
+	}
+	return pb
+}
+
+func ToProtoList(limits []*Limit) *proto.PixLimits {
+	list := &proto.PixLimits{Limits: make([]*proto.PixLimit, len(limits))}
+	for i := range limits {
+		list.Limits[i] = ToProto(limits[i])
+	}
+	return list
+}
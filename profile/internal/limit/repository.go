@@ -0,0 +1,63 @@
+package limit
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"profile/internal/cfg"
+	"profile/internal/errutils"
+	"time"
+)
+
+type Repository interface {
+	List(ctx context.Context, accountID int64) ([]*Limit, error)
+	Create(ctx context.Context, limit *Limit) error
+	Update(ctx context.Context, limit *Limit, previous time.Time) error
+}
+
+type repository struct {
+	db  *gorm.DB
+	cfg *cfg.Config
+}
+
+func (r repository) List(ctx context.Context, accountID int64) ([]*Limit, error) {
+	var limits []*Limit
+	if err := r.db.WithContext(ctx).Where("account_id = ?", accountID).Find(&limits).Error; err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+func (r repository) Create(ctx context.Context, limit *Limit) error {
+	now := time.Now()
+	limit.CreatedAt = now
+	limit.UpdatedAt = now
+	return r.db.WithContext(ctx).Create(limit).Error
+}
+
+// Update writes the limit as long as nobody changed it since previous, its
+// UpdatedAt when it was read.
+func (r repository) Update(ctx context.Context, limit *Limit, previous time.Time) error {
+	limit.UpdatedAt = time.Now()
+	result := r.db.WithContext(ctx).Model(&Limit{}).
+		Where("account_id = ? AND type = ? AND updated_at = ?", limit.AccountID, limit.Type, previous).
+		Updates(map[string]interface{}{
+			"amount":           limit.Amount,
+			"requested_amount": limit.RequestedAmount,
+			"effective_at":     limit.EffectiveAt,
+			"updated_at":       limit.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errutils.ErrLimitConflict
+	}
+	return nil
+}
+
+func NewRepository(db *gorm.DB, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
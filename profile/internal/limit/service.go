@@ -0,0 +1,168 @@
+package limit
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"profile/internal/errutils"
+	"time"
+)
+
+type Service interface {
+	List(ctx context.Context, accountID int64) ([]*Limit, error)
+	Policy(ctx context.Context, accountID int64) (*Policy, error)
+	Request(ctx context.Context, req *Request) (*Limit, error)
+}
+
+type Options func(*service)
+
+// WithDefaults sets the limits of accounts that never changed them.
+func WithDefaults(defaults map[Type]decimal.Decimal) Options {
+	return func(s *service) {
+		for t, amount := range defaults {
+			s.defaults[t] = amount
+		}
+	}
+}
+
+// WithMaximums caps what a user can raise each limit to.
+func WithMaximums(maximums map[Type]decimal.Decimal) Options {
+	return func(s *service) {
+		for t, amount := range maximums {
+			s.maximums[t] = amount
+		}
+	}
+}
+
+func WithCoolingOff(coolingOff time.Duration) Options {
+	return func(s *service) {
+		s.coolingOff = coolingOff
+	}
+}
+
+func WithNightWindow(start, end int, location *time.Location) Options {
+	return func(s *service) {
+		s.nightStart = start
+		s.nightEnd = end
+		s.location = location
+	}
+}
+
+type service struct {
+	repo       Repository
+	defaults   map[Type]decimal.Decimal
+	maximums   map[Type]decimal.Decimal
+	coolingOff time.Duration
+	nightStart int
+	nightEnd   int
+	location   *time.Location
+}
+
+// List returns every limit of the account, the defaults for the ones it never
+// changed.
+func (s *service) List(ctx context.Context, accountID int64) ([]*Limit, error) {
+	stored, err := s.repo.List(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[Type]*Limit, len(stored))
+	for _, limit := range stored {
+		byType[limit.Type] = limit
+	}
+
+	now := time.Now()
+	limits := make([]*Limit, len(Types))
+	for i, t := range Types {
+		limit, ok := byType[t]
+		if !ok {
+			limit = &Limit{AccountID: accountID, Type: t, Amount: s.defaults[t]}
+		}
+		limit.Settle(now)
+		limit.Maximum = s.maximums[t]
+		limits[i] = limit
+	}
+	return limits, nil
+}
+
+func (s *service) Policy(ctx context.Context, accountID int64) (*Policy, error) {
+	limits, err := s.List(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{NightStart: s.nightStart, NightEnd: s.nightEnd, Location: s.location}
+	for _, limit := range limits {
+		switch limit.Type {
+		case TypeTransaction:
+			policy.Transaction = limit.Amount
+		case TypeDaily:
+			policy.Daily = limit.Amount
+		case TypeMonthly:
+			policy.Monthly = limit.Amount
+		case TypeNightly:
+			policy.Nightly = limit.Amount
+		}
+	}
+	return policy, nil
+}
+
+// Request changes a limit of the account. Lowering it takes effect at once and
+// drops any increase still waiting, raising it only after the cooling-off
+// period, so a stolen session cannot raise a limit and drain the account.
+func (s *service) Request(ctx context.Context, req *Request) (*Limit, error) {
+	if !req.Type.Valid() || !req.Amount.IsPositive() {
+		return nil, errutils.ErrInvalidLimit
+	}
+	if req.Amount.GreaterThan(s.maximums[req.Type]) {
+		return nil, errutils.ErrLimitAboveMaximum
+	}
+
+	limits, err := s.List(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var limit *Limit
+	for _, l := range limits {
+		if l.Type == req.Type {
+			limit = l
+		}
+	}
+
+	previous := limit.UpdatedAt
+	if req.Amount.GreaterThan(limit.Amount) {
+		effectiveAt := time.Now().Add(s.coolingOff)
+		limit.RequestedAmount = decimal.NewNullDecimal(req.Amount)
+		limit.EffectiveAt = &effectiveAt
+	} else {
+		limit.Amount = req.Amount
+		limit.RequestedAmount = decimal.NullDecimal{}
+		limit.EffectiveAt = nil
+	}
+
+	if limit.CreatedAt.IsZero() {
+		err = s.repo.Create(ctx, limit)
+	} else {
+		err = s.repo.Update(ctx, limit, previous)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+func NewService(repo Repository, opts ...Options) Service {
+	s := &service{
+		repo:       repo,
+		defaults:   make(map[Type]decimal.Decimal),
+		maximums:   make(map[Type]decimal.Decimal),
+		coolingOff: 24 * time.Hour,
+		nightStart: 20,
+		nightEnd:   6,
+		location:   time.Local,
+	}
+	for _, f := range opts {
+		f(s)
+	}
+	return s
+}
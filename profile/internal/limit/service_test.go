@@ -0,0 +1,254 @@
+package limit
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"profile/internal/errutils"
+	"testing"
+	"time"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepo) List(ctx context.Context, accountID int64) ([]*Limit, error) {
+	args := m.Called(accountID)
+	return args.Get(0).([]*Limit), args.Error(1)
+}
+
+func (m *mockRepo) Create(ctx context.Context, limit *Limit) error {
+	args := m.Called(limit)
+	return args.Error(0)
+}
+
+func (m *mockRepo) Update(ctx context.Context, limit *Limit, previous time.Time) error {
+	args := m.Called(limit, previous)
+	return args.Error(0)
+}
+
+func newService(repo Repository) Service {
+	return NewService(repo,
+		WithDefaults(map[Type]decimal.Decimal{
+			TypeTransaction: decimal.NewFromInt(500),
+			TypeDaily:       decimal.NewFromInt(1000),
+			TypeMonthly:     decimal.NewFromInt(5000),
+			TypeNightly:     decimal.NewFromInt(100),
+		}),
+		WithMaximums(map[Type]decimal.Decimal{
+			TypeTransaction: decimal.NewFromInt(5000),
+			TypeDaily:       decimal.NewFromInt(10000),
+			TypeMonthly:     decimal.NewFromInt(50000),
+			TypeNightly:     decimal.NewFromInt(1000),
+		}),
+		WithCoolingOff(24*time.Hour))
+}
+
+func TestWindows(t *testing.T) {
+	location := time.FixedZone("BRT", -3*60*60)
+	policy := &Policy{NightStart: 20, NightEnd: 6, Location: location}
+
+	cases := []struct {
+		name  string
+		now   time.Time
+		night *time.Time
+	}{
+		{
+			name:  "day time has no night window",
+			now:   time.Date(2023, 12, 15, 14, 0, 0, 0, location),
+			night: nil,
+		},
+		{
+			name:  "night started today",
+			now:   time.Date(2023, 12, 15, 22, 0, 0, 0, location),
+			night: timePtr(time.Date(2023, 12, 15, 20, 0, 0, 0, location)),
+		},
+		{
+			name:  "night started yesterday",
+			now:   time.Date(2023, 12, 1, 3, 0, 0, 0, location),
+			night: timePtr(time.Date(2023, 11, 30, 20, 0, 0, 0, location)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			windows := policy.Windows(c.now.UTC())
+			assert.True(t, windows.Day.Equal(time.Date(c.now.Year(), c.now.Month(), c.now.Day(), 0, 0, 0, 0, location)))
+			assert.True(t, windows.Month.Equal(time.Date(c.now.Year(), c.now.Month(), 1, 0, 0, 0, 0, location)))
+			if c.night == nil {
+				assert.Nil(t, windows.Night)
+			} else {
+				assert.True(t, c.night.Equal(*windows.Night))
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestCheck(t *testing.T) {
+	policy := &Policy{
+		Transaction: decimal.NewFromInt(500),
+		Daily:       decimal.NewFromInt(1000),
+		Monthly:     decimal.NewFromInt(5000),
+		Nightly:     decimal.NewFromInt(100),
+	}
+
+	cases := []struct {
+		name   string
+		amount int64
+		usage  Usage
+		night  bool
+		err    error
+	}{
+		{
+			name:   "fits every limit",
+			amount: 500,
+			usage:  Usage{Daily: decimal.NewFromInt(500), Monthly: decimal.NewFromInt(4500)},
+			err:    nil,
+		},
+		{
+			name:   "failed because of the per transaction limit",
+			amount: 501,
+			err:    errutils.ErrTransactionLimitExceeded,
+		},
+		{
+			name:   "failed because of the daily limit",
+			amount: 300,
+			usage:  Usage{Daily: decimal.NewFromInt(800), Monthly: decimal.NewFromInt(800)},
+			err:    errutils.ErrDailyLimitExceeded,
+		},
+		{
+			name:   "failed because of the monthly limit",
+			amount: 300,
+			usage:  Usage{Monthly: decimal.NewFromInt(4800)},
+			err:    errutils.ErrMonthlyLimitExceeded,
+		},
+		{
+			name:   "failed because of the nightly limit",
+			amount: 60,
+			usage:  Usage{Nightly: decimal.NewFromInt(50)},
+			night:  true,
+			err:    errutils.ErrNightlyLimitExceeded,
+		},
+		{
+			name:   "nightly limit does not apply during the day",
+			amount: 60,
+			usage:  Usage{Nightly: decimal.NewFromInt(50)},
+			night:  false,
+			err:    nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := policy.Check(decimal.NewFromInt(c.amount), c.usage, c.night)
+			assert.Equal(t, c.err, err)
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	repo := new(mockRepo)
+	repo.On("List", int64(1)).Return([]*Limit{
+		{AccountID: 1, Type: TypeDaily, Amount: decimal.NewFromInt(2000),
+			RequestedAmount: decimal.NewNullDecimal(decimal.NewFromInt(3000)), EffectiveAt: &past},
+		{AccountID: 1, Type: TypeNightly, Amount: decimal.NewFromInt(50),
+			RequestedAmount: decimal.NewNullDecimal(decimal.NewFromInt(500)), EffectiveAt: &future},
+	}, nil)
+
+	policy, err := newService(repo).Policy(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.True(t, policy.Transaction.Equal(decimal.NewFromInt(500)), "default")
+	assert.True(t, policy.Daily.Equal(decimal.NewFromInt(3000)), "increase after the cooling-off period")
+	assert.True(t, policy.Monthly.Equal(decimal.NewFromInt(5000)), "default")
+	assert.True(t, policy.Nightly.Equal(decimal.NewFromInt(50)), "increase still cooling off")
+}
+
+func TestRequest(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name     string
+		req      *Request
+		mockFunc func(repo *mockRepo)
+		check    func(t *testing.T, limit *Limit)
+		err      error
+	}{
+		{
+			name: "increase waits for the cooling-off period",
+			req:  &Request{AccountID: 1, Type: TypeDaily, Amount: decimal.NewFromInt(2000)},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("List", int64(1)).Return([]*Limit{}, nil)
+				repo.On("Create", mock.Anything).Return(nil)
+			},
+			check: func(t *testing.T, limit *Limit) {
+				assert.True(t, limit.Amount.Equal(decimal.NewFromInt(1000)))
+				assert.True(t, limit.RequestedAmount.Decimal.Equal(decimal.NewFromInt(2000)))
+				assert.WithinDuration(t, time.Now().Add(24*time.Hour), *limit.EffectiveAt, time.Minute)
+			},
+		},
+		{
+			name: "decrease takes effect at once and drops the pending increase",
+			req:  &Request{AccountID: 1, Type: TypeNightly, Amount: decimal.NewFromInt(40)},
+			mockFunc: func(repo *mockRepo) {
+				future := time.Now().Add(time.Hour)
+				repo.On("List", int64(1)).Return([]*Limit{
+					{AccountID: 1, Type: TypeNightly, Amount: decimal.NewFromInt(100), CreatedAt: updatedAt, UpdatedAt: updatedAt,
+						RequestedAmount: decimal.NewNullDecimal(decimal.NewFromInt(500)), EffectiveAt: &future},
+				}, nil)
+				repo.On("Update", mock.Anything, updatedAt).Return(nil)
+			},
+			check: func(t *testing.T, limit *Limit) {
+				assert.True(t, limit.Amount.Equal(decimal.NewFromInt(40)))
+				assert.False(t, limit.RequestedAmount.Valid)
+				assert.Nil(t, limit.EffectiveAt)
+			},
+		},
+		{
+			name: "failed because limit was changed concurrently",
+			req:  &Request{AccountID: 1, Type: TypeMonthly, Amount: decimal.NewFromInt(100)},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("List", int64(1)).Return([]*Limit{
+					{AccountID: 1, Type: TypeMonthly, Amount: decimal.NewFromInt(5000), CreatedAt: updatedAt, UpdatedAt: updatedAt},
+				}, nil)
+				repo.On("Update", mock.Anything, updatedAt).Return(errutils.ErrLimitConflict)
+			},
+			err: errutils.ErrLimitConflict,
+		},
+		{
+			name:     "failed because amount is above the maximum",
+			req:      &Request{AccountID: 1, Type: TypeNightly, Amount: decimal.NewFromInt(1001)},
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrLimitAboveMaximum,
+		},
+		{
+			name:     "failed because type is unknown",
+			req:      &Request{AccountID: 1, Type: "WEEKLY", Amount: decimal.NewFromInt(10)},
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrInvalidLimit,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			c.mockFunc(repo)
+			got, err := newService(repo).Request(context.Background(), c.req)
+			assert.Equal(t, c.err, err)
+			if c.check != nil {
+				c.check(t, got)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package lookup
+
+import "time"
+
+// Lookup is what the payer saw about the receiver of a key. Its Token binds a
+// pix to ReceiverAccount, so the pix fails if the key moved meanwhile.
+type Lookup struct {
+	Token           string    `json:"token"`
+	PayerAccount    int64     `json:"payer_account"`
+	Key             string    `json:"key"`
+	Type            string    `json:"type"`
+	ReceiverAccount int64     `json:"receiver_account"`
+	Name            string    `json:"name"`
+	MaskedCpf       string    `json:"masked_cpf"`
+	Bank            string    `json:"bank"`
+	Agency          string    `json:"agency"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// MaskCpf keeps the middle six digits, as in ***.456.789-**.
+func MaskCpf(cpf string) string {
+	if len(cpf) != 11 {
+		return ""
+	}
+	return "***." + cpf[3:6] + "." + cpf[6:9] + "-**"
+}
@@ -0,0 +1,67 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"profile/internal/errutils"
+	"time"
+)
+
+type Repository interface {
+	Save(ctx context.Context, lookup *Lookup) error
+	Find(ctx context.Context, token string) (*Lookup, error)
+}
+
+type Options func(*repository)
+
+func WithTTL(ttl time.Duration) Options {
+	return func(r *repository) {
+		r.ttl = ttl
+	}
+}
+
+type repository struct {
+	client *redis.Client
+	ttl    time.Duration
+	prefix string
+}
+
+// Save stores the lookup until it expires and sets its ExpiresAt.
+func (r *repository) Save(ctx context.Context, lookup *Lookup) error {
+	lookup.ExpiresAt = time.Now().Add(r.ttl)
+	payload, err := json.Marshal(lookup)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, r.prefix+lookup.Token, payload, r.ttl).Err()
+}
+
+func (r *repository) Find(ctx context.Context, token string) (*Lookup, error) {
+	stored, err := r.client.Get(ctx, r.prefix+token).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errutils.ErrLookupNotFound
+		}
+		return nil, err
+	}
+
+	var lookup Lookup
+	if err = json.Unmarshal(stored, &lookup); err != nil {
+		return nil, err
+	}
+	return &lookup, nil
+}
+
+func NewRepository(client *redis.Client, opts ...Options) Repository {
+	r := &repository{
+		client: client,
+		ttl:    5 * time.Minute,
+		prefix: "lookup:",
+	}
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
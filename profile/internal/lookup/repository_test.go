@@ -0,0 +1,39 @@
+package lookup
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"profile/internal/errutils"
+	"testing"
+	"time"
+)
+
+func TestFind(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	repo := NewRepository(redis.NewClient(&redis.Options{Addr: server.Addr()}), WithTTL(time.Minute))
+
+	saved := &Lookup{Token: "token", PayerAccount: 1, Key: "fulano@pix.com", ReceiverAccount: 2}
+	require.NoError(t, repo.Save(ctx, saved))
+	assert.False(t, saved.ExpiresAt.IsZero())
+
+	found, err := repo.Find(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), found.ReceiverAccount)
+	assert.True(t, saved.ExpiresAt.Equal(found.ExpiresAt))
+
+	_, err = repo.Find(ctx, "other")
+	assert.Equal(t, errutils.ErrLookupNotFound, err)
+
+	server.FastForward(time.Minute)
+	_, err = repo.Find(ctx, "token")
+	assert.Equal(t, errutils.ErrLookupNotFound, err)
+}
+
+func TestMaskCpf(t *testing.T) {
+	assert.Equal(t, "***.456.789-**", MaskCpf("12345678909"))
+	assert.Equal(t, "", MaskCpf("123"))
+}
@@ -0,0 +1,165 @@
+package med
+
+import (
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"profile/internal/outbox"
+	proto "profile/proto/profile/v1"
+	"time"
+)
+
+// EventsTopic carries every status change of an infraction report.
+const EventsTopic = "med_events_topic"
+
+// Status of a report. An ACCEPTED report waits for its refund, which ends it
+// REFUNDED, or UNRECOVERED when nothing was blocked or the refund deadline
+// passed.
+type Status string
+
+const (
+	StatusOpen        Status = "OPEN"
+	StatusAccepted    Status = "ACCEPTED"
+	StatusRejected    Status = "REJECTED"
+	StatusRefunded    Status = "REFUNDED"
+	StatusUnrecovered Status = "UNRECOVERED"
+)
+
+// Report is an infraction report (MED) over a pix the reporter sent. The
+// blocked amount is a ledger hold on the receiver account, keyed by
+// HoldTransactionID: the report id until a refund is attempted, then the id
+// of that refund so settling it captures the hold.
+type Report struct {
+	Id                  string          `gorm:"primaryKey;type:varchar(36);column:id"`
+	TransactionID       string          `gorm:"type:varchar(36);column:transaction_id"`
+	ReporterAccountID   int64           `gorm:"type:int;column:reporter_account_id"`
+	ReceiverAccountID   int64           `gorm:"type:int;column:receiver_account_id"`
+	Amount              decimal.Decimal `gorm:"type:decimal(10,2);column:amount"`
+	BlockedAmount       decimal.Decimal `gorm:"type:decimal(10,2);column:blocked_amount"`
+	Reason              string          `gorm:"type:nvarchar(1000);column:reason"`
+	Status              Status          `gorm:"type:varchar(20);column:status"`
+	Analyst             string          `gorm:"type:varchar(255);column:analyst"`
+	ResolutionNote      string          `gorm:"type:nvarchar(1000);column:resolution_note"`
+	HoldTransactionID   string          `gorm:"type:varchar(36);column:hold_transaction_id"`
+	RefundTransactionID string          `gorm:"type:varchar(36);column:refund_transaction_id"`
+	RefundAttempts      int             `gorm:"type:int;column:refund_attempts"`
+	LastError           string          `gorm:"type:nvarchar(1000);column:last_error"`
+	AnalysisDueAt       time.Time       `gorm:"type:datetime;column:analysis_due_at"`
+	RefundDueAt         *time.Time      `gorm:"type:datetime;column:refund_due_at"`
+	NextAttemptAt       *time.Time      `gorm:"type:datetime;column:next_attempt_at"`
+	ResolvedAt          *time.Time      `gorm:"type:datetime;column:resolved_at"`
+	CreatedAt           time.Time       `gorm:"type:datetime;column:created_at"`
+	UpdatedAt           time.Time       `gorm:"type:datetime;column:updated_at"`
+}
+
+func (Report) TableName() string {
+	return "infraction_reports"
+}
+
+type Event struct {
+	ID                  string          `json:"id"`
+	TransactionID       string          `json:"transaction_id"`
+	Status              Status          `json:"status"`
+	ReporterAccountID   int64           `json:"reporter_account_id"`
+	ReceiverAccountID   int64           `json:"receiver_account_id"`
+	Amount              decimal.Decimal `json:"amount"`
+	BlockedAmount       decimal.Decimal `json:"blocked_amount"`
+	RefundTransactionID string          `json:"refund_transaction_id,omitempty"`
+	OccurredAt          time.Time       `json:"occurred_at"`
+}
+
+// NewMessage builds the outbox message of the report at its current status.
+func NewMessage(report *Report) (*outbox.Message, error) {
+	payload, err := json.Marshal(Event{
+		ID:                  report.Id,
+		TransactionID:       report.TransactionID,
+		Status:              report.Status,
+		ReporterAccountID:   report.ReporterAccountID,
+		ReceiverAccountID:   report.ReceiverAccountID,
+		Amount:              report.Amount,
+		BlockedAmount:       report.BlockedAmount,
+		RefundTransactionID: report.RefundTransactionID,
+		OccurredAt:          time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outbox.NewMessage(report.ReceiverAccountID, EventsTopic, payload), nil
+}
+
+type ReportRequest struct {
+	TransactionID string
+	AccountID     int64
+	Reason        string
+}
+
+type ListRequest struct {
+	AccountID int64
+	Status    Status
+	Limit     int
+}
+
+type Resolution struct {
+	ID      string
+	Analyst string
+	Accept  bool
+	Note    string
+}
+
+func ProtoToReportRequest(req *proto.InfractionReportRequest) *ReportRequest {
+	return &ReportRequest{
+		TransactionID: req.TransactionId,
+		AccountID:     req.AccountId,
+		Reason:        req.Reason,
+	}
+}
+
+func ProtoToListRequest(req *proto.ListInfractionReportsRequest) *ListRequest {
+	return &ListRequest{
+		AccountID: req.AccountId,
+		Status:    Status(req.Status),
+		Limit:     int(req.Limit),
+	}
+}
+
+func ProtoToResolution(req *proto.InfractionResolution) *Resolution {
+	return &Resolution{
+		ID:      req.Id,
+		Analyst: req.Analyst,
+		Accept:  req.Accept,
+		Note:    req.Note,
+	}
+}
+
+func ToProto(report *Report) *proto.InfractionReport {
+	pb := &proto.InfractionReport{
+		Id:                  report.Id,
+		TransactionId:       report.TransactionID,
+		ReporterAccountId:   report.ReporterAccountID,
+		ReceiverAccountId:   report.ReceiverAccountID,
+		Amount:              report.Amount.InexactFloat64(),
+		BlockedAmount:       report.BlockedAmount.InexactFloat64(),
+		Reason:              report.Reason,
+		Status:              string(report.Status),
+		Analyst:             report.Analyst,
+		ResolutionNote:      report.ResolutionNote,
+		RefundTransactionId: report.RefundTransactionID,
+		AnalysisDueAt:       timestamppb.New(report.AnalysisDueAt),
+		CreatedAt:           timestamppb.New(report.CreatedAt),
+	}
+	if report.RefundDueAt != nil {
+		pb.RefundDueAt = timestamppb.New(*report.RefundDueAt)
+	}
+	if report.ResolvedAt != nil {
+		pb.ResolvedAt = timestamppb.New(*report.ResolvedAt)
+	}
+	return pb
+}
+
+func ToProtoList(reports []*Report) *proto.InfractionReports {
+	list := &proto.InfractionReports{Reports: make([]*proto.InfractionReport, len(reports))}
+	for i := range reports {
+		list.Reports[i] = ToProto(reports[i])
+	}
+	return list
+}
@@ -0,0 +1,141 @@
+package med
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"profile/internal/cfg"
+	"profile/internal/errutils"
+	"profile/internal/outbox"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, report *Report, events ...*outbox.Message) error
+	Find(ctx context.Context, id string) (*Report, error)
+	HasActive(ctx context.Context, transactionID string) (bool, error)
+	List(ctx context.Context, req *ListRequest) ([]*Report, error)
+	ListDueRefunds(ctx context.Context, now time.Time, limit int) ([]*Report, error)
+	Claim(ctx context.Context, report *Report, now, until time.Time) (bool, error)
+	Update(ctx context.Context, report *Report, from Status, events ...*outbox.Message) error
+}
+
+type repository struct {
+	db  *gorm.DB
+	cfg *cfg.Config
+}
+
+func (r repository) Create(ctx context.Context, report *Report, events ...*outbox.Message) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		report.CreatedAt = now
+		report.UpdatedAt = now
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		return outbox.NewRepository(tx, r.cfg).Enqueue(ctx, events...)
+	})
+}
+
+func (r repository) Find(ctx context.Context, id string) (*Report, error) {
+	var report Report
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&report).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errutils.ErrReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// HasActive is whether the transaction has a report that was not rejected.
+func (r repository) HasActive(ctx context.Context, transactionID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Report{}).
+		Where("transaction_id = ? AND status <> ?", transactionID, StatusRejected).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// List returns the oldest reports first, the order analysts work them.
+func (r repository) List(ctx context.Context, req *ListRequest) ([]*Report, error) {
+	query := r.db.WithContext(ctx).Model(&Report{})
+	if req.AccountID != 0 {
+		query = query.Where("reporter_account_id = ? OR receiver_account_id = ?", req.AccountID, req.AccountID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var reports []*Report
+	if err := query.Order("created_at").Limit(req.Limit).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (r repository) ListDueRefunds(ctx context.Context, now time.Time, limit int) ([]*Report, error) {
+	var reports []*Report
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", StatusAccepted, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// Claim pushes the next refund attempt of a due report to until. Only one of
+// the workers that listed the report gets it.
+func (r repository) Claim(ctx context.Context, report *Report, now, until time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&Report{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", report.Id, StatusAccepted, now).
+		Updates(map[string]interface{}{"next_attempt_at": until, "updated_at": now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	report.NextAttemptAt = &until
+	return result.RowsAffected > 0, nil
+}
+
+// Update saves the report only if it is still at from, so two analysts, or an
+// analyst and the refund worker, can never both move it on. The events go to
+// the outbox in the same transaction.
+func (r repository) Update(ctx context.Context, report *Report, from Status, events ...*outbox.Message) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		report.UpdatedAt = time.Now()
+		result := tx.Model(&Report{}).
+			Where("id = ? AND status = ?", report.Id, from).
+			Updates(map[string]interface{}{
+				"status":                report.Status,
+				"analyst":               report.Analyst,
+				"resolution_note":       report.ResolutionNote,
+				"hold_transaction_id":   report.HoldTransactionID,
+				"refund_transaction_id": report.RefundTransactionID,
+				"refund_attempts":       report.RefundAttempts,
+				"last_error":            report.LastError,
+				"refund_due_at":         report.RefundDueAt,
+				"next_attempt_at":       report.NextAttemptAt,
+				"resolved_at":           report.ResolvedAt,
+				"updated_at":            report.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errutils.ErrReportResolved
+		}
+		return outbox.NewRepository(tx, r.cfg).Enqueue(ctx, events...)
+	})
+}
+
+func NewRepository(db *gorm.DB, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
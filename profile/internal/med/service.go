@@ -0,0 +1,334 @@
+package med
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"log"
+	"profile/internal/errutils"
+	"profile/internal/ledger"
+	transpb "profile/proto/transactions/v1"
+	"time"
+)
+
+// reportable are the statuses of the transaction service a pix can be
+// reported in.
+var reportable = map[string]bool{"COMPLETED": true, "PARTIALLY_REFUNDED": true}
+
+type Service interface {
+	Report(ctx context.Context, req *ReportRequest) (*Report, error)
+	List(ctx context.Context, req *ListRequest) ([]*Report, error)
+	Resolve(ctx context.Context, req *Resolution) (*Report, error)
+	RetryRefunds(ctx context.Context) error
+}
+
+type Options func(*service)
+
+// WithDeadlines sets how old a pix can be reported, how long analysts have
+// to resolve a report and how long an accepted report has to be refunded.
+func WithDeadlines(report, analysis, refund time.Duration) Options {
+	return func(s *service) {
+		s.reportWindow = report
+		s.analysisWindow = analysis
+		s.refundWindow = refund
+	}
+}
+
+func WithRetryBackoff(backoff time.Duration) Options {
+	return func(s *service) {
+		s.retryBackoff = backoff
+	}
+}
+
+type service struct {
+	repo           Repository
+	ledger         ledger.Service
+	transactions   transpb.TransactionServiceClient
+	reportWindow   time.Duration
+	analysisWindow time.Duration
+	refundWindow   time.Duration
+	retryBackoff   time.Duration
+	batchSize      int
+}
+
+// Report opens an infraction report for a pix the account sent and blocks
+// what is left of it in the receiver account, or as much as it still has.
+func (s *service) Report(ctx context.Context, req *ReportRequest) (*Report, error) {
+	if req.TransactionID == "" {
+		return nil, errutils.ErrTransactionIDRequired
+	}
+
+	active, err := s.repo.HasActive(ctx, req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, errutils.ErrReportAlreadyOpen
+	}
+
+	// the ledger tells who sent and who received the pix, pix to other banks
+	// have the system account as the receiver
+	entry, err := s.ledger.FindEntry(ctx, req.TransactionID, ledger.TypePix)
+	if err != nil {
+		if errors.Is(err, errutils.ErrEntryNotFound) {
+			return nil, errutils.ErrTransactionNotReportable
+		}
+		return nil, err
+	}
+	var sender, receiver int64
+	for _, line := range entry.Lines {
+		if line.Direction == ledger.Debit {
+			sender = line.AccountID
+		} else {
+			receiver = line.AccountID
+		}
+	}
+	if sender != req.AccountID {
+		return nil, errutils.ErrNotTransactionSender
+	}
+	if receiver == ledger.SystemAccountID {
+		return nil, errutils.ErrTransactionNotReportable
+	}
+
+	transaction, err := s.transactions.FindTransactionById(ctx, &transpb.TransactionRequest{TransactionId: req.TransactionID})
+	if err != nil {
+		return nil, err
+	}
+	if !reportable[transaction.Status] {
+		return nil, errutils.ErrTransactionNotReportable
+	}
+	now := time.Now()
+	if transaction.CreatedAt != nil && now.Sub(transaction.CreatedAt.AsTime()) > s.reportWindow {
+		return nil, errutils.ErrReportDeadlinePassed
+	}
+
+	amount := decimal.NewFromFloat(transaction.Value).Sub(decimal.NewFromFloat(transaction.RefundedValue))
+	if !amount.IsPositive() {
+		return nil, errutils.ErrTransactionNotReportable
+	}
+
+	report := &Report{
+		Id:                uuid.New().String(),
+		TransactionID:     req.TransactionID,
+		ReporterAccountID: sender,
+		ReceiverAccountID: receiver,
+		Amount:            amount,
+		Reason:            req.Reason,
+		Status:            StatusOpen,
+		AnalysisDueAt:     now.Add(s.analysisWindow),
+	}
+	report.HoldTransactionID = report.Id
+
+	// the block outlives the analysis and the refund, an expired block is
+	// released by the hold sweeper
+	hold, err := s.ledger.Block(ctx, receiver, report.HoldTransactionID, amount, report.AnalysisDueAt.Add(s.refundWindow))
+	if err != nil {
+		return nil, err
+	}
+	report.BlockedAmount = hold.Amount
+
+	message, err := NewMessage(report)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.Create(ctx, report, message); err != nil {
+		if hold.Amount.IsPositive() {
+			if releaseErr := s.ledger.Release(ctx, report.HoldTransactionID); releaseErr != nil {
+				log.Printf("failed to release block of infraction report %s: %v", report.Id, releaseErr)
+			}
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *service) List(ctx context.Context, req *ListRequest) ([]*Report, error) {
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 100
+	}
+	return s.repo.List(ctx, req)
+}
+
+// Resolve records the analyst decision. A rejected report gives the block
+// back to the receiver, an accepted one is refunded right away and, if that
+// fails, by RetryRefunds until the refund deadline.
+func (s *service) Resolve(ctx context.Context, req *Resolution) (*Report, error) {
+	if req.Analyst == "" {
+		return nil, errutils.ErrAnalystRequired
+	}
+
+	report, err := s.repo.Find(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != StatusOpen {
+		return nil, errutils.ErrReportResolved
+	}
+
+	now := time.Now()
+	report.Analyst = req.Analyst
+	report.ResolutionNote = req.Note
+	report.ResolvedAt = &now
+	switch {
+	case !req.Accept:
+		report.Status = StatusRejected
+	case !report.BlockedAmount.IsPositive():
+		report.Status = StatusUnrecovered
+	default:
+		refundDue, nextAttempt := now.Add(s.refundWindow), now.Add(s.retryBackoff)
+		report.Status = StatusAccepted
+		report.RefundDueAt = &refundDue
+		report.NextAttemptAt = &nextAttempt
+	}
+
+	message, err := NewMessage(report)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.Update(ctx, report, StatusOpen, message); err != nil {
+		return nil, err
+	}
+
+	switch report.Status {
+	case StatusRejected:
+		if report.BlockedAmount.IsPositive() {
+			if err = s.ledger.Release(ctx, report.HoldTransactionID); err != nil {
+				log.Printf("failed to release block of infraction report %s: %v", report.Id, err)
+			}
+		}
+	case StatusAccepted:
+		if err = s.refund(ctx, report); err != nil {
+			log.Printf("failed to refund infraction report %s: %v", report.Id, err)
+		}
+	}
+	return report, nil
+}
+
+// RetryRefunds retries the accepted reports whose refund is due.
+func (s *service) RetryRefunds(ctx context.Context) error {
+	now := time.Now()
+	reports, err := s.repo.ListDueRefunds(ctx, now, s.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		claimed, err := s.repo.Claim(ctx, report, now, now.Add(s.retryBackoff))
+		if err != nil {
+			log.Printf("failed to claim infraction report %s: %v", report.Id, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err = s.refund(ctx, report); err != nil {
+			log.Printf("failed to refund infraction report %s: %v", report.Id, err)
+		}
+	}
+	return nil
+}
+
+// refund returns the blocked amount to the reporter. The block is moved to a
+// new refund id first and saved on the report: the transaction service
+// settles the refund through the pix webhook, and posting it captures the
+// block, so the blocked amount pays for the refund.
+func (s *service) refund(ctx context.Context, report *Report) error {
+	refundID := uuid.New().String()
+	if err := s.ledger.MoveHold(ctx, report.HoldTransactionID, refundID); err != nil {
+		if !errors.Is(err, errutils.ErrHoldNotFound) {
+			return s.retryLater(ctx, report, err)
+		}
+		return s.holdGone(ctx, report)
+	}
+
+	report.HoldTransactionID = refundID
+	if err := s.repo.Update(ctx, report, StatusAccepted); err != nil {
+		return err
+	}
+
+	_, err := s.transactions.RefundTransaction(ctx, &transpb.RefundRequest{
+		TransactionId: report.TransactionID,
+		AccountId:     report.ReceiverAccountID,
+		Value:         report.BlockedAmount.InexactFloat64(),
+		RefundId:      refundID,
+	})
+	if err != nil {
+		return s.retryLater(ctx, report, err)
+	}
+	return s.refunded(ctx, report)
+}
+
+// holdGone settles a report whose block is no longer active: either the last
+// refund went through but was not recorded, or the block expired.
+func (s *service) holdGone(ctx context.Context, report *Report) error {
+	_, err := s.ledger.FindEntry(ctx, report.HoldTransactionID, ledger.TypeRefund)
+	if err == nil {
+		return s.refunded(ctx, report)
+	}
+	if !errors.Is(err, errutils.ErrEntryNotFound) {
+		return s.retryLater(ctx, report, err)
+	}
+
+	report.Status = StatusUnrecovered
+	report.LastError = errutils.ErrHoldNotFound.Error()
+	report.NextAttemptAt = nil
+	return s.save(ctx, report)
+}
+
+func (s *service) refunded(ctx context.Context, report *Report) error {
+	report.Status = StatusRefunded
+	report.RefundTransactionID = report.HoldTransactionID
+	report.LastError = ""
+	report.NextAttemptAt = nil
+	return s.save(ctx, report)
+}
+
+// retryLater records the failed attempt and gives up once the refund deadline
+// passed. The block of a report given up on is released when it expires.
+func (s *service) retryLater(ctx context.Context, report *Report, cause error) error {
+	now := time.Now()
+	report.RefundAttempts++
+	report.LastError = cause.Error()
+	if report.RefundDueAt != nil && now.After(*report.RefundDueAt) {
+		report.Status = StatusUnrecovered
+		report.NextAttemptAt = nil
+		if err := s.save(ctx, report); err != nil {
+			return err
+		}
+		return cause
+	}
+
+	nextAttempt := now.Add(s.retryBackoff)
+	report.NextAttemptAt = &nextAttempt
+	if err := s.repo.Update(ctx, report, StatusAccepted); err != nil {
+		return err
+	}
+	return cause
+}
+
+// save moves an accepted report to its final status and publishes it.
+func (s *service) save(ctx context.Context, report *Report) error {
+	message, err := NewMessage(report)
+	if err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, report, StatusAccepted, message)
+}
+
+func NewService(repo Repository, ledgerService ledger.Service, transactions transpb.TransactionServiceClient, opts ...Options) Service {
+	s := &service{
+		repo:           repo,
+		ledger:         ledgerService,
+		transactions:   transactions,
+		reportWindow:   80 * 24 * time.Hour,
+		analysisWindow: 7 * 24 * time.Hour,
+		refundWindow:   96 * time.Hour,
+		retryBackoff:   10 * time.Minute,
+		batchSize:      50,
+	}
+	for _, f := range opts {
+		f(s)
+	}
+	return s
+}
@@ -0,0 +1,262 @@
+package med
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"profile/internal/errutils"
+	"profile/internal/ledger"
+	"profile/internal/outbox"
+	transpb "profile/proto/transactions/v1"
+	"testing"
+	"time"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepo) Create(ctx context.Context, report *Report, events ...*outbox.Message) error {
+	args := m.Called(report)
+	return args.Error(0)
+}
+
+func (m *mockRepo) Find(ctx context.Context, id string) (*Report, error) {
+	args := m.Called(id)
+	return args.Get(0).(*Report), args.Error(1)
+}
+
+func (m *mockRepo) HasActive(ctx context.Context, transactionID string) (bool, error) {
+	args := m.Called(transactionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) Update(ctx context.Context, report *Report, from Status, events ...*outbox.Message) error {
+	args := m.Called(report.Status, from)
+	return args.Error(0)
+}
+
+type mockLedger struct {
+	ledger.Service
+	mock.Mock
+}
+
+func (m *mockLedger) FindEntry(ctx context.Context, transactionID string, entryType ledger.EntryType) (*ledger.JournalEntry, error) {
+	args := m.Called(transactionID, entryType)
+	return args.Get(0).(*ledger.JournalEntry), args.Error(1)
+}
+
+func (m *mockLedger) Block(ctx context.Context, accountID int64, transactionID string, amount decimal.Decimal, expiresAt time.Time, events ...*outbox.Message) (*ledger.Hold, error) {
+	args := m.Called(accountID, amount.String())
+	return args.Get(0).(*ledger.Hold), args.Error(1)
+}
+
+func (m *mockLedger) MoveHold(ctx context.Context, from, to string) error {
+	args := m.Called(from)
+	return args.Error(0)
+}
+
+func (m *mockLedger) Release(ctx context.Context, transactionID string) error {
+	args := m.Called(transactionID)
+	return args.Error(0)
+}
+
+type mockTransactionClient struct {
+	transpb.TransactionServiceClient
+	mock.Mock
+}
+
+func (m *mockTransactionClient) FindTransactionById(ctx context.Context, in *transpb.TransactionRequest, opts ...grpc.CallOption) (*transpb.Transaction, error) {
+	args := m.Called(in.TransactionId)
+	return args.Get(0).(*transpb.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionClient) RefundTransaction(ctx context.Context, in *transpb.RefundRequest, opts ...grpc.CallOption) (*transpb.Transaction, error) {
+	args := m.Called(in.TransactionId, in.AccountId, in.Value)
+	return args.Get(0).(*transpb.Transaction), args.Error(1)
+}
+
+func pixEntry(sender, receiver int64) *ledger.JournalEntry {
+	return ledger.NewTransfer("tx-1", ledger.TypePix, sender, receiver, decimal.NewFromInt(100), "pix")
+}
+
+func TestReport(t *testing.T) {
+	completed := &transpb.Transaction{Id: "tx-1", Status: "COMPLETED", Value: 100, RefundedValue: 20, CreatedAt: timestamppb.New(time.Now().Add(-time.Hour))}
+
+	cases := []struct {
+		name        string
+		req         *ReportRequest
+		mockFunc    func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient)
+		wantBlocked string
+		err         error
+	}{
+		{
+			name: "success blocks what the receiver still has",
+			req:  &ReportRequest{TransactionID: "tx-1", AccountID: 1, Reason: "scam"},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("HasActive", "tx-1").Return(false, nil)
+				ledgerService.On("FindEntry", "tx-1", ledger.TypePix).Return(pixEntry(1, 2), nil)
+				client.On("FindTransactionById", "tx-1").Return(completed, nil)
+				ledgerService.On("Block", int64(2), "80").Return(&ledger.Hold{Amount: decimal.NewFromInt(50)}, nil)
+				repo.On("Create", mock.MatchedBy(func(report *Report) bool {
+					return report.Status == StatusOpen && report.HoldTransactionID == report.Id && report.ReceiverAccountID == 2
+				})).Return(nil)
+			},
+			wantBlocked: "50",
+		},
+		{
+			name: "failed because only the sender can report",
+			req:  &ReportRequest{TransactionID: "tx-1", AccountID: 2},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("HasActive", "tx-1").Return(false, nil)
+				ledgerService.On("FindEntry", "tx-1", ledger.TypePix).Return(pixEntry(1, 2), nil)
+			},
+			err: errutils.ErrNotTransactionSender,
+		},
+		{
+			name: "failed because the pix went to another bank",
+			req:  &ReportRequest{TransactionID: "tx-1", AccountID: 1},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("HasActive", "tx-1").Return(false, nil)
+				ledgerService.On("FindEntry", "tx-1", ledger.TypePix).Return(pixEntry(1, ledger.SystemAccountID), nil)
+			},
+			err: errutils.ErrTransactionNotReportable,
+		},
+		{
+			name: "failed because the pix is past the report deadline",
+			req:  &ReportRequest{TransactionID: "tx-1", AccountID: 1},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("HasActive", "tx-1").Return(false, nil)
+				ledgerService.On("FindEntry", "tx-1", ledger.TypePix).Return(pixEntry(1, 2), nil)
+				client.On("FindTransactionById", "tx-1").Return(&transpb.Transaction{
+					Status: "COMPLETED", Value: 100, CreatedAt: timestamppb.New(time.Now().Add(-81 * 24 * time.Hour)),
+				}, nil)
+			},
+			err: errutils.ErrReportDeadlinePassed,
+		},
+		{
+			name: "failed because the transaction was already reported",
+			req:  &ReportRequest{TransactionID: "tx-1", AccountID: 1},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("HasActive", "tx-1").Return(true, nil)
+			},
+			err: errutils.ErrReportAlreadyOpen,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo, ledgerService, client := new(mockRepo), new(mockLedger), new(mockTransactionClient)
+			c.mockFunc(repo, ledgerService, client)
+
+			got, err := NewService(repo, ledgerService, client).Report(context.Background(), c.req)
+			assert.Equal(t, c.err, err)
+			if c.err == nil {
+				assert.Equal(t, c.wantBlocked, got.BlockedAmount.String())
+				assert.Equal(t, "80", got.Amount.String())
+			}
+			repo.AssertExpectations(t)
+			ledgerService.AssertExpectations(t)
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	open := func() *Report {
+		return &Report{Id: "med-1", TransactionID: "tx-1", ReceiverAccountID: 2, Status: StatusOpen,
+			BlockedAmount: decimal.NewFromInt(50), HoldTransactionID: "med-1"}
+	}
+
+	cases := []struct {
+		name       string
+		req        *Resolution
+		mockFunc   func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient)
+		wantStatus Status
+		err        error
+	}{
+		{
+			name: "rejected report releases the block",
+			req:  &Resolution{ID: "med-1", Analyst: "ana"},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("Find", "med-1").Return(open(), nil)
+				repo.On("Update", StatusRejected, StatusOpen).Return(nil)
+				ledgerService.On("Release", "med-1").Return(nil)
+			},
+			wantStatus: StatusRejected,
+		},
+		{
+			name: "accepted report is refunded with the blocked amount",
+			req:  &Resolution{ID: "med-1", Analyst: "ana", Accept: true},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("Find", "med-1").Return(open(), nil)
+				repo.On("Update", StatusAccepted, StatusOpen).Return(nil)
+				ledgerService.On("MoveHold", "med-1").Return(nil)
+				repo.On("Update", StatusAccepted, StatusAccepted).Return(nil).Once()
+				client.On("RefundTransaction", "tx-1", int64(2), float64(50)).Return(&transpb.Transaction{}, nil)
+				repo.On("Update", StatusRefunded, StatusAccepted).Return(nil)
+			},
+			wantStatus: StatusRefunded,
+		},
+		{
+			name: "accepted report stays accepted when the refund fails",
+			req:  &Resolution{ID: "med-1", Analyst: "ana", Accept: true},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				repo.On("Find", "med-1").Return(open(), nil)
+				repo.On("Update", StatusAccepted, StatusOpen).Return(nil)
+				ledgerService.On("MoveHold", "med-1").Return(nil)
+				repo.On("Update", StatusAccepted, StatusAccepted).Return(nil).Twice()
+				client.On("RefundTransaction", "tx-1", int64(2), float64(50)).Return((*transpb.Transaction)(nil), errors.New("MOCK-ERROR"))
+			},
+			wantStatus: StatusAccepted,
+		},
+		{
+			name: "accepted report with nothing blocked is unrecovered",
+			req:  &Resolution{ID: "med-1", Analyst: "ana", Accept: true},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				report := open()
+				report.BlockedAmount = decimal.Zero
+				repo.On("Find", "med-1").Return(report, nil)
+				repo.On("Update", StatusUnrecovered, StatusOpen).Return(nil)
+			},
+			wantStatus: StatusUnrecovered,
+		},
+		{
+			name: "failed because the report was resolved",
+			req:  &Resolution{ID: "med-1", Analyst: "ana"},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {
+				report := open()
+				report.Status = StatusRejected
+				repo.On("Find", "med-1").Return(report, nil)
+			},
+			err: errutils.ErrReportResolved,
+		},
+		{
+			name:     "failed because analyst is missing",
+			req:      &Resolution{ID: "med-1"},
+			mockFunc: func(repo *mockRepo, ledgerService *mockLedger, client *mockTransactionClient) {},
+			err:      errutils.ErrAnalystRequired,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo, ledgerService, client := new(mockRepo), new(mockLedger), new(mockTransactionClient)
+			c.mockFunc(repo, ledgerService, client)
+
+			got, err := NewService(repo, ledgerService, client).Resolve(context.Background(), c.req)
+			assert.Equal(t, c.err, err)
+			if c.err == nil {
+				assert.Equal(t, c.wantStatus, got.Status)
+			}
+			repo.AssertExpectations(t)
+			ledgerService.AssertExpectations(t)
+			client.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package outbox
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending Status = "PENDING"
+	StatusSent    Status = "SENT"
+	StatusFailed  Status = "FAILED"
+)
+
+// Message is an event waiting to be published. It is written in the same
+// database transaction as the change it describes and sent later by the Relay.
+// AggregateID is the account the event belongs to; messages of one account are
+// published in id order.
+type Message struct {
+	Id            int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	AggregateID   int64      `gorm:"type:int;column:aggregate_id"`
+	Topic         string     `gorm:"type:varchar(255);column:topic"`
+	Payload       string     `gorm:"type:nvarchar(max);column:payload"`
+	Status        Status     `gorm:"type:varchar(20);column:status"`
+	Attempts      int        `gorm:"type:int;column:attempts"`
+	LastError     string     `gorm:"type:nvarchar(1000);column:last_error"`
+	NextAttemptAt *time.Time `gorm:"type:datetime;column:next_attempt_at"`
+	SentAt        *time.Time `gorm:"type:datetime;column:sent_at"`
+	CreatedAt     time.Time  `gorm:"type:datetime;column:created_at"`
+}
+
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+func NewMessage(aggregateID int64, topic string, payload []byte) *Message {
+	return &Message{
+		AggregateID: aggregateID,
+		Topic:       topic,
+		Payload:     string(payload),
+	}
+}
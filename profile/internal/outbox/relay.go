@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"profile/internal/errutils"
+	"profile/internal/event"
+	"profile/platform/redis"
+	"strconv"
+	"time"
+)
+
+type Relay interface {
+	Run(ctx context.Context) error
+}
+
+type RelayOptions func(*relay)
+
+func WithPublisher(topic string, client event.Client) RelayOptions {
+	return func(r *relay) {
+		r.publishers[topic] = client
+	}
+}
+
+func WithLocker(locker redis.Locker) RelayOptions {
+	return func(r *relay) {
+		r.locker = locker
+	}
+}
+
+func WithBatchSize(size int) RelayOptions {
+	return func(r *relay) {
+		r.batchSize = size
+	}
+}
+
+func WithMaxAttempts(attempts int) RelayOptions {
+	return func(r *relay) {
+		r.maxAttempts = attempts
+	}
+}
+
+func WithBackoff(base, max time.Duration) RelayOptions {
+	return func(r *relay) {
+		r.baseBackoff = base
+		r.maxBackoff = max
+	}
+}
+
+type relay struct {
+	repo        Repository
+	publishers  map[string]event.Client
+	locker      redis.Locker
+	batchSize   int
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+}
+
+// Run publishes one batch of due messages. Once a message of an account fails,
+// the later messages of that account are left for another run so they are
+// never published out of order.
+func (r *relay) Run(ctx context.Context) error {
+	if r.locker != nil {
+		mutex := r.locker.NewMutex("outbox:relay")
+		if err := mutex.Lock(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			if err := mutex.Unlock(context.Background()); err != nil {
+				log.Print("failed to unlock outbox relay:", err)
+			}
+		}()
+	}
+
+	now := time.Now()
+	messages, err := r.repo.ListPending(ctx, now, r.batchSize)
+	if err != nil {
+		return err
+	}
+
+	blocked := make(map[int64]bool)
+	for _, message := range messages {
+		if blocked[message.AggregateID] {
+			continue
+		}
+
+		if err = r.publish(ctx, message); err != nil {
+			blocked[message.AggregateID] = true
+			if err = r.fail(ctx, message, err, now); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = r.repo.MarkSent(ctx, message.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *relay) publish(ctx context.Context, message *Message) error {
+	client, ok := r.publishers[message.Topic]
+	if !ok {
+		return errutils.ErrUnknownTopic
+	}
+	// keyed by account so the events of an account stay in one partition
+	return client.Publish(ctx, []byte(strconv.FormatInt(message.AggregateID, 10)), []byte(message.Payload))
+}
+
+// fail schedules the next attempt with exponential backoff, or gives up on the
+// message after maxAttempts.
+func (r *relay) fail(ctx context.Context, message *Message, cause error, now time.Time) error {
+	message.Attempts++
+	message.LastError = cause.Error()
+	if len(message.LastError) > 1000 {
+		message.LastError = message.LastError[:1000]
+	}
+
+	if message.Attempts >= r.maxAttempts {
+		message.Status = StatusFailed
+		log.Printf("outbox message %d gave up after %d attempts: %v", message.Id, message.Attempts, cause)
+	} else {
+		next := now.Add(r.backoff(message.Attempts))
+		message.NextAttemptAt = &next
+		log.Printf("outbox message %d failed, retrying at %s: %v", message.Id, next.Format(time.RFC3339), cause)
+	}
+	return r.repo.MarkFailed(ctx, message)
+}
+
+func (r *relay) backoff(attempts int) time.Duration {
+	backoff := r.baseBackoff
+	for i := 1; i < attempts && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.maxBackoff {
+		return r.maxBackoff
+	}
+	return backoff
+}
+
+func NewRelay(repo Repository, opts ...RelayOptions) Relay {
+	r := &relay{
+		repo:        repo,
+		publishers:  make(map[string]event.Client),
+		batchSize:   100,
+		maxAttempts: 10,
+		baseBackoff: time.Second,
+		maxBackoff:  5 * time.Minute,
+	}
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"profile/internal/errutils"
+	"profile/internal/event"
+	"testing"
+	"time"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepo) ListPending(ctx context.Context, now time.Time, limit int) ([]*Message, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]*Message), args.Error(1)
+}
+
+func (m *mockRepo) MarkSent(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockRepo) MarkFailed(ctx context.Context, message *Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+type mockEventClient struct {
+	event.Client
+	mock.Mock
+}
+
+func (m *mockEventClient) Publish(ctx context.Context, key, payload []byte) error {
+	args := m.Called(string(key), string(payload))
+	return args.Error(0)
+}
+
+func TestRelayRun(t *testing.T) {
+	cases := []struct {
+		name     string
+		messages []*Message
+		mockFunc func(repo *mockRepo, client *mockEventClient)
+		err      error
+	}{
+		{
+			name: "publishes pending messages in order",
+			messages: []*Message{
+				{Id: 1, AggregateID: 1, Topic: "pix", Payload: "a"},
+				{Id: 2, AggregateID: 1, Topic: "pix", Payload: "b"},
+			},
+			mockFunc: func(repo *mockRepo, client *mockEventClient) {
+				client.On("Publish", "1", "a").Return(nil).Once()
+				repo.On("MarkSent", int64(1)).Return(nil).Once()
+				client.On("Publish", "1", "b").Return(nil).Once()
+				repo.On("MarkSent", int64(2)).Return(nil).Once()
+			},
+		},
+		{
+			name: "failure holds back later messages of the same account",
+			messages: []*Message{
+				{Id: 1, AggregateID: 1, Topic: "pix", Payload: "a"},
+				{Id: 2, AggregateID: 1, Topic: "pix", Payload: "b"},
+				{Id: 3, AggregateID: 2, Topic: "pix", Payload: "c"},
+			},
+			mockFunc: func(repo *mockRepo, client *mockEventClient) {
+				client.On("Publish", "1", "a").Return(errors.New("MOCK-ERROR"))
+				repo.On("MarkFailed", mock.MatchedBy(func(message *Message) bool {
+					return message.Id == 1 && message.Attempts == 1 && message.LastError == "MOCK-ERROR" &&
+						message.NextAttemptAt != nil && message.Status == ""
+				})).Return(nil)
+				client.On("Publish", "2", "c").Return(nil)
+				repo.On("MarkSent", int64(3)).Return(nil)
+			},
+		},
+		{
+			name: "gives up after max attempts",
+			messages: []*Message{
+				{Id: 1, AggregateID: 1, Topic: "pix", Payload: "a", Attempts: 2},
+			},
+			mockFunc: func(repo *mockRepo, client *mockEventClient) {
+				client.On("Publish", "1", "a").Return(errors.New("MOCK-ERROR"))
+				repo.On("MarkFailed", mock.MatchedBy(func(message *Message) bool {
+					return message.Attempts == 3 && message.Status == StatusFailed
+				})).Return(nil)
+			},
+		},
+		{
+			name: "unknown topic is retried",
+			messages: []*Message{
+				{Id: 1, AggregateID: 1, Topic: "other", Payload: "a"},
+			},
+			mockFunc: func(repo *mockRepo, client *mockEventClient) {
+				repo.On("MarkFailed", mock.MatchedBy(func(message *Message) bool {
+					return message.LastError == errutils.ErrUnknownTopic.Error()
+				})).Return(nil)
+			},
+		},
+		{
+			name: "failed because error marking message as sent",
+			messages: []*Message{
+				{Id: 1, AggregateID: 1, Topic: "pix", Payload: "a"},
+				{Id: 2, AggregateID: 2, Topic: "pix", Payload: "b"},
+			},
+			mockFunc: func(repo *mockRepo, client *mockEventClient) {
+				client.On("Publish", "1", "a").Return(nil)
+				repo.On("MarkSent", int64(1)).Return(errors.New("MOCK-ERROR"))
+			},
+			err: errors.New("MOCK-ERROR"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			client := new(mockEventClient)
+			repo.On("ListPending", 10).Return(c.messages, nil)
+			c.mockFunc(repo, client)
+
+			r := NewRelay(repo, WithPublisher("pix", client), WithBatchSize(10), WithMaxAttempts(3))
+			err := r.Run(context.Background())
+
+			assert.Equal(t, c.err, err)
+			repo.AssertExpectations(t)
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := NewRelay(nil, WithBackoff(time.Second, 10*time.Second)).(*relay)
+
+	assert.Equal(t, time.Second, r.backoff(1))
+	assert.Equal(t, 2*time.Second, r.backoff(2))
+	assert.Equal(t, 8*time.Second, r.backoff(4))
+	assert.Equal(t, 10*time.Second, r.backoff(5))
+	assert.Equal(t, 10*time.Second, r.backoff(30))
+}
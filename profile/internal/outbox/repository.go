@@ -0,0 +1,83 @@
+package outbox
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"profile/internal/cfg"
+	"time"
+)
+
+type Repository interface {
+	Enqueue(ctx context.Context, messages ...*Message) error
+	ListPending(ctx context.Context, now time.Time, limit int) ([]*Message, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, message *Message) error
+}
+
+type repository struct {
+	db  *gorm.DB
+	cfg *cfg.Config
+}
+
+// Enqueue stores the messages with the repository's db handle. Build the
+// repository from a gorm transaction to commit them with the business change.
+func (r repository) Enqueue(ctx context.Context, messages ...*Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, message := range messages {
+		message.Status = StatusPending
+		message.CreatedAt = now
+	}
+	return r.db.WithContext(ctx).Create(messages).Error
+}
+
+// ListPending returns the pending messages due at now. An account with a
+// message waiting for its next attempt is left out entirely, so its later
+// messages wait behind it and the batch goes to the accounts that can move.
+func (r repository) ListPending(ctx context.Context, now time.Time, limit int) ([]*Message, error) {
+	waiting := r.db.Model(&Message{}).
+		Select("aggregate_id").
+		Where("status = ? AND next_attempt_at > ?", StatusPending, now)
+
+	var messages []*Message
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", StatusPending, now).
+		Where("aggregate_id NOT IN (?)", waiting).
+		Order("id").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r repository) MarkSent(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":  StatusSent,
+			"sent_at": time.Now(),
+		}).Error
+}
+
+func (r repository) MarkFailed(ctx context.Context, message *Message) error {
+	return r.db.WithContext(ctx).Model(&Message{}).
+		Where("id = ?", message.Id).
+		Updates(map[string]interface{}{
+			"status":          message.Status,
+			"attempts":        message.Attempts,
+			"last_error":      message.LastError,
+			"next_attempt_at": message.NextAttemptAt,
+		}).Error
+}
+
+func NewRepository(db *gorm.DB, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
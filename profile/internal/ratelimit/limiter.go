@@ -0,0 +1,231 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"profile/internal/errutils"
+	"strconv"
+	"time"
+)
+
+// takeScript refills the buckets in KEYS and takes ARGV[3] tokens from all of
+// them, or from none when one is short. With ARGV[6] set the tokens are taken
+// anyway, down to minus the capacity, which is how penalties are charged.
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local ttl = tonumber(ARGV[5])
+local force = ARGV[6] == "1"
+
+local tokens = {}
+local allowed = 1
+for i, key in ipairs(KEYS) do
+	local bucket = redis.call("HMGET", key, "tokens", "ts")
+	local left = tonumber(bucket[1]) or capacity
+	local ts = tonumber(bucket[2]) or now
+	left = math.min(capacity, left + math.max(0, now - ts) / 1000 * rate)
+	tokens[i] = left
+	if left < cost then
+		allowed = 0
+	end
+end
+
+for i, key in ipairs(KEYS) do
+	local left = tokens[i]
+	if allowed == 1 or force then
+		left = math.max(-capacity, left - cost)
+	end
+	redis.call("HSET", key, "tokens", tostring(left), "ts", tostring(now))
+	redis.call("EXPIRE", key, ttl)
+end
+return allowed
+`)
+
+type Limiter interface {
+	// Allow charges one lookup to every subject, unless one of them is locked
+	// out or out of tokens.
+	Allow(ctx context.Context, subjects ...string) error
+	// Penalize charges a lookup of a key that does not exist, and locks the
+	// subjects out after too many of them.
+	Penalize(ctx context.Context, subjects ...string) error
+	Status(ctx context.Context, subject string) (*Status, error)
+	Unlock(ctx context.Context, subject string) error
+}
+
+type Options func(*limiter)
+
+// WithBucket sets how many lookups a subject can burst and how many tokens it
+// gets back per minute.
+func WithBucket(capacity int, refillPerMinute int) Options {
+	return func(l *limiter) {
+		l.capacity = capacity
+		l.refillPerMinute = refillPerMinute
+	}
+}
+
+// WithPenalty sets the tokens a lookup of a missing key costs, and locks a
+// subject out for lockout after maxMisses of them within window.
+func WithPenalty(penalty int, maxMisses int, window, lockout time.Duration) Options {
+	return func(l *limiter) {
+		l.penalty = penalty
+		l.maxMisses = maxMisses
+		l.missWindow = window
+		l.lockout = lockout
+	}
+}
+
+type limiter struct {
+	client          *redis.Client
+	prefix          string
+	capacity        int
+	refillPerMinute int
+	penalty         int
+	maxMisses       int
+	missWindow      time.Duration
+	lockout         time.Duration
+	now             func() time.Time
+}
+
+func (l *limiter) Allow(ctx context.Context, subjects ...string) error {
+	for _, subject := range subjects {
+		locked, err := l.client.Exists(ctx, l.lockoutKey(subject)).Result()
+		if err != nil {
+			return err
+		}
+		if locked > 0 {
+			return errutils.ErrLookupLocked
+		}
+	}
+
+	allowed, err := l.take(ctx, subjects, 1, false)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errutils.ErrLookupRateLimited
+	}
+	return nil
+}
+
+func (l *limiter) Penalize(ctx context.Context, subjects ...string) error {
+	if _, err := l.take(ctx, subjects, l.penalty, true); err != nil {
+		return err
+	}
+
+	for _, subject := range subjects {
+		misses, err := l.client.Incr(ctx, l.missesKey(subject)).Result()
+		if err != nil {
+			return err
+		}
+		if misses == 1 {
+			if err = l.client.Expire(ctx, l.missesKey(subject), l.missWindow).Err(); err != nil {
+				return err
+			}
+		}
+		if misses < int64(l.maxMisses) {
+			continue
+		}
+
+		until := l.now().Add(l.lockout)
+		err = l.client.Set(ctx, l.lockoutKey(subject), until.Unix(), l.lockout).Err()
+		if err != nil {
+			return err
+		}
+		if err = l.client.Del(ctx, l.missesKey(subject)).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *limiter) Status(ctx context.Context, subject string) (*Status, error) {
+	status := &Status{Subject: subject, Tokens: float64(l.capacity)}
+
+	bucket, err := l.client.HMGet(ctx, l.bucketKey(subject), "tokens", "ts").Result()
+	if err != nil {
+		return nil, err
+	}
+	if tokens, ok := bucket[0].(string); ok {
+		left, _ := strconv.ParseFloat(tokens, 64)
+		ts, _ := strconv.ParseInt(bucket[1].(string), 10, 64)
+		elapsed := float64(l.now().UnixMilli()-ts) / float64(time.Minute.Milliseconds())
+		status.Tokens = left + elapsed*float64(l.refillPerMinute)
+		if status.Tokens > float64(l.capacity) {
+			status.Tokens = float64(l.capacity)
+		}
+	}
+
+	misses, err := l.client.Get(ctx, l.missesKey(subject)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	status.Misses = misses
+
+	until, err := l.client.Get(ctx, l.lockoutKey(subject)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if until > 0 {
+		status.LockedUntil = time.Unix(until, 0)
+	}
+	return status, nil
+}
+
+// Unlock lifts the lockout and forgives the misses and penalties of subject.
+func (l *limiter) Unlock(ctx context.Context, subject string) error {
+	return l.client.Del(ctx, l.lockoutKey(subject), l.missesKey(subject), l.bucketKey(subject)).Err()
+}
+
+func (l *limiter) take(ctx context.Context, subjects []string, cost int, force bool) (bool, error) {
+	keys := make([]string, len(subjects))
+	for i, subject := range subjects {
+		keys[i] = l.bucketKey(subject)
+	}
+
+	forced := "0"
+	if force {
+		forced = "1"
+	}
+	rate := float64(l.refillPerMinute) / 60
+	// a bucket in full debt is refilled after 2*capacity/rate, then it can go
+	ttl := int(2*float64(l.capacity)/rate) + 1
+	allowed, err := takeScript.Run(ctx, l.client, keys,
+		l.capacity, rate, cost, l.now().UnixMilli(), ttl, forced).Int()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
+}
+
+func (l *limiter) bucketKey(subject string) string {
+	return l.prefix + "bucket:" + subject
+}
+
+func (l *limiter) missesKey(subject string) string {
+	return l.prefix + "misses:" + subject
+}
+
+func (l *limiter) lockoutKey(subject string) string {
+	return l.prefix + "lockout:" + subject
+}
+
+func NewLimiter(client *redis.Client, opts ...Options) Limiter {
+	l := &limiter{
+		client:          client,
+		prefix:          "lookup_limit:",
+		capacity:        20,
+		refillPerMinute: 10,
+		penalty:         5,
+		maxMisses:       10,
+		missWindow:      time.Hour,
+		lockout:         24 * time.Hour,
+		now:             time.Now,
+	}
+	for _, f := range opts {
+		f(l)
+	}
+	return l
+}
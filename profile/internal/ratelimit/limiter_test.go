@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"profile/internal/errutils"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(t *testing.T, now *time.Time, opts ...Options) Limiter {
+	server := miniredis.RunT(t)
+	l := NewLimiter(redis.NewClient(&redis.Options{Addr: server.Addr()}), opts...)
+	l.(*limiter).now = func() time.Time { return *now }
+	return l
+}
+
+func TestAllow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 12, 14, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(t, &now, WithBucket(2, 60))
+
+	user, other := Subjects("user", 1), Subjects("user", 2)
+	require.NoError(t, l.Allow(ctx, user...))
+	require.NoError(t, l.Allow(ctx, user...))
+	assert.Equal(t, errutils.ErrLookupRateLimited, l.Allow(ctx, user...))
+
+	// the user bucket is shared across accounts, the account one is not charged
+	assert.Equal(t, errutils.ErrLookupRateLimited, l.Allow(ctx, other...))
+	status, err := l.Status(ctx, AccountSubject(2))
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), status.Tokens)
+
+	now = now.Add(time.Second)
+	assert.NoError(t, l.Allow(ctx, user...))
+}
+
+func TestPenalize(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 12, 14, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(t, &now, WithBucket(10, 60), WithPenalty(4, 2, time.Hour, time.Hour))
+
+	subjects := Subjects("user", 1)
+	require.NoError(t, l.Penalize(ctx, subjects...))
+	status, err := l.Status(ctx, UserSubject("user"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(6), status.Tokens)
+	assert.Equal(t, 1, status.Misses)
+	assert.False(t, status.Locked())
+
+	require.NoError(t, l.Penalize(ctx, subjects...))
+	assert.Equal(t, errutils.ErrLookupLocked, l.Allow(ctx, subjects...))
+
+	status, err = l.Status(ctx, AccountSubject(1))
+	require.NoError(t, err)
+	assert.True(t, status.Locked())
+	assert.Equal(t, now.Add(time.Hour).Unix(), status.LockedUntil.Unix())
+
+	require.NoError(t, l.Unlock(ctx, UserSubject("user")))
+	assert.Equal(t, errutils.ErrLookupLocked, l.Allow(ctx, subjects...))
+	require.NoError(t, l.Unlock(ctx, AccountSubject(1)))
+	assert.NoError(t, l.Allow(ctx, subjects...))
+}
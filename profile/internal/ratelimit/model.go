@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+)
+
+// Status is the state of one subject, as shown to support staff. Tokens goes
+// below zero while the subject pays off penalties.
+type Status struct {
+	Subject     string
+	Tokens      float64
+	Misses      int
+	LockedUntil time.Time
+}
+
+func (s *Status) Locked() bool {
+	return !s.LockedUntil.IsZero()
+}
+
+// Subjects are the buckets a lookup is charged to: the user and the account
+// the lookup is made for.
+func Subjects(userID string, accountID int64) []string {
+	return []string{UserSubject(userID), AccountSubject(accountID)}
+}
+
+func UserSubject(userID string) string {
+	return "user:" + userID
+}
+
+func AccountSubject(accountID int64) string {
+	return "account:" + strconv.FormatInt(accountID, 10)
+}
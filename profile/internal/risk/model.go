@@ -0,0 +1,146 @@
+package risk
+
+import (
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	proto "profile/proto/profile/v1"
+	"time"
+)
+
+type Decision string
+
+const (
+	DecisionAllow  Decision = "ALLOW"
+	DecisionReview Decision = "REVIEW"
+	DecisionDeny   Decision = "DENY"
+)
+
+// ReviewStatus follows a REVIEW decision. APPROVED is set before the parked
+// pix is sent, and turns into FAILED if it could not be. An allowed pix whose
+// amount could not be held is marked FAILED as well.
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "PENDING"
+	ReviewApproved ReviewStatus = "APPROVED"
+	ReviewRejected ReviewStatus = "REJECTED"
+	ReviewFailed   ReviewStatus = "FAILED"
+)
+
+// Assessment is the decision taken for a pix with the reasons of every rule
+// it matched. A pix under review is parked in Pix until an analyst resolves it.
+type Assessment struct {
+	Id            string          `gorm:"primaryKey;type:varchar(36);column:id"`
+	TransactionID string          `gorm:"type:varchar(36);column:transaction_id"`
+	AccountID     int64           `gorm:"type:int;column:account_id"`
+	Receiver      string          `gorm:"type:varchar(200);column:receiver"`
+	Amount        decimal.Decimal `gorm:"type:decimal(10,2);column:amount"`
+	Score         int             `gorm:"type:int;column:score"`
+	Decision      Decision        `gorm:"type:varchar(10);column:decision"`
+	Reasons       []string        `gorm:"type:nvarchar(max);serializer:json;column:reasons"`
+	ReviewStatus  ReviewStatus    `gorm:"type:varchar(20);column:review_status"`
+	ReviewedBy    string          `gorm:"type:varchar(255);column:reviewed_by"`
+	ReviewedAt    *time.Time      `gorm:"type:datetime;column:reviewed_at"`
+	ReviewNote    string          `gorm:"type:nvarchar(1000);column:review_note"`
+	Pix           string          `gorm:"type:nvarchar(max);column:pix"`
+	CreatedAt     time.Time       `gorm:"type:datetime;column:created_at"`
+	UpdatedAt     time.Time       `gorm:"type:datetime;column:updated_at"`
+}
+
+func (Assessment) TableName() string {
+	return "risk_assessments"
+}
+
+// Sent is whether the pix of the assessment left the account, or is on its way.
+func (a *Assessment) Sent() bool {
+	if a.ReviewStatus == ReviewFailed {
+		return false
+	}
+	return a.Decision == DecisionAllow || a.ReviewStatus == ReviewApproved
+}
+
+// Input is the pix to assess. Pix is the request to park if it goes to review.
+type Input struct {
+	TransactionID    string
+	AccountID        int64
+	Receiver         string
+	Amount           decimal.Decimal
+	ContactChangedAt *time.Time
+	Pix              []byte
+}
+
+// Facts are what the rules look at. History holds the assessments of the
+// account within the lookback of the rule set, newest first.
+type Facts struct {
+	AccountID            int64
+	Receiver             string
+	Amount               decimal.Decimal
+	Now                  time.Time
+	History              []*Assessment
+	ContactChangedAt     *time.Time
+	ReceiverKeyCreatedAt *time.Time
+}
+
+// Duration reads durations such as "10m" or "24h" from the rule set.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+type ReviewRequest struct {
+	ID      string
+	Analyst string
+	Note    string
+}
+
+func ProtoToReviewRequest(req *proto.ReviewRequest) *ReviewRequest {
+	return &ReviewRequest{
+		ID:      req.Id,
+		Analyst: req.Analyst,
+		Note:    req.Note,
+	}
+}
+
+func ToProto(assessment *Assessment) *proto.RiskAssessment {
+	pb := &proto.RiskAssessment{
+		Id:            assessment.Id,
+		TransactionId: assessment.TransactionID,
+		AccountId:     assessment.AccountID,
+		Receiver:      assessment.Receiver,
+		Amount:        assessment.Amount.InexactFloat64(),
+		Score:         int32(assessment.Score),
+		Decision:      string(assessment.Decision),
+		Reasons:       assessment.Reasons,
+		ReviewStatus:  string(assessment.ReviewStatus),
+		ReviewedBy:    assessment.ReviewedBy,
+		ReviewNote:    assessment.ReviewNote,
+		CreatedAt:     timestamppb.New(assessment.CreatedAt),
+	}
+	if assessment.ReviewedAt != nil {
+		pb.ReviewedAt = timestamppb.New(*assessment.ReviewedAt)
+	}
+	return pb
+}
+
+func ToProtoList(assessments []*Assessment) *proto.RiskAssessments {
+	list := &proto.RiskAssessments{Assessments: make([]*proto.RiskAssessment, len(assessments))}
+	for i := range assessments {
+		list.Assessments[i] = ToProto(assessments[i])
+	}
+	return list
+}
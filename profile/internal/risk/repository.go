@@ -0,0 +1,143 @@
+package risk
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"profile/internal/cfg"
+	"profile/internal/errutils"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, assessment *Assessment) error
+	Find(ctx context.Context, id string) (*Assessment, error)
+	FindByTransaction(ctx context.Context, transactionID string) (*Assessment, error)
+	ListHistory(ctx context.Context, accountID int64, since time.Time) ([]*Assessment, error)
+	ListReviews(ctx context.Context, status ReviewStatus, limit int) ([]*Assessment, error)
+	UpdateReview(ctx context.Context, assessment *Assessment, from ReviewStatus) error
+	KeyCreatedAt(ctx context.Context, key string) (*time.Time, error)
+}
+
+type repository struct {
+	db  *gorm.DB
+	cfg *cfg.Config
+}
+
+// Create stores the assessment. A pix assessed again as it runs replaces its
+// earlier assessment, so the rules count it once.
+func (r repository) Create(ctx context.Context, assessment *Assessment) error {
+	now := time.Now()
+	assessment.CreatedAt = now
+	assessment.UpdatedAt = now
+
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&Assessment{}).
+		Where("transaction_id = ?", assessment.TransactionID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		assessment.Id = ids[0]
+		return r.db.WithContext(ctx).Save(assessment).Error
+	}
+	assessment.Id = uuid.New().String()
+	return r.db.WithContext(ctx).Create(assessment).Error
+}
+
+func (r repository) Find(ctx context.Context, id string) (*Assessment, error) {
+	var assessment Assessment
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&assessment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errutils.ErrAssessmentNotFound
+		}
+		return nil, err
+	}
+	return &assessment, nil
+}
+
+func (r repository) FindByTransaction(ctx context.Context, transactionID string) (*Assessment, error) {
+	var assessment Assessment
+	if err := r.db.WithContext(ctx).Where("transaction_id = ?", transactionID).First(&assessment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errutils.ErrAssessmentNotFound
+		}
+		return nil, err
+	}
+	return &assessment, nil
+}
+
+func (r repository) ListHistory(ctx context.Context, accountID int64, since time.Time) ([]*Assessment, error) {
+	var assessments []*Assessment
+	err := r.db.WithContext(ctx).
+		Omit("pix").
+		Where("account_id = ? AND created_at >= ?", accountID, since).
+		Order("created_at DESC").
+		Find(&assessments).Error
+	if err != nil {
+		return nil, err
+	}
+	return assessments, nil
+}
+
+// ListReviews returns the oldest reviews first, the order analysts work them.
+func (r repository) ListReviews(ctx context.Context, status ReviewStatus, limit int) ([]*Assessment, error) {
+	var assessments []*Assessment
+	err := r.db.WithContext(ctx).
+		Where("decision = ? AND review_status = ?", DecisionReview, status).
+		Order("created_at").
+		Limit(limit).
+		Find(&assessments).Error
+	if err != nil {
+		return nil, err
+	}
+	return assessments, nil
+}
+
+// UpdateReview moves the review on only if it is still at from, so two
+// analysts can never both resolve it.
+func (r repository) UpdateReview(ctx context.Context, assessment *Assessment, from ReviewStatus) error {
+	assessment.UpdatedAt = time.Now()
+	result := r.db.WithContext(ctx).Model(&Assessment{}).
+		Where("id = ? AND review_status = ?", assessment.Id, from).
+		Updates(map[string]interface{}{
+			"review_status": assessment.ReviewStatus,
+			"reviewed_by":   assessment.ReviewedBy,
+			"reviewed_at":   assessment.ReviewedAt,
+			"review_note":   assessment.ReviewNote,
+			"updated_at":    assessment.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errutils.ErrReviewResolved
+	}
+	return nil
+}
+
+// KeyCreatedAt is when a key of this bank was registered, nil for keys of
+// other banks.
+func (r repository) KeyCreatedAt(ctx context.Context, key string) (*time.Time, error) {
+	var createdAt []time.Time
+	err := r.db.WithContext(ctx).Table("keys").
+		Where("name = ? AND deleted_at IS NULL", key).
+		Limit(1).
+		Pluck("created_at", &createdAt).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(createdAt) == 0 {
+		return nil, nil
+	}
+	return &createdAt[0], nil
+}
+
+func NewRepository(db *gorm.DB, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
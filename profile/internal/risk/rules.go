@@ -0,0 +1,183 @@
+package risk
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"os"
+	"profile/internal/errutils"
+	"sync"
+	"time"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rule adds Score to a pix its Condition matches. Window, Count, Factor and
+// MinHistory tune the condition, each condition reads the ones it needs.
+type Rule struct {
+	Name       string   `json:"name"`
+	Condition  string   `json:"condition"`
+	Score      int      `json:"score"`
+	Reason     string   `json:"reason"`
+	Window     Duration `json:"window,omitempty"`
+	Count      int      `json:"count,omitempty"`
+	Factor     float64  `json:"factor,omitempty"`
+	MinHistory int      `json:"min_history,omitempty"`
+}
+
+// RuleSet sends a pix to review when the scores of the rules it matched add
+// up to Review, and denies it from Deny on. Lookback is how far back the
+// history of the account is loaded for the rules.
+type RuleSet struct {
+	Review   int      `json:"review"`
+	Deny     int      `json:"deny"`
+	Lookback Duration `json:"lookback"`
+	Rules    []Rule   `json:"rules"`
+}
+
+// Condition tells whether the rule matches the pix described by facts.
+type Condition func(rule Rule, facts *Facts) bool
+
+var (
+	conditionsMu sync.RWMutex
+	conditions   = map[string]Condition{
+		"new_receiver":         newReceiver,
+		"amount_above_average": amountAboveAverage,
+		"velocity":             velocity,
+		"contact_changed":      contactChanged,
+		"new_receiver_key":     newReceiverKey,
+	}
+)
+
+// RegisterCondition makes a condition available to rule sets loaded after it.
+func RegisterCondition(name string, condition Condition) {
+	conditionsMu.Lock()
+	defer conditionsMu.Unlock()
+	conditions[name] = condition
+}
+
+func lookupCondition(name string) (Condition, bool) {
+	conditionsMu.RLock()
+	defer conditionsMu.RUnlock()
+	condition, ok := conditions[name]
+	return condition, ok
+}
+
+// LoadRuleSet reads the rule set from a JSON file, or the one shipped with
+// the service when path is empty.
+func LoadRuleSet(path string) (*RuleSet, error) {
+	data := defaultRules
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var ruleSet RuleSet
+	if err := json.Unmarshal(data, &ruleSet); err != nil {
+		return nil, fmt.Errorf("%w: %v", errutils.ErrInvalidRuleSet, err)
+	}
+	if err := ruleSet.Validate(); err != nil {
+		return nil, err
+	}
+	return &ruleSet, nil
+}
+
+func (r *RuleSet) Validate() error {
+	if r.Review <= 0 || r.Deny < r.Review {
+		return fmt.Errorf("%w: review must be positive and deny at least review", errutils.ErrInvalidRuleSet)
+	}
+
+	names := make(map[string]bool, len(r.Rules))
+	for _, rule := range r.Rules {
+		if rule.Name == "" || names[rule.Name] {
+			return fmt.Errorf("%w: rule names must be set and unique, got %q", errutils.ErrInvalidRuleSet, rule.Name)
+		}
+		names[rule.Name] = true
+		if _, ok := lookupCondition(rule.Condition); !ok {
+			return fmt.Errorf("%w: rule %s has unknown condition %q", errutils.ErrInvalidRuleSet, rule.Name, rule.Condition)
+		}
+	}
+	return nil
+}
+
+// Evaluate scores the pix and lists the reasons of the rules it matched.
+func (r *RuleSet) Evaluate(facts *Facts) (int, Decision, []string) {
+	score, reasons := 0, []string{}
+	for _, rule := range r.Rules {
+		condition, ok := lookupCondition(rule.Condition)
+		if !ok || !condition(rule, facts) {
+			continue
+		}
+		score += rule.Score
+		reason := rule.Reason
+		if reason == "" {
+			reason = rule.Name
+		}
+		reasons = append(reasons, reason)
+	}
+
+	switch {
+	case score >= r.Deny:
+		return score, DecisionDeny, reasons
+	case score >= r.Review:
+		return score, DecisionReview, reasons
+	default:
+		return score, DecisionAllow, reasons
+	}
+}
+
+// newReceiver matches a receiver the account never sent a pix to.
+func newReceiver(_ Rule, facts *Facts) bool {
+	for _, past := range facts.History {
+		if past.Sent() && past.Receiver == facts.Receiver {
+			return false
+		}
+	}
+	return true
+}
+
+// amountAboveAverage matches an amount Factor times above the average of the
+// pix the account sent, once it sent at least MinHistory of them.
+func amountAboveAverage(rule Rule, facts *Facts) bool {
+	total, count := decimal.Zero, 0
+	for _, past := range facts.History {
+		if past.Sent() {
+			total = total.Add(past.Amount)
+			count++
+		}
+	}
+	if count == 0 || count < rule.MinHistory {
+		return false
+	}
+	average := total.Div(decimal.NewFromInt(int64(count)))
+	return facts.Amount.GreaterThan(average.Mul(decimal.NewFromFloat(rule.Factor)))
+}
+
+// velocity matches when the account already tried Count pix within Window.
+// Pix that failed before leaving the account are not counted.
+func velocity(rule Rule, facts *Facts) bool {
+	since := facts.Now.Add(-time.Duration(rule.Window))
+	count := 0
+	for _, past := range facts.History {
+		if past.Decision != DecisionDeny && past.ReviewStatus != ReviewFailed && past.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count >= rule.Count
+}
+
+// contactChanged matches when the email or the phone of the user changed
+// within Window.
+func contactChanged(rule Rule, facts *Facts) bool {
+	return facts.ContactChangedAt != nil && facts.ContactChangedAt.After(facts.Now.Add(-time.Duration(rule.Window)))
+}
+
+// newReceiverKey matches a receiver key created within Window. Keys of other
+// banks are unknown here and never match.
+func newReceiverKey(rule Rule, facts *Facts) bool {
+	return facts.ReceiverKeyCreatedAt != nil && facts.ReceiverKeyCreatedAt.After(facts.Now.Add(-time.Duration(rule.Window)))
+}
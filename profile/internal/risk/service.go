@@ -0,0 +1,136 @@
+package risk
+
+import (
+	"context"
+	"profile/internal/errutils"
+	"time"
+)
+
+type Service interface {
+	Assess(ctx context.Context, input *Input) (*Assessment, error)
+	FindByTransaction(ctx context.Context, transactionID string) (*Assessment, error)
+	ListReviews(ctx context.Context, status ReviewStatus, limit int) ([]*Assessment, error)
+	Approve(ctx context.Context, req *ReviewRequest) (*Assessment, error)
+	Reject(ctx context.Context, req *ReviewRequest) (*Assessment, error)
+	Fail(ctx context.Context, assessment *Assessment, reason string) error
+}
+
+type service struct {
+	repo  Repository
+	rules *RuleSet
+}
+
+// Assess runs the rules over the pix and stores the decision. A pix sent to
+// review is parked in the assessment, pending an analyst.
+func (s *service) Assess(ctx context.Context, input *Input) (*Assessment, error) {
+	now := time.Now()
+	history, err := s.repo.ListHistory(ctx, input.AccountID, now.Add(-time.Duration(s.rules.Lookback)))
+	if err != nil {
+		return nil, err
+	}
+	// a pix assessed again as it runs is not part of its own history
+	past := history[:0]
+	for _, earlier := range history {
+		if earlier.TransactionID != input.TransactionID {
+			past = append(past, earlier)
+		}
+	}
+
+	keyCreatedAt, err := s.repo.KeyCreatedAt(ctx, input.Receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	score, decision, reasons := s.rules.Evaluate(&Facts{
+		AccountID:            input.AccountID,
+		Receiver:             input.Receiver,
+		Amount:               input.Amount,
+		Now:                  now,
+		History:              past,
+		ContactChangedAt:     input.ContactChangedAt,
+		ReceiverKeyCreatedAt: keyCreatedAt,
+	})
+
+	assessment := &Assessment{
+		TransactionID: input.TransactionID,
+		AccountID:     input.AccountID,
+		Receiver:      input.Receiver,
+		Amount:        input.Amount,
+		Score:         score,
+		Decision:      decision,
+		Reasons:       reasons,
+	}
+	if decision == DecisionReview {
+		assessment.ReviewStatus = ReviewPending
+		assessment.Pix = string(input.Pix)
+	}
+
+	if err = s.repo.Create(ctx, assessment); err != nil {
+		return nil, err
+	}
+	return assessment, nil
+}
+
+func (s *service) FindByTransaction(ctx context.Context, transactionID string) (*Assessment, error) {
+	return s.repo.FindByTransaction(ctx, transactionID)
+}
+
+func (s *service) ListReviews(ctx context.Context, status ReviewStatus, limit int) ([]*Assessment, error) {
+	if status == "" {
+		status = ReviewPending
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return s.repo.ListReviews(ctx, status, limit)
+}
+
+// Approve claims the review for the analyst. The caller sends the parked pix
+// and reports with Fail if it could not.
+func (s *service) Approve(ctx context.Context, req *ReviewRequest) (*Assessment, error) {
+	return s.resolve(ctx, req, ReviewApproved)
+}
+
+func (s *service) Reject(ctx context.Context, req *ReviewRequest) (*Assessment, error) {
+	return s.resolve(ctx, req, ReviewRejected)
+}
+
+func (s *service) resolve(ctx context.Context, req *ReviewRequest, status ReviewStatus) (*Assessment, error) {
+	if req.Analyst == "" {
+		return nil, errutils.ErrAnalystRequired
+	}
+
+	assessment, err := s.repo.Find(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if assessment.ReviewStatus != ReviewPending {
+		return nil, errutils.ErrReviewResolved
+	}
+
+	now := time.Now()
+	assessment.ReviewStatus = status
+	assessment.ReviewedBy = req.Analyst
+	assessment.ReviewedAt = &now
+	assessment.ReviewNote = req.Note
+	if err = s.repo.UpdateReview(ctx, assessment, ReviewPending); err != nil {
+		return nil, err
+	}
+	return assessment, nil
+}
+
+// Fail marks a pix that was allowed or approved as never sent, so the rules
+// stop counting it.
+func (s *service) Fail(ctx context.Context, assessment *Assessment, reason string) error {
+	from := assessment.ReviewStatus
+	assessment.ReviewStatus = ReviewFailed
+	assessment.ReviewNote = reason
+	return s.repo.UpdateReview(ctx, assessment, from)
+}
+
+func NewService(repo Repository, rules *RuleSet) Service {
+	return &service{
+		repo:  repo,
+		rules: rules,
+	}
+}
@@ -0,0 +1,258 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"profile/internal/errutils"
+	"testing"
+	"time"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepo) Create(ctx context.Context, assessment *Assessment) error {
+	args := m.Called(assessment)
+	return args.Error(0)
+}
+
+func (m *mockRepo) Find(ctx context.Context, id string) (*Assessment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*Assessment), args.Error(1)
+}
+
+func (m *mockRepo) ListHistory(ctx context.Context, accountID int64, since time.Time) ([]*Assessment, error) {
+	args := m.Called(accountID)
+	return args.Get(0).([]*Assessment), args.Error(1)
+}
+
+func (m *mockRepo) UpdateReview(ctx context.Context, assessment *Assessment, from ReviewStatus) error {
+	args := m.Called(assessment, from)
+	return args.Error(0)
+}
+
+func (m *mockRepo) KeyCreatedAt(ctx context.Context, key string) (*time.Time, error) {
+	args := m.Called(key)
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func sent(receiver string, amount int64, age time.Duration) *Assessment {
+	return &Assessment{Receiver: receiver, Amount: decimal.NewFromInt(amount), Decision: DecisionAllow, CreatedAt: time.Now().Add(-age)}
+}
+
+// failed is a pix that was allowed but never left the account.
+func failed(receiver string, amount int64, age time.Duration) *Assessment {
+	assessment := sent(receiver, amount, age)
+	assessment.ReviewStatus = ReviewFailed
+	return assessment
+}
+
+func TestLoadRuleSet(t *testing.T) {
+	rules, err := LoadRuleSet("")
+	assert.NoError(t, err)
+	assert.Len(t, rules.Rules, 5)
+
+	invalid := &RuleSet{Review: 50, Deny: 90, Rules: []Rule{{Name: "odd", Condition: "moon_phase"}}}
+	assert.True(t, errors.Is(invalid.Validate(), errutils.ErrInvalidRuleSet))
+
+	RegisterCondition("moon_phase", func(Rule, *Facts) bool { return true })
+	assert.NoError(t, invalid.Validate())
+}
+
+func TestEvaluate(t *testing.T) {
+	rules, _ := LoadRuleSet("")
+	now := time.Now()
+	recently := now.Add(-time.Hour)
+	keyCreated := now.Add(-5 * time.Minute)
+
+	cases := []struct {
+		name     string
+		facts    *Facts
+		decision Decision
+		reasons  []string
+	}{
+		{
+			name: "known receiver and usual amount",
+			facts: &Facts{Receiver: "alice", Amount: decimal.NewFromInt(100), Now: now,
+				History: []*Assessment{sent("alice", 100, 48*time.Hour), sent("bob", 80, 72*time.Hour)}},
+			decision: DecisionAllow,
+			reasons:  []string{},
+		},
+		{
+			name: "new receiver far above the average",
+			facts: &Facts{Receiver: "mallory", Amount: decimal.NewFromInt(1000), Now: now,
+				History: []*Assessment{sent("alice", 100, time.Hour), sent("alice", 100, 2*time.Hour), sent("bob", 100, 3*time.Hour)}},
+			decision: DecisionReview,
+			reasons:  []string{"first pix to this receiver", "amount far above the account average"},
+		},
+		{
+			name: "new receiver with a new key right after a contact change",
+			facts: &Facts{Receiver: "mallory", Amount: decimal.NewFromInt(100), Now: now,
+				ContactChangedAt: &recently, ReceiverKeyCreatedAt: &keyCreated},
+			decision: DecisionDeny,
+			reasons:  []string{"first pix to this receiver", "email or phone changed recently", "receiver key created minutes ago"},
+		},
+		{
+			name: "many pix in a short window",
+			facts: &Facts{Receiver: "alice", Amount: decimal.NewFromInt(10), Now: now,
+				History: []*Assessment{sent("alice", 10, time.Minute), sent("alice", 10, 2*time.Minute),
+					sent("alice", 10, 3*time.Minute), sent("alice", 10, 4*time.Minute), sent("alice", 10, 5*time.Minute)}},
+			decision: DecisionAllow,
+			reasons:  []string{"many pix in a short window"},
+		},
+		{
+			name: "pix that failed are not counted",
+			facts: &Facts{Receiver: "alice", Amount: decimal.NewFromInt(10), Now: now,
+				History: []*Assessment{failed("alice", 10, time.Minute), failed("alice", 10, 2*time.Minute),
+					failed("alice", 10, 3*time.Minute), failed("alice", 10, 4*time.Minute), failed("alice", 10, 5*time.Minute)}},
+			decision: DecisionAllow,
+			reasons:  []string{"first pix to this receiver"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, decision, reasons := rules.Evaluate(c.facts)
+			assert.Equal(t, c.decision, decision)
+			assert.Equal(t, c.reasons, reasons)
+		})
+	}
+}
+
+func TestAssessParksReview(t *testing.T) {
+	rules, _ := LoadRuleSet("")
+	repo := new(mockRepo)
+	repo.On("ListHistory", int64(1)).Return([]*Assessment{
+		sent("alice", 100, time.Hour), sent("alice", 100, 2*time.Hour), sent("bob", 100, 3*time.Hour),
+	}, nil)
+	repo.On("KeyCreatedAt", "mallory").Return((*time.Time)(nil), nil)
+	repo.On("Create", mock.Anything).Return(nil)
+
+	s := NewService(repo, rules)
+	got, err := s.Assess(context.Background(), &Input{
+		TransactionID: "tx-1",
+		AccountID:     1,
+		Receiver:      "mallory",
+		Amount:        decimal.NewFromInt(1000),
+		Pix:           []byte(`{"id":"tx-1"}`),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, DecisionReview, got.Decision)
+	assert.Equal(t, ReviewPending, got.ReviewStatus)
+	assert.Equal(t, `{"id":"tx-1"}`, got.Pix)
+	assert.Equal(t, 50, got.Score)
+	repo.AssertExpectations(t)
+}
+
+func TestAssessRunLeavesOutItsOwnAssessment(t *testing.T) {
+	scheduled := sent("mallory", 100, time.Hour)
+	scheduled.TransactionID = "tx-1"
+
+	rules, _ := LoadRuleSet("")
+	repo := new(mockRepo)
+	repo.On("ListHistory", int64(1)).Return([]*Assessment{scheduled}, nil)
+	repo.On("KeyCreatedAt", "mallory").Return((*time.Time)(nil), nil)
+	repo.On("Create", mock.Anything).Return(nil)
+
+	got, err := NewService(repo, rules).Assess(context.Background(), &Input{
+		TransactionID: "tx-1",
+		AccountID:     1,
+		Receiver:      "mallory",
+		Amount:        decimal.NewFromInt(100),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first pix to this receiver"}, got.Reasons)
+	repo.AssertExpectations(t)
+}
+
+func TestApprove(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      *ReviewRequest
+		mockFunc func(repo *mockRepo)
+		err      error
+	}{
+		{
+			name: "success",
+			req:  &ReviewRequest{ID: "risk-1", Analyst: "ana"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("Find", "risk-1").Return(&Assessment{Id: "risk-1", Decision: DecisionReview, ReviewStatus: ReviewPending}, nil)
+				repo.On("UpdateReview", mock.MatchedBy(func(a *Assessment) bool {
+					return a.ReviewStatus == ReviewApproved && a.ReviewedBy == "ana" && a.ReviewedAt != nil
+				}), ReviewPending).Return(nil)
+			},
+		},
+		{
+			name: "failed because another analyst resolved it first",
+			req:  &ReviewRequest{ID: "risk-1", Analyst: "ana"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("Find", "risk-1").Return(&Assessment{Id: "risk-1", Decision: DecisionReview, ReviewStatus: ReviewPending}, nil)
+				repo.On("UpdateReview", mock.Anything, ReviewPending).Return(errutils.ErrReviewResolved)
+			},
+			err: errutils.ErrReviewResolved,
+		},
+		{
+			name: "failed because review was already rejected",
+			req:  &ReviewRequest{ID: "risk-1", Analyst: "ana"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("Find", "risk-1").Return(&Assessment{Id: "risk-1", Decision: DecisionReview, ReviewStatus: ReviewRejected}, nil)
+			},
+			err: errutils.ErrReviewResolved,
+		},
+		{
+			name:     "failed because analyst is missing",
+			req:      &ReviewRequest{ID: "risk-1"},
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrAnalystRequired,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			c.mockFunc(repo)
+			_, err := NewService(repo, &RuleSet{}).Approve(context.Background(), c.req)
+			assert.Equal(t, c.err, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	cases := []struct {
+		name       string
+		assessment *Assessment
+		from       ReviewStatus
+	}{
+		{
+			name:       "allowed pix that was not held",
+			assessment: &Assessment{Id: "risk-1", Decision: DecisionAllow},
+			from:       "",
+		},
+		{
+			name:       "approved review that could not be sent",
+			assessment: &Assessment{Id: "risk-1", Decision: DecisionReview, ReviewStatus: ReviewApproved},
+			from:       ReviewApproved,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("UpdateReview", c.assessment, c.from).Return(nil)
+
+			err := NewService(repo, &RuleSet{}).Fail(context.Background(), c.assessment, "insufficient balance")
+			assert.NoError(t, err)
+			assert.Equal(t, ReviewFailed, c.assessment.ReviewStatus)
+			assert.False(t, c.assessment.Sent())
+			repo.AssertExpectations(t)
+		})
+	}
+}
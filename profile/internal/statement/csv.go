@@ -0,0 +1,44 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Begin(statement *Statement) error {
+	err := c.w.Write([]string{"date", "line_id", "transaction_id", "type", "description", "amount", "balance"})
+	if err != nil {
+		return err
+	}
+	return c.w.Write([]string{statement.From.Format(time.RFC3339), "", "", "", "opening balance", "", statement.OpeningBalance.StringFixed(2)})
+}
+
+func (c *csvWriter) Line(line *Line) error {
+	return c.w.Write([]string{
+		line.CreatedAt.Format(time.RFC3339),
+		line.ID,
+		line.TransactionID,
+		line.Type,
+		line.Description,
+		line.Amount.StringFixed(2),
+		line.Balance.StringFixed(2),
+	})
+}
+
+func (c *csvWriter) End(statement *Statement) error {
+	err := c.w.Write([]string{statement.To.Format(time.RFC3339), "", "", "", "closing balance", "", statement.ClosingBalance.StringFixed(2)})
+	if err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func newCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
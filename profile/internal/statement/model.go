@@ -0,0 +1,73 @@
+package statement
+
+import (
+	"github.com/shopspring/decimal"
+	"profile/internal/errutils"
+	proto "profile/proto/profile/v1"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV Format = "CSV"
+	FormatOFX Format = "OFX"
+	FormatPDF Format = "PDF"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToUpper(value)); format {
+	case FormatCSV, FormatOFX, FormatPDF:
+		return format, nil
+	default:
+		return "", errutils.ErrInvalidStatementFormat
+	}
+}
+
+// Request asks for the statement of the lines posted from From, inclusive,
+// until To, exclusive.
+type Request struct {
+	AccountID int64
+	From      time.Time
+	To        time.Time
+	Format    Format
+}
+
+// Statement is the header and the totals of an exported statement. The
+// closing balance is only known once every line was written.
+type Statement struct {
+	AccountID      int64
+	Bank           string
+	Agency         string
+	From           time.Time
+	To             time.Time
+	OpeningBalance decimal.Decimal
+	ClosingBalance decimal.Decimal
+	GeneratedAt    time.Time
+}
+
+// Line is one journal line of the account, with Amount signed by its effect
+// on the balance and Balance the running balance after it.
+type Line struct {
+	ID            string
+	TransactionID string
+	Type          string
+	Description   string
+	Amount        decimal.Decimal
+	Balance       decimal.Decimal
+	CreatedAt     time.Time
+}
+
+func ProtoToRequest(req *proto.StatementRequest) (*Request, error) {
+	format, err := ParseFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		AccountID: req.AccountId,
+		From:      req.GetFrom().AsTime(),
+		To:        req.GetTo().AsTime(),
+		Format:    format,
+	}, nil
+}
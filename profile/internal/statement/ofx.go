@@ -0,0 +1,69 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const ofxDate = "20060102150405"
+
+// ofxWriter writes an OFX 2.2 bank statement. OFX has no opening or running
+// balance, so only the closing one goes in LEDGERBAL.
+type ofxWriter struct {
+	w io.Writer
+}
+
+func (o *ofxWriter) Begin(statement *Statement) error {
+	_, err := fmt.Fprintf(o.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>POR</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>BRL</CURDEF>
+<BANKACCTFROM><BANKID>%s</BANKID><BRANCHID>%s</BRANCHID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, statement.GeneratedAt.Format(ofxDate), escapeXML(statement.Bank), escapeXML(statement.Agency), statement.AccountID,
+		statement.From.Format(ofxDate), statement.To.Format(ofxDate))
+	return err
+}
+
+func (o *ofxWriter) Line(line *Line) error {
+	kind := "CREDIT"
+	if line.Amount.IsNegative() {
+		kind = "DEBIT"
+	}
+	_, err := fmt.Fprintf(o.w, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><REFNUM>%s</REFNUM><MEMO>%s</MEMO></STMTTRN>\n",
+		kind, line.CreatedAt.Format(ofxDate), line.Amount.StringFixed(2), escapeXML(line.ID),
+		escapeXML(line.TransactionID), escapeXML(memo(line)))
+	return err
+}
+
+func (o *ofxWriter) End(statement *Statement) error {
+	_, err := fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`, statement.ClosingBalance.StringFixed(2), statement.To.Format(ofxDate))
+	return err
+}
+
+// memo keeps the type and description within the 255 characters of MEMO.
+func memo(line *Line) string {
+	text := []rune(strings.TrimSpace(line.Type + " " + line.Description))
+	if len(text) > 255 {
+		text = text[:255]
+	}
+	return string(text)
+}
+
+func escapeXML(value string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func newOFXWriter(w io.Writer) Writer {
+	return &ofxWriter{w: w}
+}
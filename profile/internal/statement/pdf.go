@@ -0,0 +1,170 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The PDF is A4 in Courier, so columns line up by padding alone.
+const (
+	pdfWidth      = 595
+	pdfHeight     = 842
+	pdfMargin     = 40
+	pdfFontSize   = 8
+	pdfLeading    = 11
+	pdfLineChars  = 100
+	pdfDateLayout = "2006-01-02 15:04"
+)
+
+// Objects 1 to 3 are the catalog, the page tree and the font. The page tree
+// lists every page, so it is written last; pages take the numbers after it.
+const (
+	pdfCatalog = 1
+	pdfPages   = 2
+	pdfFont    = 3
+)
+
+// pdfWriter writes each page as soon as it is full, keeping only the current
+// page in memory and the object offsets for the cross reference table.
+type pdfWriter struct {
+	w       *countingWriter
+	offsets map[int]int64
+	next    int
+	pages   []int
+	page    bytes.Buffer
+	y       int
+	err     error
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (p *pdfWriter) Begin(statement *Statement) error {
+	p.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	p.object(pdfCatalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPages))
+	p.object(pdfFont, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	p.newPage()
+	p.text(fmt.Sprintf("Statement of account %d - bank %s agency %s", statement.AccountID, statement.Bank, statement.Agency))
+	p.text(fmt.Sprintf("Period %s to %s, generated at %s",
+		statement.From.Format(pdfDateLayout), statement.To.Format(pdfDateLayout), statement.GeneratedAt.Format(pdfDateLayout)))
+	p.text("")
+	p.text(row(statement.From.Format(pdfDateLayout), "", "opening balance", "", statement.OpeningBalance.StringFixed(2)))
+	return p.err
+}
+
+func (p *pdfWriter) Line(line *Line) error {
+	if p.y < pdfMargin {
+		p.endPage()
+		p.newPage()
+	}
+	p.text(row(line.CreatedAt.Format(pdfDateLayout), line.Type, line.Description, line.Amount.StringFixed(2), line.Balance.StringFixed(2)))
+	return p.err
+}
+
+func (p *pdfWriter) End(statement *Statement) error {
+	if p.y < pdfMargin {
+		p.endPage()
+		p.newPage()
+	}
+	p.text(row(statement.To.Format(pdfDateLayout), "", "closing balance", "", statement.ClosingBalance.StringFixed(2)))
+	p.endPage()
+
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	p.object(pdfPages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	xref := p.w.n
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", p.next)
+	for i := 1; i < p.next; i++ {
+		p.printf("%010d 00000 n \n", p.offsets[i])
+	}
+	p.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.next, pdfCatalog, xref)
+	return p.err
+}
+
+// newPage starts a page with the column titles.
+func (p *pdfWriter) newPage() {
+	p.page.Reset()
+	p.y = pdfHeight - pdfMargin
+	p.text(row("date", "type", "description", "amount", "balance"))
+	p.text(strings.Repeat("-", pdfLineChars))
+}
+
+func (p *pdfWriter) endPage() {
+	content, page := p.next, p.next+1
+	p.next += 2
+	p.object(content, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.page.Len(), p.page.String()))
+	p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPages, pdfWidth, pdfHeight, pdfFont, content))
+	p.pages = append(p.pages, page)
+}
+
+func (p *pdfWriter) text(value string) {
+	fmt.Fprintf(&p.page, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", pdfFontSize, pdfMargin, p.y, pdfString(value))
+	p.y -= pdfLeading
+}
+
+func (p *pdfWriter) object(number int, body string) {
+	p.offsets[number] = p.w.n
+	p.printf("%d 0 obj\n%s\nendobj\n", number, body)
+}
+
+func (p *pdfWriter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func row(date, kind, description, amount, balance string) string {
+	return fmt.Sprintf("%-16s  %-10s  %-38s  %14s  %14s", date, truncate(kind, 10), truncate(description, 38), amount, balance)
+}
+
+func truncate(value string, size int) string {
+	runes := []rune(value)
+	if len(runes) > size {
+		return string(runes[:size])
+	}
+	return value
+}
+
+// pdfString escapes the text for a PDF literal string in WinAnsiEncoding,
+// which matches Latin-1 for the accented letters of Portuguese names.
+func pdfString(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		case r >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func newPDFWriter(w io.Writer) Writer {
+	return &pdfWriter{
+		w:       &countingWriter{w: w},
+		offsets: make(map[int]int64),
+		next:    pdfFont + 1,
+	}
+}
@@ -0,0 +1,90 @@
+package statement
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"profile/internal/cfg"
+	"profile/internal/ledger"
+	"time"
+)
+
+type Repository interface {
+	// BalanceBefore is the balance of the account out of the lines posted
+	// before at.
+	BalanceBefore(ctx context.Context, accountID int64, at time.Time) (decimal.Decimal, error)
+	// StreamLines calls fn for each line of the account posted in the range,
+	// oldest first, without loading the whole range in memory.
+	StreamLines(ctx context.Context, accountID int64, from, to time.Time, fn func(*Line) error) error
+}
+
+type repository struct {
+	db  *gorm.DB
+	cfg *cfg.Config
+}
+
+type lineRow struct {
+	ID            string
+	TransactionID string
+	Type          string
+	Description   string
+	Direction     ledger.Direction
+	Amount        decimal.Decimal
+	CreatedAt     time.Time
+}
+
+func (r repository) BalanceBefore(ctx context.Context, accountID int64, at time.Time) (decimal.Decimal, error) {
+	var balance decimal.NullDecimal
+	err := r.db.WithContext(ctx).Model(&ledger.JournalLine{}).
+		Select("SUM(CASE WHEN direction = ? THEN amount ELSE -amount END)", ledger.Credit).
+		Where("account_id = ? AND created_at < ?", accountID, at).
+		Scan(&balance).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return balance.Decimal, nil
+}
+
+func (r repository) StreamLines(ctx context.Context, accountID int64, from, to time.Time, fn func(*Line) error) error {
+	db := r.db.WithContext(ctx)
+	rows, err := db.Table("journal_lines AS l").
+		Select("l.id, e.transaction_id, e.type, e.description, l.direction, l.amount, l.created_at").
+		Joins("INNER JOIN journal_entries AS e ON e.id = l.entry_id").
+		Where("l.account_id = ? AND l.created_at >= ? AND l.created_at < ?", accountID, from, to).
+		Order("l.created_at, l.id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row lineRow
+		if err = db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		amount := row.Amount
+		if row.Direction == ledger.Debit {
+			amount = amount.Neg()
+		}
+		err = fn(&Line{
+			ID:            row.ID,
+			TransactionID: row.TransactionID,
+			Type:          row.Type,
+			Description:   row.Description,
+			Amount:        amount,
+			CreatedAt:     row.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func NewRepository(db *gorm.DB, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
@@ -0,0 +1,88 @@
+package statement
+
+import (
+	"context"
+	"io"
+	"profile/internal/account"
+	"profile/internal/errutils"
+	"time"
+)
+
+type Service interface {
+	// Export writes the statement to w as its lines are read, so the caller
+	// can stream it. Nothing is written when the request is invalid.
+	Export(ctx context.Context, req *Request, w io.Writer) (*Statement, error)
+}
+
+type Options func(*service)
+
+// WithMaxPeriod bounds the range of a single statement.
+func WithMaxPeriod(period time.Duration) Options {
+	return func(s *service) {
+		s.maxPeriod = period
+	}
+}
+
+type service struct {
+	repo      Repository
+	accounts  account.Repository
+	maxPeriod time.Duration
+	now       func() time.Time
+}
+
+func (s *service) Export(ctx context.Context, req *Request, w io.Writer) (*Statement, error) {
+	if req.AccountID == 0 || req.From.IsZero() || !req.To.After(req.From) || req.To.Sub(req.From) > s.maxPeriod {
+		return nil, errutils.ErrInvalidStatementPeriod
+	}
+	found, err := s.accounts.FindAccountById(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	opening, err := s.repo.BalanceBefore(ctx, req.AccountID, req.From)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		AccountID:      req.AccountID,
+		Bank:           found.Bank,
+		Agency:         found.Agency,
+		From:           req.From,
+		To:             req.To,
+		OpeningBalance: opening,
+		GeneratedAt:    s.now(),
+	}
+	writer := NewWriter(req.Format, w)
+	if err = writer.Begin(statement); err != nil {
+		return nil, err
+	}
+
+	balance := opening
+	err = s.repo.StreamLines(ctx, req.AccountID, req.From, req.To, func(line *Line) error {
+		balance = balance.Add(line.Amount)
+		line.Balance = balance
+		return writer.Line(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statement.ClosingBalance = balance
+	if err = writer.End(statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func NewService(repo Repository, accounts account.Repository, opts ...Options) Service {
+	s := &service{
+		repo:      repo,
+		accounts:  accounts,
+		maxPeriod: 366 * 24 * time.Hour,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"profile/internal/account"
+	"profile/internal/errutils"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockRepo struct {
+	mock.Mock
+	lines []*Line
+}
+
+func (m *mockRepo) BalanceBefore(ctx context.Context, accountID int64, at time.Time) (decimal.Decimal, error) {
+	args := m.Called(accountID, at)
+	return args.Get(0).(decimal.Decimal), args.Error(1)
+}
+
+func (m *mockRepo) StreamLines(ctx context.Context, accountID int64, from, to time.Time, fn func(*Line) error) error {
+	for _, line := range m.lines {
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type mockAccountRepo struct {
+	account.Repository
+	mock.Mock
+}
+
+func (m *mockAccountRepo) FindAccountById(id int64) (*account.Account, error) {
+	args := m.Called(id)
+	return args.Get(0).(*account.Account), args.Error(1)
+}
+
+var (
+	from = time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	to   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestService(lines int) Service {
+	repo := &mockRepo{}
+	for i := 0; i < lines; i++ {
+		amount := decimal.NewFromInt(10)
+		if i%2 == 1 {
+			amount = decimal.NewFromInt(-4)
+		}
+		repo.lines = append(repo.lines, &Line{
+			ID:            "line-" + strconv.Itoa(i),
+			TransactionID: "tx-" + strconv.Itoa(i),
+			Type:          "PIX",
+			Description:   "pix (João)",
+			Amount:        amount,
+			CreatedAt:     from.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	repo.On("BalanceBefore", int64(1), from).Return(decimal.NewFromInt(100), nil)
+
+	accounts := new(mockAccountRepo)
+	accounts.On("FindAccountById", int64(1)).Return(&account.Account{Id: 1, Bank: "001", Agency: "0001"}, nil)
+
+	s := NewService(repo, accounts).(*service)
+	s.now = func() time.Time { return to }
+	return s
+}
+
+func TestExportCSV(t *testing.T) {
+	var out bytes.Buffer
+	statement, err := newTestService(3).Export(context.Background(), &Request{AccountID: 1, From: from, To: to, Format: FormatCSV}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "116.00", statement.ClosingBalance.StringFixed(2))
+
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, rows, 6)
+	assert.Equal(t, "2023-12-01T00:00:00Z,,,,opening balance,,100.00", rows[1])
+	assert.Equal(t, "2023-12-01T01:00:00Z,line-1,tx-1,PIX,pix (João),-4.00,106.00", rows[3])
+	assert.Equal(t, "2024-01-01T00:00:00Z,,,,closing balance,,116.00", rows[5])
+}
+
+func TestExportOFX(t *testing.T) {
+	var out bytes.Buffer
+	_, err := newTestService(2).Export(context.Background(), &Request{AccountID: 1, From: from, To: to, Format: FormatOFX}, &out)
+	require.NoError(t, err)
+
+	ofx := out.String()
+	assert.Contains(t, ofx, "<BANKID>001</BANKID><BRANCHID>0001</BRANCHID><ACCTID>1</ACCTID>")
+	assert.Contains(t, ofx, "<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20231201010000</DTPOSTED><TRNAMT>-4.00</TRNAMT><FITID>line-1</FITID>")
+	assert.Contains(t, ofx, "<LEDGERBAL><BALAMT>106.00</BALAMT><DTASOF>20240101000000</DTASOF></LEDGERBAL>")
+}
+
+func TestExportPDF(t *testing.T) {
+	var out bytes.Buffer
+	_, err := newTestService(150).Export(context.Background(), &Request{AccountID: 1, From: from, To: to, Format: FormatPDF}, &out)
+	require.NoError(t, err)
+
+	pdf := out.String()
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	assert.Contains(t, pdf, `(2023-12-01 00:00  PIX         pix \(Jo\343o\)`)
+	assert.Contains(t, pdf, "/Count 3")
+
+	// every object must start where the cross reference table says
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf, -1)
+	require.NotEmpty(t, xref)
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(pdf[offset:], fmt.Sprintf("%d 0 obj", i+1)), "object %d", i+1)
+	}
+}
+
+func TestExportInvalidPeriod(t *testing.T) {
+	var out bytes.Buffer
+	_, err := newTestService(1).Export(context.Background(), &Request{AccountID: 1, From: to, To: from, Format: FormatCSV}, &out)
+	assert.Equal(t, errutils.ErrInvalidStatementPeriod, err)
+	assert.Zero(t, out.Len())
+
+	_, err = ParseFormat("xlsx")
+	assert.Equal(t, errutils.ErrInvalidStatementFormat, err)
+}
@@ -0,0 +1,35 @@
+package statement
+
+import (
+	"io"
+)
+
+// Writer renders a statement as it is read: the header first, then one line
+// at a time, then the closing balance.
+type Writer interface {
+	Begin(statement *Statement) error
+	Line(line *Line) error
+	End(statement *Statement) error
+}
+
+func NewWriter(format Format, w io.Writer) Writer {
+	switch format {
+	case FormatOFX:
+		return newOFXWriter(w)
+	case FormatPDF:
+		return newPDFWriter(w)
+	default:
+		return newCSVWriter(w)
+	}
+}
+
+func ContentType(format Format) string {
+	switch format {
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
@@ -3,17 +3,391 @@ package transaction
 import (
 	"fmt"
 	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"profile/internal/lookup"
+	"profile/internal/ratelimit"
 	pb "profile/proto/profile/v1"
+	transpb "profile/proto/transactions/v1"
+	"strconv"
+	"time"
 )
 
 type Pix struct {
-	UserID     string          `json:"user_id"`
-	AccountID  int64           `json:"account_id"`
-	Key        string          `json:"key"`
-	Receiver   string          `json:"receiver"`
-	Amount     decimal.Decimal `json:"amount"`
-	Status     string          `json:"status"`
-	WebhookUrl string          `json:"webhook_url"`
+	ID                    string          `json:"id"`
+	IdempotencyKey        string          `json:"idempotency_key"`
+	UserID                string          `json:"user_id"`
+	AccountID             int64           `json:"account_id"`
+	Key                   string          `json:"key"`
+	Receiver              string          `json:"receiver"`
+	Amount                decimal.Decimal `json:"amount"`
+	Status                string          `json:"status"`
+	WebhookUrl            string          `json:"webhook_url"`
+	OriginalTransactionID string          `json:"original_transaction_id,omitempty"`
+	ExecuteAt             time.Time       `json:"execute_at"`
+	CopyPaste             string          `json:"copy_paste,omitempty"`
+	TxID                  string          `json:"txid,omitempty"`
+	LookupToken           string          `json:"lookup_token,omitempty"`
+	ReceiverAccount       int64           `json:"receiver_account,omitempty"`
+	Type                  string          `json:"type,omitempty"`
+	CreatedAt             time.Time       `json:"created_at"`
+	// RefundedAmount is only known to the transaction service and is not
+	// part of the stored or published pix
+	RefundedAmount decimal.Decimal `json:"-"`
+}
+
+// IsScheduled reports whether the pix waits for a future date instead of
+// being sent right away.
+func (p *Pix) IsScheduled() bool {
+	return !p.ExecuteAt.IsZero()
+}
+
+// Refund returns part of a received pix to its sender. A zero amount refunds
+// everything not refunded yet.
+type Refund struct {
+	TransactionID string
+	AccountID     int64
+	Amount        decimal.Decimal
+}
+
+func ProtoToRefund(refund *pb.PixRefund) *Refund {
+	return &Refund{
+		TransactionID: refund.TransactionId,
+		AccountID:     refund.AccountId,
+		Amount:        decimal.NewFromFloat(refund.Amount),
+	}
+}
+
+func TransactionToPix(transaction *transpb.Transaction) *Pix {
+	pix := &Pix{
+		ID:                    transaction.Id,
+		Type:                  transaction.Type,
+		Receiver:              transaction.Receiver,
+		ReceiverAccount:       transaction.ReceiverAccount,
+		Amount:                decimal.NewFromFloat(transaction.Value),
+		RefundedAmount:        decimal.NewFromFloat(transaction.RefundedValue),
+		Status:                transaction.Status,
+		OriginalTransactionID: transaction.OriginalTransactionId,
+	}
+	if account, err := strconv.ParseInt(transaction.AccountId, 10, 64); err == nil {
+		pix.AccountID = account
+	}
+	if transaction.CreatedAt != nil {
+		pix.CreatedAt = transaction.CreatedAt.AsTime()
+	}
+	return pix
+}
+
+// HistoryRequest asks for a page of the pix of an account, newest first.
+// Direction is SENT, RECEIVED or empty for both.
+type HistoryRequest struct {
+	AccountID int64
+	Direction string
+	Status    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Cursor    string
+}
+
+type History struct {
+	Transactions []*Pix
+	NextCursor   string
+}
+
+func ProtoToHistoryRequest(req *pb.TransactionHistoryRequest) *HistoryRequest {
+	history := &HistoryRequest{
+		AccountID: req.AccountId,
+		Direction: req.Direction,
+		Status:    req.Status,
+		Limit:     int(req.Limit),
+		Cursor:    req.Cursor,
+	}
+	if req.From != nil {
+		history.From = req.From.AsTime()
+	}
+	if req.To != nil {
+		history.To = req.To.AsTime()
+	}
+	return history
+}
+
+func HistoryRequestToBackend(req *HistoryRequest) *transpb.AccountTransactionsRequest {
+	backend := &transpb.AccountTransactionsRequest{
+		AccountId: req.AccountID,
+		Direction: req.Direction,
+		Status:    req.Status,
+		Limit:     int32(req.Limit),
+		Cursor:    req.Cursor,
+	}
+	if !req.From.IsZero() {
+		backend.From = timestamppb.New(req.From)
+	}
+	if !req.To.IsZero() {
+		backend.To = timestamppb.New(req.To)
+	}
+	return backend
+}
+
+func HistoryToProto(history *History) *pb.TransactionHistory {
+	return &pb.TransactionHistory{
+		Transactions: ToProtoList(history.Transactions).Transactions,
+		NextCursor:   history.NextCursor,
+	}
+}
+
+// QRCode is a BR Code for a pix key. Payload is its "copia e cola" text.
+type QRCode struct {
+	Key          string
+	Amount       decimal.Decimal
+	TxID         string
+	MerchantName string
+	MerchantCity string
+	Description  string
+	Payload      string
+}
+
+func ProtoToQRCode(code *pb.QRCode) *QRCode {
+	return &QRCode{
+		Key:          code.Key,
+		Amount:       decimal.NewFromFloat(code.Amount),
+		TxID:         code.Txid,
+		MerchantName: code.MerchantName,
+		MerchantCity: code.MerchantCity,
+		Description:  code.Description,
+		Payload:      code.Payload,
+	}
+}
+
+func BRCodeToQRCode(code *transpb.BRCode) *QRCode {
+	return &QRCode{
+		Key:          code.Key,
+		Amount:       decimal.NewFromFloat(code.Amount),
+		TxID:         code.Txid,
+		MerchantName: code.MerchantName,
+		MerchantCity: code.MerchantCity,
+		Description:  code.Description,
+		Payload:      code.Payload,
+	}
+}
+
+func QRCodeToProto(code *QRCode) *pb.QRCode {
+	return &pb.QRCode{
+		Key:          code.Key,
+		Amount:       code.Amount.InexactFloat64(),
+		Txid:         code.TxID,
+		MerchantName: code.MerchantName,
+		MerchantCity: code.MerchantCity,
+		Description:  code.Description,
+		Payload:      code.Payload,
+	}
+}
+
+// Charge is a charge an account receives on one of its keys, immediate (cob)
+// or with a due date (cobv). The payer settles it with a pix carrying the
+// charge TxID, for AmountDue on the day it is paid.
+type Charge struct {
+	TxID          string
+	AccountID     int64
+	Key           string
+	Amount        decimal.Decimal
+	Expiration    int64
+	PayerCpf      string
+	PayerName     string
+	PayerRequest  string
+	Status        string
+	TransactionID string
+	Revision      int32
+	CreatedAt     time.Time
+	PaidAt        time.Time
+	DueDate       string
+	ValidAfterDue int32
+	Fine          ChargeAdjustment
+	Interest      ChargeAdjustment
+	Rebate        ChargeAdjustment
+	Discounts     []ChargeDiscount
+	AmountDue     decimal.Decimal
+}
+
+// ChargeAdjustment is a FIXED value or a PERCENT of the charge amount.
+type ChargeAdjustment struct {
+	Modality string
+	Value    decimal.Decimal
+}
+
+type ChargeDiscount struct {
+	Until    string
+	Modality string
+	Value    decimal.Decimal
+}
+
+func ProtoToCharge(charge *pb.PixCharge) *Charge {
+	discounts := make([]ChargeDiscount, len(charge.Discounts))
+	for i, discount := range charge.Discounts {
+		discounts[i] = ChargeDiscount{
+			Until:    discount.Until,
+			Modality: discount.Modality,
+			Value:    decimal.NewFromFloat(discount.Value),
+		}
+	}
+
+	return &Charge{
+		TxID:          charge.Txid,
+		AccountID:     charge.AccountId,
+		Key:           charge.Key,
+		Amount:        decimal.NewFromFloat(charge.Amount),
+		Expiration:    charge.Expiration,
+		PayerCpf:      charge.PayerCpf,
+		PayerName:     charge.PayerName,
+		PayerRequest:  charge.PayerRequest,
+		DueDate:       charge.DueDate,
+		ValidAfterDue: charge.ValidAfterDue,
+		Fine:          ChargeAdjustment{Modality: charge.GetFine().GetModality(), Value: decimal.NewFromFloat(charge.GetFine().GetValue())},
+		Interest:      ChargeAdjustment{Modality: charge.GetInterest().GetModality(), Value: decimal.NewFromFloat(charge.GetInterest().GetValue())},
+		Rebate:        ChargeAdjustment{Modality: charge.GetRebate().GetModality(), Value: decimal.NewFromFloat(charge.GetRebate().GetValue())},
+		Discounts:     discounts,
+	}
+}
+
+func BackendToCharge(charge *transpb.Charge) *Charge {
+	var paidAt time.Time
+	if charge.PaidAt != nil {
+		paidAt = charge.PaidAt.AsTime()
+	}
+
+	discounts := make([]ChargeDiscount, len(charge.Discounts))
+	for i, discount := range charge.Discounts {
+		discounts[i] = ChargeDiscount{
+			Until:    discount.Until,
+			Modality: discount.Modality,
+			Value:    decimal.NewFromFloat(discount.Value),
+		}
+	}
+
+	return &Charge{
+		TxID:          charge.Txid,
+		AccountID:     charge.AccountId,
+		Key:           charge.Key,
+		Amount:        decimal.NewFromFloat(charge.Amount),
+		Expiration:    charge.Expiration,
+		PayerCpf:      charge.GetPayer().GetCpf(),
+		PayerName:     charge.GetPayer().GetName(),
+		PayerRequest:  charge.PayerRequest,
+		Status:        charge.Status,
+		TransactionID: charge.TransactionId,
+		Revision:      charge.Revision,
+		CreatedAt:     charge.GetCreatedAt().AsTime(),
+		PaidAt:        paidAt,
+		DueDate:       charge.DueDate,
+		ValidAfterDue: charge.ValidAfterDue,
+		Fine:          ChargeAdjustment{Modality: charge.GetFine().GetModality(), Value: decimal.NewFromFloat(charge.GetFine().GetValue())},
+		Interest:      ChargeAdjustment{Modality: charge.GetInterest().GetModality(), Value: decimal.NewFromFloat(charge.GetInterest().GetValue())},
+		Rebate:        ChargeAdjustment{Modality: charge.GetRebate().GetModality(), Value: decimal.NewFromFloat(charge.GetRebate().GetValue())},
+		Discounts:     discounts,
+		AmountDue:     decimal.NewFromFloat(charge.AmountDue),
+	}
+}
+
+// ChargeToBackend is the charge as sent to the transaction service.
+func ChargeToBackend(charge *Charge) *transpb.Charge {
+	discounts := make([]*transpb.ChargeDiscount, len(charge.Discounts))
+	for i, discount := range charge.Discounts {
+		discounts[i] = &transpb.ChargeDiscount{
+			Until:    discount.Until,
+			Modality: discount.Modality,
+			Value:    discount.Value.InexactFloat64(),
+		}
+	}
+
+	return &transpb.Charge{
+		Txid:          charge.TxID,
+		AccountId:     charge.AccountID,
+		Key:           charge.Key,
+		Amount:        charge.Amount.InexactFloat64(),
+		Expiration:    charge.Expiration,
+		Payer:         &transpb.Payer{Cpf: charge.PayerCpf, Name: charge.PayerName},
+		PayerRequest:  charge.PayerRequest,
+		DueDate:       charge.DueDate,
+		ValidAfterDue: charge.ValidAfterDue,
+		Fine:          adjustmentToBackend(charge.Fine),
+		Interest:      adjustmentToBackend(charge.Interest),
+		Rebate:        adjustmentToBackend(charge.Rebate),
+		Discounts:     discounts,
+	}
+}
+
+func adjustmentToBackend(adjustment ChargeAdjustment) *transpb.ChargeAdjustment {
+	if adjustment.Modality == "" {
+		return nil
+	}
+	return &transpb.ChargeAdjustment{Modality: adjustment.Modality, Value: adjustment.Value.InexactFloat64()}
+}
+
+func adjustmentToProto(adjustment ChargeAdjustment) *pb.PixChargeAdjustment {
+	if adjustment.Modality == "" {
+		return nil
+	}
+	return &pb.PixChargeAdjustment{Modality: adjustment.Modality, Value: adjustment.Value.InexactFloat64()}
+}
+
+func ChargeToProto(charge *Charge) *pb.PixCharge {
+	var paidAt *timestamppb.Timestamp
+	if !charge.PaidAt.IsZero() {
+		paidAt = timestamppb.New(charge.PaidAt)
+	}
+
+	discounts := make([]*pb.PixChargeDiscount, len(charge.Discounts))
+	for i, discount := range charge.Discounts {
+		discounts[i] = &pb.PixChargeDiscount{
+			Until:    discount.Until,
+			Modality: discount.Modality,
+			Value:    discount.Value.InexactFloat64(),
+		}
+	}
+
+	return &pb.PixCharge{
+		Txid:          charge.TxID,
+		AccountId:     charge.AccountID,
+		Key:           charge.Key,
+		Amount:        charge.Amount.InexactFloat64(),
+		Expiration:    charge.Expiration,
+		PayerCpf:      charge.PayerCpf,
+		PayerName:     charge.PayerName,
+		PayerRequest:  charge.PayerRequest,
+		Status:        charge.Status,
+		TransactionId: charge.TransactionID,
+		Revision:      charge.Revision,
+		CreatedAt:     timestamppb.New(charge.CreatedAt),
+		PaidAt:        paidAt,
+		DueDate:       charge.DueDate,
+		ValidAfterDue: charge.ValidAfterDue,
+		Fine:          adjustmentToProto(charge.Fine),
+		Interest:      adjustmentToProto(charge.Interest),
+		Rebate:        adjustmentToProto(charge.Rebate),
+		Discounts:     discounts,
+		AmountDue:     charge.AmountDue.InexactFloat64(),
+	}
+}
+
+func ChargesToProto(charges []*Charge) *pb.ListPixCharges {
+	list := make([]*pb.PixCharge, len(charges))
+	for i := range charges {
+		list[i] = ChargeToProto(charges[i])
+	}
+	return &pb.ListPixCharges{Charges: list}
+}
+
+func ScheduledToPix(scheduled *transpb.ScheduledPix) *Pix {
+	return &Pix{
+		ID:              scheduled.Id,
+		IdempotencyKey:  scheduled.IdempotencyKey,
+		AccountID:       scheduled.GetAccount().GetName(),
+		Receiver:        scheduled.Receiver,
+		Amount:          decimal.NewFromFloat(scheduled.Value),
+		Status:          scheduled.Status,
+		ExecuteAt:       scheduled.GetExecuteAt().AsTime(),
+		TxID:            scheduled.Txid,
+		ReceiverAccount: scheduled.ReceiverAccount,
+	}
 }
 
 func ProtoToPix(pix *pb.PixTransaction) *Pix {
@@ -23,17 +397,65 @@ func ProtoToPix(pix *pb.PixTransaction) *Pix {
 		return nil
 	}
 
+	var executeAt time.Time
+	if pix.ExecuteAt != nil {
+		executeAt = pix.ExecuteAt.AsTime()
+	}
+
 	return &Pix{
 		AccountID: pix.AccountId,
 
-		Receiver: pix.ReceiverKey,
-		Amount:   amount,
-		Status:   pix.Status,
+		Receiver:       pix.ReceiverKey,
+		Amount:         amount,
+		Status:         pix.Status,
+		IdempotencyKey: pix.IdempotencyKey,
+		ExecuteAt:      executeAt,
+		CopyPaste:      pix.CopyPaste,
+		TxID:           pix.Txid,
+		LookupToken:    pix.LookupToken,
+	}
+}
+
+func ToProto(pix *Pix) *pb.PixTransaction {
+	var executeAt *timestamppb.Timestamp
+	if pix.IsScheduled() {
+		executeAt = timestamppb.New(pix.ExecuteAt)
+	}
+
+	var createdAt *timestamppb.Timestamp
+	if !pix.CreatedAt.IsZero() {
+		createdAt = timestamppb.New(pix.CreatedAt)
+	}
+
+	return &pb.PixTransaction{
+		Id:                    pix.ID,
+		ReceiverKey:           pix.Receiver,
+		Amount:                pix.Amount.InexactFloat64(),
+		Hour:                  createdAt,
+		Status:                pix.Status,
+		AccountId:             pix.AccountID,
+		IdempotencyKey:        pix.IdempotencyKey,
+		OriginalTransactionId: pix.OriginalTransactionID,
+		ExecuteAt:             executeAt,
+		Txid:                  pix.TxID,
+		ReceiverAccount:       pix.ReceiverAccount,
+		RefundedAmount:        pix.RefundedAmount.InexactFloat64(),
+		Type:                  pix.Type,
 	}
 }
 
+func ToProtoList(pixes []*Pix) *pb.ListPixTransactions {
+	list := make([]*pb.PixTransaction, len(pixes))
+	for i := range pixes {
+		list[i] = ToProto(pixes[i])
+	}
+	return &pb.ListPixTransactions{Transactions: list}
+}
+
 type PixEvent struct {
-	Account struct {
+	ID             string `json:"id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	Account        struct {
 		Name   int64  `json:"name"`
 		Cpf    string `json:"cpf"`
 		Agency string `json:"agency"`
@@ -42,13 +464,27 @@ type PixEvent struct {
 	Receiver   string          `json:"receiver"`
 	Amount     decimal.Decimal `json:"amount"`
 	WebhookUrl string          `json:"webhook_url"`
+	TxID       string          `json:"txid,omitempty"`
+	// ReceiverAccount is the account the payer confirmed, the pix fails if
+	// the key resolves to another one
+	ReceiverAccount int64 `json:"receiver_account,omitempty"`
 }
 
+// PixEventsTopic is consumed by the transaction service.
+const PixEventsTopic = "transaction_events_topic"
+
 type Status string
 
 const (
+	StatusPending   Status = "PENDING"
 	StatusCompleted Status = "COMPLETED"
 	StatusFailed    Status = "FAILED"
+	// StatusInReview is a pix parked by the risk analysis until an analyst
+	// approves it, nothing is held or published before that.
+	StatusInReview Status = "IN_REVIEW"
+	// StatusAuthorize is the webhook of a scheduled or recurring pix about to
+	// run, its amount is held like the one of a pix sent now.
+	StatusAuthorize Status = "AUTHORIZE"
 )
 
 func ProtoToWebhook(webhook *pb.Webhook) *Webhook {
@@ -64,16 +500,25 @@ func ProtoToWebhook(webhook *pb.Webhook) *Webhook {
 			Agency: webhook.Receiver.Agency,
 			Bank:   webhook.Receiver.Bank,
 		},
-		Amount: amount,
-		Status: Status(webhook.Status.String()),
+		Amount:                amount,
+		Status:                Status(webhook.Status.String()),
+		TransactionID:         webhook.TransactionId,
+		OriginalTransactionID: webhook.OriginalTransactionId,
+		ReceiverKey:           webhook.ReceiverKey,
 	}
 }
 
+// Webhook confirms or fails a transaction. OriginalTransactionID is set when
+// the transaction is a refund of that pix, and ReceiverKey when it authorizes
+// a scheduled or recurring pix.
 type Webhook struct {
-	Sender   Account
-	Receiver Account
-	Amount   decimal.Decimal
-	Status   Status
+	TransactionID         string
+	OriginalTransactionID string
+	Sender                Account
+	Receiver              Account
+	Amount                decimal.Decimal
+	Status                Status
+	ReceiverKey           string
 }
 
 type Account struct {
@@ -92,10 +537,41 @@ const (
 )
 
 type Key struct {
+	Id      string
 	Agency  string
 	Bank    string
 	Cpf     string
 	Account int64
 	Name    string
 	Type    Type
+	Version int64
+}
+
+func LookupToProto(found *lookup.Lookup) *pb.KeyLookup {
+	return &pb.KeyLookup{
+		Key:       found.Key,
+		Type:      pb.Type(pb.Type_value[found.Type]),
+		Name:      found.Name,
+		MaskedCpf: found.MaskedCpf,
+		Bank:      found.Bank,
+		Agency:    found.Agency,
+		Token:     found.Token,
+		ExpiresAt: timestamppb.New(found.ExpiresAt),
+	}
+}
+
+func LookupLimitsToProto(limits []*ratelimit.Status) *pb.ListLookupLimits {
+	list := make([]*pb.LookupLimit, len(limits))
+	for i, limit := range limits {
+		list[i] = &pb.LookupLimit{
+			Subject: limit.Subject,
+			Tokens:  limit.Tokens,
+			Misses:  int32(limit.Misses),
+			Locked:  limit.Locked(),
+		}
+		if limit.Locked() {
+			list[i].LockedUntil = timestamppb.New(limit.LockedUntil)
+		}
+	}
+	return &pb.ListLookupLimits{Limits: list}
 }
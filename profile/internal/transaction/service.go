@@ -3,58 +3,430 @@ package transaction
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
 	"profile/internal/account"
 	"profile/internal/cfg"
 	"profile/internal/errutils"
-	"profile/internal/event"
+	"profile/internal/idempotency"
+	"profile/internal/ledger"
+	"profile/internal/limit"
+	"profile/internal/lookup"
+	"profile/internal/outbox"
+	"profile/internal/ratelimit"
+	"profile/internal/risk"
 	"profile/internal/user"
+	"profile/platform/redis"
 	transpb "profile/proto/transactions/v1"
+	"sort"
+	"strconv"
+	"time"
 )
 
 type Service interface {
-	SendPix(ctx context.Context, req *Pix) error
+	SendPix(ctx context.Context, req *Pix) (*Pix, error)
 	PixWebhook(ctx context.Context, req *Webhook) error
-	CreateKey(ctx context.Context, req *Key) error
+	RefundPix(ctx context.Context, req *Refund) (*Pix, error)
+	ListScheduledPix(ctx context.Context, accountID int64) ([]*Pix, error)
+	ListAccountTransactions(ctx context.Context, req *HistoryRequest) (*History, error)
+	CancelScheduledPix(ctx context.Context, id string, accountID int64) (*Pix, error)
+	CreateQRCode(ctx context.Context, req *QRCode) (*QRCode, error)
+	ParseQRCode(ctx context.Context, payload string) (*QRCode, error)
+	CreateCharge(ctx context.Context, req *Charge) (*Charge, error)
+	FindCharge(ctx context.Context, txID string, accountID int64) (*Charge, error)
+	ListCharges(ctx context.Context, accountID int64) ([]*Charge, error)
+	CancelCharge(ctx context.Context, txID string, accountID int64) (*Charge, error)
+	CreateKey(ctx context.Context, req *Key) (*Key, error)
+	DeleteKey(ctx context.Context, id string) error
+	LookupKey(ctx context.Context, key string, accountID int64) (*lookup.Lookup, error)
+	LookupLimits(ctx context.Context, userID string, accountID int64) ([]*ratelimit.Status, error)
+	UnlockLookups(ctx context.Context, userID string, accountID int64) error
+	ApproveReview(ctx context.Context, req *risk.ReviewRequest) (*Pix, error)
 }
 
 type service struct {
 	accountRepository account.Repository
 	userRepository    user.Repository
-	events            event.Client
+	ledger            ledger.Service
+	limits            limit.Service
+	risk              risk.Service
+	idempotency       idempotency.Repository
+	lookups           lookup.Repository
+	limiter           ratelimit.Limiter
+	locker            redis.Locker
 	keysBackend       transpb.KeysServiceClient
+	transactions      transpb.TransactionServiceClient
+	schedules         transpb.ScheduleServiceClient
+	brcodes           transpb.BRCodeServiceClient
+	charges           transpb.ChargeServiceClient
 	config            *cfg.Config
 }
 
-func (s service) SendPix(ctx context.Context, req *Pix) error {
+// SendPix publishes the pix once per idempotency key: a replayed key returns
+// the original result and a key reused with a different payload is rejected.
+func (s service) SendPix(ctx context.Context, req *Pix) (*Pix, error) {
+	if req.CopyPaste != "" {
+		if err := s.fromCopyPaste(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if req.LookupToken != "" {
+		if err := s.fromLookup(ctx, req); err != nil {
+			return nil, err
+		}
+	}
 
-	accountModel, err := s.accountRepository.FindAccountById(req.AccountID)
+	if req.IdempotencyKey == "" {
+		return s.sendPix(ctx, req, nil)
+	}
+
+	key := fmt.Sprintf("pix:%d:%s", req.AccountID, req.IdempotencyKey)
+	parts := []string{strconv.FormatInt(req.AccountID, 10), req.Receiver, req.Amount.String()}
+	if req.IsScheduled() {
+		parts = append(parts, req.ExecuteAt.UTC().Format(time.RFC3339))
+	}
+	if req.TxID != "" {
+		parts = append(parts, req.TxID)
+	}
+	fingerprint := idempotency.Fingerprint(parts...)
+
+	record, reserved, err := s.idempotency.Reserve(ctx, key, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reserved {
+		if record.Fingerprint != fingerprint {
+			return nil, errutils.ErrIdempotencyKeyMismatch
+		}
+		if record.Status != idempotency.StatusCompleted {
+			return nil, errutils.ErrIdempotencyKeyInProgress
+		}
+		var replay Pix
+		if err = json.Unmarshal(record.Response, &replay); err != nil {
+			return nil, err
+		}
+		return &replay, nil
+	}
+
+	pix, err := s.sendPix(ctx, req, nil)
+	if err != nil {
+		if releaseErr := s.idempotency.Release(ctx, key); releaseErr != nil {
+			log.Print("failed to release idempotency key:", releaseErr)
+		}
+		return nil, err
+	}
+
+	record.Response, err = json.Marshal(pix)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.idempotency.Complete(ctx, key, record); err != nil {
+		log.Print("failed to store idempotency result:", err)
+	}
+	return pix, nil
+}
+
+// fromLookup takes the receiver key from the lookup the payer confirmed, and
+// binds the pix to the account the key resolved to.
+func (s service) fromLookup(ctx context.Context, req *Pix) error {
+	confirmed, err := s.lookups.Find(ctx, req.LookupToken)
 	if err != nil {
 		return err
 	}
+	if confirmed.PayerAccount != req.AccountID || (req.Receiver != "" && req.Receiver != confirmed.Key) {
+		return errutils.ErrLookupMismatch
+	}
 
-	userModel, err := s.userRepository.FindUserById(accountModel.UserID)
+	req.Receiver = confirmed.Key
+	req.ReceiverAccount = confirmed.ReceiverAccount
+	return nil
+}
+
+// LookupKey resolves a key to the receiver data the payer confirms before
+// sending, with the cpf masked, and stores it under a short lived token.
+// Lookups are rate limited per user and per account, and a key that does not
+// exist costs extra so keys can not be enumerated.
+func (s service) LookupKey(ctx context.Context, key string, accountID int64) (*lookup.Lookup, error) {
+	payer, err := s.accountRepository.FindAccountById(accountID)
+	if err != nil {
+		return nil, err
+	}
+	subjects := ratelimit.Subjects(payer.UserID, accountID)
+	if err = s.limiter.Allow(ctx, subjects...); err != nil {
+		return nil, err
+	}
+
+	found, err := s.keysBackend.FindKey(ctx, &transpb.KeyRequest{KeyId: key})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			if penaltyErr := s.limiter.Penalize(ctx, subjects...); penaltyErr != nil {
+				log.Printf("failed to penalize lookup of account %d: %v", accountID, penaltyErr)
+			}
+			return nil, errutils.ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	receiverAccount, err := s.accountRepository.FindAccountById(found.GetAccount().GetName())
+	if err != nil {
+		return nil, err
+	}
+	receiver, err := s.userRepository.FindUserById(receiverAccount.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &lookup.Lookup{
+		Token:           uuid.New().String(),
+		PayerAccount:    accountID,
+		Key:             found.Name,
+		Type:            found.Type.String(),
+		ReceiverAccount: found.GetAccount().GetName(),
+		Name:            receiver.Name,
+		MaskedCpf:       lookup.MaskCpf(found.GetAccount().GetCpf()),
+		Bank:            found.GetAccount().GetBank(),
+		Agency:          found.GetAccount().GetAgency(),
+	}
+	if err = s.lookups.Save(ctx, resolved); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// LookupLimits shows support staff the lookup limits of a user, an account or
+// both.
+func (s service) LookupLimits(ctx context.Context, userID string, accountID int64) ([]*ratelimit.Status, error) {
+	limits := make([]*ratelimit.Status, 0, 2)
+	for _, subject := range limitSubjects(userID, accountID) {
+		limit, err := s.limiter.Status(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
+
+func (s service) UnlockLookups(ctx context.Context, userID string, accountID int64) error {
+	for _, subject := range limitSubjects(userID, accountID) {
+		if err := s.limiter.Unlock(ctx, subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func limitSubjects(userID string, accountID int64) []string {
+	var subjects []string
+	if userID != "" {
+		subjects = append(subjects, ratelimit.UserSubject(userID))
+	}
+	if accountID != 0 {
+		subjects = append(subjects, ratelimit.AccountSubject(accountID))
+	}
+	return subjects
+}
+
+// fromCopyPaste takes the receiver key and txid, and the amount when the code
+// has one, from a "copia e cola" code. A code with a txid and no amount pays
+// the amount due of its charge, which changes with the day it is paid.
+func (s service) fromCopyPaste(ctx context.Context, req *Pix) error {
+	code, err := s.ParseQRCode(ctx, req.CopyPaste)
 	if err != nil {
 		return err
 	}
 
+	req.Receiver = code.Key
+	if code.TxID != "" {
+		req.TxID = code.TxID
+		if code.Amount.IsZero() {
+			if code.Amount, err = s.amountDue(ctx, req); err != nil {
+				return err
+			}
+		}
+	}
+	if code.Amount.IsZero() {
+		if !req.Amount.IsPositive() {
+			return errutils.ErrInvalidAmount
+		}
+		return nil
+	}
+	if !req.Amount.IsZero() && !req.Amount.Equal(code.Amount) {
+		return errutils.ErrAmountMismatch
+	}
+	req.Amount = code.Amount
+	return nil
+}
+
+// amountDue asks the charge of the pix what the payer owes on the day the pix
+// is sent.
+func (s service) amountDue(ctx context.Context, req *Pix) (decimal.Decimal, error) {
+	accountModel, err := s.accountRepository.FindAccountById(req.AccountID)
+	if err != nil {
+		return decimal.Zero, err
+	}
 	if accountModel == nil {
-		return errutils.ErrInactiveAccount
+		return decimal.Zero, errutils.ErrInactiveAccount
+	}
+	userModel, err := s.userRepository.FindUserById(accountModel.UserID)
+	if err != nil {
+		return decimal.Zero, err
 	}
 
-	if accountModel.Balance.LessThan(req.Amount) {
-		return errutils.ErrInsufficientBalance
+	payAt := time.Now()
+	if req.IsScheduled() {
+		payAt = req.ExecuteAt
 	}
+	resolved, err := s.charges.ResolveCharge(ctx, &transpb.ResolveChargeRequest{
+		Txid:     req.TxID,
+		PayerCpf: userModel.Cpf,
+		PayAt:    timestamppb.New(payAt),
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromFloat(resolved.AmountDue), nil
+}
 
+func (s service) CreateQRCode(ctx context.Context, req *QRCode) (*QRCode, error) {
+	code, err := s.brcodes.CreateBRCode(ctx, &transpb.BRCode{
+		Key:          req.Key,
+		Amount:       req.Amount.InexactFloat64(),
+		Txid:         req.TxID,
+		MerchantName: req.MerchantName,
+		MerchantCity: req.MerchantCity,
+		Description:  req.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return BRCodeToQRCode(code), nil
+}
+
+func (s service) ParseQRCode(ctx context.Context, payload string) (*QRCode, error) {
+	code, err := s.brcodes.ParseBRCode(ctx, &transpb.BRCodeRequest{Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	return BRCodeToQRCode(code), nil
+}
+
+func (s service) CreateCharge(ctx context.Context, req *Charge) (*Charge, error) {
+	accountModel, err := s.accountRepository.FindAccountById(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
 	if accountModel.BlockedAt != nil {
-		return errutils.ErrReceiverAccountBlocked
+		return nil, errutils.ErrReceiverAccountBlocked
+	}
+
+	req.AccountID = accountModel.Id
+	created, err := s.charges.CreateCharge(ctx, ChargeToBackend(req))
+	if err != nil {
+		return nil, err
+	}
+	return BackendToCharge(created), nil
+}
+
+func (s service) FindCharge(ctx context.Context, txID string, accountID int64) (*Charge, error) {
+	found, err := s.charges.FindCharge(ctx, &transpb.ChargeRequest{Txid: txID, AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+	return BackendToCharge(found), nil
+}
+
+func (s service) ListCharges(ctx context.Context, accountID int64) ([]*Charge, error) {
+	list, err := s.charges.ListCharge(ctx, &transpb.ListChargesRequest{AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	charges := make([]*Charge, len(list.Charges))
+	for i := range list.Charges {
+		charges[i] = BackendToCharge(list.Charges[i])
+	}
+	return charges, nil
+}
+
+func (s service) CancelCharge(ctx context.Context, txID string, accountID int64) (*Charge, error) {
+	canceled, err := s.charges.CancelCharge(ctx, &transpb.ChargeRequest{Txid: txID, AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+	return BackendToCharge(canceled), nil
+}
+
+// sendPix holds the amount and publishes the pix, once the risk analysis
+// allows it. review is the approved assessment of a pix that was parked, the
+// pix is sent under the id it was parked with and not assessed again.
+func (s service) sendPix(ctx context.Context, req *Pix, review *risk.Assessment) (*Pix, error) {
+	books, unlock, err := s.lockAccounts(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	accountModel, err := s.accountRepository.FindAccountById(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if accountModel == nil {
+		return nil, errutils.ErrInactiveAccount
+	}
+
+	userModel, err := s.userRepository.FindUserById(accountModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.limits.Policy(ctx, accountModel.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsScheduled() {
+		// an amount over the limits on its own is refused now, the balance and
+		// the limits with what was sent by then are checked when the pix runs
+		if err = policy.Check(req.Amount, limit.Usage{}, policy.Windows(req.ExecuteAt).Night != nil); err != nil {
+			return nil, err
+		}
+		return s.schedulePix(ctx, req, accountModel, userModel, review)
+	}
+
+	if accountModel.Available().LessThan(req.Amount) {
+		return nil, errutils.ErrInsufficientBalance
+	}
+
+	if accountModel.BlockedAt != nil {
+		return nil, errutils.ErrReceiverAccountBlocked
 	}
 
+	assessment, err := s.screen(ctx, req, userModel, review)
+	if err != nil {
+		return nil, err
+	}
+	if assessment != nil && assessment.Decision == risk.DecisionReview {
+		req.Status = string(StatusInReview)
+		return req, nil
+	}
+	req.Status = string(StatusPending)
+
 	pixEvent := PixEvent{
-		Receiver:   req.Receiver,
-		Amount:     req.Amount,
-		WebhookUrl: s.config.WebhookConfig.Url,
+		ID:              req.ID,
+		IdempotencyKey:  req.IdempotencyKey,
+		Receiver:        req.Receiver,
+		Amount:          req.Amount,
+		WebhookUrl:      s.config.WebhookConfig.Url,
+		TxID:            req.TxID,
+		ReceiverAccount: req.ReceiverAccount,
 	}
 	pixEvent.Account.Name = accountModel.Id
 	pixEvent.Account.Cpf = userModel.Cpf
@@ -62,76 +434,403 @@ func (s service) SendPix(ctx context.Context, req *Pix) error {
 	pixEvent.Account.Bank = accountModel.Bank
 	payload, err := json.Marshal(pixEvent)
 	if err != nil {
-		return status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	if err = s.events.Publish(ctx, payload); err != nil {
-		return status.Error(codes.Internal, err.Error())
+	// the event is committed with the hold and published by the outbox relay,
+	// the hold is only taken if the amount fits in the limits
+	message := outbox.NewMessage(accountModel.Id, PixEventsTopic, payload)
+	if _, err = books.Hold(ctx, accountModel.Id, req.ID, req.Amount, policy, message); err != nil {
+		// an approved review is failed by ApproveReview
+		if assessment != nil {
+			s.failAssessment(ctx, assessment, err)
+		}
+		return nil, err
 	}
 
-	return nil
+	return req, nil
 }
 
-func (s service) PixWebhook(ctx context.Context, req *Webhook) error {
+// failAssessment keeps a pix that never left the account out of the history
+// the rules look at.
+func (s service) failAssessment(ctx context.Context, assessment *risk.Assessment, cause error) {
+	if err := s.risk.Fail(ctx, assessment, cause.Error()); err != nil {
+		log.Print("failed to mark assessment as failed:", err)
+	}
+}
 
-	sender, err := s.accountRepository.FindAccountById(req.Sender.Name)
+// screen gives the pix a new id and assesses it, a denied pix is refused. A pix
+// sent by an approved review keeps the id it was parked with and is not
+// assessed again.
+func (s service) screen(ctx context.Context, req *Pix, userModel *user.User, review *risk.Assessment) (*risk.Assessment, error) {
+	if review != nil {
+		req.ID = review.TransactionID
+		return nil, nil
+	}
+
+	req.ID = uuid.New().String()
+	assessment, err := s.assess(ctx, req, userModel)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	if assessment.Decision == risk.DecisionDeny {
+		return nil, errutils.ErrPixDenied
+	}
+	return assessment, nil
+}
 
-	receiver, err := s.accountRepository.FindAccountById(req.Receiver.Name)
+// assess stores the pix with the assessment, so it can be sent as it was
+// requested if it goes to review and an analyst approves it.
+func (s service) assess(ctx context.Context, req *Pix, userModel *user.User) (*risk.Assessment, error) {
+	parked, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.risk.Assess(ctx, &risk.Input{
+		TransactionID:    req.ID,
+		AccountID:        req.AccountID,
+		Receiver:         req.Receiver,
+		Amount:           req.Amount,
+		ContactChangedAt: userModel.ContactChangedAt(),
+		Pix:              parked,
+	})
+}
+
+// ApproveReview sends a pix the risk analysis parked. Balance, block and
+// limits are checked again as the pix goes out now, and if it cannot the
+// review is marked FAILED with the reason.
+func (s service) ApproveReview(ctx context.Context, req *risk.ReviewRequest) (*Pix, error) {
+	review, err := s.risk.Approve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var parked Pix
+	if err = json.Unmarshal([]byte(review.Pix), &parked); err != nil {
+		return nil, err
+	}
+	// a scheduled pix whose date passed while it waited is sent now
+	if parked.IsScheduled() && !parked.ExecuteAt.After(time.Now()) {
+		parked.ExecuteAt = time.Time{}
+	}
+
+	pix, err := s.sendPix(ctx, &parked, review)
+	if err != nil {
+		s.failAssessment(ctx, review, err)
+		return nil, err
+	}
+	return pix, nil
+}
+
+// schedulePix assesses the pix as it is scheduled, it is assessed again by
+// authorize as it runs.
+func (s service) schedulePix(ctx context.Context, req *Pix, accountModel *account.Account, userModel *user.User, review *risk.Assessment) (*Pix, error) {
+	if accountModel.BlockedAt != nil {
+		return nil, errutils.ErrReceiverAccountBlocked
+	}
+
+	assessment, err := s.screen(ctx, req, userModel, review)
+	if err != nil {
+		return nil, err
+	}
+	if assessment != nil && assessment.Decision == risk.DecisionReview {
+		req.Status = string(StatusInReview)
+		return req, nil
+	}
+
+	scheduled, err := s.schedules.SchedulePix(ctx, &transpb.ScheduledPix{
+		Id: req.ID,
+		Account: &transpb.Account{
+			Name:   accountModel.Id,
+			Cpf:    userModel.Cpf,
+			Agency: accountModel.Agency,
+			Bank:   accountModel.Bank,
+		},
+		Receiver:        req.Receiver,
+		Value:           req.Amount.InexactFloat64(),
+		ExecuteAt:       timestamppb.New(req.ExecuteAt),
+		IdempotencyKey:  req.IdempotencyKey,
+		WebhookUrl:      s.config.WebhookConfig.Url,
+		Txid:            req.TxID,
+		ReceiverAccount: req.ReceiverAccount,
+	})
+	if err != nil {
+		if assessment != nil {
+			s.failAssessment(ctx, assessment, err)
+		}
+		return nil, err
+	}
+
+	req.ID = scheduled.Id
+	req.Status = scheduled.Status
+	req.ExecuteAt = scheduled.GetExecuteAt().AsTime()
+	return req, nil
+}
+
+func (s service) ListAccountTransactions(ctx context.Context, req *HistoryRequest) (*History, error) {
+	page, err := s.transactions.ListAccountTransactions(ctx, HistoryRequestToBackend(req))
+	if err != nil {
+		return nil, err
+	}
+
+	history := &History{Transactions: make([]*Pix, len(page.Transactions)), NextCursor: page.NextCursor}
+	for i := range page.Transactions {
+		history.Transactions[i] = TransactionToPix(page.Transactions[i])
+	}
+	return history, nil
+}
+
+func (s service) ListScheduledPix(ctx context.Context, accountID int64) ([]*Pix, error) {
+	list, err := s.schedules.ListScheduled(ctx, &transpb.ListScheduledPixRequest{AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	pixes := make([]*Pix, len(list.Scheduled))
+	for i := range list.Scheduled {
+		pixes[i] = ScheduledToPix(list.Scheduled[i])
+	}
+	return pixes, nil
+}
+
+func (s service) CancelScheduledPix(ctx context.Context, id string, accountID int64) (*Pix, error) {
+	canceled, err := s.schedules.CancelScheduled(ctx, &transpb.CancelScheduledPixRequest{Id: id, AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+	return ScheduledToPix(canceled), nil
+}
+
+func (s service) PixWebhook(ctx context.Context, req *Webhook) error {
+	books, unlock, err := s.lockAccounts(ctx, req.Sender.Name, req.Receiver.Name)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
 	switch req.Status {
+	case StatusAuthorize:
+		return s.authorize(ctx, req, books)
 	case StatusFailed:
-		return nil
+		return books.Release(ctx, req.TransactionID)
 	case StatusCompleted:
-		sender.Balance = sender.Balance.Sub(req.Amount)
-		receiver.Balance = receiver.Balance.Add(req.Amount)
-		err = s.accountRepository.UpdateAccounts([]*account.Account{sender, receiver})
+		sender, err := s.accountRepository.FindAccountById(req.Sender.Name)
 		if err != nil {
 			return err
 		}
+
+		receiver, err := s.accountRepository.FindAccountById(req.Receiver.Name)
+		if err != nil {
+			return err
+		}
+
+		if req.OriginalTransactionID != "" {
+			_, err = books.PostRefund(ctx, req.TransactionID, req.OriginalTransactionID, sender.Id, receiver.Id, req.Amount)
+		} else {
+			_, err = books.PostPix(ctx, req.TransactionID, sender.Id, receiver.Id, req.Amount)
+		}
+		if err != nil && !errors.Is(err, errutils.ErrEntryAlreadyPosted) {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s service) CreateKey(ctx context.Context, req *Key) error {
-	accountModel, err := s.accountRepository.FindAccountById(req.Account)
+// authorize holds the amount of a scheduled or recurring pix as it runs,
+// after the same block, risk and limit checks as a pix sent now. Authorizing
+// again keeps the hold, so a retried run is held once. The hold goes through
+// books, the ledger fenced by the locks of the webhook.
+func (s service) authorize(ctx context.Context, req *Webhook, books ledger.Service) error {
+	accountModel, err := s.accountRepository.FindAccountById(req.Sender.Name)
 	if err != nil {
 		return err
 	}
+	if accountModel.BlockedAt != nil {
+		return errutils.ErrReceiverAccountBlocked
+	}
 
-	userModel, err := s.userRepository.FindUserById(accountModel.UserID)
+	assessment, err := s.assessRun(ctx, req, accountModel)
+	if err != nil {
+		return err
+	}
+
+	policy, err := s.limits.Policy(ctx, accountModel.Id)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.keysBackend.CreateKey(ctx, &transpb.Key{
+	_, err = books.Hold(ctx, accountModel.Id, req.TransactionID, req.Amount, policy)
+	if errors.Is(err, errutils.ErrEntryAlreadyPosted) {
+		// the run that is retried had already settled
+		return nil
+	}
+	if err != nil && assessment != nil {
+		s.failAssessment(ctx, assessment, err)
+	}
+	return err
+}
+
+// assessRun assesses a scheduled or recurring pix under the id of the run. A
+// run sent to review fails until an analyst approves it, the approval then
+// sends the pix under that id. An approved run is not assessed again.
+func (s service) assessRun(ctx context.Context, req *Webhook, accountModel *account.Account) (*risk.Assessment, error) {
+	previous, err := s.risk.FindByTransaction(ctx, req.TransactionID)
+	if err != nil && !errors.Is(err, errutils.ErrAssessmentNotFound) {
+		return nil, err
+	}
+	if previous != nil {
+		switch previous.ReviewStatus {
+		case risk.ReviewApproved:
+			return nil, nil
+		case risk.ReviewPending:
+			return nil, errutils.ErrPixInReview
+		case risk.ReviewRejected:
+			return nil, errutils.ErrPixDenied
+		}
+	}
+
+	userModel, err := s.userRepository.FindUserById(accountModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	assessment, err := s.assess(ctx, &Pix{
+		ID:              req.TransactionID,
+		AccountID:       accountModel.Id,
+		Receiver:        req.ReceiverKey,
+		ReceiverAccount: req.Receiver.Name,
+		Amount:          req.Amount,
+	}, userModel)
+	if err != nil {
+		return nil, err
+	}
+	switch assessment.Decision {
+	case risk.DecisionDeny:
+		return nil, errutils.ErrPixDenied
+	case risk.DecisionReview:
+		return nil, errutils.ErrPixInReview
+	}
+	return assessment, nil
+}
+
+// RefundPix asks the transaction service to return a received pix. The
+// balances move when its webhook for the refund arrives.
+func (s service) RefundPix(ctx context.Context, req *Refund) (*Pix, error) {
+	accountModel, err := s.accountRepository.FindAccountById(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if accountModel.BlockedAt != nil {
+		return nil, errutils.ErrReceiverAccountBlocked
+	}
+
+	refund, err := s.transactions.RefundTransaction(ctx, &transpb.RefundRequest{
+		TransactionId: req.TransactionID,
+		AccountId:     accountModel.Id,
+		Value:         req.Amount.InexactFloat64(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pix := TransactionToPix(refund)
+	pix.AccountID = accountModel.Id
+	return pix, nil
+}
+
+// lockAccounts serializes balance changes per account. Locks are taken in id
+// order so two operations over the same pair of accounts cannot deadlock. The
+// returned ledger is fenced with the tokens of the locks, so its writes fail
+// if a lock expired and another holder wrote the account in the meantime.
+func (s service) lockAccounts(ctx context.Context, accountIDs ...int64) (ledger.Service, func(), error) {
+	ids := make([]int64, 0, len(accountIDs))
+	seen := make(map[int64]bool)
+	for _, id := range accountIDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	mutexes := make([]redis.Mutex, 0, len(ids))
+	unlock := func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			if err := mutexes[i].Unlock(context.Background()); err != nil {
+				log.Print("failed to unlock account:", err)
+			}
+		}
+	}
+
+	fences := make(ledger.Fences, len(ids))
+	for _, id := range ids {
+		mutex := s.locker.NewMutex(fmt.Sprintf("account:%d", id))
+		if err := mutex.Lock(ctx); err != nil {
+			unlock()
+			return nil, nil, err
+		}
+		mutexes = append(mutexes, mutex)
+		fences[id] = mutex.Token()
+	}
+	return s.ledger.Fenced(fences), unlock, nil
+}
+
+// CreateKey registers the key in the transaction service, which generates the
+// value of random keys, and returns it with the value that was registered.
+func (s service) CreateKey(ctx context.Context, req *Key) (*Key, error) {
+	accountModel, err := s.accountRepository.FindAccountById(req.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	userModel, err := s.userRepository.FindUserById(accountModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.keysBackend.CreateKey(ctx, &transpb.Key{
 		Account: &transpb.Account{
 			Cpf:    userModel.Cpf,
 			Name:   accountModel.Id,
 			Agency: accountModel.Agency,
 			Bank:   accountModel.Bank,
 		},
-		Name: req.Name,
-		Type: transpb.Type(transpb.Type_value[string(req.Type)]),
+		Name:    req.Name,
+		Type:    transpb.Type(transpb.Type_value[string(req.Type)]),
+		Id:      req.Id,
+		Version: req.Version,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	req.Name = created.Name
+	return req, nil
+}
+
+func (s service) DeleteKey(ctx context.Context, id string) error {
+	_, err := s.keysBackend.DeleteKey(ctx, &transpb.KeyRequest{KeyId: id})
+	return err
 }
 
-func NewService(config *cfg.Config, event event.Client, accountRepository account.Repository, keysBackend transpb.KeysServiceClient, userRepository user.Repository) Service {
+func NewService(config *cfg.Config, accountRepository account.Repository, keysBackend transpb.KeysServiceClient, transactionsBackend transpb.TransactionServiceClient, schedulesBackend transpb.ScheduleServiceClient, brcodesBackend transpb.BRCodeServiceClient, chargesBackend transpb.ChargeServiceClient, userRepository user.Repository, ledgerService ledger.Service, idempotencyRepository idempotency.Repository, lookupRepository lookup.Repository, limiter ratelimit.Limiter, locker redis.Locker, limitService limit.Service, riskService risk.Service) Service {
 	return &service{
 		config:            config,
-		events:            event,
 		accountRepository: accountRepository,
 		keysBackend:       keysBackend,
+		transactions:      transactionsBackend,
+		schedules:         schedulesBackend,
+		brcodes:           brcodesBackend,
+		charges:           chargesBackend,
 		userRepository:    userRepository,
+		ledger:            ledgerService,
+		idempotency:       idempotencyRepository,
+		lookups:           lookupRepository,
+		limiter:           limiter,
+		locker:            locker,
+		limits:            limitService,
+		risk:              riskService,
 	}
 }
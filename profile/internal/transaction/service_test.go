@@ -2,7 +2,29 @@ package transaction
 
 import (
 	"context"
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"profile/internal/account"
+	"profile/internal/cfg"
+	"profile/internal/errutils"
+	"profile/internal/idempotency"
+	"profile/internal/ledger"
+	"profile/internal/limit"
+	"profile/internal/lookup"
+	"profile/internal/outbox"
+	"profile/internal/ratelimit"
+	"profile/internal/risk"
+	"profile/internal/user"
+	"profile/platform/redis"
+	transpb "profile/proto/transactions/v1"
+	"testing"
+	"time"
 )
 
 type mockEventClient struct {
@@ -12,7 +34,68 @@ type MockAccountRepository struct {
 	mock.Mock
 }
 
-func (m *mockEventClient) Publish(ctx context.Context, payload []byte) error {
+type mockIdempotencyRepo struct {
+	idempotency.Repository
+	mock.Mock
+}
+
+func (m *mockIdempotencyRepo) Reserve(ctx context.Context, key string, fingerprint string) (*idempotency.Record, bool, error) {
+	args := m.Called(key, fingerprint)
+	return args.Get(0).(*idempotency.Record), args.Bool(1), args.Error(2)
+}
+
+type mockLedgerService struct {
+	ledger.Service
+	mock.Mock
+	fences ledger.Fences
+}
+
+func (m *mockLedgerService) Fenced(fences ledger.Fences) ledger.Service {
+	m.fences = fences
+	return m
+}
+
+func (m *mockLedgerService) Release(ctx context.Context, transactionID string) error {
+	args := m.Called(transactionID)
+	return args.Error(0)
+}
+
+type mockLocker struct {
+	events []string
+	tokens int64
+	err    error
+}
+
+func (m *mockLocker) NewMutex(key string) redis.Mutex {
+	return &mockMutex{locker: m, key: key}
+}
+
+type mockMutex struct {
+	locker *mockLocker
+	key    string
+	token  int64
+}
+
+func (m *mockMutex) Lock(ctx context.Context) error {
+	if m.locker.err != nil {
+		return m.locker.err
+	}
+	m.locker.events = append(m.locker.events, "lock "+m.key)
+	m.locker.tokens++
+	m.token = m.locker.tokens
+	return nil
+}
+
+func (m *mockMutex) Token() int64 {
+	return m.token
+}
+
+func (m *mockMutex) Unlock(ctx context.Context) error {
+	m.locker.events = append(m.locker.events, "unlock "+m.key)
+	return nil
+}
+
+func (m *mockEventClient) Publish(ctx context.Context, key, payload []byte) error {
 	args := m.Called(ctx, payload)
 	return args.Error(0)
 }
@@ -95,3 +178,687 @@ func (m *mockEventClient) SendPix(ctx context.Context, req *Pix) error {
 //		})
 //	}
 //}
+
+func TestSendPixIdempotencyReplay(t *testing.T) {
+	req := &Pix{AccountID: 1, Receiver: "receiver-1", Amount: decimal.NewFromInt(10), IdempotencyKey: "key-1"}
+	fingerprint := idempotency.Fingerprint("1", "receiver-1", "10")
+	original, _ := json.Marshal(&Pix{ID: "pix-1", AccountID: 1, Receiver: "receiver-1", Amount: decimal.NewFromInt(10), Status: "PENDING"})
+
+	cases := []struct {
+		name     string
+		mockFunc func(repo *mockIdempotencyRepo)
+		want     *Pix
+		err      error
+	}{
+		{
+			name: "completed key returns the original result",
+			mockFunc: func(repo *mockIdempotencyRepo) {
+				repo.On("Reserve", "pix:1:key-1", fingerprint).
+					Return(&idempotency.Record{Fingerprint: fingerprint, Status: idempotency.StatusCompleted, Response: original}, false, nil)
+			},
+			want: &Pix{ID: "pix-1", AccountID: 1, Receiver: "receiver-1", Amount: decimal.NewFromInt(10), Status: "PENDING"},
+		},
+		{
+			name: "same key with different payload is rejected",
+			mockFunc: func(repo *mockIdempotencyRepo) {
+				repo.On("Reserve", "pix:1:key-1", fingerprint).
+					Return(&idempotency.Record{Fingerprint: "other", Status: idempotency.StatusCompleted}, false, nil)
+			},
+			err: errutils.ErrIdempotencyKeyMismatch,
+		},
+		{
+			name: "key still in progress",
+			mockFunc: func(repo *mockIdempotencyRepo) {
+				repo.On("Reserve", "pix:1:key-1", fingerprint).
+					Return(&idempotency.Record{Fingerprint: fingerprint, Status: idempotency.StatusInProgress}, false, nil)
+			},
+			err: errutils.ErrIdempotencyKeyInProgress,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockIdempotencyRepo)
+			c.mockFunc(repo)
+			s := NewService(nil, nil, nil, nil, nil, nil, nil, nil, nil, repo, nil, nil, nil, nil, nil)
+			got, err := s.SendPix(context.Background(), req)
+			assert.Equal(t, c.err, err)
+			if c.want != nil {
+				assert.True(t, c.want.Amount.Equal(got.Amount))
+				got.Amount = c.want.Amount
+			}
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+type mockUserRepo struct {
+	user.Repository
+	mock.Mock
+}
+
+func (m *mockUserRepo) FindUserById(id string) (*user.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+type mockRiskService struct {
+	risk.Service
+	mock.Mock
+}
+
+func (m *mockRiskService) Assess(ctx context.Context, input *risk.Input) (*risk.Assessment, error) {
+	args := m.Called(input.Receiver, input.Amount.String())
+	return args.Get(0).(*risk.Assessment), args.Error(1)
+}
+
+func (m *mockRiskService) Fail(ctx context.Context, assessment *risk.Assessment, reason string) error {
+	args := m.Called(assessment.Id, reason)
+	return args.Error(0)
+}
+
+func (m *mockRiskService) FindByTransaction(ctx context.Context, transactionID string) (*risk.Assessment, error) {
+	args := m.Called(transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*risk.Assessment), args.Error(1)
+}
+
+type mockScheduleClient struct {
+	transpb.ScheduleServiceClient
+	mock.Mock
+}
+
+func (m *mockScheduleClient) SchedulePix(ctx context.Context, in *transpb.ScheduledPix, opts ...grpc.CallOption) (*transpb.ScheduledPix, error) {
+	args := m.Called(in.Receiver, in.Value)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*transpb.ScheduledPix), args.Error(1)
+}
+
+func TestSendPix(t *testing.T) {
+	thousand := decimal.NewFromInt(1000)
+	policy := &limit.Policy{Transaction: thousand, Daily: thousand, Monthly: thousand, Nightly: thousand,
+		NightStart: 20, NightEnd: 6, Location: time.UTC}
+	funded := &account.Account{Id: 7, UserID: "user-1", Balance: decimal.NewFromInt(100)}
+	allowed := &risk.Assessment{Id: "risk-1", Decision: risk.DecisionAllow}
+
+	inReview := &risk.Assessment{Id: "risk-1", Decision: risk.DecisionReview, ReviewStatus: risk.ReviewPending}
+	denied := &risk.Assessment{Id: "risk-1", Decision: risk.DecisionDeny}
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	cases := []struct {
+		name      string
+		account   *account.Account
+		executeAt time.Time
+		mockFunc  func(users *mockUserRepo, limits *mockLimitService, riskService *mockRiskService, ledgerService *mockLedgerService, schedules *mockScheduleClient)
+		want      Status
+		err       error
+	}{
+		{
+			name:    "allowed pix is held",
+			account: funded,
+			mockFunc: func(users *mockUserRepo, limits *mockLimitService, riskService *mockRiskService, ledgerService *mockLedgerService, _ *mockScheduleClient) {
+				users.On("FindUserById", "user-1").Return(&user.User{Id: "user-1"}, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(allowed, nil)
+				ledgerService.On("Hold", int64(7), mock.Anything, "50", policy).Return(nil)
+			},
+			want: StatusPending,
+		},
+		{
+			name:    "allowed pix that is not held is marked failed",
+			account: funded,
+			mockFunc: func(users *mockUserRepo, limits *mockLimitService, riskService *mockRiskService, ledgerService *mockLedgerService, _ *mockScheduleClient) {
+				users.On("FindUserById", "user-1").Return(&user.User{Id: "user-1"}, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(allowed, nil)
+				ledgerService.On("Hold", int64(7), mock.Anything, "50", policy).Return(errutils.ErrDailyLimitExceeded)
+				riskService.On("Fail", "risk-1", errutils.ErrDailyLimitExceeded.Error()).Return(nil)
+			},
+			err: errutils.ErrDailyLimitExceeded,
+		},
+		{
+			name:      "allowed scheduled pix is scheduled",
+			account:   funded,
+			executeAt: tomorrow,
+			mockFunc: func(users *mockUserRepo, limits *mockLimitService, riskService *mockRiskService, _ *mockLedgerService, schedules *mockScheduleClient) {
+				users.On("FindUserById", "user-1").Return(&user.User{Id: "user-1"}, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(allowed, nil)
+				schedules.On("SchedulePix", "fulano@pix.com", float64(50)).
+					Return(&transpb.ScheduledPix{Id: "pix-1", Status: "SCHEDULED", ExecuteAt: timestamppb.New(tomorrow)}, nil)
+			},
+			want: "SCHEDULED",
+		},
+		{
+			name:      "scheduled pix sent to review is parked",
+			account:   funded,
+			executeAt: tomorrow,
+			mockFunc: func(users *mockUserRepo, limits *mockLimitService, riskService *mockRiskService, _ *mockLedgerService, _ *mockScheduleClient) {
+				users.On("FindUserById", "user-1").Return(&user.User{Id: "user-1"}, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(inReview, nil)
+			},
+			want: StatusInReview,
+		},
+		{
+			name:      "failed because the scheduled pix is denied",
+			account:   funded,
+			executeAt: tomorrow,
+			mockFunc: func(users *mockUserRepo, limits *mockLimitService, riskService *mockRiskService, _ *mockLedgerService, _ *mockScheduleClient) {
+				users.On("FindUserById", "user-1").Return(&user.User{Id: "user-1"}, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(denied, nil)
+			},
+			err: errutils.ErrPixDenied,
+		},
+		{
+			name:     "failed because the account does not exist",
+			account:  nil,
+			mockFunc: func(*mockUserRepo, *mockLimitService, *mockRiskService, *mockLedgerService, *mockScheduleClient) {},
+			err:      errutils.ErrInactiveAccount,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			accounts, users, limits := new(mockAccountRepo), new(mockUserRepo), new(mockLimitService)
+			riskService, ledgerService, schedules := new(mockRiskService), new(mockLedgerService), new(mockScheduleClient)
+			accounts.On("FindAccountById", int64(7)).Return(c.account, nil)
+			c.mockFunc(users, limits, riskService, ledgerService, schedules)
+
+			s := NewService(&cfg.Config{}, accounts, nil, nil, schedules, nil, nil, users, ledgerService, nil, nil, nil, &mockLocker{}, limits, riskService)
+			got, err := s.SendPix(context.Background(), &Pix{AccountID: 7, Receiver: "fulano@pix.com", Amount: decimal.NewFromInt(50), ExecuteAt: c.executeAt})
+			assert.Equal(t, c.err, err)
+			if c.err == nil {
+				assert.Equal(t, string(c.want), got.Status)
+			}
+			riskService.AssertExpectations(t)
+			ledgerService.AssertExpectations(t)
+			schedules.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPixWebhookLocksAccounts(t *testing.T) {
+	cases := []struct {
+		name     string
+		locker   *mockLocker
+		mockFunc func(ledgerService *mockLedgerService)
+		want     []string
+		fences   ledger.Fences
+		err      error
+	}{
+		{
+			name:   "locks both accounts in id order",
+			locker: &mockLocker{},
+			mockFunc: func(ledgerService *mockLedgerService) {
+				ledgerService.On("Release", "tx-1").Return(nil)
+			},
+			want:   []string{"lock account:3", "lock account:7", "unlock account:7", "unlock account:3"},
+			fences: ledger.Fences{3: 1, 7: 2},
+		},
+		{
+			name:   "failed because the lock of an account was lost",
+			locker: &mockLocker{},
+			mockFunc: func(ledgerService *mockLedgerService) {
+				ledgerService.On("Release", "tx-1").Return(errutils.ErrLockLost)
+			},
+			want:   []string{"lock account:3", "lock account:7", "unlock account:7", "unlock account:3"},
+			fences: ledger.Fences{3: 1, 7: 2},
+			err:    errutils.ErrLockLost,
+		},
+		{
+			name:     "failed because lock was not acquired",
+			locker:   &mockLocker{err: context.DeadlineExceeded},
+			mockFunc: func(ledgerService *mockLedgerService) {},
+			want:     nil,
+			err:      context.DeadlineExceeded,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ledgerService := new(mockLedgerService)
+			c.mockFunc(ledgerService)
+			s := NewService(nil, nil, nil, nil, nil, nil, nil, nil, ledgerService, nil, nil, nil, c.locker, nil, nil)
+			err := s.PixWebhook(context.Background(), &Webhook{
+				TransactionID: "tx-1",
+				Sender:        Account{Name: 7},
+				Receiver:      Account{Name: 3},
+				Status:        StatusFailed,
+			})
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.want, c.locker.events)
+			assert.Equal(t, c.fences, ledgerService.fences)
+			ledgerService.AssertExpectations(t)
+		})
+	}
+}
+
+func (m *mockLedgerService) Hold(ctx context.Context, accountID int64, transactionID string, amount decimal.Decimal, policy *limit.Policy, events ...*outbox.Message) (*ledger.Hold, error) {
+	args := m.Called(accountID, transactionID, amount.String(), policy)
+	return &ledger.Hold{}, args.Error(0)
+}
+
+type mockLimitService struct {
+	limit.Service
+	mock.Mock
+}
+
+func (m *mockLimitService) Policy(ctx context.Context, accountID int64) (*limit.Policy, error) {
+	args := m.Called(accountID)
+	return args.Get(0).(*limit.Policy), args.Error(1)
+}
+
+func TestPixWebhookAuthorize(t *testing.T) {
+	policy := &limit.Policy{Transaction: decimal.NewFromInt(1000)}
+	blockedAt := time.Now()
+	owner := &user.User{Id: "user-1"}
+	allowed := &risk.Assessment{Id: "risk-1", TransactionID: "tx-1", Decision: risk.DecisionAllow}
+
+	cases := []struct {
+		name     string
+		account  *account.Account
+		mockFunc func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService)
+		err      error
+	}{
+		{
+			name:    "holds the amount within the limits of the sender",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").Return(nil, errutils.ErrAssessmentNotFound)
+				users.On("FindUserById", "user-1").Return(owner, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(allowed, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				ledgerService.On("Hold", int64(7), "tx-1", "50", policy).Return(nil)
+			},
+		},
+		{
+			name:    "scheduled pix allowed when scheduled is assessed again as it runs",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").Return(allowed, nil)
+				users.On("FindUserById", "user-1").Return(owner, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(allowed, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				ledgerService.On("Hold", int64(7), "tx-1", "50", policy).Return(nil)
+			},
+		},
+		{
+			name:    "run approved by an analyst is not assessed again",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").
+					Return(&risk.Assessment{Id: "risk-1", Decision: risk.DecisionReview, ReviewStatus: risk.ReviewApproved}, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				ledgerService.On("Hold", int64(7), "tx-1", "50", policy).Return(nil)
+			},
+		},
+		{
+			name:    "retried run that already settled is authorized",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").Return(allowed, nil)
+				users.On("FindUserById", "user-1").Return(owner, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(allowed, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				ledgerService.On("Hold", int64(7), "tx-1", "50", policy).Return(errutils.ErrEntryAlreadyPosted)
+			},
+		},
+		{
+			name:    "failed because the daily limit is reached",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").Return(nil, errutils.ErrAssessmentNotFound)
+				users.On("FindUserById", "user-1").Return(owner, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(allowed, nil)
+				limits.On("Policy", int64(7)).Return(policy, nil)
+				ledgerService.On("Hold", int64(7), "tx-1", "50", policy).Return(errutils.ErrDailyLimitExceeded)
+				riskService.On("Fail", "risk-1", errutils.ErrDailyLimitExceeded.Error()).Return(nil)
+			},
+			err: errutils.ErrDailyLimitExceeded,
+		},
+		{
+			name:    "failed because the run is denied",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").Return(nil, errutils.ErrAssessmentNotFound)
+				users.On("FindUserById", "user-1").Return(owner, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").Return(&risk.Assessment{Id: "risk-1", Decision: risk.DecisionDeny}, nil)
+			},
+			err: errutils.ErrPixDenied,
+		},
+		{
+			name:    "failed because the run is sent to review",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").Return(nil, errutils.ErrAssessmentNotFound)
+				users.On("FindUserById", "user-1").Return(owner, nil)
+				riskService.On("Assess", "fulano@pix.com", "50").
+					Return(&risk.Assessment{Id: "risk-1", Decision: risk.DecisionReview, ReviewStatus: risk.ReviewPending}, nil)
+			},
+			err: errutils.ErrPixInReview,
+		},
+		{
+			name:    "failed because the run still waits for its review",
+			account: &account.Account{Id: 7, UserID: "user-1"},
+			mockFunc: func(users *mockUserRepo, riskService *mockRiskService, ledgerService *mockLedgerService, limits *mockLimitService) {
+				riskService.On("FindByTransaction", "tx-1").
+					Return(&risk.Assessment{Id: "risk-1", Decision: risk.DecisionReview, ReviewStatus: risk.ReviewPending}, nil)
+			},
+			err: errutils.ErrPixInReview,
+		},
+		{
+			name:     "failed because the sender account is blocked",
+			account:  &account.Account{Id: 7, BlockedAt: &blockedAt},
+			mockFunc: func(*mockUserRepo, *mockRiskService, *mockLedgerService, *mockLimitService) {},
+			err:      errutils.ErrReceiverAccountBlocked,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			accounts, users, riskService := new(mockAccountRepo), new(mockUserRepo), new(mockRiskService)
+			ledgerService, limits := new(mockLedgerService), new(mockLimitService)
+			accounts.On("FindAccountById", int64(7)).Return(c.account, nil)
+			c.mockFunc(users, riskService, ledgerService, limits)
+
+			s := NewService(nil, accounts, nil, nil, nil, nil, nil, users, ledgerService, nil, nil, nil, &mockLocker{}, limits, riskService)
+			err := s.PixWebhook(context.Background(), &Webhook{
+				TransactionID: "tx-1",
+				Sender:        Account{Name: 7},
+				Receiver:      Account{Name: 3},
+				Amount:        decimal.NewFromInt(50),
+				Status:        StatusAuthorize,
+				ReceiverKey:   "fulano@pix.com",
+			})
+			assert.Equal(t, c.err, err)
+			riskService.AssertExpectations(t)
+			ledgerService.AssertExpectations(t)
+			limits.AssertExpectations(t)
+		})
+	}
+}
+
+type mockBRCodeClient struct {
+	transpb.BRCodeServiceClient
+	mock.Mock
+}
+
+func (m *mockBRCodeClient) ParseBRCode(ctx context.Context, in *transpb.BRCodeRequest, opts ...grpc.CallOption) (*transpb.BRCode, error) {
+	args := m.Called(in.Payload)
+	return args.Get(0).(*transpb.BRCode), args.Error(1)
+}
+
+type mockChargeClient struct {
+	transpb.ChargeServiceClient
+	mock.Mock
+}
+
+func (m *mockChargeClient) ResolveCharge(ctx context.Context, in *transpb.ResolveChargeRequest, opts ...grpc.CallOption) (*transpb.Charge, error) {
+	args := m.Called(in.Txid, in.PayerCpf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*transpb.Charge), args.Error(1)
+}
+
+func TestFromCopyPaste(t *testing.T) {
+	const txID = "7978c0c97ea847e78e8849634473c1f1"
+	notActive := status.Error(codes.FailedPrecondition, "charge is not active")
+
+	cases := []struct {
+		name       string
+		code       *transpb.BRCode
+		amount     decimal.Decimal
+		mockFunc   func(charges *mockChargeClient)
+		wantAmount decimal.Decimal
+		err        error
+	}{
+		{
+			name:       "amount comes from the code",
+			code:       &transpb.BRCode{Key: "receiver@pix.com", Amount: 25.5},
+			wantAmount: decimal.RequireFromString("25.5"),
+		},
+		{
+			name:       "txid comes from the code",
+			code:       &transpb.BRCode{Key: "receiver@pix.com", Amount: 25.5, Txid: "7978c0c97ea847e78e8849634473c1f1"},
+			wantAmount: decimal.RequireFromString("25.5"),
+		},
+		{
+			name:       "same amount as the code is accepted",
+			code:       &transpb.BRCode{Key: "receiver@pix.com", Amount: 25.5},
+			amount:     decimal.RequireFromString("25.50"),
+			wantAmount: decimal.RequireFromString("25.5"),
+		},
+		{
+			name:   "different amount from the code is rejected",
+			code:   &transpb.BRCode{Key: "receiver@pix.com", Amount: 25.5},
+			amount: decimal.NewFromInt(30),
+			err:    errutils.ErrAmountMismatch,
+		},
+		{
+			name:       "code without amount uses the informed one",
+			code:       &transpb.BRCode{Key: "receiver@pix.com"},
+			amount:     decimal.NewFromInt(30),
+			wantAmount: decimal.NewFromInt(30),
+		},
+		{
+			name: "code without amount needs an informed one",
+			code: &transpb.BRCode{Key: "receiver@pix.com"},
+			err:  errutils.ErrInvalidAmount,
+		},
+		{
+			name: "charge without amount in the code pays its amount due",
+			code: &transpb.BRCode{Key: "receiver@pix.com", Txid: txID},
+			mockFunc: func(charges *mockChargeClient) {
+				charges.On("ResolveCharge", txID, "12345678909").Return(&transpb.Charge{Amount: 100, AmountDue: 97.19}, nil)
+			},
+			wantAmount: decimal.RequireFromString("97.19"),
+		},
+		{
+			name:   "amount informed for a charge must be its amount due",
+			code:   &transpb.BRCode{Key: "receiver@pix.com", Txid: txID},
+			amount: decimal.NewFromInt(100),
+			mockFunc: func(charges *mockChargeClient) {
+				charges.On("ResolveCharge", txID, "12345678909").Return(&transpb.Charge{Amount: 100, AmountDue: 97.19}, nil)
+			},
+			err: errutils.ErrAmountMismatch,
+		},
+		{
+			name: "failed because the charge can no longer be paid",
+			code: &transpb.BRCode{Key: "receiver@pix.com", Txid: txID},
+			mockFunc: func(charges *mockChargeClient) {
+				charges.On("ResolveCharge", txID, "12345678909").Return(nil, notActive)
+			},
+			err: notActive,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client, charges := new(mockBRCodeClient), new(mockChargeClient)
+			client.On("ParseBRCode", "000201...").Return(c.code, nil)
+			if c.mockFunc != nil {
+				c.mockFunc(charges)
+			}
+			accounts, users := new(mockAccountRepo), new(mockUserRepo)
+			accounts.On("FindAccountById", int64(1)).Return(&account.Account{Id: 1, UserID: "user-1"}, nil)
+			users.On("FindUserById", "user-1").Return(&user.User{Id: "user-1", Cpf: "12345678909"}, nil)
+
+			req := &Pix{AccountID: 1, Amount: c.amount, CopyPaste: "000201..."}
+			s := service{brcodes: client, charges: charges, accountRepository: accounts, userRepository: users}
+			err := s.fromCopyPaste(context.Background(), req)
+			assert.Equal(t, c.err, err)
+			charges.AssertExpectations(t)
+			if c.err == nil {
+				assert.Equal(t, "receiver@pix.com", req.Receiver)
+				assert.True(t, c.wantAmount.Equal(req.Amount), req.Amount.String())
+				assert.Equal(t, c.code.Txid, req.TxID)
+			}
+		})
+	}
+}
+
+type mockLookupRepo struct {
+	lookup.Repository
+	mock.Mock
+}
+
+func (m *mockLookupRepo) Find(ctx context.Context, token string) (*lookup.Lookup, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lookup.Lookup), args.Error(1)
+}
+
+func TestFromLookup(t *testing.T) {
+	confirmed := &lookup.Lookup{Token: "token", PayerAccount: 1, Key: "receiver@pix.com", ReceiverAccount: 2}
+
+	cases := []struct {
+		name     string
+		req      *Pix
+		mockFunc func(repo *mockLookupRepo)
+		err      error
+	}{
+		{
+			name: "receiver comes from the lookup",
+			req:  &Pix{AccountID: 1, LookupToken: "token"},
+			mockFunc: func(repo *mockLookupRepo) {
+				repo.On("Find", "token").Return(confirmed, nil)
+			},
+		},
+		{
+			name: "same receiver as the lookup is accepted",
+			req:  &Pix{AccountID: 1, Receiver: "receiver@pix.com", LookupToken: "token"},
+			mockFunc: func(repo *mockLookupRepo) {
+				repo.On("Find", "token").Return(confirmed, nil)
+			},
+		},
+		{
+			name: "different receiver from the lookup is rejected",
+			req:  &Pix{AccountID: 1, Receiver: "other@pix.com", LookupToken: "token"},
+			mockFunc: func(repo *mockLookupRepo) {
+				repo.On("Find", "token").Return(confirmed, nil)
+			},
+			err: errutils.ErrLookupMismatch,
+		},
+		{
+			name: "lookup of another payer is rejected",
+			req:  &Pix{AccountID: 5, LookupToken: "token"},
+			mockFunc: func(repo *mockLookupRepo) {
+				repo.On("Find", "token").Return(confirmed, nil)
+			},
+			err: errutils.ErrLookupMismatch,
+		},
+		{
+			name: "expired lookup is rejected",
+			req:  &Pix{AccountID: 1, LookupToken: "token"},
+			mockFunc: func(repo *mockLookupRepo) {
+				repo.On("Find", "token").Return(nil, errutils.ErrLookupNotFound)
+			},
+			err: errutils.ErrLookupNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockLookupRepo)
+			c.mockFunc(repo)
+
+			err := service{lookups: repo}.fromLookup(context.Background(), c.req)
+			assert.Equal(t, c.err, err)
+			if c.err == nil {
+				assert.Equal(t, "receiver@pix.com", c.req.Receiver)
+				assert.Equal(t, int64(2), c.req.ReceiverAccount)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+type mockAccountRepo struct {
+	account.Repository
+	mock.Mock
+}
+
+func (m *mockAccountRepo) FindAccountById(id int64) (*account.Account, error) {
+	args := m.Called(id)
+	return args.Get(0).(*account.Account), args.Error(1)
+}
+
+type mockKeysClient struct {
+	transpb.KeysServiceClient
+	mock.Mock
+}
+
+func (m *mockKeysClient) FindKey(ctx context.Context, in *transpb.KeyRequest, opts ...grpc.CallOption) (*transpb.KeyResponse, error) {
+	args := m.Called(in.KeyId)
+	return nil, args.Error(1)
+}
+
+type mockLimiter struct {
+	ratelimit.Limiter
+	mock.Mock
+}
+
+func (m *mockLimiter) Allow(ctx context.Context, subjects ...string) error {
+	return m.Called(subjects).Error(0)
+}
+
+func (m *mockLimiter) Penalize(ctx context.Context, subjects ...string) error {
+	return m.Called(subjects).Error(0)
+}
+
+func TestLookupKeyLimits(t *testing.T) {
+	subjects := []string{"user:user", "account:1"}
+
+	cases := []struct {
+		name     string
+		mockFunc func(keys *mockKeysClient, limiter *mockLimiter)
+		err      error
+	}{
+		{
+			name: "rate limited lookup does not reach the keys",
+			mockFunc: func(keys *mockKeysClient, limiter *mockLimiter) {
+				limiter.On("Allow", subjects).Return(errutils.ErrLookupRateLimited)
+			},
+			err: errutils.ErrLookupRateLimited,
+		},
+		{
+			name: "locked out lookup does not reach the keys",
+			mockFunc: func(keys *mockKeysClient, limiter *mockLimiter) {
+				limiter.On("Allow", subjects).Return(errutils.ErrLookupLocked)
+			},
+			err: errutils.ErrLookupLocked,
+		},
+		{
+			name: "lookup of a missing key is penalized",
+			mockFunc: func(keys *mockKeysClient, limiter *mockLimiter) {
+				limiter.On("Allow", subjects).Return(nil)
+				keys.On("FindKey", "missing@pix.com").Return(nil, status.Error(codes.NotFound, "key not found"))
+				limiter.On("Penalize", subjects).Return(nil)
+			},
+			err: errutils.ErrKeyNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			accounts, keys, limiter := new(mockAccountRepo), new(mockKeysClient), new(mockLimiter)
+			accounts.On("FindAccountById", int64(1)).Return(&account.Account{UserID: "user"}, nil)
+			c.mockFunc(keys, limiter)
+
+			s := NewService(nil, accounts, keys, nil, nil, nil, nil, nil, nil, nil, nil, limiter, nil, nil, nil)
+			_, err := s.LookupKey(context.Background(), "missing@pix.com", 1)
+			assert.Equal(t, c.err, err)
+			keys.AssertExpectations(t)
+			limiter.AssertExpectations(t)
+		})
+	}
+}
@@ -8,16 +8,27 @@ import (
 )
 
 type User struct {
-	Id        string         `gorm:"primaryKey;type:varchar(36);column:id"`
-	Name      string         `gorm:"type:varchar(255);column:name"`
-	Email     string         `gorm:"type:varchar(255);uniqueIndex;column:email"`
-	Address   string         `gorm:"type:varchar(255);column:address"`
-	Cpf       string         `gorm:"type:varchar(11);uniqueIndex;column:cpf"`
-	Phone     string         `gorm:"type:varchar(20);column:phone"`
-	Birthday  time.Time      `gorm:"type:date;column:birthday"`
-	CreatedAt time.Time      `gorm:"type:datetime;column:created_at"`
-	UpdatedAt time.Time      `gorm:"type:datetime;column:updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index;type:datetime;column:deleted_at"`
+	Id             string         `gorm:"primaryKey;type:varchar(36);column:id"`
+	Name           string         `gorm:"type:varchar(255);column:name"`
+	Email          string         `gorm:"type:varchar(255);uniqueIndex;column:email"`
+	Address        string         `gorm:"type:varchar(255);column:address"`
+	Cpf            string         `gorm:"type:varchar(11);uniqueIndex;column:cpf"`
+	Phone          string         `gorm:"type:varchar(20);column:phone"`
+	Birthday       time.Time      `gorm:"type:date;column:birthday"`
+	EmailChangedAt *time.Time     `gorm:"type:datetime;column:email_changed_at"`
+	PhoneChangedAt *time.Time     `gorm:"type:datetime;column:phone_changed_at"`
+	CreatedAt      time.Time      `gorm:"type:datetime;column:created_at"`
+	UpdatedAt      time.Time      `gorm:"type:datetime;column:updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index;type:datetime;column:deleted_at"`
+}
+
+// ContactChangedAt is the last time the email or the phone changed, nil if
+// they never did.
+func (u *User) ContactChangedAt() *time.Time {
+	if u.EmailChangedAt == nil || (u.PhoneChangedAt != nil && u.PhoneChangedAt.After(*u.EmailChangedAt)) {
+		return u.PhoneChangedAt
+	}
+	return u.EmailChangedAt
 }
 
 type UserRequest struct {
@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"profile/internal/cfg"
+	"time"
 )
 
 type Repository interface {
@@ -66,10 +67,22 @@ func (r repository) FindUserById(id string) (*User, error) {
 	return &user, nil
 }
 
+// UpdateUser stamps a change of email or phone before the new values
+// overwrite the old ones, the risk analysis looks at recent changes.
 func (r repository) UpdateUser(user *User) (*User, error) {
-	result := r.db.Save(&user)
-	if result.Error != nil {
-		return nil, result.Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&User{}).Where("id = ?", user.Id).Updates(map[string]interface{}{
+			"email_changed_at": gorm.Expr("CASE WHEN email <> ? THEN ? ELSE email_changed_at END", user.Email, now),
+			"phone_changed_at": gorm.Expr("CASE WHEN phone <> ? THEN ? ELSE phone_changed_at END", user.Phone, now),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Omit("email_changed_at", "phone_changed_at").Save(&user).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 	return user, nil
 }
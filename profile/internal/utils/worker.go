@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn on each tick until ctx is cancelled. Errors are logged and the
+// next tick runs normally.
+func Every(ctx context.Context, interval time.Duration, name string, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("worker [%s] started, running every %s\n", name, interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				log.Printf("worker [%s] failed: %v", name, err)
+			}
+		}
+	}
+}
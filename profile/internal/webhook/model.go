@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pb "profile/proto/profile/v1"
+	transpb "profile/proto/transactions/v1"
+	"time"
+)
+
+// Subscription sends the events of an account to URL. The secret is only
+// known right after it is generated: when the subscription is created and
+// when its secret is rotated.
+type Subscription struct {
+	ID                      string
+	AccountID               int64
+	URL                     string
+	Events                  []string
+	Enabled                 bool
+	Secret                  string
+	PreviousSecretExpiresAt time.Time
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+type Request struct {
+	ID        string
+	AccountID int64
+	Enabled   bool
+}
+
+type Attempt struct {
+	Number     int32
+	StatusCode int32
+	LatencyMs  int64
+	Response   string
+	Error      string
+	At         time.Time
+}
+
+type Delivery struct {
+	ID             string
+	SubscriptionID string
+	Event          string
+	Status         string
+	Attempts       []Attempt
+	CreatedAt      time.Time
+}
+
+func ProtoToSubscription(subscription *pb.WebhookSubscription) *Subscription {
+	return &Subscription{
+		AccountID: subscription.AccountId,
+		URL:       subscription.Url,
+		Events:    subscription.Events,
+	}
+}
+
+func ProtoToRequest(request *pb.WebhookSubscriptionRequest) *Request {
+	return &Request{
+		ID:        request.Id,
+		AccountID: request.AccountId,
+		Enabled:   request.Enabled,
+	}
+}
+
+func RequestToBackend(request *Request) *transpb.SubscriptionRequest {
+	return &transpb.SubscriptionRequest{
+		Id:        request.ID,
+		AccountId: request.AccountID,
+		Enabled:   request.Enabled,
+	}
+}
+
+func BackendToSubscription(subscription *transpb.WebhookSubscription) *Subscription {
+	value := &Subscription{
+		ID:        subscription.Id,
+		AccountID: subscription.AccountId,
+		URL:       subscription.Url,
+		Events:    subscription.Events,
+		Enabled:   subscription.Enabled,
+		Secret:    subscription.Secret,
+		CreatedAt: subscription.CreatedAt.AsTime(),
+		UpdatedAt: subscription.UpdatedAt.AsTime(),
+	}
+	if subscription.PreviousSecretExpiresAt != nil {
+		value.PreviousSecretExpiresAt = subscription.PreviousSecretExpiresAt.AsTime()
+	}
+	return value
+}
+
+func BackendToDelivery(delivery *transpb.WebhookDelivery) *Delivery {
+	attempts := make([]Attempt, len(delivery.Attempts))
+	for i, attempt := range delivery.Attempts {
+		attempts[i] = Attempt{
+			Number:     attempt.Number,
+			StatusCode: attempt.StatusCode,
+			LatencyMs:  attempt.LatencyMs,
+			Response:   attempt.Response,
+			Error:      attempt.Error,
+			At:         attempt.At.AsTime(),
+		}
+	}
+	return &Delivery{
+		ID:             delivery.Id,
+		SubscriptionID: delivery.SubscriptionId,
+		Event:          delivery.Event,
+		Status:         delivery.Status,
+		Attempts:       attempts,
+		CreatedAt:      delivery.CreatedAt.AsTime(),
+	}
+}
+
+func ToProto(subscription *Subscription) *pb.WebhookSubscription {
+	value := &pb.WebhookSubscription{
+		Id:        subscription.ID,
+		AccountId: subscription.AccountID,
+		Url:       subscription.URL,
+		Events:    subscription.Events,
+		Enabled:   subscription.Enabled,
+		Secret:    subscription.Secret,
+		CreatedAt: timestamppb.New(subscription.CreatedAt),
+		UpdatedAt: timestamppb.New(subscription.UpdatedAt),
+	}
+	if !subscription.PreviousSecretExpiresAt.IsZero() {
+		value.PreviousSecretExpiresAt = timestamppb.New(subscription.PreviousSecretExpiresAt)
+	}
+	return value
+}
+
+func ListToProto(subscriptions []*Subscription) *pb.WebhookSubscriptions {
+	values := make([]*pb.WebhookSubscription, len(subscriptions))
+	for i, subscription := range subscriptions {
+		values[i] = ToProto(subscription)
+	}
+	return &pb.WebhookSubscriptions{Subscriptions: values}
+}
+
+func DeliveryToProto(delivery *Delivery) *pb.WebhookDelivery {
+	attempts := make([]*pb.WebhookAttempt, len(delivery.Attempts))
+	for i, attempt := range delivery.Attempts {
+		attempts[i] = &pb.WebhookAttempt{
+			Number:     attempt.Number,
+			StatusCode: attempt.StatusCode,
+			LatencyMs:  attempt.LatencyMs,
+			Response:   attempt.Response,
+			Error:      attempt.Error,
+			At:         timestamppb.New(attempt.At),
+		}
+	}
+	return &pb.WebhookDelivery{
+		Id:             delivery.ID,
+		SubscriptionId: delivery.SubscriptionID,
+		Event:          delivery.Event,
+		Status:         delivery.Status,
+		Attempts:       attempts,
+		CreatedAt:      timestamppb.New(delivery.CreatedAt),
+	}
+}
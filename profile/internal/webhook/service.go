@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"profile/internal/account"
+	"profile/internal/errutils"
+	transpb "profile/proto/transactions/v1"
+)
+
+type Service interface {
+	Subscribe(ctx context.Context, subscription *Subscription) (*Subscription, error)
+	List(ctx context.Context, accountID int64) ([]*Subscription, error)
+	SetEnabled(ctx context.Context, req *Request) (*Subscription, error)
+	RotateSecret(ctx context.Context, req *Request) (*Subscription, error)
+	Ping(ctx context.Context, req *Request) (*Delivery, error)
+	Unsubscribe(ctx context.Context, req *Request) error
+}
+
+type service struct {
+	accountRepository account.Repository
+	backend           transpb.WebhookServiceClient
+}
+
+// Subscribe registers the endpoint for an active account. The transaction
+// service keeps the subscriptions, as it raises the events.
+func (s *service) Subscribe(ctx context.Context, subscription *Subscription) (*Subscription, error) {
+	active, err := s.accountRepository.IsAccountActive(ctx, subscription.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, errutils.ErrInactiveAccount
+	}
+
+	created, err := s.backend.CreateSubscription(ctx, &transpb.WebhookSubscription{
+		AccountId: subscription.AccountID,
+		Url:       subscription.URL,
+		Events:    subscription.Events,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return BackendToSubscription(created), nil
+}
+
+func (s *service) List(ctx context.Context, accountID int64) ([]*Subscription, error) {
+	list, err := s.backend.ListSubscriptions(ctx, &transpb.ListSubscriptionsRequest{AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*Subscription, len(list.Subscriptions))
+	for i := range list.Subscriptions {
+		subscriptions[i] = BackendToSubscription(list.Subscriptions[i])
+	}
+	return subscriptions, nil
+}
+
+func (s *service) SetEnabled(ctx context.Context, req *Request) (*Subscription, error) {
+	updated, err := s.backend.SetSubscriptionEnabled(ctx, RequestToBackend(req))
+	if err != nil {
+		return nil, err
+	}
+	return BackendToSubscription(updated), nil
+}
+
+func (s *service) RotateSecret(ctx context.Context, req *Request) (*Subscription, error) {
+	rotated, err := s.backend.RotateSubscriptionSecret(ctx, RequestToBackend(req))
+	if err != nil {
+		return nil, err
+	}
+	return BackendToSubscription(rotated), nil
+}
+
+func (s *service) Ping(ctx context.Context, req *Request) (*Delivery, error) {
+	delivery, err := s.backend.PingSubscription(ctx, RequestToBackend(req))
+	if err != nil {
+		return nil, err
+	}
+	return BackendToDelivery(delivery), nil
+}
+
+func (s *service) Unsubscribe(ctx context.Context, req *Request) error {
+	_, err := s.backend.DeleteSubscription(ctx, RequestToBackend(req))
+	return err
+}
+
+func NewService(accountRepository account.Repository, backend transpb.WebhookServiceClient) Service {
+	return &service{
+		accountRepository: accountRepository,
+		backend:           backend,
+	}
+}
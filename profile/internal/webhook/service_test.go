@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"profile/internal/account"
+	"profile/internal/errutils"
+	transpb "profile/proto/transactions/v1"
+	"testing"
+	"time"
+)
+
+type mockAccountRepo struct {
+	account.Repository
+	mock.Mock
+}
+
+func (m *mockAccountRepo) IsAccountActive(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockWebhookClient struct {
+	transpb.WebhookServiceClient
+	mock.Mock
+}
+
+func (m *mockWebhookClient) CreateSubscription(ctx context.Context, in *transpb.WebhookSubscription, opts ...grpc.CallOption) (*transpb.WebhookSubscription, error) {
+	args := m.Called(in.AccountId, in.Url, in.Events)
+	return args.Get(0).(*transpb.WebhookSubscription), args.Error(1)
+}
+
+func TestSubscribe(t *testing.T) {
+	createdAt := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	subscription := &Subscription{AccountID: 2, URL: "https://example.com/hooks", Events: []string{"pix.received"}}
+
+	cases := []struct {
+		name     string
+		mockFunc func(accounts *mockAccountRepo, backend *mockWebhookClient)
+		want     *Subscription
+		err      error
+	}{
+		{
+			name: "success",
+			mockFunc: func(accounts *mockAccountRepo, backend *mockWebhookClient) {
+				accounts.On("IsAccountActive", int64(2)).Return(true, nil)
+				backend.On("CreateSubscription", int64(2), "https://example.com/hooks", []string{"pix.received"}).
+					Return(&transpb.WebhookSubscription{
+						Id: "sub-1", AccountId: 2, Url: "https://example.com/hooks", Events: []string{"pix.received"},
+						Enabled: true, Secret: "whsec_1", CreatedAt: timestamppb.New(createdAt), UpdatedAt: timestamppb.New(createdAt),
+					}, nil)
+			},
+			want: &Subscription{
+				ID: "sub-1", AccountID: 2, URL: "https://example.com/hooks", Events: []string{"pix.received"},
+				Enabled: true, Secret: "whsec_1", CreatedAt: createdAt, UpdatedAt: createdAt,
+			},
+		},
+		{
+			name: "inactive account",
+			mockFunc: func(accounts *mockAccountRepo, backend *mockWebhookClient) {
+				accounts.On("IsAccountActive", int64(2)).Return(false, nil)
+			},
+			err: errutils.ErrInactiveAccount,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			accounts := new(mockAccountRepo)
+			backend := new(mockWebhookClient)
+			c.mockFunc(accounts, backend)
+
+			got, err := NewService(accounts, backend).Subscribe(context.Background(), subscription)
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.want, got)
+			accounts.AssertExpectations(t)
+			backend.AssertExpectations(t)
+		})
+	}
+}
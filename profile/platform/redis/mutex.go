@@ -1,6 +1,179 @@
 package redis
 
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"time"
+)
+
+var (
+	ErrLockNotHeld = errors.New("lock is not held by this mutex")
+)
+
+// Mutex serializes work on a key across instances. It is renewed while held,
+// but a holder cut off from redis for longer than the TTL loses it without
+// knowing, so writes that must not overlap are also fenced by their storage
+// with Token.
 type Mutex interface {
-	Lock() error
-	Unlock() error
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+	// Token is the fencing token of the current acquisition. It grows on every
+	// acquisition of the same key, so storage can reject writes from a holder
+	// whose lock already expired.
+	Token() int64
+}
+
+type Locker interface {
+	NewMutex(key string) Mutex
+}
+
+type Options func(*locker)
+
+func WithTTL(ttl time.Duration) Options {
+	return func(l *locker) {
+		l.ttl = ttl
+	}
+}
+
+func WithRetryInterval(interval time.Duration) Options {
+	return func(l *locker) {
+		l.retryInterval = interval
+	}
+}
+
+// acquireScript takes the lock and hands out the next fencing token of the key
+// in one step, so no two holders ever share a token.
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return false
+`)
+
+// releaseScript deletes the lock only when it still belongs to the caller.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewScript extends the lock only while it still belongs to the caller.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type locker struct {
+	client        *redis.Client
+	ttl           time.Duration
+	retryInterval time.Duration
+}
+
+func (l *locker) NewMutex(key string) Mutex {
+	return &mutex{
+		locker: l,
+		key:    "lock:" + key,
+		fence:  "lock:" + key + ":fence",
+	}
+}
+
+type mutex struct {
+	locker *locker
+	key    string
+	fence  string
+	owner  string
+	token  int64
+	stop   context.CancelFunc
+}
+
+// Lock blocks until the lock is acquired or ctx is done. The lock is renewed
+// until Unlock.
+func (m *mutex) Lock(ctx context.Context) error {
+	owner := uuid.New().String()
+	for {
+		token, err := acquireScript.Run(ctx, m.locker.client, []string{m.key, m.fence}, owner, m.locker.ttl.Milliseconds()).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if err == nil {
+			renewCtx, stop := context.WithCancel(context.Background())
+			m.owner = owner
+			m.token = token
+			m.stop = stop
+			go m.renew(renewCtx, owner)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.locker.retryInterval):
+		}
+	}
+}
+
+func (m *mutex) Unlock(ctx context.Context) error {
+	if m.owner == "" {
+		return ErrLockNotHeld
+	}
+
+	m.stop()
+	released, err := releaseScript.Run(ctx, m.locker.client, []string{m.key}, m.owner).Int64()
+	m.owner = ""
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (m *mutex) Token() int64 {
+	return m.token
+}
+
+// renew extends the lock every third of the TTL until ctx is done or the lock
+// is no longer owner's.
+func (m *mutex) renew(ctx context.Context, owner string) {
+	ticker := time.NewTicker(m.locker.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := renewScript.Run(ctx, m.locker.client, []string{m.key}, owner, m.locker.ttl.Milliseconds()).Int64()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("failed to renew %s: %v", m.key, err)
+			}
+			continue
+		}
+		if renewed == 0 {
+			log.Printf("lost %s before it was released", m.key)
+			return
+		}
+	}
+}
+
+func NewLocker(client *redis.Client, opts ...Options) Locker {
+	l := &locker{
+		client:        client,
+		ttl:           10 * time.Second,
+		retryInterval: 50 * time.Millisecond,
+	}
+	for _, f := range opts {
+		f(l)
+	}
+	return l
 }
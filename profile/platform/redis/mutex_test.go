@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLocker(t *testing.T, opts ...Options) (Locker, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	return NewLocker(client, opts...), server
+}
+
+func TestMutexLockUnlock(t *testing.T) {
+	ctx := context.Background()
+	locker, server := newTestLocker(t)
+
+	m := locker.NewMutex("account:1")
+	require.NoError(t, m.Lock(ctx))
+	assert.True(t, server.Exists("lock:account:1"))
+	assert.Equal(t, int64(1), m.Token())
+
+	require.NoError(t, m.Unlock(ctx))
+	assert.False(t, server.Exists("lock:account:1"))
+
+	assert.Equal(t, ErrLockNotHeld, m.Unlock(ctx))
+}
+
+func TestMutexFencingTokenGrows(t *testing.T) {
+	ctx := context.Background()
+	locker, _ := newTestLocker(t)
+
+	first := locker.NewMutex("account:1")
+	require.NoError(t, first.Lock(ctx))
+	require.NoError(t, first.Unlock(ctx))
+
+	second := locker.NewMutex("account:1")
+	require.NoError(t, second.Lock(ctx))
+	assert.Greater(t, second.Token(), first.Token())
+}
+
+func TestMutexRenewedWhileHeld(t *testing.T) {
+	ctx := context.Background()
+	locker, server := newTestLocker(t, WithTTL(300*time.Millisecond))
+
+	m := locker.NewMutex("account:1")
+	require.NoError(t, m.Lock(ctx))
+
+	// most of the TTL has passed, the holder renews it before it expires
+	server.FastForward(250 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return server.TTL("lock:account:1") > 250*time.Millisecond
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, m.Unlock(ctx))
+	assert.False(t, server.Exists("lock:account:1"))
+}
+
+func TestMutexLockRespectsContext(t *testing.T) {
+	locker, _ := newTestLocker(t, WithRetryInterval(5*time.Millisecond))
+
+	holder := locker.NewMutex("account:1")
+	require.NoError(t, holder.Lock(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	err := locker.NewMutex("account:1").Lock(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMutexExpiredLockCannotBeReleasedByOldHolder(t *testing.T) {
+	ctx := context.Background()
+	locker, server := newTestLocker(t, WithTTL(time.Second))
+
+	stale := locker.NewMutex("account:1")
+	require.NoError(t, stale.Lock(ctx))
+
+	server.FastForward(2 * time.Second)
+
+	current := locker.NewMutex("account:1")
+	require.NoError(t, current.Lock(ctx))
+	assert.Greater(t, current.Token(), stale.Token())
+
+	assert.Equal(t, ErrLockNotHeld, stale.Unlock(ctx))
+	assert.True(t, server.Exists("lock:account:1"))
+}
+
+func TestMutexSerializesHolders(t *testing.T) {
+	locker, _ := newTestLocker(t, WithRetryInterval(time.Millisecond))
+
+	var (
+		wg      sync.WaitGroup
+		active  int
+		maxSeen int
+		mu      sync.Mutex
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := locker.NewMutex("account:1")
+			if err := m.Lock(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			active++
+			if active > maxSeen {
+				maxSeen = active
+			}
+			mu.Unlock()
+
+			time.Sleep(2 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+			if err := m.Unlock(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, maxSeen)
+}
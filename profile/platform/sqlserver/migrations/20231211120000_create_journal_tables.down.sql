@@ -0,0 +1,8 @@
+IF EXISTS (SELECT 1 FROM sys.tables WHERE name = 'journal_lines')
+BEGIN
+DROP TABLE journal_lines;
+END
+IF EXISTS (SELECT 1 FROM sys.tables WHERE name = 'journal_entries')
+BEGIN
+DROP TABLE journal_entries;
+END
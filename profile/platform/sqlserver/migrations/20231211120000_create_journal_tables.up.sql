@@ -0,0 +1,37 @@
+create table journal_entries
+(
+    id             varchar(36) not null primary key,
+    transaction_id varchar(36) not null,
+    type           varchar(20) not null,
+    description    varchar(255),
+    created_at     datetime
+)
+
+create unique index ux_journal_entries_transaction on journal_entries (transaction_id, type)
+
+create table journal_lines
+(
+    id         varchar(36) not null primary key,
+    entry_id   varchar(36) not null foreign key references journal_entries(id),
+    account_id int not null,
+    direction  varchar(6) not null,
+    amount     decimal(10,2) not null,
+    created_at datetime
+)
+
+create index ix_journal_lines_account on journal_lines (account_id)
+
+insert into journal_entries (id, transaction_id, type, description, created_at)
+select lower(convert(varchar(36), newid())), 'opening-' + convert(varchar(20), id), 'ADJUSTMENT', 'opening balance', getdate()
+from accounts
+where balance <> 0
+
+insert into journal_lines (id, entry_id, account_id, direction, amount, created_at)
+select lower(convert(varchar(36), newid())), e.id, a.id, case when a.balance > 0 then 'CREDIT' else 'DEBIT' end, abs(a.balance), getdate()
+from accounts a
+inner join journal_entries e on e.transaction_id = 'opening-' + convert(varchar(20), a.id)
+
+insert into journal_lines (id, entry_id, account_id, direction, amount, created_at)
+select lower(convert(varchar(36), newid())), e.id, 0, case when a.balance > 0 then 'DEBIT' else 'CREDIT' end, abs(a.balance), getdate()
+from accounts a
+inner join journal_entries e on e.transaction_id = 'opening-' + convert(varchar(20), a.id)
@@ -0,0 +1,13 @@
+IF EXISTS (SELECT 1 FROM sys.tables WHERE name = 'holds')
+BEGIN
+DROP TABLE holds;
+END
+
+DECLARE @constraint nvarchar(200)
+SELECT @constraint = name FROM sys.default_constraints
+WHERE parent_object_id = OBJECT_ID('accounts') AND COL_NAME(parent_object_id, parent_column_id) = 'held_amount'
+IF @constraint IS NOT NULL
+EXEC('ALTER TABLE accounts DROP CONSTRAINT ' + @constraint)
+
+ALTER TABLE accounts
+DROP COLUMN held_amount;
@@ -0,0 +1,17 @@
+ALTER TABLE accounts
+ADD held_amount decimal(10,2) NOT NULL DEFAULT 0;
+
+create table holds
+(
+    id             varchar(36) not null primary key,
+    account_id     int not null foreign key references accounts(id),
+    transaction_id varchar(36) not null,
+    amount         decimal(10,2) not null,
+    status         varchar(20) not null,
+    expires_at     datetime,
+    created_at     datetime,
+    updated_at     datetime
+)
+
+create unique index ux_holds_transaction on holds (transaction_id)
+create index ix_holds_status_expires on holds (status, expires_at)
@@ -0,0 +1,4 @@
+IF EXISTS (SELECT 1 FROM sys.tables WHERE name = 'outbox_messages')
+BEGIN
+DROP TABLE outbox_messages;
+END
@@ -0,0 +1,15 @@
+create table outbox_messages
+(
+    id              bigint identity(1,1) not null primary key,
+    aggregate_id    int not null,
+    topic           varchar(255) not null,
+    payload         nvarchar(max) not null,
+    status          varchar(20) not null,
+    attempts        int not null default 0,
+    last_error      nvarchar(1000),
+    next_attempt_at datetime,
+    sent_at         datetime,
+    created_at      datetime
+)
+
+create index ix_outbox_messages_status on outbox_messages (status, id)
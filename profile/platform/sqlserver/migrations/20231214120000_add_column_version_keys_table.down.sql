@@ -0,0 +1,4 @@
+ALTER TABLE keys
+DROP CONSTRAINT df_keys_version;
+ALTER TABLE keys
+DROP COLUMN version;
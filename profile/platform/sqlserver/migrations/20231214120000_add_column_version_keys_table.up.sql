@@ -0,0 +1,2 @@
+ALTER TABLE keys
+ADD version bigint NOT NULL CONSTRAINT df_keys_version DEFAULT 1;
@@ -0,0 +1 @@
+drop index ix_journal_lines_account_created on journal_lines
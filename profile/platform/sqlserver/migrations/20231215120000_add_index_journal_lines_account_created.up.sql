@@ -0,0 +1 @@
+create index ix_journal_lines_account_created on journal_lines (account_id, created_at) include (entry_id, direction, amount)
@@ -0,0 +1,2 @@
+drop index ix_holds_account_created on holds
+drop table pix_limits
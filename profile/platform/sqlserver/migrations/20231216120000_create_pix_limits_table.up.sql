@@ -0,0 +1,13 @@
+create table pix_limits
+(
+    account_id       int not null foreign key references accounts(id),
+    type             varchar(20) not null,
+    amount           decimal(10,2) not null,
+    requested_amount decimal(10,2),
+    effective_at     datetime,
+    created_at       datetime,
+    updated_at       datetime,
+    primary key (account_id, type)
+)
+
+create index ix_holds_account_created on holds (account_id, created_at) include (status, amount)
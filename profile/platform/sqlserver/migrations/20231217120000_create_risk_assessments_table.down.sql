@@ -0,0 +1,7 @@
+ALTER TABLE users
+DROP COLUMN email_changed_at, phone_changed_at;
+
+IF EXISTS (SELECT 1 FROM sys.tables WHERE name = 'risk_assessments')
+BEGIN
+DROP TABLE risk_assessments;
+END
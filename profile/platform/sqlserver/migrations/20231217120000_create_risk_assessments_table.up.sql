@@ -0,0 +1,24 @@
+create table risk_assessments
+(
+    id             varchar(36) not null primary key,
+    transaction_id varchar(36) not null,
+    account_id     int not null foreign key references accounts(id),
+    receiver       varchar(200),
+    amount         decimal(10,2) not null,
+    score          int not null,
+    decision       varchar(10) not null,
+    reasons        nvarchar(max),
+    review_status  varchar(20),
+    reviewed_by    varchar(255),
+    reviewed_at    datetime,
+    review_note    nvarchar(1000),
+    pix            nvarchar(max),
+    created_at     datetime,
+    updated_at     datetime
+)
+
+create index ix_risk_assessments_account_created on risk_assessments (account_id, created_at)
+create index ix_risk_assessments_review on risk_assessments (decision, review_status, created_at)
+
+ALTER TABLE users
+ADD email_changed_at datetime, phone_changed_at datetime;
@@ -0,0 +1,4 @@
+IF EXISTS (SELECT 1 FROM sys.tables WHERE name = 'infraction_reports')
+BEGIN
+DROP TABLE infraction_reports;
+END
@@ -0,0 +1,27 @@
+create table infraction_reports
+(
+    id                    varchar(36) not null primary key,
+    transaction_id        varchar(36) not null,
+    reporter_account_id   int not null foreign key references accounts(id),
+    receiver_account_id   int not null foreign key references accounts(id),
+    amount                decimal(10,2) not null,
+    blocked_amount        decimal(10,2) not null,
+    reason                nvarchar(1000),
+    status                varchar(20) not null,
+    analyst               varchar(255),
+    resolution_note       nvarchar(1000),
+    hold_transaction_id   varchar(36),
+    refund_transaction_id varchar(36),
+    refund_attempts       int not null default 0,
+    last_error            nvarchar(1000),
+    analysis_due_at       datetime not null,
+    refund_due_at         datetime,
+    next_attempt_at       datetime,
+    resolved_at           datetime,
+    created_at            datetime,
+    updated_at            datetime
+)
+
+create unique index ux_infraction_reports_transaction on infraction_reports (transaction_id) where status <> 'REJECTED'
+create index ix_infraction_reports_status_created on infraction_reports (status, created_at)
+create index ix_infraction_reports_next_attempt on infraction_reports (status, next_attempt_at)
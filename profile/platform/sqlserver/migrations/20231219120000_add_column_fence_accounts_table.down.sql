@@ -0,0 +1,8 @@
+DECLARE @constraint nvarchar(200)
+SELECT @constraint = name FROM sys.default_constraints
+WHERE parent_object_id = OBJECT_ID('accounts') AND COL_NAME(parent_object_id, parent_column_id) = 'fence'
+IF @constraint IS NOT NULL
+EXEC('ALTER TABLE accounts DROP CONSTRAINT ' + @constraint)
+
+ALTER TABLE accounts
+DROP COLUMN fence;
@@ -0,0 +1,2 @@
+ALTER TABLE accounts
+ADD fence bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+DECLARE @constraint nvarchar(200)
+SELECT @constraint = name FROM sys.default_constraints
+WHERE parent_object_id = OBJECT_ID('holds') AND COL_NAME(parent_object_id, parent_column_id) = 'kind'
+IF @constraint IS NOT NULL
+EXEC('ALTER TABLE holds DROP CONSTRAINT ' + @constraint)
+
+ALTER TABLE holds
+DROP COLUMN kind;
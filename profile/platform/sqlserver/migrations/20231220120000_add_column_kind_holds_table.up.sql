@@ -0,0 +1,6 @@
+ALTER TABLE holds
+ADD kind varchar(20) NOT NULL DEFAULT 'PIX';
+
+EXEC('UPDATE holds SET kind = ''BLOCK''
+WHERE transaction_id IN (SELECT id FROM infraction_reports)
+   OR transaction_id IN (SELECT hold_transaction_id FROM infraction_reports WHERE hold_transaction_id IS NOT NULL)')
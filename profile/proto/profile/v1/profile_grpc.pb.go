@@ -571,10 +571,13 @@ var AccountService_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	KeysService_CreateKey_FullMethodName = "/profile.proto.v2.KeysService/CreateKey"
-	KeysService_UpdateKey_FullMethodName = "/profile.proto.v2.KeysService/UpdateKey"
-	KeysService_ListKey_FullMethodName   = "/profile.proto.v2.KeysService/ListKey"
-	KeysService_DeleteKey_FullMethodName = "/profile.proto.v2.KeysService/DeleteKey"
+	KeysService_CreateKey_FullMethodName     = "/profile.proto.v2.KeysService/CreateKey"
+	KeysService_UpdateKey_FullMethodName     = "/profile.proto.v2.KeysService/UpdateKey"
+	KeysService_ListKey_FullMethodName       = "/profile.proto.v2.KeysService/ListKey"
+	KeysService_DeleteKey_FullMethodName     = "/profile.proto.v2.KeysService/DeleteKey"
+	KeysService_LookupKey_FullMethodName     = "/profile.proto.v2.KeysService/LookupKey"
+	KeysService_LookupLimits_FullMethodName  = "/profile.proto.v2.KeysService/LookupLimits"
+	KeysService_UnlockLookups_FullMethodName = "/profile.proto.v2.KeysService/UnlockLookups"
 )
 
 // KeysServiceClient is the client API for KeysService service.
@@ -585,6 +588,9 @@ type KeysServiceClient interface {
 	UpdateKey(ctx context.Context, in *Key, opts ...grpc.CallOption) (*empty.Empty, error)
 	ListKey(ctx context.Context, in *ListKeyRequest, opts ...grpc.CallOption) (*ListKeys, error)
 	DeleteKey(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	LookupKey(ctx context.Context, in *LookupKeyRequest, opts ...grpc.CallOption) (*KeyLookup, error)
+	LookupLimits(ctx context.Context, in *LookupLimitsRequest, opts ...grpc.CallOption) (*ListLookupLimits, error)
+	UnlockLookups(ctx context.Context, in *LookupLimitsRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
 type keysServiceClient struct {
@@ -631,6 +637,33 @@ func (c *keysServiceClient) DeleteKey(ctx context.Context, in *KeyRequest, opts
 	return out, nil
 }
 
+func (c *keysServiceClient) LookupKey(ctx context.Context, in *LookupKeyRequest, opts ...grpc.CallOption) (*KeyLookup, error) {
+	out := new(KeyLookup)
+	err := c.cc.Invoke(ctx, KeysService_LookupKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keysServiceClient) LookupLimits(ctx context.Context, in *LookupLimitsRequest, opts ...grpc.CallOption) (*ListLookupLimits, error) {
+	out := new(ListLookupLimits)
+	err := c.cc.Invoke(ctx, KeysService_LookupLimits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keysServiceClient) UnlockLookups(ctx context.Context, in *LookupLimitsRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, KeysService_UnlockLookups_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeysServiceServer is the server API for KeysService service.
 // All implementations must embed UnimplementedKeysServiceServer
 // for forward compatibility
@@ -639,6 +672,9 @@ type KeysServiceServer interface {
 	UpdateKey(context.Context, *Key) (*empty.Empty, error)
 	ListKey(context.Context, *ListKeyRequest) (*ListKeys, error)
 	DeleteKey(context.Context, *KeyRequest) (*empty.Empty, error)
+	LookupKey(context.Context, *LookupKeyRequest) (*KeyLookup, error)
+	LookupLimits(context.Context, *LookupLimitsRequest) (*ListLookupLimits, error)
+	UnlockLookups(context.Context, *LookupLimitsRequest) (*empty.Empty, error)
 	mustEmbedUnimplementedKeysServiceServer()
 }
 
@@ -658,6 +694,15 @@ func (UnimplementedKeysServiceServer) ListKey(context.Context, *ListKeyRequest)
 func (UnimplementedKeysServiceServer) DeleteKey(context.Context, *KeyRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteKey not implemented")
 }
+func (UnimplementedKeysServiceServer) LookupKey(context.Context, *LookupKeyRequest) (*KeyLookup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupKey not implemented")
+}
+func (UnimplementedKeysServiceServer) LookupLimits(context.Context, *LookupLimitsRequest) (*ListLookupLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupLimits not implemented")
+}
+func (UnimplementedKeysServiceServer) UnlockLookups(context.Context, *LookupLimitsRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockLookups not implemented")
+}
 func (UnimplementedKeysServiceServer) mustEmbedUnimplementedKeysServiceServer() {}
 
 // UnsafeKeysServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -743,6 +788,60 @@ func _KeysService_DeleteKey_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeysService_LookupKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeysServiceServer).LookupKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeysService_LookupKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeysServiceServer).LookupKey(ctx, req.(*LookupKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeysService_LookupLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeysServiceServer).LookupLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeysService_LookupLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeysServiceServer).LookupLimits(ctx, req.(*LookupLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeysService_UnlockLookups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeysServiceServer).UnlockLookups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeysService_UnlockLookups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeysServiceServer).UnlockLookups(ctx, req.(*LookupLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // KeysService_ServiceDesc is the grpc.ServiceDesc for KeysService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -766,22 +865,58 @@ var KeysService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteKey",
 			Handler:    _KeysService_DeleteKey_Handler,
 		},
+		{
+			MethodName: "LookupKey",
+			Handler:    _KeysService_LookupKey_Handler,
+		},
+		{
+			MethodName: "LookupLimits",
+			Handler:    _KeysService_LookupLimits_Handler,
+		},
+		{
+			MethodName: "UnlockLookups",
+			Handler:    _KeysService_UnlockLookups_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "profile.proto",
 }
 
 const (
-	PixTransactionService_SendPix_FullMethodName    = "/profile.proto.v2.PixTransactionService/SendPix"
-	PixTransactionService_PixWebhook_FullMethodName = "/profile.proto.v2.PixTransactionService/PixWebhook"
+	PixTransactionService_SendPix_FullMethodName                 = "/profile.proto.v2.PixTransactionService/SendPix"
+	PixTransactionService_PixWebhook_FullMethodName              = "/profile.proto.v2.PixTransactionService/PixWebhook"
+	PixTransactionService_RefundPix_FullMethodName               = "/profile.proto.v2.PixTransactionService/RefundPix"
+	PixTransactionService_ListScheduledPix_FullMethodName        = "/profile.proto.v2.PixTransactionService/ListScheduledPix"
+	PixTransactionService_ListAccountTransactions_FullMethodName = "/profile.proto.v2.PixTransactionService/ListAccountTransactions"
+	PixTransactionService_CancelScheduledPix_FullMethodName      = "/profile.proto.v2.PixTransactionService/CancelScheduledPix"
+	PixTransactionService_CreateQRCode_FullMethodName            = "/profile.proto.v2.PixTransactionService/CreateQRCode"
+	PixTransactionService_ParseQRCode_FullMethodName             = "/profile.proto.v2.PixTransactionService/ParseQRCode"
+	PixTransactionService_CreateCharge_FullMethodName            = "/profile.proto.v2.PixTransactionService/CreateCharge"
+	PixTransactionService_FindCharge_FullMethodName              = "/profile.proto.v2.PixTransactionService/FindCharge"
+	PixTransactionService_ListCharges_FullMethodName             = "/profile.proto.v2.PixTransactionService/ListCharges"
+	PixTransactionService_CancelCharge_FullMethodName            = "/profile.proto.v2.PixTransactionService/CancelCharge"
+	PixTransactionService_ListPixLimits_FullMethodName           = "/profile.proto.v2.PixTransactionService/ListPixLimits"
+	PixTransactionService_RequestPixLimit_FullMethodName         = "/profile.proto.v2.PixTransactionService/RequestPixLimit"
 )
 
 // PixTransactionServiceClient is the client API for PixTransactionService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PixTransactionServiceClient interface {
-	SendPix(ctx context.Context, in *PixTransaction, opts ...grpc.CallOption) (*empty.Empty, error)
+	SendPix(ctx context.Context, in *PixTransaction, opts ...grpc.CallOption) (*PixTransaction, error)
 	PixWebhook(ctx context.Context, in *Webhook, opts ...grpc.CallOption) (*empty.Empty, error)
+	RefundPix(ctx context.Context, in *PixRefund, opts ...grpc.CallOption) (*PixTransaction, error)
+	ListScheduledPix(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*ListPixTransactions, error)
+	ListAccountTransactions(ctx context.Context, in *TransactionHistoryRequest, opts ...grpc.CallOption) (*TransactionHistory, error)
+	CancelScheduledPix(ctx context.Context, in *ScheduledPixRequest, opts ...grpc.CallOption) (*PixTransaction, error)
+	CreateQRCode(ctx context.Context, in *QRCode, opts ...grpc.CallOption) (*QRCode, error)
+	ParseQRCode(ctx context.Context, in *QRCode, opts ...grpc.CallOption) (*QRCode, error)
+	CreateCharge(ctx context.Context, in *PixCharge, opts ...grpc.CallOption) (*PixCharge, error)
+	FindCharge(ctx context.Context, in *PixChargeRequest, opts ...grpc.CallOption) (*PixCharge, error)
+	ListCharges(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*ListPixCharges, error)
+	CancelCharge(ctx context.Context, in *PixChargeRequest, opts ...grpc.CallOption) (*PixCharge, error)
+	ListPixLimits(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*PixLimits, error)
+	RequestPixLimit(ctx context.Context, in *PixLimitRequest, opts ...grpc.CallOption) (*PixLimit, error)
 }
 
 type pixTransactionServiceClient struct {
@@ -792,8 +927,8 @@ func NewPixTransactionServiceClient(cc grpc.ClientConnInterface) PixTransactionS
 	return &pixTransactionServiceClient{cc}
 }
 
-func (c *pixTransactionServiceClient) SendPix(ctx context.Context, in *PixTransaction, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
+func (c *pixTransactionServiceClient) SendPix(ctx context.Context, in *PixTransaction, opts ...grpc.CallOption) (*PixTransaction, error) {
+	out := new(PixTransaction)
 	err := c.cc.Invoke(ctx, PixTransactionService_SendPix_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -810,12 +945,132 @@ func (c *pixTransactionServiceClient) PixWebhook(ctx context.Context, in *Webhoo
 	return out, nil
 }
 
+func (c *pixTransactionServiceClient) RefundPix(ctx context.Context, in *PixRefund, opts ...grpc.CallOption) (*PixTransaction, error) {
+	out := new(PixTransaction)
+	err := c.cc.Invoke(ctx, PixTransactionService_RefundPix_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) ListScheduledPix(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*ListPixTransactions, error) {
+	out := new(ListPixTransactions)
+	err := c.cc.Invoke(ctx, PixTransactionService_ListScheduledPix_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) ListAccountTransactions(ctx context.Context, in *TransactionHistoryRequest, opts ...grpc.CallOption) (*TransactionHistory, error) {
+	out := new(TransactionHistory)
+	err := c.cc.Invoke(ctx, PixTransactionService_ListAccountTransactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) CancelScheduledPix(ctx context.Context, in *ScheduledPixRequest, opts ...grpc.CallOption) (*PixTransaction, error) {
+	out := new(PixTransaction)
+	err := c.cc.Invoke(ctx, PixTransactionService_CancelScheduledPix_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) CreateQRCode(ctx context.Context, in *QRCode, opts ...grpc.CallOption) (*QRCode, error) {
+	out := new(QRCode)
+	err := c.cc.Invoke(ctx, PixTransactionService_CreateQRCode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) ParseQRCode(ctx context.Context, in *QRCode, opts ...grpc.CallOption) (*QRCode, error) {
+	out := new(QRCode)
+	err := c.cc.Invoke(ctx, PixTransactionService_ParseQRCode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) CreateCharge(ctx context.Context, in *PixCharge, opts ...grpc.CallOption) (*PixCharge, error) {
+	out := new(PixCharge)
+	err := c.cc.Invoke(ctx, PixTransactionService_CreateCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) FindCharge(ctx context.Context, in *PixChargeRequest, opts ...grpc.CallOption) (*PixCharge, error) {
+	out := new(PixCharge)
+	err := c.cc.Invoke(ctx, PixTransactionService_FindCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) ListCharges(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*ListPixCharges, error) {
+	out := new(ListPixCharges)
+	err := c.cc.Invoke(ctx, PixTransactionService_ListCharges_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) CancelCharge(ctx context.Context, in *PixChargeRequest, opts ...grpc.CallOption) (*PixCharge, error) {
+	out := new(PixCharge)
+	err := c.cc.Invoke(ctx, PixTransactionService_CancelCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) ListPixLimits(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*PixLimits, error) {
+	out := new(PixLimits)
+	err := c.cc.Invoke(ctx, PixTransactionService_ListPixLimits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixTransactionServiceClient) RequestPixLimit(ctx context.Context, in *PixLimitRequest, opts ...grpc.CallOption) (*PixLimit, error) {
+	out := new(PixLimit)
+	err := c.cc.Invoke(ctx, PixTransactionService_RequestPixLimit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PixTransactionServiceServer is the server API for PixTransactionService service.
 // All implementations must embed UnimplementedPixTransactionServiceServer
 // for forward compatibility
 type PixTransactionServiceServer interface {
-	SendPix(context.Context, *PixTransaction) (*empty.Empty, error)
+	SendPix(context.Context, *PixTransaction) (*PixTransaction, error)
 	PixWebhook(context.Context, *Webhook) (*empty.Empty, error)
+	RefundPix(context.Context, *PixRefund) (*PixTransaction, error)
+	ListScheduledPix(context.Context, *AccountRequest) (*ListPixTransactions, error)
+	ListAccountTransactions(context.Context, *TransactionHistoryRequest) (*TransactionHistory, error)
+	CancelScheduledPix(context.Context, *ScheduledPixRequest) (*PixTransaction, error)
+	CreateQRCode(context.Context, *QRCode) (*QRCode, error)
+	ParseQRCode(context.Context, *QRCode) (*QRCode, error)
+	CreateCharge(context.Context, *PixCharge) (*PixCharge, error)
+	FindCharge(context.Context, *PixChargeRequest) (*PixCharge, error)
+	ListCharges(context.Context, *AccountRequest) (*ListPixCharges, error)
+	CancelCharge(context.Context, *PixChargeRequest) (*PixCharge, error)
+	ListPixLimits(context.Context, *AccountRequest) (*PixLimits, error)
+	RequestPixLimit(context.Context, *PixLimitRequest) (*PixLimit, error)
 	mustEmbedUnimplementedPixTransactionServiceServer()
 }
 
@@ -823,12 +1078,48 @@ type PixTransactionServiceServer interface {
 type UnimplementedPixTransactionServiceServer struct {
 }
 
-func (UnimplementedPixTransactionServiceServer) SendPix(context.Context, *PixTransaction) (*empty.Empty, error) {
+func (UnimplementedPixTransactionServiceServer) SendPix(context.Context, *PixTransaction) (*PixTransaction, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendPix not implemented")
 }
 func (UnimplementedPixTransactionServiceServer) PixWebhook(context.Context, *Webhook) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PixWebhook not implemented")
 }
+func (UnimplementedPixTransactionServiceServer) RefundPix(context.Context, *PixRefund) (*PixTransaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundPix not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) ListScheduledPix(context.Context, *AccountRequest) (*ListPixTransactions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListScheduledPix not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) ListAccountTransactions(context.Context, *TransactionHistoryRequest) (*TransactionHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccountTransactions not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) CancelScheduledPix(context.Context, *ScheduledPixRequest) (*PixTransaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduledPix not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) CreateQRCode(context.Context, *QRCode) (*QRCode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateQRCode not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) ParseQRCode(context.Context, *QRCode) (*QRCode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ParseQRCode not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) CreateCharge(context.Context, *PixCharge) (*PixCharge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCharge not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) FindCharge(context.Context, *PixChargeRequest) (*PixCharge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindCharge not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) ListCharges(context.Context, *AccountRequest) (*ListPixCharges, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCharges not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) CancelCharge(context.Context, *PixChargeRequest) (*PixCharge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelCharge not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) ListPixLimits(context.Context, *AccountRequest) (*PixLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPixLimits not implemented")
+}
+func (UnimplementedPixTransactionServiceServer) RequestPixLimit(context.Context, *PixLimitRequest) (*PixLimit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPixLimit not implemented")
+}
 func (UnimplementedPixTransactionServiceServer) mustEmbedUnimplementedPixTransactionServiceServer() {}
 
 // UnsafePixTransactionServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -878,20 +1169,1153 @@ func _PixTransactionService_PixWebhook_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
-// PixTransactionService_ServiceDesc is the grpc.ServiceDesc for PixTransactionService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var PixTransactionService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "profile.proto.v2.PixTransactionService",
-	HandlerType: (*PixTransactionServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "SendPix",
-			Handler:    _PixTransactionService_SendPix_Handler,
-		},
-		{
-			MethodName: "PixWebhook",
-			Handler:    _PixTransactionService_PixWebhook_Handler,
+func _PixTransactionService_RefundPix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PixRefund)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).RefundPix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_RefundPix_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).RefundPix(ctx, req.(*PixRefund))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_ListScheduledPix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).ListScheduledPix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_ListScheduledPix_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).ListScheduledPix(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_ListAccountTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).ListAccountTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_ListAccountTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).ListAccountTransactions(ctx, req.(*TransactionHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_CancelScheduledPix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScheduledPixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).CancelScheduledPix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_CancelScheduledPix_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).CancelScheduledPix(ctx, req.(*ScheduledPixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_CreateQRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QRCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).CreateQRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_CreateQRCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).CreateQRCode(ctx, req.(*QRCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_ParseQRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QRCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).ParseQRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_ParseQRCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).ParseQRCode(ctx, req.(*QRCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_CreateCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PixCharge)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).CreateCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_CreateCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).CreateCharge(ctx, req.(*PixCharge))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_FindCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PixChargeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).FindCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_FindCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).FindCharge(ctx, req.(*PixChargeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_ListCharges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).ListCharges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_ListCharges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).ListCharges(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_CancelCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PixChargeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).CancelCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_CancelCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).CancelCharge(ctx, req.(*PixChargeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_ListPixLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).ListPixLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_ListPixLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).ListPixLimits(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PixTransactionService_RequestPixLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PixLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixTransactionServiceServer).RequestPixLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PixTransactionService_RequestPixLimit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixTransactionServiceServer).RequestPixLimit(ctx, req.(*PixLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PixTransactionService_ServiceDesc is the grpc.ServiceDesc for PixTransactionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PixTransactionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "profile.proto.v2.PixTransactionService",
+	HandlerType: (*PixTransactionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendPix",
+			Handler:    _PixTransactionService_SendPix_Handler,
+		},
+		{
+			MethodName: "PixWebhook",
+			Handler:    _PixTransactionService_PixWebhook_Handler,
+		},
+		{
+			MethodName: "RefundPix",
+			Handler:    _PixTransactionService_RefundPix_Handler,
+		},
+		{
+			MethodName: "ListScheduledPix",
+			Handler:    _PixTransactionService_ListScheduledPix_Handler,
+		},
+		{
+			MethodName: "ListAccountTransactions",
+			Handler:    _PixTransactionService_ListAccountTransactions_Handler,
+		},
+		{
+			MethodName: "CancelScheduledPix",
+			Handler:    _PixTransactionService_CancelScheduledPix_Handler,
+		},
+		{
+			MethodName: "CreateQRCode",
+			Handler:    _PixTransactionService_CreateQRCode_Handler,
+		},
+		{
+			MethodName: "ParseQRCode",
+			Handler:    _PixTransactionService_ParseQRCode_Handler,
+		},
+		{
+			MethodName: "CreateCharge",
+			Handler:    _PixTransactionService_CreateCharge_Handler,
+		},
+		{
+			MethodName: "FindCharge",
+			Handler:    _PixTransactionService_FindCharge_Handler,
+		},
+		{
+			MethodName: "ListCharges",
+			Handler:    _PixTransactionService_ListCharges_Handler,
+		},
+		{
+			MethodName: "CancelCharge",
+			Handler:    _PixTransactionService_CancelCharge_Handler,
+		},
+		{
+			MethodName: "ListPixLimits",
+			Handler:    _PixTransactionService_ListPixLimits_Handler,
+		},
+		{
+			MethodName: "RequestPixLimit",
+			Handler:    _PixTransactionService_RequestPixLimit_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "profile.proto",
+}
+
+const (
+	LedgerService_Adjust_FullMethodName          = "/profile.proto.v2.LedgerService/Adjust"
+	LedgerService_Reverse_FullMethodName         = "/profile.proto.v2.LedgerService/Reverse"
+	LedgerService_ListEntries_FullMethodName     = "/profile.proto.v2.LedgerService/ListEntries"
+	LedgerService_Reconcile_FullMethodName       = "/profile.proto.v2.LedgerService/Reconcile"
+	LedgerService_ExportStatement_FullMethodName = "/profile.proto.v2.LedgerService/ExportStatement"
+)
+
+// LedgerServiceClient is the client API for LedgerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LedgerServiceClient interface {
+	Adjust(ctx context.Context, in *LedgerAdjustment, opts ...grpc.CallOption) (*LedgerEntry, error)
+	Reverse(ctx context.Context, in *LedgerReversal, opts ...grpc.CallOption) (*LedgerEntry, error)
+	ListEntries(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*ListLedgerEntries, error)
+	Reconcile(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Reconciliation, error)
+	ExportStatement(ctx context.Context, in *StatementRequest, opts ...grpc.CallOption) (LedgerService_ExportStatementClient, error)
+}
+
+type ledgerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLedgerServiceClient(cc grpc.ClientConnInterface) LedgerServiceClient {
+	return &ledgerServiceClient{cc}
+}
+
+func (c *ledgerServiceClient) Adjust(ctx context.Context, in *LedgerAdjustment, opts ...grpc.CallOption) (*LedgerEntry, error) {
+	out := new(LedgerEntry)
+	err := c.cc.Invoke(ctx, LedgerService_Adjust_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) Reverse(ctx context.Context, in *LedgerReversal, opts ...grpc.CallOption) (*LedgerEntry, error) {
+	out := new(LedgerEntry)
+	err := c.cc.Invoke(ctx, LedgerService_Reverse_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ListEntries(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*ListLedgerEntries, error) {
+	out := new(ListLedgerEntries)
+	err := c.cc.Invoke(ctx, LedgerService_ListEntries_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) Reconcile(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Reconciliation, error) {
+	out := new(Reconciliation)
+	err := c.cc.Invoke(ctx, LedgerService_Reconcile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ExportStatement(ctx context.Context, in *StatementRequest, opts ...grpc.CallOption) (LedgerService_ExportStatementClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LedgerService_ServiceDesc.Streams[0], LedgerService_ExportStatement_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ledgerServiceExportStatementClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LedgerService_ExportStatementClient interface {
+	Recv() (*StatementChunk, error)
+	grpc.ClientStream
+}
+
+type ledgerServiceExportStatementClient struct {
+	grpc.ClientStream
+}
+
+func (x *ledgerServiceExportStatementClient) Recv() (*StatementChunk, error) {
+	m := new(StatementChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LedgerServiceServer is the server API for LedgerService service.
+// All implementations must embed UnimplementedLedgerServiceServer
+// for forward compatibility
+type LedgerServiceServer interface {
+	Adjust(context.Context, *LedgerAdjustment) (*LedgerEntry, error)
+	Reverse(context.Context, *LedgerReversal) (*LedgerEntry, error)
+	ListEntries(context.Context, *AccountRequest) (*ListLedgerEntries, error)
+	Reconcile(context.Context, *AccountRequest) (*Reconciliation, error)
+	ExportStatement(*StatementRequest, LedgerService_ExportStatementServer) error
+	mustEmbedUnimplementedLedgerServiceServer()
+}
+
+// UnimplementedLedgerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedLedgerServiceServer struct {
+}
+
+func (UnimplementedLedgerServiceServer) Adjust(context.Context, *LedgerAdjustment) (*LedgerEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Adjust not implemented")
+}
+func (UnimplementedLedgerServiceServer) Reverse(context.Context, *LedgerReversal) (*LedgerEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reverse not implemented")
+}
+func (UnimplementedLedgerServiceServer) ListEntries(context.Context, *AccountRequest) (*ListLedgerEntries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEntries not implemented")
+}
+func (UnimplementedLedgerServiceServer) Reconcile(context.Context, *AccountRequest) (*Reconciliation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reconcile not implemented")
+}
+func (UnimplementedLedgerServiceServer) ExportStatement(*StatementRequest, LedgerService_ExportStatementServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportStatement not implemented")
+}
+func (UnimplementedLedgerServiceServer) mustEmbedUnimplementedLedgerServiceServer() {}
+
+// UnsafeLedgerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LedgerServiceServer will
+// result in compilation errors.
+type UnsafeLedgerServiceServer interface {
+	mustEmbedUnimplementedLedgerServiceServer()
+}
+
+func RegisterLedgerServiceServer(s grpc.ServiceRegistrar, srv LedgerServiceServer) {
+	s.RegisterService(&LedgerService_ServiceDesc, srv)
+}
+
+func _LedgerService_Adjust_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LedgerAdjustment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).Adjust(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_Adjust_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).Adjust(ctx, req.(*LedgerAdjustment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_Reverse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LedgerReversal)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).Reverse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_Reverse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).Reverse(ctx, req.(*LedgerReversal))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ListEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ListEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_ListEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ListEntries(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_Reconcile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).Reconcile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_Reconcile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).Reconcile(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ExportStatement_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatementRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LedgerServiceServer).ExportStatement(m, &ledgerServiceExportStatementServer{stream})
+}
+
+type LedgerService_ExportStatementServer interface {
+	Send(*StatementChunk) error
+	grpc.ServerStream
+}
+
+type ledgerServiceExportStatementServer struct {
+	grpc.ServerStream
+}
+
+func (x *ledgerServiceExportStatementServer) Send(m *StatementChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// LedgerService_ServiceDesc is the grpc.ServiceDesc for LedgerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LedgerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "profile.proto.v2.LedgerService",
+	HandlerType: (*LedgerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Adjust",
+			Handler:    _LedgerService_Adjust_Handler,
+		},
+		{
+			MethodName: "Reverse",
+			Handler:    _LedgerService_Reverse_Handler,
+		},
+		{
+			MethodName: "ListEntries",
+			Handler:    _LedgerService_ListEntries_Handler,
+		},
+		{
+			MethodName: "Reconcile",
+			Handler:    _LedgerService_Reconcile_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportStatement",
+			Handler:       _LedgerService_ExportStatement_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "profile.proto",
+}
+
+const (
+	WebhookService_CreateWebhookSubscription_FullMethodName     = "/profile.proto.v2.WebhookService/CreateWebhookSubscription"
+	WebhookService_ListWebhookSubscriptions_FullMethodName      = "/profile.proto.v2.WebhookService/ListWebhookSubscriptions"
+	WebhookService_SetWebhookSubscriptionEnabled_FullMethodName = "/profile.proto.v2.WebhookService/SetWebhookSubscriptionEnabled"
+	WebhookService_RotateWebhookSecret_FullMethodName           = "/profile.proto.v2.WebhookService/RotateWebhookSecret"
+	WebhookService_PingWebhookSubscription_FullMethodName       = "/profile.proto.v2.WebhookService/PingWebhookSubscription"
+	WebhookService_DeleteWebhookSubscription_FullMethodName     = "/profile.proto.v2.WebhookService/DeleteWebhookSubscription"
+)
+
+// WebhookServiceClient is the client API for WebhookService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WebhookServiceClient interface {
+	CreateWebhookSubscription(ctx context.Context, in *WebhookSubscription, opts ...grpc.CallOption) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*WebhookSubscriptions, error)
+	SetWebhookSubscriptionEnabled(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error)
+	RotateWebhookSecret(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error)
+	PingWebhookSubscription(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*WebhookDelivery, error)
+	DeleteWebhookSubscription(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+}
+
+type webhookServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWebhookServiceClient(cc grpc.ClientConnInterface) WebhookServiceClient {
+	return &webhookServiceClient{cc}
+}
+
+func (c *webhookServiceClient) CreateWebhookSubscription(ctx context.Context, in *WebhookSubscription, opts ...grpc.CallOption) (*WebhookSubscription, error) {
+	out := new(WebhookSubscription)
+	err := c.cc.Invoke(ctx, WebhookService_CreateWebhookSubscription_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) ListWebhookSubscriptions(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*WebhookSubscriptions, error) {
+	out := new(WebhookSubscriptions)
+	err := c.cc.Invoke(ctx, WebhookService_ListWebhookSubscriptions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) SetWebhookSubscriptionEnabled(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error) {
+	out := new(WebhookSubscription)
+	err := c.cc.Invoke(ctx, WebhookService_SetWebhookSubscriptionEnabled_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) RotateWebhookSecret(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error) {
+	out := new(WebhookSubscription)
+	err := c.cc.Invoke(ctx, WebhookService_RotateWebhookSecret_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) PingWebhookSubscription(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*WebhookDelivery, error) {
+	out := new(WebhookDelivery)
+	err := c.cc.Invoke(ctx, WebhookService_PingWebhookSubscription_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) DeleteWebhookSubscription(ctx context.Context, in *WebhookSubscriptionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, WebhookService_DeleteWebhookSubscription_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WebhookServiceServer is the server API for WebhookService service.
+// All implementations must embed UnimplementedWebhookServiceServer
+// for forward compatibility
+type WebhookServiceServer interface {
+	CreateWebhookSubscription(context.Context, *WebhookSubscription) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(context.Context, *AccountRequest) (*WebhookSubscriptions, error)
+	SetWebhookSubscriptionEnabled(context.Context, *WebhookSubscriptionRequest) (*WebhookSubscription, error)
+	RotateWebhookSecret(context.Context, *WebhookSubscriptionRequest) (*WebhookSubscription, error)
+	PingWebhookSubscription(context.Context, *WebhookSubscriptionRequest) (*WebhookDelivery, error)
+	DeleteWebhookSubscription(context.Context, *WebhookSubscriptionRequest) (*empty.Empty, error)
+	mustEmbedUnimplementedWebhookServiceServer()
+}
+
+// UnimplementedWebhookServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedWebhookServiceServer struct {
+}
+
+func (UnimplementedWebhookServiceServer) CreateWebhookSubscription(context.Context, *WebhookSubscription) (*WebhookSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhookSubscription not implemented")
+}
+func (UnimplementedWebhookServiceServer) ListWebhookSubscriptions(context.Context, *AccountRequest) (*WebhookSubscriptions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookSubscriptions not implemented")
+}
+func (UnimplementedWebhookServiceServer) SetWebhookSubscriptionEnabled(context.Context, *WebhookSubscriptionRequest) (*WebhookSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWebhookSubscriptionEnabled not implemented")
+}
+func (UnimplementedWebhookServiceServer) RotateWebhookSecret(context.Context, *WebhookSubscriptionRequest) (*WebhookSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateWebhookSecret not implemented")
+}
+func (UnimplementedWebhookServiceServer) PingWebhookSubscription(context.Context, *WebhookSubscriptionRequest) (*WebhookDelivery, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PingWebhookSubscription not implemented")
+}
+func (UnimplementedWebhookServiceServer) DeleteWebhookSubscription(context.Context, *WebhookSubscriptionRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhookSubscription not implemented")
+}
+func (UnimplementedWebhookServiceServer) mustEmbedUnimplementedWebhookServiceServer() {}
+
+// UnsafeWebhookServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WebhookServiceServer will
+// result in compilation errors.
+type UnsafeWebhookServiceServer interface {
+	mustEmbedUnimplementedWebhookServiceServer()
+}
+
+func RegisterWebhookServiceServer(s grpc.ServiceRegistrar, srv WebhookServiceServer) {
+	s.RegisterService(&WebhookService_ServiceDesc, srv)
+}
+
+func _WebhookService_CreateWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookSubscription)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).CreateWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_CreateWebhookSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).CreateWebhookSubscription(ctx, req.(*WebhookSubscription))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_ListWebhookSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).ListWebhookSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_ListWebhookSubscriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).ListWebhookSubscriptions(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_SetWebhookSubscriptionEnabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).SetWebhookSubscriptionEnabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_SetWebhookSubscriptionEnabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).SetWebhookSubscriptionEnabled(ctx, req.(*WebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_RotateWebhookSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).RotateWebhookSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_RotateWebhookSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).RotateWebhookSecret(ctx, req.(*WebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_PingWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).PingWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_PingWebhookSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).PingWebhookSubscription(ctx, req.(*WebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_DeleteWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).DeleteWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_DeleteWebhookSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).DeleteWebhookSubscription(ctx, req.(*WebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WebhookService_ServiceDesc is the grpc.ServiceDesc for WebhookService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WebhookService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "profile.proto.v2.WebhookService",
+	HandlerType: (*WebhookServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateWebhookSubscription",
+			Handler:    _WebhookService_CreateWebhookSubscription_Handler,
+		},
+		{
+			MethodName: "ListWebhookSubscriptions",
+			Handler:    _WebhookService_ListWebhookSubscriptions_Handler,
+		},
+		{
+			MethodName: "SetWebhookSubscriptionEnabled",
+			Handler:    _WebhookService_SetWebhookSubscriptionEnabled_Handler,
+		},
+		{
+			MethodName: "RotateWebhookSecret",
+			Handler:    _WebhookService_RotateWebhookSecret_Handler,
+		},
+		{
+			MethodName: "PingWebhookSubscription",
+			Handler:    _WebhookService_PingWebhookSubscription_Handler,
+		},
+		{
+			MethodName: "DeleteWebhookSubscription",
+			Handler:    _WebhookService_DeleteWebhookSubscription_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "profile.proto",
+}
+
+const (
+	RiskService_ListReviews_FullMethodName   = "/profile.proto.v2.RiskService/ListReviews"
+	RiskService_ApproveReview_FullMethodName = "/profile.proto.v2.RiskService/ApproveReview"
+	RiskService_RejectReview_FullMethodName  = "/profile.proto.v2.RiskService/RejectReview"
+)
+
+// RiskServiceClient is the client API for RiskService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RiskServiceClient interface {
+	ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*RiskAssessments, error)
+	ApproveReview(ctx context.Context, in *ReviewRequest, opts ...grpc.CallOption) (*PixTransaction, error)
+	RejectReview(ctx context.Context, in *ReviewRequest, opts ...grpc.CallOption) (*RiskAssessment, error)
+}
+
+type riskServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRiskServiceClient(cc grpc.ClientConnInterface) RiskServiceClient {
+	return &riskServiceClient{cc}
+}
+
+func (c *riskServiceClient) ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*RiskAssessments, error) {
+	out := new(RiskAssessments)
+	err := c.cc.Invoke(ctx, RiskService_ListReviews_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *riskServiceClient) ApproveReview(ctx context.Context, in *ReviewRequest, opts ...grpc.CallOption) (*PixTransaction, error) {
+	out := new(PixTransaction)
+	err := c.cc.Invoke(ctx, RiskService_ApproveReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *riskServiceClient) RejectReview(ctx context.Context, in *ReviewRequest, opts ...grpc.CallOption) (*RiskAssessment, error) {
+	out := new(RiskAssessment)
+	err := c.cc.Invoke(ctx, RiskService_RejectReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RiskServiceServer is the server API for RiskService service.
+// All implementations must embed UnimplementedRiskServiceServer
+// for forward compatibility
+type RiskServiceServer interface {
+	ListReviews(context.Context, *ListReviewsRequest) (*RiskAssessments, error)
+	ApproveReview(context.Context, *ReviewRequest) (*PixTransaction, error)
+	RejectReview(context.Context, *ReviewRequest) (*RiskAssessment, error)
+	mustEmbedUnimplementedRiskServiceServer()
+}
+
+// UnimplementedRiskServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRiskServiceServer struct {
+}
+
+func (UnimplementedRiskServiceServer) ListReviews(context.Context, *ListReviewsRequest) (*RiskAssessments, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReviews not implemented")
+}
+func (UnimplementedRiskServiceServer) ApproveReview(context.Context, *ReviewRequest) (*PixTransaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveReview not implemented")
+}
+func (UnimplementedRiskServiceServer) RejectReview(context.Context, *ReviewRequest) (*RiskAssessment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectReview not implemented")
+}
+func (UnimplementedRiskServiceServer) mustEmbedUnimplementedRiskServiceServer() {}
+
+// UnsafeRiskServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RiskServiceServer will
+// result in compilation errors.
+type UnsafeRiskServiceServer interface {
+	mustEmbedUnimplementedRiskServiceServer()
+}
+
+func RegisterRiskServiceServer(s grpc.ServiceRegistrar, srv RiskServiceServer) {
+	s.RegisterService(&RiskService_ServiceDesc, srv)
+}
+
+func _RiskService_ListReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RiskServiceServer).ListReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RiskService_ListReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RiskServiceServer).ListReviews(ctx, req.(*ListReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RiskService_ApproveReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RiskServiceServer).ApproveReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RiskService_ApproveReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RiskServiceServer).ApproveReview(ctx, req.(*ReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RiskService_RejectReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RiskServiceServer).RejectReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RiskService_RejectReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RiskServiceServer).RejectReview(ctx, req.(*ReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RiskService_ServiceDesc is the grpc.ServiceDesc for RiskService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RiskService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "profile.proto.v2.RiskService",
+	HandlerType: (*RiskServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListReviews",
+			Handler:    _RiskService_ListReviews_Handler,
+		},
+		{
+			MethodName: "ApproveReview",
+			Handler:    _RiskService_ApproveReview_Handler,
+		},
+		{
+			MethodName: "RejectReview",
+			Handler:    _RiskService_RejectReview_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "profile.proto",
+}
+
+const (
+	MedService_ReportInfraction_FullMethodName      = "/profile.proto.v2.MedService/ReportInfraction"
+	MedService_ListInfractionReports_FullMethodName = "/profile.proto.v2.MedService/ListInfractionReports"
+	MedService_ResolveInfraction_FullMethodName     = "/profile.proto.v2.MedService/ResolveInfraction"
+)
+
+// MedServiceClient is the client API for MedService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MedServiceClient interface {
+	ReportInfraction(ctx context.Context, in *InfractionReportRequest, opts ...grpc.CallOption) (*InfractionReport, error)
+	ListInfractionReports(ctx context.Context, in *ListInfractionReportsRequest, opts ...grpc.CallOption) (*InfractionReports, error)
+	ResolveInfraction(ctx context.Context, in *InfractionResolution, opts ...grpc.CallOption) (*InfractionReport, error)
+}
+
+type medServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMedServiceClient(cc grpc.ClientConnInterface) MedServiceClient {
+	return &medServiceClient{cc}
+}
+
+func (c *medServiceClient) ReportInfraction(ctx context.Context, in *InfractionReportRequest, opts ...grpc.CallOption) (*InfractionReport, error) {
+	out := new(InfractionReport)
+	err := c.cc.Invoke(ctx, MedService_ReportInfraction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *medServiceClient) ListInfractionReports(ctx context.Context, in *ListInfractionReportsRequest, opts ...grpc.CallOption) (*InfractionReports, error) {
+	out := new(InfractionReports)
+	err := c.cc.Invoke(ctx, MedService_ListInfractionReports_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *medServiceClient) ResolveInfraction(ctx context.Context, in *InfractionResolution, opts ...grpc.CallOption) (*InfractionReport, error) {
+	out := new(InfractionReport)
+	err := c.cc.Invoke(ctx, MedService_ResolveInfraction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MedServiceServer is the server API for MedService service.
+// All implementations must embed UnimplementedMedServiceServer
+// for forward compatibility
+type MedServiceServer interface {
+	ReportInfraction(context.Context, *InfractionReportRequest) (*InfractionReport, error)
+	ListInfractionReports(context.Context, *ListInfractionReportsRequest) (*InfractionReports, error)
+	ResolveInfraction(context.Context, *InfractionResolution) (*InfractionReport, error)
+	mustEmbedUnimplementedMedServiceServer()
+}
+
+// UnimplementedMedServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMedServiceServer struct {
+}
+
+func (UnimplementedMedServiceServer) ReportInfraction(context.Context, *InfractionReportRequest) (*InfractionReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportInfraction not implemented")
+}
+func (UnimplementedMedServiceServer) ListInfractionReports(context.Context, *ListInfractionReportsRequest) (*InfractionReports, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInfractionReports not implemented")
+}
+func (UnimplementedMedServiceServer) ResolveInfraction(context.Context, *InfractionResolution) (*InfractionReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveInfraction not implemented")
+}
+func (UnimplementedMedServiceServer) mustEmbedUnimplementedMedServiceServer() {}
+
+// UnsafeMedServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MedServiceServer will
+// result in compilation errors.
+type UnsafeMedServiceServer interface {
+	mustEmbedUnimplementedMedServiceServer()
+}
+
+func RegisterMedServiceServer(s grpc.ServiceRegistrar, srv MedServiceServer) {
+	s.RegisterService(&MedService_ServiceDesc, srv)
+}
+
+func _MedService_ReportInfraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfractionReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MedServiceServer).ReportInfraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MedService_ReportInfraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MedServiceServer).ReportInfraction(ctx, req.(*InfractionReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MedService_ListInfractionReports_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInfractionReportsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MedServiceServer).ListInfractionReports(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MedService_ListInfractionReports_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MedServiceServer).ListInfractionReports(ctx, req.(*ListInfractionReportsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MedService_ResolveInfraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfractionResolution)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MedServiceServer).ResolveInfraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MedService_ResolveInfraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MedServiceServer).ResolveInfraction(ctx, req.(*InfractionResolution))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MedService_ServiceDesc is the grpc.ServiceDesc for MedService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MedService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "profile.proto.v2.MedService",
+	HandlerType: (*MedServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReportInfraction",
+			Handler:    _MedService_ReportInfraction_Handler,
+		},
+		{
+			MethodName: "ListInfractionReports",
+			Handler:    _MedService_ListInfractionReports_Handler,
+		},
+		{
+			MethodName: "ResolveInfraction",
+			Handler:    _MedService_ResolveInfraction_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
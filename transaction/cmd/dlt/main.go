@@ -0,0 +1,69 @@
+// Command dlt lists and replays messages of the pix dead-letter topic through
+// the transaction gRPC server.
+//
+//	dlt list [-offset 0] [-limit 50]
+//	dlt replay -offset 12
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"log"
+	"os"
+	"time"
+	proto "transaction/proto/v1"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: dlt <list|replay> [flags]")
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	addr := flags.String("addr", "localhost:9090", "transaction gRPC server address")
+	offset := flags.Int64("offset", 0, "dead-letter topic offset")
+	limit := flags.Int("limit", 50, "maximum number of messages to list")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	conn, err := grpc.Dial(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to %s: %v", *addr, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := proto.NewDeadLetterServiceClient(conn)
+	switch os.Args[1] {
+	case "list":
+		resp, err := client.ListDeadLetters(ctx, &proto.ListDeadLettersRequest{Offset: *offset, Limit: int32(*limit)})
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, deadLetter := range resp.GetDeadLetters() {
+			printDeadLetter(deadLetter)
+		}
+		fmt.Printf("next offset: %d\n", resp.GetNextOffset())
+	case "replay":
+		deadLetter, err := client.ReplayDeadLetter(ctx, &proto.ReplayDeadLetterRequest{Offset: *offset})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print("replayed ")
+		printDeadLetter(deadLetter)
+	default:
+		log.Fatalf("unknown command %q", os.Args[1])
+	}
+}
+
+func printDeadLetter(deadLetter *proto.DeadLetter) {
+	fmt.Printf("offset=%d topic=%s attempts=%d failed_at=%s error=%q\n  %s\n",
+		deadLetter.GetOffset(), deadLetter.GetOriginalTopic(), deadLetter.GetAttempts(),
+		deadLetter.GetFailedAt().AsTime().Format(time.RFC3339), deadLetter.GetError(), deadLetter.GetPayload())
+}
@@ -6,11 +6,17 @@ import (
 	"google.golang.org/grpc"
 	"log"
 	"net"
+	"transaction/internal/brcode"
 	"transaction/internal/cfg"
+	"transaction/internal/charge"
+	"transaction/internal/claim"
 	"transaction/internal/event"
 	keys "transaction/internal/keys"
+	"transaction/internal/mandate"
 	"transaction/internal/pix"
+	"transaction/internal/schedule"
 	"transaction/internal/transactions"
+	"transaction/internal/utils"
 	"transaction/internal/webhook"
 	"transaction/platform/dynamo"
 	"transaction/platform/kafka"
@@ -33,28 +39,82 @@ func main() {
 	// repositories
 	transactionRepository := transactions.NewRepository(db, config)
 	keysRepository := keys.NewRepository(db, config)
+	scheduleRepository := schedule.NewRepository(db, config)
+	mandateRepository := mandate.NewRepository(db, config)
+	chargeRepository := charge.NewRepository(db, config)
+	claimRepository := claim.NewRepository(db, config)
+	webhookRepository := webhook.NewRepository(db, config)
 
-	webhookService := webhook.NewService(transactionRepository)
+	webhookService := webhook.NewService(webhookRepository, transactionRepository,
+		webhook.WithSecret(config.WebhookConfig.Secret),
+		webhook.WithTimeout(config.WebhookConfig.Timeout),
+		webhook.WithSendRetry(config.WebhookConfig.SendAttempts, config.WebhookConfig.SendBackoff),
+		webhook.WithRetry(config.WebhookConfig.MaxAttempts, config.WebhookConfig.InitialBackoff, config.WebhookConfig.MaxBackoff),
+		webhook.WithBatchSize(config.WebhookConfig.BatchSize),
+		webhook.WithLease(config.WebhookConfig.Lease),
+		webhook.WithMaxSubscriptions(config.WebhookConfig.MaxSubscriptions),
+		webhook.WithRotationGrace(config.WebhookConfig.RotationGrace))
+
+	kafkaConn := kafka.NewClient(config).Connect()
+
+	claimEvents := event.NewEvent(kafkaConn, keys.ClaimsTopic,
+		event.WithAttempts(4), event.WithBroker("localhost:9092"))
 
 	// services
 	transactionService := transactions.NewService(transactionRepository)
 	keysService := keys.NewService(keysRepository)
+	brcodeService := brcode.NewService(keysRepository)
+	chargeService := charge.NewService(chargeRepository, keysRepository)
+	claimService := claim.NewService(claimRepository, keysRepository, claimEvents,
+		claim.WithBatchSize(config.ClaimConfig.BatchSize),
+		claim.WithResolutionWindow(config.ClaimConfig.ResolutionWindow),
+		claim.WithLease(config.ClaimConfig.Lease))
 
-	//server
-	transactionServer := newTransactionService(transactionService, keysService)
+	pixService := pix.NewService(transactionService, keysRepository, webhookService, chargeService)
+	scheduleService := schedule.NewService(scheduleRepository, keysRepository, pixService,
+		schedule.WithBatchSize(config.SchedulerConfig.BatchSize),
+		schedule.WithLease(config.SchedulerConfig.Lease))
+	mandateService := mandate.NewService(mandateRepository, keysRepository, pixService,
+		mandate.WithBatchSize(config.MandateConfig.BatchSize),
+		mandate.WithLease(config.MandateConfig.Lease),
+		mandate.WithRetry(config.MandateConfig.MaxAttempts, config.MandateConfig.RetryDelay))
 
-	pixService := pix.NewService(transactionService, keysRepository, webhookService)
+	eventTransaction := event.NewEvent(kafkaConn, "transaction_events_topic",
+		event.WithAttempts(4), event.WithBroker("localhost:9092"),
+		event.WithRetryPolicy(event.RetryPolicy{
+			Attempts:       config.ConsumerConfig.Attempts,
+			InitialBackoff: config.ConsumerConfig.InitialBackoff,
+			MaxBackoff:     config.ConsumerConfig.MaxBackoff,
+			TopicDelays:    config.ConsumerConfig.RetryTopicDelays,
+		}))
 
-	kafkaConn := kafka.NewClient(config).Connect()
+	eventKeys := event.NewEvent(kafkaConn, keys.EventsTopic,
+		event.WithAttempts(4), event.WithBroker("localhost:9092"),
+		event.WithRetryPolicy(event.RetryPolicy{
+			Attempts:       config.ConsumerConfig.Attempts,
+			InitialBackoff: config.ConsumerConfig.InitialBackoff,
+			MaxBackoff:     config.ConsumerConfig.MaxBackoff,
+			TopicDelays:    config.ConsumerConfig.RetryTopicDelays,
+		}))
 
-	eventTransaction := event.NewEvent(kafkaConn, "transaction_events_topic",
-		event.WithAttempts(4), event.WithBroker("localhost:9092"))
+	//server
+	transactionServer := newTransactionService(transactionService, keysService, pixService, eventTransaction, scheduleService, mandateService, brcodeService, chargeService, claimService, webhookService)
 
 	err = eventTransaction.RegisterHandler(context.Background(), pixService.Handler)
 	if err != nil {
 		panic(err)
 	}
 
+	err = eventKeys.RegisterHandler(context.Background(), keysService.Handler)
+	if err != nil {
+		panic(err)
+	}
+
+	go utils.Every(context.Background(), config.SchedulerConfig.Interval, "pix_scheduler", scheduleService.RunDue)
+	go utils.Every(context.Background(), config.MandateConfig.Interval, "mandate_runner", mandateService.RunDue)
+	go utils.Every(context.Background(), config.ClaimConfig.Interval, "claim_timer", claimService.RunDue)
+	go utils.Every(context.Background(), config.WebhookConfig.Interval, "webhook_retrier", webhookService.RunDue)
+
 	list, err := net.Listen("tcp", ":9090")
 	if err != nil {
 		log.Fatalf("Failed to listen port 9090 %v", err)
@@ -63,6 +123,13 @@ func main() {
 	server := grpc.NewServer()
 	proto.RegisterTransactionServiceServer(server, transactionServer)
 	proto.RegisterKeysServiceServer(server, transactionServer)
+	proto.RegisterDeadLetterServiceServer(server, transactionServer)
+	proto.RegisterScheduleServiceServer(server, transactionServer)
+	proto.RegisterMandateServiceServer(server, transactionServer)
+	proto.RegisterBRCodeServiceServer(server, transactionServer)
+	proto.RegisterChargeServiceServer(server, transactionServer)
+	proto.RegisterClaimServiceServer(server, transactionServer)
+	proto.RegisterWebhookServiceServer(server, transactionServer)
 
 	log.Printf("Serve is running  on port: %v", "9090")
 	if err := server.Serve(list); err != nil {
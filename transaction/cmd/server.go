@@ -2,19 +2,44 @@ package main
 
 import (
 	"context"
+	"errors"
 	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"transaction/internal/brcode"
+	"transaction/internal/charge"
+	"transaction/internal/claim"
+	"transaction/internal/errutils"
+	"transaction/internal/event"
 	keys "transaction/internal/keys"
+	"transaction/internal/mandate"
+	"transaction/internal/pix"
+	"transaction/internal/schedule"
 	"transaction/internal/transactions"
+	"transaction/internal/webhook"
 	proto "transaction/proto/v1"
 )
 
 type TransactionServer struct {
 	transaction transactions.Service
 	keys        keys.Service
+	pix         pix.Service
+	events      event.Client
+	schedules   schedule.Service
+	mandates    mandate.Service
+	brcodes     brcode.Service
+	charges     charge.Service
+	claims      claim.Service
+	webhooks    webhook.Service
 	proto.UnimplementedTransactionServiceServer
 	proto.UnimplementedKeysServiceServer
+	proto.UnimplementedDeadLetterServiceServer
+	proto.UnimplementedScheduleServiceServer
+	proto.UnimplementedMandateServiceServer
+	proto.UnimplementedBRCodeServiceServer
+	proto.UnimplementedChargeServiceServer
+	proto.UnimplementedClaimServiceServer
+	proto.UnimplementedWebhookServiceServer
 }
 
 func (t *TransactionServer) CreateTransaction(ctx context.Context, request *proto.Transaction) (*proto.Transaction, error) {
@@ -29,14 +54,29 @@ func (t *TransactionServer) CreateTransaction(ctx context.Context, request *prot
 }
 
 func (t *TransactionServer) FindTransactionById(ctx context.Context, transaction *proto.TransactionRequest) (*proto.Transaction, error) {
-	_, err := t.transaction.FindTransactionById(transactions.ProtoToTransactionRequest(transaction))
+	found, err := t.transaction.FindTransactionById(transactions.ProtoToTransactionRequest(transaction))
 	if err != nil {
 		switch err {
+		case errutils.ErrTransactionNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
 	}
-	return nil, nil
+	return transactions.ToProto(found), nil
+}
+
+func (t *TransactionServer) ListStatusTransitions(ctx context.Context, request *proto.TransactionRequest) (*proto.StatusTransitions, error) {
+	found, err := t.transaction.FindTransactionById(transactions.ProtoToTransactionRequest(request))
+	if err != nil {
+		switch err {
+		case errutils.ErrTransactionNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return transactions.TransitionsToProto(found), nil
 }
 
 func (t *TransactionServer) ListTransactions(ctx context.Context, transaction *proto.ListTransactionRequest) (*proto.ListTransaction, error) {
@@ -50,10 +90,50 @@ func (t *TransactionServer) ListTransactions(ctx context.Context, transaction *p
 	return nil, nil
 }
 
+func (t *TransactionServer) ListAccountTransactions(ctx context.Context, request *proto.AccountTransactionsRequest) (*proto.TransactionPage, error) {
+	history, err := t.transaction.ListAccountTransactions(ctx, transactions.ProtoToHistoryRequest(request))
+	if err != nil {
+		switch err {
+		case errutils.ErrInvalidHistoryFilter, errutils.ErrInvalidCursor:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return transactions.HistoryToProto(history), nil
+}
+
+func (t *TransactionServer) RefundTransaction(ctx context.Context, request *proto.RefundRequest) (*proto.Transaction, error) {
+	refund, err := t.pix.Refund(ctx, transactions.ProtoToRefundRequest(request))
+	if err != nil {
+		switch err {
+		case errutils.ErrTransactionNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errutils.ErrInvalidValue, errutils.ErrRefundExceedsValue:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errutils.ErrNotTransactionReceiver:
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errutils.ErrTransactionNotRefundable, errutils.ErrInvalidTransition:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errutils.ErrTransactionConflict:
+			return nil, status.Error(codes.Aborted, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return transactions.ToProto(refund), nil
+}
+
 func (t *TransactionServer) CreateKey(ctx context.Context, key *proto.Key) (*proto.KeyResponse, error) {
 	created, err := t.keys.CreateKey(ctx, keys.ProtoToKey(key))
 	if err != nil {
 		switch err {
+		case errutils.ErrInvalidKey, errutils.ErrKeyNotOwned:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errutils.ErrKeyAlreadyExists:
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case errutils.ErrKeyLimitReached:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -106,12 +186,387 @@ func (t *TransactionServer) FindKey(ctx context.Context, key *proto.KeyRequest)
 			return nil, status.Error(codes.Internal, err.Error())
 		}
 	}
+	if foundKey == nil {
+		return nil, status.Error(codes.NotFound, errutils.ErrKeyNotFound.Error())
+	}
 	return keys.ToProto(foundKey), nil
 }
 
-func newTransactionService(transactionService transactions.Service, keysService keys.Service) *TransactionServer {
+func (t *TransactionServer) ListAccountKeys(ctx context.Context, req *proto.AccountKeysRequest) (*proto.ListKeys, error) {
+	found, err := t.keys.ListAccountKeys(ctx, req.AccountId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return keys.ToProtoList(found), nil
+}
+
+func (t *TransactionServer) ListDeadLetters(ctx context.Context, req *proto.ListDeadLettersRequest) (*proto.DeadLetters, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 50
+	}
+
+	deadLetters, next, err := t.events.ListDeadLetters(ctx, req.GetOffset(), limit)
+	if err != nil {
+		switch err {
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return event.ToProtoList(deadLetters, next), nil
+}
+
+func (t *TransactionServer) ReplayDeadLetter(ctx context.Context, req *proto.ReplayDeadLetterRequest) (*proto.DeadLetter, error) {
+	deadLetter, err := t.events.ReplayDeadLetter(ctx, req.GetOffset())
+	if err != nil {
+		switch err {
+		case errutils.ErrDeadLetterNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return event.ToProto(deadLetter), nil
+}
+
+func (t *TransactionServer) SchedulePix(ctx context.Context, req *proto.ScheduledPix) (*proto.ScheduledPix, error) {
+	scheduled, err := t.schedules.Schedule(ctx, schedule.ProtoToSchedule(req))
+	if err != nil {
+		switch err {
+		case errutils.ErrInvalidValue, errutils.ErrInvalidExecutionDate, errutils.ErrInvalidKey:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errutils.ErrReceiverChanged:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errutils.ErrTransactionAlreadyExists:
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return schedule.ToProto(scheduled), nil
+}
+
+func (t *TransactionServer) ListScheduled(ctx context.Context, req *proto.ListScheduledPixRequest) (*proto.ListScheduledPix, error) {
+	scheduled, err := t.schedules.List(ctx, req.GetAccountId())
+	if err != nil {
+		switch err {
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return schedule.ToProtoList(scheduled), nil
+}
+
+func (t *TransactionServer) CancelScheduled(ctx context.Context, req *proto.CancelScheduledPixRequest) (*proto.ScheduledPix, error) {
+	canceled, err := t.schedules.Cancel(ctx, req.GetId(), req.GetAccountId())
+	if err != nil {
+		switch err {
+		case errutils.ErrScheduleNotFound:
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errutils.ErrScheduleNotCancelable:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return schedule.ToProto(canceled), nil
+}
+
+func (t *TransactionServer) CreateMandate(ctx context.Context, req *proto.Mandate) (*proto.Mandate, error) {
+	created, err := t.mandates.Create(ctx, mandate.ProtoToMandate(req))
+	if err != nil {
+		return nil, mandateError(err)
+	}
+	return mandate.ToProto(created), nil
+}
+
+func (t *TransactionServer) FindMandate(ctx context.Context, req *proto.MandateRequest) (*proto.Mandate, error) {
+	found, err := t.mandates.Find(ctx, mandate.ProtoToRequest(req))
+	if err != nil {
+		return nil, mandateError(err)
+	}
+	return mandate.ToProto(found), nil
+}
+
+func (t *TransactionServer) ListMandate(ctx context.Context, req *proto.ListMandatesRequest) (*proto.ListMandates, error) {
+	mandates, err := t.mandates.List(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, mandateError(err)
+	}
+	return mandate.ToProtoList(mandates), nil
+}
+
+func (t *TransactionServer) UpdateMandate(ctx context.Context, req *proto.Mandate) (*proto.Mandate, error) {
+	updated, err := t.mandates.Update(ctx, mandate.ProtoToMandate(req))
+	if err != nil {
+		return nil, mandateError(err)
+	}
+	return mandate.ToProto(updated), nil
+}
+
+func (t *TransactionServer) AuthorizeMandate(ctx context.Context, req *proto.MandateRequest) (*proto.Mandate, error) {
+	authorized, err := t.mandates.Authorize(ctx, mandate.ProtoToRequest(req))
+	if err != nil {
+		return nil, mandateError(err)
+	}
+	return mandate.ToProto(authorized), nil
+}
+
+func (t *TransactionServer) RevokeMandate(ctx context.Context, req *proto.MandateRequest) (*proto.Mandate, error) {
+	revoked, err := t.mandates.Revoke(ctx, mandate.ProtoToRequest(req))
+	if err != nil {
+		return nil, mandateError(err)
+	}
+	return mandate.ToProto(revoked), nil
+}
+
+func (t *TransactionServer) CreateBRCode(ctx context.Context, req *proto.BRCode) (*proto.BRCode, error) {
+	payload := brcode.ProtoToPayload(req)
+	code, err := t.brcodes.Create(ctx, payload)
+	if err != nil {
+		return nil, brcodeError(err)
+	}
+	return brcode.ToProto(payload, code), nil
+}
+
+func (t *TransactionServer) ParseBRCode(ctx context.Context, req *proto.BRCodeRequest) (*proto.BRCode, error) {
+	payload, err := t.brcodes.Parse(ctx, req.GetPayload())
+	if err != nil {
+		return nil, brcodeError(err)
+	}
+	return brcode.ToProto(payload, req.GetPayload()), nil
+}
+
+func (t *TransactionServer) CreateCharge(ctx context.Context, req *proto.Charge) (*proto.Charge, error) {
+	created, err := t.charges.Create(ctx, charge.ProtoToCharge(req))
+	if err != nil {
+		return nil, chargeError(err)
+	}
+	return charge.ToProto(created), nil
+}
+
+func (t *TransactionServer) FindCharge(ctx context.Context, req *proto.ChargeRequest) (*proto.Charge, error) {
+	found, err := t.charges.Find(ctx, charge.ProtoToRequest(req))
+	if err != nil {
+		return nil, chargeError(err)
+	}
+	return charge.ToProto(found), nil
+}
+
+func (t *TransactionServer) ListCharge(ctx context.Context, req *proto.ListChargesRequest) (*proto.ListCharges, error) {
+	charges, err := t.charges.List(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, chargeError(err)
+	}
+	return charge.ToProtoList(charges), nil
+}
+
+func (t *TransactionServer) CancelCharge(ctx context.Context, req *proto.ChargeRequest) (*proto.Charge, error) {
+	canceled, err := t.charges.Cancel(ctx, charge.ProtoToRequest(req))
+	if err != nil {
+		return nil, chargeError(err)
+	}
+	return charge.ToProto(canceled), nil
+}
+
+func (t *TransactionServer) ResolveCharge(ctx context.Context, req *proto.ResolveChargeRequest) (*proto.Charge, error) {
+	resolution := charge.ProtoToResolution(req)
+	resolved, err := t.charges.Resolve(ctx, resolution)
+	if err != nil {
+		return nil, chargeError(err)
+	}
+	return charge.ToProtoAt(resolved, resolution.At), nil
+}
+
+func (t *TransactionServer) OpenClaim(ctx context.Context, req *proto.Claim) (*proto.Claim, error) {
+	opened, err := t.claims.Open(ctx, claim.ProtoToClaim(req))
+	if err != nil {
+		return nil, claimError(err)
+	}
+	return claim.ToProto(opened), nil
+}
+
+func (t *TransactionServer) FindClaim(ctx context.Context, req *proto.ClaimRequest) (*proto.Claim, error) {
+	found, err := t.claims.Find(ctx, claim.ProtoToRequest(req))
+	if err != nil {
+		return nil, claimError(err)
+	}
+	return claim.ToProto(found), nil
+}
+
+func (t *TransactionServer) ListClaim(ctx context.Context, req *proto.ListClaimsRequest) (*proto.ListClaims, error) {
+	claims, err := t.claims.List(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, claimError(err)
+	}
+	return claim.ToProtoList(claims), nil
+}
+
+func (t *TransactionServer) ConfirmClaim(ctx context.Context, req *proto.ClaimRequest) (*proto.Claim, error) {
+	confirmed, err := t.claims.Confirm(ctx, claim.ProtoToRequest(req))
+	if err != nil {
+		return nil, claimError(err)
+	}
+	return claim.ToProto(confirmed), nil
+}
+
+func (t *TransactionServer) CancelClaim(ctx context.Context, req *proto.ClaimRequest) (*proto.Claim, error) {
+	cancelled, err := t.claims.Cancel(ctx, claim.ProtoToRequest(req))
+	if err != nil {
+		return nil, claimError(err)
+	}
+	return claim.ToProto(cancelled), nil
+}
+
+func (t *TransactionServer) ListDeliveries(ctx context.Context, req *proto.ListWebhookDeliveriesRequest) (*proto.WebhookDeliveries, error) {
+	deliveries, err := t.webhooks.List(ctx, webhook.ProtoToListRequest(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.ToProtoList(deliveries), nil
+}
+
+func (t *TransactionServer) RedeliverWebhook(ctx context.Context, req *proto.RedeliverWebhookRequest) (*proto.WebhookDelivery, error) {
+	delivery, err := t.webhooks.Redeliver(ctx, req.GetId())
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.ToProto(delivery), nil
+}
+
+func (t *TransactionServer) CreateSubscription(ctx context.Context, req *proto.WebhookSubscription) (*proto.WebhookSubscription, error) {
+	subscription, err := t.webhooks.Subscribe(ctx, webhook.ProtoToSubscription(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.SubscriptionToProto(subscription, true), nil
+}
+
+func (t *TransactionServer) ListSubscriptions(ctx context.Context, req *proto.ListSubscriptionsRequest) (*proto.WebhookSubscriptions, error) {
+	subscriptions, err := t.webhooks.ListSubscriptions(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.SubscriptionsToProto(subscriptions), nil
+}
+
+func (t *TransactionServer) SetSubscriptionEnabled(ctx context.Context, req *proto.SubscriptionRequest) (*proto.WebhookSubscription, error) {
+	subscription, err := t.webhooks.SetSubscriptionEnabled(ctx, webhook.ProtoToSubscriptionRequest(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.SubscriptionToProto(subscription, false), nil
+}
+
+func (t *TransactionServer) RotateSubscriptionSecret(ctx context.Context, req *proto.SubscriptionRequest) (*proto.WebhookSubscription, error) {
+	subscription, err := t.webhooks.RotateSecret(ctx, webhook.ProtoToSubscriptionRequest(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.SubscriptionToProto(subscription, true), nil
+}
+
+func (t *TransactionServer) PingSubscription(ctx context.Context, req *proto.SubscriptionRequest) (*proto.WebhookDelivery, error) {
+	delivery, err := t.webhooks.Ping(ctx, webhook.ProtoToSubscriptionRequest(req))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return webhook.ToProto(delivery), nil
+}
+
+func (t *TransactionServer) DeleteSubscription(ctx context.Context, req *proto.SubscriptionRequest) (*empty.Empty, error) {
+	if err := t.webhooks.Unsubscribe(ctx, webhook.ProtoToSubscriptionRequest(req)); err != nil {
+		return nil, webhookError(err)
+	}
+	return &empty.Empty{}, nil
+}
+
+func webhookError(err error) error {
+	switch err {
+	case errutils.ErrDeliveryNotFound, errutils.ErrSubscriptionNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errutils.ErrInvalidDeliveryFilter, errutils.ErrInvalidSubscription:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errutils.ErrDeliveryNotRetryable, errutils.ErrSubscriptionLimit:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errutils.ErrDeliveryConflict, errutils.ErrSubscriptionConflict:
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func claimError(err error) error {
+	switch err {
+	case errutils.ErrClaimNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errutils.ErrInvalidKey, errutils.ErrInvalidClaim, errutils.ErrKeyNotClaimable, errutils.ErrKeyNotOwned:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errutils.ErrKeyUnderClaim:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errutils.ErrClaimNotPending, errutils.ErrKeyLimitReached:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errutils.ErrClaimConflict:
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func chargeError(err error) error {
+	switch err {
+	case errutils.ErrChargeNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errutils.ErrChargeAlreadyExists:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errutils.ErrInvalidTxID, errutils.ErrInvalidValue, errutils.ErrInvalidKey, errutils.ErrInvalidChargeTerms:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errutils.ErrChargeNotActive:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errutils.ErrChargeConflict:
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func brcodeError(err error) error {
+	switch {
+	case errors.Is(err, errutils.ErrInvalidBRCode), errors.Is(err, errutils.ErrBRCodeChecksum),
+		errors.Is(err, errutils.ErrInvalidKey), errors.Is(err, errutils.ErrInvalidValue):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func mandateError(err error) error {
+	switch err {
+	case errutils.ErrMandateNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errutils.ErrInvalidValue, errutils.ErrAmountExceedsMax, errutils.ErrInvalidFrequency,
+		errutils.ErrInvalidMandatePeriod, errutils.ErrInvalidKey:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errutils.ErrMandateNotActive, errutils.ErrMandateNotPending:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errutils.ErrMandateConflict:
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func newTransactionService(transactionService transactions.Service, keysService keys.Service, pixService pix.Service, events event.Client, schedules schedule.Service, mandates mandate.Service, brcodes brcode.Service, charges charge.Service, claims claim.Service, webhooks webhook.Service) *TransactionServer {
 	return &TransactionServer{
 		transaction: transactionService,
 		keys:        keysService,
+		pix:         pixService,
+		events:      events,
+		schedules:   schedules,
+		mandates:    mandates,
+		brcodes:     brcodes,
+		charges:     charges,
+		claims:      claims,
+		webhooks:    webhooks,
 	}
 }
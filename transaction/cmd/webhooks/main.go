@@ -0,0 +1,82 @@
+// Command webhooks lists webhook deliveries and redelivers them through the
+// transaction gRPC server.
+//
+//	webhooks list [-transaction id] [-status PENDING|DELIVERED|FAILED] [-limit 50]
+//	webhooks redeliver -id delivery-id
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"log"
+	"os"
+	"time"
+	proto "transaction/proto/v1"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: webhooks <list|redeliver> [flags]")
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	addr := flags.String("addr", "localhost:9090", "transaction gRPC server address")
+	transactionID := flags.String("transaction", "", "transaction id")
+	deliveryStatus := flags.String("status", "", "delivery status")
+	limit := flags.Int("limit", 50, "maximum number of deliveries to list by status")
+	id := flags.String("id", "", "delivery id")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	conn, err := grpc.Dial(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to %s: %v", *addr, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := proto.NewWebhookServiceClient(conn)
+	switch os.Args[1] {
+	case "list":
+		resp, err := client.ListDeliveries(ctx, &proto.ListWebhookDeliveriesRequest{
+			TransactionId: *transactionID,
+			Status:        *deliveryStatus,
+			Limit:         int32(*limit),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, delivery := range resp.GetDeliveries() {
+			printDelivery(delivery)
+		}
+	case "redeliver":
+		delivery, err := client.RedeliverWebhook(ctx, &proto.RedeliverWebhookRequest{Id: *id})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print("redelivered ")
+		printDelivery(delivery)
+	default:
+		log.Fatalf("unknown command %q", os.Args[1])
+	}
+}
+
+func printDelivery(delivery *proto.WebhookDelivery) {
+	fmt.Printf("id=%s transaction=%s status=%s retryable=%t url=%s created_at=%s\n",
+		delivery.GetId(), delivery.GetTransactionId(), delivery.GetStatus(), delivery.GetRetryable(),
+		delivery.GetUrl(), delivery.GetCreatedAt().AsTime().Format(time.RFC3339))
+	for _, attempt := range delivery.GetAttempts() {
+		fmt.Printf("  #%d at=%s status_code=%d latency=%dms error=%q response=%q\n",
+			attempt.GetNumber(), attempt.GetAt().AsTime().Format(time.RFC3339), attempt.GetStatusCode(),
+			attempt.GetLatencyMs(), attempt.GetError(), attempt.GetResponse())
+	}
+	if delivery.GetNextAttemptAt() != nil {
+		fmt.Printf("  next attempt at %s\n", delivery.GetNextAttemptAt().AsTime().Format(time.RFC3339))
+	}
+}
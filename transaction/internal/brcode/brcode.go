@@ -0,0 +1,231 @@
+package brcode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"transaction/internal/errutils"
+)
+
+// EMV-MPM ids used by the BR Code. Only the ones a pix payment needs are read,
+// any other field is kept out of Payload and ignored.
+const (
+	idPayloadFormat      = "00"
+	idMerchantAccount    = "26"
+	idMerchantCategory   = "52"
+	idCurrency           = "53"
+	idAmount             = "54"
+	idCountry            = "58"
+	idMerchantName       = "59"
+	idMerchantCity       = "60"
+	idAdditionalData     = "62"
+	idCRC                = "63"
+	idGUI                = "00"
+	idKey                = "01"
+	idDescription        = "02"
+	idTxID               = "05"
+	gui                  = "br.gov.bcb.pix"
+	payloadFormat        = "01"
+	currencyBRL          = "986"
+	countryBR            = "BR"
+	noTxID               = "***"
+	maxMerchantNameLen   = 25
+	maxMerchantCityLen   = 15
+	maxTxIDLen           = 35
+	maxFieldValueLen     = 99
+	crcFieldPrefixLength = 4
+)
+
+// Payload is the pix data carried by a BR Code. A zero Amount lets the payer
+// choose the value, and an empty TxID is written as "***".
+type Payload struct {
+	Key          string
+	Amount       float64
+	TxID         string
+	MerchantName string
+	MerchantCity string
+	Description  string
+}
+
+// Encode builds the "copia e cola" string of the payload, ending with its
+// CRC16 in field 63.
+func Encode(payload *Payload) (string, error) {
+	name := normalize(payload.MerchantName)
+	city := normalize(payload.MerchantCity)
+	txID := payload.TxID
+	if txID == "" {
+		txID = noTxID
+	}
+
+	switch {
+	case payload.Key == "":
+		return "", fmt.Errorf("%w: key is required", errutils.ErrInvalidBRCode)
+	case name == "" || len(name) > maxMerchantNameLen:
+		return "", fmt.Errorf("%w: merchant name must have 1 to %d characters", errutils.ErrInvalidBRCode, maxMerchantNameLen)
+	case city == "" || len(city) > maxMerchantCityLen:
+		return "", fmt.Errorf("%w: merchant city must have 1 to %d characters", errutils.ErrInvalidBRCode, maxMerchantCityLen)
+	case len(txID) > maxTxIDLen || (txID != noTxID && !alphanumeric(txID)):
+		return "", fmt.Errorf("%w: txid must have up to %d letters or digits", errutils.ErrInvalidBRCode, maxTxIDLen)
+	case payload.Amount < 0:
+		return "", errutils.ErrInvalidValue
+	}
+
+	account := field(idGUI, gui) + field(idKey, payload.Key)
+	if payload.Description != "" {
+		account += field(idDescription, normalize(payload.Description))
+	}
+	if len(account) > maxFieldValueLen {
+		return "", fmt.Errorf("%w: key and description are too long", errutils.ErrInvalidBRCode)
+	}
+
+	var b strings.Builder
+	b.WriteString(field(idPayloadFormat, payloadFormat))
+	b.WriteString(field(idMerchantAccount, account))
+	b.WriteString(field(idMerchantCategory, "0000"))
+	b.WriteString(field(idCurrency, currencyBRL))
+	if payload.Amount > 0 {
+		b.WriteString(field(idAmount, strconv.FormatFloat(payload.Amount, 'f', 2, 64)))
+	}
+	b.WriteString(field(idCountry, countryBR))
+	b.WriteString(field(idMerchantName, name))
+	b.WriteString(field(idMerchantCity, city))
+	b.WriteString(field(idAdditionalData, field(idTxID, txID)))
+	b.WriteString(idCRC + "04")
+	b.WriteString(checksum(b.String()))
+	return b.String(), nil
+}
+
+// Parse reads a "copia e cola" string, rejecting it when the CRC does not
+// match or it does not carry a pix key.
+func Parse(code string) (*Payload, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < crcFieldPrefixLength+4 {
+		return nil, errutils.ErrInvalidBRCode
+	}
+
+	body, crc := code[:len(code)-4], code[len(code)-4:]
+	if !strings.HasSuffix(body, idCRC+"04") {
+		return nil, errutils.ErrInvalidBRCode
+	}
+	if !strings.EqualFold(checksum(body), crc) {
+		return nil, errutils.ErrBRCodeChecksum
+	}
+
+	fields, err := parseFields(body[:len(body)-crcFieldPrefixLength])
+	if err != nil {
+		return nil, err
+	}
+	if fields[idPayloadFormat] != payloadFormat {
+		return nil, errutils.ErrInvalidBRCode
+	}
+	if currency, ok := fields[idCurrency]; ok && currency != currencyBRL {
+		return nil, fmt.Errorf("%w: unsupported currency %s", errutils.ErrInvalidBRCode, currency)
+	}
+
+	account, err := parseFields(fields[idMerchantAccount])
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(account[idGUI], gui) || account[idKey] == "" {
+		return nil, fmt.Errorf("%w: not a pix key code", errutils.ErrInvalidBRCode)
+	}
+
+	payload := &Payload{
+		Key:          account[idKey],
+		Description:  account[idDescription],
+		MerchantName: fields[idMerchantName],
+		MerchantCity: fields[idMerchantCity],
+	}
+
+	if amount, ok := fields[idAmount]; ok {
+		payload.Amount, err = strconv.ParseFloat(amount, 64)
+		if err != nil || payload.Amount < 0 {
+			return nil, fmt.Errorf("%w: invalid amount", errutils.ErrInvalidBRCode)
+		}
+	}
+
+	if additional, ok := fields[idAdditionalData]; ok {
+		data, err := parseFields(additional)
+		if err != nil {
+			return nil, err
+		}
+		if txID := data[idTxID]; txID != noTxID {
+			payload.TxID = txID
+		}
+	}
+	return payload, nil
+}
+
+func field(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// parseFields splits a sequence of id, two digit length and value.
+func parseFields(data string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errutils.ErrInvalidBRCode
+		}
+		size, err := strconv.Atoi(data[2:4])
+		if err != nil || len(data) < 4+size {
+			return nil, errutils.ErrInvalidBRCode
+		}
+		fields[data[:2]] = data[4 : 4+size]
+		data = data[4+size:]
+	}
+	return fields, nil
+}
+
+// checksum is the CRC16-CCITT (polynomial 0x1021, initial value 0xFFFF) the
+// BR Code requires, as four uppercase hex digits.
+func checksum(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"Ç", "C", "Ñ", "N",
+)
+
+// normalize keeps free text within the ASCII set readers expect, since
+// lengths are counted in bytes.
+func normalize(text string) string {
+	text = accents.Replace(strings.TrimSpace(text))
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7E {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func alphanumeric(text string) bool {
+	for _, r := range text {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package brcode
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"transaction/internal/errutils"
+)
+
+func TestChecksum(t *testing.T) {
+	assert.Equal(t, "29B1", checksum("123456789"))
+}
+
+func TestEncode(t *testing.T) {
+	code, err := Encode(&Payload{
+		Key:          "123e4567-e12b-12d1-a456-426655440000",
+		Amount:       10.5,
+		TxID:         "pedido123",
+		MerchantName: "Padaria São João",
+		MerchantCity: "Brasília",
+	})
+	require.NoError(t, err)
+
+	want := "000201" +
+		"26580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-426655440000" +
+		"52040000" + "5303986" + "540510.50" + "5802BR" +
+		"5916Padaria Sao Joao" + "6008Brasilia" +
+		"62130509pedido123" + "6304"
+	assert.Equal(t, want+checksum(want), code)
+}
+
+func TestEncodeValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload *Payload
+	}{
+		{name: "missing key", payload: &Payload{MerchantName: "Fulano", MerchantCity: "Sao Paulo"}},
+		{name: "merchant name too long", payload: &Payload{Key: "k", MerchantName: "Fulano de Tal da Silva Sauro", MerchantCity: "Sao Paulo"}},
+		{name: "missing city", payload: &Payload{Key: "k", MerchantName: "Fulano"}},
+		{name: "txid with symbols", payload: &Payload{Key: "k", MerchantName: "Fulano", MerchantCity: "Sao Paulo", TxID: "order-1"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Encode(tc.payload)
+			assert.True(t, errors.Is(err, errutils.ErrInvalidBRCode), err)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	static, err := Encode(&Payload{Key: "fulano@pix.com", MerchantName: "Fulano", MerchantCity: "Sao Paulo", Description: "Aluguel"})
+	require.NoError(t, err)
+
+	withAmount, err := Encode(&Payload{Key: "fulano@pix.com", Amount: 99.9, TxID: "abc123", MerchantName: "Fulano", MerchantCity: "Sao Paulo"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		code string
+		want *Payload
+		err  error
+	}{
+		{
+			name: "static code without amount",
+			code: static,
+			want: &Payload{Key: "fulano@pix.com", MerchantName: "Fulano", MerchantCity: "Sao Paulo", Description: "Aluguel"},
+		},
+		{
+			name: "code with amount and txid",
+			code: withAmount,
+			want: &Payload{Key: "fulano@pix.com", Amount: 99.9, TxID: "abc123", MerchantName: "Fulano", MerchantCity: "Sao Paulo"},
+		},
+		{
+			name: "lowercase checksum and surrounding spaces are accepted",
+			code: " " + withAmount[:len(withAmount)-4] + strings.ToLower(withAmount[len(withAmount)-4:]) + "\n",
+			want: &Payload{Key: "fulano@pix.com", Amount: 99.9, TxID: "abc123", MerchantName: "Fulano", MerchantCity: "Sao Paulo"},
+		},
+		{
+			name: "tampered amount",
+			code: strings.Replace(withAmount, "540599.90", "540599.99", 1),
+			err:  errutils.ErrBRCodeChecksum,
+		},
+		{
+			name: "not a br code",
+			code: "hello",
+			err:  errutils.ErrInvalidBRCode,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.code)
+			assert.True(t, errors.Is(err, tc.err), err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package brcode
+
+import proto "transaction/proto/v1"
+
+func ProtoToPayload(code *proto.BRCode) *Payload {
+	return &Payload{
+		Key:          code.Key,
+		Amount:       code.Amount,
+		TxID:         code.Txid,
+		MerchantName: code.MerchantName,
+		MerchantCity: code.MerchantCity,
+		Description:  code.Description,
+	}
+}
+
+func ToProto(payload *Payload, code string) *proto.BRCode {
+	return &proto.BRCode{
+		Key:          payload.Key,
+		Amount:       payload.Amount,
+		Txid:         payload.TxID,
+		MerchantName: payload.MerchantName,
+		MerchantCity: payload.MerchantCity,
+		Description:  payload.Description,
+		Payload:      code,
+	}
+}
@@ -0,0 +1,39 @@
+package brcode
+
+import (
+	"context"
+	"transaction/internal/errutils"
+	keys "transaction/internal/keys"
+)
+
+type Service interface {
+	Create(ctx context.Context, payload *Payload) (string, error)
+	Parse(ctx context.Context, code string) (*Payload, error)
+}
+
+type service struct {
+	keysRepo keys.Repository
+}
+
+// Create encodes a code for a key registered here, so a code is never handed
+// out for a key nobody can receive with.
+func (s *service) Create(ctx context.Context, payload *Payload) (string, error) {
+	key, err := s.keysRepo.FindKey(ctx, payload.Key)
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", errutils.ErrInvalidKey
+	}
+	return Encode(payload)
+}
+
+func (s *service) Parse(ctx context.Context, code string) (*Payload, error) {
+	return Parse(code)
+}
+
+func NewService(keysRepo keys.Repository) Service {
+	return &service{
+		keysRepo: keysRepo,
+	}
+}
@@ -2,36 +2,149 @@ package cfg
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type DynamodbConfig struct {
-	Host             string
-	Port             string
-	TransactionTable string
-	KeysTable        string
+	Host              string
+	Port              string
+	TransactionTable  string
+	KeysTable         string
+	ScheduleTable     string
+	MandateTable      string
+	ClaimTable        string
+	WebhookTable      string
+	SubscriptionTable string
 }
 
 type KafkaConfig struct {
 	Brokers []string
 }
 
+type ConsumerConfig struct {
+	Attempts         int
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	RetryTopicDelays []time.Duration
+}
+
+// SchedulerConfig controls how often due scheduled pix are picked and how long
+// a claimed item may stay in processing before another run takes it over.
+type SchedulerConfig struct {
+	Interval  time.Duration
+	BatchSize int
+	Lease     time.Duration
+}
+
+// MandateConfig controls the recurring pix runner. A failed cycle is tried
+// MaxAttempts times, RetryDelay apart, before it is given up.
+type MandateConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// ClaimConfig controls the key claim timer. The donor of a key has
+// ResolutionWindow to confirm or cancel a claim before it is confirmed on its
+// behalf, and a confirmed claim whose key move did not finish is retried
+// after Lease.
+type ClaimConfig struct {
+	Interval         time.Duration
+	BatchSize        int
+	ResolutionWindow time.Duration
+	Lease            time.Duration
+}
+
+// WebhookConfig controls webhook delivery. Send tries SendAttempts times,
+// SendBackoff apart, as its caller waits for the outcome; retryable
+// deliveries are retried by the worker up to MaxAttempts, backing off
+// exponentially from InitialBackoff to MaxBackoff. Every request is signed
+// with Secret and given up after Timeout. An account may have up to
+// MaxSubscriptions subscriptions, and a rotated subscription secret keeps
+// signing deliveries for RotationGrace.
+type WebhookConfig struct {
+	Secret           string
+	Timeout          time.Duration
+	SendAttempts     int
+	SendBackoff      time.Duration
+	Interval         time.Duration
+	BatchSize        int
+	Lease            time.Duration
+	MaxAttempts      int
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	MaxSubscriptions int
+	RotationGrace    time.Duration
+}
+
 type Config struct {
-	DynamodbConfig DynamodbConfig
-	KafkaConfig    KafkaConfig
+	DynamodbConfig  DynamodbConfig
+	KafkaConfig     KafkaConfig
+	ConsumerConfig  ConsumerConfig
+	SchedulerConfig SchedulerConfig
+	MandateConfig   MandateConfig
+	ClaimConfig     ClaimConfig
+	WebhookConfig   WebhookConfig
 }
 
 func Load() (*Config, error) {
 	return &Config{
 		DynamodbConfig{
-			Host:             Getenv("DB_HOST", "localhost"),
-			Port:             Getenv("DB_PORT", "8000"),
-			TransactionTable: Getenv("TRANSACTION_TABLE", "transaction"),
-			KeysTable:        Getenv("KEYS_TABLE", "keys"),
+			Host:              Getenv("DB_HOST", "localhost"),
+			Port:              Getenv("DB_PORT", "8000"),
+			TransactionTable:  Getenv("TRANSACTION_TABLE", "transaction"),
+			KeysTable:         Getenv("KEYS_TABLE", "keys"),
+			ScheduleTable:     Getenv("SCHEDULE_TABLE", "schedule"),
+			MandateTable:      Getenv("MANDATE_TABLE", "mandate"),
+			ClaimTable:        Getenv("CLAIM_TABLE", "claim"),
+			WebhookTable:      Getenv("WEBHOOK_TABLE", "webhook"),
+			SubscriptionTable: Getenv("WEBHOOK_SUBSCRIPTION_TABLE", "webhook_subscription"),
 		},
 		KafkaConfig{
 			Brokers: strings.Split(Getenv("KAFKA_ADVERTISED_LISTENERS", "localhost:9092"), ","),
 		},
+		ConsumerConfig{
+			Attempts:         GetInt("CONSUMER_ATTEMPTS", 3),
+			InitialBackoff:   GetDuration("CONSUMER_INITIAL_BACKOFF", 200*time.Millisecond),
+			MaxBackoff:       GetDuration("CONSUMER_MAX_BACKOFF", 5*time.Second),
+			RetryTopicDelays: GetDurations("CONSUMER_RETRY_TOPIC_DELAYS", []time.Duration{30 * time.Second, 5 * time.Minute}),
+		},
+		SchedulerConfig{
+			Interval:  GetDuration("SCHEDULER_INTERVAL", 30*time.Second),
+			BatchSize: GetInt("SCHEDULER_BATCH_SIZE", 100),
+			Lease:     GetDuration("SCHEDULER_LEASE", 5*time.Minute),
+		},
+		MandateConfig{
+			Interval:    GetDuration("MANDATE_INTERVAL", time.Minute),
+			BatchSize:   GetInt("MANDATE_BATCH_SIZE", 100),
+			Lease:       GetDuration("MANDATE_LEASE", 5*time.Minute),
+			MaxAttempts: GetInt("MANDATE_MAX_ATTEMPTS", 3),
+			RetryDelay:  GetDuration("MANDATE_RETRY_DELAY", time.Hour),
+		},
+		ClaimConfig{
+			Interval:         GetDuration("CLAIM_INTERVAL", time.Minute),
+			BatchSize:        GetInt("CLAIM_BATCH_SIZE", 100),
+			ResolutionWindow: GetDuration("CLAIM_RESOLUTION_WINDOW", 7*24*time.Hour),
+			Lease:            GetDuration("CLAIM_LEASE", 5*time.Minute),
+		},
+		WebhookConfig{
+			Secret:           Getenv("WEBHOOK_SECRET", ""),
+			Timeout:          GetDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			SendAttempts:     GetInt("WEBHOOK_SEND_ATTEMPTS", 3),
+			SendBackoff:      GetDuration("WEBHOOK_SEND_BACKOFF", 200*time.Millisecond),
+			Interval:         GetDuration("WEBHOOK_INTERVAL", 15*time.Second),
+			BatchSize:        GetInt("WEBHOOK_BATCH_SIZE", 100),
+			Lease:            GetDuration("WEBHOOK_LEASE", time.Minute),
+			MaxAttempts:      GetInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			InitialBackoff:   GetDuration("WEBHOOK_INITIAL_BACKOFF", 30*time.Second),
+			MaxBackoff:       GetDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+			MaxSubscriptions: GetInt("WEBHOOK_MAX_SUBSCRIPTIONS", 10),
+			RotationGrace:    GetDuration("WEBHOOK_ROTATION_GRACE", 24*time.Hour),
+		},
 	}, nil
 }
 
@@ -41,3 +154,35 @@ func Getenv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func GetInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// GetDurations reads a comma separated list such as "30s,5m".
+func GetDurations(key string, fallback []time.Duration) []time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return fallback
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
@@ -0,0 +1,112 @@
+package charge
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type Modality string
+
+const (
+	ModalityFixed   Modality = "FIXED"
+	ModalityPercent Modality = "PERCENT"
+)
+
+// dateLayout is the format of due and discount dates. Dates are days in
+// Brasília time, which has no daylight saving time.
+const dateLayout = "2006-01-02"
+
+var billingZone = time.FixedZone("BRT", -3*60*60)
+
+// Adjustment is a fine, a daily interest or a rebate, either a fixed value or
+// a percentage of the charge amount.
+type Adjustment struct {
+	Modality Modality
+	Value    decimal.Decimal `dynamodbav:"-"`
+}
+
+// Discount is taken when the charge is paid until the day Until.
+type Discount struct {
+	Until    string
+	Modality Modality
+	Value    decimal.Decimal `dynamodbav:"-"`
+}
+
+var hundred = decimal.NewFromInt(100)
+
+func (a Adjustment) of(base decimal.Decimal) decimal.Decimal {
+	if a.Modality == ModalityPercent {
+		return base.Mul(a.Value).Div(hundred)
+	}
+	return a.Value
+}
+
+func (a Adjustment) valid() bool {
+	switch a.Modality {
+	case "":
+		return a.Value.IsZero()
+	case ModalityFixed:
+		return !a.Value.IsNegative()
+	case ModalityPercent:
+		return !a.Value.IsNegative() && a.Value.LessThanOrEqual(hundred)
+	}
+	return false
+}
+
+func (a Adjustment) isZero() bool {
+	return a.Modality == "" && a.Value.IsZero()
+}
+
+// HasDueDate tells a due date charge (cobv) from an immediate one.
+func (c *Charge) HasDueDate() bool {
+	return c.DueDate != ""
+}
+
+// AmountDue is the value a due date charge takes when paid at now: the rebate
+// always applies, the best discount still valid applies until the due date,
+// and after it the fine and the interest for each late day are added. The
+// adjustments are computed over the amount after the rebate.
+func (c *Charge) AmountDue(now time.Time) decimal.Decimal {
+	amount := c.Amount
+	if !c.HasDueDate() {
+		return amount
+	}
+
+	amount = amount.Sub(c.Rebate.of(amount))
+	today := day(now)
+	lateDays := int64(today.Sub(parseDay(c.DueDate)).Hours() / 24)
+
+	if lateDays <= 0 {
+		var best decimal.Decimal
+		for _, discount := range c.Discounts {
+			if today.After(parseDay(discount.Until)) {
+				continue
+			}
+			value := Adjustment{Modality: discount.Modality, Value: discount.Value}.of(amount)
+			if value.GreaterThan(best) {
+				best = value
+			}
+		}
+		amount = amount.Sub(best)
+	} else {
+		amount = amount.Add(c.Fine.of(amount)).
+			Add(c.Interest.of(amount).Mul(decimal.NewFromInt(lateDays)))
+	}
+
+	if amount.IsNegative() {
+		return decimal.Zero
+	}
+	return amount.Round(2)
+}
+
+// day truncates t to the start of its day in billingZone.
+func day(t time.Time) time.Time {
+	year, month, d := t.In(billingZone).Date()
+	return time.Date(year, month, d, 0, 0, 0, 0, billingZone)
+}
+
+// parseDay reads a date already validated on creation.
+func parseDay(date string) time.Time {
+	t, _ := time.ParseInLocation(dateLayout, date, billingZone)
+	return t
+}
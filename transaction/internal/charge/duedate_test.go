@@ -0,0 +1,51 @@
+package charge
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func dueCharge() *Charge {
+	return &Charge{
+		Amount:        decimal.NewFromInt(100),
+		Status:        StatusActive,
+		DueDate:       "2024-01-20",
+		ValidAfterDue: 10,
+		Fine:          Adjustment{Modality: ModalityPercent, Value: decimal.NewFromInt(2)},
+		Interest:      Adjustment{Modality: ModalityPercent, Value: decimal.RequireFromString("0.1")},
+		Rebate:        Adjustment{Modality: ModalityFixed, Value: decimal.NewFromInt(5)},
+		Discounts: []Discount{
+			{Until: "2024-01-10", Modality: ModalityFixed, Value: decimal.NewFromInt(10)},
+			{Until: "2024-01-15", Modality: ModalityPercent, Value: decimal.NewFromInt(5)},
+		},
+	}
+}
+
+func TestAmountDue(t *testing.T) {
+	cases := []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{name: "best discount before both discount dates", now: time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC), want: "85"},
+		{name: "only the later discount is still valid", now: time.Date(2024, 1, 12, 12, 0, 0, 0, time.UTC), want: "90.25"},
+		{name: "on the due date only the rebate applies", now: time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC), want: "95"},
+		{name: "due date ends in brasilia time", now: time.Date(2024, 1, 21, 2, 0, 0, 0, time.UTC), want: "95"},
+		{name: "fine and three days of interest", now: time.Date(2024, 1, 23, 12, 0, 0, 0, time.UTC), want: "97.19"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := dueCharge().AmountDue(tc.now)
+			assert.Equal(t, tc.want, got.String())
+		})
+	}
+}
+
+func TestDueChargeExpired(t *testing.T) {
+	charge := dueCharge()
+	assert.False(t, charge.Expired(time.Date(2024, 1, 31, 2, 59, 0, 0, time.UTC)))
+	assert.True(t, charge.Expired(time.Date(2024, 1, 31, 3, 0, 0, 0, time.UTC)))
+}
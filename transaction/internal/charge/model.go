@@ -0,0 +1,195 @@
+package charge
+
+import (
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	proto "transaction/proto/v1"
+)
+
+type Status string
+
+const (
+	StatusActive   Status = "ACTIVE"
+	StatusPaid     Status = "PAID"
+	StatusExpired  Status = "EXPIRED"
+	StatusCanceled Status = "CANCELED"
+)
+
+// keyPrefix keeps charges apart from transactions in the transaction table.
+const keyPrefix = "cob:"
+
+type Payer struct {
+	Cpf  string
+	Name string
+}
+
+// Charge is a charge the payer settles with a pix carrying its TxID. An
+// immediate charge (cob) expires Expiration seconds after created, a charge
+// with DueDate (cobv) is payable until ValidAfterDue days after it. Revision
+// grows on every change and guards concurrent updates. The repository writes
+// the amounts, which are decimals, as numbers.
+type Charge struct {
+	PK            string `dynamodbav:"PK"`
+	TxID          string
+	ChargeAccount int64
+	Key           string
+	Amount        decimal.Decimal `dynamodbav:"-"`
+	Expiration    int64
+	Payer         Payer
+	PayerRequest  string
+	Status        Status
+	TransactionID string
+	Revision      int
+	DueDate       string
+	ValidAfterDue int
+	Fine          Adjustment
+	Interest      Adjustment
+	Rebate        Adjustment
+	Discounts     []Discount
+	PaidAt        time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (c *Charge) ExpiresAt() time.Time {
+	if c.HasDueDate() {
+		return parseDay(c.DueDate).AddDate(0, 0, c.ValidAfterDue+1)
+	}
+	return c.CreatedAt.Add(time.Duration(c.Expiration) * time.Second)
+}
+
+// Expired reports whether an active charge can no longer be paid. The status
+// is not written back, an active charge past its expiration reads as expired.
+func (c *Charge) Expired(now time.Time) bool {
+	return c.Status == StatusActive && !now.Before(c.ExpiresAt())
+}
+
+type Request struct {
+	TxID      string
+	AccountID int64
+}
+
+// Resolution asks for a charge as its payer sees it, for a pix paid at At.
+type Resolution struct {
+	TxID     string
+	PayerCpf string
+	At       time.Time
+}
+
+func ProtoToResolution(request *proto.ResolveChargeRequest) *Resolution {
+	resolution := &Resolution{
+		TxID:     request.Txid,
+		PayerCpf: request.PayerCpf,
+	}
+	if request.PayAt != nil {
+		resolution.At = request.PayAt.AsTime()
+	}
+	return resolution
+}
+
+func ProtoToRequest(request *proto.ChargeRequest) *Request {
+	return &Request{
+		TxID:      request.Txid,
+		AccountID: request.AccountId,
+	}
+}
+
+func ProtoToCharge(charge *proto.Charge) *Charge {
+	discounts := make([]Discount, len(charge.Discounts))
+	for i, discount := range charge.Discounts {
+		discounts[i] = Discount{
+			Until:    discount.Until,
+			Modality: Modality(discount.Modality),
+			Value:    decimal.NewFromFloat(discount.Value),
+		}
+	}
+
+	return &Charge{
+		TxID:          charge.Txid,
+		ChargeAccount: charge.AccountId,
+		Key:           charge.Key,
+		Amount:        decimal.NewFromFloat(charge.Amount),
+		Expiration:    charge.Expiration,
+		Payer: Payer{
+			Cpf:  charge.GetPayer().GetCpf(),
+			Name: charge.GetPayer().GetName(),
+		},
+		PayerRequest:  charge.PayerRequest,
+		DueDate:       charge.DueDate,
+		ValidAfterDue: int(charge.ValidAfterDue),
+		Fine:          protoToAdjustment(charge.Fine),
+		Interest:      protoToAdjustment(charge.Interest),
+		Rebate:        protoToAdjustment(charge.Rebate),
+		Discounts:     discounts,
+	}
+}
+
+func protoToAdjustment(adjustment *proto.ChargeAdjustment) Adjustment {
+	return Adjustment{
+		Modality: Modality(adjustment.GetModality()),
+		Value:    decimal.NewFromFloat(adjustment.GetValue()),
+	}
+}
+
+func adjustmentToProto(adjustment Adjustment) *proto.ChargeAdjustment {
+	if adjustment.Modality == "" {
+		return nil
+	}
+	return &proto.ChargeAdjustment{Modality: string(adjustment.Modality), Value: adjustment.Value.InexactFloat64()}
+}
+
+// ToProto fills AmountDue with the value paid, or the value the charge takes
+// if paid now.
+func ToProto(charge *Charge) *proto.Charge {
+	return ToProtoAt(charge, time.Now())
+}
+
+// ToProtoAt fills AmountDue with the value paid, or the value the charge
+// takes if paid at now.
+func ToProtoAt(charge *Charge, now time.Time) *proto.Charge {
+	var paidAt *timestamppb.Timestamp
+	if !charge.PaidAt.IsZero() {
+		now = charge.PaidAt
+		paidAt = timestamppb.New(charge.PaidAt)
+	}
+
+	discounts := make([]*proto.ChargeDiscount, len(charge.Discounts))
+	for i, discount := range charge.Discounts {
+		discounts[i] = &proto.ChargeDiscount{
+			Until:    discount.Until,
+			Modality: string(discount.Modality),
+			Value:    discount.Value.InexactFloat64(),
+		}
+	}
+
+	return &proto.Charge{
+		Txid:          charge.TxID,
+		AccountId:     charge.ChargeAccount,
+		Key:           charge.Key,
+		Amount:        charge.Amount.InexactFloat64(),
+		Expiration:    charge.Expiration,
+		Payer:         &proto.Payer{Cpf: charge.Payer.Cpf, Name: charge.Payer.Name},
+		PayerRequest:  charge.PayerRequest,
+		Status:        string(charge.Status),
+		TransactionId: charge.TransactionID,
+		Revision:      int32(charge.Revision),
+		CreatedAt:     timestamppb.New(charge.CreatedAt),
+		PaidAt:        paidAt,
+		DueDate:       charge.DueDate,
+		ValidAfterDue: int32(charge.ValidAfterDue),
+		Fine:          adjustmentToProto(charge.Fine),
+		Interest:      adjustmentToProto(charge.Interest),
+		Rebate:        adjustmentToProto(charge.Rebate),
+		Discounts:     discounts,
+		AmountDue:     charge.AmountDue(now).InexactFloat64(),
+	}
+}
+
+func ToProtoList(charges []*Charge) *proto.ListCharges {
+	list := make([]*proto.Charge, len(charges))
+	for i := range charges {
+		list[i] = ToProto(charges[i])
+	}
+	return &proto.ListCharges{Charges: list}
+}
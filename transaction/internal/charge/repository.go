@@ -0,0 +1,198 @@
+package charge
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/shopspring/decimal"
+	"transaction/internal/cfg"
+	"transaction/internal/errutils"
+	"transaction/platform/dynamo"
+)
+
+// Charges live in the transaction table. Only charges have ChargeAccount, so
+// the ChargeAccountIndex global secondary index (ChargeAccount, CreatedAt)
+// holds charges alone.
+const chargeAccountIndex = "ChargeAccountIndex"
+
+type Repository interface {
+	CreateCharge(ctx context.Context, charge *Charge) error
+	FindCharge(ctx context.Context, txID string) (*Charge, error)
+	ListCharges(ctx context.Context, accountID int64) ([]*Charge, error)
+	UpdateCharge(ctx context.Context, charge *Charge, revision int) error
+}
+
+type repository struct {
+	db  dynamo.Client
+	cfg *cfg.Config
+}
+
+func (r *repository) CreateCharge(ctx context.Context, charge *Charge) error {
+	charge.PK = keyPrefix + charge.TxID
+	err := r.put(ctx, charge, expression.AttributeNotExists(expression.Name("PK")))
+	if errors.Is(err, errutils.ErrChargeConflict) {
+		return errutils.ErrChargeAlreadyExists
+	}
+	return err
+}
+
+// UpdateCharge replaces the charge only while it still has the revision the
+// caller read, so a payment and a cancellation can not both succeed.
+func (r *repository) UpdateCharge(ctx context.Context, charge *Charge, revision int) error {
+	return r.put(ctx, charge, expression.Name("Revision").Equal(expression.Value(revision)))
+}
+
+func (r *repository) put(ctx context.Context, charge *Charge, cond expression.ConditionBuilder) error {
+	value, err := marshalCharge(charge)
+	if err != nil {
+		return err
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.TransactionTable),
+		Item:                      value,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrChargeConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) FindCharge(ctx context.Context, txID string) (*Charge, error) {
+	value, err := r.db.DB().GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.cfg.DynamodbConfig.TransactionTable),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: keyPrefix + txID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value.Item == nil {
+		return nil, errutils.ErrChargeNotFound
+	}
+
+	return unmarshalCharge(value.Item)
+}
+
+func (r *repository) ListCharges(ctx context.Context, accountID int64) ([]*Charge, error) {
+	keyCond := expression.Key("ChargeAccount").Equal(expression.Value(accountID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	value, err := r.db.DB().Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.TransactionTable),
+		IndexName:                 aws.String(chargeAccountIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	charges := make([]*Charge, len(value.Items))
+	for i, item := range value.Items {
+		if charges[i], err = unmarshalCharge(item); err != nil {
+			return nil, err
+		}
+	}
+	return charges, nil
+}
+
+// marshalCharge writes the amounts, which attributevalue can not encode, as
+// numbers: Amount, and Value inside the adjustments and each discount.
+func marshalCharge(charge *Charge) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(charge)
+	if err != nil {
+		return nil, err
+	}
+
+	item["Amount"] = &types.AttributeValueMemberN{Value: charge.Amount.String()}
+	setValue(item["Fine"], charge.Fine.Value)
+	setValue(item["Interest"], charge.Interest.Value)
+	setValue(item["Rebate"], charge.Rebate.Value)
+	if discounts, ok := item["Discounts"].(*types.AttributeValueMemberL); ok {
+		for i, discount := range charge.Discounts {
+			setValue(discounts.Value[i], discount.Value)
+		}
+	}
+	return item, nil
+}
+
+func setValue(attribute types.AttributeValue, value decimal.Decimal) {
+	if m, ok := attribute.(*types.AttributeValueMemberM); ok {
+		m.Value["Value"] = &types.AttributeValueMemberN{Value: value.String()}
+	}
+}
+
+func unmarshalCharge(item map[string]types.AttributeValue) (*Charge, error) {
+	var charge Charge
+	err := attributevalue.UnmarshalMap(item, &charge)
+	if err != nil {
+		return nil, err
+	}
+
+	if charge.Amount, err = number(item["Amount"]); err != nil {
+		return nil, err
+	}
+	if charge.Fine.Value, err = valueOf(item["Fine"]); err != nil {
+		return nil, err
+	}
+	if charge.Interest.Value, err = valueOf(item["Interest"]); err != nil {
+		return nil, err
+	}
+	if charge.Rebate.Value, err = valueOf(item["Rebate"]); err != nil {
+		return nil, err
+	}
+	if discounts, ok := item["Discounts"].(*types.AttributeValueMemberL); ok {
+		for i := range charge.Discounts {
+			if charge.Discounts[i].Value, err = valueOf(discounts.Value[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &charge, nil
+}
+
+// valueOf reads Value of an adjustment or a discount.
+func valueOf(attribute types.AttributeValue) (decimal.Decimal, error) {
+	m, ok := attribute.(*types.AttributeValueMemberM)
+	if !ok {
+		return decimal.Zero, nil
+	}
+	return number(m.Value["Value"])
+}
+
+func number(attribute types.AttributeValue) (decimal.Decimal, error) {
+	n, ok := attribute.(*types.AttributeValueMemberN)
+	if !ok {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(n.Value)
+}
+
+func NewRepository(db dynamo.Client, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
@@ -0,0 +1,25 @@
+package charge
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMarshalCharge(t *testing.T) {
+	charge := dueCharge()
+
+	item, err := marshalCharge(charge)
+	require.NoError(t, err)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "100"}, item["Amount"])
+
+	got, err := unmarshalCharge(item)
+	require.NoError(t, err)
+
+	// the amounts are read back exactly as they were written
+	assert.True(t, charge.Amount.Equal(got.Amount))
+	assert.True(t, charge.Interest.Value.Equal(got.Interest.Value))
+	assert.True(t, charge.Discounts[1].Value.Equal(got.Discounts[1].Value))
+	assert.Equal(t, charge.AmountDue(parseDay("2024-01-25")), got.AmountDue(parseDay("2024-01-25")))
+}
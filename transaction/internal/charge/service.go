@@ -0,0 +1,240 @@
+package charge
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"regexp"
+	"strings"
+	"time"
+	"transaction/internal/errutils"
+	keys "transaction/internal/keys"
+)
+
+// txIDPattern is the txid format of an immediate charge in the Pix API.
+var txIDPattern = regexp.MustCompile(`^[a-zA-Z0-9]{26,35}$`)
+
+const defaultExpiration = 86400
+
+type Service interface {
+	Create(ctx context.Context, charge *Charge) (*Charge, error)
+	Find(ctx context.Context, req *Request) (*Charge, error)
+	List(ctx context.Context, accountID int64) ([]*Charge, error)
+	Cancel(ctx context.Context, req *Request) (*Charge, error)
+	Resolve(ctx context.Context, req *Resolution) (*Charge, error)
+	Reserve(ctx context.Context, payment *Payment) (*Charge, error)
+	Release(ctx context.Context, charge *Charge) error
+}
+
+// Payment is an incoming pix that carries a txid.
+type Payment struct {
+	TxID          string
+	TransactionID string
+	Key           string
+	Amount        decimal.Decimal
+	PayerCpf      string
+}
+
+type service struct {
+	repo     Repository
+	keysRepo keys.Repository
+	now      func() time.Time
+}
+
+func (s *service) Create(ctx context.Context, charge *Charge) (*Charge, error) {
+	if charge.TxID == "" {
+		charge.TxID = strings.ReplaceAll(uuid.New().String(), "-", "")
+	}
+	if !txIDPattern.MatchString(charge.TxID) {
+		return nil, errutils.ErrInvalidTxID
+	}
+	if !charge.Amount.IsPositive() {
+		return nil, errutils.ErrInvalidValue
+	}
+	if charge.HasDueDate() {
+		if !s.validTerms(charge) {
+			return nil, errutils.ErrInvalidChargeTerms
+		}
+		charge.Expiration = 0
+	} else {
+		if charge.ValidAfterDue != 0 || !charge.Fine.isZero() || !charge.Interest.isZero() ||
+			!charge.Rebate.isZero() || len(charge.Discounts) > 0 {
+			return nil, errutils.ErrInvalidChargeTerms
+		}
+		if charge.Expiration == 0 {
+			charge.Expiration = defaultExpiration
+		}
+		if charge.Expiration < 0 {
+			return nil, errutils.ErrInvalidValue
+		}
+	}
+
+	key, err := s.keysRepo.FindKey(ctx, charge.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.Account != charge.ChargeAccount {
+		return nil, errutils.ErrInvalidKey
+	}
+
+	now := s.now()
+	charge.Status = StatusActive
+	charge.Revision = 0
+	charge.CreatedAt = now
+	charge.UpdatedAt = now
+	if err = s.repo.CreateCharge(ctx, charge); err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+func (s *service) Find(ctx context.Context, req *Request) (*Charge, error) {
+	charge, err := s.repo.FindCharge(ctx, req.TxID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.ChargeAccount != req.AccountID {
+		return nil, errutils.ErrChargeNotFound
+	}
+	s.refreshStatus(charge)
+	return charge, nil
+}
+
+func (s *service) List(ctx context.Context, accountID int64) ([]*Charge, error) {
+	charges, err := s.repo.ListCharges(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, charge := range charges {
+		s.refreshStatus(charge)
+	}
+	return charges, nil
+}
+
+func (s *service) Cancel(ctx context.Context, req *Request) (*Charge, error) {
+	charge, err := s.Find(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status != StatusActive {
+		return nil, errutils.ErrChargeNotActive
+	}
+
+	revision := charge.Revision
+	charge.Status = StatusCanceled
+	charge.Revision++
+	charge.UpdatedAt = s.now()
+	err = s.repo.UpdateCharge(ctx, charge, revision)
+	if errors.Is(err, errutils.ErrChargeConflict) {
+		return nil, errutils.ErrChargeNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+// Reserve marks the charge paid by the pix before its balances move, so a
+// second pix for the same txid is refused. Reserving again for the same pix
+// is a no-op, which keeps redelivered events working.
+// Resolve shows the payer a charge they can still pay at req.At, so a due date
+// charge can be paid for its AmountDue on that day. req.At defaults to now and
+// is moved to now when in the past. A charge made out to a payer is only
+// shown to that payer.
+func (s *service) Resolve(ctx context.Context, req *Resolution) (*Charge, error) {
+	charge, err := s.repo.FindCharge(ctx, req.TxID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Payer.Cpf != "" && charge.Payer.Cpf != req.PayerCpf {
+		return nil, errutils.ErrChargeNotFound
+	}
+
+	if now := s.now(); req.At.Before(now) {
+		req.At = now
+	}
+	if charge.Status != StatusActive || charge.Expired(req.At) {
+		return nil, errutils.ErrChargeNotActive
+	}
+	return charge, nil
+}
+
+func (s *service) Reserve(ctx context.Context, payment *Payment) (*Charge, error) {
+	charge, err := s.repo.FindCharge(ctx, payment.TxID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status == StatusPaid && charge.TransactionID == payment.TransactionID {
+		return charge, nil
+	}
+
+	now := s.now()
+	if charge.Status != StatusActive || charge.Expired(now) {
+		return nil, errutils.ErrChargeNotActive
+	}
+	if charge.Key != payment.Key || !charge.AmountDue(now).Equal(payment.Amount) ||
+		(charge.Payer.Cpf != "" && charge.Payer.Cpf != payment.PayerCpf) {
+		return nil, errutils.ErrChargeMismatch
+	}
+
+	revision := charge.Revision
+	charge.Status = StatusPaid
+	charge.TransactionID = payment.TransactionID
+	charge.PaidAt = now
+	charge.Revision++
+	charge.UpdatedAt = now
+	err = s.repo.UpdateCharge(ctx, charge, revision)
+	if errors.Is(err, errutils.ErrChargeConflict) {
+		return nil, errutils.ErrChargeNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+// Release reopens a charge whose pix did not settle.
+func (s *service) Release(ctx context.Context, charge *Charge) error {
+	revision := charge.Revision
+	charge.Status = StatusActive
+	charge.TransactionID = ""
+	charge.PaidAt = time.Time{}
+	charge.Revision++
+	charge.UpdatedAt = s.now()
+	return s.repo.UpdateCharge(ctx, charge, revision)
+}
+
+// validTerms checks a due date charge: the due date is not in the past and
+// discounts end by it.
+func (s *service) validTerms(charge *Charge) bool {
+	dueDate, err := time.ParseInLocation(dateLayout, charge.DueDate, billingZone)
+	if err != nil || dueDate.Before(day(s.now())) || charge.ValidAfterDue < 0 {
+		return false
+	}
+	if !charge.Fine.valid() || !charge.Interest.valid() || !charge.Rebate.valid() {
+		return false
+	}
+	for _, discount := range charge.Discounts {
+		until, err := time.ParseInLocation(dateLayout, discount.Until, billingZone)
+		if err != nil || until.After(dueDate) || discount.Modality == "" ||
+			!(Adjustment{Modality: discount.Modality, Value: discount.Value}).valid() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *service) refreshStatus(charge *Charge) {
+	if charge.Expired(s.now()) {
+		charge.Status = StatusExpired
+	}
+}
+
+func NewService(repo Repository, keysRepo keys.Repository) Service {
+	return &service{
+		repo:     repo,
+		keysRepo: keysRepo,
+		now:      time.Now,
+	}
+}
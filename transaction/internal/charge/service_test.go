@@ -0,0 +1,440 @@
+package charge
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"transaction/internal/errutils"
+	keys "transaction/internal/keys"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepo) CreateCharge(ctx context.Context, charge *Charge) error {
+	args := m.Called(charge)
+	return args.Error(0)
+}
+
+func (m *mockRepo) FindCharge(ctx context.Context, txID string) (*Charge, error) {
+	args := m.Called(txID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Charge), args.Error(1)
+}
+
+func (m *mockRepo) UpdateCharge(ctx context.Context, charge *Charge, revision int) error {
+	args := m.Called(charge.Status, revision)
+	return args.Error(0)
+}
+
+type mockKeysRepo struct {
+	keys.Repository
+	mock.Mock
+}
+
+func (m *mockKeysRepo) FindKey(ctx context.Context, key string) (*keys.Key, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*keys.Key), args.Error(1)
+}
+
+const txID = "7978c0c97ea847e78e8849634473c1f1"
+
+var now = time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestService(repo *mockRepo, keysRepo *mockKeysRepo) *service {
+	s := NewService(repo, keysRepo).(*service)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func activeCharge() *Charge {
+	return &Charge{
+		TxID:          txID,
+		ChargeAccount: 1,
+		Key:           "receiver@pix.com",
+		Amount:        decimal.RequireFromString("37.5"),
+		Expiration:    3600,
+		Payer:         Payer{Cpf: "12345678909", Name: "Fulano"},
+		Status:        StatusActive,
+		Revision:      2,
+		CreatedAt:     now.Add(-time.Minute),
+	}
+}
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		charge   *Charge
+		mockFunc func(repo *mockRepo, keysRepo *mockKeysRepo)
+		err      error
+	}{
+		{
+			name:   "success with generated txid and default expiration",
+			charge: &Charge{ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10)},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "receiver@pix.com").Return(&keys.Key{Id: "receiver@pix.com", Account: 1}, nil)
+				repo.On("CreateCharge", mock.MatchedBy(func(charge *Charge) bool {
+					return len(charge.TxID) == 32 && charge.Expiration == defaultExpiration &&
+						charge.Status == StatusActive && charge.CreatedAt.Equal(now)
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "failed because txid is too short",
+			charge:   &Charge{TxID: "abc", ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10)},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidTxID,
+		},
+		{
+			name:     "failed because amount is not positive",
+			charge:   &Charge{TxID: txID, ChargeAccount: 1, Key: "receiver@pix.com"},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidValue,
+		},
+		{
+			name:   "failed because key belongs to another account",
+			charge: &Charge{TxID: txID, ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10)},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "receiver@pix.com").Return(&keys.Key{Id: "receiver@pix.com", Account: 2}, nil)
+			},
+			err: errutils.ErrInvalidKey,
+		},
+		{
+			name: "success with due date",
+			charge: &Charge{TxID: txID, ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10), DueDate: "2024-01-20",
+				Fine: Adjustment{Modality: ModalityPercent, Value: decimal.NewFromInt(2)}, Discounts: []Discount{{Until: "2024-01-15", Modality: ModalityFixed, Value: decimal.NewFromInt(1)}}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "receiver@pix.com").Return(&keys.Key{Id: "receiver@pix.com", Account: 1}, nil)
+				repo.On("CreateCharge", mock.MatchedBy(func(charge *Charge) bool {
+					return charge.Expiration == 0 && charge.Status == StatusActive
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "failed because due date is in the past",
+			charge:   &Charge{TxID: txID, ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10), DueDate: "2024-01-09"},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidChargeTerms,
+		},
+		{
+			name: "failed because discount ends after the due date",
+			charge: &Charge{TxID: txID, ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10), DueDate: "2024-01-20",
+				Discounts: []Discount{{Until: "2024-01-21", Modality: ModalityFixed, Value: decimal.NewFromInt(1)}}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidChargeTerms,
+		},
+		{
+			name:     "failed because immediate charge has a fine",
+			charge:   &Charge{TxID: txID, ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10), Fine: Adjustment{Modality: ModalityFixed, Value: decimal.NewFromInt(1)}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidChargeTerms,
+		},
+		{
+			name:   "failed because txid is already in use",
+			charge: &Charge{TxID: txID, ChargeAccount: 1, Key: "receiver@pix.com", Amount: decimal.NewFromInt(10)},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "receiver@pix.com").Return(&keys.Key{Id: "receiver@pix.com", Account: 1}, nil)
+				repo.On("CreateCharge", mock.Anything).Return(errutils.ErrChargeAlreadyExists)
+			},
+			err: errutils.ErrChargeAlreadyExists,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, keysRepo := new(mockRepo), new(mockKeysRepo)
+			tc.mockFunc(repo, keysRepo)
+
+			_, err := newTestService(repo, keysRepo).Create(context.Background(), tc.charge)
+			assert.Equal(t, tc.err, err)
+			repo.AssertExpectations(t)
+			keysRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	expired := activeCharge()
+	expired.CreatedAt = now.Add(-2 * time.Hour)
+
+	cases := []struct {
+		name   string
+		charge *Charge
+		req    *Request
+		want   Status
+		err    error
+	}{
+		{name: "active charge", charge: activeCharge(), req: &Request{TxID: txID, AccountID: 1}, want: StatusActive},
+		{name: "charge past its expiration reads as expired", charge: expired, req: &Request{TxID: txID, AccountID: 1}, want: StatusExpired},
+		{name: "charge of another account is not found", charge: activeCharge(), req: &Request{TxID: txID, AccountID: 2}, err: errutils.ErrChargeNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("FindCharge", txID).Return(tc.charge, nil)
+
+			got, err := newTestService(repo, nil).Find(context.Background(), tc.req)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, tc.want, got.Status)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	paid := activeCharge()
+	paid.Status = StatusPaid
+
+	cases := []struct {
+		name     string
+		charge   *Charge
+		mockFunc func(repo *mockRepo)
+		err      error
+	}{
+		{
+			name:   "success",
+			charge: activeCharge(),
+			mockFunc: func(repo *mockRepo) {
+				repo.On("UpdateCharge", StatusCanceled, 2).Return(nil)
+			},
+		},
+		{
+			name:     "failed because charge is paid",
+			charge:   paid,
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrChargeNotActive,
+		},
+		{
+			name:   "failed because charge was paid concurrently",
+			charge: activeCharge(),
+			mockFunc: func(repo *mockRepo) {
+				repo.On("UpdateCharge", StatusCanceled, 2).Return(errutils.ErrChargeConflict)
+			},
+			err: errutils.ErrChargeNotActive,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("FindCharge", txID).Return(tc.charge, nil)
+			tc.mockFunc(repo)
+
+			_, err := newTestService(repo, nil).Cancel(context.Background(), &Request{TxID: txID, AccountID: 1})
+			assert.Equal(t, tc.err, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	late := dueCharge()
+	late.TxID, late.DueDate = txID, "2024-01-07"
+
+	expired := activeCharge()
+	expired.CreatedAt = now.Add(-2 * time.Hour)
+
+	paid := activeCharge()
+	paid.Status = StatusPaid
+
+	cases := []struct {
+		name   string
+		charge *Charge
+		req    *Resolution
+		wantAt time.Time
+		want   string
+		err    error
+	}{
+		{
+			name:   "amount due of a late charge paid now",
+			charge: late,
+			req:    &Resolution{TxID: txID},
+			wantAt: now,
+			want:   "97.19",
+		},
+		{
+			name:   "amount due on the day a scheduled pix runs",
+			charge: late,
+			req:    &Resolution{TxID: txID, At: now.Add(48 * time.Hour)},
+			wantAt: now.Add(48 * time.Hour),
+			want:   "97.38",
+		},
+		{
+			name:   "a past day reads as now",
+			charge: late,
+			req:    &Resolution{TxID: txID, At: now.Add(-48 * time.Hour)},
+			wantAt: now,
+			want:   "97.19",
+		},
+		{
+			name:   "charge to a payer is shown to that payer",
+			charge: activeCharge(),
+			req:    &Resolution{TxID: txID, PayerCpf: "12345678909"},
+			wantAt: now,
+			want:   "37.5",
+		},
+		{
+			name:   "failed because the charge is made out to another payer",
+			charge: activeCharge(),
+			req:    &Resolution{TxID: txID, PayerCpf: "98765432100"},
+			err:    errutils.ErrChargeNotFound,
+		},
+		{
+			name:   "failed because the charge expired",
+			charge: expired,
+			req:    &Resolution{TxID: txID, PayerCpf: "12345678909"},
+			err:    errutils.ErrChargeNotActive,
+		},
+		{
+			name:   "failed because the charge was paid",
+			charge: paid,
+			req:    &Resolution{TxID: txID, PayerCpf: "12345678909"},
+			err:    errutils.ErrChargeNotActive,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("FindCharge", txID).Return(tc.charge, nil)
+
+			got, err := newTestService(repo, nil).Resolve(context.Background(), tc.req)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, tc.wantAt, tc.req.At)
+				assert.Equal(t, tc.want, got.AmountDue(tc.req.At).String())
+			}
+		})
+	}
+}
+
+func TestReserve(t *testing.T) {
+	payment := func() *Payment {
+		return &Payment{
+			TxID:          txID,
+			TransactionID: "pix-1",
+			Key:           "receiver@pix.com",
+			Amount:        decimal.RequireFromString("37.50"),
+			PayerCpf:      "12345678909",
+		}
+	}
+
+	paidByPayment := activeCharge()
+	paidByPayment.Status = StatusPaid
+	paidByPayment.TransactionID = "pix-1"
+
+	paidByOther := activeCharge()
+	paidByOther.Status = StatusPaid
+	paidByOther.TransactionID = "pix-0"
+
+	expired := activeCharge()
+	expired.CreatedAt = now.Add(-2 * time.Hour)
+
+	late := dueCharge()
+	late.TxID, late.Key, late.Revision, late.DueDate = txID, "receiver@pix.com", 2, "2024-01-07"
+
+	latePayment := payment()
+	latePayment.Amount = decimal.RequireFromString("97.19")
+
+	otherAmount := payment()
+	otherAmount.Amount = decimal.RequireFromString("37.49")
+
+	otherPayer := payment()
+	otherPayer.PayerCpf = "98765432100"
+
+	cases := []struct {
+		name     string
+		charge   *Charge
+		payment  *Payment
+		mockFunc func(repo *mockRepo)
+		err      error
+	}{
+		{
+			name:    "success",
+			charge:  activeCharge(),
+			payment: payment(),
+			mockFunc: func(repo *mockRepo) {
+				repo.On("UpdateCharge", StatusPaid, 2).Return(nil)
+			},
+		},
+		{
+			name:    "success with fine and interest of a late due date charge",
+			charge:  late,
+			payment: latePayment,
+			mockFunc: func(repo *mockRepo) {
+				repo.On("UpdateCharge", StatusPaid, 2).Return(nil)
+			},
+		},
+		{
+			name:     "redelivered pix keeps the charge paid",
+			charge:   paidByPayment,
+			payment:  payment(),
+			mockFunc: func(repo *mockRepo) {},
+		},
+		{
+			name:     "failed because charge was paid by another pix",
+			charge:   paidByOther,
+			payment:  payment(),
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrChargeNotActive,
+		},
+		{
+			name:     "failed because charge expired",
+			charge:   expired,
+			payment:  payment(),
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrChargeNotActive,
+		},
+		{
+			name:     "failed because amount differs",
+			charge:   activeCharge(),
+			payment:  otherAmount,
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrChargeMismatch,
+		},
+		{
+			name:     "failed because payer differs",
+			charge:   activeCharge(),
+			payment:  otherPayer,
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrChargeMismatch,
+		},
+		{
+			name:    "failed because another pix paid it first",
+			charge:  activeCharge(),
+			payment: payment(),
+			mockFunc: func(repo *mockRepo) {
+				repo.On("UpdateCharge", StatusPaid, 2).Return(errutils.ErrChargeConflict)
+			},
+			err: errutils.ErrChargeNotActive,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("FindCharge", txID).Return(tc.charge, nil)
+			tc.mockFunc(repo)
+
+			got, err := newTestService(repo, nil).Reserve(context.Background(), tc.payment)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, StatusPaid, got.Status)
+				assert.Equal(t, "pix-1", got.TransactionID)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
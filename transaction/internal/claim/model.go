@@ -0,0 +1,124 @@
+package claim
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	proto "transaction/proto/v1"
+)
+
+type Status string
+
+const (
+	StatusOpen              Status = "OPEN"
+	StatusWaitingResolution Status = "WAITING_RESOLUTION"
+	StatusConfirmed         Status = "CONFIRMED"
+	StatusCancelled         Status = "CANCELLED"
+	StatusCompleted         Status = "COMPLETED"
+)
+
+// Type is PORTABILITY when the claimer owns the key already, under another
+// account, and OWNERSHIP when someone else claims it.
+type Type string
+
+const (
+	TypePortability Type = "PORTABILITY"
+	TypeOwnership   Type = "OWNERSHIP"
+)
+
+type Account struct {
+	Name   int64
+	Cpf    string
+	Agency string
+	Bank   string
+}
+
+// Claim moves Key from the Donor account to the Claimer account. Deadline is
+// when the timer acts on the claim next: the end of the donor window while
+// waiting resolution, or the retry of an unfinished move once confirmed. It is
+// kept in whole UTC seconds so it sorts as a string.
+type Claim struct {
+	ID             string `dynamodbav:"PK"`
+	KeyID          string
+	Key            string
+	Type           Type
+	Claimer        Account
+	ClaimerAccount int64
+	Donor          Account
+	DonorAccount   int64
+	Status         Status
+	Deadline       time.Time
+	CancelledBy    int64
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CompletedAt    time.Time
+}
+
+// Party reports whether the account is the claimer or the donor.
+func (c *Claim) Party(accountID int64) bool {
+	return accountID == c.ClaimerAccount || accountID == c.DonorAccount
+}
+
+func (c *Claim) Pending() bool {
+	return c.Status == StatusOpen || c.Status == StatusWaitingResolution
+}
+
+type Request struct {
+	ID        string
+	AccountID int64
+}
+
+func ProtoToRequest(request *proto.ClaimRequest) *Request {
+	return &Request{
+		ID:        request.Id,
+		AccountID: request.AccountId,
+	}
+}
+
+func ProtoToClaim(claim *proto.Claim) *Claim {
+	return &Claim{
+		Key: claim.Key,
+		Claimer: Account{
+			Name:   claim.GetClaimer().GetName(),
+			Cpf:    claim.GetClaimer().GetCpf(),
+			Agency: claim.GetClaimer().GetAgency(),
+			Bank:   claim.GetClaimer().GetBank(),
+		},
+	}
+}
+
+func ToProto(claim *Claim) *proto.Claim {
+	var completedAt *timestamppb.Timestamp
+	if !claim.CompletedAt.IsZero() {
+		completedAt = timestamppb.New(claim.CompletedAt)
+	}
+
+	return &proto.Claim{
+		Id:          claim.ID,
+		Key:         claim.Key,
+		Type:        string(claim.Type),
+		Claimer:     accountToProto(claim.Claimer),
+		Donor:       accountToProto(claim.Donor),
+		Status:      string(claim.Status),
+		Deadline:    timestamppb.New(claim.Deadline),
+		CreatedAt:   timestamppb.New(claim.CreatedAt),
+		CompletedAt: completedAt,
+		CancelledBy: claim.CancelledBy,
+	}
+}
+
+func ToProtoList(claims []*Claim) *proto.ListClaims {
+	list := make([]*proto.Claim, len(claims))
+	for i := range claims {
+		list[i] = ToProto(claims[i])
+	}
+	return &proto.ListClaims{Claims: list}
+}
+
+func accountToProto(account Account) *proto.Account {
+	return &proto.Account{
+		Name:   account.Name,
+		Cpf:    account.Cpf,
+		Agency: account.Agency,
+		Bank:   account.Bank,
+	}
+}
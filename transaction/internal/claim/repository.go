@@ -0,0 +1,186 @@
+package claim
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"sort"
+	"time"
+	"transaction/internal/cfg"
+	"transaction/internal/errutils"
+	"transaction/platform/dynamo"
+)
+
+// The claim table needs three global secondary indexes: ClaimerIndex
+// (ClaimerAccount, CreatedAt) and DonorIndex (DonorAccount, CreatedAt) for
+// listing, and StatusIndex (Status, Deadline) for the timer.
+const (
+	claimerIndex = "ClaimerIndex"
+	donorIndex   = "DonorIndex"
+	statusIndex  = "StatusIndex"
+)
+
+type Repository interface {
+	CreateClaim(ctx context.Context, claim *Claim) error
+	FindClaim(ctx context.Context, id string) (*Claim, error)
+	ListClaims(ctx context.Context, accountID int64) ([]*Claim, error)
+	ListDue(ctx context.Context, status Status, before time.Time, limit int) ([]*Claim, error)
+	UpdateClaim(ctx context.Context, claim *Claim, from Status, previous time.Time) error
+}
+
+type repository struct {
+	db  dynamo.Client
+	cfg *cfg.Config
+}
+
+func (r *repository) CreateClaim(ctx context.Context, claim *Claim) error {
+	value, err := attributevalue.MarshalMap(claim)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB().PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.cfg.DynamodbConfig.ClaimTable),
+		Item:                value,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrClaimConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) FindClaim(ctx context.Context, id string) (*Claim, error) {
+	value, err := r.db.DB().GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.cfg.DynamodbConfig.ClaimTable),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value.Item == nil {
+		return nil, errutils.ErrClaimNotFound
+	}
+
+	var claim Claim
+	if err = attributevalue.UnmarshalMap(value.Item, &claim); err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// ListClaims returns the claims the account opened and the ones opened over
+// its keys, newest first.
+func (r *repository) ListClaims(ctx context.Context, accountID int64) ([]*Claim, error) {
+	var claims []*Claim
+	for index, attribute := range map[string]string{claimerIndex: "ClaimerAccount", donorIndex: "DonorAccount"} {
+		keyCond := expression.Key(attribute).Equal(expression.Value(accountID))
+		expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+		if err != nil {
+			return nil, errors.New("failed to build expression")
+		}
+
+		found, err := r.query(ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String(r.cfg.DynamodbConfig.ClaimTable),
+			IndexName:                 aws.String(index),
+			KeyConditionExpression:    expr.KeyCondition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, found...)
+	}
+
+	sort.Slice(claims, func(i, j int) bool { return claims[i].CreatedAt.After(claims[j].CreatedAt) })
+	return claims, nil
+}
+
+// ListDue returns the claims in status whose deadline is not after before,
+// oldest first.
+func (r *repository) ListDue(ctx context.Context, status Status, before time.Time, limit int) ([]*Claim, error) {
+	keyCond := expression.Key("Status").Equal(expression.Value(status)).
+		And(expression.Key("Deadline").LessThanEqual(expression.Value(before)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.ClaimTable),
+		IndexName:                 aws.String(statusIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+}
+
+func (r *repository) query(ctx context.Context, input *dynamodb.QueryInput) ([]*Claim, error) {
+	value, err := r.db.DB().Query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make([]*Claim, 0, len(value.Items))
+	if err = attributevalue.UnmarshalListOfMaps(value.Items, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// UpdateClaim writes the claim only while it is still in from and was not
+// touched since previous, so the donor, the claimer and the timer can not
+// move the same claim twice.
+func (r *repository) UpdateClaim(ctx context.Context, claim *Claim, from Status, previous time.Time) error {
+	upd := expression.Set(expression.Name("Status"), expression.Value(claim.Status)).
+		Set(expression.Name("Deadline"), expression.Value(claim.Deadline)).
+		Set(expression.Name("CancelledBy"), expression.Value(claim.CancelledBy)).
+		Set(expression.Name("CompletedAt"), expression.Value(claim.CompletedAt)).
+		Set(expression.Name("UpdatedAt"), expression.Value(claim.UpdatedAt))
+
+	cond := expression.Name("Status").Equal(expression.Value(from)).
+		And(expression.Name("UpdatedAt").Equal(expression.Value(previous)))
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: claim.ID},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.ClaimTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrClaimConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func NewRepository(db dynamo.Client, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
@@ -0,0 +1,320 @@
+package claim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"log"
+	"time"
+	"transaction/internal/errutils"
+	"transaction/internal/event"
+	keys "transaction/internal/keys"
+)
+
+type Service interface {
+	Open(ctx context.Context, claim *Claim) (*Claim, error)
+	Find(ctx context.Context, req *Request) (*Claim, error)
+	List(ctx context.Context, accountID int64) ([]*Claim, error)
+	Confirm(ctx context.Context, req *Request) (*Claim, error)
+	Cancel(ctx context.Context, req *Request) (*Claim, error)
+	RunDue(ctx context.Context) error
+}
+
+type Options func(*service)
+
+func WithBatchSize(size int) Options {
+	return func(s *service) {
+		s.batchSize = size
+	}
+}
+
+// WithResolutionWindow sets how long the donor has to answer a claim before
+// the timer confirms it.
+func WithResolutionWindow(window time.Duration) Options {
+	return func(s *service) {
+		s.window = window
+	}
+}
+
+// WithLease sets how long a confirmed claim may wait for its key move before
+// the timer moves the key again. Moving twice is safe because the move only
+// applies while the key is marked by the claim.
+func WithLease(lease time.Duration) Options {
+	return func(s *service) {
+		s.lease = lease
+	}
+}
+
+type service struct {
+	repo      Repository
+	keysRepo  keys.Repository
+	events    event.Client
+	batchSize int
+	window    time.Duration
+	lease     time.Duration
+	now       func() time.Time
+}
+
+// Open starts a claim over a key of another account. The donor is notified by
+// the timer, which moves the claim to WAITING_RESOLUTION. Phone and email keys
+// may be claimed by anyone, a cpf key only by its own cpf and random keys never.
+func (s *service) Open(ctx context.Context, claim *Claim) (*Claim, error) {
+	key, err := s.keysRepo.FindKey(ctx, claim.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errutils.ErrInvalidKey
+	}
+	if key.Account == claim.Claimer.Name {
+		return nil, errutils.ErrInvalidClaim
+	}
+	if err = claimable(key, claim.Claimer.Cpf); err != nil {
+		return nil, err
+	}
+	if err = s.checkLimit(ctx, claim.Claimer.Name); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	claim.ID = uuid.New().String()
+	claim.KeyID = key.Id
+	claim.ClaimerAccount = claim.Claimer.Name
+	claim.Donor = Account{Name: key.Account, Cpf: key.Cpf, Agency: key.Agency, Bank: key.Bank}
+	claim.DonorAccount = key.Account
+	claim.Type = TypeOwnership
+	if key.Cpf != "" && key.Cpf == claim.Claimer.Cpf {
+		claim.Type = TypePortability
+	}
+	claim.Status = StatusOpen
+	claim.Deadline = now.Truncate(time.Second)
+	claim.CreatedAt = now
+	claim.UpdatedAt = now
+
+	if err = s.keysRepo.ReserveForClaim(ctx, key.Id, claim.ID); err != nil {
+		return nil, err
+	}
+	if err = s.repo.CreateClaim(ctx, claim); err != nil {
+		if releaseErr := s.keysRepo.ReleaseClaim(ctx, key.Id, claim.ID); releaseErr != nil {
+			log.Printf("failed to release key %s from claim %s: %v", key.Id, claim.ID, releaseErr)
+		}
+		return nil, err
+	}
+	return claim, nil
+}
+
+func (s *service) Find(ctx context.Context, req *Request) (*Claim, error) {
+	claim, err := s.repo.FindClaim(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claim.Party(req.AccountID) {
+		return nil, errutils.ErrClaimNotFound
+	}
+	return claim, nil
+}
+
+func (s *service) List(ctx context.Context, accountID int64) ([]*Claim, error) {
+	return s.repo.ListClaims(ctx, accountID)
+}
+
+// Confirm is the donor releasing the key, which moves it right away.
+func (s *service) Confirm(ctx context.Context, req *Request) (*Claim, error) {
+	claim, err := s.Find(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if claim.DonorAccount != req.AccountID {
+		return nil, errutils.ErrClaimNotFound
+	}
+	if err = s.confirm(ctx, claim); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// Cancel ends a pending claim, by either side, and frees the key for new claims.
+func (s *service) Cancel(ctx context.Context, req *Request) (*Claim, error) {
+	claim, err := s.Find(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if !claim.Pending() {
+		return nil, errutils.ErrClaimNotPending
+	}
+
+	from, previous := claim.Status, claim.UpdatedAt
+	claim.Status = StatusCancelled
+	claim.CancelledBy = req.AccountID
+	claim.UpdatedAt = s.now()
+	if err = s.repo.UpdateClaim(ctx, claim, from, previous); err != nil {
+		if errors.Is(err, errutils.ErrClaimConflict) {
+			return nil, errutils.ErrClaimNotPending
+		}
+		return nil, err
+	}
+
+	if err = s.keysRepo.ReleaseClaim(ctx, claim.KeyID, claim.ID); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// RunDue notifies the donors of open claims, confirms the claims whose
+// resolution window ended and retries confirmed claims whose key did not move.
+func (s *service) RunDue(ctx context.Context) error {
+	now := s.now()
+	for _, status := range []Status{StatusOpen, StatusWaitingResolution, StatusConfirmed} {
+		due, err := s.repo.ListDue(ctx, status, now, s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, claim := range due {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			switch claim.Status {
+			case StatusOpen:
+				err = s.waitResolution(ctx, claim)
+			case StatusWaitingResolution:
+				err = s.confirm(ctx, claim)
+			case StatusConfirmed:
+				err = s.complete(ctx, claim)
+			}
+			if errors.Is(err, errutils.ErrClaimConflict) || errors.Is(err, errutils.ErrClaimNotPending) {
+				continue
+			}
+			if err != nil {
+				log.Printf("failed to resolve claim %s: %v", claim.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// waitResolution starts the window the donor has to answer the claim.
+func (s *service) waitResolution(ctx context.Context, claim *Claim) error {
+	previous := claim.UpdatedAt
+	claim.Status = StatusWaitingResolution
+	claim.UpdatedAt = s.now()
+	claim.Deadline = claim.UpdatedAt.Add(s.window).Truncate(time.Second)
+	return s.repo.UpdateClaim(ctx, claim, StatusOpen, previous)
+}
+
+// confirm records the confirmation before the key moves, so a move that fails
+// halfway is finished by the timer after the lease.
+func (s *service) confirm(ctx context.Context, claim *Claim) error {
+	if !claim.Pending() {
+		return errutils.ErrClaimNotPending
+	}
+	// the claimer may have added keys while the claim was open
+	if err := s.checkLimit(ctx, claim.ClaimerAccount); err != nil {
+		return err
+	}
+
+	from, previous := claim.Status, claim.UpdatedAt
+	claim.Status = StatusConfirmed
+	claim.UpdatedAt = s.now()
+	claim.Deadline = claim.UpdatedAt.Add(s.lease).Truncate(time.Second)
+	if err := s.repo.UpdateClaim(ctx, claim, from, previous); err != nil {
+		if errors.Is(err, errutils.ErrClaimConflict) {
+			return errutils.ErrClaimNotPending
+		}
+		return err
+	}
+	return s.complete(ctx, claim)
+}
+
+// complete moves the key and publishes the move to the profile service, which
+// owns the keys. Both are repeated until the claim is completed.
+func (s *service) complete(ctx context.Context, claim *Claim) error {
+	moved, err := s.keysRepo.TransferKey(ctx, &keys.Key{
+		Id:        claim.KeyID,
+		Account:   claim.Claimer.Name,
+		Cpf:       claim.Claimer.Cpf,
+		Agency:    claim.Claimer.Agency,
+		Bank:      claim.Claimer.Bank,
+		UpdatedAt: s.now(),
+	}, claim.ID)
+	// a key no longer marked by the claim was moved by an earlier attempt
+	if errors.Is(err, errutils.ErrClaimConflict) {
+		moved, err = s.movedKey(ctx, claim)
+	}
+	if err != nil {
+		return err
+	}
+
+	if moved != nil {
+		payload, err := json.Marshal(keys.KeyToEvent(keys.OpClaim, moved, s.now()))
+		if err != nil {
+			return err
+		}
+		if err = s.events.Publish(ctx, payload); err != nil {
+			return err
+		}
+	}
+
+	previous := claim.UpdatedAt
+	claim.Status = StatusCompleted
+	claim.UpdatedAt = s.now()
+	claim.CompletedAt = claim.UpdatedAt
+	return s.repo.UpdateClaim(ctx, claim, StatusConfirmed, previous)
+}
+
+// movedKey is the key of a claim an earlier attempt moved, or nil when the key
+// was deleted or moved again since.
+func (s *service) movedKey(ctx context.Context, claim *Claim) (*keys.Key, error) {
+	found, err := s.keysRepo.ListKey(ctx, []string{claim.KeyID})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 || found[0].Account != claim.ClaimerAccount {
+		return nil, nil
+	}
+	return found[0], nil
+}
+
+// claimable checks the key can be claimed by the claimer cpf.
+func claimable(key *keys.Key, cpf string) error {
+	switch key.Type {
+	case keys.Phone, keys.Email:
+		return nil
+	case keys.Cpf:
+		if key.Cpf == "" || key.Cpf != cpf {
+			return errutils.ErrKeyNotOwned
+		}
+		return nil
+	default:
+		return errutils.ErrKeyNotClaimable
+	}
+}
+
+func (s *service) checkLimit(ctx context.Context, accountID int64) error {
+	count, err := s.keysRepo.CountKeys(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if count >= keys.MaxKeysPerAccount {
+		return errutils.ErrKeyLimitReached
+	}
+	return nil
+}
+
+func NewService(repo Repository, keysRepo keys.Repository, events event.Client, opts ...Options) Service {
+	s := &service{
+		repo:      repo,
+		keysRepo:  keysRepo,
+		events:    events,
+		batchSize: 100,
+		window:    7 * 24 * time.Hour,
+		lease:     5 * time.Minute,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
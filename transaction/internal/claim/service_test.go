@@ -0,0 +1,416 @@
+package claim
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"transaction/internal/errutils"
+	"transaction/internal/event"
+	keys "transaction/internal/keys"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+	// statuses records the status written on every UpdateClaim call
+	statuses []Status
+}
+
+func (m *mockRepo) CreateClaim(ctx context.Context, claim *Claim) error {
+	args := m.Called(claim)
+	return args.Error(0)
+}
+
+func (m *mockRepo) FindClaim(ctx context.Context, id string) (*Claim, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Claim), args.Error(1)
+}
+
+func (m *mockRepo) ListDue(ctx context.Context, status Status, before time.Time, limit int) ([]*Claim, error) {
+	args := m.Called(status)
+	return args.Get(0).([]*Claim), args.Error(1)
+}
+
+func (m *mockRepo) UpdateClaim(ctx context.Context, claim *Claim, from Status, previous time.Time) error {
+	m.statuses = append(m.statuses, claim.Status)
+	args := m.Called(claim.ID, from)
+	return args.Error(0)
+}
+
+type mockKeysRepo struct {
+	keys.Repository
+	mock.Mock
+}
+
+func (m *mockKeysRepo) FindKey(ctx context.Context, key string) (*keys.Key, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*keys.Key), args.Error(1)
+}
+
+func (m *mockKeysRepo) ReserveForClaim(ctx context.Context, keyID, claimID string) error {
+	args := m.Called(keyID)
+	return args.Error(0)
+}
+
+func (m *mockKeysRepo) ReleaseClaim(ctx context.Context, keyID, claimID string) error {
+	args := m.Called(keyID)
+	return args.Error(0)
+}
+
+func (m *mockKeysRepo) TransferKey(ctx context.Context, key *keys.Key, claimID string) (*keys.Key, error) {
+	args := m.Called(key.Id, key.Account)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*keys.Key), args.Error(1)
+}
+
+func (m *mockKeysRepo) ListKey(ctx context.Context, ids []string) ([]*keys.Key, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]*keys.Key), args.Error(1)
+}
+
+func (m *mockKeysRepo) CountKeys(ctx context.Context, account int64) (int, error) {
+	args := m.Called(account)
+	return args.Int(0), args.Error(1)
+}
+
+type mockEvents struct {
+	event.Client
+	mock.Mock
+}
+
+func (m *mockEvents) Publish(ctx context.Context, payload []byte) error {
+	var published keys.Event
+	if err := json.Unmarshal(payload, &published); err != nil {
+		return err
+	}
+	args := m.Called(published.Op, published.ID, published.Account.Name, published.Version)
+	return args.Error(0)
+}
+
+var now = time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+// moved is the key id once a claim moved it to account 2.
+func moved(id string) *keys.Key {
+	return &keys.Key{Id: id, Name: "fulano@pix.com", Account: 2, Version: 4}
+}
+
+func newTestService(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents) *service {
+	s := NewService(repo, keysRepo, events, WithResolutionWindow(24*time.Hour)).(*service)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func pendingClaim(status Status) *Claim {
+	return &Claim{
+		ID:             "claim-1",
+		KeyID:          "key-1",
+		Key:            "fulano@pix.com",
+		Claimer:        Account{Name: 2, Cpf: "12345678909"},
+		ClaimerAccount: 2,
+		Donor:          Account{Name: 1, Cpf: "98765432100"},
+		DonorAccount:   1,
+		Status:         status,
+	}
+}
+
+func TestOpen(t *testing.T) {
+	owned := &keys.Key{Id: "key-1", Name: "fulano@pix.com", Type: keys.Email, Account: 1, Cpf: "12345678909"}
+	cpf := &keys.Key{Id: "key-2", Name: "12345678909", Type: keys.Cpf, Account: 1, Cpf: "12345678909"}
+	random := &keys.Key{Id: "key-3", Name: "5b2f7c1e-8d4a-4f6b-9c3e-1a2b3c4d5e6f", Type: keys.Random, Account: 1, Cpf: "12345678909"}
+
+	cases := []struct {
+		name     string
+		claim    *Claim
+		mockFunc func(repo *mockRepo, keysRepo *mockKeysRepo)
+		want     Type
+		err      error
+	}{
+		{
+			name:  "portability of a key of the same person",
+			claim: &Claim{Key: "fulano@pix.com", Claimer: Account{Name: 2, Cpf: "12345678909"}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "fulano@pix.com").Return(owned, nil)
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				keysRepo.On("ReserveForClaim", "key-1").Return(nil)
+				repo.On("CreateClaim", mock.MatchedBy(func(claim *Claim) bool {
+					return claim.Status == StatusOpen && claim.DonorAccount == 1 && claim.ClaimerAccount == 2
+				})).Return(nil)
+			},
+			want: TypePortability,
+		},
+		{
+			name:  "ownership claim by another person",
+			claim: &Claim{Key: "fulano@pix.com", Claimer: Account{Name: 2, Cpf: "11122233344"}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "fulano@pix.com").Return(owned, nil)
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				keysRepo.On("ReserveForClaim", "key-1").Return(nil)
+				repo.On("CreateClaim", mock.Anything).Return(nil)
+			},
+			want: TypeOwnership,
+		},
+		{
+			name:  "portability of a cpf key to an account of the same cpf",
+			claim: &Claim{Key: "12345678909", Claimer: Account{Name: 2, Cpf: "12345678909"}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "12345678909").Return(cpf, nil)
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				keysRepo.On("ReserveForClaim", "key-2").Return(nil)
+				repo.On("CreateClaim", mock.Anything).Return(nil)
+			},
+			want: TypePortability,
+		},
+		{
+			name:  "failed because a cpf key is claimed by another cpf",
+			claim: &Claim{Key: "12345678909", Claimer: Account{Name: 2, Cpf: "11122233344"}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "12345678909").Return(cpf, nil)
+			},
+			err: errutils.ErrKeyNotOwned,
+		},
+		{
+			name:  "failed because random keys can not be claimed",
+			claim: &Claim{Key: random.Name, Claimer: Account{Name: 2, Cpf: "12345678909"}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", random.Name).Return(random, nil)
+			},
+			err: errutils.ErrKeyNotClaimable,
+		},
+		{
+			name:  "failed because the claimer already owns the key",
+			claim: &Claim{Key: "fulano@pix.com", Claimer: Account{Name: 1}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "fulano@pix.com").Return(owned, nil)
+			},
+			err: errutils.ErrInvalidClaim,
+		},
+		{
+			name:  "failed because the claimer reached the maximum number of keys",
+			claim: &Claim{Key: "fulano@pix.com", Claimer: Account{Name: 2}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "fulano@pix.com").Return(owned, nil)
+				keysRepo.On("CountKeys", int64(2)).Return(keys.MaxKeysPerAccount, nil)
+			},
+			err: errutils.ErrKeyLimitReached,
+		},
+		{
+			name:  "failed because the key has an open claim",
+			claim: &Claim{Key: "fulano@pix.com", Claimer: Account{Name: 2}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "fulano@pix.com").Return(owned, nil)
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				keysRepo.On("ReserveForClaim", "key-1").Return(errutils.ErrKeyUnderClaim)
+			},
+			err: errutils.ErrKeyUnderClaim,
+		},
+		{
+			name:  "key is released when the claim is not stored",
+			claim: &Claim{Key: "fulano@pix.com", Claimer: Account{Name: 2}},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "fulano@pix.com").Return(owned, nil)
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				keysRepo.On("ReserveForClaim", "key-1").Return(nil)
+				repo.On("CreateClaim", mock.Anything).Return(errutils.ErrClaimConflict)
+				keysRepo.On("ReleaseClaim", "key-1").Return(nil)
+			},
+			err: errutils.ErrClaimConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, keysRepo := new(mockRepo), new(mockKeysRepo)
+			tc.mockFunc(repo, keysRepo)
+
+			got, err := newTestService(repo, keysRepo, new(mockEvents)).Open(context.Background(), tc.claim)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, tc.want, got.Type)
+			}
+			repo.AssertExpectations(t)
+			keysRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	cases := []struct {
+		name     string
+		claim    *Claim
+		account  int64
+		mockFunc func(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents)
+		want     []Status
+		err      error
+	}{
+		{
+			name:    "donor confirms and the key moves to the claimer",
+			claim:   pendingClaim(StatusWaitingResolution),
+			account: 1,
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents) {
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				repo.On("UpdateClaim", "claim-1", StatusWaitingResolution).Return(nil)
+				keysRepo.On("TransferKey", "key-1", int64(2)).Return(moved("key-1"), nil)
+				events.On("Publish", keys.OpClaim, "key-1", int64(2), int64(4)).Return(nil)
+				repo.On("UpdateClaim", "claim-1", StatusConfirmed).Return(nil)
+			},
+			want: []Status{StatusConfirmed, StatusCompleted},
+		},
+		{
+			name:    "failed because the claimer reached the maximum number of keys",
+			claim:   pendingClaim(StatusWaitingResolution),
+			account: 1,
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents) {
+				keysRepo.On("CountKeys", int64(2)).Return(keys.MaxKeysPerAccount, nil)
+			},
+			err: errutils.ErrKeyLimitReached,
+		},
+		{
+			name:     "failed because only the donor confirms",
+			claim:    pendingClaim(StatusWaitingResolution),
+			account:  2,
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents) {},
+			err:      errutils.ErrClaimNotFound,
+		},
+		{
+			name:     "failed because the claim was cancelled",
+			claim:    pendingClaim(StatusCancelled),
+			account:  1,
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents) {},
+			err:      errutils.ErrClaimNotPending,
+		},
+		{
+			name:    "failed key move is left confirmed for the timer",
+			claim:   pendingClaim(StatusOpen),
+			account: 1,
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents) {
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				repo.On("UpdateClaim", "claim-1", StatusOpen).Return(nil)
+				keysRepo.On("TransferKey", "key-1", int64(2)).Return(nil, assert.AnError)
+			},
+			want: []Status{StatusConfirmed},
+			err:  assert.AnError,
+		},
+		{
+			name:    "move not published to the profile service is left confirmed for the timer",
+			claim:   pendingClaim(StatusOpen),
+			account: 1,
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo, events *mockEvents) {
+				keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+				repo.On("UpdateClaim", "claim-1", StatusOpen).Return(nil)
+				keysRepo.On("TransferKey", "key-1", int64(2)).Return(moved("key-1"), nil)
+				events.On("Publish", keys.OpClaim, "key-1", int64(2), int64(4)).Return(assert.AnError)
+			},
+			want: []Status{StatusConfirmed},
+			err:  assert.AnError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, keysRepo, events := new(mockRepo), new(mockKeysRepo), new(mockEvents)
+			repo.On("FindClaim", "claim-1").Return(tc.claim, nil)
+			tc.mockFunc(repo, keysRepo, events)
+
+			_, err := newTestService(repo, keysRepo, events).Confirm(context.Background(), &Request{ID: "claim-1", AccountID: tc.account})
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.want, repo.statuses)
+			repo.AssertExpectations(t)
+			keysRepo.AssertExpectations(t)
+			events.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	cases := []struct {
+		name     string
+		claim    *Claim
+		mockFunc func(repo *mockRepo, keysRepo *mockKeysRepo)
+		err      error
+	}{
+		{
+			name:  "success",
+			claim: pendingClaim(StatusWaitingResolution),
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				repo.On("UpdateClaim", "claim-1", StatusWaitingResolution).Return(nil)
+				keysRepo.On("ReleaseClaim", "key-1").Return(nil)
+			},
+		},
+		{
+			name:     "failed because the claim is confirmed",
+			claim:    pendingClaim(StatusConfirmed),
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrClaimNotPending,
+		},
+		{
+			name:  "failed because the timer confirmed it first",
+			claim: pendingClaim(StatusWaitingResolution),
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				repo.On("UpdateClaim", "claim-1", StatusWaitingResolution).Return(errutils.ErrClaimConflict)
+			},
+			err: errutils.ErrClaimNotPending,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, keysRepo := new(mockRepo), new(mockKeysRepo)
+			repo.On("FindClaim", "claim-1").Return(tc.claim, nil)
+			tc.mockFunc(repo, keysRepo)
+
+			got, err := newTestService(repo, keysRepo, new(mockEvents)).Cancel(context.Background(), &Request{ID: "claim-1", AccountID: 2})
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, StatusCancelled, got.Status)
+				assert.Equal(t, int64(2), got.CancelledBy)
+			}
+			repo.AssertExpectations(t)
+			keysRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	open := pendingClaim(StatusOpen)
+	open.ID = "open"
+	expired := pendingClaim(StatusWaitingResolution)
+	expired.ID = "expired"
+	stuck := pendingClaim(StatusConfirmed)
+	stuck.ID, stuck.KeyID = "stuck", "key-2"
+
+	repo, keysRepo, events := new(mockRepo), new(mockKeysRepo), new(mockEvents)
+	repo.On("ListDue", StatusOpen).Return([]*Claim{open}, nil)
+	repo.On("ListDue", StatusWaitingResolution).Return([]*Claim{expired}, nil)
+	repo.On("ListDue", StatusConfirmed).Return([]*Claim{stuck}, nil)
+	repo.On("UpdateClaim", "open", StatusOpen).Return(nil)
+	keysRepo.On("CountKeys", int64(2)).Return(0, nil)
+	repo.On("UpdateClaim", "expired", StatusWaitingResolution).Return(nil)
+	keysRepo.On("TransferKey", "key-1", int64(2)).Return(moved("key-1"), nil)
+	events.On("Publish", keys.OpClaim, "key-1", int64(2), int64(4)).Return(nil)
+	repo.On("UpdateClaim", "expired", StatusConfirmed).Return(nil)
+	// the key of the stuck claim was moved before its run died, the move is
+	// published again
+	keysRepo.On("TransferKey", "key-2", int64(2)).Return(nil, errutils.ErrClaimConflict)
+	keysRepo.On("ListKey", []string{"key-2"}).Return([]*keys.Key{moved("key-2")}, nil)
+	events.On("Publish", keys.OpClaim, "key-2", int64(2), int64(4)).Return(nil)
+	repo.On("UpdateClaim", "stuck", StatusConfirmed).Return(nil)
+
+	err := newTestService(repo, keysRepo, events).RunDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Status{StatusWaitingResolution, StatusConfirmed, StatusCompleted, StatusCompleted}, repo.statuses)
+	assert.Equal(t, now.Add(24*time.Hour), open.Deadline)
+	repo.AssertExpectations(t)
+	keysRepo.AssertExpectations(t)
+	events.AssertExpectations(t)
+}
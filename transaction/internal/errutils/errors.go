@@ -3,9 +3,63 @@ package errutils
 import "errors"
 
 var (
-	ErrInsufficientBalance    = errors.New("insufficient balance")
-	ErrKeyNotFound            = errors.New("key not found in the database")
-	ErrInvalidKey             = errors.New("invalid key")
-	ErrInactiveAccount        = errors.New("inactive account")
-	ErrReceiverAccountBlocked = errors.New("account blocked")
+	ErrInsufficientBalance      = errors.New("insufficient balance")
+	ErrKeyNotFound              = errors.New("key not found in the database")
+	ErrInvalidKey               = errors.New("invalid key")
+	ErrInactiveAccount          = errors.New("inactive account")
+	ErrReceiverAccountBlocked   = errors.New("account blocked")
+	ErrTransactionAlreadyExists = errors.New("transaction already exists")
+	ErrDeadLetterNotFound       = errors.New("dead letter not found")
+	ErrTransactionNotFound      = errors.New("transaction not found")
+	ErrTransactionConflict      = errors.New("transaction was changed concurrently")
+	ErrTransactionNotRefundable = errors.New("transaction can not be refunded")
+	ErrTransactionFailed        = errors.New("transaction already failed")
+	ErrReceiverUnreachable      = errors.New("receiver did not accept the pix")
+	ErrInvalidTransition        = errors.New("transaction can not move to this status from its current one")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrInvalidHistoryFilter     = errors.New("invalid direction, status or date range")
+	ErrRefundExceedsValue       = errors.New("refund exceeds the remaining transaction value")
+	ErrInvalidValue             = errors.New("invalid value")
+	ErrNotTransactionReceiver   = errors.New("only the receiver can refund the transaction")
+	ErrScheduleNotFound         = errors.New("scheduled pix not found")
+	ErrScheduleNotCancelable    = errors.New("scheduled pix can not be canceled")
+	ErrScheduleClaimed          = errors.New("scheduled pix was already claimed")
+	ErrInvalidExecutionDate     = errors.New("execution date must be in the future")
+	ErrMandateNotFound          = errors.New("mandate not found")
+	ErrMandateConflict          = errors.New("mandate was changed concurrently")
+	ErrMandateNotActive         = errors.New("mandate is no longer active")
+	ErrMandateNotPending        = errors.New("mandate is not waiting for authorization")
+	ErrAmountExceedsMax         = errors.New("amount exceeds the mandate max amount")
+	ErrInvalidFrequency         = errors.New("invalid frequency")
+	ErrInvalidMandatePeriod     = errors.New("mandate must start in the future and end after it starts")
+	ErrInvalidBRCode            = errors.New("invalid br code")
+	ErrBRCodeChecksum           = errors.New("br code checksum does not match")
+	ErrChargeNotFound           = errors.New("charge not found")
+	ErrChargeAlreadyExists      = errors.New("charge already exists")
+	ErrChargeConflict           = errors.New("charge was changed concurrently")
+	ErrChargeNotActive          = errors.New("charge is no longer active")
+	ErrChargeMismatch           = errors.New("pix does not match the charge")
+	ErrInvalidTxID              = errors.New("txid must have 26 to 35 letters or digits")
+	ErrInvalidChargeTerms       = errors.New("invalid due date, fine, interest, rebate or discount")
+	ErrClaimNotFound            = errors.New("claim not found")
+	ErrClaimConflict            = errors.New("claim was changed concurrently")
+	ErrClaimNotPending          = errors.New("claim is no longer pending")
+	ErrKeyUnderClaim            = errors.New("key already has an open claim")
+	ErrInvalidClaim             = errors.New("key already belongs to the claimer account")
+	ErrKeyNotClaimable          = errors.New("only phone, email and cpf keys can be claimed")
+	ErrKeyAlreadyExists         = errors.New("key already exists")
+	ErrKeyNotOwned              = errors.New("cpf key must be the account holder cpf")
+	ErrKeyLimitReached          = errors.New("account reached the maximum number of keys")
+	ErrReceiverChanged          = errors.New("key belongs to another account than the confirmed one")
+	ErrInvalidKeyEvent          = errors.New("key event has an unknown operation")
+	ErrStaleKeyEvent            = errors.New("key is already at the same or a later version or on another account")
+	ErrDeliveryNotFound         = errors.New("webhook delivery not found")
+	ErrDeliveryConflict         = errors.New("webhook delivery was changed concurrently")
+	ErrDeliveryNotRetryable     = errors.New("webhook delivery decided a transaction and can not be redelivered")
+	ErrInvalidDeliveryFilter    = errors.New("transaction id or a valid status is required")
+	ErrSubscriptionNotFound     = errors.New("webhook subscription not found")
+	ErrSubscriptionConflict     = errors.New("webhook subscription was changed concurrently")
+	ErrSubscriptionDisabled     = errors.New("webhook subscription is disabled")
+	ErrSubscriptionLimit        = errors.New("account reached the maximum number of webhook subscriptions")
+	ErrInvalidSubscription      = errors.New("subscription needs an http or https url and known event types")
 )
@@ -0,0 +1,163 @@
+package event
+
+import (
+	"context"
+	kafkago "github.com/segmentio/kafka-go"
+	"strconv"
+	"time"
+	"transaction/internal/errutils"
+)
+
+const maxMessageBytes = 10e6
+
+// failureHeaders keeps the headers of msg and records why it failed. The
+// original topic, offset and attempt count carry over from earlier stages.
+func failureHeaders(msg kafkago.Message, topic string, cause error, attempts int) []kafkago.Header {
+	previous := header(msg, HeaderAttempts)
+	if total, err := strconv.Atoi(previous); err == nil {
+		attempts += total
+	}
+
+	originalTopic := header(msg, HeaderOriginalTopic)
+	if originalTopic == "" {
+		originalTopic = topic
+	}
+	originalOffset := header(msg, HeaderOriginalOffset)
+	if originalOffset == "" {
+		originalOffset = strconv.FormatInt(msg.Offset, 10)
+	}
+
+	headers := withoutHeaders(msg.Headers, HeaderOriginalTopic, HeaderOriginalOffset, HeaderError, HeaderAttempts, HeaderFailedAt)
+	return append(headers,
+		kafkago.Header{Key: HeaderOriginalTopic, Value: []byte(originalTopic)},
+		kafkago.Header{Key: HeaderOriginalOffset, Value: []byte(originalOffset)},
+		kafkago.Header{Key: HeaderError, Value: []byte(cause.Error())},
+		kafkago.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafkago.Header{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+}
+
+func header(msg kafkago.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func withoutHeaders(headers []kafkago.Header, keys ...string) []kafkago.Header {
+	var kept []kafkago.Header
+	for _, h := range headers {
+		drop := false
+		for _, key := range keys {
+			if h.Key == key {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+func toDeadLetter(msg kafkago.Message) *DeadLetter {
+	attempts, _ := strconv.Atoi(header(msg, HeaderAttempts))
+	failedAt, _ := time.Parse(time.RFC3339, header(msg, HeaderFailedAt))
+	return &DeadLetter{
+		Offset:        msg.Offset,
+		OriginalTopic: header(msg, HeaderOriginalTopic),
+		Payload:       msg.Value,
+		Error:         header(msg, HeaderError),
+		Attempts:      attempts,
+		FailedAt:      failedAt,
+	}
+}
+
+func (e *event) dialDeadLetters(ctx context.Context) (*kafkago.Conn, int64, int64, error) {
+	conn, err := kafkago.DialLeader(ctx, "tcp", e.brokers[0], e.deadLetterTopic(), 0)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		conn.Close()
+		return nil, 0, 0, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetReadDeadline(deadline)
+	} else {
+		_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	}
+	return conn, first, last, nil
+}
+
+// ListDeadLetters reads up to limit messages of the dead-letter topic starting
+// at offset, and returns the offset to continue from.
+func (e *event) ListDeadLetters(ctx context.Context, offset int64, limit int) ([]*DeadLetter, int64, error) {
+	conn, first, last, err := e.dialDeadLetters(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	if offset < first {
+		offset = first
+	}
+	if offset >= last {
+		return nil, last, nil
+	}
+	if _, err = conn.Seek(offset, kafkago.SeekAbsolute); err != nil {
+		return nil, 0, err
+	}
+
+	var deadLetters []*DeadLetter
+	for offset < last && len(deadLetters) < limit {
+		msg, err := conn.ReadMessage(maxMessageBytes)
+		if err != nil {
+			return nil, 0, err
+		}
+		deadLetters = append(deadLetters, toDeadLetter(msg))
+		offset = msg.Offset + 1
+	}
+	return deadLetters, offset, nil
+}
+
+// ReplayDeadLetter publishes the dead-lettered payload back to its original
+// topic, where it starts the retry chain again.
+func (e *event) ReplayDeadLetter(ctx context.Context, offset int64) (*DeadLetter, error) {
+	conn, first, last, err := e.dialDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if offset < first || offset >= last {
+		return nil, errutils.ErrDeadLetterNotFound
+	}
+	if _, err = conn.Seek(offset, kafkago.SeekAbsolute); err != nil {
+		return nil, err
+	}
+	msg, err := conn.ReadMessage(maxMessageBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetter := toDeadLetter(msg)
+	topic := deadLetter.OriginalTopic
+	if topic == "" {
+		topic = e.topic
+	}
+
+	headers := withoutHeaders(msg.Headers,
+		HeaderOriginalTopic, HeaderOriginalOffset, HeaderError, HeaderAttempts, HeaderFailedAt, HeaderReplayedFrom)
+	headers = append(headers, kafkago.Header{Key: HeaderReplayedFrom, Value: []byte(strconv.FormatInt(offset, 10))})
+
+	if err = e.write(ctx, topic, kafkago.Message{Key: msg.Key, Value: msg.Value, Headers: headers}); err != nil {
+		return nil, err
+	}
+	return deadLetter, nil
+}
@@ -2,6 +2,7 @@ package event
 
 import (
 	"context"
+	"fmt"
 	kafkago "github.com/segmentio/kafka-go"
 	"log"
 	"transaction/platform/kafka"
@@ -11,6 +12,8 @@ type Client interface {
 	CreateTopic() error
 	Publish(ctx context.Context, payload []byte) error
 	RegisterHandler(ctx context.Context, handler Function) error
+	ListDeadLetters(ctx context.Context, offset int64, limit int) ([]*DeadLetter, int64, error)
+	ReplayDeadLetter(ctx context.Context, offset int64) (*DeadLetter, error)
 }
 
 type Options func(*event)
@@ -27,6 +30,14 @@ func WithBroker(broker string) Options {
 	}
 }
 
+// WithRetryPolicy sets how a failed message is retried in place before it is
+// moved to the next retry topic or to the dead-letter topic.
+func WithRetryPolicy(policy RetryPolicy) Options {
+	return func(e *event) {
+		e.retry = policy
+	}
+}
+
 type Function func(ctx context.Context, payload []byte) ([]byte, error)
 
 type event struct {
@@ -34,70 +45,127 @@ type event struct {
 	maxAttempts int
 	kafka       kafka.Client
 	brokers     []string
+	retry       RetryPolicy
+	write       func(ctx context.Context, topic string, msg kafkago.Message) error
 }
 
 func (e *event) CreateTopic() error {
-	return e.kafka.Conn().CreateTopics(kafkago.TopicConfig{Topic: e.topic, NumPartitions: 1, ReplicationFactor: 1})
+	configs := []kafkago.TopicConfig{{Topic: e.topic, NumPartitions: 1, ReplicationFactor: 1}}
+	for stage := range e.retry.TopicDelays {
+		configs = append(configs, kafkago.TopicConfig{Topic: e.retryTopic(stage + 1), NumPartitions: 1, ReplicationFactor: 1})
+	}
+	configs = append(configs, kafkago.TopicConfig{Topic: e.deadLetterTopic(), NumPartitions: 1, ReplicationFactor: 1})
+	return e.kafka.Conn().CreateTopics(configs...)
 }
 
 func (e *event) Publish(ctx context.Context, payload []byte) error {
+	return e.write(ctx, e.topic, kafkago.Message{Value: payload})
+}
+
+func (e *event) writeMessage(ctx context.Context, topic string, msg kafkago.Message) error {
 	w := &kafkago.Writer{
 		Addr:                   kafkago.TCP(e.brokers...),
-		Topic:                  e.topic,
+		Topic:                  topic,
 		MaxAttempts:            e.maxAttempts,
 		Transport:              kafkago.DefaultTransport,
 		AllowAutoTopicCreation: true,
 	}
 
-	err := w.WriteMessages(ctx, kafkago.Message{Value: payload})
-	if err != nil {
-		log.Print("failed to write messages:", err)
+	err := w.WriteMessages(ctx, kafkago.Message{Key: msg.Key, Value: msg.Value, Headers: msg.Headers})
+	if closeErr := w.Close(); closeErr != nil {
+		log.Print("failed to close writer:", closeErr)
 	}
+	return err
+}
 
-	if err = w.Close(); err != nil {
-		log.Print("failed to close writer:", err)
-	}
-	return nil
+func (e *event) retryTopic(stage int) string {
+	return fmt.Sprintf("%s.retry.%d", e.topic, stage)
+}
+
+func (e *event) deadLetterTopic() string {
+	return e.topic + ".dlt"
 }
 
-func (e *event) handleMessages(ctx context.Context, handler Function) {
+// handleMessages consumes one stage of the chain: stage 0 is the main topic
+// and stage n is the n-th retry topic. The offset is only committed once the
+// message was handled or handed over to the next stage.
+func (e *event) handleMessages(ctx context.Context, stage int, handler Function) {
+	topic := e.topic
+	if stage > 0 {
+		topic = e.retryTopic(stage)
+	}
+
 	r := kafkago.NewReader(kafkago.ReaderConfig{
 		Brokers: e.brokers,
-		Topic:   e.topic,
-		GroupID: e.topic + "_handler",
+		Topic:   topic,
+		GroupID: topic + "_handler",
 	})
-	log.Printf("listener registered for topic [%s]\n", e.topic)
+	log.Printf("listener registered for topic [%s]\n", topic)
 
 	for {
-		select {
-		case <-ctx.Done():
-			if err := r.Close(); err != nil {
-				log.Print("failed to close reader:", err)
+		msg, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				if err = r.Close(); err != nil {
+					log.Print("failed to close reader:", err)
+				}
+				return
 			}
-			return
-		default:
-			msg, err := r.FetchMessage(ctx)
-			if err != nil {
-				log.Println("Failed to fetch message")
+			log.Println("Failed to fetch message:", err)
+			continue
+		}
+
+		if stage > 0 {
+			// retry topics hold messages back until their delay has passed
+			if err = e.retry.waitUntilDue(ctx, stage, msg.Time); err != nil {
+				continue
 			}
+		}
 
-			if msg.Value != nil {
-				log.Printf("Message received: [%s]\n", e.topic)
-				_, err = handler(ctx, msg.Value)
-				if err != nil {
-					log.Print("failed to handle message:", err)
-				}
+		log.Printf("Message received: [%s]\n", topic)
+		if err = e.process(ctx, stage, msg, handler); err != nil {
+			log.Printf("failed to process message from [%s] at offset %d: %v", topic, msg.Offset, err)
+			continue
+		}
 
-				if err = r.CommitMessages(ctx, msg); err != nil {
-					log.Println("Failed to commit messages")
-				}
-			}
+		if err = r.CommitMessages(ctx, msg); err != nil {
+			log.Println("Failed to commit messages:", err)
 		}
 	}
 }
 
+// process runs the handler with backoff and, when every attempt failed, moves
+// the message to the next stage. It only returns an error when the message
+// could not be handed over, so the caller must not commit it.
+func (e *event) process(ctx context.Context, stage int, msg kafkago.Message, handler Function) error {
+	attempts, handlerErr := e.retry.run(ctx, func() error {
+		_, err := handler(ctx, msg.Value)
+		return err
+	})
+	if handlerErr == nil {
+		return nil
+	}
+	log.Print("failed to handle message:", handlerErr)
+
+	next := e.deadLetterTopic()
+	if stage < len(e.retry.TopicDelays) && !isPermanent(handlerErr) {
+		next = e.retryTopic(stage + 1)
+	}
+
+	forward := kafkago.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: failureHeaders(msg, e.topic, handlerErr, attempts),
+	}
+	return e.retry.forever(ctx, func() error {
+		return e.write(ctx, next, forward)
+	})
+}
+
 func (e *event) RegisterHandler(ctx context.Context, handler Function) error {
-	go e.handleMessages(ctx, handler)
+	for stage := 0; stage <= len(e.retry.TopicDelays); stage++ {
+		go e.handleMessages(ctx, stage, handler)
+	}
 	return nil
 }
 
@@ -105,7 +173,9 @@ func NewEvent(client kafka.Client, topic string, opts ...Options) Client {
 	e := &event{
 		kafka: client,
 		topic: topic,
+		retry: DefaultRetryPolicy(),
 	}
+	e.write = e.writeMessage
 	for _, f := range opts {
 		f(e)
 	}
@@ -1 +1,156 @@
 package event
+
+import (
+	"context"
+	"errors"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type written struct {
+	topic string
+	msg   kafkago.Message
+}
+
+func newTestEvent(writes *[]written, writeErr error) *event {
+	e := NewEvent(nil, "pix", WithRetryPolicy(RetryPolicy{
+		Attempts:       2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		TopicDelays:    []time.Duration{time.Second},
+	})).(*event)
+	e.write = func(ctx context.Context, topic string, msg kafkago.Message) error {
+		*writes = append(*writes, written{topic: topic, msg: msg})
+		return writeErr
+	}
+	return e
+}
+
+func TestProcess(t *testing.T) {
+	cases := []struct {
+		name      string
+		stage     int
+		failures  int
+		err       error
+		wantCalls int
+		wantTopic string
+	}{
+		{
+			name:      "handled on first attempt",
+			stage:     0,
+			failures:  0,
+			wantCalls: 1,
+		},
+		{
+			name:      "handled after retry",
+			stage:     0,
+			failures:  1,
+			wantCalls: 2,
+		},
+		{
+			name:      "main topic failure goes to retry topic",
+			stage:     0,
+			failures:  5,
+			wantCalls: 2,
+			wantTopic: "pix.retry.1",
+		},
+		{
+			name:      "last retry topic failure goes to dead-letter topic",
+			stage:     1,
+			failures:  5,
+			wantCalls: 2,
+			wantTopic: "pix.dlt",
+		},
+		{
+			name:      "permanent failure skips retries",
+			stage:     0,
+			failures:  5,
+			err:       Permanent(errors.New("MOCK-ERROR")),
+			wantCalls: 1,
+			wantTopic: "pix.dlt",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var writes []written
+			e := newTestEvent(&writes, nil)
+
+			calls := 0
+			handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+				calls++
+				if calls <= c.failures {
+					if c.err != nil {
+						return nil, c.err
+					}
+					return nil, errors.New("MOCK-ERROR")
+				}
+				return nil, nil
+			}
+
+			err := e.process(context.Background(), c.stage, kafkago.Message{Value: []byte("payload"), Offset: 7}, handler)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.wantCalls, calls)
+			if c.wantTopic == "" {
+				assert.Empty(t, writes)
+				return
+			}
+			assert.Len(t, writes, 1)
+			assert.Equal(t, c.wantTopic, writes[0].topic)
+			assert.Equal(t, []byte("payload"), writes[0].msg.Value)
+			assert.Equal(t, "MOCK-ERROR", header(writes[0].msg, HeaderError))
+			assert.Equal(t, "pix", header(writes[0].msg, HeaderOriginalTopic))
+		})
+	}
+}
+
+func TestProcessForwardFailureIsNotCommitted(t *testing.T) {
+	var writes []written
+	e := newTestEvent(&writes, errors.New("MOCK-WRITE-ERROR"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+		return nil, errors.New("MOCK-ERROR")
+	}
+	err := e.process(ctx, 0, kafkago.Message{Value: []byte("payload")}, handler)
+
+	assert.EqualError(t, err, "MOCK-WRITE-ERROR")
+	assert.NotEmpty(t, writes)
+}
+
+func TestFailureHeaders(t *testing.T) {
+	first := kafkago.Message{
+		Offset:  7,
+		Headers: []kafkago.Header{{Key: "trace-id", Value: []byte("abc")}},
+	}
+	first.Headers = failureHeaders(first, "pix", errors.New("first"), 3)
+
+	second := failureHeaders(kafkago.Message{Offset: 2, Headers: first.Headers}, "pix", errors.New("second"), 3)
+	msg := kafkago.Message{Offset: 2, Headers: second}
+
+	assert.Equal(t, "abc", header(msg, "trace-id"))
+	assert.Equal(t, "pix", header(msg, HeaderOriginalTopic))
+	assert.Equal(t, "7", header(msg, HeaderOriginalOffset))
+	assert.Equal(t, "second", header(msg, HeaderError))
+	assert.Equal(t, "6", header(msg, HeaderAttempts))
+	assert.Len(t, second, 6)
+
+	deadLetter := toDeadLetter(msg)
+	assert.Equal(t, 6, deadLetter.Attempts)
+	assert.Equal(t, "pix", deadLetter.OriginalTopic)
+	assert.False(t, deadLetter.FailedAt.IsZero())
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, p.backoff(4))
+	assert.Equal(t, time.Second, p.backoff(5))
+}
@@ -0,0 +1,46 @@
+package event
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	proto "transaction/proto/v1"
+)
+
+const (
+	HeaderOriginalTopic  = "x-original-topic"
+	HeaderOriginalOffset = "x-original-offset"
+	HeaderError          = "x-error"
+	HeaderAttempts       = "x-attempts"
+	HeaderFailedAt       = "x-failed-at"
+	HeaderReplayedFrom   = "x-replayed-from"
+)
+
+// DeadLetter is a message that failed every retry stage, read back from the
+// dead-letter topic with the metadata of its last failure.
+type DeadLetter struct {
+	Offset        int64
+	OriginalTopic string
+	Payload       []byte
+	Error         string
+	Attempts      int
+	FailedAt      time.Time
+}
+
+func ToProto(deadLetter *DeadLetter) *proto.DeadLetter {
+	return &proto.DeadLetter{
+		Offset:        deadLetter.Offset,
+		OriginalTopic: deadLetter.OriginalTopic,
+		Payload:       deadLetter.Payload,
+		Error:         deadLetter.Error,
+		Attempts:      int32(deadLetter.Attempts),
+		FailedAt:      timestamppb.New(deadLetter.FailedAt),
+	}
+}
+
+func ToProtoList(deadLetters []*DeadLetter, nextOffset int64) *proto.DeadLetters {
+	list := &proto.DeadLetters{NextOffset: nextOffset}
+	for _, deadLetter := range deadLetters {
+		list.DeadLetters = append(list.DeadLetters, ToProto(deadLetter))
+	}
+	return list
+}
@@ -0,0 +1,115 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks a handler error that retrying cannot fix. The message goes
+// straight to the dead-letter topic.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// RetryPolicy retries a message Attempts times in place with exponential
+// backoff. When those fail the message goes to the next retry topic, one per
+// entry of TopicDelays, and finally to the dead-letter topic.
+type RetryPolicy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	TopicDelays    []time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:       3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		TopicDelays:    []time.Duration{30 * time.Second, 5 * time.Minute},
+	}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// run calls fn until it succeeds or the attempts are exhausted, and returns
+// how many attempts were made.
+func (p RetryPolicy) run(ctx context.Context, fn func() error) (int, error) {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil {
+			return attempt, nil
+		}
+		if attempt == attempts || isPermanent(err) {
+			return attempt, err
+		}
+		if waitErr := sleep(ctx, p.backoff(attempt)); waitErr != nil {
+			return attempt, err
+		}
+	}
+	return attempts, err
+}
+
+// forever calls fn with backoff until it succeeds or ctx is done.
+func (p RetryPolicy) forever(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if waitErr := sleep(ctx, p.backoff(attempt)); waitErr != nil {
+			return err
+		}
+	}
+}
+
+func (p RetryPolicy) waitUntilDue(ctx context.Context, stage int, producedAt time.Time) error {
+	return sleep(ctx, time.Until(producedAt.Add(p.TopicDelays[stage-1])))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -14,6 +14,25 @@ const (
 	Random Type = "random"
 )
 
+// EventsTopic carries the key changes made in the profile service, which owns
+// the keys.
+const EventsTopic = "key_events_topic"
+
+// ClaimsTopic carries the keys moved by a claim back to the profile service,
+// as OpClaim events.
+const ClaimsTopic = "key_claims_topic"
+
+type Op string
+
+const (
+	OpUpsert Op = "UPSERT"
+	OpDelete Op = "DELETE"
+	OpClaim  Op = "CLAIM"
+)
+
+// Key is the copy of a profile key. Version is the version of the profile row
+// it was last written from. A deleted key is kept as a tombstone with only its
+// id, Version and DeletedAt, so a late event can not bring it back.
 type Key struct {
 	Id        string `dynamodbav:"PK"`
 	Agency    string
@@ -22,8 +41,59 @@ type Key struct {
 	Account   int64
 	Name      string
 	Type      Type
+	ClaimID   string `dynamodbav:",omitempty"`
+	Version   int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt *time.Time `dynamodbav:",omitempty"`
+}
+
+// Event is a change of a profile key. Events of one key are applied in
+// Version order, older ones are ignored.
+type Event struct {
+	ID      string `json:"id"`
+	Op      Op     `json:"op"`
+	Account struct {
+		Name   int64  `json:"name"`
+		Cpf    string `json:"cpf"`
+		Agency string `json:"agency"`
+		Bank   string `json:"bank"`
+	} `json:"account"`
+	Name       string    `json:"name"`
+	Type       Type      `json:"type"`
+	Version    int64     `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func EventToKey(event *Event) *Key {
+	return &Key{
+		Id:        event.ID,
+		Agency:    event.Account.Agency,
+		Bank:      event.Account.Bank,
+		Cpf:       event.Account.Cpf,
+		Account:   event.Account.Name,
+		Name:      event.Name,
+		Type:      event.Type,
+		Version:   event.Version,
+		UpdatedAt: event.OccurredAt,
+	}
+}
+
+// KeyToEvent is the event of key at its current Version.
+func KeyToEvent(op Op, key *Key, occurredAt time.Time) *Event {
+	event := &Event{
+		ID:         key.Id,
+		Op:         op,
+		Name:       key.Name,
+		Type:       key.Type,
+		Version:    key.Version,
+		OccurredAt: occurredAt,
+	}
+	event.Account.Name = key.Account
+	event.Account.Cpf = key.Cpf
+	event.Account.Agency = key.Agency
+	event.Account.Bank = key.Bank
+	return event
 }
 
 type KeyRequest struct {
@@ -42,6 +112,8 @@ func ProtoToKey(key *proto.Key) *Key {
 		Account: key.Account.Name,
 		Name:    key.Name,
 		Type:    Type(key.Type.String()),
+		Id:      key.Id,
+		Version: key.Version,
 	}
 }
 
@@ -54,9 +126,18 @@ func ToProto(key *Key) *proto.KeyResponse {
 			Cpf:    key.Cpf,
 			Name:   key.Account,
 		},
-		Name: key.Name,
-		Type: proto.Type(proto.Type_value[string(key.Type)]),
+		Name:    key.Name,
+		Type:    proto.Type(proto.Type_value[string(key.Type)]),
+		Version: key.Version,
+	}
+}
+
+func ToProtoList(keys []*Key) *proto.ListKeys {
+	list := make([]*proto.KeyResponse, len(keys))
+	for i := range keys {
+		list[i] = ToProto(keys[i])
 	}
+	return &proto.ListKeys{Keys: list}
 }
 
 func ProtoToKeyListRequest(request *proto.ListKeyRequest) *ListKeyRequest {
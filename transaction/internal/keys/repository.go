@@ -8,16 +8,28 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"time"
 	"transaction/internal/cfg"
+	"transaction/internal/errutils"
 	"transaction/platform/dynamo"
 )
 
+// The keys table needs two global secondary indexes: NameIndex (Name) to find
+// a key by its value and AccountIndex (Account) to list and count the keys of an
+// account.
 type Repository interface {
 	CreateKey(ctx context.Context, key *Key) (*Key, error)
 	UpdateKey(ctx context.Context, key *Key) (*Key, error)
 	ListKey(ctx context.Context, ids []string) ([]*Key, error)
 	DeleteKey(ctx context.Context, id string) error
 	FindKey(ctx context.Context, key string) (*Key, error)
+	CountKeys(ctx context.Context, account int64) (int, error)
+	ListAccountKeys(ctx context.Context, account int64) ([]*Key, error)
+	ApplyKey(ctx context.Context, key *Key) error
+	RemoveKey(ctx context.Context, id string, account int64, version int64, deletedAt time.Time) error
+	ReserveForClaim(ctx context.Context, keyID, claimID string) error
+	ReleaseClaim(ctx context.Context, keyID, claimID string) error
+	TransferKey(ctx context.Context, key *Key, claimID string) (*Key, error)
 }
 
 type repository struct {
@@ -94,12 +106,16 @@ func (r repository) ListKey(ctx context.Context, ids []string) ([]*Key, error) {
 		return nil, err
 	}
 
-	listKey := make([]*Key, len(value.Responses[r.cfg.DynamodbConfig.KeysTable]))
+	listKey := make([]*Key, 0, len(value.Responses[r.cfg.DynamodbConfig.KeysTable]))
 	for i := range value.Responses[r.cfg.DynamodbConfig.KeysTable] {
-		err = attributevalue.UnmarshalMap(value.Responses[r.cfg.DynamodbConfig.KeysTable][i], &listKey[i])
+		var key Key
+		err = attributevalue.UnmarshalMap(value.Responses[r.cfg.DynamodbConfig.KeysTable][i], &key)
 		if err != nil {
 			return nil, err
 		}
+		if key.DeletedAt == nil {
+			listKey = append(listKey, &key)
+		}
 	}
 	return listKey, nil
 }
@@ -142,6 +158,200 @@ func (r repository) FindKey(ctx context.Context, key string) (*Key, error) {
 	return &keyModel, nil
 }
 
+func (r repository) CountKeys(ctx context.Context, account int64) (int, error) {
+	keyCond := expression.Key("Account").Equal(expression.Value(account))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return 0, errors.New("failed to build expression")
+	}
+
+	value, err := r.db.DB().Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.KeysTable),
+		IndexName:                 aws.String("AccountIndex"),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		Select:                    types.SelectCount,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(value.Count), nil
+}
+
+func (r repository) ListAccountKeys(ctx context.Context, account int64) ([]*Key, error) {
+	keyCond := expression.Key("Account").Equal(expression.Value(account))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	value, err := r.db.DB().Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.KeysTable),
+		IndexName:                 aws.String("AccountIndex"),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(value.Items))
+	if err = attributevalue.UnmarshalListOfMaps(value.Items, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ApplyKey writes the key from a profile event unless the stored key is at
+// the same or a later version or on another account. An open claim on the key
+// is kept.
+func (r repository) ApplyKey(ctx context.Context, key *Key) error {
+	upd := expression.Set(expression.Name("Agency"), expression.Value(key.Agency)).
+		Set(expression.Name("Bank"), expression.Value(key.Bank)).
+		Set(expression.Name("Cpf"), expression.Value(key.Cpf)).
+		Set(expression.Name("Account"), expression.Value(key.Account)).
+		Set(expression.Name("Name"), expression.Value(key.Name)).
+		Set(expression.Name("Type"), expression.Value(key.Type)).
+		Set(expression.Name("Version"), expression.Value(key.Version)).
+		Set(expression.Name("UpdatedAt"), expression.Value(key.UpdatedAt)).
+		Set(expression.Name("CreatedAt"), expression.IfNotExists(expression.Name("CreatedAt"), expression.Value(key.UpdatedAt))).
+		Remove(expression.Name("DeletedAt"))
+	return r.updateVersioned(ctx, key.Id, key.Account, key.Version, upd)
+}
+
+// RemoveKey turns the key of account into a tombstone. Without Name and
+// Account it drops out of both indexes, so it is no longer found nor counted.
+func (r repository) RemoveKey(ctx context.Context, id string, account int64, version int64, deletedAt time.Time) error {
+	upd := expression.Set(expression.Name("Version"), expression.Value(version)).
+		Set(expression.Name("DeletedAt"), expression.Value(deletedAt)).
+		Set(expression.Name("UpdatedAt"), expression.Value(deletedAt)).
+		Remove(expression.Name("Agency")).
+		Remove(expression.Name("Bank")).
+		Remove(expression.Name("Cpf")).
+		Remove(expression.Name("Account")).
+		Remove(expression.Name("Name")).
+		Remove(expression.Name("Type")).
+		Remove(expression.Name("ClaimID"))
+	return r.updateVersioned(ctx, id, account, version, upd)
+}
+
+// updateVersioned applies a profile event of the key on account. A claim moves
+// the key to another account in this table first, so events the donor account
+// sent before profile took the move are dropped whatever their version.
+func (r repository) updateVersioned(ctx context.Context, id string, account int64, version int64, upd expression.UpdateBuilder) error {
+	owner := expression.AttributeNotExists(expression.Name("Account")).
+		Or(expression.Name("Account").Equal(expression.Value(account)))
+	cond := expression.AttributeNotExists(expression.Name("PK")).
+		Or(expression.Name("Version").LessThan(expression.Value(version)).And(owner))
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.KeysTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrStaleKeyEvent
+		}
+		return err
+	}
+	return nil
+}
+
+// ReserveForClaim marks the key with the claim, so a key has one open claim
+// at a time.
+func (r repository) ReserveForClaim(ctx context.Context, keyID, claimID string) error {
+	upd := expression.Set(expression.Name("ClaimID"), expression.Value(claimID))
+	cond := expression.AttributeExists(expression.Name("PK")).
+		And(expression.AttributeNotExists(expression.Name("ClaimID")))
+	err := r.updateForClaim(ctx, keyID, upd, cond)
+	if errors.Is(err, errutils.ErrClaimConflict) {
+		return errutils.ErrKeyUnderClaim
+	}
+	return err
+}
+
+// ReleaseClaim clears the claim mark. A key no longer marked by the claim is
+// left alone.
+func (r repository) ReleaseClaim(ctx context.Context, keyID, claimID string) error {
+	upd := expression.Remove(expression.Name("ClaimID"))
+	cond := expression.Name("ClaimID").Equal(expression.Value(claimID))
+	err := r.updateForClaim(ctx, keyID, upd, cond)
+	if errors.Is(err, errutils.ErrClaimConflict) {
+		return nil
+	}
+	return err
+}
+
+// TransferKey moves the key to the account in key and clears the claim mark,
+// as long as the key is still marked by the claim. The move is a new version
+// of the key, so profile events written before it are stale. It returns the
+// key as moved.
+func (r repository) TransferKey(ctx context.Context, key *Key, claimID string) (*Key, error) {
+	upd := expression.Set(expression.Name("Account"), expression.Value(key.Account)).
+		Set(expression.Name("Agency"), expression.Value(key.Agency)).
+		Set(expression.Name("Bank"), expression.Value(key.Bank)).
+		Set(expression.Name("Cpf"), expression.Value(key.Cpf)).
+		Set(expression.Name("Version"), expression.Name("Version").Plus(expression.Value(1))).
+		Set(expression.Name("UpdatedAt"), expression.Value(key.UpdatedAt)).
+		Remove(expression.Name("ClaimID"))
+	cond := expression.Name("ClaimID").Equal(expression.Value(claimID))
+	output, err := r.updateItemForClaim(ctx, key.Id, upd, cond, types.ReturnValueAllNew)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved Key
+	if err = attributevalue.UnmarshalMap(output.Attributes, &moved); err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
+
+func (r repository) updateForClaim(ctx context.Context, keyID string, upd expression.UpdateBuilder, cond expression.ConditionBuilder) error {
+	_, err := r.updateItemForClaim(ctx, keyID, upd, cond, types.ReturnValueNone)
+	return err
+}
+
+func (r repository) updateItemForClaim(ctx context.Context, keyID string, upd expression.UpdateBuilder, cond expression.ConditionBuilder, returnValues types.ReturnValue) (*dynamodb.UpdateItemOutput, error) {
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	output, err := r.db.DB().UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: keyID},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.KeysTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+		ReturnValues:              returnValues,
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return nil, errutils.ErrClaimConflict
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
 func NewRepository(db dynamo.Client, config *cfg.Config) Repository {
 	return &repository{
 		db:  db,
@@ -2,9 +2,13 @@ package key
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
+	"log"
 	"time"
+	"transaction/internal/errutils"
+	"transaction/internal/event"
 )
 
 type Service interface {
@@ -13,25 +17,51 @@ type Service interface {
 	ListKey(ctx context.Context, req *ListKeyRequest) ([]*Key, error)
 	DeleteKey(ctx context.Context, id *KeyRequest) error
 	FindKey(ctx context.Context, key *KeyRequest) (*Key, error)
+	ListAccountKeys(ctx context.Context, account int64) ([]*Key, error)
+	Apply(ctx context.Context, event *Event) error
+	Handler(ctx context.Context, payload []byte) ([]byte, error)
 }
 
 type service struct {
 	repo Repository
 }
 
+// CreateKey validates the key for its type and generates the value of random
+// (EVP) keys, so a client can not choose it.
 func (s *service) CreateKey(ctx context.Context, key *Key) (*Key, error) {
+	if key.Type == Random {
+		key.Name = uuid.New().String()
+	}
+	if err := Normalize(key); err != nil {
+		return nil, err
+	}
+
 	findKey, err := s.repo.FindKey(ctx, key.Name)
 	if err != nil {
 		return nil, err
 	}
 
 	if findKey != nil {
-		return nil, errors.New("key already exists")
+		return nil, errutils.ErrKeyAlreadyExists
+	}
+
+	count, err := s.repo.CountKeys(ctx, key.Account)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxKeysPerAccount {
+		return nil, errutils.ErrKeyLimitReached
 	}
 
+	// the profile service sends its own id, so both stores share it
+	if key.Id == "" {
+		key.Id = uuid.New().String()
+	}
+	if key.Version == 0 {
+		key.Version = 1
+	}
 	key.CreatedAt = time.Now()
 	key.UpdatedAt = time.Now()
-	key.Id = uuid.New().String()
 	return s.repo.CreateKey(ctx, key)
 }
 
@@ -58,6 +88,44 @@ func (s *service) FindKey(ctx context.Context, key *KeyRequest) (*Key, error) {
 	return keys, nil
 }
 
+func (s *service) ListAccountKeys(ctx context.Context, account int64) ([]*Key, error) {
+	return s.repo.ListAccountKeys(ctx, account)
+}
+
+// Apply writes a key change of the profile service. Events are delivered at
+// least once and maybe out of order, so an event older than the stored key is
+// dropped.
+func (s *service) Apply(ctx context.Context, event *Event) error {
+	var err error
+	switch event.Op {
+	case OpUpsert:
+		err = s.repo.ApplyKey(ctx, EventToKey(event))
+	case OpDelete:
+		err = s.repo.RemoveKey(ctx, event.ID, event.Account.Name, event.Version, event.OccurredAt)
+	default:
+		return errutils.ErrInvalidKeyEvent
+	}
+	if errors.Is(err, errutils.ErrStaleKeyEvent) {
+		log.Printf("skipping key event %s version %d: %v", event.ID, event.Version, err)
+		return nil
+	}
+	return err
+}
+
+func (s *service) Handler(ctx context.Context, payload []byte) ([]byte, error) {
+	var keyEvent Event
+	if err := json.Unmarshal(payload, &keyEvent); err != nil {
+		log.Println("error unmarshalling key event")
+		return nil, event.Permanent(err)
+	}
+
+	err := s.Apply(ctx, &keyEvent)
+	if errors.Is(err, errutils.ErrInvalidKeyEvent) {
+		return nil, event.Permanent(err)
+	}
+	return nil, err
+}
+
 func NewService(repo Repository) Service {
 	return &service{
 		repo: repo,
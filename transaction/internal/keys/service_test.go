@@ -0,0 +1,180 @@
+package key
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"transaction/internal/errutils"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+}
+
+func (m *mockRepo) CreateKey(ctx context.Context, key *Key) (*Key, error) {
+	args := m.Called(key)
+	return key, args.Error(0)
+}
+
+func (m *mockRepo) FindKey(ctx context.Context, key string) (*Key, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Key), args.Error(1)
+}
+
+func (m *mockRepo) CountKeys(ctx context.Context, account int64) (int, error) {
+	args := m.Called(account)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockRepo) ApplyKey(ctx context.Context, key *Key) error {
+	args := m.Called(key.Id, key.Version)
+	return args.Error(0)
+}
+
+func (m *mockRepo) RemoveKey(ctx context.Context, id string, account int64, version int64, deletedAt time.Time) error {
+	args := m.Called(id, account, version)
+	return args.Error(0)
+}
+
+func TestCreateKey(t *testing.T) {
+	cases := []struct {
+		name     string
+		key      *Key
+		mockFunc func(repo *mockRepo)
+		err      error
+	}{
+		{
+			name: "success",
+			key:  &Key{Account: 1, Type: Email, Name: "Fulano@Pix.com"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindKey", "fulano@pix.com").Return(nil, nil)
+				repo.On("CountKeys", int64(1)).Return(4, nil)
+				repo.On("CreateKey", mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "random key is generated by the server",
+			key:  &Key{Account: 1, Type: Random, Name: "chosen-by-client"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindKey", mock.MatchedBy(func(name string) bool {
+					evp, err := uuid.Parse(name)
+					return err == nil && evp.Version() == 4
+				})).Return(nil, nil)
+				repo.On("CountKeys", int64(1)).Return(0, nil)
+				repo.On("CreateKey", mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:     "failed because the key is invalid for its type",
+			key:      &Key{Account: 1, Type: Phone, Name: "fulano@pix.com"},
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrInvalidKey,
+		},
+		{
+			name: "failed because the key already exists",
+			key:  &Key{Account: 1, Type: Phone, Name: "+5511987654321"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindKey", "+5511987654321").Return(&Key{Id: "1"}, nil)
+			},
+			err: errutils.ErrKeyAlreadyExists,
+		},
+		{
+			name: "failed because the account has the maximum number of keys",
+			key:  &Key{Account: 1, Type: Phone, Name: "+5511987654321"},
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindKey", "+5511987654321").Return(nil, nil)
+				repo.On("CountKeys", int64(1)).Return(MaxKeysPerAccount, nil)
+			},
+			err: errutils.ErrKeyLimitReached,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			tc.mockFunc(repo)
+
+			got, err := NewService(repo).CreateKey(context.Background(), tc.key)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.NotEmpty(t, got.Id)
+				assert.NotEqual(t, "chosen-by-client", got.Name)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	cases := []struct {
+		name     string
+		payload  string
+		mockFunc func(repo *mockRepo)
+		err      error
+	}{
+		{
+			name:    "upsert",
+			payload: `{"id":"key-1","op":"UPSERT","account":{"name":1},"name":"+5511987654321","type":"phone","version":2}`,
+			mockFunc: func(repo *mockRepo) {
+				repo.On("ApplyKey", "key-1", int64(2)).Return(nil)
+			},
+		},
+		{
+			name:    "delete",
+			payload: `{"id":"key-1","op":"DELETE","account":{"name":1},"version":3}`,
+			mockFunc: func(repo *mockRepo) {
+				repo.On("RemoveKey", "key-1", int64(1), int64(3)).Return(nil)
+			},
+		},
+		{
+			name:    "event older than the stored key is dropped",
+			payload: `{"id":"key-1","op":"UPSERT","version":1}`,
+			mockFunc: func(repo *mockRepo) {
+				repo.On("ApplyKey", "key-1", int64(1)).Return(errutils.ErrStaleKeyEvent)
+			},
+		},
+		{
+			name:    "delete by the donor of a claimed key is dropped",
+			payload: `{"id":"key-1","op":"DELETE","account":{"name":1},"version":5}`,
+			mockFunc: func(repo *mockRepo) {
+				repo.On("RemoveKey", "key-1", int64(1), int64(5)).Return(errutils.ErrStaleKeyEvent)
+			},
+		},
+		{
+			name:    "failed write is retried",
+			payload: `{"id":"key-1","op":"DELETE","account":{"name":1},"version":3}`,
+			mockFunc: func(repo *mockRepo) {
+				repo.On("RemoveKey", "key-1", int64(1), int64(3)).Return(assert.AnError)
+			},
+			err: assert.AnError,
+		},
+		{
+			name:     "failed because the operation is unknown",
+			payload:  `{"id":"key-1","op":"RENAME","version":3}`,
+			mockFunc: func(repo *mockRepo) {},
+			err:      errutils.ErrInvalidKeyEvent,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			tc.mockFunc(repo)
+
+			_, err := NewService(repo).Handler(context.Background(), []byte(tc.payload))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
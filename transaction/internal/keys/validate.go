@@ -0,0 +1,78 @@
+package key
+
+import (
+	"github.com/google/uuid"
+	"net/mail"
+	"regexp"
+	"strings"
+	"transaction/internal/errutils"
+)
+
+// MaxKeysPerAccount is the regulatory cap of keys for an individual account.
+const MaxKeysPerAccount = 5
+
+// maxEmailLen is the longest email the DICT accepts as a key.
+const maxEmailLen = 77
+
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// Normalize checks key.Name against key.Type and rewrites it in the form it is
+// stored and looked up with. A cpf key must be the cpf of the account holder.
+func Normalize(key *Key) error {
+	switch key.Type {
+	case Cpf:
+		cpf := strings.NewReplacer(".", "", "-", "").Replace(key.Name)
+		if !validCpf(cpf) {
+			return errutils.ErrInvalidKey
+		}
+		if cpf != key.Cpf {
+			return errutils.ErrKeyNotOwned
+		}
+		key.Name = cpf
+	case Phone:
+		if !phonePattern.MatchString(key.Name) {
+			return errutils.ErrInvalidKey
+		}
+	case Email:
+		email := strings.ToLower(strings.TrimSpace(key.Name))
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Name != "" || address.Address != email || len(email) > maxEmailLen {
+			return errutils.ErrInvalidKey
+		}
+		key.Name = email
+	case Random:
+		evp, err := uuid.Parse(key.Name)
+		if err != nil || evp.Version() != 4 || evp.String() != strings.ToLower(key.Name) {
+			return errutils.ErrInvalidKey
+		}
+		key.Name = evp.String()
+	default:
+		return errutils.ErrInvalidKey
+	}
+	return nil
+}
+
+func validCpf(cpf string) bool {
+	if len(cpf) != 11 || strings.Count(cpf, cpf[:1]) == 11 {
+		return false
+	}
+	for _, c := range cpf {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return cpfDigit(cpf[:9]) == cpf[9] && cpfDigit(cpf[:10]) == cpf[10]
+}
+
+// cpfDigit is the check digit that follows digits.
+func cpfDigit(digits string) byte {
+	sum := 0
+	for i := range digits {
+		sum += int(digits[i]-'0') * (len(digits) + 1 - i)
+	}
+	digit := 11 - sum%11
+	if digit > 9 {
+		digit = 0
+	}
+	return byte('0' + digit)
+}
@@ -0,0 +1,185 @@
+package mandate
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	"transaction/internal/pix"
+	proto "transaction/proto/v1"
+)
+
+type Frequency string
+
+const (
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+type Status string
+
+const (
+	StatusPending    Status = "PENDING"
+	StatusAuthorized Status = "AUTHORIZED"
+	StatusRevoked    Status = "REVOKED"
+	StatusExpired    Status = "EXPIRED"
+)
+
+type Account struct {
+	Name   int64
+	Cpf    string
+	Agency string
+	Bank   string
+}
+
+// Mandate lets Receiver charge Amount from the payer account every cycle,
+// from StartAt until EndAt. The payer authorizes it up to MaxAmount, so the
+// amount can change within that limit without a new authorization.
+//
+// Cycle counts the cycles already settled, paid or given up after every
+// attempt failed, and NextRunAt is when the current cycle runs next.
+type Mandate struct {
+	ID                string `dynamodbav:"PK"`
+	AccountID         int64
+	Account           Account
+	Receiver          string
+	Frequency         Frequency
+	Amount            float64
+	MaxAmount         float64
+	StartAt           time.Time
+	EndAt             time.Time
+	WebhookUrl        string
+	Status            Status
+	Cycle             int
+	Attempts          int
+	FailedCycles      int
+	NextRunAt         time.Time
+	LastTransactionID string
+	LastError         string
+	AuthorizedAt      time.Time
+	RevokedAt         time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// DueAt is when the given cycle, counted from zero, is due. Monthly cycles
+// keep the day of StartAt and fall back to the last day of shorter months.
+func (m *Mandate) DueAt(cycle int) time.Time {
+	if m.Frequency == FrequencyWeekly {
+		return m.StartAt.AddDate(0, 0, 7*cycle)
+	}
+
+	due := m.StartAt.AddDate(0, cycle, 0)
+	if due.Day() != m.StartAt.Day() {
+		// AddDate overflowed into the next month
+		due = due.AddDate(0, 0, -due.Day())
+	}
+	return due
+}
+
+// Ended reports whether the mandate has no cycle left after the current one.
+func (m *Mandate) Ended() bool {
+	return !m.EndAt.IsZero() && m.DueAt(m.Cycle).After(m.EndAt)
+}
+
+// TransactionID identifies the pix of the current cycle. Every attempt of the
+// cycle uses it, so a retry resumes the pix an earlier attempt left halfway
+// instead of paying the cycle twice. It is a UUID derived from the mandate id
+// and the cycle, to fit where transaction ids are stored.
+func (m *Mandate) TransactionID() string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%s-%d", m.ID, m.Cycle+1))).String()
+}
+
+func (m *Mandate) PixEvent() *pix.PixEvent {
+	event := &pix.PixEvent{
+		ID:         m.TransactionID(),
+		Receiver:   m.Receiver,
+		Amount:     decimal.NewFromFloat(m.Amount),
+		WebhookUrl: m.WebhookUrl,
+		Authorize:  true,
+	}
+	event.Account.Name = m.Account.Name
+	event.Account.Cpf = m.Account.Cpf
+	event.Account.Agency = m.Account.Agency
+	event.Account.Bank = m.Account.Bank
+	return event
+}
+
+type Request struct {
+	ID        string
+	AccountID int64
+}
+
+func ProtoToRequest(request *proto.MandateRequest) *Request {
+	return &Request{
+		ID:        request.Id,
+		AccountID: request.AccountId,
+	}
+}
+
+func ProtoToMandate(mandate *proto.Mandate) *Mandate {
+	account := Account{
+		Name:   mandate.GetAccount().GetName(),
+		Cpf:    mandate.GetAccount().GetCpf(),
+		Agency: mandate.GetAccount().GetAgency(),
+		Bank:   mandate.GetAccount().GetBank(),
+	}
+
+	var endAt time.Time
+	if mandate.EndAt != nil {
+		endAt = mandate.EndAt.AsTime()
+	}
+
+	return &Mandate{
+		ID:         mandate.Id,
+		AccountID:  account.Name,
+		Account:    account,
+		Receiver:   mandate.Receiver,
+		Frequency:  Frequency(mandate.Frequency.String()),
+		Amount:     mandate.Amount,
+		MaxAmount:  mandate.MaxAmount,
+		StartAt:    mandate.GetStartAt().AsTime(),
+		EndAt:      endAt,
+		WebhookUrl: mandate.WebhookUrl,
+	}
+}
+
+func ToProto(mandate *Mandate) *proto.Mandate {
+	var endAt *timestamppb.Timestamp
+	if !mandate.EndAt.IsZero() {
+		endAt = timestamppb.New(mandate.EndAt)
+	}
+
+	return &proto.Mandate{
+		Id: mandate.ID,
+		Account: &proto.Account{
+			Name:   mandate.Account.Name,
+			Cpf:    mandate.Account.Cpf,
+			Agency: mandate.Account.Agency,
+			Bank:   mandate.Account.Bank,
+		},
+		Receiver:          mandate.Receiver,
+		Frequency:         proto.Frequency(proto.Frequency_value[string(mandate.Frequency)]),
+		Amount:            mandate.Amount,
+		MaxAmount:         mandate.MaxAmount,
+		StartAt:           timestamppb.New(mandate.StartAt),
+		EndAt:             endAt,
+		Status:            string(mandate.Status),
+		WebhookUrl:        mandate.WebhookUrl,
+		Cycle:             int32(mandate.Cycle),
+		Attempts:          int32(mandate.Attempts),
+		FailedCycles:      int32(mandate.FailedCycles),
+		NextRunAt:         timestamppb.New(mandate.NextRunAt),
+		LastTransactionId: mandate.LastTransactionID,
+		LastError:         mandate.LastError,
+	}
+}
+
+func ToProtoList(mandates []*Mandate) *proto.ListMandates {
+	list := make([]*proto.Mandate, len(mandates))
+	for i := range mandates {
+		list[i] = ToProto(mandates[i])
+	}
+	return &proto.ListMandates{Mandates: list}
+}
@@ -0,0 +1,150 @@
+package mandate
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"time"
+	"transaction/internal/cfg"
+	"transaction/internal/errutils"
+	"transaction/platform/dynamo"
+)
+
+// The mandate table needs two global secondary indexes: AccountIndex
+// (AccountID, StartAt) for listing and StatusIndex (Status, NextRunAt) for
+// picking due cycles.
+const (
+	accountIndex = "AccountIndex"
+	statusIndex  = "StatusIndex"
+)
+
+type Repository interface {
+	CreateMandate(ctx context.Context, mandate *Mandate) error
+	FindMandateById(ctx context.Context, id string) (*Mandate, error)
+	ListMandates(ctx context.Context, accountID int64) ([]*Mandate, error)
+	ListDue(ctx context.Context, before time.Time, limit int) ([]*Mandate, error)
+	UpdateMandate(ctx context.Context, mandate *Mandate, previous time.Time) error
+}
+
+type repository struct {
+	db  dynamo.Client
+	cfg *cfg.Config
+}
+
+func (r *repository) CreateMandate(ctx context.Context, mandate *Mandate) error {
+	return r.put(ctx, mandate, expression.AttributeNotExists(expression.Name("PK")))
+}
+
+// UpdateMandate replaces the mandate only if nobody changed it since it was
+// read at previous, so a revocation is never overwritten by a running cycle.
+func (r *repository) UpdateMandate(ctx context.Context, mandate *Mandate, previous time.Time) error {
+	return r.put(ctx, mandate, expression.Name("UpdatedAt").Equal(expression.Value(previous)))
+}
+
+func (r *repository) put(ctx context.Context, mandate *Mandate, cond expression.ConditionBuilder) error {
+	value, err := attributevalue.MarshalMap(mandate)
+	if err != nil {
+		return err
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.MandateTable),
+		Item:                      value,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrMandateConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) FindMandateById(ctx context.Context, id string) (*Mandate, error) {
+	value, err := r.db.DB().GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.cfg.DynamodbConfig.MandateTable),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value.Item == nil {
+		return nil, errutils.ErrMandateNotFound
+	}
+
+	var mandate Mandate
+	if err = attributevalue.UnmarshalMap(value.Item, &mandate); err != nil {
+		return nil, err
+	}
+	return &mandate, nil
+}
+
+func (r *repository) ListMandates(ctx context.Context, accountID int64) ([]*Mandate, error) {
+	keyCond := expression.Key("AccountID").Equal(expression.Value(accountID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.MandateTable),
+		IndexName:                 aws.String(accountIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+}
+
+// ListDue returns authorized mandates whose current cycle should run by before.
+func (r *repository) ListDue(ctx context.Context, before time.Time, limit int) ([]*Mandate, error) {
+	keyCond := expression.Key("Status").Equal(expression.Value(StatusAuthorized)).
+		And(expression.Key("NextRunAt").LessThanEqual(expression.Value(before)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.MandateTable),
+		IndexName:                 aws.String(statusIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+}
+
+func (r *repository) query(ctx context.Context, input *dynamodb.QueryInput) ([]*Mandate, error) {
+	value, err := r.db.DB().Query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	mandates := make([]*Mandate, 0, len(value.Items))
+	if err = attributevalue.UnmarshalListOfMaps(value.Items, &mandates); err != nil {
+		return nil, err
+	}
+	return mandates, nil
+}
+
+func NewRepository(db dynamo.Client, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
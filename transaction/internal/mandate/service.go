@@ -0,0 +1,279 @@
+package mandate
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"log"
+	"time"
+	"transaction/internal/errutils"
+	keys "transaction/internal/keys"
+	"transaction/internal/pix"
+)
+
+type Service interface {
+	Create(ctx context.Context, mandate *Mandate) (*Mandate, error)
+	Find(ctx context.Context, req *Request) (*Mandate, error)
+	List(ctx context.Context, accountID int64) ([]*Mandate, error)
+	Update(ctx context.Context, mandate *Mandate) (*Mandate, error)
+	Authorize(ctx context.Context, req *Request) (*Mandate, error)
+	Revoke(ctx context.Context, req *Request) (*Mandate, error)
+	RunDue(ctx context.Context) error
+}
+
+type Options func(*service)
+
+func WithBatchSize(size int) Options {
+	return func(s *service) {
+		s.batchSize = size
+	}
+}
+
+// WithLease sets how long a running cycle keeps the mandate to itself. A run
+// that dies is picked up again once the lease is over.
+func WithLease(lease time.Duration) Options {
+	return func(s *service) {
+		s.lease = lease
+	}
+}
+
+// WithRetry sets how many times a cycle is tried, delay apart, before it is
+// counted as failed and the mandate moves on to the next cycle.
+func WithRetry(attempts int, delay time.Duration) Options {
+	return func(s *service) {
+		s.maxAttempts = attempts
+		s.retryDelay = delay
+	}
+}
+
+type service struct {
+	repo        Repository
+	keysRepo    keys.Repository
+	pix         pix.Service
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	retryDelay  time.Duration
+	now         func() time.Time
+}
+
+func (s *service) Create(ctx context.Context, mandate *Mandate) (*Mandate, error) {
+	if mandate.Frequency != FrequencyWeekly && mandate.Frequency != FrequencyMonthly {
+		return nil, errutils.ErrInvalidFrequency
+	}
+	if mandate.Amount <= 0 {
+		return nil, errutils.ErrInvalidValue
+	}
+	if mandate.Amount > mandate.MaxAmount {
+		return nil, errutils.ErrAmountExceedsMax
+	}
+
+	now := s.now()
+	// dates are kept in whole UTC seconds so they sort as strings
+	mandate.StartAt = mandate.StartAt.UTC().Truncate(time.Second)
+	if !mandate.EndAt.IsZero() {
+		mandate.EndAt = mandate.EndAt.UTC().Truncate(time.Second)
+	}
+	if !mandate.StartAt.After(now) || (!mandate.EndAt.IsZero() && mandate.EndAt.Before(mandate.StartAt)) {
+		return nil, errutils.ErrInvalidMandatePeriod
+	}
+
+	receiver, err := s.keysRepo.FindKey(ctx, mandate.Receiver)
+	if err != nil {
+		return nil, err
+	}
+	if receiver == nil {
+		return nil, errutils.ErrInvalidKey
+	}
+
+	mandate.ID = uuid.New().String()
+	mandate.AccountID = mandate.Account.Name
+	mandate.Status = StatusPending
+	mandate.NextRunAt = mandate.StartAt
+	mandate.CreatedAt = now
+	mandate.UpdatedAt = now
+	if err = s.repo.CreateMandate(ctx, mandate); err != nil {
+		return nil, err
+	}
+	return mandate, nil
+}
+
+func (s *service) Find(ctx context.Context, req *Request) (*Mandate, error) {
+	mandate, err := s.repo.FindMandateById(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mandate.AccountID != req.AccountID {
+		return nil, errutils.ErrMandateNotFound
+	}
+	return mandate, nil
+}
+
+func (s *service) List(ctx context.Context, accountID int64) ([]*Mandate, error) {
+	return s.repo.ListMandates(ctx, accountID)
+}
+
+// Update changes the amount, the max amount and the end date. Raising the
+// max amount needs a new authorization from the payer.
+func (s *service) Update(ctx context.Context, req *Mandate) (*Mandate, error) {
+	mandate, err := s.Find(ctx, &Request{ID: req.ID, AccountID: req.AccountID})
+	if err != nil {
+		return nil, err
+	}
+	if mandate.Status != StatusPending && mandate.Status != StatusAuthorized {
+		return nil, errutils.ErrMandateNotActive
+	}
+	if req.Amount <= 0 {
+		return nil, errutils.ErrInvalidValue
+	}
+	if req.Amount > req.MaxAmount {
+		return nil, errutils.ErrAmountExceedsMax
+	}
+	if !req.EndAt.IsZero() && req.EndAt.Before(mandate.StartAt) {
+		return nil, errutils.ErrInvalidMandatePeriod
+	}
+
+	previous := mandate.UpdatedAt
+	if req.MaxAmount > mandate.MaxAmount {
+		mandate.Status = StatusPending
+	}
+	mandate.Amount = req.Amount
+	mandate.MaxAmount = req.MaxAmount
+	mandate.EndAt = req.EndAt.UTC().Truncate(time.Second)
+	mandate.UpdatedAt = s.now()
+	if err = s.repo.UpdateMandate(ctx, mandate, previous); err != nil {
+		return nil, err
+	}
+	return mandate, nil
+}
+
+// Authorize starts charging the mandate. Cycles that came due while it waited
+// for the authorization are skipped, not charged at once.
+func (s *service) Authorize(ctx context.Context, req *Request) (*Mandate, error) {
+	mandate, err := s.Find(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if mandate.Status != StatusPending {
+		return nil, errutils.ErrMandateNotPending
+	}
+
+	now := s.now()
+	previous := mandate.UpdatedAt
+	for mandate.DueAt(mandate.Cycle).Before(now) {
+		mandate.Cycle++
+	}
+	mandate.NextRunAt = mandate.DueAt(mandate.Cycle)
+	mandate.Attempts = 0
+	mandate.Status = StatusAuthorized
+	if mandate.Ended() {
+		mandate.Status = StatusExpired
+	}
+	mandate.AuthorizedAt = now
+	mandate.UpdatedAt = now
+	if err = s.repo.UpdateMandate(ctx, mandate, previous); err != nil {
+		return nil, err
+	}
+	return mandate, nil
+}
+
+func (s *service) Revoke(ctx context.Context, req *Request) (*Mandate, error) {
+	mandate, err := s.Find(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if mandate.Status != StatusPending && mandate.Status != StatusAuthorized {
+		return nil, errutils.ErrMandateNotActive
+	}
+
+	now := s.now()
+	previous := mandate.UpdatedAt
+	mandate.Status = StatusRevoked
+	mandate.RevokedAt = now
+	mandate.UpdatedAt = now
+	if err = s.repo.UpdateMandate(ctx, mandate, previous); err != nil {
+		return nil, err
+	}
+	return mandate, nil
+}
+
+// RunDue charges the current cycle of every authorized mandate that is due.
+func (s *service) RunDue(ctx context.Context) error {
+	due, err := s.repo.ListDue(ctx, s.now(), s.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, mandate := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err = s.runCycle(ctx, mandate)
+		if errors.Is(err, errutils.ErrMandateConflict) {
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to run mandate %s: %v", mandate.ID, err)
+		}
+	}
+	return nil
+}
+
+// runCycle leases the mandate by pushing NextRunAt forward, sends the pix of
+// the current attempt and then moves to the next cycle or schedules a retry.
+func (s *service) runCycle(ctx context.Context, mandate *Mandate) error {
+	previous := mandate.UpdatedAt
+	mandate.NextRunAt = s.now().Truncate(time.Second).Add(s.lease)
+	mandate.UpdatedAt = s.now()
+	if err := s.repo.UpdateMandate(ctx, mandate, previous); err != nil {
+		return err
+	}
+
+	leased := mandate.UpdatedAt
+	mandate.LastTransactionID = mandate.TransactionID()
+	err := s.pix.Transaction(ctx, mandate.PixEvent())
+	if err == nil {
+		mandate.LastError = ""
+		s.nextCycle(mandate)
+	} else {
+		mandate.Attempts++
+		mandate.LastError = err.Error()
+		// the pix of the cycle failed for good, retrying it changes nothing
+		if mandate.Attempts >= s.maxAttempts || errors.Is(err, errutils.ErrTransactionFailed) ||
+			errors.Is(err, errutils.ErrReceiverUnreachable) {
+			mandate.FailedCycles++
+			s.nextCycle(mandate)
+		} else {
+			mandate.NextRunAt = s.now().Truncate(time.Second).Add(s.retryDelay)
+		}
+	}
+
+	mandate.UpdatedAt = s.now()
+	return s.repo.UpdateMandate(ctx, mandate, leased)
+}
+
+func (s *service) nextCycle(mandate *Mandate) {
+	mandate.Cycle++
+	mandate.Attempts = 0
+	mandate.NextRunAt = mandate.DueAt(mandate.Cycle)
+	if mandate.Ended() {
+		mandate.Status = StatusExpired
+	}
+}
+
+func NewService(repo Repository, keysRepo keys.Repository, pixService pix.Service, opts ...Options) Service {
+	s := &service{
+		repo:        repo,
+		keysRepo:    keysRepo,
+		pix:         pixService,
+		batchSize:   100,
+		lease:       5 * time.Minute,
+		maxAttempts: 3,
+		retryDelay:  time.Hour,
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+	for _, f := range opts {
+		f(s)
+	}
+	return s
+}
@@ -0,0 +1,317 @@
+package mandate
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"transaction/internal/errutils"
+	keys "transaction/internal/keys"
+	"transaction/internal/pix"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+	// updates keeps a copy of the mandate on every UpdateMandate call
+	updates []Mandate
+}
+
+func (m *mockRepo) CreateMandate(ctx context.Context, mandate *Mandate) error {
+	args := m.Called(mandate)
+	return args.Error(0)
+}
+
+func (m *mockRepo) FindMandateById(ctx context.Context, id string) (*Mandate, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Mandate), args.Error(1)
+}
+
+func (m *mockRepo) ListDue(ctx context.Context, before time.Time, limit int) ([]*Mandate, error) {
+	args := m.Called()
+	return args.Get(0).([]*Mandate), args.Error(1)
+}
+
+func (m *mockRepo) UpdateMandate(ctx context.Context, mandate *Mandate, previous time.Time) error {
+	m.updates = append(m.updates, *mandate)
+	args := m.Called(mandate.ID)
+	return args.Error(0)
+}
+
+type mockKeysRepo struct {
+	keys.Repository
+	mock.Mock
+}
+
+func (m *mockKeysRepo) FindKey(ctx context.Context, key string) (*keys.Key, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*keys.Key), args.Error(1)
+}
+
+type mockPixService struct {
+	pix.Service
+	mock.Mock
+}
+
+func (m *mockPixService) Transaction(ctx context.Context, pixEvent *pix.PixEvent) error {
+	args := m.Called(pixEvent.ID)
+	return args.Error(0)
+}
+
+var now = time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestService(repo *mockRepo, keysRepo *mockKeysRepo, pixService *mockPixService) *service {
+	s := NewService(repo, keysRepo, pixService, WithRetry(2, time.Hour), WithLease(time.Minute)).(*service)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestDueAt(t *testing.T) {
+	monthly := &Mandate{Frequency: FrequencyMonthly, StartAt: time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)}
+	assert.Equal(t, time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC), monthly.DueAt(1))
+	assert.Equal(t, time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC), monthly.DueAt(2))
+
+	weekly := &Mandate{Frequency: FrequencyWeekly, StartAt: time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)}
+	assert.Equal(t, time.Date(2024, 2, 14, 9, 0, 0, 0, time.UTC), weekly.DueAt(2))
+}
+
+func TestCreate(t *testing.T) {
+	valid := func() *Mandate {
+		return &Mandate{
+			Account:   Account{Name: 1},
+			Receiver:  "gym@pix.com",
+			Frequency: FrequencyMonthly,
+			Amount:    90,
+			MaxAmount: 100,
+			StartAt:   now.Add(24 * time.Hour),
+		}
+	}
+
+	cases := []struct {
+		name     string
+		mandate  func() *Mandate
+		mockFunc func(repo *mockRepo, keysRepo *mockKeysRepo)
+		err      error
+	}{
+		{
+			name:    "success",
+			mandate: valid,
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "gym@pix.com").Return(&keys.Key{Id: "key-1"}, nil)
+				repo.On("CreateMandate", mock.MatchedBy(func(mandate *Mandate) bool {
+					return mandate.Status == StatusPending && mandate.AccountID == 1 && mandate.NextRunAt.Equal(mandate.StartAt)
+				})).Return(nil)
+			},
+		},
+		{
+			name: "failed because amount exceeds max amount",
+			mandate: func() *Mandate {
+				mandate := valid()
+				mandate.Amount = 150
+				return mandate
+			},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrAmountExceedsMax,
+		},
+		{
+			name: "failed because frequency is unknown",
+			mandate: func() *Mandate {
+				mandate := valid()
+				mandate.Frequency = "DAILY"
+				return mandate
+			},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidFrequency,
+		},
+		{
+			name: "failed because it ends before it starts",
+			mandate: func() *Mandate {
+				mandate := valid()
+				mandate.EndAt = now
+				return mandate
+			},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidMandatePeriod,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, keysRepo := new(mockRepo), new(mockKeysRepo)
+			tc.mockFunc(repo, keysRepo)
+
+			_, err := newTestService(repo, keysRepo, new(mockPixService)).Create(context.Background(), tc.mandate())
+			assert.Equal(t, tc.err, err)
+			repo.AssertExpectations(t)
+			keysRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthorizeSkipsPastCycles(t *testing.T) {
+	repo := new(mockRepo)
+	repo.On("FindMandateById", "mandate-1").Return(&Mandate{
+		ID:        "mandate-1",
+		AccountID: 1,
+		Frequency: FrequencyWeekly,
+		StartAt:   now.AddDate(0, 0, -10),
+		Status:    StatusPending,
+	}, nil)
+	repo.On("UpdateMandate", "mandate-1").Return(nil)
+
+	authorized, err := newTestService(repo, new(mockKeysRepo), new(mockPixService)).
+		Authorize(context.Background(), &Request{ID: "mandate-1", AccountID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusAuthorized, authorized.Status)
+	assert.Equal(t, 2, authorized.Cycle)
+	assert.Equal(t, now.AddDate(0, 0, 4), authorized.NextRunAt)
+}
+
+func TestRevoke(t *testing.T) {
+	cases := []struct {
+		name   string
+		status Status
+		err    error
+	}{
+		{name: "success", status: StatusAuthorized},
+		{name: "failed because mandate already ended", status: StatusExpired, err: errutils.ErrMandateNotActive},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("FindMandateById", "mandate-1").Return(&Mandate{ID: "mandate-1", AccountID: 1, Status: tc.status}, nil)
+			repo.On("UpdateMandate", "mandate-1").Return(nil).Maybe()
+
+			revoked, err := newTestService(repo, new(mockKeysRepo), new(mockPixService)).
+				Revoke(context.Background(), &Request{ID: "mandate-1", AccountID: 1})
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, StatusRevoked, revoked.Status)
+			}
+		})
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	start := now.AddDate(0, -1, 0)
+	due := func(attempts int) *Mandate {
+		return &Mandate{
+			ID:        "mandate-1",
+			Frequency: FrequencyMonthly,
+			Amount:    90,
+			MaxAmount: 100,
+			StartAt:   start,
+			Status:    StatusAuthorized,
+			Cycle:     1,
+			Attempts:  attempts,
+			NextRunAt: now,
+		}
+	}
+
+	cases := []struct {
+		name     string
+		mandate  *Mandate
+		endAt    time.Time
+		pixErr   error
+		wantLast func(t *testing.T, mandate Mandate)
+	}{
+		{
+			name:    "paid cycle moves to the next one",
+			mandate: due(0),
+			wantLast: func(t *testing.T, mandate Mandate) {
+				assert.Equal(t, 2, mandate.Cycle)
+				assert.Equal(t, 0, mandate.Attempts)
+				assert.Equal(t, start.AddDate(0, 2, 0), mandate.NextRunAt)
+				assert.Equal(t, due(0).TransactionID(), mandate.LastTransactionID)
+				assert.Equal(t, StatusAuthorized, mandate.Status)
+			},
+		},
+		{
+			name:    "failed cycle is retried later",
+			mandate: due(0),
+			pixErr:  errors.New("webhook unavailable"),
+			wantLast: func(t *testing.T, mandate Mandate) {
+				assert.Equal(t, 1, mandate.Cycle)
+				assert.Equal(t, 1, mandate.Attempts)
+				assert.Equal(t, now.Add(time.Hour), mandate.NextRunAt)
+				assert.Equal(t, "webhook unavailable", mandate.LastError)
+			},
+		},
+		{
+			name:    "cycle is given up after the last attempt",
+			mandate: due(1),
+			pixErr:  errors.New("insufficient balance"),
+			wantLast: func(t *testing.T, mandate Mandate) {
+				assert.Equal(t, 2, mandate.Cycle)
+				assert.Equal(t, 1, mandate.FailedCycles)
+				assert.Equal(t, due(0).TransactionID(), mandate.LastTransactionID)
+			},
+		},
+		{
+			name:    "cycle whose pix failed is not retried",
+			mandate: due(0),
+			pixErr:  errutils.ErrTransactionFailed,
+			wantLast: func(t *testing.T, mandate Mandate) {
+				assert.Equal(t, 2, mandate.Cycle)
+				assert.Equal(t, 1, mandate.FailedCycles)
+			},
+		},
+		{
+			name: "mandate expires after its last cycle",
+			mandate: func() *Mandate {
+				mandate := due(0)
+				mandate.EndAt = now.AddDate(0, 0, 7)
+				return mandate
+			}(),
+			wantLast: func(t *testing.T, mandate Mandate) {
+				assert.Equal(t, StatusExpired, mandate.Status)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, pixService := new(mockRepo), new(mockPixService)
+			repo.On("ListDue").Return([]*Mandate{tc.mandate}, nil)
+			repo.On("UpdateMandate", "mandate-1").Return(nil).Twice()
+			pixService.On("Transaction", tc.mandate.TransactionID()).Return(tc.pixErr)
+
+			err := newTestService(repo, new(mockKeysRepo), pixService).RunDue(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, repo.updates, 2)
+			assert.Equal(t, now.Add(time.Minute), repo.updates[0].NextRunAt)
+			tc.wantLast(t, repo.updates[1])
+			repo.AssertExpectations(t)
+			pixService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransactionID(t *testing.T) {
+	first := &Mandate{ID: "mandate-1", Cycle: 1}
+	retry := &Mandate{ID: "mandate-1", Cycle: 1, Attempts: 2}
+	next := &Mandate{ID: "mandate-1", Cycle: 2}
+
+	assert.Equal(t, first.TransactionID(), retry.TransactionID())
+	assert.NotEqual(t, first.TransactionID(), next.TransactionID())
+	assert.Len(t, first.TransactionID(), 36)
+}
+
+func TestRunDueSkipsLeasedMandate(t *testing.T) {
+	repo, pixService := new(mockRepo), new(mockPixService)
+	repo.On("ListDue").Return([]*Mandate{{ID: "mandate-1", Status: StatusAuthorized}}, nil)
+	repo.On("UpdateMandate", "mandate-1").Return(errutils.ErrMandateConflict)
+
+	err := newTestService(repo, new(mockKeysRepo), pixService).RunDue(context.Background())
+	assert.NoError(t, err)
+	pixService.AssertNotCalled(t, "Transaction", mock.Anything)
+}
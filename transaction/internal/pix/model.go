@@ -3,7 +3,9 @@ package pix
 import "github.com/shopspring/decimal"
 
 type PixEvent struct {
-	Account struct {
+	ID             string `json:"id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	Account        struct {
 		Name   int64  `json:"name"`
 		Cpf    string `json:"cpf"`
 		Agency string `json:"agency"`
@@ -12,4 +14,10 @@ type PixEvent struct {
 	Receiver   string          `json:"receiver"`
 	Amount     decimal.Decimal `json:"amount"`
 	WebhookUrl string          `json:"webhook_url"`
+	TxID       string          `json:"txid,omitempty"`
+	// ReceiverAccount is the account the payer confirmed the key belongs to
+	ReceiverAccount int64 `json:"receiver_account,omitempty"`
+	// Authorize is set on scheduled and recurring pix, the sender side checks
+	// the limits and holds the amount as the pix runs
+	Authorize bool `json:"authorize,omitempty"`
 }
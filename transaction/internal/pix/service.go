@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/shopspring/decimal"
 	"log"
+	"time"
+	"transaction/internal/charge"
 	"transaction/internal/errutils"
+	"transaction/internal/event"
 	keys "transaction/internal/keys"
 	"transaction/internal/transactions"
 	"transaction/internal/utils"
@@ -14,12 +18,15 @@ import (
 
 type Service interface {
 	Handler(ctx context.Context, payload []byte) ([]byte, error)
+	Transaction(ctx context.Context, pixEvent *PixEvent) error
+	Refund(ctx context.Context, req *transactions.RefundRequest) (*transactions.Transaction, error)
 }
 
 type service struct {
 	transaction transactions.Service
 	keysRepo    keys.Repository
 	webhook     webhook.Service
+	charges     charge.Service
 }
 
 func (s *service) Handler(ctx context.Context, payload []byte) ([]byte, error) {
@@ -28,10 +35,16 @@ func (s *service) Handler(ctx context.Context, payload []byte) ([]byte, error) {
 	err := json.Unmarshal(payload, &pixEvent)
 	if err != nil {
 		log.Println("error unmarshalling pix event")
-		return nil, err
+		return nil, event.Permanent(err)
 	}
 
 	err = s.Transaction(ctx, &pixEvent)
+	if errors.Is(err, errutils.ErrInvalidKey) || errors.Is(err, errutils.ErrReceiverChanged) ||
+		errors.Is(err, errutils.ErrReceiverUnreachable) || chargeRefused(err) {
+		// retrying cannot make the key exist, the charge payable or the failed
+		// pix go through, the sender was already notified
+		return nil, event.Permanent(err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -42,24 +55,93 @@ func (s *service) Handler(ctx context.Context, payload []byte) ([]byte, error) {
 func (s *service) Transaction(ctx context.Context, pixEvent *PixEvent) error {
 	receiver, err := s.keysRepo.FindKey(ctx, pixEvent.Receiver)
 	if err != nil {
-		if errors.Is(errutils.ErrKeyNotFound, err) {
+		if errors.Is(err, errutils.ErrKeyNotFound) {
 			log.Println("error key not found")
 			return errutils.ErrInvalidKey
 		}
-		return nil
+		return err
+	}
+
+	if receiver == nil {
+		log.Println("error key not found")
+		s.notifyFailure(ctx, pixEvent)
+		return errutils.ErrInvalidKey
+	}
+
+	if pixEvent.ReceiverAccount != 0 && receiver.Account != pixEvent.ReceiverAccount {
+		// the key moved after the payer confirmed who they were paying
+		log.Println("error key resolves to another account")
+		s.notifyFailure(ctx, pixEvent)
+		return errutils.ErrReceiverChanged
+	}
+
+	if pixEvent.Authorize {
+		// a refused pix is never created, so a retry can run under the same id
+		if err = s.authorize(ctx, pixEvent, receiver); err != nil {
+			return err
+		}
 	}
 
 	transaction, err := s.transaction.CreateTransaction(&transactions.Transaction{
-		AccountID: pixEvent.Account.Name,
-		Receiver:  receiver.Id,
-		Value:     utils.ToFloat(pixEvent.Amount),
-		Status:    transactions.StatusPending,
+		ID:              pixEvent.ID,
+		IdempotencyKey:  pixEvent.IdempotencyKey,
+		Type:            transactions.TypePix,
+		AccountID:       pixEvent.Account.Name,
+		Receiver:        receiver.Id,
+		ReceiverAccount: receiver.Account,
+		Value:           utils.ToFloat(pixEvent.Amount),
+		WebhookUrl:      pixEvent.WebhookUrl,
+		Status:          transactions.StatusPending,
 	})
-	if err != nil {
+	if errors.Is(err, errutils.ErrTransactionAlreadyExists) {
+		// redelivered event: only resume a transaction that never finished
+		transaction, err = s.transaction.FindTransactionById(&transactions.TransactionRequest{TransactionID: pixEvent.ID})
+		if err != nil {
+			return err
+		}
+		if transaction.Status == transactions.StatusFailed && pixEvent.Authorize {
+			// a retried run learns its pix failed instead of taking it as paid
+			return errutils.ErrTransactionFailed
+		}
+		if transaction.Status != transactions.StatusPending && transaction.Status != transactions.StatusProcessing {
+			log.Printf("pix %s already processed, skipping", pixEvent.ID)
+			return nil
+		}
+	} else if err != nil {
 		return err
 	}
 
-	err = s.webhook.Send(ctx, webhook.Webhook{
+	var paid *charge.Charge
+	if pixEvent.TxID != "" {
+		paid, err = s.charges.Reserve(ctx, &charge.Payment{
+			TxID:          pixEvent.TxID,
+			TransactionID: transaction.ID,
+			Key:           receiver.Name,
+			Amount:        pixEvent.Amount,
+			PayerCpf:      pixEvent.Account.Cpf,
+		})
+		if chargeRefused(err) {
+			transaction.ErrMessage = err.Error()
+			if updateErr := s.transaction.Transition(transaction, transactions.StatusFailed, transactions.ActorPix, err.Error()); updateErr != nil {
+				return updateErr
+			}
+			s.notifyFailure(ctx, pixEvent)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if transaction.Status == transactions.StatusPending {
+		err = s.transaction.Transition(transaction, transactions.StatusProcessing, transactions.ActorPix, "notifying the receiver")
+		if err != nil {
+			return err
+		}
+	}
+
+	completed := webhook.Webhook{
+		TransactionId: transaction.ID,
 		Sender: webhook.Account{
 			Name:   pixEvent.Account.Name,
 			Agency: pixEvent.Account.Agency,
@@ -73,31 +155,142 @@ func (s *service) Transaction(ctx context.Context, pixEvent *PixEvent) error {
 			Cpf:    receiver.Cpf,
 		},
 		Amount: pixEvent.Amount,
+		TxID:   pixEvent.TxID,
 		Status: webhook.StatusCompleted,
-	}, pixEvent.WebhookUrl)
+	}
+	err = s.webhook.Send(ctx, completed, pixEvent.WebhookUrl)
 	if err != nil {
-		transaction.Status = transactions.StatusFailed
+		// Send already retried, the pix fails and the sender side releases
+		// its hold once notified
+		if paid != nil {
+			if releaseErr := s.charges.Release(ctx, paid); releaseErr != nil {
+				log.Printf("failed to release charge %s: %v", paid.TxID, releaseErr)
+			}
+		}
 		transaction.ErrMessage = err.Error()
-		err = s.transaction.UpdateTransactionStatus(transaction)
-		if err != nil {
-			return err
+		if updateErr := s.transaction.Transition(transaction, transactions.StatusFailed, transactions.ActorPix, err.Error()); updateErr != nil {
+			return updateErr
 		}
-		return err
+		s.notifyFailure(ctx, pixEvent)
+		return errutils.ErrReceiverUnreachable
 	}
 
-	transaction.Status = transactions.StatusCompleted
-	err = s.transaction.UpdateTransactionStatus(transaction)
+	err = s.transaction.Transition(transaction, transactions.StatusCompleted, transactions.ActorPix, "receiver accepted the pix")
 	if err != nil {
 		return err
 	}
 
+	s.publish(ctx, completed.Sender.Name, webhook.EventPixSent, completed)
+	s.publish(ctx, completed.Receiver.Name, webhook.EventPixReceived, completed)
+	if paid != nil {
+		s.publish(ctx, completed.Receiver.Name, webhook.EventChargePaid, completed)
+	}
 	return nil
 }
 
-func NewService(transaction transactions.Service, keysRepo keys.Repository, webhookService webhook.Service) Service {
+// Refund returns part or all of a completed pix to its sender. The balances
+// move through the same webhook as a pix, and a refund the profile side does
+// not accept is cancelled so its value can be refunded again.
+func (s *service) Refund(ctx context.Context, req *transactions.RefundRequest) (*transactions.Transaction, error) {
+	refund, err := s.transaction.Refund(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.transaction.Transition(refund, transactions.StatusProcessing, transactions.ActorRefund, "notifying the receiver"); err != nil {
+		return nil, err
+	}
+
+	completed := webhook.Webhook{
+		TransactionId:         refund.ID,
+		OriginalTransactionId: refund.OriginalTransactionID,
+		Sender:                webhook.Account{Name: refund.AccountID},
+		Receiver:              webhook.Account{Name: refund.ReceiverAccount},
+		Amount:                decimal.NewFromFloat(refund.Value),
+		Status:                webhook.StatusCompleted,
+	}
+	err = s.webhook.Send(ctx, completed, refund.WebhookUrl)
+	if err != nil {
+		if cancelErr := s.transaction.CancelRefund(refund, err); cancelErr != nil {
+			log.Printf("failed to cancel refund %s: %v", refund.ID, cancelErr)
+		}
+		return nil, err
+	}
+
+	refund.ProcessedAt = time.Now()
+	if err = s.transaction.Transition(refund, transactions.StatusCompleted, transactions.ActorRefund, "receiver accepted the refund"); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, refund.AccountID, webhook.EventRefundCreated, completed)
+	s.publish(ctx, refund.ReceiverAccount, webhook.EventRefundCreated, completed)
+	return refund, nil
+}
+
+// authorize asks the sender side to hold the amount before the pix runs.
+func (s *service) authorize(ctx context.Context, pixEvent *PixEvent, receiver *keys.Key) error {
+	return s.webhook.Send(ctx, webhook.Webhook{
+		TransactionId: pixEvent.ID,
+		Sender: webhook.Account{
+			Name:   pixEvent.Account.Name,
+			Agency: pixEvent.Account.Agency,
+			Bank:   pixEvent.Account.Bank,
+			Cpf:    pixEvent.Account.Cpf,
+		},
+		Receiver: webhook.Account{
+			Name:   receiver.Account,
+			Agency: receiver.Agency,
+			Bank:   receiver.Bank,
+			Cpf:    receiver.Cpf,
+		},
+		Amount:      pixEvent.Amount,
+		Status:      webhook.StatusAuthorize,
+		ReceiverKey: receiver.Name,
+	}, pixEvent.WebhookUrl)
+}
+
+// chargeRefused reports whether the charge a pix pays can never accept it.
+func chargeRefused(err error) bool {
+	return errors.Is(err, errutils.ErrChargeNotFound) ||
+		errors.Is(err, errutils.ErrChargeNotActive) ||
+		errors.Is(err, errutils.ErrChargeMismatch)
+}
+
+// notifyFailure tells the sender side that the pix will not settle, so the
+// reserved amount is released right away instead of waiting for the hold to expire.
+// The webhook is retried in the background until the sender side takes it.
+func (s *service) notifyFailure(ctx context.Context, pixEvent *PixEvent) {
+	failed := webhook.Webhook{
+		TransactionId: pixEvent.ID,
+		Sender: webhook.Account{
+			Name:   pixEvent.Account.Name,
+			Agency: pixEvent.Account.Agency,
+			Bank:   pixEvent.Account.Bank,
+			Cpf:    pixEvent.Account.Cpf,
+		},
+		Amount: pixEvent.Amount,
+		TxID:   pixEvent.TxID,
+		Status: webhook.StatusFailed,
+	}
+	err := s.webhook.Notify(ctx, failed, pixEvent.WebhookUrl)
+	if err != nil {
+		log.Print("failed to send failure webhook:", err)
+	}
+	s.publish(ctx, failed.Sender.Name, webhook.EventPixFailed, failed)
+}
+
+// publish tells the webhook subscriptions of the account about the event.
+// Losing it never fails the pix, which already settled.
+func (s *service) publish(ctx context.Context, accountID int64, event webhook.EventType, data webhook.Webhook) {
+	if err := s.webhook.Publish(ctx, accountID, event, data); err != nil {
+		log.Printf("failed to publish %s to account %d: %v", event, accountID, err)
+	}
+}
+
+func NewService(transaction transactions.Service, keysRepo keys.Repository, webhookService webhook.Service, charges charge.Service) Service {
 	return &service{
 		transaction: transaction,
 		keysRepo:    keysRepo,
 		webhook:     webhookService,
+		charges:     charges,
 	}
 }
@@ -0,0 +1,364 @@
+package pix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"transaction/internal/charge"
+	"transaction/internal/errutils"
+	"transaction/internal/event"
+	keys "transaction/internal/keys"
+	"transaction/internal/transactions"
+	"transaction/internal/webhook"
+)
+
+type mockTransactionService struct {
+	transactions.Service
+	mock.Mock
+}
+
+func (m *mockTransactionService) CreateTransaction(transaction *transactions.Transaction) (*transactions.Transaction, error) {
+	args := m.Called(transaction.ID)
+	return transaction, args.Error(0)
+}
+
+func (m *mockTransactionService) FindTransactionById(req *transactions.TransactionRequest) (*transactions.Transaction, error) {
+	args := m.Called(req.TransactionID)
+	return args.Get(0).(*transactions.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionService) Refund(req *transactions.RefundRequest) (*transactions.Transaction, error) {
+	args := m.Called(req)
+	return args.Get(0).(*transactions.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionService) CancelRefund(refund *transactions.Transaction, cause error) error {
+	args := m.Called(refund, cause)
+	return args.Error(0)
+}
+
+func (m *mockTransactionService) Transition(transaction *transactions.Transaction, to transactions.Status, actor, reason string) error {
+	args := m.Called(transaction.ID, to)
+	if args.Error(0) == nil {
+		transaction.Status = to
+	}
+	return args.Error(0)
+}
+
+type mockWebhookService struct {
+	webhook.Service
+	mock.Mock
+	// published records the account and event of every Publish call
+	published []string
+}
+
+func (m *mockWebhookService) Send(ctx context.Context, data webhook.Webhook, url string) error {
+	args := m.Called(data, url)
+	return args.Error(0)
+}
+
+func (m *mockWebhookService) Notify(ctx context.Context, data webhook.Webhook, url string) error {
+	args := m.Called(data, url)
+	return args.Error(0)
+}
+
+func (m *mockWebhookService) Publish(ctx context.Context, accountID int64, event webhook.EventType, data webhook.Webhook) error {
+	m.published = append(m.published, fmt.Sprintf("%d %s", accountID, event))
+	return nil
+}
+
+func TestRefund(t *testing.T) {
+	req := &transactions.RefundRequest{TransactionID: "pix-1", AccountID: 2, Value: 40}
+	newRefund := func() *transactions.Transaction {
+		return &transactions.Transaction{
+			ID:                    "refund-1",
+			Type:                  transactions.TypeRefund,
+			AccountID:             2,
+			ReceiverAccount:       1,
+			Value:                 40,
+			OriginalTransactionID: "pix-1",
+			WebhookUrl:            "http://profile/webhook",
+			Status:                transactions.StatusPending,
+		}
+	}
+
+	cases := []struct {
+		name      string
+		mockFunc  func(transaction *mockTransactionService, hook *mockWebhookService)
+		want      transactions.Status
+		published []string
+		err       error
+	}{
+		{
+			name: "success",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService) {
+				transaction.On("Refund", req).Return(newRefund(), nil)
+				hook.On("Send", mock.MatchedBy(func(data webhook.Webhook) bool {
+					return data.TransactionId == "refund-1" && data.OriginalTransactionId == "pix-1" &&
+						data.Sender.Name == 2 && data.Receiver.Name == 1 && data.Amount.IntPart() == 40 &&
+						data.Status == webhook.StatusCompleted
+				}), "http://profile/webhook").Return(nil)
+				transaction.On("Transition", "refund-1", transactions.StatusProcessing).Return(nil)
+				transaction.On("Transition", "refund-1", transactions.StatusCompleted).Return(nil)
+			},
+			want:      transactions.StatusCompleted,
+			published: []string{"2 refund.created", "1 refund.created"},
+		},
+		{
+			name: "failed because webhook was rejected",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService) {
+				transaction.On("Refund", req).Return(newRefund(), nil)
+				transaction.On("Transition", "refund-1", transactions.StatusProcessing).Return(nil)
+				hook.On("Send", mock.Anything, mock.Anything).Return(errors.New("MOCK-ERROR"))
+				transaction.On("CancelRefund", mock.Anything, errors.New("MOCK-ERROR")).Return(nil)
+			},
+			err: errors.New("MOCK-ERROR"),
+		},
+		{
+			name: "failed because refund exceeds value",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService) {
+				transaction.On("Refund", req).Return((*transactions.Transaction)(nil), errutils.ErrRefundExceedsValue)
+			},
+			err: errutils.ErrRefundExceedsValue,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			transaction := new(mockTransactionService)
+			hook := new(mockWebhookService)
+			c.mockFunc(transaction, hook)
+
+			s := NewService(transaction, nil, hook, nil)
+			got, err := s.Refund(context.Background(), req)
+
+			assert.Equal(t, c.err, err)
+			if c.err == nil {
+				assert.Equal(t, c.want, got.Status)
+				assert.False(t, got.ProcessedAt.IsZero())
+			}
+			assert.Equal(t, c.published, hook.published)
+			transaction.AssertExpectations(t)
+			hook.AssertExpectations(t)
+		})
+	}
+}
+
+type mockKeysRepo struct {
+	keys.Repository
+	mock.Mock
+}
+
+func (m *mockKeysRepo) FindKey(ctx context.Context, key string) (*keys.Key, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*keys.Key), args.Error(1)
+}
+
+func TestTransactionConfirmedReceiver(t *testing.T) {
+	keysRepo := new(mockKeysRepo)
+	keysRepo.On("FindKey", "fulano@pix.com").Return(&keys.Key{Id: "key-1", Account: 3, Name: "fulano@pix.com"}, nil)
+	hook := new(mockWebhookService)
+	hook.On("Notify", mock.MatchedBy(func(data webhook.Webhook) bool {
+		return data.TransactionId == "pix-1" && data.Status == webhook.StatusFailed
+	}), "http://hook").Return(nil)
+
+	pixEvent := &PixEvent{ID: "pix-1", Receiver: "fulano@pix.com", WebhookUrl: "http://hook", ReceiverAccount: 2}
+	err := NewService(nil, keysRepo, hook, nil).Transaction(context.Background(), pixEvent)
+	assert.Equal(t, errutils.ErrReceiverChanged, err)
+	keysRepo.AssertExpectations(t)
+	hook.AssertExpectations(t)
+	assert.Equal(t, []string{"0 pix.failed"}, hook.published)
+}
+
+func TestHandlerReceiverUnreachable(t *testing.T) {
+	keysRepo, transaction, hook := new(mockKeysRepo), new(mockTransactionService), new(mockWebhookService)
+	keysRepo.On("FindKey", "fulano@pix.com").Return(&keys.Key{Id: "key-1", Account: 3, Name: "fulano@pix.com"}, nil)
+	transaction.On("CreateTransaction", "pix-1").Return(nil)
+	transaction.On("Transition", "pix-1", transactions.StatusProcessing).Return(nil)
+	hook.On("Send", mock.Anything, "http://hook").Return(errors.New("MOCK-ERROR"))
+	transaction.On("Transition", "pix-1", transactions.StatusFailed).Return(nil)
+	hook.On("Notify", mock.MatchedBy(func(data webhook.Webhook) bool {
+		return data.TransactionId == "pix-1" && data.Status == webhook.StatusFailed
+	}), "http://hook").Return(nil)
+
+	payload := []byte(`{"id":"pix-1","account":{"name":1},"receiver":"fulano@pix.com","amount":"10","webhook_url":"http://hook"}`)
+	_, err := NewService(transaction, keysRepo, hook, nil).Handler(context.Background(), payload)
+
+	// the failed pix is not retried, the sender was told it failed
+	assert.Equal(t, event.Permanent(errutils.ErrReceiverUnreachable), err)
+	transaction.AssertExpectations(t)
+	hook.AssertExpectations(t)
+	assert.Equal(t, []string{"1 pix.failed"}, hook.published)
+}
+
+type mockChargeRepo struct {
+	charge.Repository
+	mock.Mock
+}
+
+func (m *mockChargeRepo) FindCharge(ctx context.Context, txID string) (*charge.Charge, error) {
+	args := m.Called(txID)
+	return args.Get(0).(*charge.Charge), args.Error(1)
+}
+
+func (m *mockChargeRepo) UpdateCharge(ctx context.Context, c *charge.Charge, revision int) error {
+	args := m.Called(c.Status, c.TransactionID)
+	return args.Error(0)
+}
+
+func TestTransactionCharge(t *testing.T) {
+	// the key id never equals its name, the charge is created for the name
+	key := &keys.Key{Id: "key-1", Account: 3, Name: "fulano@pix.com"}
+	newCharge := func(key string) *charge.Charge {
+		return &charge.Charge{TxID: "tx-1", ChargeAccount: 3, Key: key, Amount: decimal.NewFromInt(100), Expiration: 3600,
+			Status: charge.StatusActive, Revision: 1, CreatedAt: time.Now()}
+	}
+
+	cases := []struct {
+		name     string
+		charge   *charge.Charge
+		amount   string
+		mockFunc func(transaction *mockTransactionService, hook *mockWebhookService, chargeRepo *mockChargeRepo)
+		err      error
+	}{
+		{
+			name:   "success pays the charge of the key name",
+			charge: newCharge("fulano@pix.com"),
+			amount: "100",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService, chargeRepo *mockChargeRepo) {
+				chargeRepo.On("UpdateCharge", charge.StatusPaid, "pix-1").Return(nil)
+				transaction.On("Transition", "pix-1", transactions.StatusProcessing).Return(nil)
+				hook.On("Send", mock.MatchedBy(func(data webhook.Webhook) bool {
+					return data.TxID == "tx-1" && data.Receiver.Name == 3
+				}), "http://hook").Return(nil)
+				transaction.On("Transition", "pix-1", transactions.StatusCompleted).Return(nil)
+			},
+		},
+		{
+			name:   "failed because the charge was created for another key",
+			charge: newCharge("ciclano@pix.com"),
+			amount: "100",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService, chargeRepo *mockChargeRepo) {
+				transaction.On("Transition", "pix-1", transactions.StatusFailed).Return(nil)
+				hook.On("Notify", mock.Anything, "http://hook").Return(nil)
+			},
+			err: errutils.ErrChargeMismatch,
+		},
+		{
+			name:   "failed because the charge names the key by its id",
+			charge: newCharge("key-1"),
+			amount: "100",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService, chargeRepo *mockChargeRepo) {
+				transaction.On("Transition", "pix-1", transactions.StatusFailed).Return(nil)
+				hook.On("Notify", mock.Anything, "http://hook").Return(nil)
+			},
+			err: errutils.ErrChargeMismatch,
+		},
+		{
+			name:   "failed because the amount differs from the charge",
+			charge: newCharge("fulano@pix.com"),
+			amount: "99.99",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService, chargeRepo *mockChargeRepo) {
+				transaction.On("Transition", "pix-1", transactions.StatusFailed).Return(nil)
+				hook.On("Notify", mock.Anything, "http://hook").Return(nil)
+			},
+			err: errutils.ErrChargeMismatch,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			transaction, hook := new(mockTransactionService), new(mockWebhookService)
+			keysRepo, chargeRepo := new(mockKeysRepo), new(mockChargeRepo)
+			keysRepo.On("FindKey", "fulano@pix.com").Return(key, nil)
+			transaction.On("CreateTransaction", "pix-1").Return(nil)
+			chargeRepo.On("FindCharge", "tx-1").Return(c.charge, nil)
+			c.mockFunc(transaction, hook, chargeRepo)
+
+			pixEvent := &PixEvent{ID: "pix-1", Receiver: "fulano@pix.com", Amount: decimal.RequireFromString(c.amount),
+				WebhookUrl: "http://hook", TxID: "tx-1"}
+			err := NewService(transaction, keysRepo, hook, charge.NewService(chargeRepo, nil)).Transaction(context.Background(), pixEvent)
+
+			assert.Equal(t, c.err, err)
+			transaction.AssertExpectations(t)
+			hook.AssertExpectations(t)
+			chargeRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransactionAuthorize(t *testing.T) {
+	key := &keys.Key{Id: "key-1", Account: 3, Name: "fulano@pix.com"}
+	withStatus := func(status webhook.Status) interface{} {
+		return mock.MatchedBy(func(data webhook.Webhook) bool {
+			return data.TransactionId == "pix-1" && data.Status == status
+		})
+	}
+
+	cases := []struct {
+		name     string
+		mockFunc func(transaction *mockTransactionService, hook *mockWebhookService)
+		err      error
+	}{
+		{
+			name: "success holds the amount before the pix is created",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService) {
+				hook.On("Send", withStatus(webhook.StatusAuthorize), "http://hook").Return(nil).Once()
+				transaction.On("CreateTransaction", "pix-1").Return(nil)
+				transaction.On("Transition", "pix-1", transactions.StatusProcessing).Return(nil)
+				hook.On("Send", withStatus(webhook.StatusCompleted), "http://hook").Return(nil).Once()
+				transaction.On("Transition", "pix-1", transactions.StatusCompleted).Return(nil)
+			},
+		},
+		{
+			name: "retried pix resumes the transaction of the first run",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService) {
+				hook.On("Send", withStatus(webhook.StatusAuthorize), "http://hook").Return(nil).Once()
+				transaction.On("CreateTransaction", "pix-1").Return(errutils.ErrTransactionAlreadyExists)
+				transaction.On("FindTransactionById", "pix-1").Return(&transactions.Transaction{ID: "pix-1", Status: transactions.StatusProcessing}, nil)
+				hook.On("Send", withStatus(webhook.StatusCompleted), "http://hook").Return(nil).Once()
+				transaction.On("Transition", "pix-1", transactions.StatusCompleted).Return(nil)
+			},
+		},
+		{
+			name: "failed because the pix of the first run failed",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService) {
+				hook.On("Send", withStatus(webhook.StatusAuthorize), "http://hook").Return(nil)
+				transaction.On("CreateTransaction", "pix-1").Return(errutils.ErrTransactionAlreadyExists)
+				transaction.On("FindTransactionById", "pix-1").Return(&transactions.Transaction{ID: "pix-1", Status: transactions.StatusFailed}, nil)
+			},
+			err: errutils.ErrTransactionFailed,
+		},
+		{
+			name: "failed because the sender side refused to hold the amount",
+			mockFunc: func(transaction *mockTransactionService, hook *mockWebhookService) {
+				hook.On("Send", withStatus(webhook.StatusAuthorize), "http://hook").Return(errors.New("MOCK-ERROR"))
+			},
+			err: errors.New("MOCK-ERROR"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			transaction, hook, keysRepo := new(mockTransactionService), new(mockWebhookService), new(mockKeysRepo)
+			keysRepo.On("FindKey", "fulano@pix.com").Return(key, nil)
+			c.mockFunc(transaction, hook)
+
+			pixEvent := &PixEvent{ID: "pix-1", Receiver: "fulano@pix.com", Amount: decimal.NewFromInt(100),
+				WebhookUrl: "http://hook", Authorize: true}
+			err := NewService(transaction, keysRepo, hook, nil).Transaction(context.Background(), pixEvent)
+
+			assert.Equal(t, c.err, err)
+			transaction.AssertExpectations(t)
+			hook.AssertExpectations(t)
+		})
+	}
+}
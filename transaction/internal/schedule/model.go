@@ -0,0 +1,115 @@
+package schedule
+
+import (
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	"transaction/internal/pix"
+	proto "transaction/proto/v1"
+)
+
+type Status string
+
+const (
+	StatusScheduled  Status = "SCHEDULED"
+	StatusProcessing Status = "PROCESSING"
+	StatusExecuted   Status = "EXECUTED"
+	StatusFailed     Status = "FAILED"
+	StatusCanceled   Status = "CANCELED"
+)
+
+type Account struct {
+	Name   int64
+	Cpf    string
+	Agency string
+	Bank   string
+}
+
+// Schedule is a pix waiting for its ExecuteAt. Once executed, the pix
+// transaction it created has the same ID.
+type Schedule struct {
+	ID             string `dynamodbav:"PK"`
+	IdempotencyKey string
+	AccountID      int64
+	Account        Account
+	Receiver       string
+	TxID           string
+	// ReceiverAccount is the account the payer confirmed the key belongs to
+	ReceiverAccount int64
+	Value           float64
+	WebhookUrl      string
+	ExecuteAt       time.Time
+	Status          Status
+	ErrMessage      string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// PixEvent is the pix the scheduler hands over to the pix service.
+func (s *Schedule) PixEvent() *pix.PixEvent {
+	event := &pix.PixEvent{
+		ID:              s.ID,
+		IdempotencyKey:  s.IdempotencyKey,
+		Receiver:        s.Receiver,
+		Amount:          decimal.NewFromFloat(s.Value),
+		WebhookUrl:      s.WebhookUrl,
+		TxID:            s.TxID,
+		ReceiverAccount: s.ReceiverAccount,
+		Authorize:       true,
+	}
+	event.Account.Name = s.Account.Name
+	event.Account.Cpf = s.Account.Cpf
+	event.Account.Agency = s.Account.Agency
+	event.Account.Bank = s.Account.Bank
+	return event
+}
+
+func ProtoToSchedule(scheduled *proto.ScheduledPix) *Schedule {
+	account := Account{
+		Name:   scheduled.GetAccount().GetName(),
+		Cpf:    scheduled.GetAccount().GetCpf(),
+		Agency: scheduled.GetAccount().GetAgency(),
+		Bank:   scheduled.GetAccount().GetBank(),
+	}
+	return &Schedule{
+		ID:              scheduled.Id,
+		IdempotencyKey:  scheduled.IdempotencyKey,
+		AccountID:       account.Name,
+		Account:         account,
+		Receiver:        scheduled.Receiver,
+		TxID:            scheduled.Txid,
+		ReceiverAccount: scheduled.ReceiverAccount,
+		Value:           scheduled.Value,
+		WebhookUrl:      scheduled.WebhookUrl,
+		ExecuteAt:       scheduled.GetExecuteAt().AsTime(),
+	}
+}
+
+func ToProto(schedule *Schedule) *proto.ScheduledPix {
+	return &proto.ScheduledPix{
+		Id: schedule.ID,
+		Account: &proto.Account{
+			Name:   schedule.Account.Name,
+			Cpf:    schedule.Account.Cpf,
+			Agency: schedule.Account.Agency,
+			Bank:   schedule.Account.Bank,
+		},
+		Receiver:        schedule.Receiver,
+		Value:           schedule.Value,
+		ExecuteAt:       timestamppb.New(schedule.ExecuteAt),
+		Status:          string(schedule.Status),
+		IdempotencyKey:  schedule.IdempotencyKey,
+		WebhookUrl:      schedule.WebhookUrl,
+		ErrMessage:      schedule.ErrMessage,
+		Txid:            schedule.TxID,
+		ReceiverAccount: schedule.ReceiverAccount,
+	}
+}
+
+func ToProtoList(schedules []*Schedule) *proto.ListScheduledPix {
+	list := make([]*proto.ScheduledPix, len(schedules))
+	for i := range schedules {
+		list[i] = ToProto(schedules[i])
+	}
+	return &proto.ListScheduledPix{Scheduled: list}
+}
@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"time"
+	"transaction/internal/cfg"
+	"transaction/internal/errutils"
+	"transaction/platform/dynamo"
+)
+
+// The schedule table needs two global secondary indexes, both with ExecuteAt
+// as sort key: AccountIndex (AccountID) for listing and StatusIndex (Status)
+// for picking due items.
+const (
+	accountIndex = "AccountIndex"
+	statusIndex  = "StatusIndex"
+)
+
+type Repository interface {
+	CreateSchedule(ctx context.Context, schedule *Schedule) error
+	FindScheduleById(ctx context.Context, id string) (*Schedule, error)
+	ListSchedules(ctx context.Context, accountID int64) ([]*Schedule, error)
+	ListDue(ctx context.Context, status Status, before time.Time, limit int) ([]*Schedule, error)
+	UpdateStatus(ctx context.Context, schedule *Schedule, from Status, previous time.Time) error
+}
+
+type repository struct {
+	db  dynamo.Client
+	cfg *cfg.Config
+}
+
+func (r *repository) CreateSchedule(ctx context.Context, schedule *Schedule) error {
+	value, err := attributevalue.MarshalMap(schedule)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB().PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.cfg.DynamodbConfig.ScheduleTable),
+		Item:                value,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrTransactionAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) FindScheduleById(ctx context.Context, id string) (*Schedule, error) {
+	value, err := r.db.DB().GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.cfg.DynamodbConfig.ScheduleTable),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value.Item == nil {
+		return nil, errutils.ErrScheduleNotFound
+	}
+
+	var schedule Schedule
+	if err = attributevalue.UnmarshalMap(value.Item, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *repository) ListSchedules(ctx context.Context, accountID int64) ([]*Schedule, error) {
+	keyCond := expression.Key("AccountID").Equal(expression.Value(accountID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.ScheduleTable),
+		IndexName:                 aws.String(accountIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+}
+
+// ListDue returns the items in status whose execution date is not after
+// before, oldest first.
+func (r *repository) ListDue(ctx context.Context, status Status, before time.Time, limit int) ([]*Schedule, error) {
+	keyCond := expression.Key("Status").Equal(expression.Value(status)).
+		And(expression.Key("ExecuteAt").LessThanEqual(expression.Value(before)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.ScheduleTable),
+		IndexName:                 aws.String(statusIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+}
+
+func (r *repository) query(ctx context.Context, input *dynamodb.QueryInput) ([]*Schedule, error) {
+	value, err := r.db.DB().Query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]*Schedule, 0, len(value.Items))
+	if err = attributevalue.UnmarshalListOfMaps(value.Items, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// UpdateStatus writes the new status only while the item is still in from and
+// was not touched since previous, so two schedulers, or a scheduler and a
+// cancellation, can not both win the same item.
+func (r *repository) UpdateStatus(ctx context.Context, schedule *Schedule, from Status, previous time.Time) error {
+	upd := expression.Set(expression.Name("Status"), expression.Value(schedule.Status)).
+		Set(expression.Name("ErrMessage"), expression.Value(schedule.ErrMessage)).
+		Set(expression.Name("UpdatedAt"), expression.Value(schedule.UpdatedAt))
+
+	cond := expression.Name("Status").Equal(expression.Value(from)).
+		And(expression.Name("UpdatedAt").Equal(expression.Value(previous)))
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: schedule.ID},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.ScheduleTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrScheduleClaimed
+		}
+		return err
+	}
+	return nil
+}
+
+func NewRepository(db dynamo.Client, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
@@ -0,0 +1,179 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"log"
+	"time"
+	"transaction/internal/errutils"
+	keys "transaction/internal/keys"
+	"transaction/internal/pix"
+)
+
+type Service interface {
+	Schedule(ctx context.Context, schedule *Schedule) (*Schedule, error)
+	List(ctx context.Context, accountID int64) ([]*Schedule, error)
+	Cancel(ctx context.Context, id string, accountID int64) (*Schedule, error)
+	RunDue(ctx context.Context) error
+}
+
+type Options func(*service)
+
+func WithBatchSize(size int) Options {
+	return func(s *service) {
+		s.batchSize = size
+	}
+}
+
+// WithLease sets how long an item may stay in processing before the scheduler
+// assumes its run died and executes it again. Executing twice is safe because
+// the pix transaction reuses the schedule id.
+func WithLease(lease time.Duration) Options {
+	return func(s *service) {
+		s.lease = lease
+	}
+}
+
+type service struct {
+	repo      Repository
+	keysRepo  keys.Repository
+	pix       pix.Service
+	batchSize int
+	lease     time.Duration
+	now       func() time.Time
+}
+
+func (s *service) Schedule(ctx context.Context, schedule *Schedule) (*Schedule, error) {
+	if schedule.Value <= 0 {
+		return nil, errutils.ErrInvalidValue
+	}
+
+	now := s.now()
+	// execution dates are kept in whole UTC seconds so they sort as strings
+	schedule.ExecuteAt = schedule.ExecuteAt.UTC().Truncate(time.Second)
+	if !schedule.ExecuteAt.After(now) {
+		return nil, errutils.ErrInvalidExecutionDate
+	}
+
+	receiver, err := s.keysRepo.FindKey(ctx, schedule.Receiver)
+	if err != nil {
+		return nil, err
+	}
+	if receiver == nil {
+		return nil, errutils.ErrInvalidKey
+	}
+	if schedule.ReceiverAccount != 0 && receiver.Account != schedule.ReceiverAccount {
+		return nil, errutils.ErrReceiverChanged
+	}
+
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	schedule.AccountID = schedule.Account.Name
+	schedule.Status = StatusScheduled
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	if err = s.repo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *service) List(ctx context.Context, accountID int64) ([]*Schedule, error) {
+	return s.repo.ListSchedules(ctx, accountID)
+}
+
+func (s *service) Cancel(ctx context.Context, id string, accountID int64) (*Schedule, error) {
+	schedule, err := s.repo.FindScheduleById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.AccountID != accountID {
+		return nil, errutils.ErrScheduleNotFound
+	}
+	if schedule.Status != StatusScheduled {
+		return nil, errutils.ErrScheduleNotCancelable
+	}
+
+	previous := schedule.UpdatedAt
+	schedule.Status = StatusCanceled
+	schedule.UpdatedAt = s.now()
+	err = s.repo.UpdateStatus(ctx, schedule, StatusScheduled, previous)
+	if errors.Is(err, errutils.ErrScheduleClaimed) {
+		return nil, errutils.ErrScheduleNotCancelable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// RunDue executes every scheduled pix whose date has come, along with the ones
+// a previous run claimed but never finished.
+func (s *service) RunDue(ctx context.Context) error {
+	now := s.now()
+	due, err := s.repo.ListDue(ctx, StatusScheduled, now, s.batchSize)
+	if err != nil {
+		return err
+	}
+
+	processing, err := s.repo.ListDue(ctx, StatusProcessing, now, s.batchSize)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range processing {
+		if schedule.UpdatedAt.Before(now.Add(-s.lease)) {
+			due = append(due, schedule)
+		}
+	}
+
+	for _, schedule := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err = s.execute(ctx, schedule)
+		if errors.Is(err, errutils.ErrScheduleClaimed) {
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to execute scheduled pix %s: %v", schedule.ID, err)
+		}
+	}
+	return nil
+}
+
+// execute claims the item before running it, so a pix canceled or taken by
+// another scheduler in the meantime is left alone.
+func (s *service) execute(ctx context.Context, schedule *Schedule) error {
+	from, previous := schedule.Status, schedule.UpdatedAt
+	schedule.Status = StatusProcessing
+	schedule.UpdatedAt = s.now()
+	if err := s.repo.UpdateStatus(ctx, schedule, from, previous); err != nil {
+		return err
+	}
+
+	claimed := schedule.UpdatedAt
+	schedule.Status = StatusExecuted
+	if err := s.pix.Transaction(ctx, schedule.PixEvent()); err != nil {
+		schedule.Status = StatusFailed
+		schedule.ErrMessage = err.Error()
+	}
+	schedule.UpdatedAt = s.now()
+	return s.repo.UpdateStatus(ctx, schedule, StatusProcessing, claimed)
+}
+
+func NewService(repo Repository, keysRepo keys.Repository, pixService pix.Service, opts ...Options) Service {
+	s := &service{
+		repo:      repo,
+		keysRepo:  keysRepo,
+		pix:       pixService,
+		batchSize: 100,
+		lease:     5 * time.Minute,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+	for _, f := range opts {
+		f(s)
+	}
+	return s
+}
@@ -0,0 +1,293 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+	"transaction/internal/errutils"
+	keys "transaction/internal/keys"
+	"transaction/internal/pix"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+	// statuses records the status written on every UpdateStatus call
+	statuses []Status
+}
+
+func (m *mockRepo) CreateSchedule(ctx context.Context, schedule *Schedule) error {
+	args := m.Called(schedule)
+	return args.Error(0)
+}
+
+func (m *mockRepo) FindScheduleById(ctx context.Context, id string) (*Schedule, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Schedule), args.Error(1)
+}
+
+func (m *mockRepo) ListDue(ctx context.Context, status Status, before time.Time, limit int) ([]*Schedule, error) {
+	args := m.Called(status)
+	return args.Get(0).([]*Schedule), args.Error(1)
+}
+
+func (m *mockRepo) UpdateStatus(ctx context.Context, schedule *Schedule, from Status, previous time.Time) error {
+	m.statuses = append(m.statuses, schedule.Status)
+	args := m.Called(schedule.ID, from)
+	return args.Error(0)
+}
+
+type mockKeysRepo struct {
+	keys.Repository
+	mock.Mock
+}
+
+func (m *mockKeysRepo) FindKey(ctx context.Context, key string) (*keys.Key, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*keys.Key), args.Error(1)
+}
+
+type mockPixService struct {
+	pix.Service
+	mock.Mock
+}
+
+func (m *mockPixService) Transaction(ctx context.Context, pixEvent *pix.PixEvent) error {
+	args := m.Called(pixEvent.ID)
+	return args.Error(0)
+}
+
+var now = time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestService(repo *mockRepo, keysRepo *mockKeysRepo, pixService *mockPixService) *service {
+	s := NewService(repo, keysRepo, pixService, WithLease(time.Minute)).(*service)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestSchedule(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule *Schedule
+		mockFunc func(repo *mockRepo, keysRepo *mockKeysRepo)
+		err      error
+	}{
+		{
+			name: "success",
+			schedule: &Schedule{
+				Account:   Account{Name: 1},
+				Receiver:  "receiver@pix.com",
+				Value:     10,
+				ExecuteAt: now.Add(24*time.Hour + 500*time.Millisecond),
+			},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "receiver@pix.com").Return(&keys.Key{Id: "key-1"}, nil)
+				repo.On("CreateSchedule", mock.MatchedBy(func(schedule *Schedule) bool {
+					return schedule.ID != "" && schedule.AccountID == 1 && schedule.Status == StatusScheduled &&
+						schedule.ExecuteAt.Equal(now.Add(24*time.Hour))
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "failed because execution date is in the past",
+			schedule: &Schedule{Account: Account{Name: 1}, Receiver: "receiver@pix.com", Value: 10, ExecuteAt: now.Add(-time.Minute)},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidExecutionDate,
+		},
+		{
+			name:     "failed because value is not positive",
+			schedule: &Schedule{Account: Account{Name: 1}, Receiver: "receiver@pix.com", ExecuteAt: now.Add(time.Hour)},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {},
+			err:      errutils.ErrInvalidValue,
+		},
+		{
+			name: "failed because receiver key moved from the confirmed account",
+			schedule: &Schedule{
+				Account:         Account{Name: 1},
+				Receiver:        "receiver@pix.com",
+				ReceiverAccount: 2,
+				Value:           10,
+				ExecuteAt:       now.Add(time.Hour),
+			},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "receiver@pix.com").Return(&keys.Key{Id: "key-1", Account: 3}, nil)
+			},
+			err: errutils.ErrReceiverChanged,
+		},
+		{
+			name:     "failed because receiver key does not exist",
+			schedule: &Schedule{Account: Account{Name: 1}, Receiver: "unknown", Value: 10, ExecuteAt: now.Add(time.Hour)},
+			mockFunc: func(repo *mockRepo, keysRepo *mockKeysRepo) {
+				keysRepo.On("FindKey", "unknown").Return(nil, nil)
+			},
+			err: errutils.ErrInvalidKey,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, keysRepo := new(mockRepo), new(mockKeysRepo)
+			tc.mockFunc(repo, keysRepo)
+
+			_, err := newTestService(repo, keysRepo, new(mockPixService)).Schedule(context.Background(), tc.schedule)
+			assert.Equal(t, tc.err, err)
+			repo.AssertExpectations(t)
+			keysRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPixEvent(t *testing.T) {
+	schedule := ProtoToSchedule(ToProto(&Schedule{
+		ID:              "schedule-1",
+		Account:         Account{Name: 1, Cpf: "12345678900"},
+		Receiver:        "receiver@pix.com",
+		TxID:            "order1",
+		ReceiverAccount: 2,
+		Value:           10,
+		ExecuteAt:       now,
+	}))
+
+	event := schedule.PixEvent()
+	assert.Equal(t, "schedule-1", event.ID)
+	assert.Equal(t, "receiver@pix.com", event.Receiver)
+	assert.Equal(t, "order1", event.TxID)
+	assert.Equal(t, int64(2), event.ReceiverAccount)
+	assert.Equal(t, "12345678900", event.Account.Cpf)
+	assert.True(t, event.Authorize)
+}
+
+func TestCancel(t *testing.T) {
+	cases := []struct {
+		name     string
+		mockFunc func(repo *mockRepo)
+		err      error
+	}{
+		{
+			name: "success",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindScheduleById", "schedule-1").Return(&Schedule{ID: "schedule-1", AccountID: 1, Status: StatusScheduled}, nil)
+				repo.On("UpdateStatus", "schedule-1", StatusScheduled).Return(nil)
+			},
+		},
+		{
+			name: "failed because schedule belongs to another account",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindScheduleById", "schedule-1").Return(&Schedule{ID: "schedule-1", AccountID: 2, Status: StatusScheduled}, nil)
+			},
+			err: errutils.ErrScheduleNotFound,
+		},
+		{
+			name: "failed because schedule was already executed",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindScheduleById", "schedule-1").Return(&Schedule{ID: "schedule-1", AccountID: 1, Status: StatusExecuted}, nil)
+			},
+			err: errutils.ErrScheduleNotCancelable,
+		},
+		{
+			name: "failed because scheduler claimed it first",
+			mockFunc: func(repo *mockRepo) {
+				repo.On("FindScheduleById", "schedule-1").Return(&Schedule{ID: "schedule-1", AccountID: 1, Status: StatusScheduled}, nil)
+				repo.On("UpdateStatus", "schedule-1", StatusScheduled).Return(errutils.ErrScheduleClaimed)
+			},
+			err: errutils.ErrScheduleNotCancelable,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			tc.mockFunc(repo)
+
+			canceled, err := newTestService(repo, new(mockKeysRepo), new(mockPixService)).Cancel(context.Background(), "schedule-1", 1)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, StatusCanceled, canceled.Status)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	cases := []struct {
+		name         string
+		mockFunc     func(repo *mockRepo, pixService *mockPixService)
+		wantStatuses []Status
+	}{
+		{
+			name: "executes due pix",
+			mockFunc: func(repo *mockRepo, pixService *mockPixService) {
+				repo.On("ListDue", StatusScheduled).Return([]*Schedule{{ID: "schedule-1", Status: StatusScheduled}}, nil)
+				repo.On("ListDue", StatusProcessing).Return([]*Schedule{}, nil)
+				repo.On("UpdateStatus", "schedule-1", StatusScheduled).Return(nil)
+				pixService.On("Transaction", "schedule-1").Return(nil)
+				repo.On("UpdateStatus", "schedule-1", StatusProcessing).Return(nil)
+			},
+			wantStatuses: []Status{StatusProcessing, StatusExecuted},
+		},
+		{
+			name: "marks pix that could not be sent as failed",
+			mockFunc: func(repo *mockRepo, pixService *mockPixService) {
+				repo.On("ListDue", StatusScheduled).Return([]*Schedule{{ID: "schedule-1", Status: StatusScheduled}}, nil)
+				repo.On("ListDue", StatusProcessing).Return([]*Schedule{}, nil)
+				repo.On("UpdateStatus", "schedule-1", StatusScheduled).Return(nil)
+				pixService.On("Transaction", "schedule-1").Return(errutils.ErrInvalidKey)
+				repo.On("UpdateStatus", "schedule-1", StatusProcessing).Return(nil)
+			},
+			wantStatuses: []Status{StatusProcessing, StatusFailed},
+		},
+		{
+			name: "skips pix canceled or claimed by another run",
+			mockFunc: func(repo *mockRepo, pixService *mockPixService) {
+				repo.On("ListDue", StatusScheduled).Return([]*Schedule{{ID: "schedule-1", Status: StatusScheduled}}, nil)
+				repo.On("ListDue", StatusProcessing).Return([]*Schedule{}, nil)
+				repo.On("UpdateStatus", "schedule-1", StatusScheduled).Return(errutils.ErrScheduleClaimed)
+			},
+			wantStatuses: []Status{StatusProcessing},
+		},
+		{
+			name: "retakes processing pix only after the lease expired",
+			mockFunc: func(repo *mockRepo, pixService *mockPixService) {
+				repo.On("ListDue", StatusScheduled).Return([]*Schedule{}, nil)
+				repo.On("ListDue", StatusProcessing).Return([]*Schedule{
+					{ID: "stale", Status: StatusProcessing, UpdatedAt: now.Add(-time.Hour)},
+					{ID: "running", Status: StatusProcessing, UpdatedAt: now.Add(-time.Second)},
+				}, nil)
+				repo.On("UpdateStatus", "stale", StatusProcessing).Return(nil).Twice()
+				pixService.On("Transaction", "stale").Return(nil)
+			},
+			wantStatuses: []Status{StatusProcessing, StatusExecuted},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, pixService := new(mockRepo), new(mockPixService)
+			tc.mockFunc(repo, pixService)
+
+			err := newTestService(repo, new(mockKeysRepo), pixService).RunDue(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantStatuses, repo.statuses)
+			repo.AssertExpectations(t)
+			pixService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunDueStopsWhenListingFails(t *testing.T) {
+	repo := new(mockRepo)
+	repo.On("ListDue", StatusScheduled).Return([]*Schedule{}, errors.New("dynamo unavailable"))
+
+	err := newTestService(repo, new(mockKeysRepo), new(mockPixService)).RunDue(context.Background())
+	assert.EqualError(t, err, "dynamo unavailable")
+}
@@ -1,28 +1,110 @@
 package transactions
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"time"
+	"transaction/internal/errutils"
 	proto "transaction/proto/v1"
 )
 
 type Status string
 
 const (
-	StatusPending   Status = "PENDING"
-	StatusCompleted Status = "COMPLETED"
-	StatusFailed    Status = "FAILED"
+	StatusPending           Status = "PENDING"
+	StatusProcessing        Status = "PROCESSING"
+	StatusCompleted         Status = "COMPLETED"
+	StatusFailed            Status = "FAILED"
+	StatusPartiallyRefunded Status = "PARTIALLY_REFUNDED"
+	StatusRefunded          Status = "REFUNDED"
 )
 
+// transitions is the state machine of a transaction: the statuses each one
+// can move to. PROCESSING is set while the receiver is notified, FAILED is
+// final, and a canceled refund moves a refunded pix back.
+var transitions = map[Status][]Status{
+	StatusPending:           {StatusProcessing, StatusFailed},
+	StatusProcessing:        {StatusCompleted, StatusFailed},
+	StatusCompleted:         {StatusPartiallyRefunded, StatusRefunded},
+	StatusPartiallyRefunded: {StatusPartiallyRefunded, StatusRefunded, StatusCompleted},
+	StatusRefunded:          {StatusPartiallyRefunded, StatusCompleted},
+}
+
+func (s Status) CanTransition(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Actors recorded in the transition history.
+const (
+	ActorPix    = "pix"
+	ActorRefund = "refund"
+)
+
+// Transition is a status change kept in the history of the transaction.
+type Transition struct {
+	From   Status
+	To     Status
+	Actor  string
+	Reason string
+	At     time.Time
+}
+
+type Type string
+
+const (
+	TypePix    Type = "PIX"
+	TypeRefund Type = "REFUND"
+)
+
+// Direction tells the history of an account which side of the transactions
+// to list. An empty direction lists both.
+type Direction string
+
+const (
+	DirectionSent     Direction = "SENT"
+	DirectionReceived Direction = "RECEIVED"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Transaction moves Value from AccountID to ReceiverAccount. A refund is a
+// transaction of TypeRefund linked to the pix it returns through
+// OriginalTransactionID; the pix keeps the refunded total in RefundedValue.
+// CreatedAt is kept in whole UTC seconds so it sorts as a string in the
+// history indexes.
 type Transaction struct {
-	ID          string `dynamodbav:"PK"`
-	AccountID   int64
-	Receiver    string
-	Value       float64
-	Status      Status
-	ErrMessage  string
-	CreatedAt   time.Time
-	ProcessedAt time.Time
-	UpdatedAt   time.Time
+	ID                    string `dynamodbav:"PK"`
+	IdempotencyKey        string
+	Type                  Type
+	AccountID             int64
+	Receiver              string
+	ReceiverAccount       int64
+	Value                 float64
+	RefundedValue         float64
+	OriginalTransactionID string
+	WebhookUrl            string
+	Status                Status
+	ErrMessage            string
+	CreatedAt             time.Time
+	ProcessedAt           time.Time
+	UpdatedAt             time.Time
+	History               []Transition `dynamodbav:",omitempty"`
+}
+
+type RefundRequest struct {
+	ID            string
+	TransactionID string
+	AccountID     int64
+	Value         float64
 }
 
 type TransactionRequest struct {
@@ -38,6 +120,76 @@ type ListTransaction struct {
 	Transactions []*Transaction
 }
 
+// HistoryRequest asks for a page of the transactions of an account, newest
+// first, created between From and To when they are set.
+type HistoryRequest struct {
+	AccountID int64
+	Direction Direction
+	Status    Status
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Cursor    string
+}
+
+type History struct {
+	Transactions []*Transaction
+	NextCursor   string
+}
+
+// Position is the last transaction of a page on one side of the history.
+type Position struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// cursor keeps where each side of the history stopped, since a page of both
+// directions merges two indexes. A side that is Done has no more transactions.
+type cursor struct {
+	Sent         *Position `json:"sent,omitempty"`
+	SentDone     bool      `json:"sent_done,omitempty"`
+	Received     *Position `json:"received,omitempty"`
+	ReceivedDone bool      `json:"received_done,omitempty"`
+}
+
+func (c *cursor) side(direction Direction) (*Position, bool) {
+	if direction == DirectionSent {
+		return c.Sent, c.SentDone
+	}
+	return c.Received, c.ReceivedDone
+}
+
+func (c *cursor) advance(direction Direction, last *Position, done bool) {
+	if direction == DirectionSent {
+		c.Sent, c.SentDone = last, done
+		return
+	}
+	c.Received, c.ReceivedDone = last, done
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	c := &cursor{}
+	if value == "" {
+		return c, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errutils.ErrInvalidCursor
+	}
+	if err = json.Unmarshal(raw, c); err != nil {
+		return nil, errutils.ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func encodeCursor(c *cursor) (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
 func ProtoToTransactionRequest(request *proto.TransactionRequest) *TransactionRequest {
 	return &TransactionRequest{
 		TransactionID: request.TransactionId,
@@ -51,6 +203,32 @@ func ProtoToListTransactionRequest(request *proto.ListTransactionRequest) *ListT
 	}
 }
 
+func ProtoToHistoryRequest(request *proto.AccountTransactionsRequest) *HistoryRequest {
+	req := &HistoryRequest{
+		AccountID: request.AccountId,
+		Direction: Direction(request.Direction),
+		Status:    Status(request.Status),
+		Limit:     int(request.Limit),
+		Cursor:    request.Cursor,
+	}
+	if request.From != nil {
+		req.From = request.From.AsTime()
+	}
+	if request.To != nil {
+		req.To = request.To.AsTime()
+	}
+	return req
+}
+
+func ProtoToRefundRequest(request *proto.RefundRequest) *RefundRequest {
+	return &RefundRequest{
+		ID:            request.RefundId,
+		TransactionID: request.TransactionId,
+		AccountID:     request.AccountId,
+		Value:         request.Value,
+	}
+}
+
 func ProtoToTransaction(transaction *proto.Transaction) *Transaction {
 	return &Transaction{
 		AccountID: transaction.AccountId,
@@ -62,9 +240,41 @@ func ProtoToTransaction(transaction *proto.Transaction) *Transaction {
 
 func ToProto(transaction *Transaction) *proto.Transaction {
 	return &proto.Transaction{
-		AccountId: transaction.AccountID,
-		Receiver:  transaction.Receiver,
-		Value:     transaction.Value,
-		Status:    string(transaction.Status),
+		Id:                    transaction.ID,
+		Type:                  string(transaction.Type),
+		AccountId:             transaction.AccountID,
+		Receiver:              transaction.Receiver,
+		Value:                 transaction.Value,
+		Status:                string(transaction.Status),
+		OriginalTransactionId: transaction.OriginalTransactionID,
+		RefundedValue:         transaction.RefundedValue,
+		ReceiverAccount:       transaction.ReceiverAccount,
+		CreatedAt:             timestamppb.New(transaction.CreatedAt),
+	}
+}
+
+func HistoryToProto(history *History) *proto.TransactionPage {
+	list := make([]*proto.Transaction, len(history.Transactions))
+	for i := range history.Transactions {
+		list[i] = ToProto(history.Transactions[i])
+	}
+	return &proto.TransactionPage{Transactions: list, NextCursor: history.NextCursor}
+}
+
+func TransitionsToProto(transaction *Transaction) *proto.StatusTransitions {
+	list := make([]*proto.StatusTransition, len(transaction.History))
+	for i, transition := range transaction.History {
+		list[i] = &proto.StatusTransition{
+			From:   string(transition.From),
+			To:     string(transition.To),
+			Actor:  transition.Actor,
+			Reason: transition.Reason,
+			At:     timestamppb.New(transition.At),
+		}
+	}
+	return &proto.StatusTransitions{
+		TransactionId: transaction.ID,
+		Status:        string(transaction.Status),
+		Transitions:   list,
 	}
 }
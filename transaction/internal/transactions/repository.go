@@ -8,15 +8,29 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"strconv"
+	"time"
 	"transaction/internal/cfg"
+	"transaction/internal/errutils"
 	"transaction/platform/dynamo"
 )
 
+// The transaction table needs two global secondary indexes for the history:
+// AccountIndex (AccountID, CreatedAt) for the transactions an account sent and
+// ReceiverIndex (ReceiverAccount, CreatedAt) for the ones it received.
+const (
+	accountIndex  = "AccountIndex"
+	receiverIndex = "ReceiverIndex"
+)
+
 type Repository interface {
 	CreateTransaction(transaction *Transaction) (*Transaction, error)
 	FindTransactionById(id string) (*Transaction, error)
 	ListTransactions(ids []string) ([]*Transaction, error)
-	UpdateTransactionStatus(transaction *Transaction) error
+	ListAccountTransactions(ctx context.Context, req *HistoryRequest, direction Direction, after *Position, limit int) ([]*Transaction, bool, error)
+	UpdateTransactionStatus(transaction *Transaction, transition Transition) error
+	UpdateRefundedValue(transaction *Transaction, previous float64, transition Transition) error
+	MarkProcessed(id string, at time.Time) error
 }
 
 type repository struct {
@@ -37,6 +51,10 @@ func (r *repository) CreateTransaction(transaction *Transaction) (*Transaction,
 		ConditionExpression: aws.String("attribute_not_exists(PK)"),
 	})
 	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return nil, errutils.ErrTransactionAlreadyExists
+		}
 		return nil, err
 	}
 	err = attributevalue.UnmarshalMap(item.Attributes, transaction)
@@ -47,16 +65,25 @@ func (r *repository) CreateTransaction(transaction *Transaction) (*Transaction,
 	return transaction, nil
 }
 
+// transactionKey is the key of a transaction, ID is a string so PK is
+// written as one.
+func transactionKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: id},
+	}
+}
+
 func (r *repository) FindTransactionById(id string) (*Transaction, error) {
 	value, err := r.db.DB().GetItem(context.Background(), &dynamodb.GetItemInput{
 		TableName: aws.String(r.cfg.DynamodbConfig.TransactionTable),
-		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberN{Value: id},
-		},
+		Key:       transactionKey(id),
 	})
 	if err != nil {
 		return nil, err
 	}
+	if value.Item == nil {
+		return nil, errutils.ErrTransactionNotFound
+	}
 
 	var transaction Transaction
 	err = attributevalue.UnmarshalMap(value.Item, &transaction)
@@ -95,13 +122,92 @@ func (r *repository) ListTransactions(ids []string) ([]*Transaction, error) {
 	return listTransaction, nil
 }
 
-func (r *repository) UpdateTransactionStatus(transaction *Transaction) error {
-	upd := expression.Set(expression.Name("Status"), expression.Value(transaction.Status)).
-		Set(expression.Name("UpdateAt"), expression.Value(transaction.UpdatedAt)).
+// ListAccountTransactions returns up to limit transactions of one side of the
+// account history older than after, newest first. It reports whether the side
+// has no transactions left after the ones returned.
+func (r *repository) ListAccountTransactions(ctx context.Context, req *HistoryRequest, direction Direction, after *Position, limit int) ([]*Transaction, bool, error) {
+	index, attribute := accountIndex, "AccountID"
+	if direction == DirectionReceived {
+		index, attribute = receiverIndex, "ReceiverAccount"
+	}
+
+	keyCond := expression.Key(attribute).Equal(expression.Value(req.AccountID))
+	switch {
+	case !req.From.IsZero() && !req.To.IsZero():
+		keyCond = keyCond.And(expression.Key("CreatedAt").Between(expression.Value(req.From.UTC()), expression.Value(req.To.UTC())))
+	case !req.From.IsZero():
+		keyCond = keyCond.And(expression.Key("CreatedAt").GreaterThanEqual(expression.Value(req.From.UTC())))
+	case !req.To.IsZero():
+		keyCond = keyCond.And(expression.Key("CreatedAt").LessThanEqual(expression.Value(req.To.UTC())))
+	}
+	builder := expression.NewBuilder().WithKeyCondition(keyCond)
+	if req.Status != "" {
+		builder = builder.WithFilter(expression.Name("Status").Equal(expression.Value(req.Status)))
+	}
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, false, errors.New("failed to build expression")
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.TransactionTable),
+		IndexName:                 aws.String(index),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit)),
+	}
+	if after != nil {
+		createdAt, err := attributevalue.Marshal(after.CreatedAt)
+		if err != nil {
+			return nil, false, err
+		}
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"PK":        &types.AttributeValueMemberS{Value: after.ID},
+			attribute:   &types.AttributeValueMemberN{Value: strconv.FormatInt(req.AccountID, 10)},
+			"CreatedAt": createdAt,
+		}
+	}
+
+	// the status filter runs after the limit, so short pages are followed up
+	found := make([]*Transaction, 0, limit)
+	for {
+		value, err := r.db.DB().Query(ctx, input)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var page []*Transaction
+		if err = attributevalue.UnmarshalListOfMaps(value.Items, &page); err != nil {
+			return nil, false, err
+		}
+		for i := range page {
+			found = append(found, page[i])
+			if len(found) == limit {
+				return found, value.LastEvaluatedKey == nil && i == len(page)-1, nil
+			}
+		}
+		if value.LastEvaluatedKey == nil {
+			return found, true, nil
+		}
+		input.ExclusiveStartKey = value.LastEvaluatedKey
+	}
+}
+
+// UpdateTransactionStatus applies the transition only if the stored status is
+// still transition.From, so a late update can never overwrite a status that
+// moved on. The transition is appended to the history in the same write.
+func (r *repository) UpdateTransactionStatus(transaction *Transaction, transition Transition) error {
+	upd := appendHistory(expression.Set(expression.Name("Status"), expression.Value(transaction.Status)), transition).
+		Set(expression.Name("UpdatedAt"), expression.Value(transaction.UpdatedAt)).
 		Set(expression.Name("ProcessedAt"), expression.Value(transaction.ProcessedAt)).
 		Set(expression.Name("ErrMessage"), expression.Value(transaction.ErrMessage))
 
-	expr, err := expression.NewBuilder().WithUpdate(upd).Build()
+	cond := expression.Name("Status").Equal(expression.Value(transition.From))
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
 	if err != nil {
 		return errors.New("failed to build expression")
 	}
@@ -113,17 +219,97 @@ func (r *repository) UpdateTransactionStatus(transaction *Transaction) error {
 		TableName:                 aws.String(r.cfg.DynamodbConfig.TransactionTable),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
 		UpdateExpression:          expr.Update(),
 	}
 
 	_, err = r.db.DB().UpdateItem(context.Background(), dyInput)
 	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrTransactionConflict
+		}
 		return errors.New("failed to update item")
 	}
 
 	return nil
 }
 
+// MarkProcessed records when the receiver took the transaction, without
+// touching its status.
+func (r *repository) MarkProcessed(id string, at time.Time) error {
+	upd := expression.Set(expression.Name("ProcessedAt"), expression.Value(at)).
+		Set(expression.Name("UpdatedAt"), expression.Value(time.Now()))
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(expression.AttributeExists(expression.Name("PK"))).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.TransactionTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrTransactionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateRefundedValue stores the new refunded total and status only if the
+// refunded total and status are still the ones the caller read, so concurrent
+// refunds can not add up to more than the transaction value.
+func (r *repository) UpdateRefundedValue(transaction *Transaction, previous float64, transition Transition) error {
+	upd := appendHistory(expression.Set(expression.Name("RefundedValue"), expression.Value(transaction.RefundedValue)), transition).
+		Set(expression.Name("Status"), expression.Value(transaction.Status)).
+		Set(expression.Name("UpdatedAt"), expression.Value(transaction.UpdatedAt))
+
+	cond := expression.Name("RefundedValue").Equal(expression.Value(previous))
+	if previous == 0 {
+		cond = expression.Or(expression.Name("RefundedValue").AttributeNotExists(), cond)
+	}
+	cond = expression.And(cond, expression.Name("Status").Equal(expression.Value(transition.From)))
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: transaction.ID},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.TransactionTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrTransactionConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func appendHistory(upd expression.UpdateBuilder, transition Transition) expression.UpdateBuilder {
+	history := expression.IfNotExists(expression.Name("History"), expression.Value([]Transition{}))
+	return upd.Set(expression.Name("History"), expression.ListAppend(history, expression.Value([]Transition{transition})))
+}
+
 func NewRepository(db dynamo.Client, config *cfg.Config) Repository {
 	return &repository{
 		db:  db,
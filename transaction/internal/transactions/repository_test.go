@@ -0,0 +1,16 @@
+package transactions
+
+import (
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTransactionKey(t *testing.T) {
+	item, err := attributevalue.MarshalMap(&Transaction{ID: "tx-1"})
+	require.NoError(t, err)
+
+	// a transaction is found with the key it was created with
+	assert.Equal(t, item["PK"], transactionKey("tx-1")["PK"])
+}
@@ -1,17 +1,27 @@
 package transactions
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"time"
+	"transaction/internal/errutils"
 	"transaction/internal/event"
 )
 
+// refundAttempts bounds how often a refund retries after losing a concurrent
+// update of the same transaction.
+const refundAttempts = 3
+
 type Service interface {
 	CreateTransaction(transaction *Transaction) (*Transaction, error)
 	FindTransactionById(id *TransactionRequest) (*Transaction, error)
 	ListTransactions(transactionIDs *ListTransactionRequest) ([]*Transaction, error)
-	UpdateTransactionStatus(transaction *Transaction) error
+	ListAccountTransactions(ctx context.Context, req *HistoryRequest) (*History, error)
+	Transition(transaction *Transaction, to Status, actor, reason string) error
+	Refund(req *RefundRequest) (*Transaction, error)
+	CancelRefund(refund *Transaction, cause error) error
 }
 
 type service struct {
@@ -20,8 +30,10 @@ type service struct {
 }
 
 func (s service) CreateTransaction(transaction *Transaction) (*Transaction, error) {
-	transaction.CreatedAt = time.Now()
-	transaction.ID = uuid.New().String()
+	transaction.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	if transaction.ID == "" {
+		transaction.ID = uuid.New().String()
+	}
 	return s.repo.CreateTransaction(transaction)
 }
 
@@ -36,9 +48,259 @@ func (s service) ListTransactions(req *ListTransactionRequest) ([]*Transaction,
 	return s.repo.ListTransactions(req.transactionIDs)
 }
 
-func (s service) UpdateTransactionStatus(transaction *Transaction) error {
-	transaction.UpdatedAt = time.Now()
-	return s.repo.UpdateTransactionStatus(transaction)
+// ListAccountTransactions returns a page of the account history. Listing both
+// directions merges the sent and received indexes by creation time, and the
+// cursor keeps where each of them stopped.
+func (s service) ListAccountTransactions(ctx context.Context, req *HistoryRequest) (*History, error) {
+	if err := validHistory(req); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	current, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	directions := []Direction{DirectionSent, DirectionReceived}
+	if req.Direction != "" {
+		directions = []Direction{req.Direction}
+	}
+
+	pages := make(map[Direction][]*Transaction, len(directions))
+	exhausted := make(map[Direction]bool, len(directions))
+	for _, direction := range directions {
+		after, done := current.side(direction)
+		if done {
+			continue
+		}
+		pages[direction], exhausted[direction], err = s.repo.ListAccountTransactions(ctx, req, direction, after, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	history := &History{Transactions: make([]*Transaction, 0, limit)}
+	taken := make(map[Direction]int, len(directions))
+	for len(history.Transactions) < limit {
+		var next *Transaction
+		var from Direction
+		for _, direction := range directions {
+			if i := taken[direction]; i < len(pages[direction]) && (next == nil || newer(pages[direction][i], next)) {
+				next, from = pages[direction][i], direction
+			}
+		}
+		if next == nil {
+			break
+		}
+		taken[from]++
+
+		// a transfer between two keys of the same account is on both sides
+		if last := len(history.Transactions) - 1; last >= 0 && history.Transactions[last].ID == next.ID {
+			continue
+		}
+		history.Transactions = append(history.Transactions, next)
+	}
+
+	more := false
+	for _, direction := range directions {
+		after, done := current.side(direction)
+		if done {
+			continue
+		}
+		page, n := pages[direction], taken[direction]
+		if n > 0 {
+			after = &Position{ID: page[n-1].ID, CreatedAt: page[n-1].CreatedAt}
+		}
+		done = n == len(page) && exhausted[direction]
+		current.advance(direction, after, done)
+		more = more || !done
+	}
+	if more {
+		if history.NextCursor, err = encodeCursor(current); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+func validHistory(req *HistoryRequest) error {
+	if req.AccountID == 0 {
+		return errutils.ErrInvalidHistoryFilter
+	}
+	switch req.Direction {
+	case "", DirectionSent, DirectionReceived:
+	default:
+		return errutils.ErrInvalidHistoryFilter
+	}
+	switch req.Status {
+	case "", StatusPending, StatusProcessing, StatusCompleted, StatusFailed, StatusPartiallyRefunded, StatusRefunded:
+	default:
+		return errutils.ErrInvalidHistoryFilter
+	}
+	if !req.From.IsZero() && !req.To.IsZero() && req.To.Before(req.From) {
+		return errutils.ErrInvalidHistoryFilter
+	}
+	return nil
+}
+
+// newer orders the history newest first, by id within the same second.
+func newer(a, b *Transaction) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// Transition moves the transaction to status to if the state machine allows
+// it and nobody moved it since it was read, and keeps the change in its
+// history.
+func (s service) Transition(transaction *Transaction, to Status, actor, reason string) error {
+	from := transaction.Status
+	if !from.CanTransition(to) {
+		return errutils.ErrInvalidTransition
+	}
+
+	now := time.Now()
+	transition := Transition{From: from, To: to, Actor: actor, Reason: reason, At: now.UTC()}
+	transaction.Status = to
+	transaction.UpdatedAt = now
+	if err := s.repo.UpdateTransactionStatus(transaction, transition); err != nil {
+		transaction.Status = from
+		return err
+	}
+	transaction.History = append(transaction.History, transition)
+	return nil
+}
+
+// Refund reserves the value on the original pix and creates the linked refund
+// transaction. A zero value refunds whatever was not refunded yet.
+func (s service) Refund(req *RefundRequest) (*Transaction, error) {
+	value := decimal.NewFromFloat(req.Value)
+	if value.IsNegative() {
+		return nil, errutils.ErrInvalidValue
+	}
+
+	id := req.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	original, amount, err := s.adjustRefunded(req.TransactionID, "refund "+id, func(original *Transaction) (decimal.Decimal, error) {
+		if original.Type == TypeRefund || original.ReceiverAccount == 0 {
+			return decimal.Zero, errutils.ErrTransactionNotRefundable
+		}
+		if original.ReceiverAccount != req.AccountID {
+			return decimal.Zero, errutils.ErrNotTransactionReceiver
+		}
+		if original.Status != StatusCompleted && original.Status != StatusPartiallyRefunded {
+			return decimal.Zero, errutils.ErrTransactionNotRefundable
+		}
+
+		remaining := decimal.NewFromFloat(original.Value).Sub(decimal.NewFromFloat(original.RefundedValue))
+		if value.IsZero() {
+			value = remaining
+		}
+		if !value.IsPositive() || value.GreaterThan(remaining) {
+			return decimal.Zero, errutils.ErrRefundExceedsValue
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &Transaction{
+		ID:                    id,
+		Type:                  TypeRefund,
+		AccountID:             original.ReceiverAccount,
+		ReceiverAccount:       original.AccountID,
+		Value:                 amount.InexactFloat64(),
+		OriginalTransactionID: original.ID,
+		WebhookUrl:            original.WebhookUrl,
+		Status:                StatusPending,
+		CreatedAt:             time.Now().UTC().Truncate(time.Second),
+	}
+	if _, err = s.repo.CreateTransaction(refund); err != nil {
+		if _, _, undoErr := s.adjustRefunded(original.ID, "refund "+id+" not created", constant(amount.Neg())); undoErr != nil {
+			return nil, undoErr
+		}
+		return nil, err
+	}
+	return refund, nil
+}
+
+// CancelRefund fails a refund that could not be settled and gives its value
+// back to the original pix.
+func (s service) CancelRefund(refund *Transaction, cause error) error {
+	amount := decimal.NewFromFloat(refund.Value).Neg()
+	if _, _, err := s.adjustRefunded(refund.OriginalTransactionID, "refund "+refund.ID+" canceled", constant(amount)); err != nil {
+		return err
+	}
+
+	refund.ErrMessage = cause.Error()
+	return s.Transition(refund, StatusFailed, ActorRefund, cause.Error())
+}
+
+// adjustRefunded adds the amount returned by delta to the refunded total of the
+// transaction, re-reading it when another refund changed it in between. The
+// status change is recorded with reason.
+func (s service) adjustRefunded(id, reason string, delta func(*Transaction) (decimal.Decimal, error)) (*Transaction, decimal.Decimal, error) {
+	for attempt := 1; ; attempt++ {
+		transaction, err := s.repo.FindTransactionById(id)
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+
+		amount, err := delta(transaction)
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+
+		previous := transaction.RefundedValue
+		refunded := decimal.NewFromFloat(previous).Add(amount)
+		status := refundStatus(decimal.NewFromFloat(transaction.Value), refunded)
+		if !transaction.Status.CanTransition(status) {
+			return nil, decimal.Zero, errutils.ErrInvalidTransition
+		}
+
+		now := time.Now()
+		transition := Transition{From: transaction.Status, To: status, Actor: ActorRefund, Reason: reason, At: now.UTC()}
+		transaction.RefundedValue = refunded.InexactFloat64()
+		transaction.Status = status
+		transaction.UpdatedAt = now
+
+		err = s.repo.UpdateRefundedValue(transaction, previous, transition)
+		if errors.Is(err, errutils.ErrTransactionConflict) && attempt < refundAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+		return transaction, amount, nil
+	}
+}
+
+func refundStatus(value, refunded decimal.Decimal) Status {
+	switch {
+	case !refunded.IsPositive():
+		return StatusCompleted
+	case refunded.GreaterThanOrEqual(value):
+		return StatusRefunded
+	default:
+		return StatusPartiallyRefunded
+	}
+}
+
+func constant(amount decimal.Decimal) func(*Transaction) (decimal.Decimal, error) {
+	return func(*Transaction) (decimal.Decimal, error) {
+		return amount, nil
+	}
 }
 
 func NewService(repo Repository) Service {
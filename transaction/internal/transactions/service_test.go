@@ -1,10 +1,14 @@
 package transactions
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
+	"transaction/internal/errutils"
 )
 
 type mockTransactionRepo struct {
@@ -27,12 +31,27 @@ func (m *mockTransactionRepo) ListTransactions(ids []string) ([]*Transaction, er
 	return args.Get(0).([]*Transaction), args.Error(1)
 }
 
-func (m *mockTransactionRepo) UpdateTransactionStatus(transaction *Transaction) error {
-	args := m.Called(transaction)
+func (m *mockTransactionRepo) ListAccountTransactions(ctx context.Context, req *HistoryRequest, direction Direction, after *Position, limit int) ([]*Transaction, bool, error) {
+	args := m.Called(direction, after, limit)
+	return args.Get(0).([]*Transaction), args.Bool(1), args.Error(2)
+}
+
+func (m *mockTransactionRepo) UpdateTransactionStatus(transaction *Transaction, transition Transition) error {
+	args := m.Called(transaction.ID, transition.From, transition.To)
 	return args.Error(0)
 }
 
 func TestCreateTransaction(t *testing.T) {
+	// the service stamps the id and the creation time, the rest is stored as sent
+	created := func(id string) interface{} {
+		return mock.MatchedBy(func(tx *Transaction) bool {
+			return (id == "" && tx.ID != "" || tx.ID == id) &&
+				tx.AccountID == 1 && tx.Receiver == "2" && tx.Value == 100.0 && tx.Status == StatusPending &&
+				tx.CreatedAt.Location() == time.UTC && tx.CreatedAt.Equal(tx.CreatedAt.Truncate(time.Second)) &&
+				time.Since(tx.CreatedAt) < time.Minute
+		})
+	}
+
 	cases := []struct {
 		name     string
 		req      *Transaction
@@ -43,21 +62,16 @@ func TestCreateTransaction(t *testing.T) {
 		{
 			name: "success",
 			req: &Transaction{
-				AccountID: "1",
+				AccountID: 1,
 				Receiver:  "2",
 				Value:     100.0,
 				Status:    StatusPending,
 			},
 			mockFunc: func(repo *mockTransactionRepo) {
-				repo.On("CreateTransaction", &Transaction{
-					AccountID: "1",
-					Receiver:  "2",
-					Value:     100.0,
-					Status:    StatusPending,
-				}).
+				repo.On("CreateTransaction", created("")).
 					Return(&Transaction{
 						ID:        "1",
-						AccountID: "1",
+						AccountID: 1,
 						Receiver:  "2",
 						Value:     100.0,
 						Status:    StatusPending,
@@ -65,7 +79,35 @@ func TestCreateTransaction(t *testing.T) {
 			},
 			want: &Transaction{
 				ID:        "1",
-				AccountID: "1",
+				AccountID: 1,
+				Receiver:  "2",
+				Value:     100.0,
+				Status:    StatusPending,
+			},
+			err: nil,
+		},
+		{
+			name: "success keeping the id of the request",
+			req: &Transaction{
+				ID:        "tx-1",
+				AccountID: 1,
+				Receiver:  "2",
+				Value:     100.0,
+				Status:    StatusPending,
+			},
+			mockFunc: func(repo *mockTransactionRepo) {
+				repo.On("CreateTransaction", created("tx-1")).
+					Return(&Transaction{
+						ID:        "tx-1",
+						AccountID: 1,
+						Receiver:  "2",
+						Value:     100.0,
+						Status:    StatusPending,
+					}, nil)
+			},
+			want: &Transaction{
+				ID:        "tx-1",
+				AccountID: 1,
 				Receiver:  "2",
 				Value:     100.0,
 				Status:    StatusPending,
@@ -75,18 +117,13 @@ func TestCreateTransaction(t *testing.T) {
 		{
 			name: "failed because error in create transaction",
 			req: &Transaction{
-				AccountID: "1",
+				AccountID: 1,
 				Receiver:  "2",
 				Value:     100.0,
 				Status:    StatusPending,
 			},
 			mockFunc: func(repo *mockTransactionRepo) {
-				repo.On("CreateTransaction", &Transaction{
-					AccountID: "1",
-					Receiver:  "2",
-					Value:     100.0,
-					Status:    StatusPending,
-				}).
+				repo.On("CreateTransaction", created("")).
 					Return((*Transaction)(nil), errors.New("MOCK-ERROR"))
 			},
 			want: nil,
@@ -105,6 +142,7 @@ func TestCreateTransaction(t *testing.T) {
 			got, err := s.CreateTransaction(c.req)
 			assert.Equal(t, err, c.err)
 			assert.Equal(t, got, c.want)
+			repo.AssertExpectations(t)
 		})
 	}
 }
@@ -126,7 +164,7 @@ func TestFindTransactionById(t *testing.T) {
 				repo.On("FindTransactionById", "1").
 					Return(&Transaction{
 						ID:        "1",
-						AccountID: "1",
+						AccountID: 1,
 						Receiver:  "2",
 						Value:     100.0,
 						Status:    StatusPending,
@@ -134,7 +172,7 @@ func TestFindTransactionById(t *testing.T) {
 			},
 			want: &Transaction{
 				ID:        "1",
-				AccountID: "1",
+				AccountID: 1,
 				Receiver:  "2",
 				Value:     100.0,
 				Status:    StatusPending,
@@ -188,14 +226,14 @@ func TestListTransactions(t *testing.T) {
 					Return([]*Transaction{
 						{
 							ID:        "1",
-							AccountID: "1",
+							AccountID: 1,
 							Receiver:  "2",
 							Value:     100.0,
 							Status:    StatusPending,
 						},
 						{
 							ID:        "2",
-							AccountID: "1",
+							AccountID: 1,
 							Receiver:  "2",
 							Value:     200.0,
 							Status:    StatusCompleted,
@@ -205,14 +243,14 @@ func TestListTransactions(t *testing.T) {
 			want: []*Transaction{
 				{
 					ID:        "1",
-					AccountID: "1",
+					AccountID: 1,
 					Receiver:  "2",
 					Value:     100.0,
 					Status:    StatusPending,
 				},
 				{
 					ID:        "2",
-					AccountID: "1",
+					AccountID: 1,
 					Receiver:  "2",
 					Value:     200.0,
 					Status:    StatusCompleted,
@@ -258,40 +296,41 @@ func TestListTransactions(t *testing.T) {
 	}
 }
 
-func TestUpdateTransactionStatus(t *testing.T) {
+func TestTransition(t *testing.T) {
 	cases := []struct {
 		name     string
 		req      *Transaction
+		to       Status
 		mockFunc func(repo *mockTransactionRepo)
+		want     Status
 		err      error
 	}{
 		{
 			name: "success",
-			req: &Transaction{
-				ID:     "1",
-				Status: StatusCompleted,
-			},
+			req:  &Transaction{ID: "1", Status: StatusProcessing},
+			to:   StatusCompleted,
 			mockFunc: func(repo *mockTransactionRepo) {
-				repo.On("UpdateTransactionStatus", &Transaction{
-					ID:     "1",
-					Status: StatusCompleted,
-				}).Return(nil)
+				repo.On("UpdateTransactionStatus", "1", StatusProcessing, StatusCompleted).Return(nil)
 			},
-			err: nil,
+			want: StatusCompleted,
 		},
 		{
-			name: "failed because error in update transaction status",
-			req: &Transaction{
-				ID:     "1",
-				Status: StatusFailed,
-			},
+			name:     "failed because a completed transaction can not fail",
+			req:      &Transaction{ID: "1", Status: StatusCompleted},
+			to:       StatusFailed,
+			mockFunc: func(repo *mockTransactionRepo) {},
+			want:     StatusCompleted,
+			err:      errutils.ErrInvalidTransition,
+		},
+		{
+			name: "failed because the status moved since it was read",
+			req:  &Transaction{ID: "1", Status: StatusProcessing},
+			to:   StatusFailed,
 			mockFunc: func(repo *mockTransactionRepo) {
-				repo.On("UpdateTransactionStatus", &Transaction{
-					ID:     "1",
-					Status: StatusFailed,
-				}).Return(errors.New("MOCK-ERROR"))
+				repo.On("UpdateTransactionStatus", "1", StatusProcessing, StatusFailed).Return(errutils.ErrTransactionConflict)
 			},
-			err: errors.New("MOCK-ERROR"),
+			want: StatusProcessing,
+			err:  errutils.ErrTransactionConflict,
 		},
 	}
 
@@ -303,8 +342,122 @@ func TestUpdateTransactionStatus(t *testing.T) {
 
 			s := NewService(repo)
 
-			err := s.UpdateTransactionStatus(c.req)
+			err := s.Transition(c.req, c.to, ActorPix, "test")
 			assert.Equal(t, err, c.err)
+			assert.Equal(t, c.want, c.req.Status)
+			if c.err == nil {
+				assert.Len(t, c.req.History, 1)
+			}
+			repo.AssertExpectations(t)
 		})
 	}
 }
+
+func TestListAccountTransactions(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2023, 12, 14, 10, minute, 0, 0, time.UTC) }
+	sent := []*Transaction{{ID: "4", CreatedAt: at(4)}, {ID: "1", CreatedAt: at(1)}}
+	received := []*Transaction{{ID: "3", CreatedAt: at(3)}, {ID: "2", CreatedAt: at(2)}}
+
+	cases := []struct {
+		name       string
+		req        *HistoryRequest
+		mockFunc   func(repo *mockTransactionRepo)
+		want       []string
+		wantCursor *cursor
+		err        error
+	}{
+		{
+			name: "both directions are merged newest first",
+			req:  &HistoryRequest{AccountID: 1, Limit: 2},
+			mockFunc: func(repo *mockTransactionRepo) {
+				repo.On("ListAccountTransactions", DirectionSent, (*Position)(nil), 2).Return(sent, true, nil)
+				repo.On("ListAccountTransactions", DirectionReceived, (*Position)(nil), 2).Return(received, false, nil)
+			},
+			want: []string{"4", "3"},
+			wantCursor: &cursor{
+				Sent:     &Position{ID: "4", CreatedAt: at(4)},
+				Received: &Position{ID: "3", CreatedAt: at(3)},
+			},
+		},
+		{
+			name: "cursor skips the finished direction",
+			req: &HistoryRequest{AccountID: 1, Limit: 2, Cursor: mustCursor(t, &cursor{
+				Sent:     &Position{ID: "4", CreatedAt: at(4)},
+				Received: &Position{ID: "2", CreatedAt: at(2)}, ReceivedDone: true,
+			})},
+			mockFunc: func(repo *mockTransactionRepo) {
+				repo.On("ListAccountTransactions", DirectionSent, &Position{ID: "4", CreatedAt: at(4)}, 2).Return(sent[1:], true, nil)
+			},
+			want: []string{"1"},
+		},
+		{
+			name: "a transfer to the same account is listed once",
+			req:  &HistoryRequest{AccountID: 1, Limit: 2},
+			mockFunc: func(repo *mockTransactionRepo) {
+				repo.On("ListAccountTransactions", DirectionSent, (*Position)(nil), 2).Return(sent[:1], true, nil)
+				repo.On("ListAccountTransactions", DirectionReceived, (*Position)(nil), 2).Return(sent[:1], true, nil)
+			},
+			want: []string{"4"},
+		},
+		{
+			name: "pix still being processed can be listed",
+			req:  &HistoryRequest{AccountID: 1, Direction: DirectionSent, Status: StatusProcessing, Limit: 2},
+			mockFunc: func(repo *mockTransactionRepo) {
+				repo.On("ListAccountTransactions", DirectionSent, (*Position)(nil), 2).
+					Return([]*Transaction{{ID: "5", Status: StatusProcessing, CreatedAt: at(5)}}, true, nil)
+			},
+			want: []string{"5"},
+		},
+		{
+			name:     "unknown status is rejected",
+			req:      &HistoryRequest{AccountID: 1, Status: "SETTLED"},
+			mockFunc: func(repo *mockTransactionRepo) {},
+			err:      errutils.ErrInvalidHistoryFilter,
+		},
+		{
+			name:     "unknown direction is rejected",
+			req:      &HistoryRequest{AccountID: 1, Direction: "BOTH"},
+			mockFunc: func(repo *mockTransactionRepo) {},
+			err:      errutils.ErrInvalidHistoryFilter,
+		},
+		{
+			name:     "bad cursor is rejected",
+			req:      &HistoryRequest{AccountID: 1, Cursor: "%%%"},
+			mockFunc: func(repo *mockTransactionRepo) {},
+			err:      errutils.ErrInvalidCursor,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockTransactionRepo)
+			c.mockFunc(repo)
+
+			history, err := NewService(repo).ListAccountTransactions(context.Background(), c.req)
+			assert.Equal(t, c.err, err)
+			repo.AssertExpectations(t)
+			if c.err != nil {
+				return
+			}
+
+			ids := make([]string, len(history.Transactions))
+			for i, transaction := range history.Transactions {
+				ids[i] = transaction.ID
+			}
+			assert.Equal(t, c.want, ids)
+			if c.wantCursor == nil {
+				assert.Empty(t, history.NextCursor)
+				return
+			}
+			next, err := decodeCursor(history.NextCursor)
+			require.NoError(t, err)
+			assert.Equal(t, c.wantCursor, next)
+		})
+	}
+}
+
+func mustCursor(t *testing.T, c *cursor) string {
+	value, err := encodeCursor(c)
+	require.NoError(t, err)
+	return value
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn right away and then once per interval until ctx is done.
+// Failures are logged and the next tick runs again.
+func Every(ctx context.Context, interval time.Duration, name string, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("worker [%s] started, running every %s\n", name, interval)
+	for {
+		if err := fn(ctx); err != nil {
+			log.Printf("worker [%s] failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,20 +1,33 @@
 package webhook
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	proto "transaction/proto/v1"
+)
 
 type Status string
 
 const (
 	StatusCompleted Status = "COMPLETED"
 	StatusFailed    Status = "FAILED"
+	// StatusAuthorize asks the sender side to hold the amount of a pix it did
+	// not hold when the pix was requested.
+	StatusAuthorize Status = "AUTHORIZE"
 )
 
 type Webhook struct {
-	TransactionId string          `json:"transaction_id"`
-	Sender        Account         `json:"sender"`
-	Receiver      Account         `json:"receiver"`
-	Amount        decimal.Decimal `json:"amount"`
-	Status        Status          `json:"status"`
+	TransactionId         string          `json:"transaction_id"`
+	OriginalTransactionId string          `json:"original_transaction_id,omitempty"`
+	Sender                Account         `json:"sender"`
+	Receiver              Account         `json:"receiver"`
+	Amount                decimal.Decimal `json:"amount"`
+	TxID                  string          `json:"txid,omitempty"`
+	Status                Status          `json:"status"`
+	// ReceiverKey is the key the pix is sent to, set on StatusAuthorize so the
+	// sender side can assess the pix as it runs
+	ReceiverKey string `json:"receiver_key,omitempty"`
 }
 
 type Account struct {
@@ -23,3 +36,222 @@ type Account struct {
 	Bank   string
 	Cpf    string
 }
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	DeliveryFailed    DeliveryStatus = "FAILED"
+)
+
+// EventType is what a subscription listens to. Ping is only sent on request
+// and can not be subscribed to.
+type EventType string
+
+const (
+	EventPixReceived   EventType = "pix.received"
+	EventPixSent       EventType = "pix.sent"
+	EventPixFailed     EventType = "pix.failed"
+	EventRefundCreated EventType = "refund.created"
+	EventChargePaid    EventType = "charge.paid"
+	EventPing          EventType = "ping"
+)
+
+func (e EventType) Valid() bool {
+	switch e {
+	case EventPixReceived, EventPixSent, EventPixFailed, EventRefundCreated, EventChargePaid:
+		return true
+	}
+	return false
+}
+
+// Event is the body sent to subscriptions. The id is the delivery id, so it
+// stays the same across retries.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      *Webhook  `json:"data,omitempty"`
+}
+
+// Subscription sends the events of an account to URL. After a rotation the
+// previous secret still signs every delivery until PreviousSecretExpiresAt,
+// so the subscriber can switch secrets without dropping webhooks.
+type Subscription struct {
+	ID                      string `dynamodbav:"PK"`
+	AccountID               int64
+	URL                     string
+	Events                  []EventType
+	Enabled                 bool
+	Secret                  string
+	PreviousSecret          string
+	PreviousSecretExpiresAt time.Time
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+func (s *Subscription) Subscribes(event EventType) bool {
+	for _, subscribed := range s.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Secrets returns the secrets deliveries are signed with at now, the current
+// one first.
+func (s *Subscription) Secrets(now time.Time) []string {
+	if s.PreviousSecret != "" && now.Before(s.PreviousSecretExpiresAt) {
+		return []string{s.Secret, s.PreviousSecret}
+	}
+	return []string{s.Secret}
+}
+
+type SubscriptionRequest struct {
+	ID        string
+	AccountID int64
+	Enabled   bool
+}
+
+func ProtoToSubscription(subscription *proto.WebhookSubscription) *Subscription {
+	events := make([]EventType, len(subscription.Events))
+	for i, event := range subscription.Events {
+		events[i] = EventType(event)
+	}
+	return &Subscription{
+		AccountID: subscription.AccountId,
+		URL:       subscription.Url,
+		Events:    events,
+	}
+}
+
+func ProtoToSubscriptionRequest(request *proto.SubscriptionRequest) *SubscriptionRequest {
+	return &SubscriptionRequest{
+		ID:        request.Id,
+		AccountID: request.AccountId,
+		Enabled:   request.Enabled,
+	}
+}
+
+// SubscriptionToProto leaves the secret out unless withSecret is set, which
+// is only done right after it was generated.
+func SubscriptionToProto(subscription *Subscription, withSecret bool) *proto.WebhookSubscription {
+	events := make([]string, len(subscription.Events))
+	for i, event := range subscription.Events {
+		events[i] = string(event)
+	}
+
+	value := &proto.WebhookSubscription{
+		Id:        subscription.ID,
+		AccountId: subscription.AccountID,
+		Url:       subscription.URL,
+		Events:    events,
+		Enabled:   subscription.Enabled,
+		CreatedAt: timestamppb.New(subscription.CreatedAt),
+		UpdatedAt: timestamppb.New(subscription.UpdatedAt),
+	}
+	if withSecret {
+		value.Secret = subscription.Secret
+	}
+	if subscription.PreviousSecret != "" {
+		value.PreviousSecretExpiresAt = timestamppb.New(subscription.PreviousSecretExpiresAt)
+	}
+	return value
+}
+
+func SubscriptionsToProto(subscriptions []*Subscription) *proto.WebhookSubscriptions {
+	values := make([]*proto.WebhookSubscription, len(subscriptions))
+	for i, subscription := range subscriptions {
+		values[i] = SubscriptionToProto(subscription, false)
+	}
+	return &proto.WebhookSubscriptions{Subscriptions: values}
+}
+
+// Delivery is one webhook and every attempt made to deliver it. Retryable
+// deliveries are retried by the worker at NextAttemptAt, which is kept in
+// whole UTC seconds so it sorts as a string. A delivery made by Send is not
+// retryable, as the caller already acted on its outcome.
+type Delivery struct {
+	ID             string `dynamodbav:"PK"`
+	TransactionID  string
+	SubscriptionID string
+	Event          EventType
+	URL            string
+	Payload        string
+	Status         DeliveryStatus
+	Retryable      bool
+	MaxAttempts    int
+	Attempts       []Attempt
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Attempt keeps the start of the response body only, up to
+// responseExcerptSize bytes.
+type Attempt struct {
+	Number     int
+	StatusCode int
+	Latency    time.Duration
+	Response   string
+	Error      string
+	At         time.Time
+}
+
+type ListRequest struct {
+	TransactionID string
+	Status        DeliveryStatus
+	Limit         int
+}
+
+func ProtoToListRequest(request *proto.ListWebhookDeliveriesRequest) *ListRequest {
+	return &ListRequest{
+		TransactionID: request.TransactionId,
+		Status:        DeliveryStatus(request.Status),
+		Limit:         int(request.Limit),
+	}
+}
+
+func ToProto(delivery *Delivery) *proto.WebhookDelivery {
+	attempts := make([]*proto.WebhookAttempt, len(delivery.Attempts))
+	for i, attempt := range delivery.Attempts {
+		attempts[i] = &proto.WebhookAttempt{
+			Number:     int32(attempt.Number),
+			StatusCode: int32(attempt.StatusCode),
+			LatencyMs:  attempt.Latency.Milliseconds(),
+			Response:   attempt.Response,
+			Error:      attempt.Error,
+			At:         timestamppb.New(attempt.At),
+		}
+	}
+
+	value := &proto.WebhookDelivery{
+		Id:             delivery.ID,
+		TransactionId:  delivery.TransactionID,
+		Url:            delivery.URL,
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Retryable:      delivery.Retryable,
+		MaxAttempts:    int32(delivery.MaxAttempts),
+		Attempts:       attempts,
+		CreatedAt:      timestamppb.New(delivery.CreatedAt),
+		UpdatedAt:      timestamppb.New(delivery.UpdatedAt),
+		SubscriptionId: delivery.SubscriptionID,
+		Event:          string(delivery.Event),
+	}
+	if delivery.Status == DeliveryPending {
+		value.NextAttemptAt = timestamppb.New(delivery.NextAttemptAt)
+	}
+	return value
+}
+
+func ToProtoList(deliveries []*Delivery) *proto.WebhookDeliveries {
+	values := make([]*proto.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		values[i] = ToProto(delivery)
+	}
+	return &proto.WebhookDeliveries{Deliveries: values}
+}
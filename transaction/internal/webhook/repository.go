@@ -0,0 +1,313 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"time"
+	"transaction/internal/cfg"
+	"transaction/internal/errutils"
+	"transaction/platform/dynamo"
+)
+
+// The webhook table needs two global secondary indexes: StatusIndex
+// (Status, NextAttemptAt) for the retry worker and TransactionIndex
+// (TransactionID, CreatedAt) for listing the deliveries of a transaction.
+// The subscription table needs AccountIndex (AccountID, CreatedAt).
+const (
+	statusIndex      = "StatusIndex"
+	transactionIndex = "TransactionIndex"
+	accountIndex     = "AccountIndex"
+)
+
+type Repository interface {
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+	FindDelivery(ctx context.Context, id string) (*Delivery, error)
+	ListByTransaction(ctx context.Context, transactionID string) ([]*Delivery, error)
+	ListByStatus(ctx context.Context, status DeliveryStatus, limit int) ([]*Delivery, error)
+	ListDue(ctx context.Context, before time.Time, limit int) ([]*Delivery, error)
+	UpdateDelivery(ctx context.Context, delivery *Delivery, previous time.Time) error
+	CreateSubscription(ctx context.Context, subscription *Subscription) error
+	FindSubscription(ctx context.Context, id string) (*Subscription, error)
+	ListSubscriptions(ctx context.Context, accountID int64) ([]*Subscription, error)
+	UpdateSubscription(ctx context.Context, subscription *Subscription, previous time.Time) error
+	DeleteSubscription(ctx context.Context, id string) error
+}
+
+type repository struct {
+	db  dynamo.Client
+	cfg *cfg.Config
+}
+
+func (r *repository) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	value, err := attributevalue.MarshalMap(delivery)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB().PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.cfg.DynamodbConfig.WebhookTable),
+		Item:                value,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrDeliveryConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) FindDelivery(ctx context.Context, id string) (*Delivery, error) {
+	value, err := r.db.DB().GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.cfg.DynamodbConfig.WebhookTable),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value.Item == nil {
+		return nil, errutils.ErrDeliveryNotFound
+	}
+
+	var delivery Delivery
+	if err = attributevalue.UnmarshalMap(value.Item, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListByTransaction returns the deliveries of a transaction, newest first.
+func (r *repository) ListByTransaction(ctx context.Context, transactionID string) ([]*Delivery, error) {
+	keyCond := expression.Key("TransactionID").Equal(expression.Value(transactionID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.WebhookTable),
+		IndexName:                 aws.String(transactionIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ScanIndexForward:          aws.Bool(false),
+	})
+}
+
+// ListByStatus returns up to limit deliveries in status, the latest scheduled
+// first.
+func (r *repository) ListByStatus(ctx context.Context, status DeliveryStatus, limit int) ([]*Delivery, error) {
+	keyCond := expression.Key("Status").Equal(expression.Value(status))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.WebhookTable),
+		IndexName:                 aws.String(statusIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+}
+
+// ListDue returns the pending deliveries whose next attempt is not after
+// before, oldest first.
+func (r *repository) ListDue(ctx context.Context, before time.Time, limit int) ([]*Delivery, error) {
+	keyCond := expression.Key("Status").Equal(expression.Value(DeliveryPending)).
+		And(expression.Key("NextAttemptAt").LessThanEqual(expression.Value(before)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	return r.query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.WebhookTable),
+		IndexName:                 aws.String(statusIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+}
+
+func (r *repository) query(ctx context.Context, input *dynamodb.QueryInput) ([]*Delivery, error) {
+	value, err := r.db.DB().Query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*Delivery, 0, len(value.Items))
+	if err = attributevalue.UnmarshalListOfMaps(value.Items, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery writes the delivery only while it was not touched since
+// previous, so two workers can not record the same attempt.
+func (r *repository) UpdateDelivery(ctx context.Context, delivery *Delivery, previous time.Time) error {
+	upd := expression.Set(expression.Name("Status"), expression.Value(delivery.Status)).
+		Set(expression.Name("Attempts"), expression.Value(delivery.Attempts)).
+		Set(expression.Name("NextAttemptAt"), expression.Value(delivery.NextAttemptAt)).
+		Set(expression.Name("UpdatedAt"), expression.Value(delivery.UpdatedAt))
+
+	cond := expression.Name("UpdatedAt").Equal(expression.Value(previous))
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: delivery.ID},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.WebhookTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrDeliveryConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) CreateSubscription(ctx context.Context, subscription *Subscription) error {
+	value, err := attributevalue.MarshalMap(subscription)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB().PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.cfg.DynamodbConfig.SubscriptionTable),
+		Item:                value,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrSubscriptionConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) FindSubscription(ctx context.Context, id string) (*Subscription, error) {
+	value, err := r.db.DB().GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.cfg.DynamodbConfig.SubscriptionTable),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value.Item == nil {
+		return nil, errutils.ErrSubscriptionNotFound
+	}
+
+	var subscription Subscription
+	if err = attributevalue.UnmarshalMap(value.Item, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ListSubscriptions returns the subscriptions of an account, oldest first.
+func (r *repository) ListSubscriptions(ctx context.Context, accountID int64) ([]*Subscription, error) {
+	keyCond := expression.Key("AccountID").Equal(expression.Value(accountID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, errors.New("failed to build expression")
+	}
+
+	value, err := r.db.DB().Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.cfg.DynamodbConfig.SubscriptionTable),
+		IndexName:                 aws.String(accountIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*Subscription, 0, len(value.Items))
+	if err = attributevalue.UnmarshalListOfMaps(value.Items, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription writes the subscription only while it was not touched
+// since previous, so a rotation is not lost to a concurrent change.
+func (r *repository) UpdateSubscription(ctx context.Context, subscription *Subscription, previous time.Time) error {
+	upd := expression.Set(expression.Name("Enabled"), expression.Value(subscription.Enabled)).
+		Set(expression.Name("Secret"), expression.Value(subscription.Secret)).
+		Set(expression.Name("PreviousSecret"), expression.Value(subscription.PreviousSecret)).
+		Set(expression.Name("PreviousSecretExpiresAt"), expression.Value(subscription.PreviousSecretExpiresAt)).
+		Set(expression.Name("UpdatedAt"), expression.Value(subscription.UpdatedAt))
+
+	cond := expression.Name("UpdatedAt").Equal(expression.Value(previous))
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return errors.New("failed to build expression")
+	}
+
+	_, err = r.db.DB().UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: subscription.ID},
+		},
+		TableName:                 aws.String(r.cfg.DynamodbConfig.SubscriptionTable),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errutils.ErrSubscriptionConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteSubscription(ctx context.Context, id string) error {
+	_, err := r.db.DB().DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.cfg.DynamodbConfig.SubscriptionTable),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	return err
+}
+
+func NewRepository(db dynamo.Client, config *cfg.Config) Repository {
+	return &repository{
+		db:  db,
+		cfg: config,
+	}
+}
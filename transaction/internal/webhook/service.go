@@ -1,70 +1,639 @@
 package webhook
 
 import (
-	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
-	"transaction/internal/transactions"
+	"transaction/internal/errutils"
+)
+
+// Every request carries the delivery id, the unix time it was signed at and
+// the signature of both, so receivers can drop duplicates and replays. While a
+// rotated subscription secret is still valid the signature header lists one
+// signature per secret, separated by commas, the current secret first.
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	responseExcerptSize = 512
+	defaultListLimit    = 50
 )
 
 type Service interface {
 	Send(ctx context.Context, data Webhook, url string) error
+	Notify(ctx context.Context, data Webhook, url string) error
+	List(ctx context.Context, req *ListRequest) ([]*Delivery, error)
+	Redeliver(ctx context.Context, id string) (*Delivery, error)
+	RunDue(ctx context.Context) error
+	Publish(ctx context.Context, accountID int64, event EventType, data Webhook) error
+	Subscribe(ctx context.Context, subscription *Subscription) (*Subscription, error)
+	ListSubscriptions(ctx context.Context, accountID int64) ([]*Subscription, error)
+	SetSubscriptionEnabled(ctx context.Context, req *SubscriptionRequest) (*Subscription, error)
+	RotateSecret(ctx context.Context, req *SubscriptionRequest) (*Subscription, error)
+	Ping(ctx context.Context, req *SubscriptionRequest) (*Delivery, error)
+	Unsubscribe(ctx context.Context, req *SubscriptionRequest) error
 }
 
 type TransactionRepository interface {
-	FindTransactionById(id string) (*transactions.Transaction, error)
-	UpdateTransactionStatus(transaction *transactions.Transaction) error
+	MarkProcessed(id string, at time.Time) error
+}
+
+type Options func(*service)
+
+// WithSecret sets the key every webhook is signed with.
+func WithSecret(secret string) Options {
+	return func(s *service) {
+		s.secret = secret
+	}
+}
+
+func WithTimeout(timeout time.Duration) Options {
+	return func(s *service) {
+		s.client.Timeout = timeout
+	}
+}
+
+// WithSendRetry sets how many times Send tries, backoff apart, before it
+// reports the failure to its caller.
+func WithSendRetry(attempts int, backoff time.Duration) Options {
+	return func(s *service) {
+		s.sendAttempts = attempts
+		s.sendBackoff = backoff
+	}
+}
+
+// WithRetry sets how many times a retryable delivery is tried before it is
+// given up, and the bounds of the exponential backoff between tries.
+func WithRetry(maxAttempts int, initial, max time.Duration) Options {
+	return func(s *service) {
+		s.maxAttempts = maxAttempts
+		s.initialBackoff = initial
+		s.maxBackoff = max
+	}
+}
+
+func WithBatchSize(size int) Options {
+	return func(s *service) {
+		s.batchSize = size
+	}
+}
+
+// WithLease sets how long a worker holds a due delivery while it tries it. It
+// must outlast the request timeout, or another worker may send it again.
+func WithLease(lease time.Duration) Options {
+	return func(s *service) {
+		s.lease = lease
+	}
+}
+
+func WithMaxSubscriptions(max int) Options {
+	return func(s *service) {
+		s.maxSubscriptions = max
+	}
+}
+
+// WithRotationGrace sets how long a rotated secret keeps signing deliveries
+// next to the new one.
+func WithRotationGrace(grace time.Duration) Options {
+	return func(s *service) {
+		s.rotationGrace = grace
+	}
 }
 
 type service struct {
-	client      http.Client
-	transaction TransactionRepository
+	client           *http.Client
+	repo             Repository
+	transaction      TransactionRepository
+	secret           string
+	sendAttempts     int
+	sendBackoff      time.Duration
+	maxAttempts      int
+	initialBackoff   time.Duration
+	maxBackoff       time.Duration
+	batchSize        int
+	lease            time.Duration
+	maxSubscriptions int
+	rotationGrace    time.Duration
+	now              func() time.Time
 }
 
+// Send delivers the webhook right away and reports whether the receiver took
+// it. The delivery is logged but not retried later, since the caller decides
+// the transaction on the outcome.
 func (s *service) Send(ctx context.Context, data Webhook, url string) error {
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(data)
+	delivery := s.newDelivery(data.TransactionId, url, false, s.sendAttempts)
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	delivery.Payload = string(payload)
+
+	for {
+		err = s.attempt(ctx, delivery, []string{s.secret})
+		if err == nil || len(delivery.Attempts) >= s.sendAttempts {
+			break
+		}
+		if waitErr := wait(ctx, s.sendBackoff<<(len(delivery.Attempts)-1)); waitErr != nil {
+			break
+		}
+	}
+
+	delivery.Status = DeliveryDelivered
+	if err != nil {
+		delivery.Status = DeliveryFailed
+	}
+	delivery.UpdatedAt = s.now()
+	if createErr := s.repo.CreateDelivery(ctx, delivery); createErr != nil {
+		log.Printf("failed to log webhook delivery %s: %v", delivery.ID, createErr)
+	}
 	if err != nil {
 		return err
 	}
 
-	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if data.Status == StatusCompleted {
+		if err = s.transaction.MarkProcessed(data.TransactionId, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notify tries the webhook once and leaves it to the worker when the
+// receiver does not take it.
+func (s *service) Notify(ctx context.Context, data Webhook, url string) error {
+	delivery := s.newDelivery(data.TransactionId, url, true, s.maxAttempts)
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	delivery.Payload = string(payload)
+
+	s.finish(delivery, s.attempt(ctx, delivery, []string{s.secret}))
+	return s.repo.CreateDelivery(ctx, delivery)
+}
+
+// Publish queues the event for every enabled subscription of the account that
+// listens to it. The worker sends them, so a slow subscriber never holds the
+// pix that raised the event.
+func (s *service) Publish(ctx context.Context, accountID int64, event EventType, data Webhook) error {
+	subscriptions, err := s.repo.ListSubscriptions(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		if !subscription.Enabled || !subscription.Subscribes(event) {
+			continue
+		}
+
+		delivery, err := s.eventDelivery(subscription, event, &data, true, s.maxAttempts)
+		if err == nil {
+			err = s.repo.CreateDelivery(ctx, delivery)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", subscription.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *service) Subscribe(ctx context.Context, subscription *Subscription) (*Subscription, error) {
+	if !validSubscription(subscription) {
+		return nil, errutils.ErrInvalidSubscription
+	}
+
+	existing, err := s.repo.ListSubscriptions(ctx, subscription.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= s.maxSubscriptions {
+		return nil, errutils.ErrSubscriptionLimit
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	subscription.ID = uuid.New().String()
+	subscription.Events = uniqueEvents(subscription.Events)
+	subscription.Enabled = true
+	subscription.Secret = secret
+	subscription.CreatedAt = now.UTC().Truncate(time.Second)
+	subscription.UpdatedAt = now
+	if err = s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *service) ListSubscriptions(ctx context.Context, accountID int64) ([]*Subscription, error) {
+	return s.repo.ListSubscriptions(ctx, accountID)
+}
+
+func (s *service) SetSubscriptionEnabled(ctx context.Context, req *SubscriptionRequest) (*Subscription, error) {
+	subscription, err := s.findSubscription(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Enabled == req.Enabled {
+		return subscription, nil
+	}
+
+	previous := subscription.UpdatedAt
+	subscription.Enabled = req.Enabled
+	subscription.UpdatedAt = s.now()
+	if err = s.repo.UpdateSubscription(ctx, subscription, previous); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// RotateSecret replaces the secret of the subscription. The old secret keeps
+// signing deliveries, next to the new one, for the rotation grace.
+func (s *service) RotateSecret(ctx context.Context, req *SubscriptionRequest) (*Subscription, error) {
+	subscription, err := s.findSubscription(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := subscription.UpdatedAt
+	subscription.UpdatedAt = s.now()
+	subscription.PreviousSecret = subscription.Secret
+	subscription.PreviousSecretExpiresAt = subscription.UpdatedAt.Add(s.rotationGrace).UTC()
+	subscription.Secret = secret
+	if err = s.repo.UpdateSubscription(ctx, subscription, previous); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Ping sends a ping event to the subscription once, even when it is disabled,
+// and returns how it went. It is logged but never retried.
+func (s *service) Ping(ctx context.Context, req *SubscriptionRequest) (*Delivery, error) {
+	subscription, err := s.findSubscription(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.eventDelivery(subscription, EventPing, nil, false, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	s.finish(delivery, s.attempt(ctx, delivery, subscription.Secrets(s.now())))
+	if err = s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *service) Unsubscribe(ctx context.Context, req *SubscriptionRequest) error {
+	subscription, err := s.findSubscription(ctx, req)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, subscription.ID)
+}
+
+// findSubscription hides the subscriptions of other accounts behind not found.
+func (s *service) findSubscription(ctx context.Context, req *SubscriptionRequest) (*Subscription, error) {
+	subscription, err := s.repo.FindSubscription(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.AccountID != req.AccountID {
+		return nil, errutils.ErrSubscriptionNotFound
+	}
+	return subscription, nil
+}
+
+func (s *service) List(ctx context.Context, req *ListRequest) ([]*Delivery, error) {
+	switch req.Status {
+	case "", DeliveryPending, DeliveryDelivered, DeliveryFailed:
+	default:
+		return nil, errutils.ErrInvalidDeliveryFilter
+	}
+
+	if req.TransactionID == "" {
+		if req.Status == "" {
+			return nil, errutils.ErrInvalidDeliveryFilter
+		}
+		limit := req.Limit
+		if limit <= 0 {
+			limit = defaultListLimit
+		}
+		return s.repo.ListByStatus(ctx, req.Status, limit)
+	}
+
+	deliveries, err := s.repo.ListByTransaction(ctx, req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status == "" {
+		return deliveries, nil
+	}
+
+	matching := deliveries[:0]
+	for _, delivery := range deliveries {
+		if delivery.Status == req.Status {
+			matching = append(matching, delivery)
+		}
+	}
+	return matching, nil
+}
+
+// Redeliver tries a retryable delivery once more, whatever its status. A
+// delivery that fails again is left to the worker while it has attempts left.
+func (s *service) Redeliver(ctx context.Context, id string) (*Delivery, error) {
+	delivery, err := s.repo.FindDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !delivery.Retryable {
+		return nil, errutils.ErrDeliveryNotRetryable
+	}
+
+	if err = s.deliver(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// RunDue retries the pending deliveries whose backoff is over.
+func (s *service) RunDue(ctx context.Context) error {
+	due, err := s.repo.ListDue(ctx, s.now(), s.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err = s.deliver(ctx, delivery)
+		if errors.Is(err, errutils.ErrDeliveryConflict) {
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to retry webhook delivery %s: %v", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+// deliver leases the delivery before trying it, so a worker or a manual
+// redelivery running at the same time skips it instead of sending it twice.
+func (s *service) deliver(ctx context.Context, delivery *Delivery) error {
+	previous := delivery.UpdatedAt
+	delivery.UpdatedAt = s.now()
+	delivery.NextAttemptAt = delivery.UpdatedAt.Add(s.lease).UTC().Truncate(time.Second)
+	if err := s.repo.UpdateDelivery(ctx, delivery, previous); err != nil {
+		return err
+	}
+
+	secrets, err := s.secrets(ctx, delivery)
+	switch {
+	case errors.Is(err, errutils.ErrSubscriptionNotFound), errors.Is(err, errutils.ErrSubscriptionDisabled):
+		// nobody wants the delivery anymore
+		delivery.Attempts = append(delivery.Attempts, Attempt{Number: len(delivery.Attempts) + 1, Error: err.Error(), At: s.now()})
+		delivery.Status = DeliveryFailed
+	case err != nil:
+		return err
+	default:
+		s.finish(delivery, s.attempt(ctx, delivery, secrets))
+	}
+	previous = delivery.UpdatedAt
+	delivery.UpdatedAt = s.now()
+	return s.repo.UpdateDelivery(ctx, delivery, previous)
+}
+
+// finish sets the status after an attempt and, while the delivery has
+// attempts left, when the worker tries it next.
+func (s *service) finish(delivery *Delivery, err error) {
+	switch {
+	case err == nil:
+		delivery.Status = DeliveryDelivered
+	case len(delivery.Attempts) >= delivery.MaxAttempts:
+		delivery.Status = DeliveryFailed
+	default:
+		delivery.Status = DeliveryPending
+		delivery.NextAttemptAt = s.now().Add(s.backoff(len(delivery.Attempts))).UTC().Truncate(time.Second)
+	}
+}
+
+func (s *service) backoff(attempt int) time.Duration {
+	backoff := s.initialBackoff
+	for i := 1; i < attempt && backoff < s.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > s.maxBackoff {
+		return s.maxBackoff
+	}
+	return backoff
+}
+
+// secrets returns what the delivery is signed with: the secret of its
+// subscription, or the service secret for the webhooks that settle a pix.
+func (s *service) secrets(ctx context.Context, delivery *Delivery) ([]string, error) {
+	if delivery.SubscriptionID == "" {
+		return []string{s.secret}, nil
+	}
+
+	subscription, err := s.repo.FindSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.Enabled {
+		return nil, errutils.ErrSubscriptionDisabled
+	}
+	return subscription.Secrets(s.now()), nil
+}
+
+// attempt posts the delivery once and records the outcome on it.
+func (s *service) attempt(ctx context.Context, delivery *Delivery, secrets []string) error {
+	record := Attempt{Number: len(delivery.Attempts) + 1, At: s.now()}
+	err := s.post(ctx, delivery, secrets, &record)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	delivery.Attempts = append(delivery.Attempts, record)
+	return err
+}
+
+func (s *service) post(ctx context.Context, delivery *Delivery, secrets []string, record *Attempt) error {
+	timestamp := strconv.FormatInt(record.At.Unix(), 10)
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, strings.NewReader(delivery.Payload))
 	if err != nil {
 		return err
 	}
 
 	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set(HeaderDelivery, delivery.ID)
+	hreq.Header.Set(HeaderTimestamp, timestamp)
+	signatures := make([]string, len(secrets))
+	for i, secret := range secrets {
+		signatures[i] = Sign(secret, timestamp, []byte(delivery.Payload))
+	}
+	hreq.Header.Set(HeaderSignature, strings.Join(signatures, ","))
 
+	start := time.Now()
 	result, err := s.client.Do(hreq)
 	if err != nil {
+		record.Latency = time.Since(start)
 		return err
 	}
 	defer result.Body.Close()
 
+	excerpt, _ := io.ReadAll(io.LimitReader(result.Body, responseExcerptSize))
+	record.Latency = time.Since(start)
+	record.StatusCode = result.StatusCode
+	record.Response = string(excerpt)
+
 	if result.StatusCode < 200 || result.StatusCode >= 300 {
-		return errors.New("error when send webhook")
+		return fmt.Errorf("webhook rejected with status %d", result.StatusCode)
 	}
+	return nil
+}
 
-	if data.Status == StatusCompleted {
-		transaction, err := s.transaction.FindTransactionById(data.TransactionId)
-		if err != nil {
-			return err
+func (s *service) newDelivery(transactionID, url string, retryable bool, maxAttempts int) *Delivery {
+	now := s.now()
+	return &Delivery{
+		ID:            uuid.New().String(),
+		TransactionID: transactionID,
+		URL:           url,
+		Status:        DeliveryPending,
+		Retryable:     retryable,
+		MaxAttempts:   maxAttempts,
+		NextAttemptAt: now.UTC().Truncate(time.Second),
+		CreatedAt:     now.UTC().Truncate(time.Second),
+		UpdatedAt:     now,
+	}
+}
+
+func (s *service) eventDelivery(subscription *Subscription, event EventType, data *Webhook, retryable bool, maxAttempts int) (*Delivery, error) {
+	var transactionID string
+	if data != nil {
+		transactionID = data.TransactionId
+	}
+
+	delivery := s.newDelivery(transactionID, subscription.URL, retryable, maxAttempts)
+	delivery.SubscriptionID = subscription.ID
+	delivery.Event = event
+	payload, err := json.Marshal(Event{
+		ID:        delivery.ID,
+		Type:      event,
+		AccountID: subscription.AccountID,
+		CreatedAt: delivery.CreatedAt,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(payload)
+	return delivery, nil
+}
+
+func validSubscription(subscription *Subscription) bool {
+	if subscription.AccountID <= 0 || len(subscription.Events) == 0 {
+		return false
+	}
+	for _, event := range subscription.Events {
+		if !event.Valid() {
+			return false
 		}
-		transaction.ProcessedAt = time.Now()
-		err = s.transaction.UpdateTransactionStatus(transaction)
-		if err != nil {
-			return err
+	}
+
+	target, err := url.Parse(subscription.URL)
+	if err != nil || target.Host == "" {
+		return false
+	}
+	return target.Scheme == "http" || target.Scheme == "https"
+}
+
+func uniqueEvents(events []EventType) []EventType {
+	seen := make(map[EventType]bool, len(events))
+	unique := make([]EventType, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
 		}
 	}
-	return nil
+	return unique
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
 }
 
-func NewService(transactionRepo TransactionRepository) Service {
-	return &service{
-		client:      http.Client{},
-		transaction: transactionRepo,
+// Sign returns the signature of a body sent at timestamp. The timestamp is
+// signed with the body so a captured request can not be replayed later.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func NewService(repo Repository, transactionRepo TransactionRepository, opts ...Options) Service {
+	s := &service{
+		client:           &http.Client{Timeout: 10 * time.Second},
+		repo:             repo,
+		transaction:      transactionRepo,
+		sendAttempts:     3,
+		sendBackoff:      200 * time.Millisecond,
+		maxAttempts:      8,
+		initialBackoff:   30 * time.Second,
+		maxBackoff:       time.Hour,
+		batchSize:        100,
+		lease:            time.Minute,
+		maxSubscriptions: 10,
+		rotationGrace:    24 * time.Hour,
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
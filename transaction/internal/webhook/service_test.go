@@ -0,0 +1,383 @@
+package webhook
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+	"transaction/internal/errutils"
+)
+
+type mockRepo struct {
+	Repository
+	mock.Mock
+	// created and updated keep a copy of every delivery written
+	created []Delivery
+	updated []Delivery
+}
+
+func (m *mockRepo) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	m.created = append(m.created, *delivery)
+	return nil
+}
+
+func (m *mockRepo) FindDelivery(ctx context.Context, id string) (*Delivery, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Delivery), args.Error(1)
+}
+
+func (m *mockRepo) ListDue(ctx context.Context, before time.Time, limit int) ([]*Delivery, error) {
+	args := m.Called()
+	return args.Get(0).([]*Delivery), args.Error(1)
+}
+
+func (m *mockRepo) UpdateDelivery(ctx context.Context, delivery *Delivery, previous time.Time) error {
+	m.updated = append(m.updated, *delivery)
+	args := m.Called(delivery.ID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) CreateSubscription(ctx context.Context, subscription *Subscription) error {
+	args := m.Called(subscription.AccountID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) FindSubscription(ctx context.Context, id string) (*Subscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+
+func (m *mockRepo) ListSubscriptions(ctx context.Context, accountID int64) ([]*Subscription, error) {
+	args := m.Called(accountID)
+	return args.Get(0).([]*Subscription), args.Error(1)
+}
+
+func (m *mockRepo) UpdateSubscription(ctx context.Context, subscription *Subscription, previous time.Time) error {
+	args := m.Called(subscription.ID)
+	return args.Error(0)
+}
+
+type mockTransactionRepo struct {
+	mock.Mock
+}
+
+func (m *mockTransactionRepo) MarkProcessed(id string, at time.Time) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+var now = time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+// receiver answers with the given status codes in turn, repeating the last.
+func receiver(t *testing.T, codes ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1))
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(HeaderTimestamp)
+		assert.Equal(t, Sign("secret", timestamp, body), r.Header.Get(HeaderSignature))
+		assert.NotEmpty(t, r.Header.Get(HeaderDelivery))
+
+		code := codes[len(codes)-1]
+		if call <= len(codes) {
+			code = codes[call-1]
+		}
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte("answer"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestService(repo *mockRepo, transactionRepo *mockTransactionRepo) *service {
+	s := NewService(repo, transactionRepo,
+		WithSecret("secret"),
+		WithSendRetry(3, time.Millisecond),
+		WithRetry(3, time.Minute, 10*time.Minute)).(*service)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func completed() Webhook {
+	return Webhook{
+		TransactionId: "pix-1",
+		Sender:        Account{Name: 1},
+		Receiver:      Account{Name: 2},
+		Amount:        decimal.NewFromInt(10),
+		Status:        StatusCompleted,
+	}
+}
+
+func TestSend(t *testing.T) {
+	cases := []struct {
+		name     string
+		codes    []int
+		status   DeliveryStatus
+		attempts int
+		err      bool
+	}{
+		{name: "delivered at once", codes: []int{http.StatusOK}, status: DeliveryDelivered, attempts: 1},
+		{name: "delivered after a retry", codes: []int{http.StatusBadGateway, http.StatusAccepted}, status: DeliveryDelivered, attempts: 2},
+		{name: "failed after all attempts", codes: []int{http.StatusInternalServerError}, status: DeliveryFailed, attempts: 3, err: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, calls := receiver(t, c.codes...)
+			repo := new(mockRepo)
+			transactionRepo := new(mockTransactionRepo)
+			if !c.err {
+				transactionRepo.On("MarkProcessed", "pix-1").Return(nil)
+			}
+
+			err := newTestService(repo, transactionRepo).Send(context.Background(), completed(), server.URL)
+			assert.Equal(t, c.err, err != nil)
+			assert.Equal(t, int32(c.attempts), *calls)
+
+			assert.Len(t, repo.created, 1)
+			delivery := repo.created[0]
+			assert.Equal(t, c.status, delivery.Status)
+			assert.False(t, delivery.Retryable)
+			assert.Len(t, delivery.Attempts, c.attempts)
+			last := delivery.Attempts[c.attempts-1]
+			assert.Equal(t, c.codes[len(c.codes)-1], last.StatusCode)
+			assert.Equal(t, "answer", last.Response)
+			transactionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	server, _ := receiver(t, http.StatusServiceUnavailable)
+	repo := new(mockRepo)
+
+	data := completed()
+	data.Status = StatusFailed
+	err := newTestService(repo, nil).Notify(context.Background(), data, server.URL)
+	assert.Nil(t, err)
+
+	assert.Len(t, repo.created, 1)
+	delivery := repo.created[0]
+	assert.True(t, delivery.Retryable)
+	assert.Equal(t, DeliveryPending, delivery.Status)
+	assert.Equal(t, now.Add(time.Minute), delivery.NextAttemptAt)
+	assert.Equal(t, "webhook rejected with status 503", delivery.Attempts[0].Error)
+}
+
+func TestRunDue(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     int
+		attempts int
+		status   DeliveryStatus
+		next     time.Time
+	}{
+		{name: "delivered", code: http.StatusOK, attempts: 1, status: DeliveryDelivered},
+		{name: "backs off exponentially", code: http.StatusInternalServerError, attempts: 1, status: DeliveryPending, next: now.Add(2 * time.Minute)},
+		{name: "gives up after max attempts", code: http.StatusInternalServerError, attempts: 2, status: DeliveryFailed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, calls := receiver(t, c.code)
+			delivery := &Delivery{
+				ID:          "delivery-1",
+				URL:         server.URL,
+				Payload:     `{"transaction_id":"pix-1"}`,
+				Status:      DeliveryPending,
+				Retryable:   true,
+				MaxAttempts: 3,
+				Attempts:    make([]Attempt, c.attempts),
+				UpdatedAt:   now.Add(-time.Hour),
+			}
+			repo := new(mockRepo)
+			repo.On("ListDue").Return([]*Delivery{delivery}, nil)
+			repo.On("UpdateDelivery", "delivery-1").Return(nil)
+
+			err := newTestService(repo, nil).RunDue(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, int32(1), *calls)
+
+			assert.Len(t, repo.updated, 2)
+			assert.Equal(t, DeliveryPending, repo.updated[0].Status)
+			assert.Equal(t, now.Add(time.Minute), repo.updated[0].NextAttemptAt)
+			assert.Equal(t, c.status, repo.updated[1].Status)
+			assert.Len(t, repo.updated[1].Attempts, c.attempts+1)
+			if !c.next.IsZero() {
+				assert.Equal(t, c.next, repo.updated[1].NextAttemptAt)
+			}
+		})
+	}
+}
+
+func TestRunDueSkipsLeased(t *testing.T) {
+	server, calls := receiver(t, http.StatusOK)
+	repo := new(mockRepo)
+	repo.On("ListDue").Return([]*Delivery{{ID: "delivery-1", URL: server.URL, Retryable: true}}, nil)
+	repo.On("UpdateDelivery", "delivery-1").Return(errutils.ErrDeliveryConflict)
+
+	err := newTestService(repo, nil).RunDue(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), *calls)
+}
+
+func TestRedeliver(t *testing.T) {
+	server, _ := receiver(t, http.StatusOK)
+	cases := []struct {
+		name     string
+		delivery *Delivery
+		status   DeliveryStatus
+		err      error
+	}{
+		{
+			name:     "redelivers a failed delivery",
+			delivery: &Delivery{ID: "delivery-1", URL: server.URL, Status: DeliveryFailed, Retryable: true, MaxAttempts: 1, Attempts: []Attempt{{Number: 1}}},
+			status:   DeliveryDelivered,
+		},
+		{
+			name:     "refuses a delivery made by send",
+			delivery: &Delivery{ID: "delivery-1", URL: server.URL, Status: DeliveryFailed},
+			err:      errutils.ErrDeliveryNotRetryable,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("FindDelivery", "delivery-1").Return(c.delivery, nil)
+			repo.On("UpdateDelivery", "delivery-1").Return(nil)
+
+			got, err := newTestService(repo, nil).Redeliver(context.Background(), "delivery-1")
+			assert.Equal(t, c.err, err)
+			if c.err == nil {
+				assert.Equal(t, c.status, got.Status)
+				assert.Equal(t, 2, got.Attempts[1].Number)
+			}
+		})
+	}
+}
+
+func TestRunDueDisabledSubscription(t *testing.T) {
+	server, calls := receiver(t, http.StatusOK)
+	repo := new(mockRepo)
+	repo.On("ListDue").Return([]*Delivery{{ID: "delivery-1", SubscriptionID: "sub-1", URL: server.URL, Retryable: true, MaxAttempts: 3}}, nil)
+	repo.On("UpdateDelivery", "delivery-1").Return(nil)
+	repo.On("FindSubscription", "sub-1").Return(&Subscription{ID: "sub-1", Secret: "secret"}, nil)
+
+	err := newTestService(repo, nil).RunDue(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), *calls)
+	assert.Equal(t, DeliveryFailed, repo.updated[1].Status)
+	assert.Equal(t, errutils.ErrSubscriptionDisabled.Error(), repo.updated[1].Attempts[0].Error)
+}
+
+func TestPublish(t *testing.T) {
+	repo := new(mockRepo)
+	repo.On("ListSubscriptions", int64(2)).Return([]*Subscription{
+		{ID: "sub-1", AccountID: 2, URL: "http://one", Enabled: true, Events: []EventType{EventPixReceived, EventChargePaid}},
+		{ID: "sub-2", AccountID: 2, URL: "http://two", Enabled: true, Events: []EventType{EventPixSent}},
+		{ID: "sub-3", AccountID: 2, URL: "http://three", Enabled: false, Events: []EventType{EventPixReceived}},
+	}, nil)
+
+	err := newTestService(repo, nil).Publish(context.Background(), 2, EventPixReceived, completed())
+	assert.Nil(t, err)
+
+	assert.Len(t, repo.created, 1)
+	delivery := repo.created[0]
+	assert.Equal(t, "sub-1", delivery.SubscriptionID)
+	assert.Equal(t, "http://one", delivery.URL)
+	assert.Equal(t, "pix-1", delivery.TransactionID)
+	assert.True(t, delivery.Retryable)
+	assert.Equal(t, DeliveryPending, delivery.Status)
+	assert.Equal(t, now, delivery.NextAttemptAt)
+	assert.Contains(t, delivery.Payload, `"id":"`+delivery.ID+`","type":"pix.received","account_id":2`)
+}
+
+func TestSubscribe(t *testing.T) {
+	cases := []struct {
+		name         string
+		subscription *Subscription
+		existing     int
+		err          error
+	}{
+		{
+			name:         "success",
+			subscription: &Subscription{AccountID: 2, URL: "https://example.com/hooks", Events: []EventType{EventPixSent, EventPixSent}},
+		},
+		{
+			name:         "unknown event",
+			subscription: &Subscription{AccountID: 2, URL: "https://example.com/hooks", Events: []EventType{EventPing}},
+			err:          errutils.ErrInvalidSubscription,
+		},
+		{
+			name:         "relative url",
+			subscription: &Subscription{AccountID: 2, URL: "/hooks", Events: []EventType{EventPixSent}},
+			err:          errutils.ErrInvalidSubscription,
+		},
+		{
+			name:         "limit reached",
+			subscription: &Subscription{AccountID: 2, URL: "https://example.com/hooks", Events: []EventType{EventPixSent}},
+			existing:     10,
+			err:          errutils.ErrSubscriptionLimit,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			repo.On("ListSubscriptions", int64(2)).Return(make([]*Subscription, c.existing), nil)
+			repo.On("CreateSubscription", int64(2)).Return(nil)
+
+			got, err := newTestService(repo, nil).Subscribe(context.Background(), c.subscription)
+			assert.Equal(t, c.err, err)
+			if c.err == nil {
+				assert.True(t, got.Enabled)
+				assert.NotEmpty(t, got.ID)
+				assert.Regexp(t, "^whsec_[0-9a-f]{64}$", got.Secret)
+				assert.Equal(t, []EventType{EventPixSent}, got.Events)
+			}
+		})
+	}
+}
+
+func TestRotateSecretSignsWithBoth(t *testing.T) {
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(HeaderSignature)
+	}))
+	t.Cleanup(server.Close)
+
+	subscription := &Subscription{ID: "sub-1", AccountID: 2, URL: server.URL, Secret: "old", Enabled: true}
+	repo := new(mockRepo)
+	repo.On("FindSubscription", "sub-1").Return(subscription, nil)
+	repo.On("UpdateSubscription", "sub-1").Return(nil)
+	s := newTestService(repo, nil)
+
+	_, err := s.RotateSecret(context.Background(), &SubscriptionRequest{ID: "sub-1", AccountID: 3})
+	assert.Equal(t, errutils.ErrSubscriptionNotFound, err)
+
+	rotated, err := s.RotateSecret(context.Background(), &SubscriptionRequest{ID: "sub-1", AccountID: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "old", rotated.PreviousSecret)
+	assert.Equal(t, now.Add(24*time.Hour), rotated.PreviousSecretExpiresAt)
+
+	delivery, err := s.Ping(context.Background(), &SubscriptionRequest{ID: "sub-1", AccountID: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, DeliveryDelivered, delivery.Status)
+	assert.Equal(t, EventPing, delivery.Event)
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	assert.Equal(t, Sign(rotated.Secret, timestamp, []byte(delivery.Payload))+","+Sign("old", timestamp, []byte(delivery.Payload)), signature)
+
+	// once the grace is over only the new secret signs
+	assert.Equal(t, []string{rotated.Secret}, rotated.Secrets(now.Add(25*time.Hour)))
+}
@@ -20,9 +20,12 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	TransactionService_CreateTransaction_FullMethodName   = "/transaction.proto.v1.TransactionService/CreateTransaction"
-	TransactionService_FindTransactionById_FullMethodName = "/transaction.proto.v1.TransactionService/FindTransactionById"
-	TransactionService_ListTransactions_FullMethodName    = "/transaction.proto.v1.TransactionService/ListTransactions"
+	TransactionService_CreateTransaction_FullMethodName       = "/transaction.proto.v1.TransactionService/CreateTransaction"
+	TransactionService_FindTransactionById_FullMethodName     = "/transaction.proto.v1.TransactionService/FindTransactionById"
+	TransactionService_ListTransactions_FullMethodName        = "/transaction.proto.v1.TransactionService/ListTransactions"
+	TransactionService_RefundTransaction_FullMethodName       = "/transaction.proto.v1.TransactionService/RefundTransaction"
+	TransactionService_ListAccountTransactions_FullMethodName = "/transaction.proto.v1.TransactionService/ListAccountTransactions"
+	TransactionService_ListStatusTransitions_FullMethodName   = "/transaction.proto.v1.TransactionService/ListStatusTransitions"
 )
 
 // TransactionServiceClient is the client API for TransactionService service.
@@ -32,6 +35,9 @@ type TransactionServiceClient interface {
 	CreateTransaction(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Transaction, error)
 	FindTransactionById(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
 	ListTransactions(ctx context.Context, in *ListTransactionRequest, opts ...grpc.CallOption) (*ListTransaction, error)
+	RefundTransaction(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*Transaction, error)
+	ListAccountTransactions(ctx context.Context, in *AccountTransactionsRequest, opts ...grpc.CallOption) (*TransactionPage, error)
+	ListStatusTransitions(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*StatusTransitions, error)
 }
 
 type transactionServiceClient struct {
@@ -69,6 +75,33 @@ func (c *transactionServiceClient) ListTransactions(ctx context.Context, in *Lis
 	return out, nil
 }
 
+func (c *transactionServiceClient) RefundTransaction(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, TransactionService_RefundTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) ListAccountTransactions(ctx context.Context, in *AccountTransactionsRequest, opts ...grpc.CallOption) (*TransactionPage, error) {
+	out := new(TransactionPage)
+	err := c.cc.Invoke(ctx, TransactionService_ListAccountTransactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) ListStatusTransitions(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*StatusTransitions, error) {
+	out := new(StatusTransitions)
+	err := c.cc.Invoke(ctx, TransactionService_ListStatusTransitions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TransactionServiceServer is the server API for TransactionService service.
 // All implementations must embed UnimplementedTransactionServiceServer
 // for forward compatibility
@@ -76,6 +109,9 @@ type TransactionServiceServer interface {
 	CreateTransaction(context.Context, *Transaction) (*Transaction, error)
 	FindTransactionById(context.Context, *TransactionRequest) (*Transaction, error)
 	ListTransactions(context.Context, *ListTransactionRequest) (*ListTransaction, error)
+	RefundTransaction(context.Context, *RefundRequest) (*Transaction, error)
+	ListAccountTransactions(context.Context, *AccountTransactionsRequest) (*TransactionPage, error)
+	ListStatusTransitions(context.Context, *TransactionRequest) (*StatusTransitions, error)
 	mustEmbedUnimplementedTransactionServiceServer()
 }
 
@@ -92,6 +128,15 @@ func (UnimplementedTransactionServiceServer) FindTransactionById(context.Context
 func (UnimplementedTransactionServiceServer) ListTransactions(context.Context, *ListTransactionRequest) (*ListTransaction, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
 }
+func (UnimplementedTransactionServiceServer) RefundTransaction(context.Context, *RefundRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundTransaction not implemented")
+}
+func (UnimplementedTransactionServiceServer) ListAccountTransactions(context.Context, *AccountTransactionsRequest) (*TransactionPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccountTransactions not implemented")
+}
+func (UnimplementedTransactionServiceServer) ListStatusTransitions(context.Context, *TransactionRequest) (*StatusTransitions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStatusTransitions not implemented")
+}
 func (UnimplementedTransactionServiceServer) mustEmbedUnimplementedTransactionServiceServer() {}
 
 // UnsafeTransactionServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -159,6 +204,60 @@ func _TransactionService_ListTransactions_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TransactionService_RefundTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).RefundTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_RefundTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).RefundTransaction(ctx, req.(*RefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_ListAccountTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).ListAccountTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_ListAccountTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).ListAccountTransactions(ctx, req.(*AccountTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_ListStatusTransitions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).ListStatusTransitions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_ListStatusTransitions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).ListStatusTransitions(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TransactionService_ServiceDesc is the grpc.ServiceDesc for TransactionService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -178,17 +277,30 @@ var TransactionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListTransactions",
 			Handler:    _TransactionService_ListTransactions_Handler,
 		},
+		{
+			MethodName: "RefundTransaction",
+			Handler:    _TransactionService_RefundTransaction_Handler,
+		},
+		{
+			MethodName: "ListAccountTransactions",
+			Handler:    _TransactionService_ListAccountTransactions_Handler,
+		},
+		{
+			MethodName: "ListStatusTransitions",
+			Handler:    _TransactionService_ListStatusTransitions_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "transaction.proto",
 }
 
 const (
-	KeysService_CreateKey_FullMethodName = "/transaction.proto.v1.KeysService/CreateKey"
-	KeysService_UpdateKey_FullMethodName = "/transaction.proto.v1.KeysService/UpdateKey"
-	KeysService_ListKey_FullMethodName   = "/transaction.proto.v1.KeysService/ListKey"
-	KeysService_DeleteKey_FullMethodName = "/transaction.proto.v1.KeysService/DeleteKey"
-	KeysService_FindKey_FullMethodName   = "/transaction.proto.v1.KeysService/FindKey"
+	KeysService_CreateKey_FullMethodName       = "/transaction.proto.v1.KeysService/CreateKey"
+	KeysService_UpdateKey_FullMethodName       = "/transaction.proto.v1.KeysService/UpdateKey"
+	KeysService_ListKey_FullMethodName         = "/transaction.proto.v1.KeysService/ListKey"
+	KeysService_ListAccountKeys_FullMethodName = "/transaction.proto.v1.KeysService/ListAccountKeys"
+	KeysService_DeleteKey_FullMethodName       = "/transaction.proto.v1.KeysService/DeleteKey"
+	KeysService_FindKey_FullMethodName         = "/transaction.proto.v1.KeysService/FindKey"
 )
 
 // KeysServiceClient is the client API for KeysService service.
@@ -198,6 +310,7 @@ type KeysServiceClient interface {
 	CreateKey(ctx context.Context, in *Key, opts ...grpc.CallOption) (*KeyResponse, error)
 	UpdateKey(ctx context.Context, in *Key, opts ...grpc.CallOption) (*KeyResponse, error)
 	ListKey(ctx context.Context, in *ListKeyRequest, opts ...grpc.CallOption) (*ListKeys, error)
+	ListAccountKeys(ctx context.Context, in *AccountKeysRequest, opts ...grpc.CallOption) (*ListKeys, error)
 	DeleteKey(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	FindKey(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*KeyResponse, error)
 }
@@ -237,6 +350,15 @@ func (c *keysServiceClient) ListKey(ctx context.Context, in *ListKeyRequest, opt
 	return out, nil
 }
 
+func (c *keysServiceClient) ListAccountKeys(ctx context.Context, in *AccountKeysRequest, opts ...grpc.CallOption) (*ListKeys, error) {
+	out := new(ListKeys)
+	err := c.cc.Invoke(ctx, KeysService_ListAccountKeys_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *keysServiceClient) DeleteKey(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
 	err := c.cc.Invoke(ctx, KeysService_DeleteKey_FullMethodName, in, out, opts...)
@@ -262,6 +384,7 @@ type KeysServiceServer interface {
 	CreateKey(context.Context, *Key) (*KeyResponse, error)
 	UpdateKey(context.Context, *Key) (*KeyResponse, error)
 	ListKey(context.Context, *ListKeyRequest) (*ListKeys, error)
+	ListAccountKeys(context.Context, *AccountKeysRequest) (*ListKeys, error)
 	DeleteKey(context.Context, *KeyRequest) (*empty.Empty, error)
 	FindKey(context.Context, *KeyRequest) (*KeyResponse, error)
 	mustEmbedUnimplementedKeysServiceServer()
@@ -280,6 +403,9 @@ func (UnimplementedKeysServiceServer) UpdateKey(context.Context, *Key) (*KeyResp
 func (UnimplementedKeysServiceServer) ListKey(context.Context, *ListKeyRequest) (*ListKeys, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListKey not implemented")
 }
+func (UnimplementedKeysServiceServer) ListAccountKeys(context.Context, *AccountKeysRequest) (*ListKeys, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccountKeys not implemented")
+}
 func (UnimplementedKeysServiceServer) DeleteKey(context.Context, *KeyRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteKey not implemented")
 }
@@ -353,6 +479,24 @@ func _KeysService_ListKey_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeysService_ListAccountKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeysServiceServer).ListAccountKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeysService_ListAccountKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeysServiceServer).ListAccountKeys(ctx, req.(*AccountKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KeysService_DeleteKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(KeyRequest)
 	if err := dec(in); err != nil {
@@ -408,6 +552,10 @@ var KeysService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListKey",
 			Handler:    _KeysService_ListKey_Handler,
 		},
+		{
+			MethodName: "ListAccountKeys",
+			Handler:    _KeysService_ListAccountKeys_Handler,
+		},
 		{
 			MethodName: "DeleteKey",
 			Handler:    _KeysService_DeleteKey_Handler,
@@ -420,3 +568,1521 @@ var KeysService_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "transaction.proto",
 }
+
+const (
+	ScheduleService_SchedulePix_FullMethodName     = "/transaction.proto.v1.ScheduleService/SchedulePix"
+	ScheduleService_ListScheduled_FullMethodName   = "/transaction.proto.v1.ScheduleService/ListScheduled"
+	ScheduleService_CancelScheduled_FullMethodName = "/transaction.proto.v1.ScheduleService/CancelScheduled"
+)
+
+// ScheduleServiceClient is the client API for ScheduleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ScheduleServiceClient interface {
+	SchedulePix(ctx context.Context, in *ScheduledPix, opts ...grpc.CallOption) (*ScheduledPix, error)
+	ListScheduled(ctx context.Context, in *ListScheduledPixRequest, opts ...grpc.CallOption) (*ListScheduledPix, error)
+	CancelScheduled(ctx context.Context, in *CancelScheduledPixRequest, opts ...grpc.CallOption) (*ScheduledPix, error)
+}
+
+type scheduleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScheduleServiceClient(cc grpc.ClientConnInterface) ScheduleServiceClient {
+	return &scheduleServiceClient{cc}
+}
+
+func (c *scheduleServiceClient) SchedulePix(ctx context.Context, in *ScheduledPix, opts ...grpc.CallOption) (*ScheduledPix, error) {
+	out := new(ScheduledPix)
+	err := c.cc.Invoke(ctx, ScheduleService_SchedulePix_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scheduleServiceClient) ListScheduled(ctx context.Context, in *ListScheduledPixRequest, opts ...grpc.CallOption) (*ListScheduledPix, error) {
+	out := new(ListScheduledPix)
+	err := c.cc.Invoke(ctx, ScheduleService_ListScheduled_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scheduleServiceClient) CancelScheduled(ctx context.Context, in *CancelScheduledPixRequest, opts ...grpc.CallOption) (*ScheduledPix, error) {
+	out := new(ScheduledPix)
+	err := c.cc.Invoke(ctx, ScheduleService_CancelScheduled_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ScheduleServiceServer is the server API for ScheduleService service.
+// All implementations must embed UnimplementedScheduleServiceServer
+// for forward compatibility
+type ScheduleServiceServer interface {
+	SchedulePix(context.Context, *ScheduledPix) (*ScheduledPix, error)
+	ListScheduled(context.Context, *ListScheduledPixRequest) (*ListScheduledPix, error)
+	CancelScheduled(context.Context, *CancelScheduledPixRequest) (*ScheduledPix, error)
+	mustEmbedUnimplementedScheduleServiceServer()
+}
+
+// UnimplementedScheduleServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedScheduleServiceServer struct {
+}
+
+func (UnimplementedScheduleServiceServer) SchedulePix(context.Context, *ScheduledPix) (*ScheduledPix, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SchedulePix not implemented")
+}
+func (UnimplementedScheduleServiceServer) ListScheduled(context.Context, *ListScheduledPixRequest) (*ListScheduledPix, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListScheduled not implemented")
+}
+func (UnimplementedScheduleServiceServer) CancelScheduled(context.Context, *CancelScheduledPixRequest) (*ScheduledPix, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduled not implemented")
+}
+func (UnimplementedScheduleServiceServer) mustEmbedUnimplementedScheduleServiceServer() {}
+
+// UnsafeScheduleServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScheduleServiceServer will
+// result in compilation errors.
+type UnsafeScheduleServiceServer interface {
+	mustEmbedUnimplementedScheduleServiceServer()
+}
+
+func RegisterScheduleServiceServer(s grpc.ServiceRegistrar, srv ScheduleServiceServer) {
+	s.RegisterService(&ScheduleService_ServiceDesc, srv)
+}
+
+func _ScheduleService_SchedulePix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScheduledPix)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScheduleServiceServer).SchedulePix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScheduleService_SchedulePix_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScheduleServiceServer).SchedulePix(ctx, req.(*ScheduledPix))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScheduleService_ListScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListScheduledPixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScheduleServiceServer).ListScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScheduleService_ListScheduled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScheduleServiceServer).ListScheduled(ctx, req.(*ListScheduledPixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScheduleService_CancelScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelScheduledPixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScheduleServiceServer).CancelScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScheduleService_CancelScheduled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScheduleServiceServer).CancelScheduled(ctx, req.(*CancelScheduledPixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ScheduleService_ServiceDesc is the grpc.ServiceDesc for ScheduleService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ScheduleService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.proto.v1.ScheduleService",
+	HandlerType: (*ScheduleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SchedulePix",
+			Handler:    _ScheduleService_SchedulePix_Handler,
+		},
+		{
+			MethodName: "ListScheduled",
+			Handler:    _ScheduleService_ListScheduled_Handler,
+		},
+		{
+			MethodName: "CancelScheduled",
+			Handler:    _ScheduleService_CancelScheduled_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transaction.proto",
+}
+
+const (
+	BRCodeService_CreateBRCode_FullMethodName = "/transaction.proto.v1.BRCodeService/CreateBRCode"
+	BRCodeService_ParseBRCode_FullMethodName  = "/transaction.proto.v1.BRCodeService/ParseBRCode"
+)
+
+// BRCodeServiceClient is the client API for BRCodeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BRCodeServiceClient interface {
+	CreateBRCode(ctx context.Context, in *BRCode, opts ...grpc.CallOption) (*BRCode, error)
+	ParseBRCode(ctx context.Context, in *BRCodeRequest, opts ...grpc.CallOption) (*BRCode, error)
+}
+
+type bRCodeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBRCodeServiceClient(cc grpc.ClientConnInterface) BRCodeServiceClient {
+	return &bRCodeServiceClient{cc}
+}
+
+func (c *bRCodeServiceClient) CreateBRCode(ctx context.Context, in *BRCode, opts ...grpc.CallOption) (*BRCode, error) {
+	out := new(BRCode)
+	err := c.cc.Invoke(ctx, BRCodeService_CreateBRCode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bRCodeServiceClient) ParseBRCode(ctx context.Context, in *BRCodeRequest, opts ...grpc.CallOption) (*BRCode, error) {
+	out := new(BRCode)
+	err := c.cc.Invoke(ctx, BRCodeService_ParseBRCode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BRCodeServiceServer is the server API for BRCodeService service.
+// All implementations must embed UnimplementedBRCodeServiceServer
+// for forward compatibility
+type BRCodeServiceServer interface {
+	CreateBRCode(context.Context, *BRCode) (*BRCode, error)
+	ParseBRCode(context.Context, *BRCodeRequest) (*BRCode, error)
+	mustEmbedUnimplementedBRCodeServiceServer()
+}
+
+// UnimplementedBRCodeServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBRCodeServiceServer struct {
+}
+
+func (UnimplementedBRCodeServiceServer) CreateBRCode(context.Context, *BRCode) (*BRCode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBRCode not implemented")
+}
+func (UnimplementedBRCodeServiceServer) ParseBRCode(context.Context, *BRCodeRequest) (*BRCode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ParseBRCode not implemented")
+}
+func (UnimplementedBRCodeServiceServer) mustEmbedUnimplementedBRCodeServiceServer() {}
+
+// UnsafeBRCodeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BRCodeServiceServer will
+// result in compilation errors.
+type UnsafeBRCodeServiceServer interface {
+	mustEmbedUnimplementedBRCodeServiceServer()
+}
+
+func RegisterBRCodeServiceServer(s grpc.ServiceRegistrar, srv BRCodeServiceServer) {
+	s.RegisterService(&BRCodeService_ServiceDesc, srv)
+}
+
+func _BRCodeService_CreateBRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BRCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BRCodeServiceServer).CreateBRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BRCodeService_CreateBRCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BRCodeServiceServer).CreateBRCode(ctx, req.(*BRCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BRCodeService_ParseBRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BRCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BRCodeServiceServer).ParseBRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BRCodeService_ParseBRCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BRCodeServiceServer).ParseBRCode(ctx, req.(*BRCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BRCodeService_ServiceDesc is the grpc.ServiceDesc for BRCodeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BRCodeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.proto.v1.BRCodeService",
+	HandlerType: (*BRCodeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateBRCode",
+			Handler:    _BRCodeService_CreateBRCode_Handler,
+		},
+		{
+			MethodName: "ParseBRCode",
+			Handler:    _BRCodeService_ParseBRCode_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transaction.proto",
+}
+
+const (
+	ChargeService_CreateCharge_FullMethodName  = "/transaction.proto.v1.ChargeService/CreateCharge"
+	ChargeService_FindCharge_FullMethodName    = "/transaction.proto.v1.ChargeService/FindCharge"
+	ChargeService_ListCharge_FullMethodName    = "/transaction.proto.v1.ChargeService/ListCharge"
+	ChargeService_CancelCharge_FullMethodName  = "/transaction.proto.v1.ChargeService/CancelCharge"
+	ChargeService_ResolveCharge_FullMethodName = "/transaction.proto.v1.ChargeService/ResolveCharge"
+)
+
+// ChargeServiceClient is the client API for ChargeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ChargeServiceClient interface {
+	CreateCharge(ctx context.Context, in *Charge, opts ...grpc.CallOption) (*Charge, error)
+	FindCharge(ctx context.Context, in *ChargeRequest, opts ...grpc.CallOption) (*Charge, error)
+	ListCharge(ctx context.Context, in *ListChargesRequest, opts ...grpc.CallOption) (*ListCharges, error)
+	CancelCharge(ctx context.Context, in *ChargeRequest, opts ...grpc.CallOption) (*Charge, error)
+	ResolveCharge(ctx context.Context, in *ResolveChargeRequest, opts ...grpc.CallOption) (*Charge, error)
+}
+
+type chargeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChargeServiceClient(cc grpc.ClientConnInterface) ChargeServiceClient {
+	return &chargeServiceClient{cc}
+}
+
+func (c *chargeServiceClient) CreateCharge(ctx context.Context, in *Charge, opts ...grpc.CallOption) (*Charge, error) {
+	out := new(Charge)
+	err := c.cc.Invoke(ctx, ChargeService_CreateCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chargeServiceClient) FindCharge(ctx context.Context, in *ChargeRequest, opts ...grpc.CallOption) (*Charge, error) {
+	out := new(Charge)
+	err := c.cc.Invoke(ctx, ChargeService_FindCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chargeServiceClient) ListCharge(ctx context.Context, in *ListChargesRequest, opts ...grpc.CallOption) (*ListCharges, error) {
+	out := new(ListCharges)
+	err := c.cc.Invoke(ctx, ChargeService_ListCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chargeServiceClient) CancelCharge(ctx context.Context, in *ChargeRequest, opts ...grpc.CallOption) (*Charge, error) {
+	out := new(Charge)
+	err := c.cc.Invoke(ctx, ChargeService_CancelCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chargeServiceClient) ResolveCharge(ctx context.Context, in *ResolveChargeRequest, opts ...grpc.CallOption) (*Charge, error) {
+	out := new(Charge)
+	err := c.cc.Invoke(ctx, ChargeService_ResolveCharge_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChargeServiceServer is the server API for ChargeService service.
+// All implementations must embed UnimplementedChargeServiceServer
+// for forward compatibility
+type ChargeServiceServer interface {
+	CreateCharge(context.Context, *Charge) (*Charge, error)
+	FindCharge(context.Context, *ChargeRequest) (*Charge, error)
+	ListCharge(context.Context, *ListChargesRequest) (*ListCharges, error)
+	CancelCharge(context.Context, *ChargeRequest) (*Charge, error)
+	ResolveCharge(context.Context, *ResolveChargeRequest) (*Charge, error)
+	mustEmbedUnimplementedChargeServiceServer()
+}
+
+// UnimplementedChargeServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedChargeServiceServer struct {
+}
+
+func (UnimplementedChargeServiceServer) CreateCharge(context.Context, *Charge) (*Charge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCharge not implemented")
+}
+func (UnimplementedChargeServiceServer) FindCharge(context.Context, *ChargeRequest) (*Charge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindCharge not implemented")
+}
+func (UnimplementedChargeServiceServer) ListCharge(context.Context, *ListChargesRequest) (*ListCharges, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCharge not implemented")
+}
+func (UnimplementedChargeServiceServer) CancelCharge(context.Context, *ChargeRequest) (*Charge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelCharge not implemented")
+}
+func (UnimplementedChargeServiceServer) ResolveCharge(context.Context, *ResolveChargeRequest) (*Charge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveCharge not implemented")
+}
+func (UnimplementedChargeServiceServer) mustEmbedUnimplementedChargeServiceServer() {}
+
+// UnsafeChargeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChargeServiceServer will
+// result in compilation errors.
+type UnsafeChargeServiceServer interface {
+	mustEmbedUnimplementedChargeServiceServer()
+}
+
+func RegisterChargeServiceServer(s grpc.ServiceRegistrar, srv ChargeServiceServer) {
+	s.RegisterService(&ChargeService_ServiceDesc, srv)
+}
+
+func _ChargeService_CreateCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Charge)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChargeServiceServer).CreateCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChargeService_CreateCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChargeServiceServer).CreateCharge(ctx, req.(*Charge))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChargeService_FindCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChargeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChargeServiceServer).FindCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChargeService_FindCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChargeServiceServer).FindCharge(ctx, req.(*ChargeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChargeService_ListCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChargesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChargeServiceServer).ListCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChargeService_ListCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChargeServiceServer).ListCharge(ctx, req.(*ListChargesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChargeService_CancelCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChargeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChargeServiceServer).CancelCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChargeService_CancelCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChargeServiceServer).CancelCharge(ctx, req.(*ChargeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChargeService_ResolveCharge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveChargeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChargeServiceServer).ResolveCharge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChargeService_ResolveCharge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChargeServiceServer).ResolveCharge(ctx, req.(*ResolveChargeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ChargeService_ServiceDesc is the grpc.ServiceDesc for ChargeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChargeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.proto.v1.ChargeService",
+	HandlerType: (*ChargeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateCharge",
+			Handler:    _ChargeService_CreateCharge_Handler,
+		},
+		{
+			MethodName: "FindCharge",
+			Handler:    _ChargeService_FindCharge_Handler,
+		},
+		{
+			MethodName: "ListCharge",
+			Handler:    _ChargeService_ListCharge_Handler,
+		},
+		{
+			MethodName: "CancelCharge",
+			Handler:    _ChargeService_CancelCharge_Handler,
+		},
+		{
+			MethodName: "ResolveCharge",
+			Handler:    _ChargeService_ResolveCharge_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transaction.proto",
+}
+
+const (
+	MandateService_CreateMandate_FullMethodName    = "/transaction.proto.v1.MandateService/CreateMandate"
+	MandateService_FindMandate_FullMethodName      = "/transaction.proto.v1.MandateService/FindMandate"
+	MandateService_ListMandate_FullMethodName      = "/transaction.proto.v1.MandateService/ListMandate"
+	MandateService_UpdateMandate_FullMethodName    = "/transaction.proto.v1.MandateService/UpdateMandate"
+	MandateService_AuthorizeMandate_FullMethodName = "/transaction.proto.v1.MandateService/AuthorizeMandate"
+	MandateService_RevokeMandate_FullMethodName    = "/transaction.proto.v1.MandateService/RevokeMandate"
+)
+
+// MandateServiceClient is the client API for MandateService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MandateServiceClient interface {
+	CreateMandate(ctx context.Context, in *Mandate, opts ...grpc.CallOption) (*Mandate, error)
+	FindMandate(ctx context.Context, in *MandateRequest, opts ...grpc.CallOption) (*Mandate, error)
+	ListMandate(ctx context.Context, in *ListMandatesRequest, opts ...grpc.CallOption) (*ListMandates, error)
+	UpdateMandate(ctx context.Context, in *Mandate, opts ...grpc.CallOption) (*Mandate, error)
+	AuthorizeMandate(ctx context.Context, in *MandateRequest, opts ...grpc.CallOption) (*Mandate, error)
+	RevokeMandate(ctx context.Context, in *MandateRequest, opts ...grpc.CallOption) (*Mandate, error)
+}
+
+type mandateServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMandateServiceClient(cc grpc.ClientConnInterface) MandateServiceClient {
+	return &mandateServiceClient{cc}
+}
+
+func (c *mandateServiceClient) CreateMandate(ctx context.Context, in *Mandate, opts ...grpc.CallOption) (*Mandate, error) {
+	out := new(Mandate)
+	err := c.cc.Invoke(ctx, MandateService_CreateMandate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mandateServiceClient) FindMandate(ctx context.Context, in *MandateRequest, opts ...grpc.CallOption) (*Mandate, error) {
+	out := new(Mandate)
+	err := c.cc.Invoke(ctx, MandateService_FindMandate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mandateServiceClient) ListMandate(ctx context.Context, in *ListMandatesRequest, opts ...grpc.CallOption) (*ListMandates, error) {
+	out := new(ListMandates)
+	err := c.cc.Invoke(ctx, MandateService_ListMandate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mandateServiceClient) UpdateMandate(ctx context.Context, in *Mandate, opts ...grpc.CallOption) (*Mandate, error) {
+	out := new(Mandate)
+	err := c.cc.Invoke(ctx, MandateService_UpdateMandate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mandateServiceClient) AuthorizeMandate(ctx context.Context, in *MandateRequest, opts ...grpc.CallOption) (*Mandate, error) {
+	out := new(Mandate)
+	err := c.cc.Invoke(ctx, MandateService_AuthorizeMandate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mandateServiceClient) RevokeMandate(ctx context.Context, in *MandateRequest, opts ...grpc.CallOption) (*Mandate, error) {
+	out := new(Mandate)
+	err := c.cc.Invoke(ctx, MandateService_RevokeMandate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MandateServiceServer is the server API for MandateService service.
+// All implementations must embed UnimplementedMandateServiceServer
+// for forward compatibility
+type MandateServiceServer interface {
+	CreateMandate(context.Context, *Mandate) (*Mandate, error)
+	FindMandate(context.Context, *MandateRequest) (*Mandate, error)
+	ListMandate(context.Context, *ListMandatesRequest) (*ListMandates, error)
+	UpdateMandate(context.Context, *Mandate) (*Mandate, error)
+	AuthorizeMandate(context.Context, *MandateRequest) (*Mandate, error)
+	RevokeMandate(context.Context, *MandateRequest) (*Mandate, error)
+	mustEmbedUnimplementedMandateServiceServer()
+}
+
+// UnimplementedMandateServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMandateServiceServer struct {
+}
+
+func (UnimplementedMandateServiceServer) CreateMandate(context.Context, *Mandate) (*Mandate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateMandate not implemented")
+}
+func (UnimplementedMandateServiceServer) FindMandate(context.Context, *MandateRequest) (*Mandate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindMandate not implemented")
+}
+func (UnimplementedMandateServiceServer) ListMandate(context.Context, *ListMandatesRequest) (*ListMandates, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMandate not implemented")
+}
+func (UnimplementedMandateServiceServer) UpdateMandate(context.Context, *Mandate) (*Mandate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMandate not implemented")
+}
+func (UnimplementedMandateServiceServer) AuthorizeMandate(context.Context, *MandateRequest) (*Mandate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AuthorizeMandate not implemented")
+}
+func (UnimplementedMandateServiceServer) RevokeMandate(context.Context, *MandateRequest) (*Mandate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeMandate not implemented")
+}
+func (UnimplementedMandateServiceServer) mustEmbedUnimplementedMandateServiceServer() {}
+
+// UnsafeMandateServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MandateServiceServer will
+// result in compilation errors.
+type UnsafeMandateServiceServer interface {
+	mustEmbedUnimplementedMandateServiceServer()
+}
+
+func RegisterMandateServiceServer(s grpc.ServiceRegistrar, srv MandateServiceServer) {
+	s.RegisterService(&MandateService_ServiceDesc, srv)
+}
+
+func _MandateService_CreateMandate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Mandate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandateServiceServer).CreateMandate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MandateService_CreateMandate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandateServiceServer).CreateMandate(ctx, req.(*Mandate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MandateService_FindMandate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MandateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandateServiceServer).FindMandate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MandateService_FindMandate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandateServiceServer).FindMandate(ctx, req.(*MandateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MandateService_ListMandate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMandatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandateServiceServer).ListMandate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MandateService_ListMandate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandateServiceServer).ListMandate(ctx, req.(*ListMandatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MandateService_UpdateMandate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Mandate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandateServiceServer).UpdateMandate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MandateService_UpdateMandate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandateServiceServer).UpdateMandate(ctx, req.(*Mandate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MandateService_AuthorizeMandate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MandateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandateServiceServer).AuthorizeMandate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MandateService_AuthorizeMandate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandateServiceServer).AuthorizeMandate(ctx, req.(*MandateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MandateService_RevokeMandate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MandateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandateServiceServer).RevokeMandate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MandateService_RevokeMandate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandateServiceServer).RevokeMandate(ctx, req.(*MandateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MandateService_ServiceDesc is the grpc.ServiceDesc for MandateService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MandateService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.proto.v1.MandateService",
+	HandlerType: (*MandateServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateMandate",
+			Handler:    _MandateService_CreateMandate_Handler,
+		},
+		{
+			MethodName: "FindMandate",
+			Handler:    _MandateService_FindMandate_Handler,
+		},
+		{
+			MethodName: "ListMandate",
+			Handler:    _MandateService_ListMandate_Handler,
+		},
+		{
+			MethodName: "UpdateMandate",
+			Handler:    _MandateService_UpdateMandate_Handler,
+		},
+		{
+			MethodName: "AuthorizeMandate",
+			Handler:    _MandateService_AuthorizeMandate_Handler,
+		},
+		{
+			MethodName: "RevokeMandate",
+			Handler:    _MandateService_RevokeMandate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transaction.proto",
+}
+
+const (
+	DeadLetterService_ListDeadLetters_FullMethodName  = "/transaction.proto.v1.DeadLetterService/ListDeadLetters"
+	DeadLetterService_ReplayDeadLetter_FullMethodName = "/transaction.proto.v1.DeadLetterService/ReplayDeadLetter"
+)
+
+// DeadLetterServiceClient is the client API for DeadLetterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeadLetterServiceClient interface {
+	ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*DeadLetters, error)
+	ReplayDeadLetter(ctx context.Context, in *ReplayDeadLetterRequest, opts ...grpc.CallOption) (*DeadLetter, error)
+}
+
+type deadLetterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeadLetterServiceClient(cc grpc.ClientConnInterface) DeadLetterServiceClient {
+	return &deadLetterServiceClient{cc}
+}
+
+func (c *deadLetterServiceClient) ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*DeadLetters, error) {
+	out := new(DeadLetters)
+	err := c.cc.Invoke(ctx, DeadLetterService_ListDeadLetters_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deadLetterServiceClient) ReplayDeadLetter(ctx context.Context, in *ReplayDeadLetterRequest, opts ...grpc.CallOption) (*DeadLetter, error) {
+	out := new(DeadLetter)
+	err := c.cc.Invoke(ctx, DeadLetterService_ReplayDeadLetter_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeadLetterServiceServer is the server API for DeadLetterService service.
+// All implementations must embed UnimplementedDeadLetterServiceServer
+// for forward compatibility
+type DeadLetterServiceServer interface {
+	ListDeadLetters(context.Context, *ListDeadLettersRequest) (*DeadLetters, error)
+	ReplayDeadLetter(context.Context, *ReplayDeadLetterRequest) (*DeadLetter, error)
+	mustEmbedUnimplementedDeadLetterServiceServer()
+}
+
+// UnimplementedDeadLetterServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDeadLetterServiceServer struct {
+}
+
+func (UnimplementedDeadLetterServiceServer) ListDeadLetters(context.Context, *ListDeadLettersRequest) (*DeadLetters, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeadLetters not implemented")
+}
+func (UnimplementedDeadLetterServiceServer) ReplayDeadLetter(context.Context, *ReplayDeadLetterRequest) (*DeadLetter, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayDeadLetter not implemented")
+}
+func (UnimplementedDeadLetterServiceServer) mustEmbedUnimplementedDeadLetterServiceServer() {}
+
+// UnsafeDeadLetterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeadLetterServiceServer will
+// result in compilation errors.
+type UnsafeDeadLetterServiceServer interface {
+	mustEmbedUnimplementedDeadLetterServiceServer()
+}
+
+func RegisterDeadLetterServiceServer(s grpc.ServiceRegistrar, srv DeadLetterServiceServer) {
+	s.RegisterService(&DeadLetterService_ServiceDesc, srv)
+}
+
+func _DeadLetterService_ListDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeadLetterServiceServer).ListDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeadLetterService_ListDeadLetters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeadLetterServiceServer).ListDeadLetters(ctx, req.(*ListDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeadLetterService_ReplayDeadLetter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayDeadLetterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeadLetterServiceServer).ReplayDeadLetter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeadLetterService_ReplayDeadLetter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeadLetterServiceServer).ReplayDeadLetter(ctx, req.(*ReplayDeadLetterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DeadLetterService_ServiceDesc is the grpc.ServiceDesc for DeadLetterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DeadLetterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.proto.v1.DeadLetterService",
+	HandlerType: (*DeadLetterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDeadLetters",
+			Handler:    _DeadLetterService_ListDeadLetters_Handler,
+		},
+		{
+			MethodName: "ReplayDeadLetter",
+			Handler:    _DeadLetterService_ReplayDeadLetter_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transaction.proto",
+}
+
+const (
+	ClaimService_OpenClaim_FullMethodName    = "/transaction.proto.v1.ClaimService/OpenClaim"
+	ClaimService_FindClaim_FullMethodName    = "/transaction.proto.v1.ClaimService/FindClaim"
+	ClaimService_ListClaim_FullMethodName    = "/transaction.proto.v1.ClaimService/ListClaim"
+	ClaimService_ConfirmClaim_FullMethodName = "/transaction.proto.v1.ClaimService/ConfirmClaim"
+	ClaimService_CancelClaim_FullMethodName  = "/transaction.proto.v1.ClaimService/CancelClaim"
+)
+
+// ClaimServiceClient is the client API for ClaimService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ClaimServiceClient interface {
+	OpenClaim(ctx context.Context, in *Claim, opts ...grpc.CallOption) (*Claim, error)
+	FindClaim(ctx context.Context, in *ClaimRequest, opts ...grpc.CallOption) (*Claim, error)
+	ListClaim(ctx context.Context, in *ListClaimsRequest, opts ...grpc.CallOption) (*ListClaims, error)
+	ConfirmClaim(ctx context.Context, in *ClaimRequest, opts ...grpc.CallOption) (*Claim, error)
+	CancelClaim(ctx context.Context, in *ClaimRequest, opts ...grpc.CallOption) (*Claim, error)
+}
+
+type claimServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClaimServiceClient(cc grpc.ClientConnInterface) ClaimServiceClient {
+	return &claimServiceClient{cc}
+}
+
+func (c *claimServiceClient) OpenClaim(ctx context.Context, in *Claim, opts ...grpc.CallOption) (*Claim, error) {
+	out := new(Claim)
+	err := c.cc.Invoke(ctx, ClaimService_OpenClaim_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claimServiceClient) FindClaim(ctx context.Context, in *ClaimRequest, opts ...grpc.CallOption) (*Claim, error) {
+	out := new(Claim)
+	err := c.cc.Invoke(ctx, ClaimService_FindClaim_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claimServiceClient) ListClaim(ctx context.Context, in *ListClaimsRequest, opts ...grpc.CallOption) (*ListClaims, error) {
+	out := new(ListClaims)
+	err := c.cc.Invoke(ctx, ClaimService_ListClaim_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claimServiceClient) ConfirmClaim(ctx context.Context, in *ClaimRequest, opts ...grpc.CallOption) (*Claim, error) {
+	out := new(Claim)
+	err := c.cc.Invoke(ctx, ClaimService_ConfirmClaim_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claimServiceClient) CancelClaim(ctx context.Context, in *ClaimRequest, opts ...grpc.CallOption) (*Claim, error) {
+	out := new(Claim)
+	err := c.cc.Invoke(ctx, ClaimService_CancelClaim_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClaimServiceServer is the server API for ClaimService service.
+// All implementations must embed UnimplementedClaimServiceServer
+// for forward compatibility
+type ClaimServiceServer interface {
+	OpenClaim(context.Context, *Claim) (*Claim, error)
+	FindClaim(context.Context, *ClaimRequest) (*Claim, error)
+	ListClaim(context.Context, *ListClaimsRequest) (*ListClaims, error)
+	ConfirmClaim(context.Context, *ClaimRequest) (*Claim, error)
+	CancelClaim(context.Context, *ClaimRequest) (*Claim, error)
+	mustEmbedUnimplementedClaimServiceServer()
+}
+
+// UnimplementedClaimServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedClaimServiceServer struct {
+}
+
+func (UnimplementedClaimServiceServer) OpenClaim(context.Context, *Claim) (*Claim, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenClaim not implemented")
+}
+func (UnimplementedClaimServiceServer) FindClaim(context.Context, *ClaimRequest) (*Claim, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindClaim not implemented")
+}
+func (UnimplementedClaimServiceServer) ListClaim(context.Context, *ListClaimsRequest) (*ListClaims, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClaim not implemented")
+}
+func (UnimplementedClaimServiceServer) ConfirmClaim(context.Context, *ClaimRequest) (*Claim, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmClaim not implemented")
+}
+func (UnimplementedClaimServiceServer) CancelClaim(context.Context, *ClaimRequest) (*Claim, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelClaim not implemented")
+}
+func (UnimplementedClaimServiceServer) mustEmbedUnimplementedClaimServiceServer() {}
+
+// UnsafeClaimServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClaimServiceServer will
+// result in compilation errors.
+type UnsafeClaimServiceServer interface {
+	mustEmbedUnimplementedClaimServiceServer()
+}
+
+func RegisterClaimServiceServer(s grpc.ServiceRegistrar, srv ClaimServiceServer) {
+	s.RegisterService(&ClaimService_ServiceDesc, srv)
+}
+
+func _ClaimService_OpenClaim_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Claim)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaimServiceServer).OpenClaim(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaimService_OpenClaim_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaimServiceServer).OpenClaim(ctx, req.(*Claim))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaimService_FindClaim_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClaimRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaimServiceServer).FindClaim(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaimService_FindClaim_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaimServiceServer).FindClaim(ctx, req.(*ClaimRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaimService_ListClaim_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListClaimsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaimServiceServer).ListClaim(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaimService_ListClaim_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaimServiceServer).ListClaim(ctx, req.(*ListClaimsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaimService_ConfirmClaim_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClaimRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaimServiceServer).ConfirmClaim(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaimService_ConfirmClaim_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaimServiceServer).ConfirmClaim(ctx, req.(*ClaimRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaimService_CancelClaim_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClaimRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaimServiceServer).CancelClaim(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaimService_CancelClaim_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaimServiceServer).CancelClaim(ctx, req.(*ClaimRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ClaimService_ServiceDesc is the grpc.ServiceDesc for ClaimService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClaimService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.proto.v1.ClaimService",
+	HandlerType: (*ClaimServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "OpenClaim",
+			Handler:    _ClaimService_OpenClaim_Handler,
+		},
+		{
+			MethodName: "FindClaim",
+			Handler:    _ClaimService_FindClaim_Handler,
+		},
+		{
+			MethodName: "ListClaim",
+			Handler:    _ClaimService_ListClaim_Handler,
+		},
+		{
+			MethodName: "ConfirmClaim",
+			Handler:    _ClaimService_ConfirmClaim_Handler,
+		},
+		{
+			MethodName: "CancelClaim",
+			Handler:    _ClaimService_CancelClaim_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transaction.proto",
+}
+
+const (
+	WebhookService_ListDeliveries_FullMethodName           = "/transaction.proto.v1.WebhookService/ListDeliveries"
+	WebhookService_RedeliverWebhook_FullMethodName         = "/transaction.proto.v1.WebhookService/RedeliverWebhook"
+	WebhookService_CreateSubscription_FullMethodName       = "/transaction.proto.v1.WebhookService/CreateSubscription"
+	WebhookService_ListSubscriptions_FullMethodName        = "/transaction.proto.v1.WebhookService/ListSubscriptions"
+	WebhookService_SetSubscriptionEnabled_FullMethodName   = "/transaction.proto.v1.WebhookService/SetSubscriptionEnabled"
+	WebhookService_RotateSubscriptionSecret_FullMethodName = "/transaction.proto.v1.WebhookService/RotateSubscriptionSecret"
+	WebhookService_PingSubscription_FullMethodName         = "/transaction.proto.v1.WebhookService/PingSubscription"
+	WebhookService_DeleteSubscription_FullMethodName       = "/transaction.proto.v1.WebhookService/DeleteSubscription"
+)
+
+// WebhookServiceClient is the client API for WebhookService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WebhookServiceClient interface {
+	ListDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*WebhookDeliveries, error)
+	RedeliverWebhook(ctx context.Context, in *RedeliverWebhookRequest, opts ...grpc.CallOption) (*WebhookDelivery, error)
+	CreateSubscription(ctx context.Context, in *WebhookSubscription, opts ...grpc.CallOption) (*WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*WebhookSubscriptions, error)
+	SetSubscriptionEnabled(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error)
+	RotateSubscriptionSecret(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error)
+	PingSubscription(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*WebhookDelivery, error)
+	DeleteSubscription(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+}
+
+type webhookServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWebhookServiceClient(cc grpc.ClientConnInterface) WebhookServiceClient {
+	return &webhookServiceClient{cc}
+}
+
+func (c *webhookServiceClient) ListDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*WebhookDeliveries, error) {
+	out := new(WebhookDeliveries)
+	err := c.cc.Invoke(ctx, WebhookService_ListDeliveries_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) RedeliverWebhook(ctx context.Context, in *RedeliverWebhookRequest, opts ...grpc.CallOption) (*WebhookDelivery, error) {
+	out := new(WebhookDelivery)
+	err := c.cc.Invoke(ctx, WebhookService_RedeliverWebhook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) CreateSubscription(ctx context.Context, in *WebhookSubscription, opts ...grpc.CallOption) (*WebhookSubscription, error) {
+	out := new(WebhookSubscription)
+	err := c.cc.Invoke(ctx, WebhookService_CreateSubscription_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*WebhookSubscriptions, error) {
+	out := new(WebhookSubscriptions)
+	err := c.cc.Invoke(ctx, WebhookService_ListSubscriptions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) SetSubscriptionEnabled(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error) {
+	out := new(WebhookSubscription)
+	err := c.cc.Invoke(ctx, WebhookService_SetSubscriptionEnabled_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) RotateSubscriptionSecret(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*WebhookSubscription, error) {
+	out := new(WebhookSubscription)
+	err := c.cc.Invoke(ctx, WebhookService_RotateSubscriptionSecret_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) PingSubscription(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*WebhookDelivery, error) {
+	out := new(WebhookDelivery)
+	err := c.cc.Invoke(ctx, WebhookService_PingSubscription_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) DeleteSubscription(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, WebhookService_DeleteSubscription_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WebhookServiceServer is the server API for WebhookService service.
+// All implementations must embed UnimplementedWebhookServiceServer
+// for forward compatibility
+type WebhookServiceServer interface {
+	ListDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*WebhookDeliveries, error)
+	RedeliverWebhook(context.Context, *RedeliverWebhookRequest) (*WebhookDelivery, error)
+	CreateSubscription(context.Context, *WebhookSubscription) (*WebhookSubscription, error)
+	ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*WebhookSubscriptions, error)
+	SetSubscriptionEnabled(context.Context, *SubscriptionRequest) (*WebhookSubscription, error)
+	RotateSubscriptionSecret(context.Context, *SubscriptionRequest) (*WebhookSubscription, error)
+	PingSubscription(context.Context, *SubscriptionRequest) (*WebhookDelivery, error)
+	DeleteSubscription(context.Context, *SubscriptionRequest) (*empty.Empty, error)
+	mustEmbedUnimplementedWebhookServiceServer()
+}
+
+// UnimplementedWebhookServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedWebhookServiceServer struct {
+}
+
+func (UnimplementedWebhookServiceServer) ListDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*WebhookDeliveries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeliveries not implemented")
+}
+func (UnimplementedWebhookServiceServer) RedeliverWebhook(context.Context, *RedeliverWebhookRequest) (*WebhookDelivery, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedeliverWebhook not implemented")
+}
+func (UnimplementedWebhookServiceServer) CreateSubscription(context.Context, *WebhookSubscription) (*WebhookSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSubscription not implemented")
+}
+func (UnimplementedWebhookServiceServer) ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*WebhookSubscriptions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubscriptions not implemented")
+}
+func (UnimplementedWebhookServiceServer) SetSubscriptionEnabled(context.Context, *SubscriptionRequest) (*WebhookSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSubscriptionEnabled not implemented")
+}
+func (UnimplementedWebhookServiceServer) RotateSubscriptionSecret(context.Context, *SubscriptionRequest) (*WebhookSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateSubscriptionSecret not implemented")
+}
+func (UnimplementedWebhookServiceServer) PingSubscription(context.Context, *SubscriptionRequest) (*WebhookDelivery, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PingSubscription not implemented")
+}
+func (UnimplementedWebhookServiceServer) DeleteSubscription(context.Context, *SubscriptionRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSubscription not implemented")
+}
+func (UnimplementedWebhookServiceServer) mustEmbedUnimplementedWebhookServiceServer() {}
+
+// UnsafeWebhookServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WebhookServiceServer will
+// result in compilation errors.
+type UnsafeWebhookServiceServer interface {
+	mustEmbedUnimplementedWebhookServiceServer()
+}
+
+func RegisterWebhookServiceServer(s grpc.ServiceRegistrar, srv WebhookServiceServer) {
+	s.RegisterService(&WebhookService_ServiceDesc, srv)
+}
+
+func _WebhookService_ListDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).ListDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_ListDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).ListDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_RedeliverWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedeliverWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).RedeliverWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_RedeliverWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).RedeliverWebhook(ctx, req.(*RedeliverWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_CreateSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookSubscription)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).CreateSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_CreateSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).CreateSubscription(ctx, req.(*WebhookSubscription))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_ListSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).ListSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_ListSubscriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).ListSubscriptions(ctx, req.(*ListSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_SetSubscriptionEnabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).SetSubscriptionEnabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_SetSubscriptionEnabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).SetSubscriptionEnabled(ctx, req.(*SubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_RotateSubscriptionSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).RotateSubscriptionSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_RotateSubscriptionSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).RotateSubscriptionSecret(ctx, req.(*SubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_PingSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).PingSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_PingSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).PingSubscription(ctx, req.(*SubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_DeleteSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).DeleteSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_DeleteSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).DeleteSubscription(ctx, req.(*SubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WebhookService_ServiceDesc is the grpc.ServiceDesc for WebhookService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WebhookService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.proto.v1.WebhookService",
+	HandlerType: (*WebhookServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDeliveries",
+			Handler:    _WebhookService_ListDeliveries_Handler,
+		},
+		{
+			MethodName: "RedeliverWebhook",
+			Handler:    _WebhookService_RedeliverWebhook_Handler,
+		},
+		{
+			MethodName: "CreateSubscription",
+			Handler:    _WebhookService_CreateSubscription_Handler,
+		},
+		{
+			MethodName: "ListSubscriptions",
+			Handler:    _WebhookService_ListSubscriptions_Handler,
+		},
+		{
+			MethodName: "SetSubscriptionEnabled",
+			Handler:    _WebhookService_SetSubscriptionEnabled_Handler,
+		},
+		{
+			MethodName: "RotateSubscriptionSecret",
+			Handler:    _WebhookService_RotateSubscriptionSecret_Handler,
+		},
+		{
+			MethodName: "PingSubscription",
+			Handler:    _WebhookService_PingSubscription_Handler,
+		},
+		{
+			MethodName: "DeleteSubscription",
+			Handler:    _WebhookService_DeleteSubscription_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transaction.proto",
+}